@@ -0,0 +1,264 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Audit event types
+	AUDIT_EVENT_TYPE_CHANGE   = "change"
+	AUDIT_EVENT_TYPE_DECISION = "decision"
+	AUDIT_EVENT_TYPE_ALERT    = "alert"
+
+	// Audit decision results
+	AUDIT_DECISION_ALLOW = "allow"
+	AUDIT_DECISION_DENY  = "deny"
+
+	// Decision audit events waiting to be stored. Authorization requests wait when the queue is full
+	AUDIT_DECISION_QUEUE_SIZE = 10000
+	// Max decision audit events stored in the same transaction
+	AUDIT_DECISION_BATCH_SIZE = 500
+)
+
+// TYPE DEFINITIONS
+
+// Audit event of a mutating operation or an authorization decision
+type AuditEvent struct {
+	ID                  string      `json:"id,omitempty"`
+	Type                string      `json:"type,omitempty"`
+	Actor               string      `json:"actor,omitempty"`
+	BreakGlassSessionID string      `json:"breakGlassSessionId,omitempty"`
+	RequestID           string      `json:"requestId,omitempty"`
+	Action              string      `json:"action,omitempty"`
+	EntityUrn           string      `json:"entityUrn,omitempty"`
+	Before              interface{} `json:"before,omitempty"`
+	After               interface{} `json:"after,omitempty"`
+	Urn                 string      `json:"urn,omitempty"`
+	CreateAt            time.Time   `json:"createAt,omitempty"`
+}
+
+func (e AuditEvent) String() string {
+	return fmt.Sprintf("[id: %v, type: %v, actor: %v, breakGlassSessionId: %v, requestId: %v, action: %v, entityUrn: %v, urn: %v, createAt: %v]",
+		e.ID, e.Type, e.Actor, e.BreakGlassSessionID, e.RequestID, e.Action, e.EntityUrn, e.Urn,
+		e.CreateAt.Format("2006-01-02 15:04:05 MST"))
+}
+
+func (e AuditEvent) GetUrn() string {
+	return e.Urn
+}
+
+// Queue of decision audit events, stored in batches out of the authorization requests
+type AuditDecisionQueue struct {
+	repo   AuditRepo
+	events chan AuditEvent
+	done   chan struct{}
+
+	lock   sync.RWMutex
+	closed bool
+}
+
+// NewAuditDecisionQueue returns a queue that stores its events with the repo until it is closed
+func NewAuditDecisionQueue(repo AuditRepo) *AuditDecisionQueue {
+	queue := &AuditDecisionQueue{
+		repo:   repo,
+		events: make(chan AuditEvent, AUDIT_DECISION_QUEUE_SIZE),
+		done:   make(chan struct{}),
+	}
+	go queue.run()
+	return queue
+}
+
+// Add queues the events. Events added after the queue is closed are discarded
+func (q *AuditDecisionQueue) Add(events []AuditEvent) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.closed {
+		Log.Errorf("Audit decision queue is closed, %v decision audit events discarded", len(events))
+		return
+	}
+	for _, event := range events {
+		q.events <- event
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are stored
+func (q *AuditDecisionQueue) Close() {
+	q.lock.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.lock.Unlock()
+	<-q.done
+}
+
+// run stores the queued events, taking every event available up to the batch size
+func (q *AuditDecisionQueue) run() {
+	defer close(q.done)
+	for event := range q.events {
+		batch := []AuditEvent{event}
+	fill:
+		for len(batch) < AUDIT_DECISION_BATCH_SIZE {
+			select {
+			case event, ok := <-q.events:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, event)
+			default:
+				break fill
+			}
+		}
+		if err := q.repo.AddAuditEvents(batch); err != nil {
+			//Transform to DB error
+			dbError := err.(*database.Error)
+			Log.Errorf("Unable to store %v decision audit events: %v", len(batch), dbError.Message)
+		}
+	}
+}
+
+// AUDIT API IMPLEMENTATION
+
+func (api WorkerAPI) ListAuditEvents(requestInfo RequestInfo, filter *Filter) ([]AuditEvent, int, error) {
+	api, span := api.startSpan(requestInfo, "ListAuditEvents")
+	defer span.Finish()
+
+	// Validate fields
+	var total int
+	orderByValidColumns := api.AuditRepo.OrderByValidColumns(AUDIT_ACTION_LIST_EVENTS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, total, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: to %v is before from %v", filter.To.Format(time.RFC3339), filter.From.Format(time.RFC3339)),
+		}
+	}
+
+	// Call repo to retrieve the events
+	events, total, err := api.AuditRepo.GetAuditEventsFiltered(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions to list
+	urnPrefix := GetUrnPrefix("", RESOURCE_AUDIT_EVENT, "/")
+	eventsFiltered, err := api.GetAuthorizedAuditEvents(requestInfo, urnPrefix, AUDIT_ACTION_LIST_EVENTS, events)
+	if err != nil {
+		return nil, total, err
+	}
+
+	return eventsFiltered, total, nil
+}
+
+func (api WorkerAPI) PurgeAuditEvents() error {
+	// Events are kept forever without retention
+	if api.AuditRetention <= 0 {
+		return nil
+	}
+
+	if err := api.AuditRepo.RemoveAuditEvents(time.Now().UTC().Add(-api.AuditRetention)); err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Return a copy of the API whose repositories store the audit events, with the pending ones, and the deliveries
+// of the webhooks subscribed to them in the transaction of their next change, so they are kept or lost with it.
+// The copy must only be used for that change
+func (api WorkerAPI) withAuditEvents(events ...AuditEvent) (WorkerAPI, error) {
+	events = append(append([]AuditEvent{}, api.pendingAuditEvents...), events...)
+	api.pendingAuditEvents = nil
+
+	deliveries, err := api.createWebhookDeliveries(events)
+	if err != nil {
+		return api, err
+	}
+
+	if repo, ok := api.UserRepo.(EventRepo); ok {
+		api.UserRepo = repo.WithEvents(events, deliveries).(UserRepo)
+	}
+	if repo, ok := api.GroupRepo.(EventRepo); ok {
+		api.GroupRepo = repo.WithEvents(events, deliveries).(GroupRepo)
+	}
+	if repo, ok := api.PolicyRepo.(EventRepo); ok {
+		api.PolicyRepo = repo.WithEvents(events, deliveries).(PolicyRepo)
+	}
+	if repo, ok := api.ProxyRepo.(EventRepo); ok {
+		api.ProxyRepo = repo.WithEvents(events, deliveries).(ProxyRepo)
+	}
+	if repo, ok := api.AuthOidcRepo.(EventRepo); ok {
+		api.AuthOidcRepo = repo.WithEvents(events, deliveries).(AuthOidcRepo)
+	}
+	if repo, ok := api.PolicyTemplateRepo.(EventRepo); ok {
+		api.PolicyTemplateRepo = repo.WithEvents(events, deliveries).(PolicyTemplateRepo)
+	}
+	if repo, ok := api.BreakGlassRepo.(EventRepo); ok {
+		api.BreakGlassRepo = repo.WithEvents(events, deliveries).(BreakGlassRepo)
+	}
+	if repo, ok := api.ChangeRequestRepo.(EventRepo); ok {
+		api.ChangeRequestRepo = repo.WithEvents(events, deliveries).(ChangeRequestRepo)
+	}
+	if repo, ok := api.WebhookRepo.(EventRepo); ok {
+		api.WebhookRepo = repo.WithEvents(events, deliveries).(WebhookRepo)
+	}
+
+	return api, nil
+}
+
+// Queue a decision audit event for each requested resource, with the result as after snapshot
+func (api WorkerAPI) addDecisionAuditEvents(requestInfo RequestInfo, action string, resources []string, allowedUrns []string) {
+	allowed := make(map[string]bool, len(allowedUrns))
+	for _, urn := range allowedUrns {
+		allowed[urn] = true
+	}
+	events := make([]AuditEvent, 0, len(resources))
+	for _, urn := range resources {
+		decision := AUDIT_DECISION_DENY
+		if allowed[urn] {
+			decision = AUDIT_DECISION_ALLOW
+		}
+		events = append(events, createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_DECISION, action, urn, nil, decision))
+	}
+	api.AuditDecisionQueue.Add(events)
+}
+
+func createAuditEvent(requestInfo RequestInfo, eventType string, action string, entityUrn string, before interface{}, after interface{}) AuditEvent {
+	id := uuid.NewV4().String()
+	urn := CreateUrn("", RESOURCE_AUDIT_EVENT, "/", id)
+
+	return AuditEvent{
+		ID:                  id,
+		Type:                eventType,
+		Actor:               requestInfo.Identifier,
+		BreakGlassSessionID: requestInfo.BreakGlassSessionID,
+		RequestID:           requestInfo.RequestID,
+		Action:              action,
+		EntityUrn:           entityUrn,
+		Before:              before,
+		After:               after,
+		Urn:                 urn,
+		CreateAt:            time.Now().UTC(),
+	}
+}
@@ -0,0 +1,421 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerAPI_ListAuditEvents(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		filter      *Filter
+		// Expected result
+		expectedEvents []AuditEvent
+		totalResult    int
+		wantError      error
+		// Manager Results
+		getGroupsByUserIDResult      []TestUserGroupRelation
+		getAttachedPoliciesResult    []TestPolicyGroupRelation
+		getUserByExternalIDResult    *User
+		getAuditEventsFilteredResult []AuditEvent
+		// Manager Errors
+		getUserByExternalIDErr    error
+		getAuditEventsFilteredErr error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Actor:     "123456",
+				EntityUrn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+				Action:    USER_ACTION_UPDATE_USER,
+				From:      now.Add(-time.Hour),
+				To:        now,
+			},
+			expectedEvents: []AuditEvent{
+				{
+					ID:    "event1",
+					Type:  AUDIT_EVENT_TYPE_CHANGE,
+					Actor: "123456",
+					Urn:   CreateUrn("", RESOURCE_AUDIT_EVENT, "/", "event1"),
+				},
+			},
+			totalResult: 1,
+			getAuditEventsFilteredResult: []AuditEvent{
+				{
+					ID:    "event1",
+					Type:  AUDIT_EVENT_TYPE_CHANGE,
+					Actor: "123456",
+					Urn:   CreateUrn("", RESOURCE_AUDIT_EVENT, "/", "event1"),
+				},
+			},
+		},
+		"OkCaseUser": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{},
+			expectedEvents: []AuditEvent{
+				{
+					ID:  "eventAllowed",
+					Urn: CreateUrn("", RESOURCE_AUDIT_EVENT, "/", "eventAllowed"),
+				},
+			},
+			totalResult: 2,
+			getAuditEventsFilteredResult: []AuditEvent{
+				{
+					ID:  "eventAllowed",
+					Urn: CreateUrn("", RESOURCE_AUDIT_EVENT, "/", "eventAllowed"),
+				},
+				{
+					ID:  "eventDenied",
+					Urn: CreateUrn("", RESOURCE_AUDIT_EVENT, "/", "eventDenied"),
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GROUP-USER-ID",
+						Name: "groupUser",
+						Path: "/path/1/",
+						Urn:  CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "example",
+						Path: "/path/",
+						Urn:  CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									AUDIT_ACTION_LIST_EVENTS,
+								},
+								Resources: []string{
+									GetUrnPrefix("", RESOURCE_AUDIT_EVENT, "/"),
+								},
+							},
+							{
+								Effect: "deny",
+								Actions: []string{
+									AUDIT_ACTION_LIST_EVENTS,
+								},
+								Resources: []string{
+									CreateUrn("", RESOURCE_AUDIT_EVENT, "/", "eventDenied"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseInvalidOrderBy": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				OrderBy: "invalid",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: OrderBy invalid",
+			},
+		},
+		"ErrorCaseInvalidTimeRange": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				From: now,
+				To:   now.Add(-time.Hour),
+			},
+			wantError: &Error{
+				Code: INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: to " + now.Add(-time.Hour).Format(time.RFC3339) +
+					" is before from " + now.Format(time.RFC3339),
+			},
+		},
+		"ErrorCaseInternalErrorAuditEventsFiltered": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{},
+			getAuditEventsFilteredErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{},
+			getAuditEventsFilteredResult: []AuditEvent{
+				{
+					ID:  "eventDenied",
+					Urn: CreateUrn("", RESOURCE_AUDIT_EVENT, "/", "eventDenied"),
+				},
+			},
+			getUserByExternalIDErr: &database.Error{
+				Code: database.USER_NOT_FOUND,
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Authenticated user with externalId 123456 not found. Unable to retrieve permissions.",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.AuditRetention = 24 * time.Hour
+
+		testRepo.ArgsOut[OrderByValidColumnsMethod][0] = []string{"create_at"}
+		testRepo.ArgsOut[GetAuditEventsFilteredMethod][0] = testcase.getAuditEventsFilteredResult
+		testRepo.ArgsOut[GetAuditEventsFilteredMethod][1] = testcase.totalResult
+		testRepo.ArgsOut[GetAuditEventsFilteredMethod][2] = testcase.getAuditEventsFilteredErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = testcase.getUserByExternalIDErr
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		events, total, err := testAPI.ListAuditEvents(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedEvents, events)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+		}
+		// Listing events never removes them
+		assert.Nil(t, testRepo.ArgsIn[RemoveAuditEventsMethod][0], "Error in test case %v", x)
+	}
+}
+
+func TestWorkerAPI_PurgeAuditEvents(t *testing.T) {
+	testcases := map[string]struct {
+		retention time.Duration
+		// Expected result
+		expectedPurge bool
+		wantError     error
+		// Manager Errors
+		removeAuditEventsErr error
+	}{
+		"OkCase": {
+			retention:     24 * time.Hour,
+			expectedPurge: true,
+		},
+		"OkCaseWithoutRetention": {},
+		"ErrorCaseInternalErrorRemoveAuditEvents": {
+			retention:     time.Hour,
+			expectedPurge: true,
+			removeAuditEventsErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.AuditRetention = testcase.retention
+
+		testRepo.ArgsOut[RemoveAuditEventsMethod][0] = testcase.removeAuditEventsErr
+		err := testAPI.PurgeAuditEvents()
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.expectedPurge {
+			before := testRepo.ArgsIn[RemoveAuditEventsMethod][0].(time.Time)
+			assert.WithinDuration(t, time.Now().UTC().Add(-testcase.retention), before, time.Minute, "Error in test case %v", x)
+		} else {
+			assert.Nil(t, testRepo.ArgsIn[RemoveAuditEventsMethod][0], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_withAuditEvents(t *testing.T) {
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo   RequestInfo
+		action        string
+		entityUrn     string
+		before        interface{}
+		after         interface{}
+		pendingEvents []AuditEvent
+		// Expected result
+		wantError error
+		// Manager Results
+		getWebhooksResult []Webhook
+		// Manager Errors
+		getWebhooksErr error
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier:          "123456",
+				RequestID:           "request1",
+				BreakGlassSessionID: "session1",
+			},
+			action:    USER_ACTION_UPDATE_USER,
+			entityUrn: CreateUrn("", RESOURCE_USER, "/path2/", "user1"),
+			before: &User{
+				ExternalID: "user1",
+				Path:       "/path/",
+			},
+			after: &User{
+				ExternalID: "user1",
+				Path:       "/path2/",
+			},
+			getWebhooksResult: []Webhook{
+				{
+					ID:     "webhook1",
+					Events: []string{USER_ACTION_UPDATE_USER},
+				},
+			},
+		},
+		"OkCasePendingEvents": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				RequestID:  "request1",
+			},
+			action:    POLICY_ACTION_UPDATE_POLICY,
+			entityUrn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policy1"),
+			pendingEvents: []AuditEvent{
+				{
+					ID:     "event1",
+					Type:   AUDIT_EVENT_TYPE_CHANGE,
+					Action: CHANGE_REQUEST_ACTION_APPROVE_CHANGE_REQUEST,
+				},
+			},
+		},
+		"ErrorCaseGetWebhooksDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				RequestID:  "request1",
+			},
+			action:    USER_ACTION_DELETE_USER,
+			entityUrn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			getWebhooksErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.pendingAuditEvents = testcase.pendingEvents
+
+		testRepo.ArgsOut[GetWebhooksMethod][0] = testcase.getWebhooksResult
+		testRepo.ArgsOut[GetWebhooksMethod][1] = testcase.getWebhooksErr
+		changeAPI, err := testAPI.withAuditEvents(createAuditEvent(testcase.requestInfo, AUDIT_EVENT_TYPE_CHANGE, testcase.action,
+			testcase.entityUrn, testcase.before, testcase.after))
+		if testcase.wantError != nil {
+			apiError, _ := err.(*Error)
+			assert.Equal(t, testcase.wantError, apiError, "Error in test case %v", x)
+			assert.Nil(t, testRepo.ArgsIn[WithEventsMethod][0], "Error in test case %v", x)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", x)
+		assert.Nil(t, changeAPI.pendingAuditEvents, "Error in test case %v", x)
+
+		// Pending events are stored with the change ones
+		events := testRepo.ArgsIn[WithEventsMethod][0].([]AuditEvent)
+		assert.Equal(t, len(testcase.pendingEvents)+1, len(events), "Error in test case %v", x)
+		for i, pendingEvent := range testcase.pendingEvents {
+			assert.Equal(t, pendingEvent, events[i], "Error in test case %v", x)
+		}
+		event := events[len(events)-1]
+		assert.Equal(t, AUDIT_EVENT_TYPE_CHANGE, event.Type, "Error in test case %v", x)
+		assert.Equal(t, testcase.requestInfo.Identifier, event.Actor, "Error in test case %v", x)
+		assert.Equal(t, testcase.requestInfo.RequestID, event.RequestID, "Error in test case %v", x)
+		assert.Equal(t, testcase.requestInfo.BreakGlassSessionID, event.BreakGlassSessionID, "Error in test case %v", x)
+		assert.Equal(t, testcase.action, event.Action, "Error in test case %v", x)
+		assert.Equal(t, testcase.entityUrn, event.EntityUrn, "Error in test case %v", x)
+		assert.Equal(t, testcase.before, event.Before, "Error in test case %v", x)
+		assert.Equal(t, testcase.after, event.After, "Error in test case %v", x)
+		assert.Equal(t, CreateUrn("", RESOURCE_AUDIT_EVENT, "/", event.ID), event.Urn, "Error in test case %v", x)
+
+		deliveries := testRepo.ArgsIn[WithEventsMethod][1].([]WebhookDelivery)
+		assert.Equal(t, len(testcase.getWebhooksResult), len(deliveries), "Error in test case %v", x)
+	}
+}
+
+func TestWorkerAPI_addDecisionAuditEvents(t *testing.T) {
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+	testAPI.AuditDecisionQueue = NewAuditDecisionQueue(testRepo)
+
+	requestInfo := RequestInfo{
+		Identifier: "123456",
+		Admin:      true,
+	}
+	_, err := testAPI.GetAuthorizedExternalResources(requestInfo, "example:get", []string{"urn:ews:example:instance1:resource/res1"})
+	assert.Nil(t, err)
+	// Decisions are stored out of the request, in batches
+	assert.Nil(t, testRepo.ArgsIn[AddAuditEventsMethod][0])
+	testAPI.AuditDecisionQueue.Close()
+
+	events := testRepo.ArgsIn[AddAuditEventsMethod][0].([]AuditEvent)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, AUDIT_EVENT_TYPE_DECISION, events[0].Type)
+	assert.Equal(t, "example:get", events[0].Action)
+	assert.Equal(t, "urn:ews:example:instance1:resource/res1", events[0].EntityUrn)
+	assert.Equal(t, AUDIT_DECISION_ALLOW, events[0].After)
+}
+
+func TestAuditDecisionQueue(t *testing.T) {
+	testRepo := makeTestRepo()
+	queue := NewAuditDecisionQueue(testRepo)
+
+	events := []AuditEvent{}
+	for i := 0; i < AUDIT_DECISION_BATCH_SIZE+1; i++ {
+		events = append(events, createAuditEvent(RequestInfo{Identifier: "123456"}, AUDIT_EVENT_TYPE_DECISION, "example:get",
+			"urn:ews:example:instance1:resource/res1", nil, AUDIT_DECISION_DENY))
+	}
+	testRepo.ArgsOut[AddAuditEventsMethod][0] = &database.Error{
+		Code:    database.INTERNAL_ERROR,
+		Message: "Error",
+	}
+	queue.Add(events)
+	queue.Close()
+
+	// Every event is stored before closing, the last batch has the remaining event
+	stored := testRepo.ArgsIn[AddAuditEventsMethod][0].([]AuditEvent)
+	assert.Equal(t, events[len(events)-len(stored):], stored)
+
+	// Events added after closing are discarded
+	testRepo.ArgsIn[AddAuditEventsMethod][0] = nil
+	queue.Add(events)
+	assert.Nil(t, testRepo.ArgsIn[AddAuditEventsMethod][0])
+}
@@ -44,6 +44,9 @@ func (op OidcProvider) GetUrn() string {
 // AUTHENTICATOR OIDC API IMPLEMENTATION
 
 func (api WorkerAPI) AddOidcProvider(requestInfo RequestInfo, name string, path string, issuerURL string, oidcClients []string) (*OidcProvider, error) {
+	api, span := api.startSpan(requestInfo, "AddOidcProvider")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -99,8 +102,18 @@ func (api WorkerAPI) AddOidcProvider(requestInfo RequestInfo, name string, path
 		switch dbError.Code {
 		// OIDC provider doesn't exist in DB
 		case database.AUTH_OIDC_PROVIDER_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &oidcProvider, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, AUTH_OIDC_ACTION_CREATE_PROVIDER, oidcProvider.Urn, nil, &oidcProvider))
+			if err != nil {
+				return nil, err
+			}
+
 			// Create OIDC provider
-			createdOidcProvider, err := api.AuthOidcRepo.AddOidcProvider(oidcProvider)
+			createdOidcProvider, err := changeAPI.AuthOidcRepo.AddOidcProvider(oidcProvider)
 
 			// Check if there is an unexpected error in DB
 			if err != nil {
@@ -129,6 +142,9 @@ func (api WorkerAPI) AddOidcProvider(requestInfo RequestInfo, name string, path
 }
 
 func (api WorkerAPI) GetOidcProviderByName(requestInfo RequestInfo, name string) (*OidcProvider, error) {
+	api, span := api.startSpan(requestInfo, "GetOidcProviderByName")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -175,6 +191,9 @@ func (api WorkerAPI) GetOidcProviderByName(requestInfo RequestInfo, name string)
 }
 
 func (api WorkerAPI) ListOidcProviders(requestInfo RequestInfo, filter *Filter) ([]string, int, error) {
+	api, span := api.startSpan(requestInfo, "ListOidcProviders")
+	defer span.Finish()
+
 	// Validate fields
 	var total int
 	orderByValidColumns := api.AuthOidcRepo.OrderByValidColumns(AUTH_OIDC_ACTION_LIST_PROVIDERS)
@@ -218,6 +237,9 @@ func (api WorkerAPI) ListOidcProviders(requestInfo RequestInfo, filter *Filter)
 
 func (api WorkerAPI) UpdateOidcProvider(requestInfo RequestInfo, oidcProviderName string, newName string, newPath string, newIssuerUrl string,
 	newClients []string) (*OidcProvider, error) {
+	api, span := api.startSpan(requestInfo, "UpdateOidcProvider")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(newName) {
 		return nil, &Error{
@@ -320,8 +342,18 @@ func (api WorkerAPI) UpdateOidcProvider(requestInfo RequestInfo, oidcProviderNam
 		OidcClients: oidcClients,
 	}
 
+	// Check dry run
+	if requestInfo.DryRun {
+		return &oidcProvider, nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, AUTH_OIDC_ACTION_UPDATE_PROVIDER, oidcProvider.Urn, oldOidcProvider, &oidcProvider))
+	if err != nil {
+		return nil, err
+	}
+
 	// Update OIDC Provider
-	updatedOidcProvider, err := api.AuthOidcRepo.UpdateOidcProvider(oidcProvider)
+	updatedOidcProvider, err := changeAPI.AuthOidcRepo.UpdateOidcProvider(oidcProvider)
 
 	// Check unexpected DB error
 	if err != nil {
@@ -339,6 +371,9 @@ func (api WorkerAPI) UpdateOidcProvider(requestInfo RequestInfo, oidcProviderNam
 }
 
 func (api WorkerAPI) RemoveOidcProvider(requestInfo RequestInfo, name string) error {
+	api, span := api.startSpan(requestInfo, "RemoveOidcProvider")
+	defer span.Finish()
+
 	// Call repo to retrieve the OIDC provider
 	oidcProvider, err := api.GetOidcProviderByName(requestInfo, name)
 	if err != nil {
@@ -358,7 +393,17 @@ func (api WorkerAPI) RemoveOidcProvider(requestInfo RequestInfo, name string) er
 		}
 	}
 
-	err = api.AuthOidcRepo.RemoveOidcProvider(oidcProvider.ID)
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, AUTH_OIDC_ACTION_DELETE_PROVIDER, oidcProvider.Urn, oidcProvider, nil))
+	if err != nil {
+		return err
+	}
+
+	err = changeAPI.AuthOidcRepo.RemoveOidcProvider(oidcProvider.ID)
 
 	// Error handling
 	if err != nil {
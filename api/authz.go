@@ -6,6 +6,8 @@ import (
 	"sync"
 
 	"github.com/Tecsisa/foulkon/database"
+	"github.com/Tecsisa/foulkon/metrics"
+	"github.com/Tecsisa/foulkon/tracing"
 )
 
 // TYPE DEFINITIONS
@@ -14,6 +16,16 @@ type RequestInfo struct {
 	Identifier string
 	Admin      bool
 	RequestID  string
+	// DryRun requests run all validation, authorization and conflict checks but don't write anything
+	DryRun bool
+	// BreakGlass is set when the user authenticated with a pre-registered break-glass account
+	BreakGlass bool
+	// BreakGlassSessionID is the emergency admin session the request was made under, if any
+	BreakGlassSessionID string
+	// approvedChangeRequest is set when the request replays an approved change request
+	approvedChangeRequest string
+	// Span is the trace span of the request, if it is traced
+	Span *tracing.Span
 }
 
 type EffectRestriction struct {
@@ -28,6 +40,20 @@ type Restrictions struct {
 	DeniedFullUrns     []string `json:"deniedFullUrns,omitempty"`
 }
 
+// Restrictions for an action over an urn prefix, with the predicate that applies them if it was requested
+type AuthorizedRestrictions struct {
+	Action       string        `json:"action,omitempty"`
+	UrnPrefix    string        `json:"urnPrefix,omitempty"`
+	Restrictions *Restrictions `json:"restrictions,omitempty"`
+	Predicate    *Predicate    `json:"predicate,omitempty"`
+}
+
+// SQL-like predicate over an urn column. Args are bound in order to the ? placeholders of Where
+type Predicate struct {
+	Where string   `json:"where,omitempty"`
+	Args  []string `json:"args,omitempty"`
+}
+
 type ExternalResource struct {
 	Urn string `json:"urn,omitempty"`
 }
@@ -40,6 +66,9 @@ func (e ExternalResource) GetUrn() string {
 
 // GetAuthorizedUsers returns authorized users for specified resource+action
 func (api WorkerAPI) GetAuthorizedUsers(requestInfo RequestInfo, resourceUrn string, action string, users []User) ([]User, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedUsers")
+	defer span.Finish()
+
 	resourcesToAuthorize := []Resource{}
 	for _, usr := range users {
 		resourcesToAuthorize = append(resourcesToAuthorize, usr)
@@ -57,6 +86,9 @@ func (api WorkerAPI) GetAuthorizedUsers(requestInfo RequestInfo, resourceUrn str
 
 // GetAuthorizedGroups returns authorized users for specified user combined with resource+action
 func (api WorkerAPI) GetAuthorizedGroups(requestInfo RequestInfo, resourceUrn string, action string, groups []Group) ([]Group, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedGroups")
+	defer span.Finish()
+
 	resourcesToAuthorize := []Resource{}
 	for _, group := range groups {
 		resourcesToAuthorize = append(resourcesToAuthorize, group)
@@ -74,6 +106,9 @@ func (api WorkerAPI) GetAuthorizedGroups(requestInfo RequestInfo, resourceUrn st
 
 // GetAuthorizedPolicies returns authorized policies for specified user combined with resource+action
 func (api WorkerAPI) GetAuthorizedPolicies(requestInfo RequestInfo, resourceUrn string, action string, policies []Policy) ([]Policy, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedPolicies")
+	defer span.Finish()
+
 	resourcesToAuthorize := []Resource{}
 	for _, policy := range policies {
 		resourcesToAuthorize = append(resourcesToAuthorize, policy)
@@ -89,8 +124,31 @@ func (api WorkerAPI) GetAuthorizedPolicies(requestInfo RequestInfo, resourceUrn
 	return policiesFiltered, nil
 }
 
+// GetAuthorizedPolicyTemplates returns authorized policy templates for specified user combined with resource+action
+func (api WorkerAPI) GetAuthorizedPolicyTemplates(requestInfo RequestInfo, resourceUrn string, action string, policyTemplates []PolicyTemplate) ([]PolicyTemplate, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedPolicyTemplates")
+	defer span.Finish()
+
+	resourcesToAuthorize := []Resource{}
+	for _, policyTemplate := range policyTemplates {
+		resourcesToAuthorize = append(resourcesToAuthorize, policyTemplate)
+	}
+	resources, err := api.getAuthorizedResources(requestInfo, resourceUrn, action, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	policyTemplatesFiltered := []PolicyTemplate{}
+	for _, res := range resources {
+		policyTemplatesFiltered = append(policyTemplatesFiltered, res.(PolicyTemplate))
+	}
+	return policyTemplatesFiltered, nil
+}
+
 // GetAuthorizedProxyResources returns authorized proxy resources for specified user combined with resource+action
 func (api WorkerAPI) GetAuthorizedProxyResources(requestInfo RequestInfo, resourceUrn string, action string, proxyResources []ProxyResource) ([]ProxyResource, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedProxyResources")
+	defer span.Finish()
+
 	resourcesToAuthorize := []Resource{}
 	for _, proxyResource := range proxyResources {
 		resourcesToAuthorize = append(resourcesToAuthorize, proxyResource)
@@ -108,6 +166,9 @@ func (api WorkerAPI) GetAuthorizedProxyResources(requestInfo RequestInfo, resour
 
 // GetAuthorizedOidcProviders returns authorized OIDC providers for specified user combined with resource+action
 func (api WorkerAPI) GetAuthorizedOidcProviders(requestInfo RequestInfo, resourceUrn string, action string, oidcProviders []OidcProvider) ([]OidcProvider, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedOidcProviders")
+	defer span.Finish()
+
 	resourcesToAuthorize := []Resource{}
 	for _, oidcProvider := range oidcProviders {
 		resourcesToAuthorize = append(resourcesToAuthorize, oidcProvider)
@@ -123,8 +184,123 @@ func (api WorkerAPI) GetAuthorizedOidcProviders(requestInfo RequestInfo, resourc
 	return oidcProvidersFiltered, nil
 }
 
+// GetAuthorizedBreakGlassSessions returns authorized break-glass sessions for specified user combined with resource+action
+func (api WorkerAPI) GetAuthorizedBreakGlassSessions(requestInfo RequestInfo, resourceUrn string, action string, sessions []BreakGlassSession) ([]BreakGlassSession, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedBreakGlassSessions")
+	defer span.Finish()
+
+	resourcesToAuthorize := []Resource{}
+	for _, session := range sessions {
+		resourcesToAuthorize = append(resourcesToAuthorize, session)
+	}
+	resources, err := api.getAuthorizedResources(requestInfo, resourceUrn, action, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	sessionsFiltered := []BreakGlassSession{}
+	for _, res := range resources {
+		sessionsFiltered = append(sessionsFiltered, res.(BreakGlassSession))
+	}
+	return sessionsFiltered, nil
+}
+
+// GetAuthorizedAuditEvents returns authorized audit events for specified user combined with resource+action
+func (api WorkerAPI) GetAuthorizedAuditEvents(requestInfo RequestInfo, resourceUrn string, action string, events []AuditEvent) ([]AuditEvent, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedAuditEvents")
+	defer span.Finish()
+
+	resourcesToAuthorize := []Resource{}
+	for _, event := range events {
+		resourcesToAuthorize = append(resourcesToAuthorize, event)
+	}
+	resources, err := api.getAuthorizedResources(requestInfo, resourceUrn, action, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	eventsFiltered := []AuditEvent{}
+	for _, res := range resources {
+		eventsFiltered = append(eventsFiltered, res.(AuditEvent))
+	}
+	return eventsFiltered, nil
+}
+
+// GetAuthorizedWebhooks returns authorized webhooks for specified user combined with resource+action
+func (api WorkerAPI) GetAuthorizedWebhooks(requestInfo RequestInfo, resourceUrn string, action string, webhooks []Webhook) ([]Webhook, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedWebhooks")
+	defer span.Finish()
+
+	resourcesToAuthorize := []Resource{}
+	for _, webhook := range webhooks {
+		resourcesToAuthorize = append(resourcesToAuthorize, webhook)
+	}
+	resources, err := api.getAuthorizedResources(requestInfo, resourceUrn, action, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	webhooksFiltered := []Webhook{}
+	for _, res := range resources {
+		webhooksFiltered = append(webhooksFiltered, res.(Webhook))
+	}
+	return webhooksFiltered, nil
+}
+
+// GetAuthorizedChangeRequests returns authorized change requests for specified user combined with resource+action
+func (api WorkerAPI) GetAuthorizedChangeRequests(requestInfo RequestInfo, resourceUrn string, action string, changeRequests []ChangeRequest) ([]ChangeRequest, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedChangeRequests")
+	defer span.Finish()
+
+	resourcesToAuthorize := []Resource{}
+	for _, changeRequest := range changeRequests {
+		resourcesToAuthorize = append(resourcesToAuthorize, changeRequest)
+	}
+	resources, err := api.getAuthorizedResources(requestInfo, resourceUrn, action, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	changeRequestsFiltered := []ChangeRequest{}
+	for _, res := range resources {
+		changeRequestsFiltered = append(changeRequestsFiltered, res.(ChangeRequest))
+	}
+	return changeRequestsFiltered, nil
+}
+
 // GetAuthorizedExternalResources returns the resources where the specified user has the action granted
 func (api WorkerAPI) GetAuthorizedExternalResources(requestInfo RequestInfo, action string, resources []string) ([]string, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedExternalResources")
+	defer span.Finish()
+
+	// Validate parameters
+	externalResources, err := getExternalResources(action, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	allowedResources, err := api.getAuthorizedResources(requestInfo, "urn:*", action, externalResources)
+	var allowedUrns []string
+	if err == nil {
+		allowedUrns, err = getAllowedExternalUrns(requestInfo.Identifier, allowedResources)
+	}
+
+	// Record decisions, unexpected errors aren't decisions
+	if err == nil || err.(*Error).Code == UNAUTHORIZED_RESOURCES_ERROR {
+		observeDecisions(action, resources, allowedUrns)
+		if api.AuditDecisionQueue != nil {
+			api.addDecisionAuditEvents(requestInfo, action, resources, allowedUrns)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return allowedUrns, nil
+}
+
+// GetAuthorizedRestrictions returns the restrictions the specified user has for the action over the urn prefix,
+// and the predicate rendered for the column if it isn't empty
+func (api WorkerAPI) GetAuthorizedRestrictions(requestInfo RequestInfo, action string, urnPrefix string, column string) (*AuthorizedRestrictions, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthorizedRestrictions")
+	defer span.Finish()
+
 	// Validate parameters
 	if err := AreValidActions([]string{action}); err != nil {
 		// Transform to API error
@@ -134,55 +310,68 @@ func (api WorkerAPI) GetAuthorizedExternalResources(requestInfo RequestInfo, act
 			Message: apiError.Message,
 		}
 	}
-	if len(resources) < 1 || len(resources) > MAX_RESOURCE_NUMBER {
+	if strings.Contains(action, "*") {
 		return nil, &Error{
 			Code:    INVALID_PARAMETER_ERROR,
-			Message: fmt.Sprintf("Invalid parameter Resources. Resources can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+			Message: fmt.Sprintf("Invalid parameter action %v. Action parameter can't be a prefix", action),
 		}
 	}
-	externalResources := []Resource{}
-	for _, res := range resources {
-		if !isFullUrn(res) {
-			return nil, &Error{
-				Code:    INVALID_PARAMETER_ERROR,
-				Message: fmt.Sprintf("Invalid parameter resource %v. Urn prefixes are not allowed here", res),
-			}
+	if err := AreValidResources([]string{urnPrefix}, RESOURCE_IAM); err != nil {
+		// Transform to API error
+		apiError := err.(*Error)
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: apiError.Message,
 		}
-		if err := AreValidResources([]string{res}, RESOURCE_EXTERNAL); err != nil {
-			// Transform to API error
-			apiError := err.(*Error)
-			return nil, &Error{
-				Code:    INVALID_PARAMETER_ERROR,
-				Message: apiError.Message,
-			}
+	}
+	if isFullUrn(urnPrefix) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter urnPrefix %v. Full urns are not allowed here", urnPrefix),
 		}
-		externalResources = append(externalResources, ExternalResource{Urn: res})
 	}
-	if strings.Contains(action, "*") {
+	if len(column) > 0 && !rColumn.MatchString(column) {
 		return nil, &Error{
 			Code:    INVALID_PARAMETER_ERROR,
-			Message: fmt.Sprintf("Invalid parameter action %v. Action parameter can't be a prefix", action),
+			Message: fmt.Sprintf("Invalid parameter column %v", column),
 		}
 	}
 
-	allowedUrns, err := api.getAuthorizedResources(requestInfo, "urn:*", action, externalResources)
-	if err != nil {
-		return nil, err
-	}
+	var restrictions *Restrictions
+	if requestInfo.Admin {
+		// Admin has the whole urn prefix allowed
+		restrictions = &Restrictions{
+			AllowedUrnPrefixes: []string{urnPrefix},
+			AllowedFullUrns:    []string{},
+			DeniedUrnPrefixes:  []string{},
+			DeniedFullUrns:     []string{},
+		}
+	} else {
+		userRestrictions, err := api.getRestrictions(requestInfo.Identifier, action, urnPrefix)
+		if err != nil {
+			return nil, err
+		}
+		restrictions = narrowRestrictions(userRestrictions, urnPrefix)
 
-	if len(allowedUrns) < 1 {
-		return nil, &Error{
-			Code:    UNAUTHORIZED_RESOURCES_ERROR,
-			Message: fmt.Sprintf("User with externalId %v is not allowed to access to any resource", requestInfo.Identifier),
+		// Check if there are some restrictions for this urn prefix
+		if len(restrictions.AllowedFullUrns) < 1 && len(restrictions.AllowedUrnPrefixes) < 1 {
+			return nil, &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v", requestInfo.Identifier, urnPrefix),
+			}
 		}
 	}
 
-	response := []string{}
-	for _, res := range allowedUrns {
-		response = append(response, res.GetUrn())
+	authorizedRestrictions := &AuthorizedRestrictions{
+		Action:       action,
+		UrnPrefix:    urnPrefix,
+		Restrictions: restrictions,
+	}
+	if len(column) > 0 {
+		authorizedRestrictions.Predicate = restrictions.renderPredicate(column)
 	}
 
-	return response, nil
+	return authorizedRestrictions, nil
 }
 
 // PRIVATE HELPER METHODS
@@ -202,18 +391,7 @@ func (api WorkerAPI) getAuthorizedResources(requestInfo RequestInfo, resourceUrn
 
 	Log.Debugf("Restrictions: %v", *restrictions)
 
-	// Check if there are some restrictions for this urn resource
-	if len(restrictions.AllowedFullUrns) < 1 && len(restrictions.AllowedUrnPrefixes) < 1 {
-		return nil, &Error{
-			Code:    UNAUTHORIZED_RESOURCES_ERROR,
-			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v", requestInfo.Identifier, resourceUrn),
-		}
-	}
-
-	// Filter resources
-	resourcesFiltered := filterResources(resources, restrictions)
-
-	return resourcesFiltered, nil
+	return filterAuthorizedResources(requestInfo.Identifier, resourceUrn, restrictions, resources)
 }
 
 // Get restrictions for this action and full resource or prefix resource, attached to this authenticated user
@@ -249,14 +427,7 @@ func (api WorkerAPI) getRestrictions(externalID string, action string, resource
 		return nil, err
 	}
 
-	// Retrieve valid statements
-	statements := getStatementsByRequestedAction(policies, action)
-
-	// Retrieve restrictions
-	var authResources *Restrictions
-	authResources = getRestrictions(statements, resource, isFullUrn(resource))
-
-	return authResources, nil
+	return getRestrictionsByPolicies(policies, action, resource), nil
 }
 
 func (api WorkerAPI) getGroupsByUser(userID string) ([]Group, error) {
@@ -309,6 +480,101 @@ func (api WorkerAPI) getPoliciesByGroups(groups []Group) ([]Policy, error) {
 	return policies, nil
 }
 
+// Validate the action and resources of an external resources authorization request
+func getExternalResources(action string, resources []string) ([]Resource, error) {
+	if err := AreValidActions([]string{action}); err != nil {
+		// Transform to API error
+		apiError := err.(*Error)
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: apiError.Message,
+		}
+	}
+	if len(resources) < 1 || len(resources) > MAX_RESOURCE_NUMBER {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter Resources. Resources can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+		}
+	}
+	externalResources := []Resource{}
+	for _, res := range resources {
+		if !isFullUrn(res) {
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter resource %v. Urn prefixes are not allowed here", res),
+			}
+		}
+		if err := AreValidResources([]string{res}, RESOURCE_EXTERNAL); err != nil {
+			// Transform to API error
+			apiError := err.(*Error)
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: apiError.Message,
+			}
+		}
+		externalResources = append(externalResources, ExternalResource{Urn: res})
+	}
+	if strings.Contains(action, "*") {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter action %v. Action parameter can't be a prefix", action),
+		}
+	}
+
+	return externalResources, nil
+}
+
+// Transform allowed external resources to its urns, throwing error if there isn't any
+func getAllowedExternalUrns(externalID string, allowedResources []Resource) ([]string, error) {
+	if len(allowedResources) < 1 {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to any resource", externalID),
+		}
+	}
+
+	response := []string{}
+	for _, res := range allowedResources {
+		response = append(response, res.GetUrn())
+	}
+
+	return response, nil
+}
+
+// Record metrics of the decision for the action over each requested resource
+func observeDecisions(action string, resources []string, allowedUrns []string) {
+	allowed := make(map[string]bool, len(allowedUrns))
+	for _, urn := range allowedUrns {
+		allowed[urn] = true
+	}
+	for _, urn := range resources {
+		metrics.ObserveAuthzDecision(action, allowed[urn])
+	}
+}
+
+// Filter resources with the user restrictions, throwing error if restrictions don't allow anything
+func filterAuthorizedResources(externalID string, resourceUrn string, restrictions *Restrictions, resources []Resource) ([]Resource, error) {
+	// Check if there are some restrictions for this urn resource
+	if len(restrictions.AllowedFullUrns) < 1 && len(restrictions.AllowedUrnPrefixes) < 1 {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v", externalID, resourceUrn),
+		}
+	}
+
+	// Filter resources
+	return filterResources(resources, restrictions), nil
+}
+
+// Retrieve restrictions for the action and full resource or prefix resource granted by the policies
+func getRestrictionsByPolicies(policies []Policy, action string, resource string) *Restrictions {
+	// Retrieve valid statements
+	statements := getStatementsByRequestedAction(policies, action)
+
+	// Retrieve restrictions
+	return getRestrictions(statements, resource, isFullUrn(resource))
+}
+
 // Filter a slice of statements for a specified action
 func getStatementsByRequestedAction(policies []Policy, requestedAction string) []Statement {
 	// Check received policies
@@ -521,6 +787,89 @@ func getRestrictions(statements []Statement, resource string, resourceIsFullUrn
 	return restrictions
 }
 
+// Narrow restriction prefixes that contain the urn prefix to the urn prefix itself, so restrictions
+// only describe resources inside it
+func narrowRestrictions(restrictions *Restrictions, urnPrefix string) *Restrictions {
+	narrowPrefixes := func(prefixes []string) []string {
+		narrowed := []string{}
+		for _, prefix := range prefixes {
+			if isContainedOrEqual(urnPrefix, prefix) {
+				prefix = urnPrefix
+			}
+			duplicated := false
+			for _, p := range narrowed {
+				if p == prefix {
+					duplicated = true
+					break
+				}
+			}
+			if !duplicated {
+				narrowed = append(narrowed, prefix)
+			}
+		}
+		return narrowed
+	}
+
+	narrowed := &Restrictions{
+		AllowedUrnPrefixes: narrowPrefixes(restrictions.AllowedUrnPrefixes),
+		AllowedFullUrns:    append([]string{}, restrictions.AllowedFullUrns...),
+		DeniedUrnPrefixes:  narrowPrefixes(restrictions.DeniedUrnPrefixes),
+		DeniedFullUrns:     append([]string{}, restrictions.DeniedFullUrns...),
+	}
+
+	// If the whole urn prefix is denied nothing is allowed
+	for _, prefix := range narrowed.DeniedUrnPrefixes {
+		if prefix == urnPrefix {
+			narrowed.AllowedUrnPrefixes = []string{}
+			narrowed.AllowedFullUrns = []string{}
+			break
+		}
+	}
+
+	return narrowed
+}
+
+// Render restrictions as a predicate over the column with the same semantics as isAllowedResource:
+// resource has to match any allowed restriction and none of the denied ones
+func (r Restrictions) renderPredicate(column string) *Predicate {
+	args := []string{}
+	renderMatches := func(prefixes []string, fullUrns []string) string {
+		matches := []string{}
+		for _, prefix := range prefixes {
+			matches = append(matches, fmt.Sprintf("%v LIKE ?", column))
+			args = append(args, escapeLikePattern(strings.Trim(prefix, "*"))+"%")
+		}
+		for _, urn := range fullUrns {
+			matches = append(matches, fmt.Sprintf("%v = ?", column))
+			args = append(args, urn)
+		}
+		return strings.Join(matches, " OR ")
+	}
+
+	where := renderMatches(r.AllowedUrnPrefixes, r.AllowedFullUrns)
+	if len(where) < 1 {
+		// Nothing allowed
+		return &Predicate{
+			Where: "1 = 0",
+			Args:  args,
+		}
+	}
+	where = "(" + where + ")"
+	if denied := renderMatches(r.DeniedUrnPrefixes, r.DeniedFullUrns); len(denied) > 0 {
+		where = where + " AND NOT (" + denied + ")"
+	}
+
+	return &Predicate{
+		Where: where,
+		Args:  args,
+	}
+}
+
+// Escape LIKE wildcards with the default backslash escape character
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
 // Remove resources that are not allowed by the restrictions
 func filterResources(resources []Resource, restrictions *Restrictions) []Resource {
 	filteredResource := []Resource{}
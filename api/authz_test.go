@@ -700,6 +700,319 @@ func TestGetAuthorizedExternalResources(t *testing.T) {
 	}
 }
 
+func TestGetAuthorizedRestrictions(t *testing.T) {
+	testcases := map[string]struct {
+		// Authenticated user
+		requestInfo RequestInfo
+		// Action to do
+		action string
+		// Urn prefix that user wants to access
+		urnPrefix string
+		// Column to render the predicate
+		column string
+		// Expected result
+		expectedRestrictions *AuthorizedRestrictions
+		// Error to compare when we expect an error
+		wantError error
+		// GetUserByExternalID Method Out Arguments
+		getUserByExternalIDResult *User
+		getUserByExternalIDError  error
+		// GetGroupsByUserID Method Out Arguments
+		getGroupsByUserIDResult []TestUserGroupRelation
+		// GetAttachedPolicies Method Out Arguments
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+	}{
+		"OktestCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			column:    "urn",
+			expectedRestrictions: &AuthorizedRestrictions{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+				Restrictions: &Restrictions{
+					AllowedUrnPrefixes: []string{"urn:ews:product:instance:resource/*"},
+					AllowedFullUrns:    []string{},
+					DeniedUrnPrefixes:  []string{},
+					DeniedFullUrns:     []string{},
+				},
+				Predicate: &Predicate{
+					Where: "(urn LIKE ?)",
+					Args:  []string{"urn:ews:product:instance:resource/%"},
+				},
+			},
+		},
+		"OktestCaseWithRestrictions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			column:    "t.urn",
+			expectedRestrictions: &AuthorizedRestrictions{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+				Restrictions: &Restrictions{
+					AllowedUrnPrefixes: []string{"urn:ews:product:instance:resource/path_1*"},
+					AllowedFullUrns:    []string{"urn:ews:product:instance:resource/path2/resourceAllow"},
+					DeniedUrnPrefixes:  []string{},
+					DeniedFullUrns:     []string{"urn:ews:product:instance:resource/path_1/resourceDeny"},
+				},
+				Predicate: &Predicate{
+					Where: "(t.urn LIKE ? OR t.urn = ?) AND NOT (t.urn = ?)",
+					Args: []string{
+						`urn:ews:product:instance:resource/path\_1%`,
+						"urn:ews:product:instance:resource/path2/resourceAllow",
+						"urn:ews:product:instance:resource/path_1/resourceDeny",
+					},
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:  "123456",
+				Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:  "GROUP-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:  "POLICY-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									"product:DoAction",
+								},
+								Resources: []string{
+									"urn:ews:product:instance:resource/path_1*",
+									"urn:ews:product:instance:resource/path2/resourceAllow",
+									"urn:ews:product:instance:other/*",
+								},
+							},
+							{
+								Effect: "deny",
+								Actions: []string{
+									"product:DoAction",
+								},
+								Resources: []string{
+									"urn:ews:product:instance:resource/path_1/resourceDeny",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"OktestCaseNarrowedPrefixWithoutPredicate": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			expectedRestrictions: &AuthorizedRestrictions{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+				Restrictions: &Restrictions{
+					AllowedUrnPrefixes: []string{"urn:ews:product:instance:resource/*"},
+					AllowedFullUrns:    []string{},
+					DeniedUrnPrefixes:  []string{},
+					DeniedFullUrns:     []string{},
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:  "123456",
+				Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:  "GROUP-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:  "POLICY-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									"product:*",
+								},
+								Resources: []string{
+									"urn:ews:product:*",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrortestCaseWholePrefixDenied": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:ews:product:instance:resource/*",
+			},
+			getUserByExternalIDResult: &User{
+				ID:  "123456",
+				Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:  "GROUP-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:  "POLICY-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									"product:DoAction",
+								},
+								Resources: []string{
+									"urn:ews:product:instance:resource/path1*",
+								},
+							},
+							{
+								Effect: "deny",
+								Actions: []string{
+									"product:DoAction",
+								},
+								Resources: []string{
+									"urn:ews:product:*",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrortestCaseNoRestrictions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:ews:product:instance:resource/*",
+			},
+			getUserByExternalIDResult: &User{
+				ID:  "123456",
+				Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+		},
+		"ErrortestCaseGetRestrictions": {
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Authenticated user with externalId  not found. Unable to retrieve permissions.",
+			},
+			getUserByExternalIDError: &database.Error{
+				Code: database.USER_NOT_FOUND,
+			},
+		},
+		"ErrortestCaseInvalidAction": {
+			requestInfo: RequestInfo{
+				Admin: true,
+			},
+			action:    "valid::Action",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action, value: valid::Action",
+			},
+		},
+		"ErrortestCaseActionPrefix": {
+			requestInfo: RequestInfo{
+				Admin: true,
+			},
+			action:    "product:DoPrefix*",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action product:DoPrefix*. Action parameter can't be a prefix",
+			},
+		},
+		"ErrortestCaseInvalidUrnPrefix": {
+			requestInfo: RequestInfo{
+				Admin: true,
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:invalid/resource:resource*",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter urn, value: urn:invalid/resource:resource*",
+			},
+		},
+		"ErrortestCaseFullUrn": {
+			requestInfo: RequestInfo{
+				Admin: true,
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/resource1",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter urnPrefix urn:ews:product:instance:resource/resource1. Full urns are not allowed here",
+			},
+		},
+		"ErrortestCaseInvalidColumn": {
+			requestInfo: RequestInfo{
+				Admin: true,
+			},
+			action:    "product:DoAction",
+			urnPrefix: "urn:ews:product:instance:resource/*",
+			column:    "urn; DROP TABLE users",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter column urn; DROP TABLE users",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = test.getUserByExternalIDResult
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = test.getUserByExternalIDError
+
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = test.getGroupsByUserIDResult
+
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = test.getAttachedPoliciesResult
+
+		restrictions, err := testAPI.GetAuthorizedRestrictions(test.requestInfo, test.action, test.urnPrefix, test.column)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedRestrictions, restrictions)
+	}
+}
+
 // Test for aux methods of Foulkon
 
 func TestGetAuthorizedResources(t *testing.T) {
@@ -2406,3 +2719,309 @@ func TestIsAllowedResource(t *testing.T) {
 		checkMethodResponse(t, n, nil, nil, test.expectedData, response)
 	}
 }
+
+func TestWorkerAPI_DryRun(t *testing.T) {
+	dryRunRequestInfo := RequestInfo{
+		Identifier: "123456",
+		Admin:      true,
+		DryRun:     true,
+	}
+	user := &User{
+		ID:         "USER-ID",
+		ExternalID: "1234",
+		Path:       "/path/",
+		Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+	}
+	group := &Group{
+		ID:   "GROUP-ID",
+		Name: "group1",
+		Org:  "123",
+		Path: "/path/",
+		Urn:  CreateUrn("123", RESOURCE_GROUP, "/path/", "group1"),
+	}
+	policy := &Policy{
+		ID:   "POLICY-ID",
+		Name: "policy1",
+		Org:  "123",
+		Path: "/path/",
+		Urn:  CreateUrn("123", RESOURCE_POLICY, "/path/", "policy1"),
+		Statements: &[]Statement{
+			{
+				Effect: "allow",
+				Actions: []string{
+					USER_ACTION_GET_USER,
+				},
+				Resources: []string{
+					GetUrnPrefix("", RESOURCE_USER, "/path/"),
+				},
+			},
+		},
+	}
+	testcases := map[string]struct {
+		// Repo method that mustn't be called
+		writeMethod string
+		// Manager Results
+		getUserByExternalIDMethodResult    *User
+		getUserByExternalIDMethodErr       error
+		getGroupByNameMethodResult         *Group
+		getGroupByNameMethodErr            error
+		getPolicyByNameMethodResult        *Policy
+		getPolicyByNameMethodErr           error
+		getProxyResourceByNameMethodErr    error
+		getOidcProviderByNameMethodErr     error
+		getPolicyTemplateByNameMethodValue *PolicyTemplate
+		getPolicyTemplateByNameMethodErr   error
+		getActiveBreakGlassSessionErr      error
+		// Call to API
+		call func(api *WorkerAPI) (interface{}, error)
+		// Expected result
+		checkResponse func(t *testing.T, testcase string, response interface{})
+		wantError     error
+	}{
+		"OKCaseAddUser": {
+			writeMethod: AddUserMethod,
+			getUserByExternalIDMethodErr: &database.Error{
+				Code: database.USER_NOT_FOUND,
+			},
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.AddUser(dryRunRequestInfo, "1234", "/path/")
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				created := response.(*User)
+				assert.Equal(t, "1234", created.ExternalID, "Error in test case %v", testcase)
+				assert.Equal(t, CreateUrn("", RESOURCE_USER, "/path/", "1234"), created.Urn, "Error in test case %v", testcase)
+			},
+		},
+		"ErrorCaseAddUserAlreadyExist": {
+			writeMethod:                     AddUserMethod,
+			getUserByExternalIDMethodResult: user,
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.AddUser(dryRunRequestInfo, "1234", "/path/")
+			},
+			wantError: &Error{
+				Code:    USER_ALREADY_EXIST,
+				Message: "Unable to create user, user with externalId 1234 already exist",
+			},
+		},
+		"OKCaseUpdateUser": {
+			writeMethod:                     UpdateUserMethod,
+			getUserByExternalIDMethodResult: user,
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.UpdateUser(dryRunRequestInfo, "1234", "/newpath/")
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				updated := response.(*User)
+				assert.Equal(t, "/newpath/", updated.Path, "Error in test case %v", testcase)
+				assert.Equal(t, user.ID, updated.ID, "Error in test case %v", testcase)
+			},
+		},
+		"OKCaseRemoveUser": {
+			writeMethod:                     RemoveUserMethod,
+			getUserByExternalIDMethodResult: user,
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return nil, api.RemoveUser(dryRunRequestInfo, "1234")
+			},
+		},
+		"OKCaseAddGroup": {
+			writeMethod: AddGroupMethod,
+			getGroupByNameMethodErr: &database.Error{
+				Code: database.GROUP_NOT_FOUND,
+			},
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.AddGroup(dryRunRequestInfo, "123", "group1", "/path/")
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				created := response.(*Group)
+				assert.Equal(t, group.Urn, created.Urn, "Error in test case %v", testcase)
+			},
+		},
+		"OKCaseAddMember": {
+			writeMethod:                     AddMemberMethod,
+			getUserByExternalIDMethodResult: user,
+			getGroupByNameMethodResult:      group,
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return nil, api.AddMember(dryRunRequestInfo, "1234", "group1", "123")
+			},
+		},
+		"OKCaseAttachPolicyToGroup": {
+			writeMethod:                 AttachPolicyMethod,
+			getGroupByNameMethodResult:  group,
+			getPolicyByNameMethodResult: policy,
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return nil, api.AttachPolicyToGroup(dryRunRequestInfo, "123", "group1", "policy1")
+			},
+		},
+		"OKCaseAddPolicy": {
+			writeMethod: AddPolicyMethod,
+			getPolicyByNameMethodErr: &database.Error{
+				Code: database.POLICY_NOT_FOUND,
+			},
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.AddPolicy(dryRunRequestInfo, "policy1", "/path/", "123", *policy.Statements)
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				created := response.(*Policy)
+				assert.Equal(t, policy.Urn, created.Urn, "Error in test case %v", testcase)
+				assert.Equal(t, policy.Statements, created.Statements, "Error in test case %v", testcase)
+			},
+		},
+		"OKCaseRemovePolicy": {
+			writeMethod:                 RemovePolicyMethod,
+			getPolicyByNameMethodResult: policy,
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return nil, api.RemovePolicy(dryRunRequestInfo, "123", "policy1")
+			},
+		},
+		"OKCaseAddProxyResource": {
+			writeMethod: AddProxyResourceMethod,
+			getProxyResourceByNameMethodErr: &database.Error{
+				Code: database.PROXY_RESOURCE_NOT_FOUND,
+			},
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.AddProxyResource(dryRunRequestInfo, "resource1", "123", "/path/", ResourceEntity{
+					Host:   "http://localhost:8000",
+					Path:   "/resource",
+					Method: "GET",
+					Urn:    "urn:ews:example:instance1:resource/get",
+					Action: "example:get",
+				})
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				created := response.(*ProxyResource)
+				assert.Equal(t, "resource1", created.Name, "Error in test case %v", testcase)
+			},
+		},
+		"OKCaseAddOidcProvider": {
+			writeMethod: AddOidcProviderMethod,
+			getOidcProviderByNameMethodErr: &database.Error{
+				Code: database.AUTH_OIDC_PROVIDER_NOT_FOUND,
+			},
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.AddOidcProvider(dryRunRequestInfo, "test", "/path/", "https://test.com", []string{"client1"})
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				created := response.(*OidcProvider)
+				assert.Equal(t, "https://test.com", created.IssuerURL, "Error in test case %v", testcase)
+			},
+		},
+		"OKCaseActivateBreakGlassSession": {
+			writeMethod: AddBreakGlassSessionMethod,
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code: database.BREAK_GLASS_SESSION_NOT_FOUND,
+			},
+			call: func(api *WorkerAPI) (interface{}, error) {
+				requestInfo := RequestInfo{
+					Identifier: "emergency",
+					BreakGlass: true,
+					DryRun:     true,
+				}
+				return api.ActivateBreakGlassSession(requestInfo, "OIDC provider down")
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				created := response.(*BreakGlassSession)
+				assert.Equal(t, "OIDC provider down", created.Justification, "Error in test case %v", testcase)
+			},
+		},
+		"OKCaseInstantiatePolicyTemplate": {
+			writeMethod:                        AddPolicyTemplateInstanceMethod,
+			getPolicyTemplateByNameMethodValue: getTestPolicyTemplate(),
+			getPolicyByNameMethodErr: &database.Error{
+				Code: database.POLICY_NOT_FOUND,
+			},
+			call: func(api *WorkerAPI) (interface{}, error) {
+				return api.InstantiatePolicyTemplate(dryRunRequestInfo, "123", "team", "teamA", "/path/",
+					map[string]string{"team": "a"})
+			},
+			checkResponse: func(t *testing.T, testcase string, response interface{}) {
+				created := response.(*Policy)
+				assert.Equal(t, []string{"urn:iws:iam::user/a/*"}, (*created.Statements)[0].Resources, "Error in test case %v", testcase)
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDMethodResult
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = testcase.getUserByExternalIDMethodErr
+		testRepo.ArgsOut[GetGroupByNameMethod][0] = testcase.getGroupByNameMethodResult
+		testRepo.ArgsOut[GetGroupByNameMethod][1] = testcase.getGroupByNameMethodErr
+		testRepo.ArgsOut[GetPolicyByNameMethod][0] = testcase.getPolicyByNameMethodResult
+		testRepo.ArgsOut[GetPolicyByNameMethod][1] = testcase.getPolicyByNameMethodErr
+		testRepo.ArgsOut[GetProxyResourceByNameMethod][1] = testcase.getProxyResourceByNameMethodErr
+		testRepo.ArgsOut[GetOidcProviderByNameMethod][1] = testcase.getOidcProviderByNameMethodErr
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][0] = testcase.getPolicyTemplateByNameMethodValue
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][1] = testcase.getPolicyTemplateByNameMethodErr
+		testRepo.ArgsOut[GetActiveBreakGlassSessionMethod][1] = testcase.getActiveBreakGlassSessionErr
+		testRepo.ArgsOut[IsMemberOfGroupMethod][0] = false
+		testRepo.ArgsOut[IsAttachedToGroupMethod][0] = false
+
+		response, err := testcase.call(testAPI)
+		if testcase.wantError != nil {
+			apiError, _ := err.(*Error)
+			assert.Equal(t, testcase.wantError, apiError, "Error in test case %v", x)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", x)
+			if testcase.checkResponse != nil {
+				testcase.checkResponse(t, x, response)
+			}
+		}
+
+		// Check that nothing was written
+		for _, arg := range testRepo.ArgsIn[testcase.writeMethod] {
+			assert.Nil(t, arg, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestRenderPredicate(t *testing.T) {
+	testcases := map[string]struct {
+		restrictions      Restrictions
+		column            string
+		expectedPredicate *Predicate
+	}{
+		"OkCaseNothingAllowed": {
+			restrictions: Restrictions{
+				DeniedFullUrns: []string{"urn:ews:product:instance:resource/resource1"},
+			},
+			column: "urn",
+			expectedPredicate: &Predicate{
+				Where: "1 = 0",
+				Args:  []string{},
+			},
+		},
+		"OkCaseAllowedAndDenied": {
+			restrictions: Restrictions{
+				AllowedUrnPrefixes: []string{"urn:ews:product:instance:resource/*", "urn:ews:product:instance:other*"},
+				DeniedUrnPrefixes:  []string{"urn:ews:product:instance:resource/private%/*"},
+				DeniedFullUrns:     []string{"urn:ews:product:instance:other1"},
+			},
+			column: "resources.urn",
+			expectedPredicate: &Predicate{
+				Where: "(resources.urn LIKE ? OR resources.urn LIKE ?) AND NOT (resources.urn LIKE ? OR resources.urn = ?)",
+				Args: []string{
+					"urn:ews:product:instance:resource/%",
+					"urn:ews:product:instance:other%",
+					`urn:ews:product:instance:resource/private\%/%`,
+					"urn:ews:product:instance:other1",
+				},
+			},
+		},
+		"OkCaseEverythingAllowed": {
+			restrictions: Restrictions{
+				AllowedUrnPrefixes: []string{"*"},
+			},
+			column: "urn",
+			expectedPredicate: &Predicate{
+				Where: "(urn LIKE ?)",
+				Args:  []string{"%"},
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		predicate := test.restrictions.renderPredicate(test.column)
+		assert.Equal(t, test.expectedPredicate, predicate, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Default break-glass session duration
+	DEFAULT_BREAK_GLASS_SESSION_TTL = time.Hour
+
+	// Constraints
+	MAX_JUSTIFICATION_LENGTH = 1024
+)
+
+// TYPE DEFINITIONS
+
+// Break-glass emergency admin session
+type BreakGlassSession struct {
+	ID            string    `json:"id,omitempty"`
+	UserID        string    `json:"userId,omitempty"`
+	Justification string    `json:"justification,omitempty"`
+	Urn           string    `json:"urn,omitempty"`
+	CreateAt      time.Time `json:"createAt,omitempty"`
+	ExpiresAt     time.Time `json:"expiresAt,omitempty"`
+	// FirstUseAt is zero until the session is used, it is only used to raise the alert once
+	FirstUseAt time.Time `json:"-"`
+}
+
+func (s BreakGlassSession) String() string {
+	return fmt.Sprintf("[id: %v, userId: %v, justification: %v, urn: %v, createAt: %v, expiresAt: %v]",
+		s.ID, s.UserID, s.Justification, s.Urn, s.CreateAt.Format("2006-01-02 15:04:05 MST"),
+		s.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
+}
+
+func (s BreakGlassSession) GetUrn() string {
+	return s.Urn
+}
+
+// BREAK-GLASS API IMPLEMENTATION
+
+func (api WorkerAPI) ActivateBreakGlassSession(requestInfo RequestInfo, justification string) (*BreakGlassSession, error) {
+	api, span := api.startSpan(requestInfo, "ActivateBreakGlassSession")
+	defer span.Finish()
+
+	// Only pre-registered break-glass accounts can activate a session
+	if !requestInfo.BreakGlass {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to activate a break-glass session", requestInfo.Identifier),
+		}
+	}
+
+	// Validate fields
+	justification = strings.TrimSpace(justification)
+	if len(justification) < 1 || len(justification) > MAX_JUSTIFICATION_LENGTH {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: justification %v", justification),
+		}
+	}
+
+	session := createBreakGlassSession(requestInfo.Identifier, justification, api.BreakGlassSessionTTL)
+
+	// Check if there is an active session for this user
+	activeSession, err := api.BreakGlassRepo.GetActiveBreakGlassSession(requestInfo.Identifier, session.CreateAt)
+
+	// Check if active session could be retrieved
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		// There isn't any active session
+		case database.BREAK_GLASS_SESSION_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &session, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, BREAK_GLASS_ACTION_ACTIVATE_SESSION, session.Urn, nil, &session))
+			if err != nil {
+				return nil, err
+			}
+
+			// Create break-glass session
+			createdSession, err := changeAPI.BreakGlassRepo.AddBreakGlassSession(session)
+
+			// Check if there is an unexpected error in DB
+			if err != nil {
+				//Transform to DB error
+				dbError := err.(*database.Error)
+				return nil, &Error{
+					Code:    UNKNOWN_API_ERROR,
+					Message: dbError.Message,
+				}
+			}
+
+			LogBreakGlassActivation(requestInfo.RequestID, requestInfo.Identifier, createdSession)
+			return createdSession, nil
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	} else { // Fail if there is an active session
+		return nil, &Error{
+			Code: BREAK_GLASS_SESSION_ALREADY_ACTIVE,
+			Message: fmt.Sprintf("Unable to activate break-glass session, user %v has an active session %v until %v",
+				requestInfo.Identifier, activeSession.ID, activeSession.ExpiresAt.Format(time.RFC3339)),
+		}
+	}
+}
+
+func (api WorkerAPI) GetActiveBreakGlassSession(userID string) (*BreakGlassSession, error) {
+	// Validate fields
+	if !IsValidUserExternalID(userID) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: userId %v", userID),
+		}
+	}
+
+	// Call repo to retrieve the active session
+	session, err := api.BreakGlassRepo.GetActiveBreakGlassSession(userID, time.Now().UTC())
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		// Session doesn't exist or it has expired
+		if dbError.Code == database.BREAK_GLASS_SESSION_NOT_FOUND {
+			return nil, &Error{
+				Code:    BREAK_GLASS_SESSION_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		}
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	return session, nil
+}
+
+func (api WorkerAPI) UseBreakGlassSession(requestInfo RequestInfo) (*BreakGlassSession, error) {
+	// Only pre-registered break-glass accounts have sessions
+	if !requestInfo.BreakGlass {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to use a break-glass session", requestInfo.Identifier),
+		}
+	}
+
+	session, err := api.GetActiveBreakGlassSession(requestInfo.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	// First use of emergency access raises an alert once for the session, even with several workers. Next uses
+	// are recorded with the session ID in the audit events of their requests
+	if !session.FirstUseAt.IsZero() {
+		return session, nil
+	}
+	requestInfo.BreakGlassSessionID = session.ID
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_ALERT, BREAK_GLASS_ACTION_USE_SESSION, session.Urn, nil, session))
+	if err != nil {
+		return nil, err
+	}
+
+	// Alert is only stored with the first use
+	firstUseAt := time.Now().UTC()
+	firstUse, err := changeAPI.BreakGlassRepo.SetBreakGlassSessionFirstUse(session.ID, firstUseAt)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	if firstUse {
+		session.FirstUseAt = firstUseAt
+		LogBreakGlassUse(requestInfo.RequestID, requestInfo.Identifier, session)
+	}
+	return session, nil
+}
+
+func (api WorkerAPI) ListBreakGlassSessions(requestInfo RequestInfo, filter *Filter) ([]BreakGlassSession, int, error) {
+	api, span := api.startSpan(requestInfo, "ListBreakGlassSessions")
+	defer span.Finish()
+
+	// Validate fields
+	var total int
+	orderByValidColumns := api.BreakGlassRepo.OrderByValidColumns(BREAK_GLASS_ACTION_LIST_SESSIONS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the sessions
+	sessions, total, err := api.BreakGlassRepo.GetBreakGlassSessionsFiltered(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions to list
+	urnPrefix := GetUrnPrefix("", RESOURCE_BREAK_GLASS_SESSION, "/")
+	sessionsFiltered, err := api.GetAuthorizedBreakGlassSessions(requestInfo, urnPrefix, BREAK_GLASS_ACTION_LIST_SESSIONS, sessions)
+	if err != nil {
+		return nil, total, err
+	}
+
+	return sessionsFiltered, total, nil
+}
+
+// PRIVATE HELPER METHODS
+
+func createBreakGlassSession(userID string, justification string, ttl time.Duration) BreakGlassSession {
+	if ttl <= 0 {
+		ttl = DEFAULT_BREAK_GLASS_SESSION_TTL
+	}
+	id := uuid.NewV4().String()
+	createAt := time.Now().UTC()
+	urn := CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", id)
+
+	return BreakGlassSession{
+		ID:            id,
+		UserID:        userID,
+		Justification: justification,
+		Urn:           urn,
+		CreateAt:      createAt,
+		ExpiresAt:     createAt.Add(ttl),
+	}
+}
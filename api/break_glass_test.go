@@ -0,0 +1,625 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerAPI_ActivateBreakGlassSession(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo   RequestInfo
+		justification string
+		ttl           time.Duration
+		// Expected result
+		expectedSession *BreakGlassSession
+		wantError       error
+		// Manager Results
+		getActiveBreakGlassSessionResult *BreakGlassSession
+		addBreakGlassSessionResult       *BreakGlassSession
+		// Manager Errors
+		getActiveBreakGlassSessionErr error
+		addBreakGlassSessionErr       error
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			justification: "  OIDC provider down ",
+			ttl:           30 * time.Minute,
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code: database.BREAK_GLASS_SESSION_NOT_FOUND,
+			},
+			addBreakGlassSessionResult: &BreakGlassSession{
+				ID:            "session1",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:      now,
+				ExpiresAt:     now.Add(30 * time.Minute),
+			},
+			expectedSession: &BreakGlassSession{
+				ID:            "session1",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:      now,
+				ExpiresAt:     now.Add(30 * time.Minute),
+			},
+		},
+		"OkCaseDefaultTTL": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			justification: "OIDC provider down",
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code: database.BREAK_GLASS_SESSION_NOT_FOUND,
+			},
+			addBreakGlassSessionResult: &BreakGlassSession{
+				ID:            "session1",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:      now,
+				ExpiresAt:     now.Add(DEFAULT_BREAK_GLASS_SESSION_TTL),
+			},
+			expectedSession: &BreakGlassSession{
+				ID:            "session1",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:      now,
+				ExpiresAt:     now.Add(DEFAULT_BREAK_GLASS_SESSION_TTL),
+			},
+		},
+		"ErrorCaseNotBreakGlassAccount": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			justification: "OIDC provider down",
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to activate a break-glass session",
+			},
+		},
+		"ErrorCaseEmptyJustification": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			justification: "   ",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: justification ",
+			},
+		},
+		"ErrorCaseJustificationTooLong": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			justification: strings.Repeat("a", MAX_JUSTIFICATION_LENGTH+1),
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: justification " + strings.Repeat("a", MAX_JUSTIFICATION_LENGTH+1),
+			},
+		},
+		"ErrorCaseSessionAlreadyActive": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			justification: "OIDC provider down",
+			getActiveBreakGlassSessionResult: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				ExpiresAt: time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC),
+			},
+			wantError: &Error{
+				Code:    BREAK_GLASS_SESSION_ALREADY_ACTIVE,
+				Message: "Unable to activate break-glass session, user emergency has an active session session1 until 2016-01-01T10:00:00Z",
+			},
+		},
+		"ErrorCaseGetActiveSessionDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			justification: "OIDC provider down",
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseAddSessionDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			justification: "OIDC provider down",
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code: database.BREAK_GLASS_SESSION_NOT_FOUND,
+			},
+			addBreakGlassSessionErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.BreakGlassSessionTTL = testcase.ttl
+
+		testRepo.ArgsOut[GetActiveBreakGlassSessionMethod][0] = testcase.getActiveBreakGlassSessionResult
+		testRepo.ArgsOut[GetActiveBreakGlassSessionMethod][1] = testcase.getActiveBreakGlassSessionErr
+		testRepo.ArgsOut[AddBreakGlassSessionMethod][0] = testcase.addBreakGlassSessionResult
+		testRepo.ArgsOut[AddBreakGlassSessionMethod][1] = testcase.addBreakGlassSessionErr
+
+		session, err := testAPI.ActivateBreakGlassSession(testcase.requestInfo, testcase.justification)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedSession, session)
+		if testcase.wantError == nil {
+			// Check session stored
+			stored, ok := testRepo.ArgsIn[AddBreakGlassSessionMethod][0].(BreakGlassSession)
+			assert.True(t, ok, "Error in test case %v", x)
+			assert.Equal(t, testcase.expectedSession.UserID, stored.UserID, "Error in test case %v", x)
+			assert.Equal(t, testcase.expectedSession.Justification, stored.Justification, "Error in test case %v", x)
+			assert.Equal(t, CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", stored.ID), stored.Urn, "Error in test case %v", x)
+			assert.Equal(t, testcase.expectedSession.ExpiresAt.Sub(testcase.expectedSession.CreateAt),
+				stored.ExpiresAt.Sub(stored.CreateAt), "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_GetActiveBreakGlassSession(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API Method args
+		userID string
+		// Expected result
+		expectedSession *BreakGlassSession
+		wantError       error
+		// Manager Results
+		getActiveBreakGlassSessionResult *BreakGlassSession
+		// Manager Errors
+		getActiveBreakGlassSessionErr error
+	}{
+		"OkCase": {
+			userID: "emergency",
+			getActiveBreakGlassSessionResult: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedSession: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+		},
+		"ErrorCaseInvalidUserID": {
+			userID: "**!^#~@#",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: userId **!^#~@#",
+			},
+		},
+		"ErrorCaseSessionNotFound": {
+			userID: "emergency",
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code:    database.BREAK_GLASS_SESSION_NOT_FOUND,
+				Message: "Active break-glass session for user emergency not found",
+			},
+			wantError: &Error{
+				Code:    BREAK_GLASS_SESSION_NOT_FOUND,
+				Message: "Active break-glass session for user emergency not found",
+			},
+		},
+		"ErrorCaseInternalError": {
+			userID: "emergency",
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetActiveBreakGlassSessionMethod][0] = testcase.getActiveBreakGlassSessionResult
+		testRepo.ArgsOut[GetActiveBreakGlassSessionMethod][1] = testcase.getActiveBreakGlassSessionErr
+
+		session, err := testAPI.GetActiveBreakGlassSession(testcase.userID)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedSession, session)
+	}
+}
+
+func TestWorkerAPI_UseBreakGlassSession(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		// Expected result
+		expectedSession *BreakGlassSession
+		expectedAlert   bool
+		wantError       error
+		// Manager Results
+		getActiveBreakGlassSessionResult   *BreakGlassSession
+		setBreakGlassSessionFirstUseResult bool
+		getWebhooksResult                  []Webhook
+		// Manager Errors
+		getActiveBreakGlassSessionErr   error
+		setBreakGlassSessionFirstUseErr error
+	}{
+		"OkCaseFirstUse": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				RequestID:  "request1",
+				BreakGlass: true,
+			},
+			getActiveBreakGlassSessionResult: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				Urn:       CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			setBreakGlassSessionFirstUseResult: true,
+			getWebhooksResult: []Webhook{
+				{
+					Name:   "alerts",
+					Events: []string{BREAK_GLASS_ACTION_USE_SESSION},
+				},
+			},
+			expectedSession: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				Urn:       CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedAlert: true,
+		},
+		"OkCaseFirstUseByOtherWorker": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				RequestID:  "request1",
+				BreakGlass: true,
+			},
+			getActiveBreakGlassSessionResult: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				Urn:       CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedSession: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				Urn:       CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+		},
+		"OkCaseUsedSession": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				RequestID:  "request2",
+				BreakGlass: true,
+			},
+			getActiveBreakGlassSessionResult: &BreakGlassSession{
+				ID:         "session1",
+				UserID:     "emergency",
+				Urn:        CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:   now,
+				ExpiresAt:  now.Add(time.Hour),
+				FirstUseAt: now,
+			},
+			expectedSession: &BreakGlassSession{
+				ID:         "session1",
+				UserID:     "emergency",
+				Urn:        CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:   now,
+				ExpiresAt:  now.Add(time.Hour),
+				FirstUseAt: now,
+			},
+		},
+		"ErrorCaseNoBreakGlassAccount": {
+			requestInfo: RequestInfo{
+				Identifier: "user1",
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId user1 is not allowed to use a break-glass session",
+			},
+		},
+		"ErrorCaseSessionNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			getActiveBreakGlassSessionErr: &database.Error{
+				Code:    database.BREAK_GLASS_SESSION_NOT_FOUND,
+				Message: "Active break-glass session for user emergency not found",
+			},
+			wantError: &Error{
+				Code:    BREAK_GLASS_SESSION_NOT_FOUND,
+				Message: "Active break-glass session for user emergency not found",
+			},
+		},
+		"ErrorCaseSetBreakGlassSessionFirstUseDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+			getActiveBreakGlassSessionResult: &BreakGlassSession{
+				ID:        "session1",
+				UserID:    "emergency",
+				Urn:       CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			setBreakGlassSessionFirstUseErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetActiveBreakGlassSessionMethod][0] = testcase.getActiveBreakGlassSessionResult
+		testRepo.ArgsOut[GetActiveBreakGlassSessionMethod][1] = testcase.getActiveBreakGlassSessionErr
+		testRepo.ArgsOut[SetBreakGlassSessionFirstUseMethod][0] = testcase.setBreakGlassSessionFirstUseResult
+		testRepo.ArgsOut[SetBreakGlassSessionFirstUseMethod][1] = testcase.setBreakGlassSessionFirstUseErr
+		testRepo.ArgsOut[GetWebhooksMethod][0] = testcase.getWebhooksResult
+		used := testcase.getActiveBreakGlassSessionResult != nil && !testcase.getActiveBreakGlassSessionResult.FirstUseAt.IsZero()
+
+		session, err := testAPI.UseBreakGlassSession(testcase.requestInfo)
+		if testcase.expectedAlert {
+			// First use is set when the alert is raised
+			assert.NotNil(t, session, "Error in test case %v", x)
+			assert.False(t, session.FirstUseAt.IsZero(), "Error in test case %v", x)
+			testcase.expectedSession.FirstUseAt = session.FirstUseAt
+		}
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedSession, session)
+
+		// Used sessions aren't updated again
+		if used {
+			assert.Nil(t, testRepo.ArgsIn[SetBreakGlassSessionFirstUseMethod][0], "Error in test case %v", x)
+		}
+
+		// Check alert event, given to repo with the first use so it's stored only if the session wasn't used yet
+		if testcase.getActiveBreakGlassSessionResult == nil || used {
+			assert.Nil(t, testRepo.ArgsIn[WithEventsMethod][0], "Error in test case %v", x)
+			continue
+		}
+		events := testRepo.ArgsIn[WithEventsMethod][0].([]AuditEvent)
+		if assert.Len(t, events, 1, "Error in test case %v", x) {
+			event := events[0]
+			assert.Equal(t, AUDIT_EVENT_TYPE_ALERT, event.Type, "Error in test case %v", x)
+			assert.Equal(t, BREAK_GLASS_ACTION_USE_SESSION, event.Action, "Error in test case %v", x)
+			assert.Equal(t, testcase.getActiveBreakGlassSessionResult.Urn, event.EntityUrn, "Error in test case %v", x)
+			assert.Equal(t, testcase.getActiveBreakGlassSessionResult.ID, event.BreakGlassSessionID, "Error in test case %v", x)
+		}
+		deliveries := testRepo.ArgsIn[WithEventsMethod][1].([]WebhookDelivery)
+		assert.Equal(t, len(testcase.getWebhooksResult), len(deliveries), "Error in test case %v", x)
+		for _, delivery := range deliveries {
+			assert.Equal(t, BREAK_GLASS_ACTION_USE_SESSION, delivery.Event, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_ListBreakGlassSessions(t *testing.T) {
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		filter      *Filter
+		// Expected result
+		expectedSessions []BreakGlassSession
+		totalResult      int
+		wantError        error
+		// Manager Results
+		getGroupsByUserIDResult             []TestUserGroupRelation
+		getAttachedPoliciesResult           []TestPolicyGroupRelation
+		getUserByExternalIDResult           *User
+		getBreakGlassSessionsFilteredResult []BreakGlassSession
+		// Manager Errors
+		getUserByExternalIDErr           error
+		getBreakGlassSessionsFilteredErr error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				ExternalID: "emergency",
+			},
+			expectedSessions: []BreakGlassSession{
+				{
+					ID:     "session1",
+					UserID: "emergency",
+					Urn:    CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				},
+			},
+			totalResult: 1,
+			getBreakGlassSessionsFilteredResult: []BreakGlassSession{
+				{
+					ID:     "session1",
+					UserID: "emergency",
+					Urn:    CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				},
+			},
+		},
+		"OkCaseUser": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{},
+			expectedSessions: []BreakGlassSession{
+				{
+					ID:     "sessionAllowed",
+					UserID: "emergency",
+					Urn:    CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "sessionAllowed"),
+				},
+			},
+			totalResult: 2,
+			getBreakGlassSessionsFilteredResult: []BreakGlassSession{
+				{
+					ID:     "sessionAllowed",
+					UserID: "emergency",
+					Urn:    CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "sessionAllowed"),
+				},
+				{
+					ID:     "sessionDenied",
+					UserID: "emergency",
+					Urn:    CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "sessionDenied"),
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GROUP-USER-ID",
+						Name: "groupUser",
+						Path: "/path/1/",
+						Urn:  CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "example",
+						Path: "/path/",
+						Urn:  CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									BREAK_GLASS_ACTION_LIST_SESSIONS,
+								},
+								Resources: []string{
+									GetUrnPrefix("", RESOURCE_BREAK_GLASS_SESSION, "/"),
+								},
+							},
+							{
+								Effect: "deny",
+								Actions: []string{
+									BREAK_GLASS_ACTION_LIST_SESSIONS,
+								},
+								Resources: []string{
+									CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "sessionDenied"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseInvalidExternalID": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				ExternalID: "**!^#~@#",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: externalID **!^#~@#",
+			},
+		},
+		"ErrorCaseInternalErrorSessionsFiltered": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{},
+			getBreakGlassSessionsFilteredErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{},
+			getBreakGlassSessionsFilteredResult: []BreakGlassSession{
+				{
+					ID:     "sessionDenied",
+					UserID: "emergency",
+					Urn:    CreateUrn("", RESOURCE_BREAK_GLASS_SESSION, "/", "sessionDenied"),
+				},
+			},
+			getUserByExternalIDErr: &database.Error{
+				Code: database.USER_NOT_FOUND,
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Authenticated user with externalId 123456 not found. Unable to retrieve permissions.",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetBreakGlassSessionsFilteredMethod][0] = testcase.getBreakGlassSessionsFilteredResult
+		testRepo.ArgsOut[GetBreakGlassSessionsFilteredMethod][1] = testcase.totalResult
+		testRepo.ArgsOut[GetBreakGlassSessionsFilteredMethod][2] = testcase.getBreakGlassSessionsFilteredErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = testcase.getUserByExternalIDErr
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		sessions, total, err := testAPI.ListBreakGlassSessions(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedSessions, sessions)
+		assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+	}
+}
@@ -0,0 +1,500 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Change request status
+	CHANGE_REQUEST_STATUS_PENDING  = "pending"
+	CHANGE_REQUEST_STATUS_APPROVED = "approved"
+	CHANGE_REQUEST_STATUS_REJECTED = "rejected"
+	CHANGE_REQUEST_STATUS_EXPIRED  = "expired"
+
+	// Operations that can be proposed
+	CHANGE_REQUEST_OPERATION_UPDATE_POLICY          = "UpdatePolicy"
+	CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP = "AttachPolicyToGroup"
+	CHANGE_REQUEST_OPERATION_UPDATE_POLICY_TEMPLATE = "UpdatePolicyTemplate"
+
+	// Default time to review a change request
+	DEFAULT_CHANGE_REQUEST_TTL = 24 * time.Hour
+
+	// Constraints
+	MAX_COMMENT_LENGTH = 1024
+)
+
+// TYPE DEFINITIONS
+
+// Rules that make a mutation need the approval of a second user
+type ApprovalRules struct {
+	// Actions that can't be granted without approval, e.g. "iam:*"
+	Actions []string
+	// Organizations whose policy updates and attachments always need approval
+	ProtectedOrgs []string
+	// Time to review a change request, DEFAULT_CHANGE_REQUEST_TTL if not set
+	TTL time.Duration
+}
+
+// Change request domain. It stores a mutation pending of approval
+type ChangeRequest struct {
+	ID             string               `json:"id,omitempty"`
+	Org            string               `json:"org,omitempty"`
+	Operation      string               `json:"operation,omitempty"`
+	Payload        ChangeRequestPayload `json:"payload,omitempty"`
+	Status         string               `json:"status,omitempty"`
+	Requester      string               `json:"requester,omitempty"`
+	RequesterAdmin bool                 `json:"-"`
+	Reviewer       string               `json:"reviewer,omitempty"`
+	Comment        string               `json:"comment,omitempty"`
+	Urn            string               `json:"urn,omitempty"`
+	CreateAt       time.Time            `json:"createAt,omitempty"`
+	ExpiresAt      time.Time            `json:"expiresAt,omitempty"`
+	ReviewAt       time.Time            `json:"reviewAt,omitempty"`
+}
+
+func (c ChangeRequest) String() string {
+	return fmt.Sprintf("[id: %v, org: %v, operation: %v, payload: %v, status: %v, requester: %v, reviewer: %v, urn: %v, createAt: %v, expiresAt: %v]",
+		c.ID, c.Org, c.Operation, c.Payload, c.Status, c.Requester, c.Reviewer, c.Urn,
+		c.CreateAt.Format("2006-01-02 15:04:05 MST"), c.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
+}
+
+func (c ChangeRequest) GetUrn() string {
+	return c.Urn
+}
+
+// Arguments of the proposed operation, used to replay it once approved
+type ChangeRequestPayload struct {
+	PolicyName   string                    `json:"policyName,omitempty"`
+	GroupName    string                    `json:"groupName,omitempty"`
+	TemplateName string                    `json:"templateName,omitempty"`
+	NewName      string                    `json:"newName,omitempty"`
+	NewPath      string                    `json:"newPath,omitempty"`
+	Parameters   []PolicyTemplateParameter `json:"parameters,omitempty"`
+	Statements   *[]Statement              `json:"statements,omitempty"`
+}
+
+func (p ChangeRequestPayload) String() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// CHANGE REQUEST API IMPLEMENTATION
+
+func (api WorkerAPI) GetChangeRequest(requestInfo RequestInfo, org string, id string) (*ChangeRequest, error) {
+	api, span := api.startSpan(requestInfo, "GetChangeRequest")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidOrg(org) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: org %v", org),
+		}
+	}
+	if _, err := uuid.FromString(id); err != nil {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: id %v", id),
+		}
+	}
+
+	// Call repo to retrieve the change request
+	changeRequest, err := api.ChangeRequestRepo.GetChangeRequestByID(org, id)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		// Change request doesn't exist in DB
+		switch dbError.Code {
+		case database.CHANGE_REQUEST_NOT_FOUND:
+			return nil, &Error{
+				Code:    CHANGE_REQUEST_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	}
+
+	// Check restrictions
+	changeRequestsFiltered, err := api.GetAuthorizedChangeRequests(requestInfo, changeRequest.Urn, CHANGE_REQUEST_ACTION_GET_CHANGE_REQUEST,
+		[]ChangeRequest{*changeRequest})
+	if err != nil {
+		return nil, err
+	}
+	if len(changeRequestsFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, changeRequest.Urn),
+		}
+	}
+
+	return changeRequest, nil
+}
+
+func (api WorkerAPI) ListChangeRequests(requestInfo RequestInfo, filter *Filter) ([]ChangeRequest, int, error) {
+	api, span := api.startSpan(requestInfo, "ListChangeRequests")
+	defer span.Finish()
+
+	// Validate fields
+	var total int
+	orderByValidColumns := api.ChangeRequestRepo.OrderByValidColumns(CHANGE_REQUEST_ACTION_LIST_CHANGE_REQUESTS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+	switch filter.Status {
+	case "", CHANGE_REQUEST_STATUS_PENDING, CHANGE_REQUEST_STATUS_APPROVED, CHANGE_REQUEST_STATUS_REJECTED, CHANGE_REQUEST_STATUS_EXPIRED:
+	default:
+		return nil, total, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: status %v", filter.Status),
+		}
+	}
+
+	// Expire pending change requests out of time before listing them
+	if err := api.ChangeRequestRepo.ExpireChangeRequests(time.Now().UTC()); err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Call repo to retrieve the change requests
+	changeRequests, total, err := api.ChangeRequestRepo.GetChangeRequestsFiltered(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions to list
+	var urnPrefix string
+	if len(filter.Org) == 0 {
+		urnPrefix = "*"
+	} else {
+		urnPrefix = GetUrnPrefix(filter.Org, RESOURCE_CHANGE_REQUEST, "/")
+	}
+	changeRequestsFiltered, err := api.GetAuthorizedChangeRequests(requestInfo, urnPrefix, CHANGE_REQUEST_ACTION_LIST_CHANGE_REQUESTS, changeRequests)
+	if err != nil {
+		return nil, total, err
+	}
+
+	return changeRequestsFiltered, total, nil
+}
+
+func (api WorkerAPI) ApproveChangeRequest(requestInfo RequestInfo, org string, id string, comment string) (*ChangeRequest, error) {
+	api, span := api.startSpan(requestInfo, "ApproveChangeRequest")
+	defer span.Finish()
+
+	changeRequest, err := api.getChangeRequestToReview(requestInfo, org, id, comment, CHANGE_REQUEST_ACTION_APPROVE_CHANGE_REQUEST)
+	if err != nil {
+		return nil, err
+	}
+	pendingChangeRequest := *changeRequest
+
+	changeRequest.Status = CHANGE_REQUEST_STATUS_APPROVED
+	changeRequest.Reviewer = requestInfo.Identifier
+	changeRequest.Comment = strings.TrimSpace(comment)
+	changeRequest.ReviewAt = time.Now().UTC()
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return changeRequest, nil
+	}
+
+	// Claim change request before replaying it, so concurrent reviews can't replay it twice
+	updatedChangeRequest, err := api.updateChangeRequest(*changeRequest, CHANGE_REQUEST_STATUS_PENDING)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay the operation with the requester identity, approval is stored with the replayed change.
+	// If it fails, change request is pending again
+	replayAPI := api
+	replayAPI.pendingAuditEvents = []AuditEvent{
+		createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, CHANGE_REQUEST_ACTION_APPROVE_CHANGE_REQUEST, updatedChangeRequest.Urn,
+			pendingChangeRequest, updatedChangeRequest),
+	}
+	if err := replayAPI.replayChangeRequest(requestInfo, changeRequest); err != nil {
+		if _, revertErr := api.updateChangeRequest(pendingChangeRequest, CHANGE_REQUEST_STATUS_APPROVED); revertErr != nil {
+			LogOperationError(requestInfo.RequestID, requestInfo.Identifier, revertErr.(*Error))
+		}
+		return nil, err
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Change request approved %+v", updatedChangeRequest))
+	return updatedChangeRequest, nil
+}
+
+func (api WorkerAPI) RejectChangeRequest(requestInfo RequestInfo, org string, id string, comment string) (*ChangeRequest, error) {
+	api, span := api.startSpan(requestInfo, "RejectChangeRequest")
+	defer span.Finish()
+
+	changeRequest, err := api.getChangeRequestToReview(requestInfo, org, id, comment, CHANGE_REQUEST_ACTION_REJECT_CHANGE_REQUEST)
+	if err != nil {
+		return nil, err
+	}
+	pendingChangeRequest := *changeRequest
+
+	changeRequest.Status = CHANGE_REQUEST_STATUS_REJECTED
+	changeRequest.Reviewer = requestInfo.Identifier
+	changeRequest.Comment = strings.TrimSpace(comment)
+	changeRequest.ReviewAt = time.Now().UTC()
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return changeRequest, nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, CHANGE_REQUEST_ACTION_REJECT_CHANGE_REQUEST,
+		changeRequest.Urn, pendingChangeRequest, changeRequest))
+	if err != nil {
+		return nil, err
+	}
+
+	// Update change request
+	updatedChangeRequest, err := changeAPI.updateChangeRequest(*changeRequest, CHANGE_REQUEST_STATUS_PENDING)
+	if err != nil {
+		return nil, err
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Change request rejected %+v", updatedChangeRequest))
+	return updatedChangeRequest, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Returns true if the mutation matches any approval rule. Replays of approved change requests never need approval.
+func (api WorkerAPI) needsApproval(requestInfo RequestInfo, org string, statements []Statement) bool {
+	if len(requestInfo.approvedChangeRequest) > 0 {
+		return false
+	}
+	for _, protectedOrg := range api.ApprovalRules.ProtectedOrgs {
+		if org == protectedOrg {
+			return true
+		}
+	}
+	for _, statement := range statements {
+		if statement.Effect != "allow" {
+			continue
+		}
+		for _, action := range api.ApprovalRules.Actions {
+			for _, statementAction := range statement.Actions {
+				if isActionOverlapped(action, statementAction) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Returns true if some action matches both action patterns, e.g. "iam:*" and "iam:Create*"
+func isActionOverlapped(action string, otherAction string) bool {
+	return isActionContained(action, []string{otherAction}) || isActionContained(otherAction, []string{action})
+}
+
+// Store the mutation as a pending change request. It returns the error that informs the user about it.
+// Dry runs return the same error without storing anything
+func (api WorkerAPI) submitChangeRequest(requestInfo RequestInfo, org string, operation string, payload ChangeRequestPayload) error {
+	changeRequest := createChangeRequest(requestInfo, org, operation, payload, api.ApprovalRules.TTL)
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return &Error{
+			Code:    CHANGE_REQUEST_PENDING,
+			Message: fmt.Sprintf("Operation %v needs approval, a change request would be pending until approved", operation),
+		}
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, CHANGE_REQUEST_ACTION_CREATE_CHANGE_REQUEST, changeRequest.Urn, nil, &changeRequest))
+	if err != nil {
+		return err
+	}
+
+	// Store change request
+	createdChangeRequest, err := changeAPI.ChangeRequestRepo.AddChangeRequest(changeRequest)
+
+	// Check unexpected DB error
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Change request created %+v", createdChangeRequest))
+	return &Error{
+		Code: CHANGE_REQUEST_PENDING,
+		Message: fmt.Sprintf("Operation %v needs approval, change request %v is pending until %v",
+			operation, createdChangeRequest.ID, createdChangeRequest.ExpiresAt.Format(time.RFC3339)),
+	}
+}
+
+// Retrieve a change request that the user can review with the action
+func (api WorkerAPI) getChangeRequestToReview(requestInfo RequestInfo, org string, id string, comment string, action string) (*ChangeRequest, error) {
+	// Validate fields
+	if len(comment) > MAX_COMMENT_LENGTH {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: comment %v", comment),
+		}
+	}
+
+	// Call repo to retrieve the change request
+	changeRequest, err := api.GetChangeRequest(requestInfo, org, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check restrictions
+	changeRequestsFiltered, err := api.GetAuthorizedChangeRequests(requestInfo, changeRequest.Urn, action, []ChangeRequest{*changeRequest})
+	if err != nil {
+		return nil, err
+	}
+	if len(changeRequestsFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, changeRequest.Urn),
+		}
+	}
+
+	// Requester can't review its own change request
+	if changeRequest.Requester == requestInfo.Identifier {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to review its own change request %v",
+				requestInfo.Identifier, changeRequest.ID),
+		}
+	}
+
+	// Check status
+	if changeRequest.Status != CHANGE_REQUEST_STATUS_PENDING {
+		return nil, &Error{
+			Code:    CHANGE_REQUEST_NOT_PENDING,
+			Message: fmt.Sprintf("Change request %v is %v", changeRequest.ID, changeRequest.Status),
+		}
+	}
+	if !changeRequest.ExpiresAt.After(time.Now().UTC()) {
+		if !requestInfo.DryRun {
+			changeRequest.Status = CHANGE_REQUEST_STATUS_EXPIRED
+			if _, err := api.updateChangeRequest(*changeRequest, CHANGE_REQUEST_STATUS_PENDING); err != nil {
+				return nil, err
+			}
+		}
+		return nil, &Error{
+			Code: CHANGE_REQUEST_NOT_PENDING,
+			Message: fmt.Sprintf("Change request %v expired at %v", changeRequest.ID,
+				changeRequest.ExpiresAt.Format(time.RFC3339)),
+		}
+	}
+
+	return changeRequest, nil
+}
+
+// Update a change request only if it still has the given status
+func (api WorkerAPI) updateChangeRequest(changeRequest ChangeRequest, status string) (*ChangeRequest, error) {
+	updatedChangeRequest, err := api.ChangeRequestRepo.UpdateChangeRequest(changeRequest, status)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		// Change request has been reviewed by another request
+		case database.CHANGE_REQUEST_STATUS_CHANGED:
+			return nil, &Error{
+				Code:    CHANGE_REQUEST_NOT_PENDING,
+				Message: fmt.Sprintf("Change request %v is no longer %v", changeRequest.ID, status),
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	}
+
+	return updatedChangeRequest, nil
+}
+
+// Apply the proposed operation on behalf of the requester
+func (api WorkerAPI) replayChangeRequest(requestInfo RequestInfo, changeRequest *ChangeRequest) error {
+	replayRequestInfo := RequestInfo{
+		Identifier:            changeRequest.Requester,
+		Admin:                 changeRequest.RequesterAdmin,
+		RequestID:             requestInfo.RequestID,
+		approvedChangeRequest: changeRequest.ID,
+		Span:                  requestInfo.Span,
+	}
+
+	payload := changeRequest.Payload
+	switch changeRequest.Operation {
+	case CHANGE_REQUEST_OPERATION_UPDATE_POLICY:
+		statements := []Statement{}
+		if payload.Statements != nil {
+			statements = *payload.Statements
+		}
+		_, err := api.UpdatePolicy(replayRequestInfo, changeRequest.Org, payload.PolicyName, payload.NewName, payload.NewPath, statements)
+		return err
+	case CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP:
+		return api.AttachPolicyToGroup(replayRequestInfo, changeRequest.Org, payload.GroupName, payload.PolicyName)
+	case CHANGE_REQUEST_OPERATION_UPDATE_POLICY_TEMPLATE:
+		statements := []Statement{}
+		if payload.Statements != nil {
+			statements = *payload.Statements
+		}
+		_, err := api.UpdatePolicyTemplate(replayRequestInfo, changeRequest.Org, payload.TemplateName, payload.NewName, payload.NewPath,
+			payload.Parameters, statements)
+		return err
+	default:
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: fmt.Sprintf("Unexpected operation %v in change request %v", changeRequest.Operation, changeRequest.ID),
+		}
+	}
+}
+
+func createChangeRequest(requestInfo RequestInfo, org string, operation string, payload ChangeRequestPayload, ttl time.Duration) ChangeRequest {
+	if ttl <= 0 {
+		ttl = DEFAULT_CHANGE_REQUEST_TTL
+	}
+	id := uuid.NewV4().String()
+	createAt := time.Now().UTC()
+	urn := CreateUrn(org, RESOURCE_CHANGE_REQUEST, "/", id)
+
+	return ChangeRequest{
+		ID:             id,
+		Org:            org,
+		Operation:      operation,
+		Payload:        payload,
+		Status:         CHANGE_REQUEST_STATUS_PENDING,
+		Requester:      requestInfo.Identifier,
+		RequesterAdmin: requestInfo.Admin,
+		Urn:            urn,
+		CreateAt:       createAt,
+		ExpiresAt:      createAt.Add(ttl),
+	}
+}
@@ -0,0 +1,1083 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+const changeRequestID = "01234567-89ab-cdef-0123-456789abcdef"
+
+func TestWorkerAPI_ApprovalRules(t *testing.T) {
+	expiresAt := time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)
+	iamStatements := []Statement{
+		{
+			Effect:    "allow",
+			Actions:   []string{"iam:*"},
+			Resources: []string{GetUrnPrefix("", RESOURCE_USER, "/path/")},
+		},
+	}
+	readStatements := []Statement{
+		{
+			Effect:    "allow",
+			Actions:   []string{USER_ACTION_GET_USER},
+			Resources: []string{GetUrnPrefix("", RESOURCE_USER, "/path/")},
+		},
+	}
+	templateParameters := []PolicyTemplateParameter{
+		{
+			Name: "team",
+		},
+	}
+	templateStatements := []Statement{
+		{
+			Effect:    "allow",
+			Actions:   []string{USER_ACTION_CREATE_USER},
+			Resources: []string{"urn:iws:iam::user/{{team}}/*"},
+		},
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		rules       ApprovalRules
+		call        func(api *WorkerAPI, requestInfo RequestInfo) error
+		// Expected result
+		wantError             error
+		expectedChangeRequest *ChangeRequest
+		// Manager Results
+		getPolicyByNameResult            *Policy
+		getPolicyTemplateInstancesResult []PolicyTemplateInstance
+		// Manager Errors
+		addChangeRequestErr error
+	}{
+		"OkCaseUpdatePolicyGrantingIamActions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			rules: ApprovalRules{
+				Actions: []string{"iam:*"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				_, err := api.UpdatePolicy(requestInfo, "org1", "policy1", "policy1", "/path/", iamStatements)
+				return err
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_PENDING,
+				Message: "Operation UpdatePolicy needs approval, change request " + changeRequestID + " is pending until 2016-01-01T10:00:00Z",
+			},
+			expectedChangeRequest: &ChangeRequest{
+				Org:       "org1",
+				Operation: CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+				Payload: ChangeRequestPayload{
+					PolicyName: "policy1",
+					NewName:    "policy1",
+					NewPath:    "/path/",
+					Statements: &iamStatements,
+				},
+				Status:         CHANGE_REQUEST_STATUS_PENDING,
+				Requester:      "123456",
+				RequesterAdmin: true,
+			},
+		},
+		"OkCaseAttachPolicyInProtectedOrg": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			rules: ApprovalRules{
+				ProtectedOrgs: []string{"org1"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				return api.AttachPolicyToGroup(requestInfo, "org1", "group1", "policy1")
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_PENDING,
+				Message: "Operation AttachPolicyToGroup needs approval, change request " + changeRequestID + " is pending until 2016-01-01T10:00:00Z",
+			},
+			expectedChangeRequest: &ChangeRequest{
+				Org:       "org1",
+				Operation: CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:         CHANGE_REQUEST_STATUS_PENDING,
+				Requester:      "123456",
+				RequesterAdmin: true,
+			},
+		},
+		"OkCaseAttachPolicyGrantingActionMatchedByRule": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			rules: ApprovalRules{
+				Actions: []string{"iam:Get*"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				return api.AttachPolicyToGroup(requestInfo, "org1", "group1", "policy1")
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_PENDING,
+				Message: "Operation AttachPolicyToGroup needs approval, change request " + changeRequestID + " is pending until 2016-01-01T10:00:00Z",
+			},
+			expectedChangeRequest: &ChangeRequest{
+				Org:       "org1",
+				Operation: CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:         CHANGE_REQUEST_STATUS_PENDING,
+				Requester:      "123456",
+				RequesterAdmin: true,
+			},
+		},
+		"OkCaseUpdatePolicyTemplateRenderingIamActions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			rules: ApprovalRules{
+				Actions: []string{"iam:Create*"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				_, err := api.UpdatePolicyTemplate(requestInfo, "org1", "team", "team", "/path/", templateParameters, templateStatements)
+				return err
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+			getPolicyTemplateInstancesResult: []PolicyTemplateInstance{
+				{
+					TemplateID: "template1",
+					Policy: &Policy{
+						ID:         "POLICY-ID",
+						Name:       "policy1",
+						Org:        "org1",
+						Path:       "/path/",
+						Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+						Statements: &readStatements,
+					},
+					Parameters: map[string]string{
+						"team": "a",
+					},
+				},
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_PENDING,
+				Message: "Operation UpdatePolicyTemplate needs approval, change request " + changeRequestID + " is pending until 2016-01-01T10:00:00Z",
+			},
+			expectedChangeRequest: &ChangeRequest{
+				Org:       "org1",
+				Operation: CHANGE_REQUEST_OPERATION_UPDATE_POLICY_TEMPLATE,
+				Payload: ChangeRequestPayload{
+					TemplateName: "team",
+					NewName:      "team",
+					NewPath:      "/path/",
+					Parameters:   templateParameters,
+					Statements:   &templateStatements,
+				},
+				Status:         CHANGE_REQUEST_STATUS_PENDING,
+				Requester:      "123456",
+				RequesterAdmin: true,
+			},
+		},
+		"OkCaseUpdatePolicyDryRun": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+				DryRun:     true,
+			},
+			rules: ApprovalRules{
+				Actions: []string{"iam:*"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				_, err := api.UpdatePolicy(requestInfo, "org1", "policy1", "policy1", "/path/", iamStatements)
+				return err
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_PENDING,
+				Message: "Operation UpdatePolicy needs approval, a change request would be pending until approved",
+			},
+		},
+		"OkCaseAttachPolicyDryRun": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+				DryRun:     true,
+			},
+			rules: ApprovalRules{
+				ProtectedOrgs: []string{"org1"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				return api.AttachPolicyToGroup(requestInfo, "org1", "group1", "policy1")
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_PENDING,
+				Message: "Operation AttachPolicyToGroup needs approval, a change request would be pending until approved",
+			},
+		},
+		"OkCaseUpdatePolicyTemplateDryRun": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+				DryRun:     true,
+			},
+			rules: ApprovalRules{
+				Actions: []string{"iam:Create*"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				_, err := api.UpdatePolicyTemplate(requestInfo, "org1", "team", "team", "/path/", templateParameters, templateStatements)
+				return err
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+			getPolicyTemplateInstancesResult: []PolicyTemplateInstance{
+				{
+					TemplateID: "template1",
+					Policy: &Policy{
+						ID:         "POLICY-ID",
+						Name:       "policy1",
+						Org:        "org1",
+						Path:       "/path/",
+						Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+						Statements: &readStatements,
+					},
+					Parameters: map[string]string{
+						"team": "a",
+					},
+				},
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_PENDING,
+				Message: "Operation UpdatePolicyTemplate needs approval, a change request would be pending until approved",
+			},
+		},
+		"OkCaseAttachPolicyWithoutRulesMatched": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			rules: ApprovalRules{
+				Actions:       []string{"auth:*"},
+				ProtectedOrgs: []string{"org2"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				return api.AttachPolicyToGroup(requestInfo, "org1", "group1", "policy1")
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &readStatements,
+			},
+		},
+		"OkCaseReplayOfApprovedChangeRequest": {
+			requestInfo: RequestInfo{
+				Identifier:            "123456",
+				Admin:                 true,
+				approvedChangeRequest: changeRequestID,
+			},
+			rules: ApprovalRules{
+				ProtectedOrgs: []string{"org1"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				return api.AttachPolicyToGroup(requestInfo, "org1", "group1", "policy1")
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &iamStatements,
+			},
+		},
+		"ErrorCaseAddChangeRequestDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			rules: ApprovalRules{
+				Actions: []string{"iam:*"},
+			},
+			call: func(api *WorkerAPI, requestInfo RequestInfo) error {
+				return api.AttachPolicyToGroup(requestInfo, "org1", "group1", "policy1")
+			},
+			getPolicyByNameResult: &Policy{
+				ID:         "POLICY-ID",
+				Name:       "policy1",
+				Org:        "org1",
+				Path:       "/path/",
+				Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &iamStatements,
+			},
+			addChangeRequestErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.ApprovalRules = testcase.rules
+
+		testRepo.ArgsOut[GetGroupByNameMethod][0] = &Group{
+			ID:   "GROUP-ID",
+			Name: "group1",
+			Org:  "org1",
+			Path: "/path/",
+			Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "group1"),
+		}
+		testRepo.ArgsOut[IsAttachedToGroupMethod][0] = false
+		testRepo.ArgsOut[GetPolicyByNameMethod][0] = testcase.getPolicyByNameResult
+		testRepo.SpecialFuncs[GetPolicyByNameMethod] = func(org string, name string) (*Policy, error) {
+			if name != testcase.getPolicyByNameResult.Name {
+				return nil, &database.Error{
+					Code: database.POLICY_NOT_FOUND,
+				}
+			}
+			return testcase.getPolicyByNameResult, nil
+		}
+		testRepo.ArgsOut[UpdatePolicyMethod][0] = testcase.getPolicyByNameResult
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][0] = &PolicyTemplate{
+			ID:   "template1",
+			Name: "team",
+			Org:  "org1",
+			Path: "/path/",
+			Urn:  CreateUrn("org1", RESOURCE_POLICY_TEMPLATE, "/path/", "team"),
+		}
+		testRepo.ArgsOut[GetPolicyTemplateInstancesMethod][0] = testcase.getPolicyTemplateInstancesResult
+		testRepo.ArgsOut[AddChangeRequestMethod][0] = &ChangeRequest{
+			ID:        changeRequestID,
+			ExpiresAt: expiresAt,
+		}
+		testRepo.ArgsOut[AddChangeRequestMethod][1] = testcase.addChangeRequestErr
+
+		err := testcase.call(testAPI, testcase.requestInfo)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.expectedChangeRequest != nil {
+			// Check change request stored
+			stored, ok := testRepo.ArgsIn[AddChangeRequestMethod][0].(ChangeRequest)
+			assert.True(t, ok, "Error in test case %v", x)
+			assert.Equal(t, CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", stored.ID), stored.Urn, "Error in test case %v", x)
+			assert.Equal(t, DEFAULT_CHANGE_REQUEST_TTL, stored.ExpiresAt.Sub(stored.CreateAt), "Error in test case %v", x)
+			stored.ID, stored.Urn, stored.CreateAt, stored.ExpiresAt = "", "", time.Time{}, time.Time{}
+			assert.Equal(t, *testcase.expectedChangeRequest, stored, "Error in test case %v", x)
+			// Nothing is applied
+			assert.Nil(t, testRepo.ArgsIn[AttachPolicyMethod][0], "Error in test case %v", x)
+			assert.Nil(t, testRepo.ArgsIn[UpdatePolicyMethod][0], "Error in test case %v", x)
+			assert.Nil(t, testRepo.ArgsIn[UpdatePolicyTemplateMethod][0], "Error in test case %v", x)
+		} else if testcase.wantError == nil || testcase.requestInfo.DryRun {
+			assert.Nil(t, testRepo.ArgsIn[AddChangeRequestMethod][0], "Error in test case %v", x)
+		}
+		if testcase.requestInfo.DryRun {
+			// Nothing is applied
+			assert.Nil(t, testRepo.ArgsIn[AttachPolicyMethod][0], "Error in test case %v", x)
+			assert.Nil(t, testRepo.ArgsIn[UpdatePolicyMethod][0], "Error in test case %v", x)
+			assert.Nil(t, testRepo.ArgsIn[UpdatePolicyTemplateMethod][0], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_GetChangeRequest(t *testing.T) {
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		org         string
+		id          string
+		// Expected result
+		expectedChangeRequest *ChangeRequest
+		wantError             error
+		// Manager Results
+		getChangeRequestByIDResult *ChangeRequest
+		getUserByExternalIDResult  *User
+		// Manager Errors
+		getChangeRequestByIDErr error
+		getUserByExternalIDErr  error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org: "org1",
+			id:  changeRequestID,
+			getChangeRequestByIDResult: &ChangeRequest{
+				ID:        changeRequestID,
+				Org:       "org1",
+				Operation: CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Status:    CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+			},
+			expectedChangeRequest: &ChangeRequest{
+				ID:        changeRequestID,
+				Org:       "org1",
+				Operation: CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Status:    CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+			},
+		},
+		"ErrorCaseInvalidOrg": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org: "!*^**~$%&/()",
+			id:  changeRequestID,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: org !*^**~$%&/()",
+			},
+		},
+		"ErrorCaseInvalidID": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org: "org1",
+			id:  "invalid",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: id invalid",
+			},
+		},
+		"ErrorCaseNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org: "org1",
+			id:  changeRequestID,
+			getChangeRequestByIDErr: &database.Error{
+				Code:    database.CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org: "org1",
+			id:  changeRequestID,
+			getChangeRequestByIDErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			org: "org1",
+			id:  changeRequestID,
+			getChangeRequestByIDResult: &ChangeRequest{
+				ID:  changeRequestID,
+				Org: "org1",
+				Urn: CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource " +
+					CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetChangeRequestByIDMethod][0] = testcase.getChangeRequestByIDResult
+		testRepo.ArgsOut[GetChangeRequestByIDMethod][1] = testcase.getChangeRequestByIDErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = testcase.getUserByExternalIDErr
+
+		changeRequest, err := testAPI.GetChangeRequest(testcase.requestInfo, testcase.org, testcase.id)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedChangeRequest, changeRequest)
+	}
+}
+
+func TestWorkerAPI_ListChangeRequests(t *testing.T) {
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		filter      *Filter
+		// Expected result
+		expectedChangeRequests []ChangeRequest
+		totalResult            int
+		wantError              error
+		// Manager Results
+		getGroupsByUserIDResult         []TestUserGroupRelation
+		getAttachedPoliciesResult       []TestPolicyGroupRelation
+		getUserByExternalIDResult       *User
+		getChangeRequestsFilteredResult []ChangeRequest
+		// Manager Errors
+		expireChangeRequestsErr      error
+		getChangeRequestsFilteredErr error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:    "org1",
+				Status: CHANGE_REQUEST_STATUS_PENDING,
+			},
+			expectedChangeRequests: []ChangeRequest{
+				{
+					ID:     "cr1",
+					Org:    "org1",
+					Status: CHANGE_REQUEST_STATUS_PENDING,
+					Urn:    CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", "cr1"),
+				},
+			},
+			totalResult: 1,
+			getChangeRequestsFilteredResult: []ChangeRequest{
+				{
+					ID:     "cr1",
+					Org:    "org1",
+					Status: CHANGE_REQUEST_STATUS_PENDING,
+					Urn:    CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", "cr1"),
+				},
+			},
+		},
+		"OkCaseUser": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{},
+			expectedChangeRequests: []ChangeRequest{
+				{
+					ID:  "cr1",
+					Org: "org1",
+					Urn: CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", "cr1"),
+				},
+			},
+			totalResult: 2,
+			getChangeRequestsFilteredResult: []ChangeRequest{
+				{
+					ID:  "cr1",
+					Org: "org1",
+					Urn: CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", "cr1"),
+				},
+				{
+					ID:  "cr2",
+					Org: "org2",
+					Urn: CreateUrn("org2", RESOURCE_CHANGE_REQUEST, "/", "cr2"),
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GROUP-USER-ID",
+						Name: "groupUser",
+						Path: "/path/1/",
+						Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									CHANGE_REQUEST_ACTION_LIST_CHANGE_REQUESTS,
+								},
+								Resources: []string{
+									GetUrnPrefix("org1", RESOURCE_CHANGE_REQUEST, "/"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseInvalidStatus": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Status: "unknown",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: status unknown",
+			},
+		},
+		"ErrorCaseExpireChangeRequestsDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{},
+			expireChangeRequestsErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseInternalErrorChangeRequestsFiltered": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{},
+			getChangeRequestsFilteredErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[ExpireChangeRequestsMethod][0] = testcase.expireChangeRequestsErr
+		testRepo.ArgsOut[GetChangeRequestsFilteredMethod][0] = testcase.getChangeRequestsFilteredResult
+		testRepo.ArgsOut[GetChangeRequestsFilteredMethod][1] = testcase.totalResult
+		testRepo.ArgsOut[GetChangeRequestsFilteredMethod][2] = testcase.getChangeRequestsFilteredErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		changeRequests, total, err := testAPI.ListChangeRequests(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedChangeRequests, changeRequests)
+		assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+	}
+}
+
+func TestWorkerAPI_ApproveChangeRequest(t *testing.T) {
+	now := time.Now().UTC()
+	pendingChangeRequest := &ChangeRequest{
+		ID:        changeRequestID,
+		Org:       "org1",
+		Operation: CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+		Payload: ChangeRequestPayload{
+			GroupName:  "group1",
+			PolicyName: "policy1",
+		},
+		Status:         CHANGE_REQUEST_STATUS_PENDING,
+		Requester:      "requester",
+		RequesterAdmin: true,
+		Urn:            CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+		CreateAt:       now,
+		ExpiresAt:      now.Add(time.Hour),
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		comment     string
+		// Expected result
+		expectedStatus string
+		wantError      error
+		// Manager Results
+		getChangeRequestByIDResult *ChangeRequest
+		isAttachedToGroupResult    bool
+		// Manager Errors
+		attachPolicyErr        error
+		updateChangeRequestErr error
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			comment:                    " Looks good ",
+			getChangeRequestByIDResult: pendingChangeRequest,
+			expectedStatus:             CHANGE_REQUEST_STATUS_APPROVED,
+		},
+		"OkCaseDryRun": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+				DryRun:     true,
+			},
+			comment:                    "Looks good",
+			getChangeRequestByIDResult: pendingChangeRequest,
+			expectedStatus:             CHANGE_REQUEST_STATUS_APPROVED,
+		},
+		"ErrorCaseCommentTooLong": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			comment: strings.Repeat("a", MAX_COMMENT_LENGTH+1),
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: comment " + strings.Repeat("a", MAX_COMMENT_LENGTH+1),
+			},
+		},
+		"ErrorCaseOwnChangeRequest": {
+			requestInfo: RequestInfo{
+				Identifier: "requester",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: pendingChangeRequest,
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId requester is not allowed to review its own change request " + changeRequestID,
+			},
+		},
+		"ErrorCaseAlreadyReviewed": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: &ChangeRequest{
+				ID:        changeRequestID,
+				Org:       "org1",
+				Status:    CHANGE_REQUEST_STATUS_REJECTED,
+				Requester: "requester",
+				Urn:       CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_NOT_PENDING,
+				Message: "Change request " + changeRequestID + " is rejected",
+			},
+		},
+		"ErrorCaseExpired": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: &ChangeRequest{
+				ID:        changeRequestID,
+				Org:       "org1",
+				Status:    CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "requester",
+				Urn:       CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+				ExpiresAt: time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC),
+			},
+			expectedStatus: CHANGE_REQUEST_STATUS_EXPIRED,
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_NOT_PENDING,
+				Message: "Change request " + changeRequestID + " expired at 2016-01-01T10:00:00Z",
+			},
+		},
+		"ErrorCaseReplayFails": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: pendingChangeRequest,
+			isAttachedToGroupResult:    true,
+			expectedStatus:             CHANGE_REQUEST_STATUS_PENDING,
+			wantError: &Error{
+				Code:    POLICY_IS_ALREADY_ATTACHED_TO_GROUP,
+				Message: "Policy: policy1 is already attached to Group: group1",
+			},
+		},
+		"ErrorCaseReviewedConcurrently": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: pendingChangeRequest,
+			updateChangeRequestErr: &database.Error{
+				Code:    database.CHANGE_REQUEST_STATUS_CHANGED,
+				Message: "Error",
+			},
+			expectedStatus: CHANGE_REQUEST_STATUS_APPROVED,
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_NOT_PENDING,
+				Message: "Change request " + changeRequestID + " is no longer pending",
+			},
+		},
+		"ErrorCaseUpdateChangeRequestDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: pendingChangeRequest,
+			updateChangeRequestErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			expectedStatus: CHANGE_REQUEST_STATUS_APPROVED,
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.ApprovalRules = ApprovalRules{
+			ProtectedOrgs: []string{"org1"},
+		}
+
+		testRepo.ArgsOut[GetChangeRequestByIDMethod][0] = testcase.getChangeRequestByIDResult
+		testRepo.ArgsOut[GetGroupByNameMethod][0] = &Group{
+			ID:   "GROUP-ID",
+			Name: "group1",
+			Org:  "org1",
+			Path: "/path/",
+			Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "group1"),
+		}
+		testRepo.ArgsOut[GetPolicyByNameMethod][0] = &Policy{
+			ID:         "POLICY-ID",
+			Name:       "policy1",
+			Org:        "org1",
+			Path:       "/path/",
+			Urn:        CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+			Statements: &[]Statement{},
+		}
+		testRepo.ArgsOut[IsAttachedToGroupMethod][0] = testcase.isAttachedToGroupResult
+		testRepo.ArgsOut[AttachPolicyMethod][0] = testcase.attachPolicyErr
+		testRepo.ArgsOut[UpdateChangeRequestMethod][1] = testcase.updateChangeRequestErr
+		if testcase.updateChangeRequestErr == nil && testcase.getChangeRequestByIDResult != nil {
+			updated := *testcase.getChangeRequestByIDResult
+			updated.Status = testcase.expectedStatus
+			updated.Reviewer = testcase.requestInfo.Identifier
+			updated.Comment = strings.TrimSpace(testcase.comment)
+			testRepo.ArgsOut[UpdateChangeRequestMethod][0] = &updated
+		}
+
+		changeRequest, err := testAPI.ApproveChangeRequest(testcase.requestInfo, "org1", changeRequestID, testcase.comment)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		updated, _ := testRepo.ArgsIn[UpdateChangeRequestMethod][0].(ChangeRequest)
+		if testcase.wantError == nil {
+			assert.Equal(t, CHANGE_REQUEST_STATUS_APPROVED, changeRequest.Status, "Error in test case %v", x)
+			assert.Equal(t, testcase.requestInfo.Identifier, changeRequest.Reviewer, "Error in test case %v", x)
+			assert.Equal(t, "Looks good", changeRequest.Comment, "Error in test case %v", x)
+			if testcase.requestInfo.DryRun {
+				// Nothing is applied
+				assert.Nil(t, testRepo.ArgsIn[AttachPolicyMethod][0], "Error in test case %v", x)
+				assert.Nil(t, testRepo.ArgsIn[UpdateChangeRequestMethod][0], "Error in test case %v", x)
+			} else {
+				// Change request is claimed and operation replayed
+				assert.Equal(t, "GROUP-ID", testRepo.ArgsIn[AttachPolicyMethod][0], "Error in test case %v", x)
+				assert.Equal(t, "POLICY-ID", testRepo.ArgsIn[AttachPolicyMethod][1], "Error in test case %v", x)
+				assert.Equal(t, CHANGE_REQUEST_STATUS_PENDING, testRepo.ArgsIn[UpdateChangeRequestMethod][1], "Error in test case %v", x)
+				assert.Equal(t, CHANGE_REQUEST_STATUS_APPROVED, updated.Status, "Error in test case %v", x)
+				assert.Equal(t, "reviewer", updated.Reviewer, "Error in test case %v", x)
+				assert.False(t, updated.ReviewAt.IsZero(), "Error in test case %v", x)
+				// Approval is stored with the replayed change
+				events := testRepo.ArgsIn[WithEventsMethod][0].([]AuditEvent)
+				if assert.Len(t, events, 2, "Error in test case %v", x) {
+					assert.Equal(t, CHANGE_REQUEST_ACTION_APPROVE_CHANGE_REQUEST, events[0].Action, "Error in test case %v", x)
+					assert.Equal(t, pendingChangeRequest.Urn, events[0].EntityUrn, "Error in test case %v", x)
+					assert.Equal(t, GROUP_ACTION_ATTACH_GROUP_POLICY, events[1].Action, "Error in test case %v", x)
+				}
+			}
+		} else {
+			// Change request is only updated when it expires, when it's claimed or when the replay fails
+			assert.Equal(t, testcase.expectedStatus, updated.Status, "Error in test case %v", x)
+			if testcase.updateChangeRequestErr != nil {
+				// Operation isn't replayed if change request can't be claimed
+				assert.Nil(t, testRepo.ArgsIn[AttachPolicyMethod][0], "Error in test case %v", x)
+			}
+		}
+	}
+}
+
+func TestWorkerAPI_RejectChangeRequest(t *testing.T) {
+	now := time.Now().UTC()
+	pendingChangeRequest := &ChangeRequest{
+		ID:        changeRequestID,
+		Org:       "org1",
+		Operation: CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+		Payload: ChangeRequestPayload{
+			GroupName:  "group1",
+			PolicyName: "policy1",
+		},
+		Status:    CHANGE_REQUEST_STATUS_PENDING,
+		Requester: "requester",
+		Urn:       CreateUrn("org1", RESOURCE_CHANGE_REQUEST, "/", changeRequestID),
+		CreateAt:  now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		comment     string
+		// Expected result
+		wantError error
+		// Manager Results
+		getChangeRequestByIDResult *ChangeRequest
+		// Manager Errors
+		getChangeRequestByIDErr error
+		updateChangeRequestErr  error
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			comment:                    "Too broad",
+			getChangeRequestByIDResult: pendingChangeRequest,
+		},
+		"ErrorCaseNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			getChangeRequestByIDErr: &database.Error{
+				Code:    database.CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+			wantError: &Error{
+				Code:    CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+		},
+		"ErrorCaseOwnChangeRequest": {
+			requestInfo: RequestInfo{
+				Identifier: "requester",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: pendingChangeRequest,
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId requester is not allowed to review its own change request " + changeRequestID,
+			},
+		},
+		"ErrorCaseUpdateChangeRequestDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "reviewer",
+				Admin:      true,
+			},
+			getChangeRequestByIDResult: pendingChangeRequest,
+			updateChangeRequestErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetChangeRequestByIDMethod][0] = testcase.getChangeRequestByIDResult
+		testRepo.ArgsOut[GetChangeRequestByIDMethod][1] = testcase.getChangeRequestByIDErr
+		testRepo.ArgsOut[UpdateChangeRequestMethod][1] = testcase.updateChangeRequestErr
+		if testcase.getChangeRequestByIDResult != nil {
+			rejected := *testcase.getChangeRequestByIDResult
+			rejected.Status = CHANGE_REQUEST_STATUS_REJECTED
+			testRepo.ArgsOut[UpdateChangeRequestMethod][0] = &rejected
+		}
+
+		changeRequest, err := testAPI.RejectChangeRequest(testcase.requestInfo, "org1", changeRequestID, testcase.comment)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, CHANGE_REQUEST_STATUS_REJECTED, changeRequest.Status, "Error in test case %v", x)
+			updated, _ := testRepo.ArgsIn[UpdateChangeRequestMethod][0].(ChangeRequest)
+			assert.Equal(t, CHANGE_REQUEST_STATUS_REJECTED, updated.Status, "Error in test case %v", x)
+			assert.Equal(t, "reviewer", updated.Reviewer, "Error in test case %v", x)
+			assert.Equal(t, testcase.comment, updated.Comment, "Error in test case %v", x)
+			// Operation isn't applied
+			assert.Nil(t, testRepo.ArgsIn[AttachPolicyMethod][0], "Error in test case %v", x)
+		}
+	}
+}
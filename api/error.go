@@ -31,6 +31,10 @@ const (
 	POLICY_ALREADY_EXIST             = "PolicyAlreadyExist"
 	POLICY_BY_ORG_AND_NAME_NOT_FOUND = "PolicyWithOrgAndNameNotFound"
 
+	// Policy template API error codes
+	POLICY_TEMPLATE_ALREADY_EXIST             = "PolicyTemplateAlreadyExist"
+	POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND = "PolicyTemplateWithOrgAndNameNotFound"
+
 	// Proxy resources API error codes
 	PROXY_RESOURCE_ALREADY_EXIST             = "ProxyResourceAlreadyExist"
 	PROXY_RESOURCE_BY_ORG_AND_NAME_NOT_FOUND = "ProxyResourceWithOrgAndNameNotFound"
@@ -40,6 +44,19 @@ const (
 	AUTH_OIDC_PROVIDER_ALREADY_EXIST     = "AuthOidcProviderAlreadyExist"
 	AUTH_OIDC_PROVIDER_BY_NAME_NOT_FOUND = "AuthOidcProviderWithNameNotFound"
 
+	// Break-glass API error codes
+	BREAK_GLASS_SESSION_ALREADY_ACTIVE = "BreakGlassSessionAlreadyActive"
+	BREAK_GLASS_SESSION_NOT_FOUND      = "BreakGlassSessionNotFound"
+
+	// Change request API error codes
+	CHANGE_REQUEST_PENDING     = "ChangeRequestPending"
+	CHANGE_REQUEST_NOT_FOUND   = "ChangeRequestNotFound"
+	CHANGE_REQUEST_NOT_PENDING = "ChangeRequestNotPending"
+
+	// Webhook API error codes
+	WEBHOOK_ALREADY_EXIST     = "WebhookAlreadyExist"
+	WEBHOOK_BY_NAME_NOT_FOUND = "WebhookWithNameNotFound"
+
 	// Regex error
 	REGEX_NO_MATCH = "RegexNoMatch"
 )
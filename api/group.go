@@ -49,6 +49,9 @@ type GroupPolicies struct {
 // GROUP API IMPLEMENTATION
 
 func (api WorkerAPI) AddGroup(requestInfo RequestInfo, org string, name string, path string) (*Group, error) {
+	api, span := api.startSpan(requestInfo, "AddGroup")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -94,8 +97,18 @@ func (api WorkerAPI) AddGroup(requestInfo RequestInfo, org string, name string,
 		switch dbError.Code {
 		// Group doesn't exist in DB, so we can create it
 		case database.GROUP_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &group, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, GROUP_ACTION_CREATE_GROUP, group.Urn, nil, &group))
+			if err != nil {
+				return nil, err
+			}
+
 			// Create group
-			createdGroup, err := api.GroupRepo.AddGroup(group)
+			createdGroup, err := changeAPI.GroupRepo.AddGroup(group)
 
 			// Check if there is an unexpected error in DB
 			if err != nil {
@@ -124,6 +137,9 @@ func (api WorkerAPI) AddGroup(requestInfo RequestInfo, org string, name string,
 }
 
 func (api WorkerAPI) GetGroupByName(requestInfo RequestInfo, org string, name string) (*Group, error) {
+	api, span := api.startSpan(requestInfo, "GetGroupByName")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -179,6 +195,9 @@ func (api WorkerAPI) GetGroupByName(requestInfo RequestInfo, org string, name st
 }
 
 func (api WorkerAPI) ListGroups(requestInfo RequestInfo, filter *Filter) ([]GroupIdentity, int, error) {
+	api, span := api.startSpan(requestInfo, "ListGroups")
+	defer span.Finish()
+
 	// Validate fields
 	var total int
 	orderByValidColumns := api.GroupRepo.OrderByValidColumns(GROUP_ACTION_LIST_GROUPS)
@@ -225,6 +244,9 @@ func (api WorkerAPI) ListGroups(requestInfo RequestInfo, filter *Filter) ([]Grou
 }
 
 func (api WorkerAPI) UpdateGroup(requestInfo RequestInfo, org string, name string, newName string, newPath string) (*Group, error) {
+	api, span := api.startSpan(requestInfo, "UpdateGroup")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(newName) {
 		return nil, &Error{
@@ -303,7 +325,17 @@ func (api WorkerAPI) UpdateGroup(requestInfo RequestInfo, org string, name strin
 		UpdateAt: time.Now().UTC(),
 	}
 
-	updatedGroup, err := api.GroupRepo.UpdateGroup(group)
+	// Check dry run
+	if requestInfo.DryRun {
+		return &group, nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, GROUP_ACTION_UPDATE_GROUP, group.Urn, oldGroup, &group))
+	if err != nil {
+		return nil, err
+	}
+
+	updatedGroup, err := changeAPI.GroupRepo.UpdateGroup(group)
 
 	// Check unexpected DB error
 	if err != nil {
@@ -321,6 +353,9 @@ func (api WorkerAPI) UpdateGroup(requestInfo RequestInfo, org string, name strin
 }
 
 func (api WorkerAPI) RemoveGroup(requestInfo RequestInfo, org string, name string) error {
+	api, span := api.startSpan(requestInfo, "RemoveGroup")
+	defer span.Finish()
+
 	// Call repo to retrieve the group
 	group, err := api.GetGroupByName(requestInfo, org, name)
 	if err != nil {
@@ -340,7 +375,17 @@ func (api WorkerAPI) RemoveGroup(requestInfo RequestInfo, org string, name strin
 		}
 	}
 
-	err = api.GroupRepo.RemoveGroup(group.ID)
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, GROUP_ACTION_DELETE_GROUP, group.Urn, group, nil))
+	if err != nil {
+		return err
+	}
+
+	err = changeAPI.GroupRepo.RemoveGroup(group.ID)
 
 	// Error handling
 	if err != nil {
@@ -357,6 +402,9 @@ func (api WorkerAPI) RemoveGroup(requestInfo RequestInfo, org string, name strin
 }
 
 func (api WorkerAPI) AddMember(requestInfo RequestInfo, externalId string, name string, org string) error {
+	api, span := api.startSpan(requestInfo, "AddMember")
+	defer span.Finish()
+
 	// Call repo to retrieve the group
 	groupDB, err := api.GetGroupByName(requestInfo, org, name)
 	if err != nil {
@@ -401,8 +449,18 @@ func (api WorkerAPI) AddMember(requestInfo RequestInfo, externalId string, name
 		}
 	}
 
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, GROUP_ACTION_ADD_MEMBER, groupDB.Urn, nil, userDB))
+	if err != nil {
+		return err
+	}
+
 	// Add Member
-	err = api.GroupRepo.AddMember(userDB.ID, groupDB.ID)
+	err = changeAPI.GroupRepo.AddMember(userDB.ID, groupDB.ID)
 
 	// Check if there is an unexpected error in DB
 	if err != nil {
@@ -418,6 +476,9 @@ func (api WorkerAPI) AddMember(requestInfo RequestInfo, externalId string, name
 }
 
 func (api WorkerAPI) RemoveMember(requestInfo RequestInfo, externalId string, name string, org string) error {
+	api, span := api.startSpan(requestInfo, "RemoveMember")
+	defer span.Finish()
+
 	// Call repo to retrieve the group
 	groupDB, err := api.GetGroupByName(requestInfo, org, name)
 	if err != nil {
@@ -462,8 +523,18 @@ func (api WorkerAPI) RemoveMember(requestInfo RequestInfo, externalId string, na
 		}
 	}
 
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, GROUP_ACTION_REMOVE_MEMBER, groupDB.Urn, userDB, nil))
+	if err != nil {
+		return err
+	}
+
 	// Remove Member
-	err = api.GroupRepo.RemoveMember(userDB.ID, groupDB.ID)
+	err = changeAPI.GroupRepo.RemoveMember(userDB.ID, groupDB.ID)
 
 	// Check if there is an unexpected error in DB
 	if err != nil {
@@ -480,6 +551,9 @@ func (api WorkerAPI) RemoveMember(requestInfo RequestInfo, externalId string, na
 }
 
 func (api WorkerAPI) ListMembers(requestInfo RequestInfo, filter *Filter) ([]GroupMembers, int, error) {
+	api, span := api.startSpan(requestInfo, "ListMembers")
+	defer span.Finish()
+
 	// Validate fields
 	var total int
 	orderByValidColumns := api.UserRepo.OrderByValidColumns(GROUP_ACTION_LIST_MEMBERS)
@@ -535,6 +609,8 @@ func (api WorkerAPI) ListMembers(requestInfo RequestInfo, filter *Filter) ([]Gro
 }
 
 func (api WorkerAPI) AttachPolicyToGroup(requestInfo RequestInfo, org string, name string, policyName string) error {
+	api, span := api.startSpan(requestInfo, "AttachPolicyToGroup")
+	defer span.Finish()
 
 	// Check if group exists
 	group, err := api.GetGroupByName(requestInfo, org, name)
@@ -579,8 +655,30 @@ func (api WorkerAPI) AttachPolicyToGroup(requestInfo RequestInfo, org string, na
 		}
 	}
 
+	// Check approval rules, dry runs report the change request too
+	var statements []Statement
+	if policy.Statements != nil {
+		statements = *policy.Statements
+	}
+	if api.needsApproval(requestInfo, org, statements) {
+		return api.submitChangeRequest(requestInfo, org, CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP, ChangeRequestPayload{
+			GroupName:  name,
+			PolicyName: policyName,
+		})
+	}
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, GROUP_ACTION_ATTACH_GROUP_POLICY, group.Urn, nil, policy))
+	if err != nil {
+		return err
+	}
+
 	// Attach Policy to Group
-	err = api.GroupRepo.AttachPolicy(group.ID, policy.ID)
+	err = changeAPI.GroupRepo.AttachPolicy(group.ID, policy.ID)
 
 	if err != nil {
 		dbError := err.(*database.Error)
@@ -595,6 +693,8 @@ func (api WorkerAPI) AttachPolicyToGroup(requestInfo RequestInfo, org string, na
 }
 
 func (api WorkerAPI) DetachPolicyToGroup(requestInfo RequestInfo, org string, name string, policyName string) error {
+	api, span := api.startSpan(requestInfo, "DetachPolicyToGroup")
+	defer span.Finish()
 
 	// Check if group exists
 	group, err := api.GetGroupByName(requestInfo, org, name)
@@ -640,8 +740,18 @@ func (api WorkerAPI) DetachPolicyToGroup(requestInfo RequestInfo, org string, na
 
 	}
 
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, GROUP_ACTION_DETACH_GROUP_POLICY, group.Urn, policy, nil))
+	if err != nil {
+		return err
+	}
+
 	// Detach Policy to Group
-	err = api.GroupRepo.DetachPolicy(group.ID, policy.ID)
+	err = changeAPI.GroupRepo.DetachPolicy(group.ID, policy.ID)
 
 	if err != nil {
 		dbError := err.(*database.Error)
@@ -656,6 +766,9 @@ func (api WorkerAPI) DetachPolicyToGroup(requestInfo RequestInfo, org string, na
 }
 
 func (api WorkerAPI) ListAttachedGroupPolicies(requestInfo RequestInfo, filter *Filter) ([]GroupPolicies, int, error) {
+	api, span := api.startSpan(requestInfo, "ListAttachedGroupPolicies")
+	defer span.Finish()
+
 	// Validate fields
 	var total int
 	orderByValidColumns := api.UserRepo.OrderByValidColumns(GROUP_ACTION_LIST_ATTACHED_GROUP_POLICIES)
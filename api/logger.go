@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 )
@@ -27,6 +28,24 @@ func LogOperationError(requestID string, userID string, err *Error) {
 	Log.WithFields(fields).Error(err.Message)
 }
 
+// LogBreakGlassActivation logs an activated break-glass session with request identifier and user
+func LogBreakGlassActivation(requestID string, userID string, session *BreakGlassSession) {
+	fields := getLogFields(requestID, userID, "", nil, 0, nil)
+	fields["event"] = "BreakGlassSessionActivated"
+	fields["breakGlassSession"] = session.ID
+	fields["justification"] = session.Justification
+	fields["expiresAt"] = session.ExpiresAt.Format(time.RFC3339)
+	Log.WithFields(fields).Warn("BREAK-GLASS SESSION ACTIVATED: emergency admin access granted")
+}
+
+// LogBreakGlassUse logs the first request made under an active break-glass session with request identifier and user
+func LogBreakGlassUse(requestID string, userID string, session *BreakGlassSession) {
+	fields := getLogFields(requestID, userID, "", nil, 0, nil)
+	fields["event"] = "BreakGlassSessionUsed"
+	fields["breakGlassSession"] = session.ID
+	Log.WithFields(fields).Warnf("Request made under break-glass session %v", session.ID)
+}
+
 // TransactionRequestLog logs a request transaction received with http request, user and request identifier
 func TransactionRequestLog(requestID string, userID string, r *http.Request) {
 	fields := getLogFields(requestID, userID, "", r, 0, nil)
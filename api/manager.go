@@ -1,7 +1,11 @@
 package api
 
 import (
+	"context"
+	"net/http"
 	"time"
+
+	"github.com/Tecsisa/foulkon/tracing"
 )
 
 // TYPE DEFINITIONS
@@ -28,11 +32,44 @@ type PolicyGroupRelation interface {
 
 // WorkerAPI that implements API interfaces using repositories
 type WorkerAPI struct {
-	UserRepo     UserRepo
-	GroupRepo    GroupRepo
-	PolicyRepo   PolicyRepo
-	ProxyRepo    ProxyRepo
-	AuthOidcRepo AuthOidcRepo
+	UserRepo           UserRepo
+	GroupRepo          GroupRepo
+	PolicyRepo         PolicyRepo
+	ProxyRepo          ProxyRepo
+	AuthOidcRepo       AuthOidcRepo
+	PolicyTemplateRepo PolicyTemplateRepo
+	BreakGlassRepo     BreakGlassRepo
+	ChangeRequestRepo  ChangeRequestRepo
+	AuditRepo          AuditRepo
+	WebhookRepo        WebhookRepo
+	SnapshotRepo       SnapshotRepo
+
+	// Break-glass session duration, DEFAULT_BREAK_GLASS_SESSION_TTL if not set
+	BreakGlassSessionTTL time.Duration
+
+	// Rules for mutations that need a second approver
+	ApprovalRules ApprovalRules
+
+	// Audit events older than retention are removed, they are kept forever if not set
+	AuditRetention time.Duration
+	// Queue of authorization decisions of external resources recorded as audit events, they aren't recorded if not set
+	AuditDecisionQueue *AuditDecisionQueue
+
+	// Client used to deliver webhook events, http.DefaultClient if not set
+	WebhookClient *http.Client
+	// Retry policy of failed webhook deliveries
+	WebhookRetry WebhookRetry
+
+	// Last built authorization snapshot, it's built again on every request if not set
+	AuthzSnapshotCache *AuthzSnapshotCache
+
+	// Time between revision checks of proxy resource watches, DEFAULT_PROXY_RESOURCES_WATCH_INTERVAL if not set
+	ProxyResourcesWatchInterval time.Duration
+
+	// Span of the method being called, parent of the spans of the methods and repository calls it makes
+	span *tracing.Span
+	// Audit events stored with the next change, besides its own ones
+	pendingAuditEvents []AuditEvent
 }
 
 // ProxyAPI that implements API interfaces using repositories
@@ -49,6 +86,21 @@ type Filter struct {
 	GroupName         string
 	ProxyResourceName string
 	AuthProviderName  string
+	WebhookName       string
+	// Policy template
+	PolicyTemplateName string
+	// Change request
+	ChangeRequestID string
+	Status          string
+	// Audit event
+	Actor     string
+	EntityUrn string
+	Action    string
+	From      time.Time
+	To        time.Time
+	// Proxy resources watch
+	Revision int64
+	Timeout  time.Duration
 	// Pagination
 	Offset int
 	Limit  int
@@ -162,6 +214,38 @@ type PolicyAPI interface {
 	ListAttachedGroups(requestInfo RequestInfo, filter *Filter) ([]PolicyGroups, int, error)
 }
 
+// PolicyTemplateAPI interface
+type PolicyTemplateAPI interface {
+	// Store policy template in database. Throw error when the input parameters are invalid,
+	// the policy template already exist or unexpected error happen.
+	AddPolicyTemplate(requestInfo RequestInfo, name string, path string, org string, parameters []PolicyTemplateParameter,
+		statements []Statement) (*PolicyTemplate, error)
+
+	// Retrieve policy template from database. Throw error when the input parameters are invalid,
+	// policy template doesn't exist or unexpected error happen.
+	GetPolicyTemplateByName(requestInfo RequestInfo, org string, name string) (*PolicyTemplate, error)
+
+	// Retrieve policy template identifiers from database filtered by org and pathPrefix parameters. These input parameters are optional.
+	// Throw error if the input parameters are invalid or unexpected error happen.
+	ListPolicyTemplates(requestInfo RequestInfo, filter *Filter) ([]PolicyTemplateIdentity, int, error)
+
+	// Update policy template stored in database with new name, new pathPrefix, new parameters and new statements.
+	// Every policy created from the template is rendered again with its stored parameters. Throw error if the input
+	// parameters are invalid, policy template to update doesn't exist, target policy template already exist, any
+	// policy can't be rendered with the new definition or unexpected error happen.
+	UpdatePolicyTemplate(requestInfo RequestInfo, org string, name string, newName string, newPath string,
+		newParameters []PolicyTemplateParameter, newStatements []Statement) (*PolicyTemplate, error)
+
+	// Remove policy template stored in database. Policies created from it are kept as regular policies.
+	// Throw error if the input parameters are invalid, the policy template doesn't exist or unexpected error happen.
+	RemovePolicyTemplate(requestInfo RequestInfo, org string, name string) error
+
+	// Create a policy rendering the policy template with the parameters. Throw error if the input parameters are invalid,
+	// don't match the template declaration, policy template doesn't exist, policy already exist or unexpected error happen.
+	InstantiatePolicyTemplate(requestInfo RequestInfo, org string, templateName string, policyName string, policyPath string,
+		parameters map[string]string) (*Policy, error)
+}
+
 // AuthzAPI interface
 type AuthzAPI interface {
 	// Retrieve list of authorized user resources filtered according to the input parameters. Throw error
@@ -183,6 +267,15 @@ type AuthzAPI interface {
 	// Retrieve list of authorized external resources filtered according to the input parameters. Throw error
 	// if requestInfo doesn't exist, requestInfo doesn't have access to any resources or unexpected error happen.
 	GetAuthorizedExternalResources(requestInfo RequestInfo, action string, resources []string) ([]string, error)
+
+	// Retrieve the restrictions requestInfo has for the action over the urn prefix, and the predicate that applies them
+	// to the column when it isn't empty. Throw error if input parameters are invalid, requestInfo doesn't exist,
+	// requestInfo doesn't have access to any resource in the urn prefix or unexpected error happen.
+	GetAuthorizedRestrictions(requestInfo RequestInfo, action string, urnPrefix string, column string) (*AuthorizedRestrictions, error)
+
+	// Retrieve a versioned snapshot of users, groups and policies to evaluate authorization out of the worker.
+	// Throw error if requestInfo isn't admin or unexpected error happen.
+	GetAuthzSnapshot(requestInfo RequestInfo) (*AuthzSnapshot, error)
 }
 
 // InternalProxyAPI interface to manage proxy resources
@@ -213,6 +306,11 @@ type ProxyResourcesAPI interface {
 	// Remove proxy resource stored in database.
 	// Throw error if the input parameters are invalid, the proxy resource doesn't exist or unexpected error happen.
 	RemoveProxyResource(requestInfo RequestInfo, org string, name string) error
+
+	// Wait until proxy resources change from revision, timeout expires or ctx is done. When they change, it returns all
+	// proxy resources with their revision and true. Throw error if the input parameters are invalid,
+	// requestInfo isn't admin or unexpected error happen.
+	WatchProxyResources(ctx context.Context, requestInfo RequestInfo, revision int64, timeout time.Duration) (*ProxyResourcesWatch, bool, error)
 }
 
 // AuthOidcAPI interface
@@ -239,6 +337,86 @@ type AuthOidcAPI interface {
 	RemoveOidcProvider(requestInfo RequestInfo, name string) error
 }
 
+// BreakGlassAPI interface
+type BreakGlassAPI interface {
+	// Store a new time-boxed emergency admin session for a break-glass account. Throw error when the user
+	// isn't a break-glass account, justification is empty, user has an active session or unexpected error happen.
+	ActivateBreakGlassSession(requestInfo RequestInfo, justification string) (*BreakGlassSession, error)
+
+	// Retrieve the active break-glass session for the user. Throw error when parameter is invalid,
+	// there isn't any active session or unexpected error happen.
+	GetActiveBreakGlassSession(userID string) (*BreakGlassSession, error)
+
+	// Retrieve the active break-glass session of the break-glass account that makes the request, and record its use
+	// with an alert audit event that is sent to subscribed webhooks. Throw error when the user isn't a break-glass
+	// account, there isn't any active session or unexpected error happen.
+	UseBreakGlassSession(requestInfo RequestInfo) (*BreakGlassSession, error)
+
+	// Retrieve break-glass sessions from database filtered by user (optional parameter). Throw error
+	// if filter is invalid or unexpected error happen.
+	ListBreakGlassSessions(requestInfo RequestInfo, filter *Filter) ([]BreakGlassSession, int, error)
+}
+
+// ChangeRequestAPI interface
+type ChangeRequestAPI interface {
+	// Retrieve change request from database. Throw error when the input parameters are invalid,
+	// change request doesn't exist or unexpected error happen.
+	GetChangeRequest(requestInfo RequestInfo, org string, id string) (*ChangeRequest, error)
+
+	// Retrieve change requests from database filtered by org and status parameters. These input parameters are optional.
+	// Pending change requests out of time are expired before. Throw error if the input parameters are invalid
+	// or unexpected error happen.
+	ListChangeRequests(requestInfo RequestInfo, filter *Filter) ([]ChangeRequest, int, error)
+
+	// Approve a pending change request replaying its operation on behalf of the requester. Throw error if the input
+	// parameters are invalid, change request doesn't exist, user is the requester, change request isn't pending
+	// or expired, the operation fails or unexpected error happen.
+	ApproveChangeRequest(requestInfo RequestInfo, org string, id string, comment string) (*ChangeRequest, error)
+
+	// Reject a pending change request. Throw error if the input parameters are invalid, change request doesn't exist,
+	// user is the requester, change request isn't pending or expired or unexpected error happen.
+	RejectChangeRequest(requestInfo RequestInfo, org string, id string, comment string) (*ChangeRequest, error)
+}
+
+// AuditAPI interface
+type AuditAPI interface {
+	// Retrieve audit events from database filtered by actor, entity urn, action and time range. These input
+	// parameters are optional. Events out of retention are removed before. Throw error if the input parameters
+	// are invalid or unexpected error happen.
+	ListAuditEvents(requestInfo RequestInfo, filter *Filter) ([]AuditEvent, int, error)
+
+	// Remove audit events out of retention. Throw error if unexpected error happen.
+	PurgeAuditEvents() error
+}
+
+// WebhookAPI interface
+type WebhookAPI interface {
+	// Store a new webhook in database. Throw error when parameters are invalid,
+	// the webhook already exists or unexpected error happen.
+	AddWebhook(requestInfo RequestInfo, name string, webhookURL string, events []string, secret string) (*Webhook, error)
+
+	// Retrieve webhook from database. Throw error when parameter is invalid,
+	// the webhook doesn't exist or unexpected error happen.
+	GetWebhookByName(requestInfo RequestInfo, name string) (*Webhook, error)
+
+	// Retrieve webhook names from database. Throw error if the input parameters are invalid
+	// or unexpected error happen.
+	ListWebhooks(requestInfo RequestInfo, filter *Filter) ([]string, int, error)
+
+	// Update webhook stored in database with new parameters. The secret is kept if the new one is empty.
+	// Throw error if the input parameters are invalid, the webhook doesn't exist or unexpected error happen.
+	UpdateWebhook(requestInfo RequestInfo, webhookName string, newName string, newURL string, newEvents []string,
+		newSecret string) (*Webhook, error)
+
+	// Remove webhook stored in database with its pending deliveries.
+	// Throw error if name parameter is invalid, webhook doesn't exist or unexpected error happen.
+	RemoveWebhook(requestInfo RequestInfo, name string) error
+
+	// Send the pending webhook deliveries, scheduling a retry with exponential backoff for the failed ones.
+	// Throw error if unexpected error happen.
+	DeliverWebhooks() error
+}
+
 // REPOSITORY INTERFACES
 
 // UserRepo contains all database operations
@@ -350,6 +528,39 @@ type PolicyRepo interface {
 	OrderByValidColumns(action string) []string
 }
 
+// PolicyTemplateRepo contains all database operations
+type PolicyTemplateRepo interface {
+	// Store policy template in database if there aren't errors.
+	AddPolicyTemplate(policyTemplate PolicyTemplate) (*PolicyTemplate, error)
+
+	// Retrieve policy template from database if it exists. Otherwise it throws an error.
+	GetPolicyTemplateByName(org string, name string) (*PolicyTemplate, error)
+
+	// Retrieve policy templates from database filtered by org and pathPrefix optional parameters. Throw error
+	// if there are problems with database.
+	GetPolicyTemplatesFiltered(filter *Filter) ([]PolicyTemplate, int, error)
+
+	// Update policy template stored in database with new fields, overriding its parameters and statements.
+	// Statements of the rendered policies are overridden in the same transaction.
+	// Throw error if there are problems with database.
+	UpdatePolicyTemplate(policyTemplate PolicyTemplate, renderedPolicies []Policy) (*PolicyTemplate, error)
+
+	// Remove policy template stored in database with its instance relationships.
+	// Throw error if there are problems during transactions.
+	RemovePolicyTemplate(id string) error
+
+	// Store policy in database linked to the policy template it was created from.
+	// Throw error if there are problems during transactions.
+	AddPolicyTemplateInstance(instance PolicyTemplateInstance) (*Policy, error)
+
+	// Retrieve policies created from the policy template with the parameters used.
+	// Throw error if there are problems with database.
+	GetPolicyTemplateInstances(templateID string) ([]PolicyTemplateInstance, error)
+
+	// OrderByValidColumns returns valid columns that you can use in OrderBy
+	OrderByValidColumns(action string) []string
+}
+
 // ProxyRepo contains all database operations
 type ProxyRepo interface {
 	// Retrieve proxy resources from database. Otherwise it throws an error.
@@ -369,6 +580,10 @@ type ProxyRepo interface {
 	// Throw error if there are problems during transaction.
 	RemoveProxyResource(proxyResourceID string) error
 
+	// Retrieve proxy resources revision, increased with every change of proxy resources.
+	// Throw error if there are problems with database.
+	GetProxyResourcesRevision() (int64, error)
+
 	// OrderByValidColumns returns valid columns that you can use in OrderBy
 	OrderByValidColumns(action string) []string
 }
@@ -396,3 +611,122 @@ type AuthOidcRepo interface {
 	// OrderByValidColumns returns valid columns that you can use in OrderBy
 	OrderByValidColumns(action string) []string
 }
+
+// BreakGlassRepo contains all database operations
+type BreakGlassRepo interface {
+	// Store a break-glass session in database if there aren't errors.
+	AddBreakGlassSession(session BreakGlassSession) (*BreakGlassSession, error)
+
+	// Retrieve the user's session not expired at the given time from database if it exists.
+	// Otherwise it throws an error.
+	GetActiveBreakGlassSession(userID string, now time.Time) (*BreakGlassSession, error)
+
+	// Set the first use of a break-glass session in database if it hasn't been used before. It returns true
+	// when this is its first use. Throw error if there are problems with database.
+	SetBreakGlassSessionFirstUse(id string, firstUseAt time.Time) (bool, error)
+
+	// Retrieve break-glass sessions from database filtered by user optional parameter. Throw error
+	// if there are problems with database.
+	GetBreakGlassSessionsFiltered(filter *Filter) ([]BreakGlassSession, int, error)
+
+	// OrderByValidColumns returns valid columns that you can use in OrderBy
+	OrderByValidColumns(action string) []string
+}
+
+// ChangeRequestRepo contains all database operations
+type ChangeRequestRepo interface {
+	// Store a change request in database if there aren't errors.
+	AddChangeRequest(changeRequest ChangeRequest) (*ChangeRequest, error)
+
+	// Retrieve change request from database if it exists. Otherwise it throws an error.
+	GetChangeRequestByID(org string, id string) (*ChangeRequest, error)
+
+	// Retrieve change requests from database filtered by org and status optional parameters. Throw error
+	// if there are problems with database.
+	GetChangeRequestsFiltered(filter *Filter) ([]ChangeRequest, int, error)
+
+	// Update status and review fields of a change request stored in database, only if its current status
+	// is the given one. Otherwise it throws an error, so concurrent reviews can't update it twice.
+	UpdateChangeRequest(changeRequest ChangeRequest, status string) (*ChangeRequest, error)
+
+	// Mark as expired the pending change requests not reviewed before the given time.
+	// Throw error if there are problems with database.
+	ExpireChangeRequests(now time.Time) error
+
+	// OrderByValidColumns returns valid columns that you can use in OrderBy
+	OrderByValidColumns(action string) []string
+}
+
+// AuditRepo contains all database operations
+type AuditRepo interface {
+	// Store audit events in database in the same transaction. Throw error if there are problems with database.
+	AddAuditEvents(events []AuditEvent) error
+
+	// Retrieve audit events from database filtered by actor, entity urn, action and time range optional parameters.
+	// Throw error if there are problems with database.
+	GetAuditEventsFiltered(filter *Filter) ([]AuditEvent, int, error)
+
+	// Remove the audit events created before the given time. Throw error if there are problems with database.
+	RemoveAuditEvents(before time.Time) error
+
+	// OrderByValidColumns returns valid columns that you can use in OrderBy
+	OrderByValidColumns(action string) []string
+}
+
+// WebhookRepo contains all database operations
+type WebhookRepo interface {
+	// Store a webhook in database if there aren't errors.
+	AddWebhook(webhook Webhook) (*Webhook, error)
+
+	// Retrieve the webhook from database if it exists. Otherwise it throws an error.
+	GetWebhookByName(name string) (*Webhook, error)
+
+	// Retrieve webhooks from database filtered. Throw error if there are problems with database.
+	GetWebhooksFiltered(filter *Filter) ([]Webhook, int, error)
+
+	// Retrieve all webhooks from database. Throw error if there are problems with database.
+	GetWebhooks() ([]Webhook, error)
+
+	// Update the webhook stored in database with new fields.
+	// Throw error if there are problems with database.
+	UpdateWebhook(webhook Webhook) (*Webhook, error)
+
+	// Remove the webhook stored in database with its pending deliveries.
+	// Throw error if there are problems with database.
+	RemoveWebhook(id string) error
+
+	// Claim the pending webhook deliveries whose next attempt is due at the given time, oldest first.
+	// Their next attempt is delayed until claimUntil, so other workers don't retrieve them while they are sent.
+	// Throw error if there are problems with database.
+	ClaimWebhookDeliveries(now time.Time, claimUntil time.Time, limit int) ([]WebhookDelivery, error)
+
+	// Update the webhook delivery stored in database with the attempt result.
+	// Throw error if there are problems with database.
+	UpdateWebhookDelivery(delivery WebhookDelivery) (*WebhookDelivery, error)
+
+	// OrderByValidColumns returns valid columns that you can use in OrderBy
+	OrderByValidColumns(action string) []string
+}
+
+// SnapshotRepo contains all database operations
+type SnapshotRepo interface {
+	// Retrieve authorization revision, increased with every change of users, memberships, group urns,
+	// attached policies or policy statements. Throw error if there are problems with database.
+	GetAuthzRevision() (int64, error)
+
+	// Retrieve all users with the ids of their groups, the groups with members with the ids of their attached
+	// policies, and the policies attached to them with their statements. Throw error if there are problems with database.
+	GetAuthzSnapshot() (*AuthzSnapshot, error)
+}
+
+// EventRepo is implemented by repositories that store the audit events of a change in its transaction
+type EventRepo interface {
+	// Return a copy of the repository whose changes also store the audit events and webhook deliveries
+	WithEvents(events []AuditEvent, deliveries []WebhookDelivery) interface{}
+}
+
+// TracedRepo is implemented by repositories that trace their calls as children of a span
+type TracedRepo interface {
+	// Return a copy of the repository that traces its calls as children of the span
+	WithSpan(span *tracing.Span) interface{}
+}
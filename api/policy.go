@@ -55,6 +55,9 @@ func (s Statement) String() string {
 // POLICY API IMPLEMENTATION
 
 func (api WorkerAPI) AddPolicy(requestInfo RequestInfo, name string, path string, org string, statements []Statement) (*Policy, error) {
+	api, span := api.startSpan(requestInfo, "AddPolicy")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -110,8 +113,18 @@ func (api WorkerAPI) AddPolicy(requestInfo RequestInfo, name string, path string
 		switch dbError.Code {
 		// Policy doesn't exist in DB
 		case database.POLICY_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &policy, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_ACTION_CREATE_POLICY, policy.Urn, nil, &policy))
+			if err != nil {
+				return nil, err
+			}
+
 			// Create policy
-			createdPolicy, err := api.PolicyRepo.AddPolicy(policy)
+			createdPolicy, err := changeAPI.PolicyRepo.AddPolicy(policy)
 
 			// Check if there is an unexpected error in DB
 			if err != nil {
@@ -140,6 +153,9 @@ func (api WorkerAPI) AddPolicy(requestInfo RequestInfo, name string, path string
 }
 
 func (api WorkerAPI) GetPolicyByName(requestInfo RequestInfo, org string, policyName string) (*Policy, error) {
+	api, span := api.startSpan(requestInfo, "GetPolicyByName")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(policyName) {
 		return nil, &Error{
@@ -193,6 +209,9 @@ func (api WorkerAPI) GetPolicyByName(requestInfo RequestInfo, org string, policy
 }
 
 func (api WorkerAPI) ListPolicies(requestInfo RequestInfo, filter *Filter) ([]PolicyIdentity, int, error) {
+	api, span := api.startSpan(requestInfo, "ListPolicies")
+	defer span.Finish()
+
 	// Validate fields
 	var total int
 	orderByValidColumns := api.PolicyRepo.OrderByValidColumns(POLICY_ACTION_LIST_POLICIES)
@@ -239,6 +258,9 @@ func (api WorkerAPI) ListPolicies(requestInfo RequestInfo, filter *Filter) ([]Po
 
 func (api WorkerAPI) UpdatePolicy(requestInfo RequestInfo, org string, policyName string, newName string, newPath string,
 	newStatements []Statement) (*Policy, error) {
+	api, span := api.startSpan(requestInfo, "UpdatePolicy")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(newName) {
 		return nil, &Error{
@@ -327,8 +349,28 @@ func (api WorkerAPI) UpdatePolicy(requestInfo RequestInfo, org string, policyNam
 		Statements: &newStatements,
 	}
 
+	// Check approval rules, dry runs report the change request too
+	if api.needsApproval(requestInfo, org, newStatements) {
+		return nil, api.submitChangeRequest(requestInfo, org, CHANGE_REQUEST_OPERATION_UPDATE_POLICY, ChangeRequestPayload{
+			PolicyName: policyName,
+			NewName:    newName,
+			NewPath:    newPath,
+			Statements: &newStatements,
+		})
+	}
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return &policy, nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_ACTION_UPDATE_POLICY, policy.Urn, oldPolicy, &policy))
+	if err != nil {
+		return nil, err
+	}
+
 	// Update policy
-	updatedPolicy, err := api.PolicyRepo.UpdatePolicy(policy)
+	updatedPolicy, err := changeAPI.PolicyRepo.UpdatePolicy(policy)
 
 	// Check unexpected DB error
 	if err != nil {
@@ -345,6 +387,8 @@ func (api WorkerAPI) UpdatePolicy(requestInfo RequestInfo, org string, policyNam
 }
 
 func (api WorkerAPI) RemovePolicy(requestInfo RequestInfo, org string, name string) error {
+	api, span := api.startSpan(requestInfo, "RemovePolicy")
+	defer span.Finish()
 
 	// Call repo to retrieve the policy
 	policy, err := api.GetPolicyByName(requestInfo, org, name)
@@ -365,7 +409,17 @@ func (api WorkerAPI) RemovePolicy(requestInfo RequestInfo, org string, name stri
 		}
 	}
 
-	err = api.PolicyRepo.RemovePolicy(policy.ID)
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_ACTION_DELETE_POLICY, policy.Urn, policy, nil))
+	if err != nil {
+		return err
+	}
+
+	err = changeAPI.PolicyRepo.RemovePolicy(policy.ID)
 	if err != nil {
 		//Transform to DB error
 		dbError := err.(*database.Error)
@@ -380,6 +434,9 @@ func (api WorkerAPI) RemovePolicy(requestInfo RequestInfo, org string, name stri
 }
 
 func (api WorkerAPI) ListAttachedGroups(requestInfo RequestInfo, filter *Filter) ([]PolicyGroups, int, error) {
+	api, span := api.startSpan(requestInfo, "ListAttachedGroups")
+	defer span.Finish()
+
 	// Validate fields
 	var total int
 	orderByValidColumns := api.UserRepo.OrderByValidColumns(POLICY_ACTION_LIST_ATTACHED_GROUPS)
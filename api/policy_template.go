@@ -0,0 +1,757 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+// TYPE DEFINITIONS
+
+// PolicyTemplate domain
+type PolicyTemplate struct {
+	ID         string                    `json:"id,omitempty"`
+	Name       string                    `json:"name,omitempty"`
+	Path       string                    `json:"path,omitempty"`
+	Org        string                    `json:"org,omitempty"`
+	Urn        string                    `json:"urn,omitempty"`
+	CreateAt   time.Time                 `json:"createAt,omitempty"`
+	UpdateAt   time.Time                 `json:"updateAt,omitempty"`
+	Parameters []PolicyTemplateParameter `json:"parameters,omitempty"`
+	Statements *[]Statement              `json:"statements,omitempty"`
+}
+
+func (p PolicyTemplate) String() string {
+	return fmt.Sprintf("[id: %v, name: %v, path: %v, org: %v, urn: %v, createAt: %v, parameters: %v, statements: %v]",
+		p.ID, p.Name, p.Path, p.Org, p.Urn, p.CreateAt.Format("2006-01-02 15:04:05 MST"), p.Parameters, p.Statements)
+}
+
+func (p PolicyTemplate) GetUrn() string {
+	return p.Urn
+}
+
+// Policy template identifier to retrieve them from DB
+type PolicyTemplateIdentity struct {
+	Org  string `json:"org,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Parameter declared by a policy template. Every declared parameter is mandatory
+// and, if pattern isn't empty, its value must match it.
+type PolicyTemplateParameter struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+}
+
+func (p PolicyTemplateParameter) String() string {
+	return fmt.Sprintf("[name: %v, description: %v, pattern: %v]", p.Name, p.Description, p.Pattern)
+}
+
+// Policy created from a policy template with the parameters used to render it
+type PolicyTemplateInstance struct {
+	TemplateID string            `json:"-"`
+	Policy     *Policy           `json:"policy,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// POLICY TEMPLATE API IMPLEMENTATION
+
+func (api WorkerAPI) AddPolicyTemplate(requestInfo RequestInfo, name string, path string, org string,
+	parameters []PolicyTemplateParameter, statements []Statement) (*PolicyTemplate, error) {
+	api, span := api.startSpan(requestInfo, "AddPolicyTemplate")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+	if !IsValidOrg(org) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: org %v", org),
+		}
+	}
+	if !IsValidPath(path) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: path %v", path),
+		}
+	}
+	if err := areValidTemplateDefinitions(parameters, statements); err != nil {
+		return nil, err
+	}
+
+	policyTemplate := createPolicyTemplate(name, path, org, parameters, &statements)
+
+	// Check restrictions
+	templatesFiltered, err := api.GetAuthorizedPolicyTemplates(requestInfo, policyTemplate.Urn, POLICY_TEMPLATE_ACTION_CREATE_POLICY_TEMPLATE,
+		[]PolicyTemplate{policyTemplate})
+	if err != nil {
+		return nil, err
+	}
+	if len(templatesFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, policyTemplate.Urn),
+		}
+	}
+
+	// Check if policy template already exists
+	_, err = api.PolicyTemplateRepo.GetPolicyTemplateByName(org, name)
+
+	// Check if policy template could be retrieved
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		// Policy template doesn't exist in DB
+		case database.POLICY_TEMPLATE_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &policyTemplate, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_TEMPLATE_ACTION_CREATE_POLICY_TEMPLATE, policyTemplate.Urn, nil, &policyTemplate))
+			if err != nil {
+				return nil, err
+			}
+
+			// Create policy template
+			createdTemplate, err := changeAPI.PolicyTemplateRepo.AddPolicyTemplate(policyTemplate)
+
+			// Check if there is an unexpected error in DB
+			if err != nil {
+				//Transform to DB error
+				dbError := err.(*database.Error)
+				return nil, &Error{
+					Code:    UNKNOWN_API_ERROR,
+					Message: dbError.Message,
+				}
+			}
+
+			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy template created %+v", createdTemplate))
+			return createdTemplate, nil
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	} else { // Fail if policy template exists
+		return nil, &Error{
+			Code:    POLICY_TEMPLATE_ALREADY_EXIST,
+			Message: fmt.Sprintf("Unable to create policy template, policy template with org %v and name %v already exist", org, name),
+		}
+	}
+}
+
+func (api WorkerAPI) GetPolicyTemplateByName(requestInfo RequestInfo, org string, name string) (*PolicyTemplate, error) {
+	api, span := api.startSpan(requestInfo, "GetPolicyTemplateByName")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+	if !IsValidOrg(org) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: org %v", org),
+		}
+	}
+
+	// Call repo to retrieve the policy template
+	policyTemplate, err := api.PolicyTemplateRepo.GetPolicyTemplateByName(org, name)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		// Policy template doesn't exist in DB
+		if dbError.Code == database.POLICY_TEMPLATE_NOT_FOUND {
+			return nil, &Error{
+				Code:    POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		}
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions
+	templatesFiltered, err := api.GetAuthorizedPolicyTemplates(requestInfo, policyTemplate.Urn, POLICY_TEMPLATE_ACTION_GET_POLICY_TEMPLATE,
+		[]PolicyTemplate{*policyTemplate})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(templatesFiltered) > 0 {
+		templateFiltered := templatesFiltered[0]
+		return &templateFiltered, nil
+	}
+	return nil, &Error{
+		Code: UNAUTHORIZED_RESOURCES_ERROR,
+		Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+			requestInfo.Identifier, policyTemplate.Urn),
+	}
+}
+
+func (api WorkerAPI) ListPolicyTemplates(requestInfo RequestInfo, filter *Filter) ([]PolicyTemplateIdentity, int, error) {
+	api, span := api.startSpan(requestInfo, "ListPolicyTemplates")
+	defer span.Finish()
+
+	// Validate fields
+	var total int
+	orderByValidColumns := api.PolicyTemplateRepo.OrderByValidColumns(POLICY_TEMPLATE_ACTION_LIST_POLICY_TEMPLATES)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the policy templates
+	policyTemplates, total, err := api.PolicyTemplateRepo.GetPolicyTemplatesFiltered(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions to list
+	var urnPrefix string
+	if len(filter.Org) == 0 {
+		urnPrefix = "*"
+	} else {
+		urnPrefix = GetUrnPrefix(filter.Org, RESOURCE_POLICY_TEMPLATE, filter.PathPrefix)
+	}
+	templatesFiltered, err := api.GetAuthorizedPolicyTemplates(requestInfo, urnPrefix, POLICY_TEMPLATE_ACTION_LIST_POLICY_TEMPLATES, policyTemplates)
+	if err != nil {
+		return nil, total, err
+	}
+
+	templateIDs := []PolicyTemplateIdentity{}
+	for _, t := range templatesFiltered {
+		templateIDs = append(templateIDs, PolicyTemplateIdentity{
+			Org:  t.Org,
+			Name: t.Name,
+		})
+	}
+
+	return templateIDs, total, nil
+}
+
+func (api WorkerAPI) UpdatePolicyTemplate(requestInfo RequestInfo, org string, name string, newName string, newPath string,
+	newParameters []PolicyTemplateParameter, newStatements []Statement) (*PolicyTemplate, error) {
+	api, span := api.startSpan(requestInfo, "UpdatePolicyTemplate")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidName(newName) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: new name %v", newName),
+		}
+	}
+	if !IsValidPath(newPath) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: new path %v", newPath),
+		}
+	}
+	if err := areValidTemplateDefinitions(newParameters, newStatements); err != nil {
+		return nil, err
+	}
+
+	// Call repo to retrieve the old policy template
+	oldTemplate, err := api.GetPolicyTemplateByName(requestInfo, org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check restrictions
+	templatesFiltered, err := api.GetAuthorizedPolicyTemplates(requestInfo, oldTemplate.Urn, POLICY_TEMPLATE_ACTION_UPDATE_POLICY_TEMPLATE,
+		[]PolicyTemplate{*oldTemplate})
+	if err != nil {
+		return nil, err
+	}
+	if len(templatesFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, oldTemplate.Urn),
+		}
+	}
+
+	// Check if policy template with "newName" exists
+	targetTemplate, err := api.GetPolicyTemplateByName(requestInfo, org, newName)
+
+	if err == nil && targetTemplate.ID != oldTemplate.ID {
+		// Policy template already exists
+		return nil, &Error{
+			Code:    POLICY_TEMPLATE_ALREADY_EXIST,
+			Message: fmt.Sprintf("Policy template name: %v already exists", newName),
+		}
+	}
+
+	if err != nil {
+		if apiError := err.(*Error); apiError.Code != POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND {
+			return nil, err
+		}
+	}
+
+	auxTemplate := PolicyTemplate{
+		Urn: CreateUrn(org, RESOURCE_POLICY_TEMPLATE, newPath, newName),
+	}
+
+	// Check restrictions
+	templatesFiltered, err = api.GetAuthorizedPolicyTemplates(requestInfo, auxTemplate.Urn, POLICY_TEMPLATE_ACTION_UPDATE_POLICY_TEMPLATE,
+		[]PolicyTemplate{auxTemplate})
+	if err != nil {
+		return nil, err
+	}
+	if len(templatesFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, auxTemplate.Urn),
+		}
+	}
+
+	policyTemplate := PolicyTemplate{
+		ID:         oldTemplate.ID,
+		Name:       newName,
+		Path:       newPath,
+		Org:        oldTemplate.Org,
+		Urn:        auxTemplate.Urn,
+		CreateAt:   oldTemplate.CreateAt,
+		UpdateAt:   time.Now().UTC(),
+		Parameters: newParameters,
+		Statements: &newStatements,
+	}
+
+	// Retrieve policies created from this template in order to render them again
+	instances, err := api.PolicyTemplateRepo.GetPolicyTemplateInstances(oldTemplate.ID)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	renderedPolicies := []Policy{}
+	for _, instance := range instances {
+		statements, err := renderPolicyTemplate(policyTemplate, instance.Parameters)
+		if err != nil {
+			apiError := err.(*Error)
+			return nil, &Error{
+				Code: INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Unable to render policy %v with the new template definition: %v",
+					instance.Policy.Urn, apiError.Message),
+			}
+		}
+		renderedPolicies = append(renderedPolicies, Policy{
+			ID:         instance.Policy.ID,
+			Name:       instance.Policy.Name,
+			Path:       instance.Policy.Path,
+			Org:        instance.Policy.Org,
+			Urn:        instance.Policy.Urn,
+			CreateAt:   instance.Policy.CreateAt,
+			UpdateAt:   policyTemplate.UpdateAt,
+			Statements: &statements,
+		})
+	}
+
+	// Check restrictions over the policies that will be rendered again
+	if len(renderedPolicies) > 0 {
+		policiesFiltered, err := api.GetAuthorizedPolicies(requestInfo, GetUrnPrefix(org, RESOURCE_POLICY, "/"),
+			POLICY_ACTION_UPDATE_POLICY, renderedPolicies)
+		if err != nil {
+			return nil, err
+		}
+		if len(policiesFiltered) < len(renderedPolicies) {
+			return nil, &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: fmt.Sprintf("User with externalId %v is not allowed to update all policies created from template %v",
+					requestInfo.Identifier, oldTemplate.Urn),
+			}
+		}
+	}
+
+	// Check approval rules over the policies that will be rendered again, dry runs report the change request too
+	if len(renderedPolicies) > 0 {
+		renderedStatements := []Statement{}
+		for _, renderedPolicy := range renderedPolicies {
+			renderedStatements = append(renderedStatements, *renderedPolicy.Statements...)
+		}
+		if api.needsApproval(requestInfo, org, renderedStatements) {
+			return nil, api.submitChangeRequest(requestInfo, org, CHANGE_REQUEST_OPERATION_UPDATE_POLICY_TEMPLATE, ChangeRequestPayload{
+				TemplateName: name,
+				NewName:      newName,
+				NewPath:      newPath,
+				Parameters:   newParameters,
+				Statements:   &newStatements,
+			})
+		}
+	}
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return &policyTemplate, nil
+	}
+
+	// Policies rendered again are changed too
+	events := []AuditEvent{
+		createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_TEMPLATE_ACTION_UPDATE_POLICY_TEMPLATE, policyTemplate.Urn, oldTemplate, &policyTemplate),
+	}
+	for i, instance := range instances {
+		events = append(events, createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_ACTION_UPDATE_POLICY,
+			instance.Policy.Urn, instance.Policy, &renderedPolicies[i]))
+	}
+	changeAPI, err := api.withAuditEvents(events...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update policy template and its instances
+	updatedTemplate, err := changeAPI.PolicyTemplateRepo.UpdatePolicyTemplate(policyTemplate, renderedPolicies)
+
+	// Check unexpected DB error
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier,
+		fmt.Sprintf("Policy template updated from %+v to %+v, %v policies rendered again", oldTemplate, updatedTemplate, len(renderedPolicies)))
+	return updatedTemplate, nil
+}
+
+func (api WorkerAPI) RemovePolicyTemplate(requestInfo RequestInfo, org string, name string) error {
+	api, span := api.startSpan(requestInfo, "RemovePolicyTemplate")
+	defer span.Finish()
+
+	// Call repo to retrieve the policy template
+	policyTemplate, err := api.GetPolicyTemplateByName(requestInfo, org, name)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	templatesFiltered, err := api.GetAuthorizedPolicyTemplates(requestInfo, policyTemplate.Urn, POLICY_TEMPLATE_ACTION_DELETE_POLICY_TEMPLATE,
+		[]PolicyTemplate{*policyTemplate})
+	if err != nil {
+		return err
+	}
+	if len(templatesFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, policyTemplate.Urn),
+		}
+	}
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_TEMPLATE_ACTION_DELETE_POLICY_TEMPLATE, policyTemplate.Urn, policyTemplate, nil))
+	if err != nil {
+		return err
+	}
+
+	err = changeAPI.PolicyTemplateRepo.RemovePolicyTemplate(policyTemplate.ID)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy template deleted %+v", policyTemplate))
+	return nil
+}
+
+func (api WorkerAPI) InstantiatePolicyTemplate(requestInfo RequestInfo, org string, templateName string, policyName string,
+	policyPath string, parameters map[string]string) (*Policy, error) {
+	api, span := api.startSpan(requestInfo, "InstantiatePolicyTemplate")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidName(policyName) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", policyName),
+		}
+	}
+	if !IsValidPath(policyPath) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: path %v", policyPath),
+		}
+	}
+
+	// Call repo to retrieve the policy template
+	policyTemplate, err := api.GetPolicyTemplateByName(requestInfo, org, templateName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check restrictions
+	templatesFiltered, err := api.GetAuthorizedPolicyTemplates(requestInfo, policyTemplate.Urn, POLICY_TEMPLATE_ACTION_INSTANTIATE_POLICY_TEMPLATE,
+		[]PolicyTemplate{*policyTemplate})
+	if err != nil {
+		return nil, err
+	}
+	if len(templatesFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, policyTemplate.Urn),
+		}
+	}
+
+	// Render statements with the received parameters
+	statements, err := renderPolicyTemplate(*policyTemplate, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := createPolicy(policyName, policyPath, org, &statements)
+
+	// Check restrictions
+	policiesFiltered, err := api.GetAuthorizedPolicies(requestInfo, policy.Urn, POLICY_ACTION_CREATE_POLICY, []Policy{policy})
+	if err != nil {
+		return nil, err
+	}
+	if len(policiesFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, policy.Urn),
+		}
+	}
+
+	// Check if policy already exists
+	_, err = api.PolicyRepo.GetPolicyByName(org, policyName)
+
+	// Check if policy could be retrieved
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		// Policy doesn't exist in DB
+		case database.POLICY_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &policy, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, POLICY_TEMPLATE_ACTION_INSTANTIATE_POLICY_TEMPLATE, policy.Urn, nil, &policy))
+			if err != nil {
+				return nil, err
+			}
+
+			// Create policy linked to its template
+			createdPolicy, err := changeAPI.PolicyTemplateRepo.AddPolicyTemplateInstance(PolicyTemplateInstance{
+				TemplateID: policyTemplate.ID,
+				Policy:     &policy,
+				Parameters: parameters,
+			})
+
+			// Check if there is an unexpected error in DB
+			if err != nil {
+				//Transform to DB error
+				dbError := err.(*database.Error)
+				return nil, &Error{
+					Code:    UNKNOWN_API_ERROR,
+					Message: dbError.Message,
+				}
+			}
+
+			LogOperation(requestInfo.RequestID, requestInfo.Identifier,
+				fmt.Sprintf("Policy created %+v from policy template %v", createdPolicy, policyTemplate.Urn))
+			return createdPolicy, nil
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	} else { // Fail if policy exists
+		return nil, &Error{
+			Code:    POLICY_ALREADY_EXIST,
+			Message: fmt.Sprintf("Unable to create policy, policy with org %v and name %v already exist", org, policyName),
+		}
+	}
+}
+
+// PRIVATE HELPER METHODS
+
+var rTemplatePlaceholder = regexp.MustCompile(`\{\{\s*([\w\-]+)\s*\}\}`)
+
+func createPolicyTemplate(name string, path string, org string, parameters []PolicyTemplateParameter, statements *[]Statement) PolicyTemplate {
+	urn := CreateUrn(org, RESOURCE_POLICY_TEMPLATE, path, name)
+	policyTemplate := PolicyTemplate{
+		ID:         uuid.NewV4().String(),
+		Name:       name,
+		Path:       path,
+		CreateAt:   time.Now().UTC(),
+		UpdateAt:   time.Now().UTC(),
+		Org:        org,
+		Urn:        urn,
+		Parameters: parameters,
+		Statements: statements,
+	}
+
+	return policyTemplate
+}
+
+// areValidTemplateDefinitions checks parameter declarations and statements of a policy template. Statements
+// are validated rendering every placeholder with a neutral value, and every placeholder must be declared.
+func areValidTemplateDefinitions(parameters []PolicyTemplateParameter, statements []Statement) error {
+	declared := map[string]bool{}
+	for _, parameter := range parameters {
+		if !IsValidName(parameter.Name) {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: template parameter name %v", parameter.Name),
+			}
+		}
+		if declared[parameter.Name] {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: template parameter %v is declared more than once", parameter.Name),
+			}
+		}
+		if _, err := regexp.Compile(parameter.Pattern); err != nil {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: template parameter %v has an invalid pattern %v", parameter.Name, parameter.Pattern),
+			}
+		}
+		declared[parameter.Name] = true
+	}
+
+	// Check that every placeholder is declared
+	for _, statement := range statements {
+		for _, value := range append(append([]string{}, statement.Actions...), statement.Resources...) {
+			for _, match := range rTemplatePlaceholder.FindAllStringSubmatch(value, -1) {
+				if !declared[match[1]] {
+					return &Error{
+						Code:    INVALID_PARAMETER_ERROR,
+						Message: fmt.Sprintf("Invalid parameter: placeholder %v isn't declared as template parameter", match[1]),
+					}
+				}
+			}
+		}
+	}
+
+	// Check statements format with placeholders replaced
+	sampleStatements := renderStatements(statements, func(string) string { return "x" })
+	if err := AreValidStatements(&sampleStatements); err != nil {
+		apiError := err.(*Error)
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: apiError.Message,
+		}
+	}
+
+	return nil
+}
+
+// renderPolicyTemplate validates parameters against the template declaration and returns its rendered statements
+func renderPolicyTemplate(policyTemplate PolicyTemplate, parameters map[string]string) ([]Statement, error) {
+	declared := map[string]bool{}
+	for _, parameter := range policyTemplate.Parameters {
+		declared[parameter.Name] = true
+		value, ok := parameters[parameter.Name]
+		if !ok || len(value) < 1 {
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: template parameter %v is required", parameter.Name),
+			}
+		}
+		if len(parameter.Pattern) > 0 {
+			rPattern, err := regexp.Compile("^(?:" + parameter.Pattern + ")$")
+			if err != nil || !rPattern.MatchString(value) {
+				return nil, &Error{
+					Code:    INVALID_PARAMETER_ERROR,
+					Message: fmt.Sprintf("Invalid parameter: template parameter %v with value %v doesn't match %v", parameter.Name, value, parameter.Pattern),
+				}
+			}
+		}
+	}
+	for name := range parameters {
+		if !declared[name] {
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: template parameter %v isn't declared", name),
+			}
+		}
+	}
+
+	statements := []Statement{}
+	if policyTemplate.Statements != nil {
+		statements = renderStatements(*policyTemplate.Statements, func(name string) string { return parameters[name] })
+	}
+	if err := AreValidStatements(&statements); err != nil {
+		apiError := err.(*Error)
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: apiError.Message,
+		}
+	}
+
+	return statements, nil
+}
+
+// renderStatements returns a copy of statements with their placeholders replaced by the value func
+func renderStatements(statements []Statement, value func(name string) string) []Statement {
+	replace := func(items []string) []string {
+		rendered := make([]string, len(items))
+		for i, item := range items {
+			rendered[i] = rTemplatePlaceholder.ReplaceAllStringFunc(item, func(placeholder string) string {
+				return value(strings.TrimSpace(strings.Trim(placeholder, "{}")))
+			})
+		}
+		return rendered
+	}
+
+	rendered := make([]Statement, len(statements))
+	for i, statement := range statements {
+		rendered[i] = Statement{
+			Effect:    statement.Effect,
+			Actions:   replace(statement.Actions),
+			Resources: replace(statement.Resources),
+		}
+	}
+
+	return rendered
+}
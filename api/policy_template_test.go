@@ -0,0 +1,1040 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestPolicyTemplate() *PolicyTemplate {
+	return &PolicyTemplate{
+		ID:   "template1",
+		Name: "team",
+		Org:  "123",
+		Path: "/path/",
+		Urn:  CreateUrn("123", RESOURCE_POLICY_TEMPLATE, "/path/", "team"),
+		Parameters: []PolicyTemplateParameter{
+			{
+				Name:    "team",
+				Pattern: "[a-z]+",
+			},
+		},
+		Statements: &[]Statement{
+			{
+				Effect: "allow",
+				Actions: []string{
+					USER_ACTION_GET_USER,
+				},
+				Resources: []string{
+					"urn:iws:iam::user/{{team}}/*",
+				},
+			},
+		},
+	}
+}
+
+func TestWorkerAPI_AddPolicyTemplate(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		org         string
+		name        string
+		path        string
+		parameters  []PolicyTemplateParameter
+		statements  []Statement
+
+		getUserByExternalIDResult *User
+
+		addPolicyTemplateMethodResult       *PolicyTemplate
+		getPolicyTemplateByNameMethodResult *PolicyTemplate
+		wantError                           error
+
+		getPolicyTemplateByNameMethodErr error
+		addPolicyTemplateMethodErr       error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			name:       "team",
+			path:       "/path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodErr: &database.Error{
+				Code: database.POLICY_TEMPLATE_NOT_FOUND,
+			},
+			addPolicyTemplateMethodResult: getTestPolicyTemplate(),
+		},
+		"ErrorCasePolicyTemplateAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:                                 "123",
+			name:                                "team",
+			path:                                "/path/",
+			parameters:                          getTestPolicyTemplate().Parameters,
+			statements:                          *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			wantError: &Error{
+				Code:    POLICY_TEMPLATE_ALREADY_EXIST,
+				Message: "Unable to create policy template, policy template with org 123 and name team already exist",
+			},
+		},
+		"ErrorCaseBadName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			name:       "**!^#~",
+			path:       "/path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name **!^#~",
+			},
+		},
+		"ErrorCaseBadOrg": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "**!^#~",
+			name:       "team",
+			path:       "/path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: org **!^#~",
+			},
+		},
+		"ErrorCaseBadPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			name:       "team",
+			path:       "/**!^#~path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: path /**!^#~path/",
+			},
+		},
+		"ErrorCaseBadParameterName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "123",
+			name: "team",
+			path: "/path/",
+			parameters: []PolicyTemplateParameter{
+				{
+					Name: "te am",
+				},
+			},
+			statements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter name te am",
+			},
+		},
+		"ErrorCaseDuplicatedParameter": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "123",
+			name: "team",
+			path: "/path/",
+			parameters: []PolicyTemplateParameter{
+				{
+					Name: "team",
+				},
+				{
+					Name: "team",
+				},
+			},
+			statements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter team is declared more than once",
+			},
+		},
+		"ErrorCaseInvalidPattern": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "123",
+			name: "team",
+			path: "/path/",
+			parameters: []PolicyTemplateParameter{
+				{
+					Name:    "team",
+					Pattern: "[a-z",
+				},
+			},
+			statements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter team has an invalid pattern [a-z",
+			},
+		},
+		"ErrorCaseUndeclaredPlaceholder": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			name:       "team",
+			path:       "/path/",
+			parameters: []PolicyTemplateParameter{},
+			statements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: placeholder team isn't declared as template parameter",
+			},
+		},
+		"ErrorCaseInvalidStatement": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			name:       "team",
+			path:       "/path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: []Statement{
+				{
+					Effect: "allow",
+					Actions: []string{
+						USER_ACTION_GET_USER,
+					},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Empty resources",
+			},
+		},
+		"ErrorCaseNotAllowed": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			org:        "123",
+			name:       "team",
+			path:       "/path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: *getTestPolicyTemplate().Statements,
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:iws:iam:123:policytemplate/path/team",
+			},
+		},
+		"ErrorCaseAddPolicyTemplateDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			name:       "team",
+			path:       "/path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodErr: &database.Error{
+				Code: database.POLICY_TEMPLATE_NOT_FOUND,
+			},
+			addPolicyTemplateMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseGetPolicyTemplateDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			name:       "team",
+			path:       "/path/",
+			parameters: getTestPolicyTemplate().Parameters,
+			statements: *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[AddPolicyTemplateMethod][0] = testcase.addPolicyTemplateMethodResult
+		testRepo.ArgsOut[AddPolicyTemplateMethod][1] = testcase.addPolicyTemplateMethodErr
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][0] = testcase.getPolicyTemplateByNameMethodResult
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][1] = testcase.getPolicyTemplateByNameMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = nil
+		policyTemplate, err := testAPI.AddPolicyTemplate(testcase.requestInfo, testcase.name, testcase.path, testcase.org,
+			testcase.parameters, testcase.statements)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.addPolicyTemplateMethodResult, policyTemplate)
+	}
+}
+
+func TestWorkerAPI_GetPolicyTemplateByName(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		org         string
+		name        string
+
+		getPolicyTemplateByNameMethodResult *PolicyTemplate
+		wantError                           error
+
+		getPolicyTemplateByNameMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:                                 "123",
+			name:                                "team",
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+		},
+		"ErrorCaseBadName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "123",
+			name: "**!^#~",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name **!^#~",
+			},
+		},
+		"ErrorCaseBadOrg": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "**!^#~",
+			name: "team",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: org **!^#~",
+			},
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "123",
+			name: "team",
+			getPolicyTemplateByNameMethodErr: &database.Error{
+				Code:    database.POLICY_TEMPLATE_NOT_FOUND,
+				Message: "Policy template not found",
+			},
+			wantError: &Error{
+				Code:    POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy template not found",
+			},
+		},
+		"ErrorCaseDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "123",
+			name: "team",
+			getPolicyTemplateByNameMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][0] = testcase.getPolicyTemplateByNameMethodResult
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][1] = testcase.getPolicyTemplateByNameMethodErr
+		policyTemplate, err := testAPI.GetPolicyTemplateByName(testcase.requestInfo, testcase.org, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.getPolicyTemplateByNameMethodResult, policyTemplate)
+	}
+}
+
+func TestWorkerAPI_ListPolicyTemplates(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		filter      *Filter
+
+		getPolicyTemplatesFilteredMethodResult []PolicyTemplate
+		totalResult                            int
+		expectedResult                         []PolicyTemplateIdentity
+		wantError                              error
+
+		getPolicyTemplatesFilteredMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:        "123",
+				PathPrefix: "/path/",
+			},
+			getPolicyTemplatesFilteredMethodResult: []PolicyTemplate{*getTestPolicyTemplate()},
+			totalResult:                            1,
+			expectedResult: []PolicyTemplateIdentity{
+				{
+					Org:  "123",
+					Name: "team",
+				},
+			},
+		},
+		"ErrorCaseInvalidPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:        "123",
+				PathPrefix: "/path*/ /",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: pathPrefix /path*/ /",
+			},
+		},
+		"ErrorCaseDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org: "123",
+			},
+			getPolicyTemplatesFilteredMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetPolicyTemplatesFilteredMethod][0] = testcase.getPolicyTemplatesFilteredMethodResult
+		testRepo.ArgsOut[GetPolicyTemplatesFilteredMethod][1] = testcase.totalResult
+		testRepo.ArgsOut[GetPolicyTemplatesFilteredMethod][2] = testcase.getPolicyTemplatesFilteredMethodErr
+		policyTemplates, total, err := testAPI.ListPolicyTemplates(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedResult, policyTemplates)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_UpdatePolicyTemplate(t *testing.T) {
+	instancePolicy := &Policy{
+		ID:   "policy1",
+		Name: "teamA",
+		Org:  "123",
+		Path: "/path/",
+		Urn:  CreateUrn("123", RESOURCE_POLICY, "/path/", "teamA"),
+		Statements: &[]Statement{
+			{
+				Effect: "allow",
+				Actions: []string{
+					USER_ACTION_GET_USER,
+				},
+				Resources: []string{
+					"urn:iws:iam::user/a/*",
+				},
+			},
+		},
+	}
+	testcases := map[string]struct {
+		requestInfo   RequestInfo
+		org           string
+		name          string
+		newName       string
+		newPath       string
+		newParameters []PolicyTemplateParameter
+		newStatements []Statement
+
+		getPolicyTemplateInstancesMethodResult []PolicyTemplateInstance
+		updatePolicyTemplateMethodResult       *PolicyTemplate
+		expectedRenderedPolicies               []Policy
+		wantError                              error
+
+		getPolicyTemplateInstancesMethodErr error
+		updatePolicyTemplateMethodErr       error
+
+		getPolicyTemplateByNameMethodSpecialFunc func(string, string) (*PolicyTemplate, error)
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:           "123",
+			name:          "team",
+			newName:       "team",
+			newPath:       "/path/",
+			newParameters: getTestPolicyTemplate().Parameters,
+			newStatements: []Statement{
+				{
+					Effect: "allow",
+					Actions: []string{
+						USER_ACTION_GET_USER,
+						USER_ACTION_LIST_USERS,
+					},
+					Resources: []string{
+						"urn:iws:iam::user/{{team}}/*",
+					},
+				},
+			},
+			getPolicyTemplateInstancesMethodResult: []PolicyTemplateInstance{
+				{
+					TemplateID: "template1",
+					Policy:     instancePolicy,
+					Parameters: map[string]string{
+						"team": "a",
+					},
+				},
+			},
+			expectedRenderedPolicies: []Policy{
+				{
+					ID:   "policy1",
+					Name: "teamA",
+					Org:  "123",
+					Path: "/path/",
+					Urn:  CreateUrn("123", RESOURCE_POLICY, "/path/", "teamA"),
+					Statements: &[]Statement{
+						{
+							Effect: "allow",
+							Actions: []string{
+								USER_ACTION_GET_USER,
+								USER_ACTION_LIST_USERS,
+							},
+							Resources: []string{
+								"urn:iws:iam::user/a/*",
+							},
+						},
+					},
+				},
+			},
+			updatePolicyTemplateMethodResult: getTestPolicyTemplate(),
+			getPolicyTemplateByNameMethodSpecialFunc: func(org string, name string) (*PolicyTemplate, error) {
+				return getTestPolicyTemplate(), nil
+			},
+		},
+		"ErrorCaseInstanceCannotBeRendered": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:     "123",
+			name:    "team",
+			newName: "team",
+			newPath: "/path/",
+			newParameters: []PolicyTemplateParameter{
+				{
+					Name: "team",
+				},
+				{
+					Name: "env",
+				},
+			},
+			newStatements: []Statement{
+				{
+					Effect: "allow",
+					Actions: []string{
+						USER_ACTION_GET_USER,
+					},
+					Resources: []string{
+						"urn:iws:iam::user/{{env}}/{{team}}/*",
+					},
+				},
+			},
+			getPolicyTemplateInstancesMethodResult: []PolicyTemplateInstance{
+				{
+					TemplateID: "template1",
+					Policy:     instancePolicy,
+					Parameters: map[string]string{
+						"team": "a",
+					},
+				},
+			},
+			getPolicyTemplateByNameMethodSpecialFunc: func(org string, name string) (*PolicyTemplate, error) {
+				return getTestPolicyTemplate(), nil
+			},
+			wantError: &Error{
+				Code: INVALID_PARAMETER_ERROR,
+				Message: "Unable to render policy urn:iws:iam:123:policy/path/teamA with the new template definition: " +
+					"Invalid parameter: template parameter env is required",
+			},
+		},
+		"ErrorCaseTargetAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:           "123",
+			name:          "team",
+			newName:       "team2",
+			newPath:       "/path/",
+			newParameters: getTestPolicyTemplate().Parameters,
+			newStatements: *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodSpecialFunc: func(org string, name string) (*PolicyTemplate, error) {
+				policyTemplate := getTestPolicyTemplate()
+				if name == "team2" {
+					policyTemplate.ID = "template2"
+					policyTemplate.Name = "team2"
+				}
+				return policyTemplate, nil
+			},
+			wantError: &Error{
+				Code:    POLICY_TEMPLATE_ALREADY_EXIST,
+				Message: "Policy template name: team2 already exists",
+			},
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:           "123",
+			name:          "team",
+			newName:       "team",
+			newPath:       "/path/",
+			newParameters: getTestPolicyTemplate().Parameters,
+			newStatements: *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodSpecialFunc: func(org string, name string) (*PolicyTemplate, error) {
+				return nil, &database.Error{
+					Code:    database.POLICY_TEMPLATE_NOT_FOUND,
+					Message: "Policy template not found",
+				}
+			},
+			wantError: &Error{
+				Code:    POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy template not found",
+			},
+		},
+		"ErrorCaseBadNewName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:           "123",
+			name:          "team",
+			newName:       "**!^#~",
+			newPath:       "/path/",
+			newParameters: getTestPolicyTemplate().Parameters,
+			newStatements: *getTestPolicyTemplate().Statements,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: new name **!^#~",
+			},
+		},
+		"ErrorCaseGetInstancesDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:           "123",
+			name:          "team",
+			newName:       "team",
+			newPath:       "/path/",
+			newParameters: getTestPolicyTemplate().Parameters,
+			newStatements: *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodSpecialFunc: func(org string, name string) (*PolicyTemplate, error) {
+				return getTestPolicyTemplate(), nil
+			},
+			getPolicyTemplateInstancesMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUpdateDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:           "123",
+			name:          "team",
+			newName:       "team",
+			newPath:       "/path/",
+			newParameters: getTestPolicyTemplate().Parameters,
+			newStatements: *getTestPolicyTemplate().Statements,
+			getPolicyTemplateByNameMethodSpecialFunc: func(org string, name string) (*PolicyTemplate, error) {
+				return getTestPolicyTemplate(), nil
+			},
+			updatePolicyTemplateMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.SpecialFuncs[GetPolicyTemplateByNameMethod] = testcase.getPolicyTemplateByNameMethodSpecialFunc
+		testRepo.ArgsOut[GetPolicyTemplateInstancesMethod][0] = testcase.getPolicyTemplateInstancesMethodResult
+		testRepo.ArgsOut[GetPolicyTemplateInstancesMethod][1] = testcase.getPolicyTemplateInstancesMethodErr
+		testRepo.ArgsOut[UpdatePolicyTemplateMethod][0] = testcase.updatePolicyTemplateMethodResult
+		testRepo.ArgsOut[UpdatePolicyTemplateMethod][1] = testcase.updatePolicyTemplateMethodErr
+		policyTemplate, err := testAPI.UpdatePolicyTemplate(testcase.requestInfo, testcase.org, testcase.name, testcase.newName,
+			testcase.newPath, testcase.newParameters, testcase.newStatements)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.updatePolicyTemplateMethodResult, policyTemplate)
+		if testcase.expectedRenderedPolicies != nil {
+			renderedPolicies := testRepo.ArgsIn[UpdatePolicyTemplateMethod][1].([]Policy)
+			assert.Equal(t, len(testcase.expectedRenderedPolicies), len(renderedPolicies), "Error in test case %v", x)
+			for i, p := range renderedPolicies {
+				assert.Equal(t, testcase.expectedRenderedPolicies[i].ID, p.ID, "Error in test case %v", x)
+				assert.Equal(t, testcase.expectedRenderedPolicies[i].Urn, p.Urn, "Error in test case %v", x)
+				assert.Equal(t, testcase.expectedRenderedPolicies[i].Statements, p.Statements, "Error in test case %v", x)
+			}
+
+			// Template change is followed by one policy change for each rendered policy
+			events := testRepo.ArgsIn[WithEventsMethod][0].([]AuditEvent)
+			if assert.Equal(t, len(renderedPolicies)+1, len(events), "Error in test case %v", x) {
+				assert.Equal(t, POLICY_TEMPLATE_ACTION_UPDATE_POLICY_TEMPLATE, events[0].Action, "Error in test case %v", x)
+				for i, p := range renderedPolicies {
+					assert.Equal(t, POLICY_ACTION_UPDATE_POLICY, events[i+1].Action, "Error in test case %v", x)
+					assert.Equal(t, p.Urn, events[i+1].EntityUrn, "Error in test case %v", x)
+					assert.Equal(t, &renderedPolicies[i], events[i+1].After, "Error in test case %v", x)
+				}
+			}
+		}
+	}
+}
+
+func TestWorkerAPI_RemovePolicyTemplate(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		org         string
+		name        string
+
+		getPolicyTemplateByNameMethodResult *PolicyTemplate
+		wantError                           error
+
+		getPolicyTemplateByNameMethodErr error
+		removePolicyTemplateMethodErr    error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:                                 "123",
+			name:                                "team",
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "123",
+			name: "team",
+			getPolicyTemplateByNameMethodErr: &database.Error{
+				Code:    database.POLICY_TEMPLATE_NOT_FOUND,
+				Message: "Policy template not found",
+			},
+			wantError: &Error{
+				Code:    POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy template not found",
+			},
+		},
+		"ErrorCaseRemoveDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:                                 "123",
+			name:                                "team",
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			removePolicyTemplateMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][0] = testcase.getPolicyTemplateByNameMethodResult
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][1] = testcase.getPolicyTemplateByNameMethodErr
+		testRepo.ArgsOut[RemovePolicyTemplateMethod][0] = testcase.removePolicyTemplateMethodErr
+		err := testAPI.RemovePolicyTemplate(testcase.requestInfo, testcase.org, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.getPolicyTemplateByNameMethodResult.ID, testRepo.ArgsIn[RemovePolicyTemplateMethod][0],
+				"Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_InstantiatePolicyTemplate(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo  RequestInfo
+		org          string
+		templateName string
+		policyName   string
+		policyPath   string
+		parameters   map[string]string
+
+		getPolicyTemplateByNameMethodResult   *PolicyTemplate
+		getPolicyByNameMethodResult           *Policy
+		addPolicyTemplateInstanceMethodResult *Policy
+		expectedStatements                    *[]Statement
+		wantError                             error
+
+		getPolicyTemplateByNameMethodErr   error
+		getPolicyByNameMethodErr           error
+		addPolicyTemplateInstanceMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:          "123",
+			templateName: "team",
+			policyName:   "teamA",
+			policyPath:   "/path/",
+			parameters: map[string]string{
+				"team": "a",
+			},
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			getPolicyByNameMethodErr: &database.Error{
+				Code: database.POLICY_NOT_FOUND,
+			},
+			addPolicyTemplateInstanceMethodResult: &Policy{
+				ID:   "policy1",
+				Name: "teamA",
+				Org:  "123",
+				Path: "/path/",
+				Urn:  CreateUrn("123", RESOURCE_POLICY, "/path/", "teamA"),
+			},
+			expectedStatements: &[]Statement{
+				{
+					Effect: "allow",
+					Actions: []string{
+						USER_ACTION_GET_USER,
+					},
+					Resources: []string{
+						"urn:iws:iam::user/a/*",
+					},
+				},
+			},
+		},
+		"ErrorCaseMissingParameter": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:                                 "123",
+			templateName:                        "team",
+			policyName:                          "teamA",
+			policyPath:                          "/path/",
+			parameters:                          map[string]string{},
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter team is required",
+			},
+		},
+		"ErrorCasePatternMismatch": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:          "123",
+			templateName: "team",
+			policyName:   "teamA",
+			policyPath:   "/path/",
+			parameters: map[string]string{
+				"team": "A1",
+			},
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter team with value A1 doesn't match [a-z]+",
+			},
+		},
+		"ErrorCaseUndeclaredParameter": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:          "123",
+			templateName: "team",
+			policyName:   "teamA",
+			policyPath:   "/path/",
+			parameters: map[string]string{
+				"team": "a",
+				"env":  "prod",
+			},
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter env isn't declared",
+			},
+		},
+		"ErrorCaseBadPolicyName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:          "123",
+			templateName: "team",
+			policyName:   "**!^#~",
+			policyPath:   "/path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name **!^#~",
+			},
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:          "123",
+			templateName: "team",
+			policyName:   "teamA",
+			policyPath:   "/path/",
+			getPolicyTemplateByNameMethodErr: &database.Error{
+				Code:    database.POLICY_TEMPLATE_NOT_FOUND,
+				Message: "Policy template not found",
+			},
+			wantError: &Error{
+				Code:    POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy template not found",
+			},
+		},
+		"ErrorCasePolicyAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:          "123",
+			templateName: "team",
+			policyName:   "teamA",
+			policyPath:   "/path/",
+			parameters: map[string]string{
+				"team": "a",
+			},
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			getPolicyByNameMethodResult: &Policy{
+				ID: "policy1",
+			},
+			wantError: &Error{
+				Code:    POLICY_ALREADY_EXIST,
+				Message: "Unable to create policy, policy with org 123 and name teamA already exist",
+			},
+		},
+		"ErrorCaseAddInstanceDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:          "123",
+			templateName: "team",
+			policyName:   "teamA",
+			policyPath:   "/path/",
+			parameters: map[string]string{
+				"team": "a",
+			},
+			getPolicyTemplateByNameMethodResult: getTestPolicyTemplate(),
+			getPolicyByNameMethodErr: &database.Error{
+				Code: database.POLICY_NOT_FOUND,
+			},
+			addPolicyTemplateInstanceMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.SpecialFuncs[GetPolicyTemplateByNameMethod] = nil
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][0] = testcase.getPolicyTemplateByNameMethodResult
+		testRepo.ArgsOut[GetPolicyTemplateByNameMethod][1] = testcase.getPolicyTemplateByNameMethodErr
+		testRepo.ArgsOut[GetPolicyByNameMethod][0] = testcase.getPolicyByNameMethodResult
+		testRepo.ArgsOut[GetPolicyByNameMethod][1] = testcase.getPolicyByNameMethodErr
+		testRepo.ArgsOut[AddPolicyTemplateInstanceMethod][0] = testcase.addPolicyTemplateInstanceMethodResult
+		testRepo.ArgsOut[AddPolicyTemplateInstanceMethod][1] = testcase.addPolicyTemplateInstanceMethodErr
+		policy, err := testAPI.InstantiatePolicyTemplate(testcase.requestInfo, testcase.org, testcase.templateName,
+			testcase.policyName, testcase.policyPath, testcase.parameters)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.addPolicyTemplateInstanceMethodResult, policy)
+		if testcase.wantError == nil {
+			instance := testRepo.ArgsIn[AddPolicyTemplateInstanceMethod][0].(PolicyTemplateInstance)
+			assert.Equal(t, testcase.getPolicyTemplateByNameMethodResult.ID, instance.TemplateID, "Error in test case %v", x)
+			assert.Equal(t, testcase.parameters, instance.Parameters, "Error in test case %v", x)
+			assert.Equal(t, testcase.expectedStatements, instance.Policy.Statements, "Error in test case %v", x)
+			assert.Equal(t, CreateUrn(testcase.org, RESOURCE_POLICY, testcase.policyPath, testcase.policyName), instance.Policy.Urn,
+				"Error in test case %v", x)
+		}
+	}
+}
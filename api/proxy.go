@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -10,6 +11,15 @@ import (
 	"github.com/satori/go.uuid"
 )
 
+const (
+	// Proxy resources watch
+	DEFAULT_PROXY_RESOURCES_WATCH_INTERVAL = time.Second
+	DEFAULT_PROXY_RESOURCES_WATCH_TIMEOUT  = 30 * time.Second
+	MAX_PROXY_RESOURCES_WATCH_TIMEOUT      = 5 * time.Minute
+	// Revision that asks for current proxy resources without waiting
+	PROXY_RESOURCES_CURRENT_REVISION = -1
+)
+
 // TYPE DEFINITIONS
 
 // ProxyResource domain
@@ -38,6 +48,12 @@ type ResourceEntity struct {
 	Action string `json:"action,omitempty"`
 }
 
+// Proxy resources with the revision they were read at
+type ProxyResourcesWatch struct {
+	Revision  int64           `json:"revision"`
+	Resources []ProxyResource `json:"resources"`
+}
+
 func (p ProxyResource) GetUrn() string {
 	return p.Urn
 }
@@ -60,6 +76,9 @@ func (api ProxyAPI) GetProxyResources() ([]ProxyResource, error) {
 }
 
 func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org string, path string, resource ResourceEntity) (*ProxyResource, error) {
+	api, span := api.startSpan(requestInfo, "AddProxyResource")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -137,8 +156,18 @@ func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org
 				}
 			}
 
+			// Check dry run
+			if requestInfo.DryRun {
+				return &proxyResource, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, PROXY_ACTION_CREATE_RESOURCE, proxyResource.Urn, nil, &proxyResource))
+			if err != nil {
+				return nil, err
+			}
+
 			// Create proxy resource
-			created, err := api.ProxyRepo.AddProxyResource(proxyResource)
+			created, err := changeAPI.ProxyRepo.AddProxyResource(proxyResource)
 
 			// Check unexpected DB error
 			if err != nil {
@@ -166,6 +195,9 @@ func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org
 }
 
 func (api WorkerAPI) GetProxyResourceByName(requestInfo RequestInfo, org string, name string) (*ProxyResource, error) {
+	api, span := api.startSpan(requestInfo, "GetProxyResourceByName")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -221,6 +253,9 @@ func (api WorkerAPI) GetProxyResourceByName(requestInfo RequestInfo, org string,
 }
 
 func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, name string, newName string, newPath string, newResource ResourceEntity) (*ProxyResource, error) {
+	api, span := api.startSpan(requestInfo, "UpdateProxyResource")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidName(newName) {
 		return nil, &Error{
@@ -339,7 +374,17 @@ func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, na
 		}
 	}
 
-	updatedProxyResource, err := api.ProxyRepo.UpdateProxyResource(proxyResource)
+	// Check dry run
+	if requestInfo.DryRun {
+		return &proxyResource, nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, PROXY_ACTION_UPDATE_RESOURCE, proxyResource.Urn, oldProxyResource, &proxyResource))
+	if err != nil {
+		return nil, err
+	}
+
+	updatedProxyResource, err := changeAPI.ProxyRepo.UpdateProxyResource(proxyResource)
 
 	// Check unexpected DB error
 	if err != nil {
@@ -356,6 +401,9 @@ func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, na
 }
 
 func (api WorkerAPI) RemoveProxyResource(requestInfo RequestInfo, org string, name string) error {
+	api, span := api.startSpan(requestInfo, "RemoveProxyResource")
+	defer span.Finish()
+
 	// Call repo to retrieve the proxy resource
 	proxyResource, err := api.GetProxyResourceByName(requestInfo, org, name)
 	if err != nil {
@@ -375,7 +423,17 @@ func (api WorkerAPI) RemoveProxyResource(requestInfo RequestInfo, org string, na
 		}
 	}
 
-	err = api.ProxyRepo.RemoveProxyResource(proxyResource.ID)
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, PROXY_ACTION_DELETE_RESOURCE, proxyResource.Urn, proxyResource, nil))
+	if err != nil {
+		return err
+	}
+
+	err = changeAPI.ProxyRepo.RemoveProxyResource(proxyResource.ID)
 
 	// Error handling
 	if err != nil {
@@ -392,6 +450,9 @@ func (api WorkerAPI) RemoveProxyResource(requestInfo RequestInfo, org string, na
 }
 
 func (api WorkerAPI) ListProxyResources(requestInfo RequestInfo, filter *Filter) ([]ProxyResourceIdentity, int, error) {
+	api, span := api.startSpan(requestInfo, "ListProxyResources")
+	defer span.Finish()
+
 	// Validate fields
 	var total int
 	orderByValidColumns := api.ProxyRepo.OrderByValidColumns(PROXY_ACTION_LIST_RESOURCES)
@@ -436,6 +497,90 @@ func (api WorkerAPI) ListProxyResources(requestInfo RequestInfo, filter *Filter)
 	return proxyResourcesIDs, total, nil
 }
 
+func (api WorkerAPI) WatchProxyResources(ctx context.Context, requestInfo RequestInfo, revision int64, timeout time.Duration) (*ProxyResourcesWatch, bool, error) {
+	api, span := api.startSpan(requestInfo, "WatchProxyResources")
+	defer span.Finish()
+
+	// Only admin can read all proxy resources
+	if !requestInfo.Admin {
+		return nil, false, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to watch proxy resources", requestInfo.Identifier),
+		}
+	}
+
+	// Validate fields
+	if revision < PROXY_RESOURCES_CURRENT_REVISION {
+		return nil, false, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: revision %v", revision),
+		}
+	}
+	if timeout == 0 {
+		timeout = DEFAULT_PROXY_RESOURCES_WATCH_TIMEOUT
+	}
+	if timeout < 0 || timeout > MAX_PROXY_RESOURCES_WATCH_TIMEOUT {
+		return nil, false, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: timeout %v, it must be at most %v", timeout, MAX_PROXY_RESOURCES_WATCH_TIMEOUT),
+		}
+	}
+
+	interval := api.ProxyResourcesWatchInterval
+	if interval <= 0 {
+		interval = DEFAULT_PROXY_RESOURCES_WATCH_INTERVAL
+	}
+
+	// Check revision until it changes, the revision is read from database
+	// to see the changes made through any worker
+	deadline := time.Now().Add(timeout)
+	for {
+		currentRevision, err := api.ProxyRepo.GetProxyResourcesRevision()
+		if err != nil {
+			//Transform to DB error
+			dbError := err.(*database.Error)
+			return nil, false, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+
+		// A different revision, even a lower one after a database restore, means resources changed.
+		// Revision 0 is a valid revision, databases start with it until resources change
+		if revision == PROXY_RESOURCES_CURRENT_REVISION || currentRevision != revision {
+			resources, _, err := api.ProxyRepo.GetProxyResources(&Filter{})
+			if err != nil {
+				//Transform to DB error
+				dbError := err.(*database.Error)
+				return nil, false, &Error{
+					Code:    UNKNOWN_API_ERROR,
+					Message: dbError.Message,
+				}
+			}
+			return &ProxyResourcesWatch{
+				Revision:  currentRevision,
+				Resources: resources,
+			}, true, nil
+		}
+
+		wait := deadline.Sub(time.Now())
+		if wait <= 0 {
+			return nil, false, nil
+		}
+		if wait > interval {
+			wait = interval
+		}
+		// Stop waiting when the client goes away or the worker shuts down
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, false, nil
+		case <-timer.C:
+		}
+	}
+}
+
 // PRIVATE HELPER METHODS
 
 // This method validates proxy routes to avoid panics when they will be instantiated
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/Tecsisa/foulkon/database"
 	"github.com/stretchr/testify/assert"
@@ -2668,3 +2670,200 @@ func TestWorkerAPI_AddProxyResource(t *testing.T) {
 		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedProxyResource, proxyResource)
 	}
 }
+
+func TestWorkerAPI_WatchProxyResources(t *testing.T) {
+	resources := []ProxyResource{
+		{
+			ID:   "12345",
+			Name: "pr",
+			Org:  "org",
+			Path: "/path/",
+			Resource: ResourceEntity{
+				Host:   "http://example.com",
+				Path:   "/path",
+				Method: "GET",
+				Action: "example:get",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		// API method args
+		requestInfo RequestInfo
+		revision    int64
+		timeout     time.Duration
+		canceled    bool
+		// Expected result
+		expectedResponse *ProxyResourcesWatch
+		expectedModified bool
+		wantError        error
+		// Manager Results
+		getProxyResourcesRevisionResults []int64
+		getProxyResourcesResult          []ProxyResource
+		// Manager Errors
+		getProxyResourcesRevisionErr error
+		getProxyResourcesErr         error
+	}{
+		"OkCaseCurrentResources": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision:                         PROXY_RESOURCES_CURRENT_REVISION,
+			getProxyResourcesRevisionResults: []int64{0},
+			getProxyResourcesResult:          resources,
+			expectedResponse: &ProxyResourcesWatch{
+				Revision:  0,
+				Resources: resources,
+			},
+			expectedModified: true,
+		},
+		"OkCaseInitialRevisionNotModified": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision:                         0,
+			timeout:                          20 * time.Millisecond,
+			getProxyResourcesRevisionResults: []int64{0},
+		},
+		"OkCaseChangedWhileWaiting": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision:                         3,
+			timeout:                          time.Second,
+			getProxyResourcesRevisionResults: []int64{3, 3, 4},
+			getProxyResourcesResult:          resources,
+			expectedResponse: &ProxyResourcesWatch{
+				Revision:  4,
+				Resources: resources,
+			},
+			expectedModified: true,
+		},
+		"OkCaseLowerRevision": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision:                         7,
+			getProxyResourcesRevisionResults: []int64{2},
+			getProxyResourcesResult:          resources,
+			expectedResponse: &ProxyResourcesWatch{
+				Revision:  2,
+				Resources: resources,
+			},
+			expectedModified: true,
+		},
+		"OkCaseNotModified": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision:                         3,
+			timeout:                          20 * time.Millisecond,
+			getProxyResourcesRevisionResults: []int64{3},
+		},
+		"OkCaseCanceled": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision:                         3,
+			timeout:                          MAX_PROXY_RESOURCES_WATCH_TIMEOUT,
+			canceled:                         true,
+			getProxyResourcesRevisionResults: []int64{3},
+		},
+		"ErrorCaseNotAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to watch proxy resources",
+			},
+		},
+		"ErrorCaseInvalidRevision": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision: -2,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: revision -2",
+			},
+		},
+		"ErrorCaseInvalidTimeout": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			timeout: time.Hour,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: timeout 1h0m0s, it must be at most 5m0s",
+			},
+		},
+		"ErrorCaseGetProxyResourcesRevisionDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			getProxyResourcesRevisionErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseGetProxyResourcesDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			revision:                         PROXY_RESOURCES_CURRENT_REVISION,
+			getProxyResourcesRevisionResults: []int64{3},
+			getProxyResourcesErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.ProxyResourcesWatchInterval = time.Millisecond
+
+		// Return revisions in order, repeating the last one
+		revisions := testcase.getProxyResourcesRevisionResults
+		testRepo.SpecialFuncs[GetProxyResourcesRevisionMethod] = func() (int64, error) {
+			if testcase.getProxyResourcesRevisionErr != nil {
+				return 0, testcase.getProxyResourcesRevisionErr
+			}
+			revision := revisions[0]
+			if len(revisions) > 1 {
+				revisions = revisions[1:]
+			}
+			return revision, nil
+		}
+		testRepo.ArgsOut[GetProxyResourcesMethod][0] = testcase.getProxyResourcesResult
+		testRepo.ArgsOut[GetProxyResourcesMethod][2] = testcase.getProxyResourcesErr
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if testcase.canceled {
+			cancel()
+		}
+		watch, modified, err := testAPI.WatchProxyResources(ctx, testcase.requestInfo, testcase.revision, testcase.timeout)
+		cancel()
+		checkMethodResponse(t, n, testcase.wantError, err, testcase.expectedResponse, watch)
+		assert.Equal(t, testcase.expectedModified, modified, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// TYPE DEFINITIONS
+
+// AuthzSnapshot is a versioned copy of the users, groups and policies the worker uses to authorize requests,
+// so authorization can be evaluated out of the worker with the same rules
+type AuthzSnapshot struct {
+	// Version changes when any user membership, group policy or policy statement changes
+	Version  string          `json:"version,omitempty"`
+	CreateAt time.Time       `json:"createAt,omitempty"`
+	Users    []SnapshotUser  `json:"users,omitempty"`
+	Groups   []SnapshotGroup `json:"groups,omitempty"`
+	Policies []Policy        `json:"policies,omitempty"`
+
+	// Indexes built on first evaluation
+	once     sync.Once
+	users    map[string]SnapshotUser
+	groups   map[string]SnapshotGroup
+	policies map[string]Policy
+}
+
+// User with the ids of the groups it is member of
+type SnapshotUser struct {
+	ExternalID string   `json:"externalId,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+}
+
+// Group with the ids of its attached policies
+type SnapshotGroup struct {
+	ID       string   `json:"id,omitempty"`
+	Urn      string   `json:"urn,omitempty"`
+	Policies []string `json:"policies,omitempty"`
+}
+
+// AuthzSnapshotCache keeps the last built snapshot with the authorization revision it was built from
+type AuthzSnapshotCache struct {
+	lock     sync.Mutex
+	revision int64
+	snapshot *AuthzSnapshot
+}
+
+// SNAPSHOT API IMPLEMENTATION
+
+func (api WorkerAPI) GetAuthzSnapshot(requestInfo RequestInfo) (*AuthzSnapshot, error) {
+	api, span := api.startSpan(requestInfo, "GetAuthzSnapshot")
+	defer span.Finish()
+
+	// Only admin can read all permissions
+	if !requestInfo.Admin {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to authorization snapshot", requestInfo.Identifier),
+		}
+	}
+
+	// Read revision before content, so a change committed meanwhile only makes the next request build it again
+	revision, err := api.SnapshotRepo.GetAuthzRevision()
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	if snapshot := api.AuthzSnapshotCache.get(revision); snapshot != nil {
+		return snapshot, nil
+	}
+
+	snapshot, err := api.SnapshotRepo.GetAuthzSnapshot()
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Sort content, so version only depends on data
+	for i := range snapshot.Users {
+		sort.Strings(snapshot.Users[i].Groups)
+	}
+	for i := range snapshot.Groups {
+		sort.Strings(snapshot.Groups[i].Policies)
+	}
+	sort.Sort(snapshotUsers(snapshot.Users))
+	sort.Sort(snapshotGroups(snapshot.Groups))
+	sort.Sort(snapshotPolicies(snapshot.Policies))
+
+	version, err := snapshot.computeVersion()
+	if err != nil {
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: err.Error(),
+		}
+	}
+	snapshot.Version = version
+	snapshot.CreateAt = time.Now().UTC()
+	api.AuthzSnapshotCache.set(revision, snapshot)
+
+	return snapshot, nil
+}
+
+// SNAPSHOT EVALUATION
+
+// GetAuthorizedExternalResources returns the resources where the user has the action granted in the snapshot,
+// with the same validation and rules as the worker
+func (s *AuthzSnapshot) GetAuthorizedExternalResources(externalID string, action string, resources []string) ([]string, error) {
+	// Validate parameters
+	externalResources, err := getExternalResources(action, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	restrictions, err := s.GetRestrictions(externalID, action, "urn:*")
+	if err != nil {
+		return nil, err
+	}
+
+	allowedUrns, err := filterAuthorizedResources(externalID, "urn:*", restrictions, externalResources)
+	if err != nil {
+		return nil, err
+	}
+
+	return getAllowedExternalUrns(externalID, allowedUrns)
+}
+
+// GetRestrictions returns the restrictions of the user for the action and full resource or prefix resource
+func (s *AuthzSnapshot) GetRestrictions(externalID string, action string, resource string) (*Restrictions, error) {
+	s.once.Do(s.buildIndexes)
+
+	user, ok := s.users[externalID]
+	if !ok {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("Authenticated user with externalId %v not found. Unable to retrieve permissions.", externalID),
+		}
+	}
+
+	policies := []Policy{}
+	for _, groupID := range user.Groups {
+		for _, policyID := range s.groups[groupID].Policies {
+			if policy, ok := s.policies[policyID]; ok {
+				policies = append(policies, policy)
+			}
+		}
+	}
+
+	return getRestrictionsByPolicies(policies, action, resource), nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Return the cached snapshot if it was built from the revision, nil otherwise
+func (c *AuthzSnapshotCache) get(revision int64) *AuthzSnapshot {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.snapshot == nil || c.revision != revision {
+		return nil
+	}
+	return c.snapshot
+}
+
+// Cache the snapshot unless a newer revision is cached
+func (c *AuthzSnapshotCache) set(revision int64, snapshot *AuthzSnapshot) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.snapshot != nil && c.revision > revision {
+		return
+	}
+	c.revision = revision
+	c.snapshot = snapshot
+}
+
+func (s *AuthzSnapshot) buildIndexes() {
+	s.users = make(map[string]SnapshotUser, len(s.Users))
+	for _, user := range s.Users {
+		s.users[user.ExternalID] = user
+	}
+	s.groups = make(map[string]SnapshotGroup, len(s.Groups))
+	for _, group := range s.Groups {
+		s.groups[group.ID] = group
+	}
+	s.policies = make(map[string]Policy, len(s.Policies))
+	for _, policy := range s.Policies {
+		s.policies[policy.ID] = policy
+	}
+}
+
+// Sort helpers for snapshot content
+
+type snapshotUsers []SnapshotUser
+
+func (s snapshotUsers) Len() int           { return len(s) }
+func (s snapshotUsers) Less(i, j int) bool { return s[i].ExternalID < s[j].ExternalID }
+func (s snapshotUsers) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type snapshotGroups []SnapshotGroup
+
+func (s snapshotGroups) Len() int           { return len(s) }
+func (s snapshotGroups) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s snapshotGroups) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type snapshotPolicies []Policy
+
+func (s snapshotPolicies) Len() int           { return len(s) }
+func (s snapshotPolicies) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s snapshotPolicies) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Compute version as the hash of snapshot content
+func (s *AuthzSnapshot) computeVersion() (string, error) {
+	content, err := json.Marshal(struct {
+		Users    []SnapshotUser  `json:"users"`
+		Groups   []SnapshotGroup `json:"groups"`
+		Policies []Policy        `json:"policies"`
+	}{s.Users, s.Groups, s.Policies})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(content)), nil
+}
@@ -0,0 +1,351 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerAPI_GetAuthzSnapshot(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		requestInfo RequestInfo
+		// Expected result
+		expectedUsers    []SnapshotUser
+		expectedGroups   []SnapshotGroup
+		expectedPolicies []Policy
+		wantError        error
+		// Manager Results
+		getAuthzRevisionResult int64
+		getAuthzSnapshotResult *AuthzSnapshot
+		// Manager Errors
+		getAuthzRevisionError error
+		getAuthzSnapshotError error
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			getAuthzRevisionResult: 3,
+			getAuthzSnapshotResult: &AuthzSnapshot{
+				Users: []SnapshotUser{
+					{
+						ExternalID: "user2",
+						Groups:     []string{"GROUP2", "GROUP1"},
+					},
+					{
+						ExternalID: "user1",
+						Groups:     []string{"GROUP1"},
+					},
+					{
+						ExternalID: "user3",
+						Groups:     []string{},
+					},
+				},
+				Groups: []SnapshotGroup{
+					{
+						ID:       "GROUP2",
+						Urn:      CreateUrn("example", RESOURCE_GROUP, "/path/", "group2"),
+						Policies: []string{},
+					},
+					{
+						ID:       "GROUP1",
+						Urn:      CreateUrn("example", RESOURCE_GROUP, "/path/", "group1"),
+						Policies: []string{"POLICY2", "POLICY1"},
+					},
+				},
+				Policies: []Policy{
+					{
+						ID:  "POLICY2",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policy2"),
+					},
+					{
+						ID:  "POLICY1",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policy1"),
+					},
+				},
+			},
+			expectedUsers: []SnapshotUser{
+				{
+					ExternalID: "user1",
+					Groups:     []string{"GROUP1"},
+				},
+				{
+					ExternalID: "user2",
+					Groups:     []string{"GROUP1", "GROUP2"},
+				},
+				{
+					ExternalID: "user3",
+					Groups:     []string{},
+				},
+			},
+			expectedGroups: []SnapshotGroup{
+				{
+					ID:       "GROUP1",
+					Urn:      CreateUrn("example", RESOURCE_GROUP, "/path/", "group1"),
+					Policies: []string{"POLICY1", "POLICY2"},
+				},
+				{
+					ID:       "GROUP2",
+					Urn:      CreateUrn("example", RESOURCE_GROUP, "/path/", "group2"),
+					Policies: []string{},
+				},
+			},
+			expectedPolicies: []Policy{
+				{
+					ID:  "POLICY1",
+					Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policy1"),
+				},
+				{
+					ID:  "POLICY2",
+					Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policy2"),
+				},
+			},
+		},
+		"OkCaseNoUsers": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			getAuthzSnapshotResult: &AuthzSnapshot{
+				Users:    []SnapshotUser{},
+				Groups:   []SnapshotGroup{},
+				Policies: []Policy{},
+			},
+			expectedUsers:    []SnapshotUser{},
+			expectedGroups:   []SnapshotGroup{},
+			expectedPolicies: []Policy{},
+		},
+		"ErrorCaseNotAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to authorization snapshot",
+			},
+		},
+		"ErrorCaseGetAuthzRevisionDBError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			getAuthzRevisionError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseGetAuthzSnapshotDBError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			getAuthzSnapshotError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetAuthzRevisionMethod][0] = test.getAuthzRevisionResult
+		testRepo.ArgsOut[GetAuthzRevisionMethod][1] = test.getAuthzRevisionError
+		testRepo.ArgsOut[GetAuthzSnapshotMethod][0] = test.getAuthzSnapshotResult
+		testRepo.ArgsOut[GetAuthzSnapshotMethod][1] = test.getAuthzSnapshotError
+
+		snapshot, err := testAPI.GetAuthzSnapshot(test.requestInfo)
+		if test.wantError != nil {
+			apiError, _ := err.(*Error)
+			assert.Equal(t, test.wantError, apiError, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedUsers, snapshot.Users, "Error in test case %v", n)
+		assert.Equal(t, test.expectedGroups, snapshot.Groups, "Error in test case %v", n)
+		assert.Equal(t, test.expectedPolicies, snapshot.Policies, "Error in test case %v", n)
+		assert.NotEmpty(t, snapshot.Version, "Error in test case %v", n)
+
+		// Same content must have same version
+		other, err := testAPI.GetAuthzSnapshot(test.requestInfo)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, snapshot.Version, other.Version, "Error in test case %v", n)
+	}
+}
+
+func TestWorkerAPI_GetAuthzSnapshotCache(t *testing.T) {
+	testcases := map[string]struct {
+		// Revisions read by each call
+		revisions []int64
+		// Expected result
+		expectedBuilds int
+		expectedCached []bool
+	}{
+		"OkCaseSameRevision": {
+			revisions:      []int64{1, 1, 1},
+			expectedBuilds: 1,
+			expectedCached: []bool{false, true, true},
+		},
+		"OkCaseRevisionChanged": {
+			revisions:      []int64{1, 2, 2},
+			expectedBuilds: 2,
+			expectedCached: []bool{false, false, true},
+		},
+		"OkCaseOlderRevision": {
+			revisions:      []int64{2, 1, 2},
+			expectedBuilds: 2,
+			expectedCached: []bool{false, false, true},
+		},
+	}
+
+	requestInfo := RequestInfo{
+		Identifier: "123456",
+		Admin:      true,
+	}
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.AuthzSnapshotCache = &AuthzSnapshotCache{}
+
+		builds := 0
+		testRepo.SpecialFuncs[GetAuthzSnapshotMethod] = func() (*AuthzSnapshot, error) {
+			builds++
+			return &AuthzSnapshot{
+				Users:    []SnapshotUser{},
+				Groups:   []SnapshotGroup{},
+				Policies: []Policy{},
+			}, nil
+		}
+
+		for i, revision := range test.revisions {
+			testRepo.ArgsOut[GetAuthzRevisionMethod][0] = revision
+			previousBuilds := builds
+			_, err := testAPI.GetAuthzSnapshot(requestInfo)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedCached[i], builds == previousBuilds, "Error in test case %v, call %v", n, i)
+		}
+		assert.Equal(t, test.expectedBuilds, builds, "Error in test case %v", n)
+	}
+}
+
+func TestAuthzSnapshot_GetAuthorizedExternalResources(t *testing.T) {
+	snapshot := &AuthzSnapshot{
+		Users: []SnapshotUser{
+			{
+				ExternalID: "user1",
+				Groups:     []string{"GROUP1"},
+			},
+			{
+				ExternalID: "user2",
+				Groups:     []string{},
+			},
+		},
+		Groups: []SnapshotGroup{
+			{
+				ID:       "GROUP1",
+				Policies: []string{"POLICY1"},
+			},
+		},
+		Policies: []Policy{
+			{
+				ID: "POLICY1",
+				Statements: &[]Statement{
+					{
+						Effect:  "allow",
+						Actions: []string{"product:DoAction"},
+						Resources: []string{
+							"urn:ews:product:instance:resource/path1*",
+						},
+					},
+					{
+						Effect:  "deny",
+						Actions: []string{"product:DoAction"},
+						Resources: []string{
+							"urn:ews:product:instance:resource/path1/resourceDeny",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testcases := map[string]struct {
+		externalID        string
+		action            string
+		resourceUrns      []string
+		expectedResources []string
+		wantError         error
+	}{
+		"OkCaseAllowedPrefix": {
+			externalID: "user1",
+			action:     "product:DoAction",
+			resourceUrns: []string{
+				"urn:ews:product:instance:resource/path1/resourceAllow",
+				"urn:ews:product:instance:resource/path1/resourceDeny",
+				"urn:ews:product:instance:resource/path2/resource",
+			},
+			expectedResources: []string{
+				"urn:ews:product:instance:resource/path1/resourceAllow",
+			},
+		},
+		"ErrorCaseDeniedFullUrn": {
+			externalID: "user1",
+			action:     "product:DoAction",
+			resourceUrns: []string{
+				"urn:ews:product:instance:resource/path1/resourceDeny",
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId user1 is not allowed to access to any resource",
+			},
+		},
+		"ErrorCaseUserWithoutPolicies": {
+			externalID: "user2",
+			action:     "product:DoAction",
+			resourceUrns: []string{
+				"urn:ews:product:instance:resource/path1/resourceAllow",
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId user2 is not allowed to access to resource urn:*",
+			},
+		},
+		"ErrorCaseUnknownUser": {
+			externalID: "unknown",
+			action:     "product:DoAction",
+			resourceUrns: []string{
+				"urn:ews:product:instance:resource/path1/resourceAllow",
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Authenticated user with externalId unknown not found. Unable to retrieve permissions.",
+			},
+		},
+		"ErrorCaseInvalidAction": {
+			externalID: "user1",
+			action:     "valid::Action",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action, value: valid::Action",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		resources, err := snapshot.GetAuthorizedExternalResources(test.externalID, test.action, test.resourceUrns)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedResources, resources)
+	}
+}
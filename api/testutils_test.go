@@ -12,42 +12,73 @@ import (
 )
 
 const (
-	GetUserByExternalIDMethod      = "GetUserByExternalID"
-	AddUserMethod                  = "AddUser"
-	UpdateUserMethod               = "UpdateUser"
-	GetUsersFilteredMethod         = "GetUsersFiltered"
-	GetGroupsByUserIDMethod        = "GetGroupsByUserID"
-	RemoveUserMethod               = "RemoveUser"
-	GetGroupByNameMethod           = "GetGroupByName"
-	IsMemberOfGroupMethod          = "IsMemberOfGroup"
-	GetGroupMembersMethod          = "GetGroupMembers"
-	IsAttachedToGroupMethod        = "IsAttachedToGroup"
-	GetAttachedPoliciesMethod      = "GetAttachedPolicies"
-	GetGroupsFilteredMethod        = "GetGroupsFiltered"
-	RemoveGroupMethod              = "RemoveGroup"
-	AddGroupMethod                 = "AddGroup"
-	AddMemberMethod                = "AddMember"
-	RemoveMemberMethod             = "RemoveMember"
-	UpdateGroupMethod              = "UpdateGroup"
-	AttachPolicyMethod             = "AttachPolicy"
-	DetachPolicyMethod             = "DetachPolicy"
-	GetPolicyByNameMethod          = "GetPolicyByName"
-	AddPolicyMethod                = "AddPolicy"
-	UpdatePolicyMethod             = "UpdatePolicy"
-	RemovePolicyMethod             = "RemovePolicy"
-	GetPoliciesFilteredMethod      = "GetPoliciesFiltered"
-	GetAttachedGroupsMethod        = "GetAttachedGroups"
-	OrderByValidColumnsMethod      = "OrderByValidColumns"
-	GetProxyResourcesMethod        = "GetProxyResources"
-	RemoveProxyResourceMethod      = "RemoveProxyResource"
-	AddProxyResourceMethod         = "AddProxyResource"
-	UpdateProxyResourceMethod      = "UpdateProxyResource"
-	GetProxyResourceByNameMethod   = "GetProxyResourceByName"
-	AddOidcProviderMethod          = "AddOidcProvider"
-	GetOidcProviderByNameMethod    = "GetOidcProviderByName"
-	GetOidcProvidersFilteredMethod = "GetOidcProvidersFiltered"
-	UpdateOidcProviderMethod       = "UpdateOidcProvider"
-	RemoveOidcProviderMethod       = "RemoveOidcProviderMethod"
+	GetUserByExternalIDMethod           = "GetUserByExternalID"
+	AddUserMethod                       = "AddUser"
+	UpdateUserMethod                    = "UpdateUser"
+	GetUsersFilteredMethod              = "GetUsersFiltered"
+	GetGroupsByUserIDMethod             = "GetGroupsByUserID"
+	RemoveUserMethod                    = "RemoveUser"
+	GetGroupByNameMethod                = "GetGroupByName"
+	IsMemberOfGroupMethod               = "IsMemberOfGroup"
+	GetGroupMembersMethod               = "GetGroupMembers"
+	IsAttachedToGroupMethod             = "IsAttachedToGroup"
+	GetAttachedPoliciesMethod           = "GetAttachedPolicies"
+	GetGroupsFilteredMethod             = "GetGroupsFiltered"
+	RemoveGroupMethod                   = "RemoveGroup"
+	AddGroupMethod                      = "AddGroup"
+	AddMemberMethod                     = "AddMember"
+	RemoveMemberMethod                  = "RemoveMember"
+	UpdateGroupMethod                   = "UpdateGroup"
+	AttachPolicyMethod                  = "AttachPolicy"
+	DetachPolicyMethod                  = "DetachPolicy"
+	GetPolicyByNameMethod               = "GetPolicyByName"
+	AddPolicyMethod                     = "AddPolicy"
+	UpdatePolicyMethod                  = "UpdatePolicy"
+	RemovePolicyMethod                  = "RemovePolicy"
+	GetPoliciesFilteredMethod           = "GetPoliciesFiltered"
+	GetAttachedGroupsMethod             = "GetAttachedGroups"
+	OrderByValidColumnsMethod           = "OrderByValidColumns"
+	GetProxyResourcesMethod             = "GetProxyResources"
+	RemoveProxyResourceMethod           = "RemoveProxyResource"
+	AddProxyResourceMethod              = "AddProxyResource"
+	UpdateProxyResourceMethod           = "UpdateProxyResource"
+	GetProxyResourceByNameMethod        = "GetProxyResourceByName"
+	GetProxyResourcesRevisionMethod     = "GetProxyResourcesRevision"
+	AddOidcProviderMethod               = "AddOidcProvider"
+	GetOidcProviderByNameMethod         = "GetOidcProviderByName"
+	GetOidcProvidersFilteredMethod      = "GetOidcProvidersFiltered"
+	UpdateOidcProviderMethod            = "UpdateOidcProvider"
+	RemoveOidcProviderMethod            = "RemoveOidcProviderMethod"
+	AddPolicyTemplateMethod             = "AddPolicyTemplate"
+	GetPolicyTemplateByNameMethod       = "GetPolicyTemplateByName"
+	GetPolicyTemplatesFilteredMethod    = "GetPolicyTemplatesFiltered"
+	UpdatePolicyTemplateMethod          = "UpdatePolicyTemplate"
+	RemovePolicyTemplateMethod          = "RemovePolicyTemplate"
+	AddPolicyTemplateInstanceMethod     = "AddPolicyTemplateInstance"
+	GetPolicyTemplateInstancesMethod    = "GetPolicyTemplateInstances"
+	AddBreakGlassSessionMethod          = "AddBreakGlassSession"
+	GetActiveBreakGlassSessionMethod    = "GetActiveBreakGlassSession"
+	SetBreakGlassSessionFirstUseMethod  = "SetBreakGlassSessionFirstUse"
+	GetBreakGlassSessionsFilteredMethod = "GetBreakGlassSessionsFiltered"
+	AddChangeRequestMethod              = "AddChangeRequest"
+	GetChangeRequestByIDMethod          = "GetChangeRequestByID"
+	GetChangeRequestsFilteredMethod     = "GetChangeRequestsFiltered"
+	UpdateChangeRequestMethod           = "UpdateChangeRequest"
+	ExpireChangeRequestsMethod          = "ExpireChangeRequests"
+	AddAuditEventsMethod                = "AddAuditEvents"
+	GetAuditEventsFilteredMethod        = "GetAuditEventsFiltered"
+	RemoveAuditEventsMethod             = "RemoveAuditEvents"
+	AddWebhookMethod                    = "AddWebhook"
+	GetWebhookByNameMethod              = "GetWebhookByName"
+	GetWebhooksFilteredMethod           = "GetWebhooksFiltered"
+	GetWebhooksMethod                   = "GetWebhooks"
+	UpdateWebhookMethod                 = "UpdateWebhook"
+	RemoveWebhookMethod                 = "RemoveWebhook"
+	ClaimWebhookDeliveriesMethod        = "ClaimWebhookDeliveries"
+	UpdateWebhookDeliveryMethod         = "UpdateWebhookDelivery"
+	GetAuthzRevisionMethod              = "GetAuthzRevision"
+	GetAuthzSnapshotMethod              = "GetAuthzSnapshot"
+	WithEventsMethod                    = "WithEvents"
 )
 
 // TestRepo that implements all repo manager interfaces
@@ -122,6 +153,34 @@ func makeTestRepo() *TestRepo {
 	testRepo.ArgsIn[GetOidcProvidersFilteredMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[UpdateOidcProviderMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[RemoveOidcProviderMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddPolicyTemplateMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetPolicyTemplateByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[GetPolicyTemplatesFilteredMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdatePolicyTemplateMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[RemovePolicyTemplateMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddPolicyTemplateInstanceMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetPolicyTemplateInstancesMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddBreakGlassSessionMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetActiveBreakGlassSessionMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[SetBreakGlassSessionFirstUseMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[GetBreakGlassSessionsFilteredMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddChangeRequestMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetChangeRequestByIDMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[GetChangeRequestsFilteredMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdateChangeRequestMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[ExpireChangeRequestsMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddAuditEventsMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetAuditEventsFilteredMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[RemoveAuditEventsMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddWebhookMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetWebhookByNameMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetWebhooksFilteredMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetWebhooksMethod] = make([]interface{}, 0)
+	testRepo.ArgsIn[UpdateWebhookMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[RemoveWebhookMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[ClaimWebhookDeliveriesMethod] = make([]interface{}, 3)
+	testRepo.ArgsIn[UpdateWebhookDeliveryMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[WithEventsMethod] = make([]interface{}, 2)
 
 	testRepo.ArgsOut[GetUserByExternalIDMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[AddUserMethod] = make([]interface{}, 2)
@@ -151,6 +210,7 @@ func makeTestRepo() *TestRepo {
 	testRepo.ArgsOut[OrderByValidColumnsMethod] = make([]interface{}, 1)
 	testRepo.ArgsOut[GetProxyResourcesMethod] = make([]interface{}, 3)
 	testRepo.ArgsOut[RemoveProxyResourceMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[GetProxyResourcesRevisionMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[AddProxyResourceMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[UpdateProxyResourceMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[GetProxyResourceByNameMethod] = make([]interface{}, 2)
@@ -159,17 +219,52 @@ func makeTestRepo() *TestRepo {
 	testRepo.ArgsOut[GetOidcProvidersFilteredMethod] = make([]interface{}, 3)
 	testRepo.ArgsOut[UpdateOidcProviderMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[RemoveOidcProviderMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[AddPolicyTemplateMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetPolicyTemplateByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetPolicyTemplatesFilteredMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[UpdatePolicyTemplateMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[RemovePolicyTemplateMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[AddPolicyTemplateInstanceMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetPolicyTemplateInstancesMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[AddBreakGlassSessionMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetActiveBreakGlassSessionMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[SetBreakGlassSessionFirstUseMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetBreakGlassSessionsFilteredMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[AddChangeRequestMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetChangeRequestByIDMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetChangeRequestsFilteredMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[UpdateChangeRequestMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[ExpireChangeRequestsMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[AddAuditEventsMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[GetAuditEventsFilteredMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[RemoveAuditEventsMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[AddWebhookMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetWebhookByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetWebhooksFilteredMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[GetWebhooksMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[UpdateWebhookMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[RemoveWebhookMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[ClaimWebhookDeliveriesMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[UpdateWebhookDeliveryMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetAuthzRevisionMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetAuthzSnapshotMethod] = make([]interface{}, 2)
 
 	return testRepo
 }
 
 func makeTestAPI(testRepo *TestRepo) *WorkerAPI {
 	api := &WorkerAPI{
-		UserRepo:     testRepo,
-		GroupRepo:    testRepo,
-		PolicyRepo:   testRepo,
-		ProxyRepo:    testRepo,
-		AuthOidcRepo: testRepo,
+		UserRepo:           testRepo,
+		GroupRepo:          testRepo,
+		PolicyRepo:         testRepo,
+		ProxyRepo:          testRepo,
+		AuthOidcRepo:       testRepo,
+		PolicyTemplateRepo: testRepo,
+		BreakGlassRepo:     testRepo,
+		ChangeRequestRepo:  testRepo,
+		AuditRepo:          testRepo,
+		WebhookRepo:        testRepo,
+		SnapshotRepo:       testRepo,
 	}
 	Log = &log.Logger{
 		Out:       bytes.NewBuffer([]byte{}),
@@ -682,6 +777,21 @@ func (t TestRepo) RemoveProxyResource(id string) error {
 	return err
 }
 
+func (t TestRepo) GetProxyResourcesRevision() (int64, error) {
+	if specialFunc, ok := t.SpecialFuncs[GetProxyResourcesRevisionMethod].(func() (int64, error)); ok && specialFunc != nil {
+		return specialFunc()
+	}
+	var revision int64
+	if t.ArgsOut[GetProxyResourcesRevisionMethod][0] != nil {
+		revision = t.ArgsOut[GetProxyResourcesRevisionMethod][0].(int64)
+	}
+	var err error
+	if t.ArgsOut[GetProxyResourcesRevisionMethod][1] != nil {
+		err = t.ArgsOut[GetProxyResourcesRevisionMethod][1].(error)
+	}
+	return revision, err
+}
+
 ///////////////////////////
 // Auth OIDC provider repo
 //////////////////////////
@@ -756,6 +866,426 @@ func (t TestRepo) RemoveOidcProvider(id string) error {
 	return err
 }
 
+//////////////////////////
+// Policy template repo
+//////////////////////////
+
+func (t TestRepo) AddPolicyTemplate(policyTemplate PolicyTemplate) (*PolicyTemplate, error) {
+	t.ArgsIn[AddPolicyTemplateMethod][0] = policyTemplate
+	var created *PolicyTemplate
+	if t.ArgsOut[AddPolicyTemplateMethod][0] != nil {
+		created = t.ArgsOut[AddPolicyTemplateMethod][0].(*PolicyTemplate)
+	}
+	var err error
+	if t.ArgsOut[AddPolicyTemplateMethod][1] != nil {
+		err = t.ArgsOut[AddPolicyTemplateMethod][1].(error)
+	}
+	return created, err
+}
+
+func (t TestRepo) GetPolicyTemplateByName(org string, name string) (*PolicyTemplate, error) {
+	t.ArgsIn[GetPolicyTemplateByNameMethod][0] = org
+	t.ArgsIn[GetPolicyTemplateByNameMethod][1] = name
+	if specialFunc, ok := t.SpecialFuncs[GetPolicyTemplateByNameMethod].(func(org string, name string) (*PolicyTemplate, error)); ok && specialFunc != nil {
+		return specialFunc(org, name)
+	}
+	var policyTemplate *PolicyTemplate
+	if t.ArgsOut[GetPolicyTemplateByNameMethod][0] != nil {
+		policyTemplate = t.ArgsOut[GetPolicyTemplateByNameMethod][0].(*PolicyTemplate)
+	}
+	var err error
+	if t.ArgsOut[GetPolicyTemplateByNameMethod][1] != nil {
+		err = t.ArgsOut[GetPolicyTemplateByNameMethod][1].(error)
+	}
+	return policyTemplate, err
+}
+
+func (t TestRepo) GetPolicyTemplatesFiltered(filter *Filter) ([]PolicyTemplate, int, error) {
+	t.ArgsIn[GetPolicyTemplatesFilteredMethod][0] = filter
+
+	var policyTemplates []PolicyTemplate
+	if t.ArgsOut[GetPolicyTemplatesFilteredMethod][0] != nil {
+		policyTemplates = t.ArgsOut[GetPolicyTemplatesFilteredMethod][0].([]PolicyTemplate)
+	}
+	var total int
+	if t.ArgsOut[GetPolicyTemplatesFilteredMethod][1] != nil {
+		total = t.ArgsOut[GetPolicyTemplatesFilteredMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetPolicyTemplatesFilteredMethod][2] != nil {
+		err = t.ArgsOut[GetPolicyTemplatesFilteredMethod][2].(error)
+	}
+	return policyTemplates, total, err
+}
+
+func (t TestRepo) UpdatePolicyTemplate(policyTemplate PolicyTemplate, renderedPolicies []Policy) (*PolicyTemplate, error) {
+	t.ArgsIn[UpdatePolicyTemplateMethod][0] = policyTemplate
+	t.ArgsIn[UpdatePolicyTemplateMethod][1] = renderedPolicies
+
+	var updated *PolicyTemplate
+	if t.ArgsOut[UpdatePolicyTemplateMethod][0] != nil {
+		updated = t.ArgsOut[UpdatePolicyTemplateMethod][0].(*PolicyTemplate)
+	}
+	var err error
+	if t.ArgsOut[UpdatePolicyTemplateMethod][1] != nil {
+		err = t.ArgsOut[UpdatePolicyTemplateMethod][1].(error)
+	}
+	return updated, err
+}
+
+func (t TestRepo) RemovePolicyTemplate(id string) error {
+	t.ArgsIn[RemovePolicyTemplateMethod][0] = id
+	var err error
+	if t.ArgsOut[RemovePolicyTemplateMethod][0] != nil {
+		err = t.ArgsOut[RemovePolicyTemplateMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) AddPolicyTemplateInstance(instance PolicyTemplateInstance) (*Policy, error) {
+	t.ArgsIn[AddPolicyTemplateInstanceMethod][0] = instance
+	var created *Policy
+	if t.ArgsOut[AddPolicyTemplateInstanceMethod][0] != nil {
+		created = t.ArgsOut[AddPolicyTemplateInstanceMethod][0].(*Policy)
+	}
+	var err error
+	if t.ArgsOut[AddPolicyTemplateInstanceMethod][1] != nil {
+		err = t.ArgsOut[AddPolicyTemplateInstanceMethod][1].(error)
+	}
+	return created, err
+}
+
+func (t TestRepo) GetPolicyTemplateInstances(templateID string) ([]PolicyTemplateInstance, error) {
+	t.ArgsIn[GetPolicyTemplateInstancesMethod][0] = templateID
+	var instances []PolicyTemplateInstance
+	if t.ArgsOut[GetPolicyTemplateInstancesMethod][0] != nil {
+		instances = t.ArgsOut[GetPolicyTemplateInstancesMethod][0].([]PolicyTemplateInstance)
+	}
+	var err error
+	if t.ArgsOut[GetPolicyTemplateInstancesMethod][1] != nil {
+		err = t.ArgsOut[GetPolicyTemplateInstancesMethod][1].(error)
+	}
+	return instances, err
+}
+
+func (t TestRepo) AddBreakGlassSession(session BreakGlassSession) (*BreakGlassSession, error) {
+	t.ArgsIn[AddBreakGlassSessionMethod][0] = session
+	var created *BreakGlassSession
+	if t.ArgsOut[AddBreakGlassSessionMethod][0] != nil {
+		created = t.ArgsOut[AddBreakGlassSessionMethod][0].(*BreakGlassSession)
+	}
+	var err error
+	if t.ArgsOut[AddBreakGlassSessionMethod][1] != nil {
+		err = t.ArgsOut[AddBreakGlassSessionMethod][1].(error)
+	}
+	return created, err
+}
+
+func (t TestRepo) GetActiveBreakGlassSession(userID string, now time.Time) (*BreakGlassSession, error) {
+	t.ArgsIn[GetActiveBreakGlassSessionMethod][0] = userID
+	t.ArgsIn[GetActiveBreakGlassSessionMethod][1] = now
+	var session *BreakGlassSession
+	if t.ArgsOut[GetActiveBreakGlassSessionMethod][0] != nil {
+		session = t.ArgsOut[GetActiveBreakGlassSessionMethod][0].(*BreakGlassSession)
+	}
+	var err error
+	if t.ArgsOut[GetActiveBreakGlassSessionMethod][1] != nil {
+		err = t.ArgsOut[GetActiveBreakGlassSessionMethod][1].(error)
+	}
+	return session, err
+}
+
+func (t TestRepo) SetBreakGlassSessionFirstUse(id string, firstUseAt time.Time) (bool, error) {
+	t.ArgsIn[SetBreakGlassSessionFirstUseMethod][0] = id
+	t.ArgsIn[SetBreakGlassSessionFirstUseMethod][1] = firstUseAt
+	var firstUse bool
+	if t.ArgsOut[SetBreakGlassSessionFirstUseMethod][0] != nil {
+		firstUse = t.ArgsOut[SetBreakGlassSessionFirstUseMethod][0].(bool)
+	}
+	var err error
+	if t.ArgsOut[SetBreakGlassSessionFirstUseMethod][1] != nil {
+		err = t.ArgsOut[SetBreakGlassSessionFirstUseMethod][1].(error)
+	}
+	return firstUse, err
+}
+
+func (t TestRepo) GetBreakGlassSessionsFiltered(filter *Filter) ([]BreakGlassSession, int, error) {
+	t.ArgsIn[GetBreakGlassSessionsFilteredMethod][0] = filter
+	var sessions []BreakGlassSession
+	if t.ArgsOut[GetBreakGlassSessionsFilteredMethod][0] != nil {
+		sessions = t.ArgsOut[GetBreakGlassSessionsFilteredMethod][0].([]BreakGlassSession)
+	}
+	var total int
+	if t.ArgsOut[GetBreakGlassSessionsFilteredMethod][1] != nil {
+		total = t.ArgsOut[GetBreakGlassSessionsFilteredMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetBreakGlassSessionsFilteredMethod][2] != nil {
+		err = t.ArgsOut[GetBreakGlassSessionsFilteredMethod][2].(error)
+	}
+	return sessions, total, err
+}
+
+func (t TestRepo) AddChangeRequest(changeRequest ChangeRequest) (*ChangeRequest, error) {
+	t.ArgsIn[AddChangeRequestMethod][0] = changeRequest
+	var created *ChangeRequest
+	if t.ArgsOut[AddChangeRequestMethod][0] != nil {
+		created = t.ArgsOut[AddChangeRequestMethod][0].(*ChangeRequest)
+	}
+	var err error
+	if t.ArgsOut[AddChangeRequestMethod][1] != nil {
+		err = t.ArgsOut[AddChangeRequestMethod][1].(error)
+	}
+	return created, err
+}
+
+func (t TestRepo) GetChangeRequestByID(org string, id string) (*ChangeRequest, error) {
+	t.ArgsIn[GetChangeRequestByIDMethod][0] = org
+	t.ArgsIn[GetChangeRequestByIDMethod][1] = id
+	var changeRequest *ChangeRequest
+	if cr, ok := t.ArgsOut[GetChangeRequestByIDMethod][0].(*ChangeRequest); ok && cr != nil {
+		// Return a copy, callers modify it
+		crCopy := *cr
+		changeRequest = &crCopy
+	}
+	var err error
+	if t.ArgsOut[GetChangeRequestByIDMethod][1] != nil {
+		err = t.ArgsOut[GetChangeRequestByIDMethod][1].(error)
+	}
+	return changeRequest, err
+}
+
+func (t TestRepo) GetChangeRequestsFiltered(filter *Filter) ([]ChangeRequest, int, error) {
+	t.ArgsIn[GetChangeRequestsFilteredMethod][0] = filter
+	var changeRequests []ChangeRequest
+	if t.ArgsOut[GetChangeRequestsFilteredMethod][0] != nil {
+		changeRequests = t.ArgsOut[GetChangeRequestsFilteredMethod][0].([]ChangeRequest)
+	}
+	var total int
+	if t.ArgsOut[GetChangeRequestsFilteredMethod][1] != nil {
+		total = t.ArgsOut[GetChangeRequestsFilteredMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetChangeRequestsFilteredMethod][2] != nil {
+		err = t.ArgsOut[GetChangeRequestsFilteredMethod][2].(error)
+	}
+	return changeRequests, total, err
+}
+
+func (t TestRepo) UpdateChangeRequest(changeRequest ChangeRequest, status string) (*ChangeRequest, error) {
+	t.ArgsIn[UpdateChangeRequestMethod][0] = changeRequest
+	t.ArgsIn[UpdateChangeRequestMethod][1] = status
+	var updated *ChangeRequest
+	if t.ArgsOut[UpdateChangeRequestMethod][0] != nil {
+		updated = t.ArgsOut[UpdateChangeRequestMethod][0].(*ChangeRequest)
+	}
+	var err error
+	if t.ArgsOut[UpdateChangeRequestMethod][1] != nil {
+		err = t.ArgsOut[UpdateChangeRequestMethod][1].(error)
+	}
+	return updated, err
+}
+
+func (t TestRepo) ExpireChangeRequests(now time.Time) error {
+	t.ArgsIn[ExpireChangeRequestsMethod][0] = now
+	var err error
+	if t.ArgsOut[ExpireChangeRequestsMethod][0] != nil {
+		err = t.ArgsOut[ExpireChangeRequestsMethod][0].(error)
+	}
+	return err
+}
+
+// Audit repo
+
+func (t TestRepo) AddAuditEvents(events []AuditEvent) error {
+	t.ArgsIn[AddAuditEventsMethod][0] = events
+	var err error
+	if t.ArgsOut[AddAuditEventsMethod][0] != nil {
+		err = t.ArgsOut[AddAuditEventsMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) GetAuditEventsFiltered(filter *Filter) ([]AuditEvent, int, error) {
+	t.ArgsIn[GetAuditEventsFilteredMethod][0] = filter
+	var events []AuditEvent
+	if t.ArgsOut[GetAuditEventsFilteredMethod][0] != nil {
+		events = t.ArgsOut[GetAuditEventsFilteredMethod][0].([]AuditEvent)
+	}
+	var total int
+	if t.ArgsOut[GetAuditEventsFilteredMethod][1] != nil {
+		total = t.ArgsOut[GetAuditEventsFilteredMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetAuditEventsFilteredMethod][2] != nil {
+		err = t.ArgsOut[GetAuditEventsFilteredMethod][2].(error)
+	}
+	return events, total, err
+}
+
+func (t TestRepo) RemoveAuditEvents(before time.Time) error {
+	t.ArgsIn[RemoveAuditEventsMethod][0] = before
+	var err error
+	if t.ArgsOut[RemoveAuditEventsMethod][0] != nil {
+		err = t.ArgsOut[RemoveAuditEventsMethod][0].(error)
+	}
+	return err
+}
+
+// Webhook repo
+
+func (t TestRepo) AddWebhook(webhook Webhook) (*Webhook, error) {
+	t.ArgsIn[AddWebhookMethod][0] = webhook
+	var created *Webhook
+	if t.ArgsOut[AddWebhookMethod][0] != nil {
+		created = t.ArgsOut[AddWebhookMethod][0].(*Webhook)
+	}
+	var err error
+	if t.ArgsOut[AddWebhookMethod][1] != nil {
+		err = t.ArgsOut[AddWebhookMethod][1].(error)
+	}
+	return created, err
+}
+
+func (t TestRepo) GetWebhookByName(name string) (*Webhook, error) {
+	t.ArgsIn[GetWebhookByNameMethod][0] = name
+	if specialFunc, ok := t.SpecialFuncs[GetWebhookByNameMethod].(func(name string) (*Webhook, error)); ok && specialFunc != nil {
+		return specialFunc(name)
+	}
+	var webhook *Webhook
+	if t.ArgsOut[GetWebhookByNameMethod][0] != nil {
+		webhook = t.ArgsOut[GetWebhookByNameMethod][0].(*Webhook)
+	}
+	var err error
+	if t.ArgsOut[GetWebhookByNameMethod][1] != nil {
+		err = t.ArgsOut[GetWebhookByNameMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestRepo) GetWebhooksFiltered(filter *Filter) ([]Webhook, int, error) {
+	t.ArgsIn[GetWebhooksFilteredMethod][0] = filter
+	var webhooks []Webhook
+	if t.ArgsOut[GetWebhooksFilteredMethod][0] != nil {
+		webhooks = t.ArgsOut[GetWebhooksFilteredMethod][0].([]Webhook)
+	}
+	var total int
+	if t.ArgsOut[GetWebhooksFilteredMethod][1] != nil {
+		total = t.ArgsOut[GetWebhooksFilteredMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetWebhooksFilteredMethod][2] != nil {
+		err = t.ArgsOut[GetWebhooksFilteredMethod][2].(error)
+	}
+	return webhooks, total, err
+}
+
+func (t TestRepo) GetWebhooks() ([]Webhook, error) {
+	var webhooks []Webhook
+	if t.ArgsOut[GetWebhooksMethod][0] != nil {
+		webhooks = t.ArgsOut[GetWebhooksMethod][0].([]Webhook)
+	}
+	var err error
+	if t.ArgsOut[GetWebhooksMethod][1] != nil {
+		err = t.ArgsOut[GetWebhooksMethod][1].(error)
+	}
+	return webhooks, err
+}
+
+func (t TestRepo) UpdateWebhook(webhook Webhook) (*Webhook, error) {
+	t.ArgsIn[UpdateWebhookMethod][0] = webhook
+	var updated *Webhook
+	if t.ArgsOut[UpdateWebhookMethod][0] != nil {
+		updated = t.ArgsOut[UpdateWebhookMethod][0].(*Webhook)
+	}
+	var err error
+	if t.ArgsOut[UpdateWebhookMethod][1] != nil {
+		err = t.ArgsOut[UpdateWebhookMethod][1].(error)
+	}
+	return updated, err
+}
+
+func (t TestRepo) RemoveWebhook(id string) error {
+	t.ArgsIn[RemoveWebhookMethod][0] = id
+	var err error
+	if t.ArgsOut[RemoveWebhookMethod][0] != nil {
+		err = t.ArgsOut[RemoveWebhookMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) ClaimWebhookDeliveries(now time.Time, claimUntil time.Time, limit int) ([]WebhookDelivery, error) {
+	t.ArgsIn[ClaimWebhookDeliveriesMethod][0] = now
+	t.ArgsIn[ClaimWebhookDeliveriesMethod][1] = claimUntil
+	t.ArgsIn[ClaimWebhookDeliveriesMethod][2] = limit
+	var deliveries []WebhookDelivery
+	if t.ArgsOut[ClaimWebhookDeliveriesMethod][0] != nil {
+		deliveries = t.ArgsOut[ClaimWebhookDeliveriesMethod][0].([]WebhookDelivery)
+	}
+	var err error
+	if t.ArgsOut[ClaimWebhookDeliveriesMethod][1] != nil {
+		err = t.ArgsOut[ClaimWebhookDeliveriesMethod][1].(error)
+	}
+	return deliveries, err
+}
+
+func (t TestRepo) UpdateWebhookDelivery(delivery WebhookDelivery) (*WebhookDelivery, error) {
+	t.ArgsIn[UpdateWebhookDeliveryMethod][0] = delivery
+	if specialFunc, ok := t.SpecialFuncs[UpdateWebhookDeliveryMethod].(func(delivery WebhookDelivery) (*WebhookDelivery, error)); ok && specialFunc != nil {
+		return specialFunc(delivery)
+	}
+	var updated *WebhookDelivery
+	if t.ArgsOut[UpdateWebhookDeliveryMethod][0] != nil {
+		updated = t.ArgsOut[UpdateWebhookDeliveryMethod][0].(*WebhookDelivery)
+	}
+	var err error
+	if t.ArgsOut[UpdateWebhookDeliveryMethod][1] != nil {
+		err = t.ArgsOut[UpdateWebhookDeliveryMethod][1].(error)
+	}
+	return updated, err
+}
+
+////////////////
+// Snapshot repo
+////////////////
+
+func (t TestRepo) GetAuthzRevision() (int64, error) {
+	var revision int64
+	if t.ArgsOut[GetAuthzRevisionMethod][0] != nil {
+		revision = t.ArgsOut[GetAuthzRevisionMethod][0].(int64)
+	}
+	var err error
+	if t.ArgsOut[GetAuthzRevisionMethod][1] != nil {
+		err = t.ArgsOut[GetAuthzRevisionMethod][1].(error)
+	}
+	return revision, err
+}
+
+func (t TestRepo) GetAuthzSnapshot() (*AuthzSnapshot, error) {
+	if specialFunc, ok := t.SpecialFuncs[GetAuthzSnapshotMethod].(func() (*AuthzSnapshot, error)); ok && specialFunc != nil {
+		return specialFunc()
+	}
+	var snapshot *AuthzSnapshot
+	if t.ArgsOut[GetAuthzSnapshotMethod][0] != nil {
+		snapshot = t.ArgsOut[GetAuthzSnapshotMethod][0].(*AuthzSnapshot)
+	}
+	var err error
+	if t.ArgsOut[GetAuthzSnapshotMethod][1] != nil {
+		err = t.ArgsOut[GetAuthzSnapshotMethod][1].(error)
+	}
+	return snapshot, err
+}
+
+/////////////
+// Event repo
+/////////////
+
+func (t TestRepo) WithEvents(events []AuditEvent, deliveries []WebhookDelivery) interface{} {
+	t.ArgsIn[WithEventsMethod][0] = events
+	t.ArgsIn[WithEventsMethod][1] = deliveries
+	return t
+}
+
 // Private helper methods
 
 func getRandomString(runeValue []rune, n int) string {
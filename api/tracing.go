@@ -0,0 +1,56 @@
+package api
+
+import (
+	"github.com/Tecsisa/foulkon/tracing"
+)
+
+// startSpan starts the span of a WorkerAPI method, child of the calling method span or else of the request span.
+// It returns a copy of the API whose traced repositories calls are children of the new span
+func (api WorkerAPI) startSpan(requestInfo RequestInfo, name string) (WorkerAPI, *tracing.Span) {
+	parent := api.span
+	if parent == nil {
+		parent = requestInfo.Span
+	}
+	span := tracing.StartSpan("WorkerAPI."+name, tracing.KIND_INTERNAL, parent.GetContext())
+	if span == nil {
+		return api, nil
+	}
+	span.SetAttribute("request.id", requestInfo.RequestID)
+
+	api.span = span
+	if repo, ok := api.UserRepo.(TracedRepo); ok {
+		api.UserRepo = repo.WithSpan(span).(UserRepo)
+	}
+	if repo, ok := api.GroupRepo.(TracedRepo); ok {
+		api.GroupRepo = repo.WithSpan(span).(GroupRepo)
+	}
+	if repo, ok := api.PolicyRepo.(TracedRepo); ok {
+		api.PolicyRepo = repo.WithSpan(span).(PolicyRepo)
+	}
+	if repo, ok := api.ProxyRepo.(TracedRepo); ok {
+		api.ProxyRepo = repo.WithSpan(span).(ProxyRepo)
+	}
+	if repo, ok := api.AuthOidcRepo.(TracedRepo); ok {
+		api.AuthOidcRepo = repo.WithSpan(span).(AuthOidcRepo)
+	}
+	if repo, ok := api.PolicyTemplateRepo.(TracedRepo); ok {
+		api.PolicyTemplateRepo = repo.WithSpan(span).(PolicyTemplateRepo)
+	}
+	if repo, ok := api.BreakGlassRepo.(TracedRepo); ok {
+		api.BreakGlassRepo = repo.WithSpan(span).(BreakGlassRepo)
+	}
+	if repo, ok := api.ChangeRequestRepo.(TracedRepo); ok {
+		api.ChangeRequestRepo = repo.WithSpan(span).(ChangeRequestRepo)
+	}
+	if repo, ok := api.AuditRepo.(TracedRepo); ok {
+		api.AuditRepo = repo.WithSpan(span).(AuditRepo)
+	}
+	if repo, ok := api.WebhookRepo.(TracedRepo); ok {
+		api.WebhookRepo = repo.WithSpan(span).(WebhookRepo)
+	}
+	if repo, ok := api.SnapshotRepo.(TracedRepo); ok {
+		api.SnapshotRepo = repo.WithSpan(span).(SnapshotRepo)
+	}
+
+	return api, span
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+// tracedTestRepo is a TestRepo that keeps the span of its calls
+type tracedTestRepo struct {
+	*TestRepo
+	span *tracing.Span
+}
+
+func (r tracedTestRepo) WithSpan(span *tracing.Span) interface{} {
+	r.span = span
+	return r
+}
+
+// testExporter keeps exported spans
+type testExporter struct {
+	lock  sync.Mutex
+	spans []*tracing.Span
+}
+
+func (te *testExporter) Export(span *tracing.Span) {
+	te.lock.Lock()
+	defer te.lock.Unlock()
+	te.spans = append(te.spans, span)
+}
+
+func TestWorkerAPI_startSpan(t *testing.T) {
+	testcases := map[string]struct {
+		disabled    bool
+		requestSpan bool
+		callerSpan  bool
+	}{
+		"OkCaseRequestSpan": {
+			requestSpan: true,
+		},
+		"OkCaseCallerSpan": {
+			requestSpan: true,
+			callerSpan:  true,
+		},
+		"OkCaseNewTrace": {},
+		"OkCaseDisabled": {
+			disabled:    true,
+			requestSpan: true,
+		},
+	}
+
+	for n, test := range testcases {
+		tracing.SetExporter(&testExporter{})
+		testAPI := makeTestAPI(&TestRepo{})
+		repo := tracedTestRepo{TestRepo: &TestRepo{}}
+		testAPI.UserRepo = repo
+		testAPI.WebhookRepo = repo
+		testAPI.SnapshotRepo = repo
+
+		requestInfo := RequestInfo{RequestID: "requestID"}
+		if test.requestSpan {
+			requestInfo.Span = tracing.StartSpan("request", tracing.KIND_SERVER, tracing.SpanContext{})
+		}
+		expectedParent := requestInfo.Span.GetContext()
+		if test.callerSpan {
+			testAPI.span = tracing.StartSpan("caller", tracing.KIND_INTERNAL, expectedParent)
+			expectedParent = testAPI.span.GetContext()
+		}
+		if test.disabled {
+			tracing.SetExporter(nil)
+		}
+
+		tracedAPI, span := testAPI.startSpan(requestInfo, "Test")
+
+		if test.disabled {
+			assert.Nil(t, span, "Error in test case %v", n)
+			assert.Equal(t, *testAPI, tracedAPI, "Error in test case %v", n)
+			continue
+		}
+
+		assert.Equal(t, "WorkerAPI.Test", span.Name, "Error in test case %v", n)
+		assert.Equal(t, "requestID", span.Attributes["request.id"], "Error in test case %v", n)
+		if expectedParent.IsValid() {
+			assert.Equal(t, expectedParent.TraceID, span.Context.TraceID, "Error in test case %v", n)
+			assert.Equal(t, expectedParent.SpanID, span.ParentID, "Error in test case %v", n)
+		} else {
+			assert.Equal(t, "", span.ParentID, "Error in test case %v", n)
+		}
+
+		// Check traced repositories
+		assert.Equal(t, span, tracedAPI.span, "Error in test case %v", n)
+		assert.Equal(t, span, tracedAPI.UserRepo.(tracedTestRepo).span, "Error in test case %v", n)
+		assert.Equal(t, span, tracedAPI.WebhookRepo.(tracedTestRepo).span, "Error in test case %v", n)
+		assert.Equal(t, span, tracedAPI.SnapshotRepo.(tracedTestRepo).span, "Error in test case %v", n)
+		assert.Equal(t, testAPI.GroupRepo, tracedAPI.GroupRepo, "Error in test case %v", n)
+		assert.Nil(t, repo.span, "Error in test case %v", n)
+	}
+	tracing.SetExporter(nil)
+}
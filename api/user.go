@@ -38,6 +38,9 @@ func (u User) GetUrn() string {
 // USER API IMPLEMENTATION
 
 func (api WorkerAPI) AddUser(requestInfo RequestInfo, externalId string, path string) (*User, error) {
+	api, span := api.startSpan(requestInfo, "AddUser")
+	defer span.Finish()
+
 	// Validate fields
 	if !IsValidUserExternalID(externalId) {
 		return nil, &Error{
@@ -76,8 +79,18 @@ func (api WorkerAPI) AddUser(requestInfo RequestInfo, externalId string, path st
 		// User doesn't exist in DB
 		switch dbError.Code {
 		case database.USER_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &user, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, USER_ACTION_CREATE_USER, user.Urn, nil, &user))
+			if err != nil {
+				return nil, err
+			}
+
 			// Create user
-			createdUser, err := api.UserRepo.AddUser(user)
+			createdUser, err := changeAPI.UserRepo.AddUser(user)
 
 			// Check unexpected DB error
 			if err != nil {
@@ -105,6 +118,9 @@ func (api WorkerAPI) AddUser(requestInfo RequestInfo, externalId string, path st
 }
 
 func (api WorkerAPI) GetUserByExternalID(requestInfo RequestInfo, externalId string) (*User, error) {
+	api, span := api.startSpan(requestInfo, "GetUserByExternalID")
+	defer span.Finish()
+
 	if !IsValidUserExternalID(externalId) {
 		return nil, &Error{
 			Code:    INVALID_PARAMETER_ERROR,
@@ -149,6 +165,9 @@ func (api WorkerAPI) GetUserByExternalID(requestInfo RequestInfo, externalId str
 }
 
 func (api WorkerAPI) ListUsers(requestInfo RequestInfo, filter *Filter) ([]string, int, error) {
+	api, span := api.startSpan(requestInfo, "ListUsers")
+	defer span.Finish()
+
 	// Check parameters
 	var total int
 	orderByValidColumns := api.UserRepo.OrderByValidColumns(USER_ACTION_LIST_USERS)
@@ -187,6 +206,9 @@ func (api WorkerAPI) ListUsers(requestInfo RequestInfo, filter *Filter) ([]strin
 }
 
 func (api WorkerAPI) UpdateUser(requestInfo RequestInfo, externalId string, newPath string) (*User, error) {
+	api, span := api.startSpan(requestInfo, "UpdateUser")
+	defer span.Finish()
+
 	if !IsValidPath(newPath) {
 		return nil, &Error{
 			Code:    INVALID_PARAMETER_ERROR,
@@ -239,7 +261,17 @@ func (api WorkerAPI) UpdateUser(requestInfo RequestInfo, externalId string, newP
 		Urn:        auxUser.Urn,
 	}
 
-	updatedUser, err := api.UserRepo.UpdateUser(user)
+	// Check dry run
+	if requestInfo.DryRun {
+		return &user, nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, USER_ACTION_UPDATE_USER, user.Urn, oldUser, &user))
+	if err != nil {
+		return nil, err
+	}
+
+	updatedUser, err := changeAPI.UserRepo.UpdateUser(user)
 
 	// Check unexpected DB error
 	if err != nil {
@@ -257,6 +289,9 @@ func (api WorkerAPI) UpdateUser(requestInfo RequestInfo, externalId string, newP
 }
 
 func (api WorkerAPI) RemoveUser(requestInfo RequestInfo, externalId string) error {
+	api, span := api.startSpan(requestInfo, "RemoveUser")
+	defer span.Finish()
+
 	// Call repo to retrieve the user
 	user, err := api.GetUserByExternalID(requestInfo, externalId)
 	if err != nil {
@@ -276,7 +311,17 @@ func (api WorkerAPI) RemoveUser(requestInfo RequestInfo, externalId string) erro
 		}
 	}
 
-	err = api.UserRepo.RemoveUser(user.ID)
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, USER_ACTION_DELETE_USER, user.Urn, user, nil))
+	if err != nil {
+		return err
+	}
+
+	err = changeAPI.UserRepo.RemoveUser(user.ID)
 
 	// Error handling
 	if err != nil {
@@ -292,6 +337,9 @@ func (api WorkerAPI) RemoveUser(requestInfo RequestInfo, externalId string) erro
 }
 
 func (api WorkerAPI) ListGroupsByUser(requestInfo RequestInfo, filter *Filter) ([]UserGroups, int, error) {
+	api, span := api.startSpan(requestInfo, "ListGroupsByUser")
+	defer span.Finish()
+
 	// Check parameters
 	var total int
 	orderByValidColumns := api.UserRepo.OrderByValidColumns(USER_ACTION_LIST_GROUPS_FOR_USER)
@@ -8,11 +8,16 @@ import (
 
 const (
 	// Resource types
-	RESOURCE_GROUP              = "group"
-	RESOURCE_USER               = "user"
-	RESOURCE_POLICY             = "policy"
-	RESOURCE_PROXY              = "proxy"
-	RESOURCE_AUTH_OIDC_PROVIDER = "oidc"
+	RESOURCE_GROUP               = "group"
+	RESOURCE_USER                = "user"
+	RESOURCE_POLICY              = "policy"
+	RESOURCE_POLICY_TEMPLATE     = "policytemplate"
+	RESOURCE_PROXY               = "proxy"
+	RESOURCE_AUTH_OIDC_PROVIDER  = "oidc"
+	RESOURCE_BREAK_GLASS_SESSION = "breakglass"
+	RESOURCE_CHANGE_REQUEST      = "changerequest"
+	RESOURCE_AUDIT_EVENT         = "audit"
+	RESOURCE_WEBHOOK             = "webhook"
 
 	// Resource validation
 	RESOURCE_EXTERNAL = "external"
@@ -58,6 +63,14 @@ const (
 	POLICY_ACTION_LIST_ATTACHED_GROUPS = "iam:ListAttachedGroups"
 	POLICY_ACTION_LIST_POLICIES        = "iam:ListPolicies"
 
+	// Policy template actions
+	POLICY_TEMPLATE_ACTION_CREATE_POLICY_TEMPLATE      = "iam:CreatePolicyTemplate"
+	POLICY_TEMPLATE_ACTION_DELETE_POLICY_TEMPLATE      = "iam:DeletePolicyTemplate"
+	POLICY_TEMPLATE_ACTION_UPDATE_POLICY_TEMPLATE      = "iam:UpdatePolicyTemplate"
+	POLICY_TEMPLATE_ACTION_GET_POLICY_TEMPLATE         = "iam:GetPolicyTemplate"
+	POLICY_TEMPLATE_ACTION_LIST_POLICY_TEMPLATES       = "iam:ListPolicyTemplates"
+	POLICY_TEMPLATE_ACTION_INSTANTIATE_POLICY_TEMPLATE = "iam:InstantiatePolicyTemplate"
+
 	// Proxy resource actions
 	PROXY_ACTION_CREATE_RESOURCE    = "iam:CreateProxyResource"
 	PROXY_ACTION_DELETE_RESOURCE    = "iam:DeleteProxyResource"
@@ -71,6 +84,28 @@ const (
 	AUTH_OIDC_ACTION_UPDATE_PROVIDER = "auth:UpdateOidcProvider"
 	AUTH_OIDC_ACTION_LIST_PROVIDERS  = "auth:ListOidcProviders"
 	AUTH_OIDC_ACTION_GET_PROVIDER    = "auth:GetOidcProvider"
+
+	// Break-glass actions
+	BREAK_GLASS_ACTION_LIST_SESSIONS    = "auth:ListBreakGlassSessions"
+	BREAK_GLASS_ACTION_ACTIVATE_SESSION = "auth:ActivateBreakGlassSession"
+	BREAK_GLASS_ACTION_USE_SESSION      = "auth:UseBreakGlassSession"
+
+	// Change request actions
+	CHANGE_REQUEST_ACTION_GET_CHANGE_REQUEST     = "iam:GetChangeRequest"
+	CHANGE_REQUEST_ACTION_LIST_CHANGE_REQUESTS   = "iam:ListChangeRequests"
+	CHANGE_REQUEST_ACTION_APPROVE_CHANGE_REQUEST = "iam:ApproveChangeRequest"
+	CHANGE_REQUEST_ACTION_REJECT_CHANGE_REQUEST  = "iam:RejectChangeRequest"
+	CHANGE_REQUEST_ACTION_CREATE_CHANGE_REQUEST  = "iam:CreateChangeRequest"
+
+	// Audit actions
+	AUDIT_ACTION_LIST_EVENTS = "auth:ListAuditEvents"
+
+	// Webhook actions
+	WEBHOOK_ACTION_CREATE_WEBHOOK = "auth:CreateWebhook"
+	WEBHOOK_ACTION_DELETE_WEBHOOK = "auth:DeleteWebhook"
+	WEBHOOK_ACTION_UPDATE_WEBHOOK = "auth:UpdateWebhook"
+	WEBHOOK_ACTION_LIST_WEBHOOKS  = "auth:ListWebhooks"
+	WEBHOOK_ACTION_GET_WEBHOOK    = "auth:GetWebhook"
 )
 
 var (
@@ -88,6 +123,7 @@ var (
 	rUrnExclude, _         = regexp.Compile(`[/]{2,}|[:]{2,}|[*]{2,}`)
 	rPathResource, _       = regexp.Compile(`^/$|^(/([\w*_-]+|:[\w_-]+))+$`)
 	rHost, _               = regexp.Compile(`^https?:/{2}[\w+\/\-_.]+(:\d{1,5})?$`)
+	rColumn, _             = regexp.Compile(`^[a-zA-Z_]\w*(\.[a-zA-Z_]\w*)?$`)
 	rUrnProxy, _           = regexp.Compile(`^\*$|^[\w+\-@.]+\*?$|^[\w+\-@.]+\*?$|^([\w+\-@.]|\{\w+\})+(/?(([\w+\-@.]|\{\w+\})+/)*([\w+\-@.]|\{\w+\})+)?$`)
 )
 
@@ -95,7 +131,7 @@ func CreateUrn(org string, resource string, path string, name string) string {
 	switch resource {
 	case RESOURCE_USER:
 		return fmt.Sprintf("urn:iws:iam::user%v%v", path, name)
-	case RESOURCE_AUTH_OIDC_PROVIDER:
+	case RESOURCE_AUTH_OIDC_PROVIDER, RESOURCE_BREAK_GLASS_SESSION, RESOURCE_AUDIT_EVENT, RESOURCE_WEBHOOK:
 		return fmt.Sprintf("urn:iws:auth::%v%v%v", resource, path, name)
 	default:
 		return fmt.Sprintf("urn:iws:iam:%v:%v%v%v", org, resource, path, name)
@@ -106,7 +142,7 @@ func GetUrnPrefix(org string, resource string, path string) string {
 	switch resource {
 	case RESOURCE_USER:
 		return fmt.Sprintf("urn:iws:iam::user%v*", path)
-	case RESOURCE_AUTH_OIDC_PROVIDER:
+	case RESOURCE_AUTH_OIDC_PROVIDER, RESOURCE_BREAK_GLASS_SESSION, RESOURCE_AUDIT_EVENT, RESOURCE_WEBHOOK:
 		return fmt.Sprintf("urn:iws:auth::%v%v*", resource, path)
 	default:
 		return fmt.Sprintf("urn:iws:iam:%v:%v%v*", org, resource, path)
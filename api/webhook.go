@@ -0,0 +1,702 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Webhook delivery status
+	WEBHOOK_DELIVERY_STATUS_PENDING   = "pending"
+	WEBHOOK_DELIVERY_STATUS_DELIVERED = "delivered"
+	WEBHOOK_DELIVERY_STATUS_FAILED    = "failed"
+
+	// Default retry policy of webhook deliveries
+	DEFAULT_WEBHOOK_MAX_ATTEMPTS = 10
+	DEFAULT_WEBHOOK_BACKOFF      = 10 * time.Second
+	DEFAULT_WEBHOOK_MAX_BACKOFF  = time.Hour
+
+	// Webhook deliveries sent in each delivery round
+	WEBHOOK_DELIVERY_BATCH_SIZE = 100
+	// Time deliveries are claimed by a delivery round when webhook client hasn't timeout
+	DEFAULT_WEBHOOK_DELIVERY_CLAIM = time.Hour
+
+	// Headers of webhook deliveries
+	WEBHOOK_EVENT_HEADER     = "X-Foulkon-Event"
+	WEBHOOK_DELIVERY_HEADER  = "X-Foulkon-Delivery"
+	WEBHOOK_SIGNATURE_HEADER = "X-Foulkon-Signature"
+
+	// Constraints
+	MAX_WEBHOOK_SECRET_LENGTH = 256
+	MAX_WEBHOOK_EVENTS_NUMBER = 50
+)
+
+// TYPE DEFINITIONS
+
+// Retry policy of failed webhook deliveries
+type WebhookRetry struct {
+	// Attempts before a delivery is marked as failed, DEFAULT_WEBHOOK_MAX_ATTEMPTS if not set
+	MaxAttempts int
+	// Wait before the first retry, doubled on each attempt. DEFAULT_WEBHOOK_BACKOFF if not set
+	Backoff time.Duration
+	// Maximum wait between retries, DEFAULT_WEBHOOK_MAX_BACKOFF if not set
+	MaxBackoff time.Duration
+}
+
+// Webhook domain. It subscribes an URL to entity change events
+type Webhook struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+	// Actions of the changes to send, e.g. "iam:AddMember" or "iam:*"
+	Events []string `json:"events,omitempty"`
+	// Key used to sign the deliveries, never returned
+	Secret   string    `json:"-"`
+	Urn      string    `json:"urn,omitempty"`
+	CreateAt time.Time `json:"createAt,omitempty"`
+	UpdateAt time.Time `json:"updateAt,omitempty"`
+}
+
+func (w Webhook) String() string {
+	return fmt.Sprintf("[id: %v, name: %v, url: %v, events: %v, urn: %v, createAt: %v, updateAt: %v]",
+		w.ID, w.Name, w.URL, w.Events, w.Urn, w.CreateAt.Format("2006-01-02 15:04:05 MST"),
+		w.UpdateAt.Format("2006-01-02 15:04:05 MST"))
+}
+
+func (w Webhook) GetUrn() string {
+	return w.Urn
+}
+
+// Webhook delivery stored in the outbox until it is sent or it fails
+type WebhookDelivery struct {
+	ID        string `json:"id,omitempty"`
+	WebhookID string `json:"webhookId,omitempty"`
+	EventID   string `json:"eventId,omitempty"`
+	Event     string `json:"event,omitempty"`
+	// Audit event in JSON format
+	Payload       string    `json:"payload,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Attempts      int       `json:"attempts,omitempty"`
+	LastError     string    `json:"lastError,omitempty"`
+	NextAttemptAt time.Time `json:"nextAttemptAt,omitempty"`
+	CreateAt      time.Time `json:"createAt,omitempty"`
+	UpdateAt      time.Time `json:"updateAt,omitempty"`
+}
+
+func (d WebhookDelivery) String() string {
+	return fmt.Sprintf("[id: %v, webhookId: %v, eventId: %v, event: %v, status: %v, attempts: %v, lastError: %v, nextAttemptAt: %v]",
+		d.ID, d.WebhookID, d.EventID, d.Event, d.Status, d.Attempts, d.LastError,
+		d.NextAttemptAt.Format("2006-01-02 15:04:05 MST"))
+}
+
+// WEBHOOK API IMPLEMENTATION
+
+func (api WorkerAPI) AddWebhook(requestInfo RequestInfo, name string, webhookURL string, events []string, secret string) (*Webhook, error) {
+	api, span := api.startSpan(requestInfo, "AddWebhook")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+	if len(secret) == 0 || len(secret) > MAX_WEBHOOK_SECRET_LENGTH {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: secret, it must have between 1 and %v characters", MAX_WEBHOOK_SECRET_LENGTH),
+		}
+	}
+	if err := validateWebhookFields(webhookURL, events); err != nil {
+		return nil, err
+	}
+
+	webhook := createWebhook(name, webhookURL, events, secret)
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, webhook.Urn, WEBHOOK_ACTION_CREATE_WEBHOOK, []Webhook{webhook})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooksFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, webhook.Urn),
+		}
+	}
+
+	// Check if webhook already exists
+	_, err = api.WebhookRepo.GetWebhookByName(name)
+
+	// Check if webhook could be retrieved
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		// Webhook doesn't exist in DB
+		case database.WEBHOOK_NOT_FOUND:
+			// Check dry run
+			if requestInfo.DryRun {
+				return &webhook, nil
+			}
+
+			changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, WEBHOOK_ACTION_CREATE_WEBHOOK, webhook.Urn, nil, &webhook))
+			if err != nil {
+				return nil, err
+			}
+
+			// Create webhook
+			createdWebhook, err := changeAPI.WebhookRepo.AddWebhook(webhook)
+
+			// Check if there is an unexpected error in DB
+			if err != nil {
+				//Transform to DB error
+				dbError := err.(*database.Error)
+				return nil, &Error{
+					Code:    UNKNOWN_API_ERROR,
+					Message: dbError.Message,
+				}
+			}
+
+			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Webhook created %+v", createdWebhook))
+			return createdWebhook, nil
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	} else { // Fail if webhook exists
+		return nil, &Error{
+			Code:    WEBHOOK_ALREADY_EXIST,
+			Message: fmt.Sprintf("Unable to create webhook, webhook with name %v already exist", name),
+		}
+	}
+}
+
+func (api WorkerAPI) GetWebhookByName(requestInfo RequestInfo, name string) (*Webhook, error) {
+	api, span := api.startSpan(requestInfo, "GetWebhookByName")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+
+	// Call repo to retrieve the webhook
+	webhook, err := api.WebhookRepo.GetWebhookByName(name)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		// Webhook doesn't exist in DB
+		if dbError.Code == database.WEBHOOK_NOT_FOUND {
+			return nil, &Error{
+				Code:    WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		}
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, webhook.Urn, WEBHOOK_ACTION_GET_WEBHOOK, []Webhook{*webhook})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(webhooksFiltered) > 0 {
+		webhookFiltered := webhooksFiltered[0]
+		return &webhookFiltered, nil
+	}
+	return nil, &Error{
+		Code: UNAUTHORIZED_RESOURCES_ERROR,
+		Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+			requestInfo.Identifier, webhook.Urn),
+	}
+}
+
+func (api WorkerAPI) ListWebhooks(requestInfo RequestInfo, filter *Filter) ([]string, int, error) {
+	api, span := api.startSpan(requestInfo, "ListWebhooks")
+	defer span.Finish()
+
+	// Validate fields
+	var total int
+	orderByValidColumns := api.WebhookRepo.OrderByValidColumns(WEBHOOK_ACTION_LIST_WEBHOOKS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the webhooks
+	webhooks, total, err := api.WebhookRepo.GetWebhooksFiltered(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions to list
+	urnPrefix := GetUrnPrefix("", RESOURCE_WEBHOOK, "/")
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, urnPrefix, WEBHOOK_ACTION_LIST_WEBHOOKS, webhooks)
+	if err != nil {
+		return nil, total, err
+	}
+
+	webhookNames := []string{}
+	for _, w := range webhooksFiltered {
+		webhookNames = append(webhookNames, w.Name)
+	}
+
+	return webhookNames, total, nil
+}
+
+func (api WorkerAPI) UpdateWebhook(requestInfo RequestInfo, webhookName string, newName string, newURL string, newEvents []string,
+	newSecret string) (*Webhook, error) {
+	api, span := api.startSpan(requestInfo, "UpdateWebhook")
+	defer span.Finish()
+
+	// Validate fields
+	if !IsValidName(newName) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: new name %v", newName),
+		}
+	}
+	if len(newSecret) > MAX_WEBHOOK_SECRET_LENGTH {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: secret, it must have at most %v characters", MAX_WEBHOOK_SECRET_LENGTH),
+		}
+	}
+	if err := validateWebhookFields(newURL, newEvents); err != nil {
+		return nil, err
+	}
+
+	// Call repo to retrieve the old webhook
+	oldWebhook, err := api.GetWebhookByName(requestInfo, webhookName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, oldWebhook.Urn, WEBHOOK_ACTION_UPDATE_WEBHOOK, []Webhook{*oldWebhook})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooksFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, oldWebhook.Urn),
+		}
+	}
+
+	// Check if webhook with "newName" exists
+	targetWebhook, err := api.GetWebhookByName(requestInfo, newName)
+
+	if err == nil && targetWebhook.ID != oldWebhook.ID {
+		// Webhook already exists
+		return nil, &Error{
+			Code:    WEBHOOK_ALREADY_EXIST,
+			Message: fmt.Sprintf("Webhook name: %v already exists", newName),
+		}
+	}
+
+	if err != nil {
+		if apiError := err.(*Error); apiError.Code != WEBHOOK_BY_NAME_NOT_FOUND {
+			return nil, err
+		}
+	}
+
+	auxWebhook := Webhook{
+		Urn: CreateUrn("", RESOURCE_WEBHOOK, "/", newName),
+	}
+
+	// Check restrictions
+	webhooksFiltered, err = api.GetAuthorizedWebhooks(requestInfo, auxWebhook.Urn, WEBHOOK_ACTION_UPDATE_WEBHOOK, []Webhook{auxWebhook})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooksFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, auxWebhook.Urn),
+		}
+	}
+
+	// Keep the old secret if a new one isn't provided
+	secret := oldWebhook.Secret
+	if len(newSecret) > 0 {
+		secret = newSecret
+	}
+
+	webhook := Webhook{
+		ID:       oldWebhook.ID,
+		Name:     newName,
+		URL:      newURL,
+		Events:   newEvents,
+		Secret:   secret,
+		Urn:      auxWebhook.Urn,
+		CreateAt: oldWebhook.CreateAt,
+		UpdateAt: time.Now().UTC(),
+	}
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return &webhook, nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, WEBHOOK_ACTION_UPDATE_WEBHOOK, webhook.Urn, oldWebhook, &webhook))
+	if err != nil {
+		return nil, err
+	}
+
+	// Update webhook
+	updatedWebhook, err := changeAPI.WebhookRepo.UpdateWebhook(webhook)
+
+	// Check unexpected DB error
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Webhook updated from %+v to %+v",
+		oldWebhook, updatedWebhook))
+	return updatedWebhook, nil
+}
+
+func (api WorkerAPI) RemoveWebhook(requestInfo RequestInfo, name string) error {
+	api, span := api.startSpan(requestInfo, "RemoveWebhook")
+	defer span.Finish()
+
+	// Call repo to retrieve the webhook
+	webhook, err := api.GetWebhookByName(requestInfo, name)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, webhook.Urn, WEBHOOK_ACTION_DELETE_WEBHOOK, []Webhook{*webhook})
+	if err != nil {
+		return err
+	}
+	if len(webhooksFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, webhook.Urn),
+		}
+	}
+
+	// Check dry run
+	if requestInfo.DryRun {
+		return nil
+	}
+
+	changeAPI, err := api.withAuditEvents(createAuditEvent(requestInfo, AUDIT_EVENT_TYPE_CHANGE, WEBHOOK_ACTION_DELETE_WEBHOOK, webhook.Urn, webhook, nil))
+	if err != nil {
+		return err
+	}
+
+	err = changeAPI.WebhookRepo.RemoveWebhook(webhook.ID)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Webhook deleted %v", webhook))
+	return nil
+}
+
+func (api WorkerAPI) DeliverWebhooks() error {
+	now := time.Now().UTC()
+
+	// Call repo to claim the deliveries to send, other workers skip them until the claim expires
+	deliveries, err := api.WebhookRepo.ClaimWebhookDeliveries(now, now.Add(api.webhookDeliveryClaim()), WEBHOOK_DELIVERY_BATCH_SIZE)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	webhooks, err := api.WebhookRepo.GetWebhooks()
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	webhooksByID := make(map[string]Webhook, len(webhooks))
+	for _, w := range webhooks {
+		webhooksByID[w.ID] = w
+	}
+
+	// Every delivery is updated even if others fail, so sent deliveries aren't sent again
+	var updateErr error
+	for _, delivery := range deliveries {
+		webhook, ok := webhooksByID[delivery.WebhookID]
+		if ok {
+			delivery = api.webhookRetry().nextDelivery(delivery, api.sendWebhookDelivery(webhook, delivery), now)
+		} else {
+			// Webhook removed after the delivery was read, it won't be sent
+			delivery.Status = WEBHOOK_DELIVERY_STATUS_FAILED
+			delivery.LastError = fmt.Sprintf("Webhook with id %v not found", delivery.WebhookID)
+			delivery.UpdateAt = now
+		}
+
+		if _, err := api.WebhookRepo.UpdateWebhookDelivery(delivery); err != nil {
+			//Transform to DB error
+			dbError := err.(*database.Error)
+			Log.Errorf("Unable to update webhook delivery %v: %v", delivery, dbError.Message)
+			updateErr = &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+			continue
+		}
+		if delivery.Status == WEBHOOK_DELIVERY_STATUS_FAILED {
+			Log.Errorf("Webhook delivery %v failed: %v", delivery.ID, delivery.LastError)
+		}
+	}
+
+	return updateErr
+}
+
+// SignWebhookPayload returns the signature of a webhook delivery, an hex HMAC-SHA256 of the payload
+// with the webhook secret as key. Receivers must compare it with the X-Foulkon-Signature header.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// PRIVATE HELPER METHODS
+
+// Create a delivery for each webhook subscribed to the changes and alerts
+func (api WorkerAPI) createWebhookDeliveries(events []AuditEvent) ([]WebhookDelivery, error) {
+	if api.WebhookRepo == nil {
+		return nil, nil
+	}
+
+	webhooks, err := api.WebhookRepo.GetWebhooks()
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	deliveries := []WebhookDelivery{}
+	for _, event := range events {
+		if event.Type != AUDIT_EVENT_TYPE_CHANGE && event.Type != AUDIT_EVENT_TYPE_ALERT {
+			continue
+		}
+
+		var payload []byte
+		for _, webhook := range webhooks {
+			if !isWebhookSubscribed(webhook, event.Action) {
+				continue
+			}
+			if payload == nil {
+				if payload, err = json.Marshal(event); err != nil {
+					return nil, &Error{
+						Code:    UNKNOWN_API_ERROR,
+						Message: fmt.Sprintf("Unable to encode event %v: %v", event, err.Error()),
+					}
+				}
+			}
+			deliveries = append(deliveries, createWebhookDelivery(webhook, event, string(payload)))
+		}
+	}
+
+	return deliveries, nil
+}
+
+// Send a delivery to its webhook, any response out of 2xx is an error
+func (api WorkerAPI) sendWebhookDelivery(webhook Webhook, delivery WebhookDelivery) error {
+	client := api.WebhookClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WEBHOOK_EVENT_HEADER, delivery.Event)
+	req.Header.Set(WEBHOOK_DELIVERY_HEADER, delivery.ID)
+	req.Header.Set(WEBHOOK_SIGNATURE_HEADER, SignWebhookPayload(webhook.Secret, []byte(delivery.Payload)))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("Unexpected status code %v", res.StatusCode)
+	}
+	return nil
+}
+
+// Retry policy with defaults for the fields not set
+func (api WorkerAPI) webhookRetry() WebhookRetry {
+	retry := api.WebhookRetry
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = DEFAULT_WEBHOOK_MAX_ATTEMPTS
+	}
+	if retry.Backoff <= 0 {
+		retry.Backoff = DEFAULT_WEBHOOK_BACKOFF
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = DEFAULT_WEBHOOK_MAX_BACKOFF
+	}
+	return retry
+}
+
+// Time deliveries are claimed by a delivery round, enough to send all of them with the client timeout
+func (api WorkerAPI) webhookDeliveryClaim() time.Duration {
+	if api.WebhookClient != nil && api.WebhookClient.Timeout > 0 {
+		return WEBHOOK_DELIVERY_BATCH_SIZE * api.WebhookClient.Timeout
+	}
+	return DEFAULT_WEBHOOK_DELIVERY_CLAIM
+}
+
+// Update the delivery with the result of an attempt, scheduling the next one if it failed
+func (r WebhookRetry) nextDelivery(delivery WebhookDelivery, sendErr error, now time.Time) WebhookDelivery {
+	delivery.Attempts++
+	delivery.UpdateAt = now
+	if sendErr == nil {
+		delivery.Status = WEBHOOK_DELIVERY_STATUS_DELIVERED
+		delivery.LastError = ""
+		return delivery
+	}
+
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= r.MaxAttempts {
+		delivery.Status = WEBHOOK_DELIVERY_STATUS_FAILED
+		return delivery
+	}
+	delivery.NextAttemptAt = now.Add(r.backoff(delivery.Attempts))
+	return delivery
+}
+
+// Wait after the given failed attempts, doubled on each attempt until max backoff
+func (r WebhookRetry) backoff(attempts int) time.Duration {
+	backoff := r.Backoff
+	for i := 1; i < attempts && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.MaxBackoff {
+		return r.MaxBackoff
+	}
+	return backoff
+}
+
+func isWebhookSubscribed(webhook Webhook, action string) bool {
+	return isActionContained(action, webhook.Events)
+}
+
+func validateWebhookFields(webhookURL string, events []string) error {
+	if u, err := url.ParseRequestURI(webhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: url %v", webhookURL),
+		}
+	}
+	if len(events) == 0 || len(events) > MAX_WEBHOOK_EVENTS_NUMBER {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: events, it must have between 1 and %v events", MAX_WEBHOOK_EVENTS_NUMBER),
+		}
+	}
+	if err := AreValidActions(events); err != nil {
+		apiError := err.(*Error)
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: apiError.Message,
+		}
+	}
+	return nil
+}
+
+func createWebhook(name string, webhookURL string, events []string, secret string) Webhook {
+	urn := CreateUrn("", RESOURCE_WEBHOOK, "/", name)
+	webhook := Webhook{
+		ID:       uuid.NewV4().String(),
+		Name:     name,
+		URL:      webhookURL,
+		Events:   events,
+		Secret:   secret,
+		Urn:      urn,
+		CreateAt: time.Now().UTC(),
+		UpdateAt: time.Now().UTC(),
+	}
+
+	return webhook
+}
+
+func createWebhookDelivery(webhook Webhook, event AuditEvent, payload string) WebhookDelivery {
+	now := time.Now().UTC()
+	return WebhookDelivery{
+		ID:            uuid.NewV4().String(),
+		WebhookID:     webhook.ID,
+		EventID:       event.ID,
+		Event:         event.Action,
+		Payload:       payload,
+		Status:        WEBHOOK_DELIVERY_STATUS_PENDING,
+		NextAttemptAt: now,
+		CreateAt:      now,
+		UpdateAt:      now,
+	}
+}
@@ -0,0 +1,1042 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerAPI_AddWebhook(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		name        string
+		url         string
+		events      []string
+		secret      string
+
+		getGroupsByUserIDResult   []TestUserGroupRelation
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+		getUserByExternalIDResult *User
+
+		addWebhookMethodResult       *Webhook
+		getWebhookByNameMethodResult *Webhook
+		wantError                    error
+
+		getWebhookByNameMethodErr error
+		addWebhookMethodErr       error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER, "iam:Update*"},
+			secret: "secret",
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.WEBHOOK_NOT_FOUND,
+			},
+			addWebhookMethodResult: &Webhook{
+				ID:     "test1",
+				Name:   "test",
+				URL:    "https://example.com/hook",
+				Events: []string{GROUP_ACTION_ADD_MEMBER, "iam:Update*"},
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+		},
+		"ErrorCaseWebhookAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER},
+			secret: "secret",
+			getWebhookByNameMethodResult: &Webhook{
+				ID:   "test1",
+				Name: "test",
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_ALREADY_EXIST,
+				Message: "Unable to create webhook, webhook with name test already exist",
+			},
+		},
+		"ErrorCaseInvalidName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "*%~#@|",
+			url:    "https://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER},
+			secret: "secret",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name *%~#@|",
+			},
+		},
+		"ErrorCaseInvalidURL": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "ftp://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER},
+			secret: "secret",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: url ftp://example.com/hook",
+			},
+		},
+		"ErrorCaseEmptyEvents": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			secret: "secret",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: events, it must have between 1 and 50 events",
+			},
+		},
+		"ErrorCaseInvalidEvent": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			events: []string{"iam:**"},
+			secret: "secret",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action, value: iam:**",
+			},
+		},
+		"ErrorCaseEmptySecret": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: secret, it must have between 1 and 256 characters",
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER},
+			secret: "secret",
+			getUserByExternalIDResult: &User{
+				ID:         "123456",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource " +
+					CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+		},
+		"ErrorCaseGetWebhookDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER},
+			secret: "secret",
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseAddWebhookDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			url:    "https://example.com/hook",
+			events: []string{GROUP_ACTION_ADD_MEMBER},
+			secret: "secret",
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.WEBHOOK_NOT_FOUND,
+			},
+			addWebhookMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[AddWebhookMethod][0] = testcase.addWebhookMethodResult
+		testRepo.ArgsOut[AddWebhookMethod][1] = testcase.addWebhookMethodErr
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameMethodResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		webhook, err := testAPI.AddWebhook(testcase.requestInfo, testcase.name, testcase.url, testcase.events, testcase.secret)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.addWebhookMethodResult, webhook)
+		if testcase.wantError == nil {
+			created := testRepo.ArgsIn[AddWebhookMethod][0].(Webhook)
+			assert.Equal(t, testcase.secret, created.Secret, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_GetWebhookByName(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		name        string
+
+		getGroupsByUserIDResult   []TestUserGroupRelation
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+		getUserByExternalIDResult *User
+
+		getWebhookByNameMethodResult *Webhook
+		wantError                    error
+
+		getWebhookByNameMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodResult: &Webhook{
+				ID:   "test1",
+				Name: "test",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+		},
+		"ErrorCaseInvalidName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "*%~#@|",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name *%~#@|",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.WEBHOOK_NOT_FOUND,
+			},
+			wantError: &Error{
+				Code: WEBHOOK_BY_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			name: "test",
+			getWebhookByNameMethodResult: &Webhook{
+				ID:   "test1",
+				Name: "test",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "123456",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource " +
+					CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameMethodResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		webhook, err := testAPI.GetWebhookByName(testcase.requestInfo, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.getWebhookByNameMethodResult, webhook)
+	}
+}
+
+func TestWorkerAPI_ListWebhooks(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		filter      *Filter
+
+		getGroupsByUserIDResult   []TestUserGroupRelation
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+		getUserByExternalIDResult *User
+
+		getWebhooksFilteredMethodResult []Webhook
+		expectedWebhooks                []string
+		totalResult                     int
+		wantError                       error
+
+		getWebhooksFilteredMethodErr error
+	}{
+		"OKCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{},
+			getWebhooksFilteredMethodResult: []Webhook{
+				{
+					ID:   "test1",
+					Name: "test1",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test1"),
+				},
+				{
+					ID:   "test2",
+					Name: "test2",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test2"),
+				},
+			},
+			expectedWebhooks: []string{"test1", "test2"},
+			totalResult:      2,
+		},
+		"OKCaseUser": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{},
+			getWebhooksFilteredMethodResult: []Webhook{
+				{
+					ID:   "test1",
+					Name: "test1",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test1"),
+				},
+				{
+					ID:   "test2",
+					Name: "test2",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test2"),
+				},
+			},
+			expectedWebhooks: []string{"test1"},
+			totalResult:      2,
+			getUserByExternalIDResult: &User{
+				ID:         "123456",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GROUP-USER-ID",
+						Name: "groupUser",
+						Path: "/path/",
+						Urn:  CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "example",
+						Path: "/path/",
+						Urn:  CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									WEBHOOK_ACTION_LIST_WEBHOOKS,
+								},
+								Resources: []string{
+									CreateUrn("", RESOURCE_WEBHOOK, "/", "test1"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseInvalidOrderBy": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				OrderBy: "invalid",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: OrderBy invalid",
+			},
+		},
+		"ErrorCaseDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{},
+			getWebhooksFilteredMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[OrderByValidColumnsMethod][0] = []string{"name"}
+		testRepo.ArgsOut[GetWebhooksFilteredMethod][0] = testcase.getWebhooksFilteredMethodResult
+		testRepo.ArgsOut[GetWebhooksFilteredMethod][1] = testcase.totalResult
+		testRepo.ArgsOut[GetWebhooksFilteredMethod][2] = testcase.getWebhooksFilteredMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		webhooks, total, err := testAPI.ListWebhooks(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedWebhooks, webhooks)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_UpdateWebhook(t *testing.T) {
+	oldWebhook := &Webhook{
+		ID:     "test1",
+		Name:   "test",
+		URL:    "https://example.com/hook",
+		Events: []string{GROUP_ACTION_ADD_MEMBER},
+		Secret: "oldSecret",
+		Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+	}
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		name        string
+		newName     string
+		newURL      string
+		newEvents   []string
+		newSecret   string
+
+		getWebhookByNameMethodSpecialFunc func(string) (*Webhook, error)
+		updateWebhookMethodResult         *Webhook
+		expectedSecret                    string
+		wantError                         error
+
+		updateWebhookMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "test",
+			newName:   "newTest",
+			newURL:    "https://example.com/newhook",
+			newEvents: []string{"iam:*"},
+			newSecret: "newSecret",
+			getWebhookByNameMethodSpecialFunc: func(name string) (*Webhook, error) {
+				if name == "test" {
+					return oldWebhook, nil
+				}
+				return nil, &database.Error{
+					Code: database.WEBHOOK_NOT_FOUND,
+				}
+			},
+			updateWebhookMethodResult: &Webhook{
+				ID:     "test1",
+				Name:   "newTest",
+				URL:    "https://example.com/newhook",
+				Events: []string{"iam:*"},
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/", "newTest"),
+			},
+			expectedSecret: "newSecret",
+		},
+		"OKCaseKeepSecret": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "test",
+			newName:   "test",
+			newURL:    "https://example.com/newhook",
+			newEvents: []string{"iam:*"},
+			getWebhookByNameMethodSpecialFunc: func(name string) (*Webhook, error) {
+				return oldWebhook, nil
+			},
+			updateWebhookMethodResult: &Webhook{
+				ID:     "test1",
+				Name:   "test",
+				URL:    "https://example.com/newhook",
+				Events: []string{"iam:*"},
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+			expectedSecret: "oldSecret",
+		},
+		"ErrorCaseInvalidNewName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "test",
+			newName:   "*%~#@|",
+			newURL:    "https://example.com/newhook",
+			newEvents: []string{"iam:*"},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: new name *%~#@|",
+			},
+		},
+		"ErrorCaseInvalidURL": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "test",
+			newName:   "test",
+			newURL:    "example.com",
+			newEvents: []string{"iam:*"},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: url example.com",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "test",
+			newName:   "test",
+			newURL:    "https://example.com/newhook",
+			newEvents: []string{"iam:*"},
+			getWebhookByNameMethodSpecialFunc: func(name string) (*Webhook, error) {
+				return nil, &database.Error{
+					Code: database.WEBHOOK_NOT_FOUND,
+				}
+			},
+			wantError: &Error{
+				Code: WEBHOOK_BY_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseNewNameAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "test",
+			newName:   "other",
+			newURL:    "https://example.com/newhook",
+			newEvents: []string{"iam:*"},
+			getWebhookByNameMethodSpecialFunc: func(name string) (*Webhook, error) {
+				if name == "test" {
+					return oldWebhook, nil
+				}
+				return &Webhook{
+					ID:   "other1",
+					Name: "other",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "other"),
+				}, nil
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_ALREADY_EXIST,
+				Message: "Webhook name: other already exists",
+			},
+		},
+		"ErrorCaseUpdateWebhookDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "test",
+			newName:   "test",
+			newURL:    "https://example.com/newhook",
+			newEvents: []string{"iam:*"},
+			getWebhookByNameMethodSpecialFunc: func(name string) (*Webhook, error) {
+				return oldWebhook, nil
+			},
+			updateWebhookMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.SpecialFuncs[GetWebhookByNameMethod] = testcase.getWebhookByNameMethodSpecialFunc
+		testRepo.ArgsOut[UpdateWebhookMethod][0] = testcase.updateWebhookMethodResult
+		testRepo.ArgsOut[UpdateWebhookMethod][1] = testcase.updateWebhookMethodErr
+		webhook, err := testAPI.UpdateWebhook(testcase.requestInfo, testcase.name, testcase.newName, testcase.newURL,
+			testcase.newEvents, testcase.newSecret)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.updateWebhookMethodResult, webhook)
+		if testcase.wantError == nil {
+			updated := testRepo.ArgsIn[UpdateWebhookMethod][0].(Webhook)
+			assert.Equal(t, testcase.expectedSecret, updated.Secret, "Error in test case %v", x)
+			assert.Equal(t, oldWebhook.ID, updated.ID, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_RemoveWebhook(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		name        string
+
+		getWebhookByNameMethodResult *Webhook
+		wantError                    error
+
+		getWebhookByNameMethodErr error
+		removeWebhookMethodErr    error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodResult: &Webhook{
+				ID:   "test1",
+				Name: "test",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.WEBHOOK_NOT_FOUND,
+			},
+			wantError: &Error{
+				Code: WEBHOOK_BY_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseRemoveWebhookDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodResult: &Webhook{
+				ID:   "test1",
+				Name: "test",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/", "test"),
+			},
+			removeWebhookMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameMethodResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameMethodErr
+		testRepo.ArgsOut[RemoveWebhookMethod][0] = testcase.removeWebhookMethodErr
+		err := testAPI.RemoveWebhook(testcase.requestInfo, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.getWebhookByNameMethodResult.ID, testRepo.ArgsIn[RemoveWebhookMethod][0], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_DeliverWebhooks(t *testing.T) {
+	var receivedRequest *http.Request
+	var receivedBody []byte
+	statusCode := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = r
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	webhook := Webhook{
+		ID:     "webhook1",
+		Name:   "test",
+		URL:    server.URL,
+		Events: []string{"iam:*"},
+		Secret: "secret",
+	}
+	testcases := map[string]struct {
+		delivery   WebhookDelivery
+		webhooks   []Webhook
+		statusCode int
+		// Expected result
+		expectedStatus   string
+		expectedAttempts int
+		expectedBackoff  time.Duration
+		expectedRequest  bool
+		wantError        error
+		// Manager Errors
+		claimWebhookDeliveriesErr error
+		updateWebhookDeliveryErr  error
+	}{
+		"OkCaseDelivered": {
+			delivery: WebhookDelivery{
+				ID:        "delivery1",
+				WebhookID: "webhook1",
+				Event:     GROUP_ACTION_ADD_MEMBER,
+				Payload:   `{"action":"iam:AddMember"}`,
+				Status:    WEBHOOK_DELIVERY_STATUS_PENDING,
+			},
+			webhooks:         []Webhook{webhook},
+			statusCode:       http.StatusNoContent,
+			expectedStatus:   WEBHOOK_DELIVERY_STATUS_DELIVERED,
+			expectedAttempts: 1,
+			expectedRequest:  true,
+		},
+		"OkCaseRetry": {
+			delivery: WebhookDelivery{
+				ID:        "delivery1",
+				WebhookID: "webhook1",
+				Event:     GROUP_ACTION_ADD_MEMBER,
+				Payload:   `{"action":"iam:AddMember"}`,
+				Status:    WEBHOOK_DELIVERY_STATUS_PENDING,
+				Attempts:  2,
+			},
+			webhooks:         []Webhook{webhook},
+			statusCode:       http.StatusInternalServerError,
+			expectedStatus:   WEBHOOK_DELIVERY_STATUS_PENDING,
+			expectedAttempts: 3,
+			expectedBackoff:  4 * time.Second,
+			expectedRequest:  true,
+		},
+		"OkCaseFailedAfterMaxAttempts": {
+			delivery: WebhookDelivery{
+				ID:        "delivery1",
+				WebhookID: "webhook1",
+				Event:     GROUP_ACTION_ADD_MEMBER,
+				Payload:   `{"action":"iam:AddMember"}`,
+				Status:    WEBHOOK_DELIVERY_STATUS_PENDING,
+				Attempts:  4,
+			},
+			webhooks:         []Webhook{webhook},
+			statusCode:       http.StatusBadGateway,
+			expectedStatus:   WEBHOOK_DELIVERY_STATUS_FAILED,
+			expectedAttempts: 5,
+			expectedRequest:  true,
+		},
+		"OkCaseWebhookRemoved": {
+			delivery: WebhookDelivery{
+				ID:        "delivery1",
+				WebhookID: "webhook1",
+				Event:     GROUP_ACTION_ADD_MEMBER,
+				Payload:   `{"action":"iam:AddMember"}`,
+				Status:    WEBHOOK_DELIVERY_STATUS_PENDING,
+			},
+			expectedStatus: WEBHOOK_DELIVERY_STATUS_FAILED,
+		},
+		"ErrorCaseClaimDeliveriesDBErr": {
+			claimWebhookDeliveriesErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseUpdateDeliveryDBErr": {
+			delivery: WebhookDelivery{
+				ID:        "delivery1",
+				WebhookID: "webhook1",
+				Event:     GROUP_ACTION_ADD_MEMBER,
+				Payload:   `{"action":"iam:AddMember"}`,
+				Status:    WEBHOOK_DELIVERY_STATUS_PENDING,
+			},
+			webhooks:   []Webhook{webhook},
+			statusCode: http.StatusOK,
+			updateWebhookDeliveryErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			expectedRequest: true,
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.WebhookRetry = WebhookRetry{
+			MaxAttempts: 5,
+			Backoff:     time.Second,
+			MaxBackoff:  time.Minute,
+		}
+		receivedRequest = nil
+		statusCode = testcase.statusCode
+
+		if testcase.claimWebhookDeliveriesErr == nil {
+			testRepo.ArgsOut[ClaimWebhookDeliveriesMethod][0] = []WebhookDelivery{testcase.delivery}
+		}
+		testRepo.ArgsOut[ClaimWebhookDeliveriesMethod][1] = testcase.claimWebhookDeliveriesErr
+		testRepo.ArgsOut[GetWebhooksMethod][0] = testcase.webhooks
+		testRepo.ArgsOut[UpdateWebhookDeliveryMethod][1] = testcase.updateWebhookDeliveryErr
+		err := testAPI.DeliverWebhooks()
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+
+		// Check claim
+		now := testRepo.ArgsIn[ClaimWebhookDeliveriesMethod][0].(time.Time)
+		assert.Equal(t, now.Add(DEFAULT_WEBHOOK_DELIVERY_CLAIM), testRepo.ArgsIn[ClaimWebhookDeliveriesMethod][1], "Error in test case %v", x)
+		assert.Equal(t, WEBHOOK_DELIVERY_BATCH_SIZE, testRepo.ArgsIn[ClaimWebhookDeliveriesMethod][2], "Error in test case %v", x)
+
+		if testcase.expectedRequest {
+			if assert.NotNil(t, receivedRequest, "Error in test case %v", x) {
+				assert.Equal(t, testcase.delivery.Payload, string(receivedBody), "Error in test case %v", x)
+				assert.Equal(t, testcase.delivery.Event, receivedRequest.Header.Get(WEBHOOK_EVENT_HEADER), "Error in test case %v", x)
+				assert.Equal(t, testcase.delivery.ID, receivedRequest.Header.Get(WEBHOOK_DELIVERY_HEADER), "Error in test case %v", x)
+				assert.Equal(t, SignWebhookPayload("secret", []byte(testcase.delivery.Payload)),
+					receivedRequest.Header.Get(WEBHOOK_SIGNATURE_HEADER), "Error in test case %v", x)
+			}
+		} else {
+			assert.Nil(t, receivedRequest, "Error in test case %v", x)
+		}
+
+		if testcase.wantError == nil {
+			updated := testRepo.ArgsIn[UpdateWebhookDeliveryMethod][0].(WebhookDelivery)
+			assert.Equal(t, testcase.expectedStatus, updated.Status, "Error in test case %v", x)
+			assert.Equal(t, testcase.expectedAttempts, updated.Attempts, "Error in test case %v", x)
+			if testcase.expectedBackoff > 0 {
+				assert.WithinDuration(t, time.Now().UTC().Add(testcase.expectedBackoff), updated.NextAttemptAt, time.Second,
+					"Error in test case %v", x)
+			}
+		}
+	}
+}
+
+func TestWorkerAPI_DeliverWebhooksUpdateError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+	testAPI.WebhookClient = &http.Client{Timeout: time.Second}
+	testRepo.ArgsOut[ClaimWebhookDeliveriesMethod][0] = []WebhookDelivery{
+		{ID: "delivery1", WebhookID: "webhook1", Status: WEBHOOK_DELIVERY_STATUS_PENDING},
+		{ID: "delivery2", WebhookID: "webhook1", Status: WEBHOOK_DELIVERY_STATUS_PENDING},
+	}
+	testRepo.ArgsOut[GetWebhooksMethod][0] = []Webhook{{ID: "webhook1", URL: server.URL, Secret: "secret"}}
+	updated := []WebhookDelivery{}
+	testRepo.SpecialFuncs[UpdateWebhookDeliveryMethod] = func(delivery WebhookDelivery) (*WebhookDelivery, error) {
+		if delivery.ID == "delivery1" {
+			return nil, &database.Error{Code: database.INTERNAL_ERROR}
+		}
+		updated = append(updated, delivery)
+		return &delivery, nil
+	}
+
+	// Failed update doesn't stop the delivery round
+	err := testAPI.DeliverWebhooks()
+	checkMethodResponse(t, "ErrorCaseUpdateDeliveryDBErr", &Error{Code: UNKNOWN_API_ERROR}, err, nil, nil)
+	assert.Equal(t, 2, requests, "Error in test")
+	if assert.Len(t, updated, 1, "Error in test") {
+		assert.Equal(t, "delivery2", updated[0].ID, "Error in test")
+		assert.Equal(t, WEBHOOK_DELIVERY_STATUS_DELIVERED, updated[0].Status, "Error in test")
+	}
+
+	// Claim lasts until the whole batch can be sent with client timeout
+	now := testRepo.ArgsIn[ClaimWebhookDeliveriesMethod][0].(time.Time)
+	assert.Equal(t, now.Add(WEBHOOK_DELIVERY_BATCH_SIZE*time.Second), testRepo.ArgsIn[ClaimWebhookDeliveriesMethod][1], "Error in test")
+}
+
+func TestWorkerAPI_createWebhookDeliveries(t *testing.T) {
+	testcases := map[string]struct {
+		action   string
+		webhooks []Webhook
+		// Expected result
+		expectedWebhookID string
+		wantError         error
+		// Manager Errors
+		getWebhooksErr error
+	}{
+		"OkCaseSubscribed": {
+			action: GROUP_ACTION_ADD_MEMBER,
+			webhooks: []Webhook{
+				{
+					ID:     "webhook1",
+					Events: []string{POLICY_ACTION_UPDATE_POLICY},
+				},
+				{
+					ID:     "webhook2",
+					Events: []string{"iam:Add*"},
+				},
+			},
+			expectedWebhookID: "webhook2",
+		},
+		"OkCaseNotSubscribed": {
+			action: GROUP_ACTION_ADD_MEMBER,
+			webhooks: []Webhook{
+				{
+					ID:     "webhook1",
+					Events: []string{POLICY_ACTION_UPDATE_POLICY},
+				},
+			},
+		},
+		"ErrorCaseGetWebhooksDBErr": {
+			action: GROUP_ACTION_ADD_MEMBER,
+			getWebhooksErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetWebhooksMethod][0] = testcase.webhooks
+		testRepo.ArgsOut[GetWebhooksMethod][1] = testcase.getWebhooksErr
+		event := createAuditEvent(RequestInfo{Identifier: "123456"}, AUDIT_EVENT_TYPE_CHANGE, testcase.action,
+			CreateUrn("example", RESOURCE_GROUP, "/path/", "group1"), nil, nil)
+		deliveries, err := testAPI.createWebhookDeliveries([]AuditEvent{event})
+		if testcase.wantError != nil {
+			apiError, _ := err.(*Error)
+			assert.Equal(t, testcase.wantError, apiError, "Error in test case %v", x)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", x)
+
+		if testcase.expectedWebhookID == "" {
+			assert.Empty(t, deliveries, "Error in test case %v", x)
+			continue
+		}
+		if assert.Len(t, deliveries, 1, "Error in test case %v", x) {
+			delivery := deliveries[0]
+			assert.Equal(t, testcase.expectedWebhookID, delivery.WebhookID, "Error in test case %v", x)
+			assert.Equal(t, event.ID, delivery.EventID, "Error in test case %v", x)
+			assert.Equal(t, testcase.action, delivery.Event, "Error in test case %v", x)
+			assert.Equal(t, WEBHOOK_DELIVERY_STATUS_PENDING, delivery.Status, "Error in test case %v", x)
+			assert.Contains(t, delivery.Payload, event.ID, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_createWebhookDeliveriesDecisionsAreIgnored(t *testing.T) {
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	testRepo.ArgsOut[GetWebhooksMethod][0] = []Webhook{
+		{
+			ID:     "webhook1",
+			Events: []string{"*"},
+		},
+	}
+	deliveries, err := testAPI.createWebhookDeliveries([]AuditEvent{
+		createAuditEvent(RequestInfo{Identifier: "123456"}, AUDIT_EVENT_TYPE_DECISION, "example:get",
+			"urn:ews:example:instance1:resource/res1", nil, AUDIT_DECISION_ALLOW),
+	})
+
+	assert.Nil(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestWebhookRetry_backoff(t *testing.T) {
+	retry := WebhookRetry{
+		Backoff:    10 * time.Second,
+		MaxBackoff: time.Minute,
+	}
+	testcases := map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		3:  40 * time.Second,
+		4:  time.Minute,
+		50: time.Minute,
+	}
+	for attempts, expected := range testcases {
+		assert.Equal(t, expected, retry.backoff(attempts), "Error with attempts %v", attempts)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Signature computed with: echo -n '{"a":1}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494",
+		SignWebhookPayload("secret", []byte(`{"a":1}`)))
+	assert.NotEqual(t, SignWebhookPayload("secret", []byte(`{"a":1}`)), SignWebhookPayload("other", []byte(`{"a":1}`)))
+}
@@ -0,0 +1,185 @@
+package authorizer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const (
+	// Authorizer error codes
+	SNAPSHOT_NOT_LOADED_ERROR = "SnapshotNotLoadedError"
+	SNAPSHOT_TOO_OLD_ERROR    = "SnapshotTooOldError"
+
+	// Default configuration
+	DEFAULT_REFRESH_INTERVAL = 30 * time.Second
+	DEFAULT_MAX_AGE          = 5 * time.Minute
+)
+
+// Source retrieves the authorization snapshot. When version is the current one, it returns
+// a nil snapshot and false. *client.Client implements it
+type Source interface {
+	GetAuthzSnapshot(version string) (*api.AuthzSnapshot, bool, error)
+}
+
+// Config of the Authorizer
+type Config struct {
+	// Time between snapshot synchronizations
+	RefreshInterval time.Duration
+	// Max time since last successful synchronization to keep answering. After it, all requests fail
+	MaxAge time.Duration
+	// Logger for synchronization errors. If nil, logrus standard logger is used
+	Logger *logrus.Logger
+}
+
+// Authorizer evaluates authorization requests in process, using a snapshot synchronized with the worker.
+// Decisions are the same the worker takes with the snapshot data
+type Authorizer struct {
+	source Source
+	config Config
+
+	mutex    sync.RWMutex
+	snapshot *api.AuthzSnapshot
+	syncAt   time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	now      func() time.Time
+}
+
+// New returns an Authorizer that synchronizes the snapshot from source. Zero values in config are
+// replaced with the defaults
+func New(source Source, config Config) *Authorizer {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = DEFAULT_REFRESH_INTERVAL
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = DEFAULT_MAX_AGE
+	}
+	if config.Logger == nil {
+		config.Logger = logrus.StandardLogger()
+	}
+	return &Authorizer{
+		source: source,
+		config: config,
+		stop:   make(chan struct{}),
+		now:    time.Now,
+	}
+}
+
+// Start synchronizes the snapshot and keeps it updated in background until Stop is called.
+// It returns the error of the first synchronization
+func (a *Authorizer) Start() error {
+	err := a.Sync()
+
+	go func() {
+		ticker := time.NewTicker(a.config.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.Sync(); err != nil {
+					a.config.Logger.Errorf("Error synchronizing authorization snapshot: %v", err)
+				}
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+
+	return err
+}
+
+// Stop ends the background synchronization
+func (a *Authorizer) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.stop)
+	})
+}
+
+// Sync retrieves the snapshot from source if it changed since last synchronization
+func (a *Authorizer) Sync() error {
+	snapshot, modified, err := a.source.GetAuthzSnapshot(a.Version())
+	if err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if modified {
+		a.snapshot = snapshot
+	}
+	a.syncAt = a.now()
+
+	return nil
+}
+
+// Version returns the version of current snapshot, or empty if it isn't loaded yet
+func (a *Authorizer) Version() string {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if a.snapshot == nil {
+		return ""
+	}
+	return a.snapshot.Version
+}
+
+// IsAllowed checks if the user is allowed to do the action over the resource
+func (a *Authorizer) IsAllowed(externalID string, action string, resource string) (bool, error) {
+	resources, err := a.GetAuthorizedExternalResources(externalID, action, []string{resource})
+	if err != nil {
+		if apiError := err.(*api.Error); apiError.Code == api.UNAUTHORIZED_RESOURCES_ERROR {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, allowed := range resources {
+		if allowed == resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetAuthorizedExternalResources returns the resources the user is allowed to do the action over.
+// When none is allowed, the error has the api.UNAUTHORIZED_RESOURCES_ERROR code
+func (a *Authorizer) GetAuthorizedExternalResources(externalID string, action string, resources []string) ([]string, error) {
+	snapshot, err := a.getSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.GetAuthorizedExternalResources(externalID, action, resources)
+}
+
+// GetRestrictions returns the restrictions the user has for the action and full resource or prefix resource
+func (a *Authorizer) GetRestrictions(externalID string, action string, resource string) (*api.Restrictions, error) {
+	snapshot, err := a.getSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.GetRestrictions(externalID, action, resource)
+}
+
+// PRIVATE HELPER METHODS
+
+// getSnapshot returns current snapshot, failing when it isn't loaded or it is too old to be trusted
+func (a *Authorizer) getSnapshot() (*api.AuthzSnapshot, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if a.snapshot == nil {
+		return nil, &api.Error{
+			Code:    SNAPSHOT_NOT_LOADED_ERROR,
+			Message: "Authorization snapshot not loaded",
+		}
+	}
+	if age := a.now().Sub(a.syncAt); age > a.config.MaxAge {
+		return nil, &api.Error{
+			Code:    SNAPSHOT_TOO_OLD_ERROR,
+			Message: fmt.Sprintf("Authorization snapshot %v is too old, last synchronization %v ago", a.snapshot.Version, age),
+		}
+	}
+	return a.snapshot, nil
+}
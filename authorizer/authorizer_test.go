@@ -0,0 +1,253 @@
+package authorizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSource returns the configured snapshot, saving the received version
+type TestSource struct {
+	snapshot *api.AuthzSnapshot
+	modified bool
+	err      error
+
+	version string
+}
+
+func (s *TestSource) GetAuthzSnapshot(version string) (*api.AuthzSnapshot, bool, error) {
+	s.version = version
+	return s.snapshot, s.modified, s.err
+}
+
+func makeTestSnapshot(version string) *api.AuthzSnapshot {
+	return &api.AuthzSnapshot{
+		Version: version,
+		Users: []api.SnapshotUser{
+			{
+				ExternalID: "user1",
+				Groups:     []string{"GROUP1"},
+			},
+		},
+		Groups: []api.SnapshotGroup{
+			{
+				ID:       "GROUP1",
+				Policies: []string{"POLICY1"},
+			},
+		},
+		Policies: []api.Policy{
+			{
+				ID: "POLICY1",
+				Statements: &[]api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{"product:DoAction"},
+						Resources: []string{"urn:ews:product:instance:resource/path1*"},
+					},
+					{
+						Effect:    "deny",
+						Actions:   []string{"product:DoAction"},
+						Resources: []string{"urn:ews:product:instance:resource/path1/resourceDeny"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	authorizer := New(&TestSource{}, Config{})
+	assert.Equal(t, DEFAULT_REFRESH_INTERVAL, authorizer.config.RefreshInterval)
+	assert.Equal(t, DEFAULT_MAX_AGE, authorizer.config.MaxAge)
+	assert.NotNil(t, authorizer.config.Logger)
+	assert.Equal(t, "", authorizer.Version())
+}
+
+func TestAuthorizer_Sync(t *testing.T) {
+	testcases := map[string]struct {
+		// Current snapshot
+		snapshot *api.AuthzSnapshot
+		// Source response
+		sourceSnapshot *api.AuthzSnapshot
+		sourceModified bool
+		sourceError    error
+		// Expected result
+		expectedSourceVersion string
+		expectedVersion       string
+		expectedSync          bool
+		wantError             error
+	}{
+		"OkCaseFirstSync": {
+			sourceSnapshot:  makeTestSnapshot("v1"),
+			sourceModified:  true,
+			expectedVersion: "v1",
+			expectedSync:    true,
+		},
+		"OkCaseModified": {
+			snapshot:              makeTestSnapshot("v1"),
+			sourceSnapshot:        makeTestSnapshot("v2"),
+			sourceModified:        true,
+			expectedSourceVersion: "v1",
+			expectedVersion:       "v2",
+			expectedSync:          true,
+		},
+		"OkCaseNotModified": {
+			snapshot:              makeTestSnapshot("v1"),
+			expectedSourceVersion: "v1",
+			expectedVersion:       "v1",
+			expectedSync:          true,
+		},
+		"ErrorCaseSourceError": {
+			snapshot: makeTestSnapshot("v1"),
+			sourceError: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			expectedSourceVersion: "v1",
+			expectedVersion:       "v1",
+			wantError: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	for n, test := range testcases {
+		source := &TestSource{
+			snapshot: test.sourceSnapshot,
+			modified: test.sourceModified,
+			err:      test.sourceError,
+		}
+		authorizer := New(source, Config{})
+		authorizer.snapshot = test.snapshot
+		authorizer.now = func() time.Time { return now }
+
+		err := authorizer.Sync()
+		assert.Equal(t, test.wantError, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedSourceVersion, source.version, "Error in test case %v", n)
+		assert.Equal(t, test.expectedVersion, authorizer.Version(), "Error in test case %v", n)
+		assert.Equal(t, test.expectedSync, authorizer.syncAt.Equal(now), "Error in test case %v", n)
+	}
+}
+
+func TestAuthorizer_IsAllowed(t *testing.T) {
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	testcases := map[string]struct {
+		// Authorizer state
+		snapshot *api.AuthzSnapshot
+		syncAt   time.Time
+		// Request
+		externalID string
+		action     string
+		resource   string
+		// Expected result
+		expectedAllowed bool
+		wantError       error
+	}{
+		"OkCaseAllowed": {
+			snapshot:        makeTestSnapshot("v1"),
+			syncAt:          now.Add(-time.Minute),
+			externalID:      "user1",
+			action:          "product:DoAction",
+			resource:        "urn:ews:product:instance:resource/path1/resourceAllow",
+			expectedAllowed: true,
+		},
+		"OkCaseDenied": {
+			snapshot:   makeTestSnapshot("v1"),
+			syncAt:     now.Add(-time.Minute),
+			externalID: "user1",
+			action:     "product:DoAction",
+			resource:   "urn:ews:product:instance:resource/path1/resourceDeny",
+		},
+		"OkCaseNotAllowed": {
+			snapshot:   makeTestSnapshot("v1"),
+			syncAt:     now.Add(-time.Minute),
+			externalID: "user1",
+			action:     "product:DoAction",
+			resource:   "urn:ews:product:instance:resource/path2/resource",
+		},
+		"OkCaseUnknownUser": {
+			snapshot:   makeTestSnapshot("v1"),
+			syncAt:     now.Add(-time.Minute),
+			externalID: "unknown",
+			action:     "product:DoAction",
+			resource:   "urn:ews:product:instance:resource/path1/resourceAllow",
+		},
+		"ErrorCaseSnapshotNotLoaded": {
+			externalID: "user1",
+			action:     "product:DoAction",
+			resource:   "urn:ews:product:instance:resource/path1/resourceAllow",
+			wantError: &api.Error{
+				Code:    SNAPSHOT_NOT_LOADED_ERROR,
+				Message: "Authorization snapshot not loaded",
+			},
+		},
+		"ErrorCaseSnapshotTooOld": {
+			snapshot:   makeTestSnapshot("v1"),
+			syncAt:     now.Add(-DEFAULT_MAX_AGE - time.Second),
+			externalID: "user1",
+			action:     "product:DoAction",
+			resource:   "urn:ews:product:instance:resource/path1/resourceAllow",
+			wantError: &api.Error{
+				Code:    SNAPSHOT_TOO_OLD_ERROR,
+				Message: "Authorization snapshot v1 is too old, last synchronization 5m1s ago",
+			},
+		},
+		"ErrorCaseInvalidAction": {
+			snapshot:   makeTestSnapshot("v1"),
+			syncAt:     now.Add(-time.Minute),
+			externalID: "user1",
+			action:     "product::DoAction",
+			resource:   "urn:ews:product:instance:resource/path1/resourceAllow",
+			wantError: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action, value: product::DoAction",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		authorizer := New(&TestSource{}, Config{})
+		authorizer.snapshot = test.snapshot
+		authorizer.syncAt = test.syncAt
+		authorizer.now = func() time.Time { return now }
+
+		allowed, err := authorizer.IsAllowed(test.externalID, test.action, test.resource)
+		if test.wantError != nil {
+			assert.Equal(t, test.wantError, err, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedAllowed, allowed, "Error in test case %v", n)
+	}
+}
+
+func TestAuthorizer_GetRestrictions(t *testing.T) {
+	authorizer := New(&TestSource{}, Config{})
+	authorizer.snapshot = makeTestSnapshot("v1")
+	authorizer.syncAt = authorizer.now()
+
+	restrictions, err := authorizer.GetRestrictions("user1", "product:DoAction", "urn:*")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"urn:ews:product:instance:resource/path1*"}, restrictions.AllowedUrnPrefixes)
+	assert.Equal(t, []string{"urn:ews:product:instance:resource/path1/resourceDeny"}, restrictions.DeniedFullUrns)
+}
+
+func TestAuthorizer_StartStop(t *testing.T) {
+	source := &TestSource{
+		snapshot: makeTestSnapshot("v1"),
+		modified: true,
+	}
+	authorizer := New(source, Config{RefreshInterval: time.Hour})
+
+	err := authorizer.Start()
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", authorizer.Version())
+
+	// Stop can be called several times
+	authorizer.Stop()
+	authorizer.Stop()
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// OIDC PROVIDER API
+
+// AddOidcProvider creates an OIDC provider
+func (c *Client) AddOidcProvider(request internalhttp.CreateOidcProviderRequest) (*api.OidcProvider, error) {
+	response := &api.OidcProvider{}
+	if err := c.do(http.MethodPost, internalhttp.OIDC_AUTH_ROOT_URL, nil, request, response, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetOidcProviderByName retrieves the OIDC provider with the name
+func (c *Client) GetOidcProviderByName(name string) (*api.OidcProvider, error) {
+	response := &api.OidcProvider{}
+	path := urlPath(internalhttp.OIDC_AUTH_ID_URL, internalhttp.AUTH_PROVIDER_NAME, name)
+	if err := c.do(http.MethodGet, path, nil, nil, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ListOidcProviders iterates over the names of the OIDC providers
+func (c *Client) ListOidcProviders(filter *api.Filter) *StringIterator {
+	return newStringIterator(filter, func(query url.Values) ([]string, int, error) {
+		response := internalhttp.ListOidcProvidersResponse{}
+		err := c.do(http.MethodGet, internalhttp.OIDC_AUTH_ROOT_URL, query, nil, &response, http.StatusOK)
+		return response.Providers, response.Total, err
+	})
+}
+
+// UpdateOidcProvider updates the OIDC provider with the name
+func (c *Client) UpdateOidcProvider(name string, request internalhttp.UpdateOidcProviderRequest) (*api.OidcProvider, error) {
+	response := &api.OidcProvider{}
+	path := urlPath(internalhttp.OIDC_AUTH_ID_URL, internalhttp.AUTH_PROVIDER_NAME, name)
+	if err := c.do(http.MethodPut, path, nil, request, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RemoveOidcProvider deletes the OIDC provider with the name
+func (c *Client) RemoveOidcProvider(name string) error {
+	path := urlPath(internalhttp.OIDC_AUTH_ID_URL, internalhttp.AUTH_PROVIDER_NAME, name)
+	return c.do(http.MethodDelete, path, nil, nil, nil, http.StatusNoContent)
+}
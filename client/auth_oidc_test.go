@@ -0,0 +1,88 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+func TestClient_OidcProviderAPI(t *testing.T) {
+	provider := &api.OidcProvider{
+		ID:          "1234",
+		Name:        "provider1",
+		Path:        "/path/",
+		Urn:         api.CreateUrn("", api.RESOURCE_AUTH_OIDC_PROVIDER, "/path/", "provider1"),
+		IssuerURL:   "https://issuer.com",
+		OidcClients: []api.OidcClient{{Name: "client1"}},
+	}
+	testcases := map[string]clientTestCase{
+		"OkCaseAddOidcProvider": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddOidcProvider(internalhttp.CreateOidcProviderRequest{Name: "provider1", Path: "/path/",
+					IssuerURL: "https://issuer.com", OidcClients: []string{"client1"}})
+			},
+			statusCode:     http.StatusCreated,
+			response:       provider,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/admin/auth/oidc/providers",
+			expectedBody: internalhttp.CreateOidcProviderRequest{Name: "provider1", Path: "/path/",
+				IssuerURL: "https://issuer.com", OidcClients: []string{"client1"}},
+			expectedResult: provider,
+		},
+		"OkCaseGetOidcProviderByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetOidcProviderByName("provider1")
+			},
+			statusCode:     http.StatusOK,
+			response:       provider,
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/auth/oidc/providers/provider1",
+			expectedResult: provider,
+		},
+		"OkCaseListOidcProviders": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListOidcProviders(nil)
+				providers := []string{}
+				for it.Next() {
+					providers = append(providers, it.Value())
+				}
+				return providers, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListOidcProvidersResponse{
+				Providers: []string{"provider1"},
+				Limit:     20,
+				Total:     1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/auth/oidc/providers",
+			expectedQuery:  "Offset=0",
+			expectedResult: []string{"provider1"},
+		},
+		"OkCaseUpdateOidcProvider": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateOidcProvider("provider1", internalhttp.UpdateOidcProviderRequest{Name: "provider1", Path: "/path/",
+					IssuerURL: "https://issuer.com", OidcClients: []string{"client1"}})
+			},
+			statusCode:     http.StatusOK,
+			response:       provider,
+			expectedMethod: http.MethodPut,
+			expectedPath:   "/api/v1/admin/auth/oidc/providers/provider1",
+			expectedBody: internalhttp.UpdateOidcProviderRequest{Name: "provider1", Path: "/path/",
+				IssuerURL: "https://issuer.com", OidcClients: []string{"client1"}},
+			expectedResult: provider,
+		},
+		"OkCaseRemoveOidcProvider": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveOidcProvider("provider1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/admin/auth/oidc/providers/provider1",
+		},
+	}
+
+	checkClientTestCases(t, testcases)
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// AUTHORIZATION API
+
+// GetAuthorizedExternalResources returns the resources the authenticated user is allowed to do the action over.
+// When none is allowed, the error has the api.UNAUTHORIZED_RESOURCES_ERROR code
+func (c *Client) GetAuthorizedExternalResources(request internalhttp.AuthorizeResourcesRequest) ([]string, error) {
+	response := internalhttp.AuthorizeResourcesResponse{}
+	if err := c.do(http.MethodPost, internalhttp.RESOURCE_URL, nil, request, &response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response.ResourcesAllowed, nil
+}
+
+// IsAuthorized checks if the authenticated user is allowed to do the action over the resource
+func (c *Client) IsAuthorized(action string, resource string) (bool, error) {
+	resources, err := c.GetAuthorizedExternalResources(internalhttp.AuthorizeResourcesRequest{
+		Action:    action,
+		Resources: []string{resource},
+	})
+	if err != nil {
+		if apiError := err.(*api.Error); apiError.Code == api.UNAUTHORIZED_RESOURCES_ERROR {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, allowed := range resources {
+		if allowed == resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetAuthorizedRestrictions returns the restrictions the authenticated user has for the action under the urn prefix,
+// with the SQL predicate that applies them when the request has a column
+func (c *Client) GetAuthorizedRestrictions(request internalhttp.AuthorizeRestrictionsRequest) (*api.AuthorizedRestrictions, error) {
+	response := &api.AuthorizedRestrictions{}
+	if err := c.do(http.MethodPost, internalhttp.RESOURCE_RESTRICTIONS_URL, nil, request, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+func TestClient_AuthzAPI(t *testing.T) {
+	restrictions := &api.AuthorizedRestrictions{
+		Action:    "example:get",
+		UrnPrefix: "urn:ews:example:instance1:resource/",
+		Restrictions: &api.Restrictions{
+			AllowedUrnPrefixes: []string{"urn:ews:example:instance1:resource/"},
+		},
+		Predicate: &api.Predicate{
+			Where: "(urn LIKE ?)",
+			Args:  []string{"urn:ews:example:instance1:resource/%"},
+		},
+	}
+	testcases := map[string]clientTestCase{
+		"OkCaseGetAuthorizedExternalResources": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetAuthorizedExternalResources(internalhttp.AuthorizeResourcesRequest{
+					Action:    "example:get",
+					Resources: []string{"urn:ews:example:instance1:resource/get", "urn:ews:example:instance1:resource/list"},
+				})
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.AuthorizeResourcesResponse{
+				ResourcesAllowed: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource",
+			expectedBody: internalhttp.AuthorizeResourcesRequest{
+				Action:    "example:get",
+				Resources: []string{"urn:ews:example:instance1:resource/get", "urn:ews:example:instance1:resource/list"},
+			},
+			expectedResult: []string{"urn:ews:example:instance1:resource/get"},
+		},
+		"OkCaseIsAuthorized": {
+			call: func(c *Client) (interface{}, error) {
+				return c.IsAuthorized("example:get", "urn:ews:example:instance1:resource/get")
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.AuthorizeResourcesResponse{
+				ResourcesAllowed: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource",
+			expectedBody: internalhttp.AuthorizeResourcesRequest{
+				Action:    "example:get",
+				Resources: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedResult: true,
+		},
+		"OkCaseIsAuthorizedDenied": {
+			call: func(c *Client) (interface{}, error) {
+				return c.IsAuthorized("example:get", "urn:ews:example:instance1:resource/get")
+			},
+			statusCode: http.StatusForbidden,
+			response: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource",
+			expectedBody: internalhttp.AuthorizeResourcesRequest{
+				Action:    "example:get",
+				Resources: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedResult: false,
+		},
+		"OkCaseGetAuthorizedRestrictions": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetAuthorizedRestrictions(internalhttp.AuthorizeRestrictionsRequest{
+					Action:    "example:get",
+					UrnPrefix: "urn:ews:example:instance1:resource/",
+					Column:    "urn",
+				})
+			},
+			statusCode:     http.StatusOK,
+			response:       restrictions,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource/restrictions",
+			expectedBody: internalhttp.AuthorizeRestrictionsRequest{
+				Action:    "example:get",
+				UrnPrefix: "urn:ews:example:instance1:resource/",
+				Column:    "urn",
+			},
+			expectedResult: restrictions,
+		},
+		"ErrorCaseIsAuthorizedInvalidParameter": {
+			call: func(c *Client) (interface{}, error) {
+				return c.IsAuthorized("example:get", "invalid")
+			},
+			statusCode: http.StatusBadRequest,
+			response: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource",
+			expectedBody: internalhttp.AuthorizeResourcesRequest{
+				Action:    "example:get",
+				Resources: []string{"invalid"},
+			},
+			expectedError: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+		},
+	}
+
+	checkClientTestCases(t, testcases)
+}
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// Authenticator adds the client credentials to the worker requests
+type Authenticator interface {
+	Authenticate(r *http.Request)
+}
+
+// AuthenticatorFunc allows to use a function as Authenticator
+type AuthenticatorFunc func(r *http.Request)
+
+// Authenticate calls f(r)
+func (f AuthenticatorFunc) Authenticate(r *http.Request) {
+	f(r)
+}
+
+// BasicAuth returns an Authenticator that uses basic authentication, like the admin user
+func BasicAuth(username string, password string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) {
+		r.SetBasicAuth(username, password)
+	})
+}
+
+// BearerToken returns an Authenticator that sends the token in the Authorization header, like OIDC tokens
+func BearerToken(token string) Authenticator {
+	return HeaderAuth("Authorization", "Bearer "+token)
+}
+
+// HeaderAuth returns an Authenticator that sends the value in the header, like the header authenticator connector
+func HeaderAuth(header string, value string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) {
+		r.Header.Set(header, value)
+	})
+}
+
+// Client gives typed access to the worker API. All methods return *api.Error errors,
+// with the code returned by the worker
+type Client struct {
+	host       string
+	auth       Authenticator
+	httpClient *http.Client
+}
+
+// NewClient returns a Client for the worker in host, like https://foulkon.example.com. If httpClient is nil,
+// http.DefaultClient is used
+func NewClient(host string, auth Authenticator, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		host:       strings.TrimSuffix(host, "/"),
+		auth:       auth,
+		httpClient: httpClient,
+	}
+}
+
+// PRIVATE HELPER METHODS
+
+// do sends the request to the worker and decodes the response when the status code is the expected one
+func (c *Client) do(method string, path string, query url.Values, request interface{}, response interface{}, statusCode int) error {
+	req, err := c.newRequest(method, path, query, request)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != statusCode {
+		return getResponseError(res)
+	}
+
+	if response != nil {
+		return decodeResponse(res, response)
+	}
+
+	return nil
+}
+
+// newRequest builds an authenticated request to the worker, with the request encoded as JSON body
+func (c *Client) newRequest(method string, path string, query url.Values, request interface{}) (*http.Request, error) {
+	var body io.Reader
+	if request != nil {
+		b, err := json.Marshal(request)
+		if err != nil {
+			return nil, &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: err.Error(),
+			}
+		}
+		body = bytes.NewBuffer(b)
+	}
+
+	u := c.host + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, &api.Error{
+			Code:    api.INVALID_PARAMETER_ERROR,
+			Message: err.Error(),
+		}
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		c.auth.Authenticate(req)
+	}
+
+	return req, nil
+}
+
+// send sends the request to the worker. Caller must close the response body
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &api.Error{
+			Code:    internalhttp.HOST_UNREACHABLE,
+			Message: err.Error(),
+		}
+	}
+	return res, nil
+}
+
+// decodeResponse decodes the JSON body of the response
+func decodeResponse(res *http.Response, response interface{}) error {
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		return &api.Error{
+			Code:    api.UNKNOWN_API_ERROR,
+			Message: fmt.Sprintf("Error parsing foulkon response %v", err.Error()),
+		}
+	}
+	return nil
+}
+
+// getResponseError retrieves the API error from the response, or builds one from its status code
+// when the worker didn't send it, like in authentication errors
+func getResponseError(res *http.Response) error {
+	apiError := &api.Error{}
+	if err := json.NewDecoder(res.Body).Decode(apiError); err == nil && apiError.Code != "" {
+		return apiError
+	}
+
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		apiError.Code = api.AUTHENTICATION_API_ERROR
+	case http.StatusForbidden:
+		apiError.Code = api.UNAUTHORIZED_RESOURCES_ERROR
+	default:
+		apiError.Code = api.UNKNOWN_API_ERROR
+	}
+	apiError.Message = fmt.Sprintf("Unexpected status code %v", res.StatusCode)
+
+	return apiError
+}
+
+// urlPath replaces the parameters of the url template with the escaped values, given as name and value pairs
+func urlPath(template string, params ...string) string {
+	path := template
+	for i := 0; i+1 < len(params); i += 2 {
+		path = strings.Replace(path, internalhttp.URI_PATH_PREFIX+params[i], "/"+url.PathEscape(params[i+1]), 1)
+	}
+	return path
+}
+
+// filterQuery transforms the filter into the query parameters of list requests
+func filterQuery(filter *api.Filter) url.Values {
+	query := url.Values{}
+	if filter == nil {
+		return query
+	}
+	if filter.PathPrefix != "" {
+		query.Set("PathPrefix", filter.PathPrefix)
+	}
+	if filter.Org != "" {
+		query.Set("Org", filter.Org)
+	}
+	if filter.OrderBy != "" {
+		query.Set("OrderBy", filter.OrderBy)
+	}
+	if filter.Limit > 0 {
+		query.Set("Limit", strconv.Itoa(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		query.Set("Offset", strconv.Itoa(filter.Offset))
+	}
+	return query
+}
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+	"github.com/stretchr/testify/assert"
+)
+
+var server *httptest.Server
+var handler http.HandlerFunc
+
+// Request received by test server
+type testRequest struct {
+	method string
+	path   string
+	query  string
+	body   string
+	header http.Header
+}
+
+// Client method call with the response of the test server and the expected request and result
+type clientTestCase struct {
+	call func(c *Client) (interface{}, error)
+	// Test server response
+	statusCode int
+	response   interface{}
+	// Expected request
+	expectedMethod string
+	expectedPath   string
+	expectedQuery  string
+	expectedBody   interface{}
+	// Expected result
+	expectedResult interface{}
+	expectedError  *api.Error
+}
+
+// Main Test that executes at first time and create all necessary data to work
+func TestMain(m *testing.M) {
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r)
+	}))
+
+	// Run tests
+	result := m.Run()
+
+	server.Close()
+
+	// Exit tests.
+	os.Exit(result)
+}
+
+func TestClient_Do(t *testing.T) {
+	testcases := map[string]struct {
+		// Test server response
+		statusCode  int
+		contentType string
+		response    string
+		// Expected result
+		expectedResult *api.User
+		expectedError  *api.Error
+	}{
+		"OkCase": {
+			statusCode: http.StatusOK,
+			response:   `{"externalId":"user1","path":"/path/"}`,
+			expectedResult: &api.User{
+				ExternalID: "user1",
+				Path:       "/path/",
+			},
+		},
+		"ErrorCaseApiError": {
+			statusCode: http.StatusNotFound,
+			response:   `{"code":"UserWithExternalIDNotFound","message":"User not found"}`,
+			expectedError: &api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User not found",
+			},
+		},
+		"ErrorCaseChangeRequestPending": {
+			statusCode: http.StatusAccepted,
+			response:   `{"code":"ChangeRequestPending","message":"Change request 1234 pending"}`,
+			expectedError: &api.Error{
+				Code:    api.CHANGE_REQUEST_PENDING,
+				Message: "Change request 1234 pending",
+			},
+		},
+		"ErrorCaseUnauthenticated": {
+			statusCode: http.StatusUnauthorized,
+			response:   "Authentication failed\n",
+			expectedError: &api.Error{
+				Code:    api.AUTHENTICATION_API_ERROR,
+				Message: "Unexpected status code 401",
+			},
+		},
+		"ErrorCaseForbiddenWithoutBody": {
+			statusCode: http.StatusForbidden,
+			expectedError: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unexpected status code 403",
+			},
+		},
+		"ErrorCaseInternalServerErrorWithoutBody": {
+			statusCode: http.StatusInternalServerError,
+			expectedError: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Unexpected status code 500",
+			},
+		},
+		"ErrorCaseInvalidResponse": {
+			statusCode: http.StatusOK,
+			response:   "invalid",
+			expectedError: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error parsing foulkon response invalid character 'i' looking for beginning of value",
+			},
+		},
+	}
+
+	client := NewClient(server.URL, nil, nil)
+	for n, test := range testcases {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.statusCode)
+			w.Write([]byte(test.response))
+		}
+
+		user, err := client.GetUserByExternalID("user1")
+		if test.expectedError != nil {
+			assert.Equal(t, test.expectedError, err, "Error in test case %v", n)
+			assert.Nil(t, user, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResult, user, "Error in test case %v", n)
+	}
+}
+
+func TestClient_DoHostUnreachable(t *testing.T) {
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	_, err := NewClient(unreachable.URL, nil, nil).GetUserByExternalID("user1")
+	apiError, ok := err.(*api.Error)
+	assert.True(t, ok)
+	assert.Equal(t, internalhttp.HOST_UNREACHABLE, apiError.Code)
+}
+
+func TestAuthenticators(t *testing.T) {
+	testcases := map[string]struct {
+		auth Authenticator
+		// Expected headers
+		expectedHeader string
+		expectedValue  string
+	}{
+		"OkCaseBasicAuth": {
+			auth:           BasicAuth("admin", "admin"),
+			expectedHeader: "Authorization",
+			expectedValue:  "Basic YWRtaW46YWRtaW4=",
+		},
+		"OkCaseBearerToken": {
+			auth:           BearerToken("token"),
+			expectedHeader: "Authorization",
+			expectedValue:  "Bearer token",
+		},
+		"OkCaseHeaderAuth": {
+			auth:           HeaderAuth("X-Remote-User", "user1"),
+			expectedHeader: "X-Remote-User",
+			expectedValue:  "user1",
+		},
+		"OkCaseAuthenticatorFunc": {
+			auth: AuthenticatorFunc(func(r *http.Request) {
+				r.Header.Set("X-Custom", "custom")
+			}),
+			expectedHeader: "X-Custom",
+			expectedValue:  "custom",
+		},
+	}
+
+	for n, test := range testcases {
+		request := serveResponse(http.StatusNoContent, nil)
+		err := NewClient(server.URL+"/", test.auth, nil).RemoveUser("user1")
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedValue, request.header.Get(test.expectedHeader), "Error in test case %v", n)
+		assert.Equal(t, internalhttp.USER_ROOT_URL+"/user1", request.path, "Error in test case %v", n)
+	}
+}
+
+func TestUrlPath(t *testing.T) {
+	testcases := map[string]struct {
+		template string
+		params   []string
+		// Expected result
+		expectedPath string
+	}{
+		"OkCaseNoParams": {
+			template:     internalhttp.USER_ROOT_URL,
+			expectedPath: "/api/v1/users",
+		},
+		"OkCaseSeveralParams": {
+			template:     internalhttp.GROUP_ID_USERS_ID_URL,
+			params:       []string{internalhttp.ORG_NAME, "org1", internalhttp.GROUP_NAME, "group1", internalhttp.USER_ID, "user1"},
+			expectedPath: "/api/v1/organizations/org1/groups/group1/users/user1",
+		},
+		"OkCaseEscapedParams": {
+			template:     internalhttp.USER_ID_URL,
+			params:       []string{internalhttp.USER_ID, "user 1/2"},
+			expectedPath: "/api/v1/users/user%201%2F2",
+		},
+	}
+
+	for n, test := range testcases {
+		assert.Equal(t, test.expectedPath, urlPath(test.template, test.params...), "Error in test case %v", n)
+	}
+}
+
+// Aux functions
+
+// serveResponse makes test server answer with the status code and response, and returns the request it receives
+func serveResponse(statusCode int, response interface{}) *testRequest {
+	request := &testRequest{}
+	handler = responseHandler(statusCode, response, request)
+	return request
+}
+
+// responseHandler answers with the status code and response, saving the received request
+func responseHandler(statusCode int, response interface{}, request *testRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*request = testRequest{
+			method: r.Method,
+			path:   r.URL.EscapedPath(),
+			query:  r.URL.RawQuery,
+			body:   string(body),
+			header: r.Header,
+		}
+		if response != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statusCode)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+		w.WriteHeader(statusCode)
+	}
+}
+
+func checkClientTestCases(t *testing.T, testcases map[string]clientTestCase) {
+	client := NewClient(server.URL, BasicAuth("admin", "admin"), nil)
+	for n, test := range testcases {
+		request := serveResponse(test.statusCode, test.response)
+
+		result, err := test.call(client)
+
+		// Check request
+		assert.Equal(t, test.expectedMethod, request.method, "Error in test case %v", n)
+		assert.Equal(t, test.expectedPath, request.path, "Error in test case %v", n)
+		assert.Equal(t, test.expectedQuery, request.query, "Error in test case %v", n)
+		if test.expectedBody != nil {
+			body, _ := json.Marshal(test.expectedBody)
+			assert.JSONEq(t, string(body), request.body, "Error in test case %v", n)
+		} else {
+			assert.Empty(t, request.body, "Error in test case %v", n)
+		}
+
+		// Check result
+		if test.expectedError != nil {
+			assert.Equal(t, test.expectedError, err, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResult, result, "Error in test case %v", n)
+	}
+}
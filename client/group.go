@@ -0,0 +1,119 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// GROUP API
+
+// AddGroup creates a group in the organization
+func (c *Client) AddGroup(org string, request internalhttp.CreateGroupRequest) (*api.Group, error) {
+	response := &api.Group{}
+	path := urlPath(internalhttp.GROUP_ORG_ROOT_URL, internalhttp.ORG_NAME, org)
+	if err := c.do(http.MethodPost, path, nil, request, response, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetGroupByName retrieves the group with the name in the organization
+func (c *Client) GetGroupByName(org string, name string) (*api.Group, error) {
+	response := &api.Group{}
+	path := urlPath(internalhttp.GROUP_ID_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, name)
+	if err := c.do(http.MethodGet, path, nil, nil, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ListGroups iterates over the names of the groups in the organization
+func (c *Client) ListGroups(org string, filter *api.Filter) *StringIterator {
+	path := urlPath(internalhttp.GROUP_ORG_ROOT_URL, internalhttp.ORG_NAME, org)
+	return newStringIterator(filter, func(query url.Values) ([]string, int, error) {
+		response := internalhttp.ListGroupsResponse{}
+		err := c.do(http.MethodGet, path, query, nil, &response, http.StatusOK)
+		return response.Groups, response.Total, err
+	})
+}
+
+// ListAllGroups iterates over the groups of all organizations, or the organization in the filter
+func (c *Client) ListAllGroups(filter *api.Filter) *GroupIdentityIterator {
+	return newGroupIdentityIterator(filter, func(query url.Values) ([]api.GroupIdentity, int, error) {
+		response := internalhttp.ListAllGroupsResponse{}
+		err := c.do(http.MethodGet, internalhttp.API_VERSION_1+"/groups", query, nil, &response, http.StatusOK)
+		return response.Groups, response.Total, err
+	})
+}
+
+// UpdateGroup updates the group with the name in the organization
+func (c *Client) UpdateGroup(org string, name string, request internalhttp.UpdateGroupRequest) (*api.Group, error) {
+	response := &api.Group{}
+	path := urlPath(internalhttp.GROUP_ID_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, name)
+	if err := c.do(http.MethodPut, path, nil, request, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RemoveGroup deletes the group with the name in the organization
+func (c *Client) RemoveGroup(org string, name string) error {
+	path := urlPath(internalhttp.GROUP_ID_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, name)
+	return c.do(http.MethodDelete, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// MEMBER API
+
+// AddMember adds the user with the external id to the group
+func (c *Client) AddMember(org string, groupName string, externalID string) error {
+	path := urlPath(internalhttp.GROUP_ID_USERS_ID_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, groupName,
+		internalhttp.USER_ID, externalID)
+	return c.do(http.MethodPost, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// RemoveMember removes the user with the external id from the group
+func (c *Client) RemoveMember(org string, groupName string, externalID string) error {
+	path := urlPath(internalhttp.GROUP_ID_USERS_ID_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, groupName,
+		internalhttp.USER_ID, externalID)
+	return c.do(http.MethodDelete, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// ListMembers iterates over the members of the group
+func (c *Client) ListMembers(org string, groupName string, filter *api.Filter) *GroupMemberIterator {
+	path := urlPath(internalhttp.GROUP_ID_USERS_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, groupName)
+	return newGroupMemberIterator(filter, func(query url.Values) ([]api.GroupMembers, int, error) {
+		response := internalhttp.ListMembersResponse{}
+		err := c.do(http.MethodGet, path, query, nil, &response, http.StatusOK)
+		return response.Members, response.Total, err
+	})
+}
+
+// GROUP POLICIES API
+
+// AttachPolicyToGroup attaches the policy to the group. When the change needs approval,
+// the error has the api.CHANGE_REQUEST_PENDING code
+func (c *Client) AttachPolicyToGroup(org string, groupName string, policyName string) error {
+	path := urlPath(internalhttp.GROUP_ID_POLICIES_ID_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, groupName,
+		internalhttp.POLICY_NAME, policyName)
+	return c.do(http.MethodPost, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// DetachPolicyToGroup detaches the policy from the group
+func (c *Client) DetachPolicyToGroup(org string, groupName string, policyName string) error {
+	path := urlPath(internalhttp.GROUP_ID_POLICIES_ID_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, groupName,
+		internalhttp.POLICY_NAME, policyName)
+	return c.do(http.MethodDelete, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// ListAttachedGroupPolicies iterates over the policies attached to the group
+func (c *Client) ListAttachedGroupPolicies(org string, groupName string, filter *api.Filter) *GroupPolicyIterator {
+	path := urlPath(internalhttp.GROUP_ID_POLICIES_URL, internalhttp.ORG_NAME, org, internalhttp.GROUP_NAME, groupName)
+	return newGroupPolicyIterator(filter, func(query url.Values) ([]api.GroupPolicies, int, error) {
+		response := internalhttp.ListAttachedGroupPoliciesResponse{}
+		err := c.do(http.MethodGet, path, query, nil, &response, http.StatusOK)
+		return response.AttachedPolicies, response.Total, err
+	})
+}
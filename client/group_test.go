@@ -0,0 +1,191 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+func TestClient_GroupAPI(t *testing.T) {
+	group := &api.Group{
+		ID:   "1234",
+		Name: "group1",
+		Path: "/path/",
+		Org:  "org1",
+		Urn:  api.CreateUrn("org1", api.RESOURCE_GROUP, "/path/", "group1"),
+	}
+	testcases := map[string]clientTestCase{
+		"OkCaseAddGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddGroup("org1", internalhttp.CreateGroupRequest{Name: "group1", Path: "/path/"})
+			},
+			statusCode:     http.StatusCreated,
+			response:       group,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups",
+			expectedBody:   internalhttp.CreateGroupRequest{Name: "group1", Path: "/path/"},
+			expectedResult: group,
+		},
+		"OkCaseGetGroupByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetGroupByName("org1", "group1")
+			},
+			statusCode:     http.StatusOK,
+			response:       group,
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1",
+			expectedResult: group,
+		},
+		"OkCaseListGroups": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListGroups("org1", &api.Filter{OrderBy: "name"})
+				groups := []string{}
+				for it.Next() {
+					groups = append(groups, it.Value())
+				}
+				return groups, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListGroupsResponse{
+				Groups: []string{"group1"},
+				Limit:  20,
+				Total:  1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups",
+			expectedQuery:  "Offset=0&OrderBy=name",
+			expectedResult: []string{"group1"},
+		},
+		"OkCaseListAllGroups": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListAllGroups(&api.Filter{Org: "org1"})
+				groups := []api.GroupIdentity{}
+				for it.Next() {
+					groups = append(groups, it.Value())
+				}
+				return groups, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListAllGroupsResponse{
+				Groups: []api.GroupIdentity{{Org: "org1", Name: "group1"}},
+				Limit:  20,
+				Total:  1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/groups",
+			expectedQuery:  "Offset=0&Org=org1",
+			expectedResult: []api.GroupIdentity{{Org: "org1", Name: "group1"}},
+		},
+		"OkCaseUpdateGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateGroup("org1", "group1", internalhttp.UpdateGroupRequest{Name: "group1", Path: "/path/"})
+			},
+			statusCode:     http.StatusOK,
+			response:       group,
+			expectedMethod: http.MethodPut,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1",
+			expectedBody:   internalhttp.UpdateGroupRequest{Name: "group1", Path: "/path/"},
+			expectedResult: group,
+		},
+		"OkCaseRemoveGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveGroup("org1", "group1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1",
+		},
+		"OkCaseAddMember": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.AddMember("org1", "group1", "user1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/users/user1",
+		},
+		"OkCaseRemoveMember": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveMember("org1", "group1", "user1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/users/user1",
+		},
+		"OkCaseListMembers": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListMembers("org1", "group1", nil)
+				members := []api.GroupMembers{}
+				for it.Next() {
+					members = append(members, it.Value())
+				}
+				return members, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListMembersResponse{
+				Members: []api.GroupMembers{{User: "user1"}},
+				Limit:   20,
+				Total:   1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/users",
+			expectedQuery:  "Offset=0",
+			expectedResult: []api.GroupMembers{{User: "user1"}},
+		},
+		"OkCaseAttachPolicyToGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.AttachPolicyToGroup("org1", "group1", "policy1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/policies/policy1",
+		},
+		"OkCaseDetachPolicyToGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.DetachPolicyToGroup("org1", "group1", "policy1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/policies/policy1",
+		},
+		"OkCaseListAttachedGroupPolicies": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListAttachedGroupPolicies("org1", "group1", nil)
+				policies := []api.GroupPolicies{}
+				for it.Next() {
+					policies = append(policies, it.Value())
+				}
+				return policies, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListAttachedGroupPoliciesResponse{
+				AttachedPolicies: []api.GroupPolicies{{Policy: "policy1"}},
+				Limit:            20,
+				Total:            1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/policies",
+			expectedQuery:  "Offset=0",
+			expectedResult: []api.GroupPolicies{{Policy: "policy1"}},
+		},
+		"ErrorCaseAttachPolicyToGroupPending": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.AttachPolicyToGroup("org1", "group1", "policy1")
+			},
+			statusCode: http.StatusAccepted,
+			response: api.Error{
+				Code:    api.CHANGE_REQUEST_PENDING,
+				Message: "Change request 1234 pending",
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/policies/policy1",
+			expectedError: &api.Error{
+				Code:    api.CHANGE_REQUEST_PENDING,
+				Message: "Change request 1234 pending",
+			},
+		},
+	}
+
+	checkClientTestCases(t, testcases)
+}
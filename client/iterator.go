@@ -0,0 +1,224 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// listFunc retrieves the page of a list request with the query, and returns the page size and the total of elements
+type listFunc func(query url.Values) (int, int, error)
+
+// pager walks the pages of a list request, using the Limit of the filter as page size
+type pager struct {
+	query url.Values
+	list  listFunc
+
+	// Position in current page
+	pos  int
+	size int
+	// Offset of next page
+	offset  int
+	total   int
+	fetched bool
+
+	err error
+}
+
+func newPager(filter *api.Filter, list listFunc) pager {
+	p := pager{
+		query: filterQuery(filter),
+		list:  list,
+		pos:   -1,
+	}
+	if filter != nil {
+		p.offset = filter.Offset
+	}
+	return p
+}
+
+// Next advances to the next element, retrieving the next page when current one is consumed.
+// It returns false when there aren't more elements or an error happens
+func (p *pager) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	p.pos++
+	if p.pos < p.size {
+		return true
+	}
+
+	// Current page consumed, retrieve next one
+	if p.fetched && p.offset >= p.total {
+		return false
+	}
+	p.query.Set("Offset", strconv.Itoa(p.offset))
+	size, total, err := p.list(p.query)
+	p.fetched = true
+	if err != nil {
+		p.err = err
+		return false
+	}
+	p.pos, p.size, p.total = 0, size, total
+	p.offset += size
+
+	return size > 0
+}
+
+// Total returns the total of elements reported by the worker in the last page
+func (p *pager) Total() int {
+	return p.total
+}
+
+// Err returns the error that stopped the iteration, if any
+func (p *pager) Err() error {
+	return p.err
+}
+
+// StringIterator iterates over the identifiers returned by a list request
+type StringIterator struct {
+	pager
+	page []string
+}
+
+// Value returns the current element
+func (it *StringIterator) Value() string {
+	return it.page[it.pos]
+}
+
+func newStringIterator(filter *api.Filter, list func(query url.Values) ([]string, int, error)) *StringIterator {
+	it := &StringIterator{}
+	it.pager = newPager(filter, func(query url.Values) (int, int, error) {
+		page, total, err := list(query)
+		it.page = page
+		return len(page), total, err
+	})
+	return it
+}
+
+// UserGroupIterator iterates over the groups of a user
+type UserGroupIterator struct {
+	pager
+	page []api.UserGroups
+}
+
+// Value returns the current element
+func (it *UserGroupIterator) Value() api.UserGroups {
+	return it.page[it.pos]
+}
+
+func newUserGroupIterator(filter *api.Filter, list func(query url.Values) ([]api.UserGroups, int, error)) *UserGroupIterator {
+	it := &UserGroupIterator{}
+	it.pager = newPager(filter, func(query url.Values) (int, int, error) {
+		page, total, err := list(query)
+		it.page = page
+		return len(page), total, err
+	})
+	return it
+}
+
+// GroupIdentityIterator iterates over the groups of all organizations
+type GroupIdentityIterator struct {
+	pager
+	page []api.GroupIdentity
+}
+
+// Value returns the current element
+func (it *GroupIdentityIterator) Value() api.GroupIdentity {
+	return it.page[it.pos]
+}
+
+func newGroupIdentityIterator(filter *api.Filter, list func(query url.Values) ([]api.GroupIdentity, int, error)) *GroupIdentityIterator {
+	it := &GroupIdentityIterator{}
+	it.pager = newPager(filter, func(query url.Values) (int, int, error) {
+		page, total, err := list(query)
+		it.page = page
+		return len(page), total, err
+	})
+	return it
+}
+
+// GroupMemberIterator iterates over the members of a group
+type GroupMemberIterator struct {
+	pager
+	page []api.GroupMembers
+}
+
+// Value returns the current element
+func (it *GroupMemberIterator) Value() api.GroupMembers {
+	return it.page[it.pos]
+}
+
+func newGroupMemberIterator(filter *api.Filter, list func(query url.Values) ([]api.GroupMembers, int, error)) *GroupMemberIterator {
+	it := &GroupMemberIterator{}
+	it.pager = newPager(filter, func(query url.Values) (int, int, error) {
+		page, total, err := list(query)
+		it.page = page
+		return len(page), total, err
+	})
+	return it
+}
+
+// GroupPolicyIterator iterates over the policies attached to a group
+type GroupPolicyIterator struct {
+	pager
+	page []api.GroupPolicies
+}
+
+// Value returns the current element
+func (it *GroupPolicyIterator) Value() api.GroupPolicies {
+	return it.page[it.pos]
+}
+
+func newGroupPolicyIterator(filter *api.Filter, list func(query url.Values) ([]api.GroupPolicies, int, error)) *GroupPolicyIterator {
+	it := &GroupPolicyIterator{}
+	it.pager = newPager(filter, func(query url.Values) (int, int, error) {
+		page, total, err := list(query)
+		it.page = page
+		return len(page), total, err
+	})
+	return it
+}
+
+// PolicyIdentityIterator iterates over the policies of all organizations
+type PolicyIdentityIterator struct {
+	pager
+	page []api.PolicyIdentity
+}
+
+// Value returns the current element
+func (it *PolicyIdentityIterator) Value() api.PolicyIdentity {
+	return it.page[it.pos]
+}
+
+func newPolicyIdentityIterator(filter *api.Filter, list func(query url.Values) ([]api.PolicyIdentity, int, error)) *PolicyIdentityIterator {
+	it := &PolicyIdentityIterator{}
+	it.pager = newPager(filter, func(query url.Values) (int, int, error) {
+		page, total, err := list(query)
+		it.page = page
+		return len(page), total, err
+	})
+	return it
+}
+
+// PolicyGroupIterator iterates over the groups a policy is attached to
+type PolicyGroupIterator struct {
+	pager
+	page []api.PolicyGroups
+}
+
+// Value returns the current element
+func (it *PolicyGroupIterator) Value() api.PolicyGroups {
+	return it.page[it.pos]
+}
+
+func newPolicyGroupIterator(filter *api.Filter, list func(query url.Values) ([]api.PolicyGroups, int, error)) *PolicyGroupIterator {
+	it := &PolicyGroupIterator{}
+	it.pager = newPager(filter, func(query url.Values) (int, int, error) {
+		page, total, err := list(query)
+		it.page = page
+		return len(page), total, err
+	})
+	return it
+}
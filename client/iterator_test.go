@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringIterator(t *testing.T) {
+	testcases := map[string]struct {
+		filter *api.Filter
+		// Test server data
+		users      []string
+		failOffset int
+		// Expected result
+		expectedUsers   []string
+		expectedOffsets []string
+		expectedError   *api.Error
+	}{
+		"OkCaseSeveralPages": {
+			filter:          &api.Filter{Limit: 2},
+			users:           []string{"user1", "user2", "user3", "user4", "user5"},
+			failOffset:      -1,
+			expectedUsers:   []string{"user1", "user2", "user3", "user4", "user5"},
+			expectedOffsets: []string{"0", "2", "4"},
+		},
+		"OkCaseStartOffset": {
+			filter:          &api.Filter{Limit: 2, Offset: 3},
+			users:           []string{"user1", "user2", "user3", "user4", "user5"},
+			failOffset:      -1,
+			expectedUsers:   []string{"user4", "user5"},
+			expectedOffsets: []string{"3"},
+		},
+		"OkCaseEmpty": {
+			filter:          &api.Filter{Limit: 2},
+			users:           []string{},
+			failOffset:      -1,
+			expectedUsers:   []string{},
+			expectedOffsets: []string{"0"},
+		},
+		"ErrorCaseSecondPage": {
+			filter:          &api.Filter{Limit: 2},
+			users:           []string{"user1", "user2", "user3", "user4", "user5"},
+			failOffset:      2,
+			expectedUsers:   []string{"user1", "user2"},
+			expectedOffsets: []string{"0", "2"},
+			expectedError: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := NewClient(server.URL, nil, nil)
+	for n, test := range testcases {
+		offsets := []string{}
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			offsets = append(offsets, r.URL.Query().Get("Offset"))
+			assert.Equal(t, strconv.Itoa(test.filter.Limit), r.URL.Query().Get("Limit"), "Error in test case %v", n)
+			offset, _ := strconv.Atoi(r.URL.Query().Get("Offset"))
+			if offset == test.failOffset {
+				responseHandler(http.StatusInternalServerError, api.Error{Code: api.UNKNOWN_API_ERROR, Message: "Error"}, &testRequest{})(w, r)
+				return
+			}
+			end := offset + test.filter.Limit
+			if end > len(test.users) {
+				end = len(test.users)
+			}
+			responseHandler(http.StatusOK, internalhttp.GetUserExternalIDsResponse{
+				ExternalIDs: test.users[offset:end],
+				Limit:       test.filter.Limit,
+				Offset:      offset,
+				Total:       len(test.users),
+			}, &testRequest{})(w, r)
+		}
+
+		it := client.ListUsers(test.filter)
+		users := []string{}
+		for it.Next() {
+			users = append(users, it.Value())
+		}
+		assert.Equal(t, test.expectedUsers, users, "Error in test case %v", n)
+		assert.Equal(t, test.expectedOffsets, offsets, "Error in test case %v", n)
+		if test.expectedError != nil {
+			assert.Equal(t, test.expectedError, it.Err(), "Error in test case %v", n)
+			assert.False(t, it.Next(), "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, it.Err(), "Error in test case %v", n)
+		assert.Equal(t, len(test.users), it.Total(), "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// POLICY API
+
+// AddPolicy creates a policy in the organization
+func (c *Client) AddPolicy(org string, request internalhttp.CreatePolicyRequest) (*api.Policy, error) {
+	response := &api.Policy{}
+	path := urlPath(internalhttp.POLICY_ROOT_URL, internalhttp.ORG_NAME, org)
+	if err := c.do(http.MethodPost, path, nil, request, response, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetPolicyByName retrieves the policy with the name in the organization
+func (c *Client) GetPolicyByName(org string, name string) (*api.Policy, error) {
+	response := &api.Policy{}
+	path := urlPath(internalhttp.POLICY_ID_URL, internalhttp.ORG_NAME, org, internalhttp.POLICY_NAME, name)
+	if err := c.do(http.MethodGet, path, nil, nil, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ListPolicies iterates over the names of the policies in the organization
+func (c *Client) ListPolicies(org string, filter *api.Filter) *StringIterator {
+	path := urlPath(internalhttp.POLICY_ROOT_URL, internalhttp.ORG_NAME, org)
+	return newStringIterator(filter, func(query url.Values) ([]string, int, error) {
+		response := internalhttp.ListPoliciesResponse{}
+		err := c.do(http.MethodGet, path, query, nil, &response, http.StatusOK)
+		return response.Policies, response.Total, err
+	})
+}
+
+// ListAllPolicies iterates over the policies of all organizations, or the organization in the filter
+func (c *Client) ListAllPolicies(filter *api.Filter) *PolicyIdentityIterator {
+	return newPolicyIdentityIterator(filter, func(query url.Values) ([]api.PolicyIdentity, int, error) {
+		response := internalhttp.ListAllPoliciesResponse{}
+		err := c.do(http.MethodGet, internalhttp.API_VERSION_1+"/policies", query, nil, &response, http.StatusOK)
+		return response.Policies, response.Total, err
+	})
+}
+
+// UpdatePolicy updates the policy with the name in the organization. When the change needs approval,
+// the error has the api.CHANGE_REQUEST_PENDING code
+func (c *Client) UpdatePolicy(org string, name string, request internalhttp.UpdatePolicyRequest) (*api.Policy, error) {
+	response := &api.Policy{}
+	path := urlPath(internalhttp.POLICY_ID_URL, internalhttp.ORG_NAME, org, internalhttp.POLICY_NAME, name)
+	if err := c.do(http.MethodPut, path, nil, request, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RemovePolicy deletes the policy with the name in the organization
+func (c *Client) RemovePolicy(org string, name string) error {
+	path := urlPath(internalhttp.POLICY_ID_URL, internalhttp.ORG_NAME, org, internalhttp.POLICY_NAME, name)
+	return c.do(http.MethodDelete, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// ListAttachedGroups iterates over the groups the policy is attached to
+func (c *Client) ListAttachedGroups(org string, name string, filter *api.Filter) *PolicyGroupIterator {
+	path := urlPath(internalhttp.POLICY_ID_GROUPS_URL, internalhttp.ORG_NAME, org, internalhttp.POLICY_NAME, name)
+	return newPolicyGroupIterator(filter, func(query url.Values) ([]api.PolicyGroups, int, error) {
+		response := internalhttp.ListAttachedGroupsResponse{}
+		err := c.do(http.MethodGet, path, query, nil, &response, http.StatusOK)
+		return response.Groups, response.Total, err
+	})
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+func TestClient_PolicyAPI(t *testing.T) {
+	statements := []api.Statement{
+		{
+			Effect:    "allow",
+			Actions:   []string{api.USER_ACTION_GET_USER},
+			Resources: []string{api.GetUrnPrefix("", api.RESOURCE_USER, "/path/")},
+		},
+	}
+	policy := &api.Policy{
+		ID:         "1234",
+		Name:       "policy1",
+		Path:       "/path/",
+		Org:        "org1",
+		Urn:        api.CreateUrn("org1", api.RESOURCE_POLICY, "/path/", "policy1"),
+		Statements: &statements,
+	}
+	testcases := map[string]clientTestCase{
+		"OkCaseAddPolicy": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddPolicy("org1", internalhttp.CreatePolicyRequest{Name: "policy1", Path: "/path/", Statements: statements})
+			},
+			statusCode:     http.StatusCreated,
+			response:       policy,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/policies",
+			expectedBody:   internalhttp.CreatePolicyRequest{Name: "policy1", Path: "/path/", Statements: statements},
+			expectedResult: policy,
+		},
+		"OkCaseGetPolicyByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetPolicyByName("org1", "policy1")
+			},
+			statusCode:     http.StatusOK,
+			response:       policy,
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy1",
+			expectedResult: policy,
+		},
+		"OkCaseListPolicies": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListPolicies("org1", nil)
+				policies := []string{}
+				for it.Next() {
+					policies = append(policies, it.Value())
+				}
+				return policies, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListPoliciesResponse{
+				Policies: []string{"policy1"},
+				Limit:    20,
+				Total:    1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/policies",
+			expectedQuery:  "Offset=0",
+			expectedResult: []string{"policy1"},
+		},
+		"OkCaseListAllPolicies": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListAllPolicies(nil)
+				policies := []api.PolicyIdentity{}
+				for it.Next() {
+					policies = append(policies, it.Value())
+				}
+				return policies, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListAllPoliciesResponse{
+				Policies: []api.PolicyIdentity{{Org: "org1", Name: "policy1"}},
+				Limit:    20,
+				Total:    1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/policies",
+			expectedQuery:  "Offset=0",
+			expectedResult: []api.PolicyIdentity{{Org: "org1", Name: "policy1"}},
+		},
+		"OkCaseUpdatePolicy": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdatePolicy("org1", "policy1", internalhttp.UpdatePolicyRequest{Name: "policy1", Path: "/path/", Statements: statements})
+			},
+			statusCode:     http.StatusOK,
+			response:       policy,
+			expectedMethod: http.MethodPut,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy1",
+			expectedBody:   internalhttp.UpdatePolicyRequest{Name: "policy1", Path: "/path/", Statements: statements},
+			expectedResult: policy,
+		},
+		"OkCaseRemovePolicy": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemovePolicy("org1", "policy1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy1",
+		},
+		"OkCaseListAttachedGroups": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListAttachedGroups("org1", "policy1", nil)
+				groups := []api.PolicyGroups{}
+				for it.Next() {
+					groups = append(groups, it.Value())
+				}
+				return groups, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListAttachedGroupsResponse{
+				Groups: []api.PolicyGroups{{Group: "group1"}},
+				Limit:  20,
+				Total:  1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy1/groups",
+			expectedQuery:  "Offset=0",
+			expectedResult: []api.PolicyGroups{{Group: "group1"}},
+		},
+		"ErrorCaseUnauthorized": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetPolicyByName("org1", "policy1")
+			},
+			statusCode: http.StatusForbidden,
+			response: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy1",
+			expectedError: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+	}
+
+	checkClientTestCases(t, testcases)
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// PROXY RESOURCE API
+
+// AddProxyResource creates a proxy resource in the organization
+func (c *Client) AddProxyResource(org string, request internalhttp.CreateProxyResourceRequest) (*api.ProxyResource, error) {
+	response := &api.ProxyResource{}
+	path := urlPath(internalhttp.PROXY_RESOURCE_ROOT_URL, internalhttp.ORG_NAME, org)
+	if err := c.do(http.MethodPost, path, nil, request, response, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetProxyResourceByName retrieves the proxy resource with the name in the organization
+func (c *Client) GetProxyResourceByName(org string, name string) (*api.ProxyResource, error) {
+	response := &api.ProxyResource{}
+	path := urlPath(internalhttp.PROXY_RESOURCE_ID_URL, internalhttp.ORG_NAME, org, internalhttp.PROXY_RESOURCE_NAME, name)
+	if err := c.do(http.MethodGet, path, nil, nil, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ListProxyResources iterates over the names of the proxy resources in the organization
+func (c *Client) ListProxyResources(org string, filter *api.Filter) *StringIterator {
+	path := urlPath(internalhttp.PROXY_RESOURCE_ROOT_URL, internalhttp.ORG_NAME, org)
+	return newStringIterator(filter, func(query url.Values) ([]string, int, error) {
+		response := internalhttp.ListProxyResourcesResponse{}
+		err := c.do(http.MethodGet, path, query, nil, &response, http.StatusOK)
+		return response.Resources, response.Total, err
+	})
+}
+
+// UpdateProxyResource updates the proxy resource with the name in the organization
+func (c *Client) UpdateProxyResource(org string, name string, request internalhttp.UpdateProxyResourceRequest) (*api.ProxyResource, error) {
+	response := &api.ProxyResource{}
+	path := urlPath(internalhttp.PROXY_RESOURCE_ID_URL, internalhttp.ORG_NAME, org, internalhttp.PROXY_RESOURCE_NAME, name)
+	if err := c.do(http.MethodPut, path, nil, request, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RemoveProxyResource deletes the proxy resource with the name in the organization
+func (c *Client) RemoveProxyResource(org string, name string) error {
+	path := urlPath(internalhttp.PROXY_RESOURCE_ID_URL, internalhttp.ORG_NAME, org, internalhttp.PROXY_RESOURCE_NAME, name)
+	return c.do(http.MethodDelete, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// WatchProxyResources waits until proxy resources change from revision or timeout expires. When they change, it
+// returns all proxy resources with their revision and true. Revision 0 retrieves current resources without waiting
+func (c *Client) WatchProxyResources(revision int64, timeout time.Duration) (*api.ProxyResourcesWatch, bool, error) {
+	query := url.Values{}
+	query.Set("Revision", strconv.FormatInt(revision, 10))
+	if timeout > 0 {
+		query.Set("Timeout", timeout.String())
+	}
+	req, err := c.newRequest(http.MethodGet, internalhttp.PROXY_RESOURCES_WATCH_URL, query, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	res, err := c.send(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		return nil, false, nil
+	case http.StatusOK:
+		watch := &api.ProxyResourcesWatch{}
+		if err := decodeResponse(res, watch); err != nil {
+			return nil, false, err
+		}
+		return watch, true, nil
+	default:
+		return nil, false, getResponseError(res)
+	}
+}
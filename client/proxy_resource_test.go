@@ -0,0 +1,184 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_ProxyResourceAPI(t *testing.T) {
+	resource := api.ResourceEntity{
+		Host:   "http://host.com",
+		Path:   "/path",
+		Method: http.MethodGet,
+		Urn:    "urn:ews:example:instance1:resource/get",
+		Action: "example:get",
+	}
+	proxyResource := &api.ProxyResource{
+		ID:       "1234",
+		Name:     "proxy1",
+		Org:      "org1",
+		Path:     "/path/",
+		Urn:      api.CreateUrn("org1", api.RESOURCE_PROXY, "/path/", "proxy1"),
+		Resource: resource,
+	}
+	testcases := map[string]clientTestCase{
+		"OkCaseAddProxyResource": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddProxyResource("org1", internalhttp.CreateProxyResourceRequest{Name: "proxy1", Path: "/path/", Resource: resource})
+			},
+			statusCode:     http.StatusCreated,
+			response:       proxyResource,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources",
+			expectedBody:   internalhttp.CreateProxyResourceRequest{Name: "proxy1", Path: "/path/", Resource: resource},
+			expectedResult: proxyResource,
+		},
+		"OkCaseGetProxyResourceByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetProxyResourceByName("org1", "proxy1")
+			},
+			statusCode:     http.StatusOK,
+			response:       proxyResource,
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources/proxy1",
+			expectedResult: proxyResource,
+		},
+		"OkCaseListProxyResources": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListProxyResources("org1", nil)
+				resources := []string{}
+				for it.Next() {
+					resources = append(resources, it.Value())
+				}
+				return resources, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.ListProxyResourcesResponse{
+				Resources: []string{"proxy1"},
+				Limit:     20,
+				Total:     1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources",
+			expectedQuery:  "Offset=0",
+			expectedResult: []string{"proxy1"},
+		},
+		"OkCaseUpdateProxyResource": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateProxyResource("org1", "proxy1", internalhttp.UpdateProxyResourceRequest{Name: "proxy1", Path: "/path/", Resource: resource})
+			},
+			statusCode:     http.StatusOK,
+			response:       proxyResource,
+			expectedMethod: http.MethodPut,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources/proxy1",
+			expectedBody:   internalhttp.UpdateProxyResourceRequest{Name: "proxy1", Path: "/path/", Resource: resource},
+			expectedResult: proxyResource,
+		},
+		"OkCaseRemoveProxyResource": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveProxyResource("org1", "proxy1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources/proxy1",
+		},
+		"ErrorCaseRoutesConflict": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddProxyResource("org1", internalhttp.CreateProxyResourceRequest{Name: "proxy1", Path: "/path/", Resource: resource})
+			},
+			statusCode: http.StatusConflict,
+			response: api.Error{
+				Code:    api.PROXY_RESOURCES_ROUTES_CONFLICT,
+				Message: "Conflict",
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources",
+			expectedBody:   internalhttp.CreateProxyResourceRequest{Name: "proxy1", Path: "/path/", Resource: resource},
+			expectedError: &api.Error{
+				Code:    api.PROXY_RESOURCES_ROUTES_CONFLICT,
+				Message: "Conflict",
+			},
+		},
+	}
+
+	checkClientTestCases(t, testcases)
+}
+
+func TestClient_WatchProxyResources(t *testing.T) {
+	watch := &api.ProxyResourcesWatch{
+		Revision: 4,
+		Resources: []api.ProxyResource{
+			{
+				ID:   "ID",
+				Name: "resource1",
+				Org:  "org1",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		revision int64
+		timeout  time.Duration
+		// Test server response
+		statusCode int
+		response   interface{}
+		// Expected request
+		expectedQuery string
+		// Expected result
+		expectedWatch    *api.ProxyResourcesWatch
+		expectedModified bool
+		expectedError    *api.Error
+	}{
+		"OkCase": {
+			revision:         3,
+			timeout:          10 * time.Second,
+			statusCode:       http.StatusOK,
+			response:         watch,
+			expectedQuery:    "Revision=3&Timeout=10s",
+			expectedWatch:    watch,
+			expectedModified: true,
+		},
+		"OkCaseNotModified": {
+			revision:      4,
+			statusCode:    http.StatusNotModified,
+			expectedQuery: "Revision=4",
+		},
+		"ErrorCaseUnauthorized": {
+			statusCode: http.StatusForbidden,
+			response: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedQuery: "Revision=0",
+			expectedError: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+	}
+
+	client := NewClient(server.URL, BasicAuth("admin", "admin"), nil)
+	for n, test := range testcases {
+		request := serveResponse(test.statusCode, test.response)
+
+		result, modified, err := client.WatchProxyResources(test.revision, test.timeout)
+
+		// Check request
+		assert.Equal(t, http.MethodGet, request.method, "Error in test case %v", n)
+		assert.Equal(t, "/api/v1/admin/proxy-resources/watch", request.path, "Error in test case %v", n)
+		assert.Equal(t, test.expectedQuery, request.query, "Error in test case %v", n)
+
+		// Check result
+		if test.expectedError != nil {
+			assert.Equal(t, test.expectedError, err, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedModified, modified, "Error in test case %v", n)
+		assert.Equal(t, test.expectedWatch, result, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// AUTHORIZATION SNAPSHOT API
+
+// GetAuthzSnapshot retrieves the authorization snapshot of the worker. When version is the current one,
+// the worker doesn't send it again and the method returns a nil snapshot and false
+func (c *Client) GetAuthzSnapshot(version string) (*api.AuthzSnapshot, bool, error) {
+	req, err := c.newRequest(http.MethodGet, internalhttp.AUTHZ_SNAPSHOT_URL, nil, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", `"`+version+`"`)
+	}
+
+	res, err := c.send(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		return nil, false, nil
+	case http.StatusOK:
+		snapshot := &api.AuthzSnapshot{}
+		if err := decodeResponse(res, snapshot); err != nil {
+			return nil, false, err
+		}
+		return snapshot, true, nil
+	default:
+		return nil, false, getResponseError(res)
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetAuthzSnapshot(t *testing.T) {
+	snapshot := &api.AuthzSnapshot{
+		Version: "v2",
+		Users: []api.SnapshotUser{
+			{
+				ExternalID: "user1",
+				Groups:     []string{"GROUP1"},
+			},
+		},
+		Groups: []api.SnapshotGroup{
+			{
+				ID:       "GROUP1",
+				Urn:      "urn:iws:iam:org1:group/path/group1",
+				Policies: []string{"POLICY1"},
+			},
+		},
+	}
+	testcases := map[string]struct {
+		version string
+		// Test server response
+		statusCode int
+		response   interface{}
+		// Expected request
+		expectedIfNoneMatch string
+		// Expected result
+		expectedSnapshot *api.AuthzSnapshot
+		expectedModified bool
+		expectedError    *api.Error
+	}{
+		"OkCase": {
+			statusCode:       http.StatusOK,
+			response:         snapshot,
+			expectedSnapshot: snapshot,
+			expectedModified: true,
+		},
+		"OkCaseOldVersion": {
+			version:             "v1",
+			statusCode:          http.StatusOK,
+			response:            snapshot,
+			expectedIfNoneMatch: `"v1"`,
+			expectedSnapshot:    snapshot,
+			expectedModified:    true,
+		},
+		"OkCaseNotModified": {
+			version:             "v2",
+			statusCode:          http.StatusNotModified,
+			expectedIfNoneMatch: `"v2"`,
+		},
+		"ErrorCaseUnauthorized": {
+			statusCode: http.StatusForbidden,
+			response: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedError: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+	}
+
+	client := NewClient(server.URL, BasicAuth("admin", "admin"), nil)
+	for n, test := range testcases {
+		request := serveResponse(test.statusCode, test.response)
+
+		result, modified, err := client.GetAuthzSnapshot(test.version)
+
+		// Check request
+		assert.Equal(t, http.MethodGet, request.method, "Error in test case %v", n)
+		assert.Equal(t, "/api/v1/admin/authz/snapshot", request.path, "Error in test case %v", n)
+		assert.Equal(t, test.expectedIfNoneMatch, request.header.Get("If-None-Match"), "Error in test case %v", n)
+
+		// Check result
+		if test.expectedError != nil {
+			assert.Equal(t, test.expectedError, err, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedModified, modified, "Error in test case %v", n)
+		if test.expectedSnapshot == nil {
+			assert.Nil(t, result, "Error in test case %v", n)
+			continue
+		}
+		assert.Equal(t, test.expectedSnapshot.Version, result.Version, "Error in test case %v", n)
+		assert.Equal(t, test.expectedSnapshot.Users, result.Users, "Error in test case %v", n)
+		assert.Equal(t, test.expectedSnapshot.Groups, result.Groups, "Error in test case %v", n)
+	}
+}
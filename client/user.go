@@ -0,0 +1,65 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+// USER API
+
+// AddUser creates a user
+func (c *Client) AddUser(request internalhttp.CreateUserRequest) (*api.User, error) {
+	response := &api.User{}
+	if err := c.do(http.MethodPost, internalhttp.USER_ROOT_URL, nil, request, response, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetUserByExternalID retrieves the user with the external id
+func (c *Client) GetUserByExternalID(externalID string) (*api.User, error) {
+	response := &api.User{}
+	path := urlPath(internalhttp.USER_ID_URL, internalhttp.USER_ID, externalID)
+	if err := c.do(http.MethodGet, path, nil, nil, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ListUsers iterates over the external ids of the users filtered by path prefix
+func (c *Client) ListUsers(filter *api.Filter) *StringIterator {
+	return newStringIterator(filter, func(query url.Values) ([]string, int, error) {
+		response := internalhttp.GetUserExternalIDsResponse{}
+		err := c.do(http.MethodGet, internalhttp.USER_ROOT_URL, query, nil, &response, http.StatusOK)
+		return response.ExternalIDs, response.Total, err
+	})
+}
+
+// UpdateUser updates the user with the external id
+func (c *Client) UpdateUser(externalID string, request internalhttp.UpdateUserRequest) (*api.User, error) {
+	response := &api.User{}
+	path := urlPath(internalhttp.USER_ID_URL, internalhttp.USER_ID, externalID)
+	if err := c.do(http.MethodPut, path, nil, request, response, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RemoveUser deletes the user with the external id
+func (c *Client) RemoveUser(externalID string) error {
+	path := urlPath(internalhttp.USER_ID_URL, internalhttp.USER_ID, externalID)
+	return c.do(http.MethodDelete, path, nil, nil, nil, http.StatusNoContent)
+}
+
+// ListGroupsByUser iterates over the groups the user with the external id is member of
+func (c *Client) ListGroupsByUser(externalID string, filter *api.Filter) *UserGroupIterator {
+	path := urlPath(internalhttp.USER_ID_GROUPS_URL, internalhttp.USER_ID, externalID)
+	return newUserGroupIterator(filter, func(query url.Values) ([]api.UserGroups, int, error) {
+		response := internalhttp.GetGroupsByUserIdResponse{}
+		err := c.do(http.MethodGet, path, query, nil, &response, http.StatusOK)
+		return response.Groups, response.Total, err
+	})
+}
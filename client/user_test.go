@@ -0,0 +1,118 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+)
+
+func TestClient_UserAPI(t *testing.T) {
+	user := &api.User{
+		ID:         "1234",
+		ExternalID: "user1",
+		Path:       "/path/",
+		Urn:        api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+	}
+	testcases := map[string]clientTestCase{
+		"OkCaseAddUser": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddUser(internalhttp.CreateUserRequest{ExternalID: "user1", Path: "/path/"})
+			},
+			statusCode:     http.StatusCreated,
+			response:       user,
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/users",
+			expectedBody:   internalhttp.CreateUserRequest{ExternalID: "user1", Path: "/path/"},
+			expectedResult: user,
+		},
+		"OkCaseGetUserByExternalID": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetUserByExternalID("user1")
+			},
+			statusCode:     http.StatusOK,
+			response:       user,
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			expectedResult: user,
+		},
+		"OkCaseListUsers": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListUsers(&api.Filter{PathPrefix: "/path/"})
+				users := []string{}
+				for it.Next() {
+					users = append(users, it.Value())
+				}
+				return users, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.GetUserExternalIDsResponse{
+				ExternalIDs: []string{"user1", "user2"},
+				Limit:       20,
+				Total:       2,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users",
+			expectedQuery:  "Offset=0&PathPrefix=%2Fpath%2F",
+			expectedResult: []string{"user1", "user2"},
+		},
+		"OkCaseUpdateUser": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateUser("user1", internalhttp.UpdateUserRequest{Path: "/path/"})
+			},
+			statusCode:     http.StatusOK,
+			response:       user,
+			expectedMethod: http.MethodPut,
+			expectedPath:   "/api/v1/users/user1",
+			expectedBody:   internalhttp.UpdateUserRequest{Path: "/path/"},
+			expectedResult: user,
+		},
+		"OkCaseRemoveUser": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveUser("user1")
+			},
+			statusCode:     http.StatusNoContent,
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/users/user1",
+		},
+		"OkCaseListGroupsByUser": {
+			call: func(c *Client) (interface{}, error) {
+				it := c.ListGroupsByUser("user1", nil)
+				groups := []api.UserGroups{}
+				for it.Next() {
+					groups = append(groups, it.Value())
+				}
+				return groups, it.Err()
+			},
+			statusCode: http.StatusOK,
+			response: internalhttp.GetGroupsByUserIdResponse{
+				Groups: []api.UserGroups{{Org: "org1", Name: "group1"}},
+				Limit:  20,
+				Total:  1,
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1/groups",
+			expectedQuery:  "Offset=0",
+			expectedResult: []api.UserGroups{{Org: "org1", Name: "group1"}},
+		},
+		"ErrorCaseUserNotFound": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetUserByExternalID("user1")
+			},
+			statusCode: http.StatusNotFound,
+			response: api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User with externalId user1 not found",
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			expectedError: &api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User with externalId user1 not found",
+			},
+		},
+	}
+
+	checkClientTestCases(t, testcases)
+}
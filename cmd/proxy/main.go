@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,6 +12,7 @@ import (
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/foulkon"
 	internalhttp "github.com/Tecsisa/foulkon/http"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/pelletier/go-toml"
 )
 
@@ -44,23 +47,51 @@ func main() {
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	// Start metrics server if it is enabled
+	var ms *http.Server
+	if proxy.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle(internalhttp.METRICS_URL, metrics.Handler())
+		ms = &http.Server{Addr: proxy.MetricsAddress, Handler: mux}
+		go func() {
+			api.Log.Infof("Metrics server running in %v", proxy.MetricsAddress)
+			if err := ms.ListenAndServe(); err != http.ErrServerClosed {
+				api.Log.Error(err.Error())
+			}
+		}()
+	}
+
+	ps := internalhttp.NewProxy(proxy)
+	ps.Configuration()
+
+	// Stop accepting connections when a signal is received, draining in-flight requests
+	shutdown := make(chan error, 1)
 	go func() {
-		for {
-			sigrecv := <-sig
-			switch sigrecv {
-			case syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGQUIT:
-				api.Log.Infof("Signal '%v' received, closing proxy...", sigrecv.String())
-				os.Exit(foulkon.CloseProxy())
-			default:
-				api.Log.Warnf("Unknown OS signal received, ignoring...")
+		sigrecv := <-sig
+		api.Log.Infof("Signal '%v' received, closing proxy...", sigrecv.String())
+		ctx, cancel := context.WithTimeout(context.Background(), proxy.ShutdownTimeout)
+		defer cancel()
+		err := ps.Shutdown(ctx)
+		if ms != nil {
+			if metricsErr := ms.Shutdown(ctx); metricsErr != nil {
+				err = metricsErr
 			}
 		}
+		shutdown <- err
 	}()
 
 	api.Log.Infof("Server running in %v:%v", proxy.Host, proxy.Port)
-	ps := internalhttp.NewProxy(proxy)
-	ps.Configuration()
-	api.Log.Error(ps.Run().Error())
+	status := 0
+	if err := ps.Run(); err != http.ErrServerClosed {
+		api.Log.Error(err.Error())
+		status = 1
+	} else if err := <-shutdown; err != nil {
+		api.Log.Errorf("Couldn't drain in-flight requests: %v", err)
+		status = 1
+	}
 
-	os.Exit(foulkon.CloseProxy())
+	if foulkon.CloseProxy() != 0 {
+		status = 1
+	}
+	os.Exit(status)
 }
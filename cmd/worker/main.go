@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"net/http"
 
 	"os"
 
@@ -11,7 +13,9 @@ import (
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/foulkon"
+	internalgrpc "github.com/Tecsisa/foulkon/grpc"
 	internalhttp "github.com/Tecsisa/foulkon/http"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/pelletier/go-toml"
 )
 
@@ -41,28 +45,97 @@ func main() {
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig,
-		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	// Reload configuration file when SIGHUP is received
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
 	go func() {
-		for {
-			sigrecv := <-sig
-			switch sigrecv {
-			case syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGQUIT:
-				api.Log.Infof("Signal '%v' received, closing worker...", sigrecv.String())
-				os.Exit(foulkon.CloseWorker())
-			default:
-				api.Log.Warnf("Unknown OS signal received, ignoring...")
+		for sigrecv := range reload {
+			api.Log.Infof("Signal '%v' received, reloading configuration...", sigrecv.String())
+			config, err := toml.LoadFile(*configFile)
+			if err != nil {
+				api.Log.Errorf("Cannot read configuration file %v, error: %v", *configFile, err)
+				continue
+			}
+			if err := core.Reload(config); err != nil {
+				api.Log.Errorf("Configuration not reloaded: %v", err)
 			}
 		}
 	}()
 
-	api.Log.Infof("Server running in %v:%v", core.Host, core.Port)
+	// Reload OIDC providers, remove audit events out of retention and send webhook deliveries periodically
+	core.StartBackgroundTasks()
+
+	// Start gRPC authorization server if it is enabled
+	var gs *internalgrpc.WorkerServer
+	if core.GrpcPort != "" {
+		gs = internalgrpc.NewWorker(core)
+		if err := gs.Configuration(); err != nil {
+			api.Log.Error(err.Error())
+			foulkon.CloseWorker()
+			os.Exit(1)
+		}
+		go func() {
+			api.Log.Infof("gRPC server running in %v:%v", core.Host, core.GrpcPort)
+			if err := gs.Run(); err != nil {
+				api.Log.Error(err.Error())
+			}
+		}()
+	}
+
+	// Start metrics server if it has its own address
+	var ms *http.Server
+	if core.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle(internalhttp.METRICS_URL, metrics.Handler())
+		ms = &http.Server{Addr: core.MetricsAddress, Handler: mux}
+		go func() {
+			api.Log.Infof("Metrics server running in %v", core.MetricsAddress)
+			if err := ms.ListenAndServe(); err != http.ErrServerClosed {
+				api.Log.Error(err.Error())
+			}
+		}()
+	}
+
 	ws := internalhttp.NewWorker(core, internalhttp.WorkerHandlerRouter(core))
 	ws.Configuration()
-	api.Log.Error(ws.Run().Error())
 
-	os.Exit(foulkon.CloseWorker())
+	// Stop accepting connections when a signal is received, draining in-flight requests
+	shutdown := make(chan error, 1)
+	go func() {
+		sigrecv := <-sig
+		api.Log.Infof("Signal '%v' received, closing worker...", sigrecv.String())
+		ctx, cancel := context.WithTimeout(context.Background(), core.ShutdownTimeout)
+		defer cancel()
+		err := ws.Shutdown(ctx)
+		if gs != nil {
+			if grpcErr := gs.Shutdown(ctx); grpcErr != nil {
+				err = grpcErr
+			}
+		}
+		if ms != nil {
+			if metricsErr := ms.Shutdown(ctx); metricsErr != nil {
+				err = metricsErr
+			}
+		}
+		shutdown <- err
+	}()
+
+	api.Log.Infof("Server running in %v:%v", core.Host, core.Port)
+	status := 0
+	if err := ws.Run(); err != http.ErrServerClosed {
+		api.Log.Error(err.Error())
+		status = 1
+	} else if err := <-shutdown; err != nil {
+		api.Log.Errorf("Couldn't drain in-flight requests: %v", err)
+		status = 1
+	}
+
+	if foulkon.CloseWorker() != 0 {
+		status = 1
+	}
+	os.Exit(status)
 }
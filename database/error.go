@@ -21,11 +21,24 @@ const (
 	// Policy Codes
 	POLICY_NOT_FOUND = "PolicyNotFound"
 
+	// Policy template Codes
+	POLICY_TEMPLATE_NOT_FOUND = "PolicyTemplateNotFound"
+
 	// Proxy resource Codes
 	PROXY_RESOURCE_NOT_FOUND = "ProxyResourceNotFound"
 
 	// Auth Provider Codes
 	AUTH_OIDC_PROVIDER_NOT_FOUND = "AuthOidcProviderNotFound"
+
+	// Break-glass session Codes
+	BREAK_GLASS_SESSION_NOT_FOUND = "BreakGlassSessionNotFound"
+
+	// Change request Codes
+	CHANGE_REQUEST_NOT_FOUND      = "ChangeRequestNotFound"
+	CHANGE_REQUEST_STATUS_CHANGED = "ChangeRequestStatusChanged"
+
+	// Webhook Codes
+	WEBHOOK_NOT_FOUND = "WebhookNotFound"
 )
 
 type Error struct {
@@ -0,0 +1,214 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/jinzhu/gorm"
+)
+
+// AUDIT REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) AddAuditEvents(events []api.AuditEvent) error {
+	defer pr.observeQuery("AddAuditEvents")()
+	transaction := pr.Dbmap.Begin()
+
+	// Store audit events
+	for _, event := range events {
+		eventDB, err := apiAuditEventToDBAuditEvent(event)
+		if err == nil {
+			err = transaction.Create(eventDB).Error
+		}
+		if err != nil {
+			transaction.Rollback()
+			return &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+	}
+
+	// Error handling
+	if err := transaction.Commit().Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (pr PostgresRepo) GetAuditEventsFiltered(filter *api.Filter) ([]api.AuditEvent, int, error) {
+	defer pr.observeQuery("GetAuditEventsFiltered")()
+	var total int
+	events := []AuditEvent{}
+	query := pr.Dbmap
+
+	if len(filter.Actor) > 0 {
+		query = query.Where("actor like ?", filter.Actor)
+	}
+	if len(filter.EntityUrn) > 0 {
+		query = query.Where("entity_urn like ?", filter.EntityUrn)
+	}
+	if len(filter.Action) > 0 {
+		query = query.Where("action like ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("create_at >= ?", filter.From.UnixNano())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("create_at <= ?", filter.To.UnixNano())
+	}
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	} else {
+		query = query.Order("create_at desc")
+	}
+
+	// Error handling
+	if err := query.Find(&events).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&events).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform audit events to API
+	var apiEvents []api.AuditEvent
+	if events != nil {
+		apiEvents = make([]api.AuditEvent, len(events), cap(events))
+		for i, e := range events {
+			apiEvent, err := dbAuditEventToAPIAuditEvent(&e)
+			if err != nil {
+				return nil, total, &database.Error{
+					Code:    database.INTERNAL_ERROR,
+					Message: err.Error(),
+				}
+			}
+			apiEvents[i] = *apiEvent
+		}
+	}
+
+	return apiEvents, total, nil
+}
+
+func (pr PostgresRepo) RemoveAuditEvents(before time.Time) error {
+	defer pr.observeQuery("RemoveAuditEvents")()
+	query := pr.Dbmap.Where("create_at < ?", before.UnixNano()).Delete(&AuditEvent{})
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Store the audit events and webhook deliveries of the repository in the transaction of the change,
+// so they are committed or rolled back with it
+func (pr PostgresRepo) addChangeEvents(transaction *gorm.DB) error {
+	for _, event := range pr.events {
+		eventDB, err := apiAuditEventToDBAuditEvent(event)
+		if err == nil {
+			err = transaction.Create(eventDB).Error
+		}
+		if err != nil {
+			return &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+	}
+	for _, delivery := range pr.deliveries {
+		if err := transaction.Create(apiWebhookDeliveryToDBWebhookDelivery(delivery)).Error; err != nil {
+			return &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+	}
+	return nil
+}
+
+// Transform an audit event for API into an audit event model for db
+func apiAuditEventToDBAuditEvent(event api.AuditEvent) (*AuditEvent, error) {
+	before, err := marshalAuditSnapshot(event.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := marshalAuditSnapshot(event.After)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEvent{
+		ID:                  event.ID,
+		Type:                event.Type,
+		Actor:               event.Actor,
+		BreakGlassSessionID: event.BreakGlassSessionID,
+		RequestID:           event.RequestID,
+		Action:              event.Action,
+		EntityUrn:           event.EntityUrn,
+		Before:              before,
+		After:               after,
+		Urn:                 event.Urn,
+		CreateAt:            event.CreateAt.UnixNano(),
+	}, nil
+}
+
+// Transform an audit event retrieved from db into an audit event for API
+func dbAuditEventToAPIAuditEvent(event *AuditEvent) (*api.AuditEvent, error) {
+	before, err := unmarshalAuditSnapshot(event.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := unmarshalAuditSnapshot(event.After)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.AuditEvent{
+		ID:                  event.ID,
+		Type:                event.Type,
+		Actor:               event.Actor,
+		BreakGlassSessionID: event.BreakGlassSessionID,
+		RequestID:           event.RequestID,
+		Action:              event.Action,
+		EntityUrn:           event.EntityUrn,
+		Before:              before,
+		After:               after,
+		Urn:                 event.Urn,
+		CreateAt:            time.Unix(0, event.CreateAt).UTC(),
+	}, nil
+}
+
+// Snapshots are stored as JSON, empty if there isn't snapshot
+func marshalAuditSnapshot(snapshot interface{}) (string, error) {
+	if snapshot == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalAuditSnapshot(data string) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var snapshot interface{}
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
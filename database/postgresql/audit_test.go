@@ -0,0 +1,367 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRepo_AddAuditEvents(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		eventsToCreate []api.AuditEvent
+		// Expected result
+		expectedEvents int
+		expectedError  *database.Error
+	}{
+		"OkCase": {
+			eventsToCreate: []api.AuditEvent{
+				{
+					ID:        "EventID1",
+					Type:      api.AUDIT_EVENT_TYPE_DECISION,
+					Actor:     "user1",
+					Action:    "example:get",
+					EntityUrn: "urn:ews:example:instance1:resource/res1",
+					After:     api.AUDIT_DECISION_ALLOW,
+					Urn:       "urn1",
+					CreateAt:  now,
+				},
+				{
+					ID:        "EventID2",
+					Type:      api.AUDIT_EVENT_TYPE_DECISION,
+					Actor:     "user1",
+					Action:    "example:get",
+					EntityUrn: "urn:ews:example:instance1:resource/res2",
+					After:     api.AUDIT_DECISION_DENY,
+					Urn:       "urn2",
+					CreateAt:  now,
+				},
+			},
+			expectedEvents: 2,
+		},
+		"ErrorCaseRepeatedEvent": {
+			eventsToCreate: []api.AuditEvent{
+				{
+					ID:        "EventID1",
+					Type:      api.AUDIT_EVENT_TYPE_DECISION,
+					Actor:     "user1",
+					Action:    "example:get",
+					EntityUrn: "urn:ews:example:instance1:resource/res1",
+					Urn:       "urn1",
+					CreateAt:  now,
+				},
+				{
+					ID:        "EventID1",
+					Type:      api.AUDIT_EVENT_TYPE_DECISION,
+					Actor:     "user1",
+					Action:    "example:get",
+					EntityUrn: "urn:ews:example:instance1:resource/res1",
+					Urn:       "urn1",
+					CreateAt:  now,
+				},
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "pq: duplicate key value violates unique constraint \"audit_events_pkey\"",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean audit events database
+		cleanAuditEventsTable(t, n)
+
+		// Call to repository to store the events
+		err := repoDB.AddAuditEvents(test.eventsToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+		}
+		// Check database, events of a failed batch aren't stored
+		eventNumber := getAuditEventsCountFiltered(t, n, "", "user1", "")
+		assert.Equal(t, test.expectedEvents, eventNumber, "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_WithEvents(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousEvent *AuditEvent
+		// Postgres Repo Args
+		userToCreate api.User
+		event        api.AuditEvent
+		delivery     api.WebhookDelivery
+		// Expected result
+		expectedUsers      int
+		expectedEvents     int
+		expectedDeliveries int
+		expectedError      *database.Error
+	}{
+		"OkCase": {
+			userToCreate: api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+			event: api.AuditEvent{
+				ID:        "EventID",
+				Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+				Actor:     "user1",
+				Action:    api.USER_ACTION_CREATE_USER,
+				EntityUrn: "urn",
+				Urn:       "eventUrn",
+				CreateAt:  now,
+			},
+			delivery: api.WebhookDelivery{
+				ID:            "DeliveryID",
+				WebhookID:     "WebhookID",
+				EventID:       "EventID",
+				Event:         api.USER_ACTION_CREATE_USER,
+				Payload:       "{}",
+				Status:        api.WEBHOOK_DELIVERY_STATUS_PENDING,
+				NextAttemptAt: now,
+				CreateAt:      now,
+				UpdateAt:      now,
+			},
+			expectedUsers:      1,
+			expectedEvents:     1,
+			expectedDeliveries: 1,
+		},
+		"ErrorCaseEventNotStored": {
+			previousEvent: &AuditEvent{
+				ID:        "EventID",
+				Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+				Actor:     "user1",
+				Action:    api.USER_ACTION_CREATE_USER,
+				EntityUrn: "urn",
+				Urn:       "eventUrn",
+				CreateAt:  now.UnixNano(),
+			},
+			userToCreate: api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+			event: api.AuditEvent{
+				ID:        "EventID",
+				Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+				Actor:     "user1",
+				Action:    api.USER_ACTION_CREATE_USER,
+				EntityUrn: "urn",
+				Urn:       "eventUrn",
+				CreateAt:  now,
+			},
+			delivery: api.WebhookDelivery{
+				ID:            "DeliveryID",
+				WebhookID:     "WebhookID",
+				EventID:       "EventID",
+				Event:         api.USER_ACTION_CREATE_USER,
+				Payload:       "{}",
+				Status:        api.WEBHOOK_DELIVERY_STATUS_PENDING,
+				NextAttemptAt: now,
+				CreateAt:      now,
+				UpdateAt:      now,
+			},
+			expectedEvents: 1,
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "pq: duplicate key value violates unique constraint \"audit_events_pkey\"",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanUserTable(t, n)
+		cleanAuditEventsTable(t, n)
+		cleanWebhookDeliveriesTable(t, n)
+
+		// Insert previous data
+		if test.previousEvent != nil {
+			insertAuditEvent(t, n, *test.previousEvent)
+		}
+		// Call to repository to store an user with its event and delivery
+		changeRepo := repoDB.WithEvents([]api.AuditEvent{test.event}, []api.WebhookDelivery{test.delivery}).(PostgresRepo)
+		_, err := changeRepo.AddUser(test.userToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+		}
+		// Check database, change and its events are stored in the same transaction
+		userNumber := getUsersCountFiltered(t, n, test.userToCreate.ID, "", "", 0, 0, "", "")
+		assert.Equal(t, test.expectedUsers, userNumber, "Error in test case %v", n)
+		eventNumber := getAuditEventsCountFiltered(t, n, test.event.ID, "", "")
+		assert.Equal(t, test.expectedEvents, eventNumber, "Error in test case %v", n)
+		deliveryNumber := getWebhookDeliveriesCountFiltered(t, n, test.delivery.ID, "", "")
+		assert.Equal(t, test.expectedDeliveries, deliveryNumber, "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_GetAuditEventsFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	previousEvents := []AuditEvent{
+		{
+			ID:        "Event1",
+			Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+			Actor:     "admin",
+			Action:    api.USER_ACTION_CREATE_USER,
+			EntityUrn: "urn:iws:iam::user/path/user1",
+			After:     `{"externalId":"user1"}`,
+			Urn:       "urn1",
+			CreateAt:  now.Add(-2 * time.Hour).UnixNano(),
+		},
+		{
+			ID:        "Event2",
+			Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+			Actor:     "user2",
+			Action:    api.USER_ACTION_DELETE_USER,
+			EntityUrn: "urn:iws:iam::user/path/user1",
+			Before:    `{"externalId":"user1"}`,
+			Urn:       "urn2",
+			CreateAt:  now.UnixNano(),
+		},
+	}
+	event1 := api.AuditEvent{
+		ID:        "Event1",
+		Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+		Actor:     "admin",
+		Action:    api.USER_ACTION_CREATE_USER,
+		EntityUrn: "urn:iws:iam::user/path/user1",
+		After:     map[string]interface{}{"externalId": "user1"},
+		Urn:       "urn1",
+		CreateAt:  now.Add(-2 * time.Hour),
+	}
+	event2 := api.AuditEvent{
+		ID:        "Event2",
+		Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+		Actor:     "user2",
+		Action:    api.USER_ACTION_DELETE_USER,
+		EntityUrn: "urn:iws:iam::user/path/user1",
+		Before:    map[string]interface{}{"externalId": "user1"},
+		Urn:       "urn2",
+		CreateAt:  now,
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.AuditEvent
+		expectedTotal    int
+	}{
+		"OkCaseNewestFirst": {
+			filter: &api.Filter{
+				Limit: 20,
+			},
+			expectedResponse: []api.AuditEvent{event2, event1},
+			expectedTotal:    2,
+		},
+		"OkCaseFilterByActor": {
+			filter: &api.Filter{
+				Actor: "admin",
+				Limit: 20,
+			},
+			expectedResponse: []api.AuditEvent{event1},
+			expectedTotal:    1,
+		},
+		"OkCaseFilterByUrnAndAction": {
+			filter: &api.Filter{
+				EntityUrn: "urn:iws:iam::user/path/user1",
+				Action:    api.USER_ACTION_DELETE_USER,
+				Limit:     20,
+			},
+			expectedResponse: []api.AuditEvent{event2},
+			expectedTotal:    1,
+		},
+		"OkCaseFilterByTimeRange": {
+			filter: &api.Filter{
+				From:  now.Add(-3 * time.Hour),
+				To:    now.Add(-time.Hour),
+				Limit: 20,
+			},
+			expectedResponse: []api.AuditEvent{event1},
+			expectedTotal:    1,
+		},
+		"OkCaseOrderBy": {
+			filter: &api.Filter{
+				OrderBy: "create_at",
+				Limit:   20,
+			},
+			expectedResponse: []api.AuditEvent{event1, event2},
+			expectedTotal:    2,
+		},
+		"OkCaseNoResults": {
+			filter: &api.Filter{
+				Actor: "other",
+				Limit: 20,
+			},
+			expectedResponse: []api.AuditEvent{},
+			expectedTotal:    0,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean audit events database
+		cleanAuditEventsTable(t, n)
+
+		// Insert previous data
+		for _, event := range previousEvents {
+			insertAuditEvent(t, n, event)
+		}
+		// Call to repository to get the events
+		events, total, err := repoDB.GetAuditEventsFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, events, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_RemoveAuditEvents(t *testing.T) {
+	now := time.Now().UTC()
+	n := "OkCase"
+
+	// Clean audit events database
+	cleanAuditEventsTable(t, n)
+
+	// Insert previous data
+	insertAuditEvent(t, n, AuditEvent{
+		ID:        "Old",
+		Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+		Actor:     "admin",
+		Action:    api.USER_ACTION_DELETE_USER,
+		EntityUrn: "urn:iws:iam::user/path/user1",
+		Urn:       "urn1",
+		CreateAt:  now.Add(-48 * time.Hour).UnixNano(),
+	})
+	insertAuditEvent(t, n, AuditEvent{
+		ID:        "New",
+		Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+		Actor:     "admin",
+		Action:    api.USER_ACTION_CREATE_USER,
+		EntityUrn: "urn:iws:iam::user/path/user1",
+		Urn:       "urn2",
+		CreateAt:  now.UnixNano(),
+	})
+
+	// Call to repository to remove old events
+	err := repoDB.RemoveAuditEvents(now.Add(-24 * time.Hour))
+	assert.Nil(t, err, "Error in test case %v", n)
+
+	// Check database
+	assert.Equal(t, 0, getAuditEventsCountFiltered(t, n, "Old", "", ""), "Error in test case %v", n)
+	assert.Equal(t, 1, getAuditEventsCountFiltered(t, n, "New", "", ""), "Error in test case %v", n)
+}
@@ -13,6 +13,7 @@ import (
 // AUTH OIDC PROVIDER REPOSITORY IMPLEMENTATION
 
 func (pr PostgresRepo) AddOidcProvider(oidcProvider api.OidcProvider) (*api.OidcProvider, error) {
+	defer pr.observeQuery("AddOidcProvider")()
 	// Create OIDC Provider model
 	oidcProviderDB := &OidcProvider{
 		ID:        oidcProvider.ID,
@@ -52,6 +53,12 @@ func (pr PostgresRepo) AddOidcProvider(oidcProvider api.OidcProvider) (*api.Oidc
 		}
 	}
 
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
 	transaction.Commit()
 
 	// Create API OIDC Provider
@@ -62,6 +69,7 @@ func (pr PostgresRepo) AddOidcProvider(oidcProvider api.OidcProvider) (*api.Oidc
 }
 
 func (pr PostgresRepo) GetOidcProviderByName(name string) (*api.OidcProvider, error) {
+	defer pr.observeQuery("GetOidcProviderByName")()
 	oidcProvider := &OidcProvider{}
 	query := pr.Dbmap.Where("name like ?", name).First(oidcProvider)
 
@@ -100,6 +108,7 @@ func (pr PostgresRepo) GetOidcProviderByName(name string) (*api.OidcProvider, er
 }
 
 func (pr PostgresRepo) GetOidcProvidersFiltered(filter *api.Filter) ([]api.OidcProvider, int, error) {
+	defer pr.observeQuery("GetOidcProvidersFiltered")()
 	var total int
 	oidcProviders := []OidcProvider{}
 	query := pr.Dbmap
@@ -150,6 +159,7 @@ func (pr PostgresRepo) GetOidcProvidersFiltered(filter *api.Filter) ([]api.OidcP
 }
 
 func (pr PostgresRepo) UpdateOidcProvider(oidcProvider api.OidcProvider) (*api.OidcProvider, error) {
+	defer pr.observeQuery("UpdateOidcProvider")()
 	oidcProviderDB := OidcProvider{
 		ID:        oidcProvider.ID,
 		Name:      oidcProvider.Name,
@@ -196,12 +206,19 @@ func (pr PostgresRepo) UpdateOidcProvider(oidcProvider api.OidcProvider) (*api.O
 		}
 	}
 
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
 	transaction.Commit()
 
 	return &oidcProvider, nil
 }
 
 func (pr PostgresRepo) RemoveOidcProvider(id string) error {
+	defer pr.observeQuery("RemoveOidcProvider")()
 	transaction := pr.Dbmap.Begin()
 
 	// Delete OIDC Provider
@@ -225,6 +242,12 @@ func (pr PostgresRepo) RemoveOidcProvider(id string) error {
 
 	}
 
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
 	transaction.Commit()
 	return nil
 }
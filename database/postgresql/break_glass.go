@@ -0,0 +1,162 @@
+package postgresql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// BREAK-GLASS SESSION REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) AddBreakGlassSession(session api.BreakGlassSession) (*api.BreakGlassSession, error) {
+	defer pr.observeQuery("AddBreakGlassSession")()
+	// Create break-glass session model
+	sessionDB := &BreakGlassSession{
+		ID:            session.ID,
+		UserID:        session.UserID,
+		Justification: session.Justification,
+		Urn:           session.Urn,
+		CreateAt:      session.CreateAt.UnixNano(),
+		ExpiresAt:     session.ExpiresAt.UnixNano(),
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Store break-glass session
+	if err := transaction.Create(sessionDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbBreakGlassSessionToAPIBreakGlassSession(sessionDB), nil
+}
+
+func (pr PostgresRepo) GetActiveBreakGlassSession(userID string, now time.Time) (*api.BreakGlassSession, error) {
+	defer pr.observeQuery("GetActiveBreakGlassSession")()
+	session := &BreakGlassSession{}
+	query := pr.Dbmap.Where("user_id like ? AND expires_at > ?", userID, now.UnixNano()).Order("expires_at desc").First(session)
+
+	// Check if there is an active session
+	if query.RecordNotFound() {
+		return nil, &database.Error{
+			Code:    database.BREAK_GLASS_SESSION_NOT_FOUND,
+			Message: fmt.Sprintf("Active break-glass session for user %v not found", userID),
+		}
+	}
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbBreakGlassSessionToAPIBreakGlassSession(session), nil
+}
+
+func (pr PostgresRepo) SetBreakGlassSessionFirstUse(id string, firstUseAt time.Time) (bool, error) {
+	defer pr.observeQuery("SetBreakGlassSessionFirstUse")()
+	transaction := pr.Dbmap.Begin()
+
+	// Update session only if nobody has used it before
+	query := transaction.Model(&BreakGlassSession{}).Where("id like ? AND first_use_at = 0", id).
+		Update("first_use_at", firstUseAt.UnixNano())
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		transaction.Rollback()
+		return false, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	if query.RowsAffected != 1 {
+		transaction.Rollback()
+		return false, nil
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return false, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return false, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return true, nil
+}
+
+func (pr PostgresRepo) GetBreakGlassSessionsFiltered(filter *api.Filter) ([]api.BreakGlassSession, int, error) {
+	defer pr.observeQuery("GetBreakGlassSessionsFiltered")()
+	var total int
+	sessions := []BreakGlassSession{}
+	query := pr.Dbmap
+
+	if len(filter.ExternalID) > 0 {
+		query = query.Where("user_id like ?", filter.ExternalID)
+	}
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	}
+
+	// Error handling
+	if err := query.Find(&sessions).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&sessions).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform break-glass sessions to API
+	var apiSessions []api.BreakGlassSession
+	if sessions != nil {
+		apiSessions = make([]api.BreakGlassSession, len(sessions), cap(sessions))
+		for i, s := range sessions {
+			apiSessions[i] = *dbBreakGlassSessionToAPIBreakGlassSession(&s)
+		}
+	}
+
+	return apiSessions, total, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform a break-glass session retrieved from db into a session for API
+func dbBreakGlassSessionToAPIBreakGlassSession(session *BreakGlassSession) *api.BreakGlassSession {
+	apiSession := &api.BreakGlassSession{
+		ID:            session.ID,
+		UserID:        session.UserID,
+		Justification: session.Justification,
+		Urn:           session.Urn,
+		CreateAt:      time.Unix(0, session.CreateAt).UTC(),
+		ExpiresAt:     time.Unix(0, session.ExpiresAt).UTC(),
+	}
+	if session.FirstUseAt != 0 {
+		apiSession.FirstUseAt = time.Unix(0, session.FirstUseAt).UTC()
+	}
+	return apiSession
+}
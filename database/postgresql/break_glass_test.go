@@ -0,0 +1,374 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRepo_AddBreakGlassSession(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousSession *BreakGlassSession
+		// Postgres Repo Args
+		sessionToCreate *api.BreakGlassSession
+		// Expected result
+		expectedResponse *api.BreakGlassSession
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			sessionToCreate: &api.BreakGlassSession{
+				ID:            "SessionID",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           "urn",
+				CreateAt:      now,
+				ExpiresAt:     now.Add(time.Hour),
+			},
+			expectedResponse: &api.BreakGlassSession{
+				ID:            "SessionID",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           "urn",
+				CreateAt:      now,
+				ExpiresAt:     now.Add(time.Hour),
+			},
+		},
+		"ErrorCaseAlreadyExists": {
+			previousSession: &BreakGlassSession{
+				ID:            "SessionID",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           "urn",
+				CreateAt:      now.UnixNano(),
+				ExpiresAt:     now.Add(time.Hour).UnixNano(),
+			},
+			sessionToCreate: &api.BreakGlassSession{
+				ID:            "SessionID",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           "urn",
+				CreateAt:      now,
+				ExpiresAt:     now.Add(time.Hour),
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "pq: duplicate key value violates unique constraint \"break_glass_sessions_pkey\"",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean break-glass sessions database
+		cleanBreakGlassSessionsTable(t, n)
+
+		// Insert previous data
+		if test.previousSession != nil {
+			insertBreakGlassSession(t, n, *test.previousSession)
+		}
+		// Call to repository to store the session
+		storedSession, err := repoDB.AddBreakGlassSession(*test.sessionToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check response
+			assert.Equal(t, test.expectedResponse, storedSession, "Error in test case %v", n)
+			// Check database
+			sessionNumber := getBreakGlassSessionsCountFiltered(t, n, test.sessionToCreate.ID, test.sessionToCreate.UserID,
+				test.sessionToCreate.Justification)
+			assert.Equal(t, 1, sessionNumber, "Error in test case %v, session not found in database", n)
+		}
+	}
+}
+
+func TestPostgresRepo_GetActiveBreakGlassSession(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousSessions []BreakGlassSession
+		// Postgres Repo Args
+		userID string
+		// Expected result
+		expectedResponse *api.BreakGlassSession
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousSessions: []BreakGlassSession{
+				{
+					ID:            "Expired",
+					UserID:        "emergency",
+					Justification: "Policy misconfiguration",
+					Urn:           "urn1",
+					CreateAt:      now.Add(-2 * time.Hour).UnixNano(),
+					ExpiresAt:     now.Add(-time.Hour).UnixNano(),
+				},
+				{
+					ID:            "Active",
+					UserID:        "emergency",
+					Justification: "OIDC provider down",
+					Urn:           "urn2",
+					CreateAt:      now.UnixNano(),
+					ExpiresAt:     now.Add(time.Hour).UnixNano(),
+				},
+			},
+			userID: "emergency",
+			expectedResponse: &api.BreakGlassSession{
+				ID:            "Active",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           "urn2",
+				CreateAt:      now,
+				ExpiresAt:     now.Add(time.Hour),
+			},
+		},
+		"ErrorCaseSessionExpired": {
+			previousSessions: []BreakGlassSession{
+				{
+					ID:            "Expired",
+					UserID:        "emergency",
+					Justification: "Policy misconfiguration",
+					Urn:           "urn1",
+					CreateAt:      now.Add(-2 * time.Hour).UnixNano(),
+					ExpiresAt:     now.Add(-time.Hour).UnixNano(),
+				},
+			},
+			userID: "emergency",
+			expectedError: &database.Error{
+				Code:    database.BREAK_GLASS_SESSION_NOT_FOUND,
+				Message: "Active break-glass session for user emergency not found",
+			},
+		},
+		"ErrorCaseOtherUser": {
+			previousSessions: []BreakGlassSession{
+				{
+					ID:            "Active",
+					UserID:        "emergency",
+					Justification: "OIDC provider down",
+					Urn:           "urn2",
+					CreateAt:      now.UnixNano(),
+					ExpiresAt:     now.Add(time.Hour).UnixNano(),
+				},
+			},
+			userID: "other",
+			expectedError: &database.Error{
+				Code:    database.BREAK_GLASS_SESSION_NOT_FOUND,
+				Message: "Active break-glass session for user other not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean break-glass sessions database
+		cleanBreakGlassSessionsTable(t, n)
+
+		// Insert previous data
+		for _, session := range test.previousSessions {
+			insertBreakGlassSession(t, n, session)
+		}
+		// Call to repository to get the active session
+		session, err := repoDB.GetActiveBreakGlassSession(test.userID, now)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, session, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_SetBreakGlassSessionFirstUse(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousSession *BreakGlassSession
+		// Postgres Repo Args
+		id string
+		// Expected result
+		expectedFirstUse   bool
+		expectedFirstUseAt int64
+	}{
+		"OkCaseFirstUse": {
+			previousSession: &BreakGlassSession{
+				ID:            "SessionID",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           "urn",
+				CreateAt:      now.UnixNano(),
+				ExpiresAt:     now.Add(time.Hour).UnixNano(),
+			},
+			id:                 "SessionID",
+			expectedFirstUse:   true,
+			expectedFirstUseAt: now.Add(time.Minute).UnixNano(),
+		},
+		"OkCaseUsedSession": {
+			previousSession: &BreakGlassSession{
+				ID:            "SessionID",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           "urn",
+				CreateAt:      now.UnixNano(),
+				ExpiresAt:     now.Add(time.Hour).UnixNano(),
+				FirstUseAt:    now.UnixNano(),
+			},
+			id:                 "SessionID",
+			expectedFirstUseAt: now.UnixNano(),
+		},
+		"OkCaseSessionNotFound": {
+			id: "SessionID",
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean break-glass sessions database
+		cleanBreakGlassSessionsTable(t, n)
+		cleanAuditEventsTable(t, n)
+
+		// Insert previous data
+		if test.previousSession != nil {
+			insertBreakGlassSession(t, n, *test.previousSession)
+		}
+		// Call to repository to set the first use with its alert
+		alert := api.AuditEvent{
+			ID:                  "EventID",
+			Type:                api.AUDIT_EVENT_TYPE_ALERT,
+			Actor:               "emergency",
+			BreakGlassSessionID: test.id,
+			Action:              api.BREAK_GLASS_ACTION_USE_SESSION,
+			EntityUrn:           "urn",
+			Urn:                 "eventUrn",
+			CreateAt:            now,
+		}
+		changeRepo := repoDB.WithEvents([]api.AuditEvent{alert}, nil).(PostgresRepo)
+		firstUse, err := changeRepo.SetBreakGlassSessionFirstUse(test.id, now.Add(time.Minute))
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedFirstUse, firstUse, "Error in test case %v", n)
+		// Alert is only stored with the first use
+		eventNumber := getAuditEventsCountFiltered(t, n, alert.ID, "", "")
+		if test.expectedFirstUse {
+			assert.Equal(t, 1, eventNumber, "Error in test case %v", n)
+		} else {
+			assert.Equal(t, 0, eventNumber, "Error in test case %v", n)
+		}
+		// Check database
+		if test.previousSession != nil {
+			session := BreakGlassSession{}
+			err = repoDB.Dbmap.Where("id = ?", test.id).First(&session).Error
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedFirstUseAt, session.FirstUseAt, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_GetBreakGlassSessionsFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousSessions []BreakGlassSession
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.BreakGlassSession
+		expectedTotal    int
+	}{
+		"OkCaseFilterByUser": {
+			previousSessions: []BreakGlassSession{
+				{
+					ID:            "Session1",
+					UserID:        "emergency",
+					Justification: "OIDC provider down",
+					Urn:           "urn1",
+					CreateAt:      now.UnixNano(),
+					ExpiresAt:     now.Add(time.Hour).UnixNano(),
+				},
+				{
+					ID:            "Session2",
+					UserID:        "other",
+					Justification: "Policy misconfiguration",
+					Urn:           "urn2",
+					CreateAt:      now.UnixNano(),
+					ExpiresAt:     now.Add(time.Hour).UnixNano(),
+				},
+			},
+			filter: &api.Filter{
+				ExternalID: "emergency",
+				Limit:      20,
+			},
+			expectedResponse: []api.BreakGlassSession{
+				{
+					ID:            "Session1",
+					UserID:        "emergency",
+					Justification: "OIDC provider down",
+					Urn:           "urn1",
+					CreateAt:      now,
+					ExpiresAt:     now.Add(time.Hour),
+				},
+			},
+			expectedTotal: 1,
+		},
+		"OkCaseOrderBy": {
+			previousSessions: []BreakGlassSession{
+				{
+					ID:            "Session1",
+					UserID:        "emergency",
+					Justification: "OIDC provider down",
+					Urn:           "urn1",
+					CreateAt:      now.UnixNano(),
+					ExpiresAt:     now.Add(time.Hour).UnixNano(),
+				},
+				{
+					ID:            "Session2",
+					UserID:        "other",
+					Justification: "Policy misconfiguration",
+					Urn:           "urn2",
+					CreateAt:      now.UnixNano(),
+					ExpiresAt:     now.Add(time.Hour).UnixNano(),
+				},
+			},
+			filter: &api.Filter{
+				Limit:   20,
+				OrderBy: "user_id desc",
+			},
+			expectedResponse: []api.BreakGlassSession{
+				{
+					ID:            "Session2",
+					UserID:        "other",
+					Justification: "Policy misconfiguration",
+					Urn:           "urn2",
+					CreateAt:      now,
+					ExpiresAt:     now.Add(time.Hour),
+				},
+				{
+					ID:            "Session1",
+					UserID:        "emergency",
+					Justification: "OIDC provider down",
+					Urn:           "urn1",
+					CreateAt:      now,
+					ExpiresAt:     now.Add(time.Hour),
+				},
+			},
+			expectedTotal: 2,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean break-glass sessions database
+		cleanBreakGlassSessionsTable(t, n)
+
+		// Insert previous data
+		for _, session := range test.previousSessions {
+			insertBreakGlassSession(t, n, session)
+		}
+		// Call to repository to get sessions
+		sessions, total, err := repoDB.GetBreakGlassSessionsFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, sessions, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,249 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// CHANGE REQUEST REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) AddChangeRequest(changeRequest api.ChangeRequest) (*api.ChangeRequest, error) {
+	defer pr.observeQuery("AddChangeRequest")()
+	// Create change request model
+	changeRequestDB, err := apiChangeRequestToDBChangeRequest(changeRequest)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Store change request
+	if err := transaction.Create(changeRequestDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return &changeRequest, nil
+}
+
+func (pr PostgresRepo) GetChangeRequestByID(org string, id string) (*api.ChangeRequest, error) {
+	defer pr.observeQuery("GetChangeRequestByID")()
+	changeRequest := &ChangeRequest{}
+	query := pr.Dbmap.Where("org like ? AND id like ?", org, id).First(changeRequest)
+
+	// Check if change request exists
+	if query.RecordNotFound() {
+		return nil, &database.Error{
+			Code:    database.CHANGE_REQUEST_NOT_FOUND,
+			Message: fmt.Sprintf("Change request with organization %v and id %v not found", org, id),
+		}
+	}
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	apiChangeRequest, err := dbChangeRequestToAPIChangeRequest(changeRequest)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return apiChangeRequest, nil
+}
+
+func (pr PostgresRepo) GetChangeRequestsFiltered(filter *api.Filter) ([]api.ChangeRequest, int, error) {
+	defer pr.observeQuery("GetChangeRequestsFiltered")()
+	var total int
+	changeRequests := []ChangeRequest{}
+	query := pr.Dbmap
+
+	if len(filter.Org) > 0 {
+		query = query.Where("org like ?", filter.Org)
+	}
+	if len(filter.Status) > 0 {
+		query = query.Where("status like ?", filter.Status)
+	}
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	}
+
+	// Error handling
+	if err := query.Find(&changeRequests).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&changeRequests).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform change requests to API
+	var apiChangeRequests []api.ChangeRequest
+	if changeRequests != nil {
+		apiChangeRequests = make([]api.ChangeRequest, len(changeRequests), cap(changeRequests))
+		for i, c := range changeRequests {
+			apiChangeRequest, err := dbChangeRequestToAPIChangeRequest(&c)
+			if err != nil {
+				return nil, total, &database.Error{
+					Code:    database.INTERNAL_ERROR,
+					Message: err.Error(),
+				}
+			}
+			apiChangeRequests[i] = *apiChangeRequest
+		}
+	}
+
+	return apiChangeRequests, total, nil
+}
+
+func (pr PostgresRepo) UpdateChangeRequest(changeRequest api.ChangeRequest, status string) (*api.ChangeRequest, error) {
+	defer pr.observeQuery("UpdateChangeRequest")()
+	var reviewAt int64
+	if !changeRequest.ReviewAt.IsZero() {
+		reviewAt = changeRequest.ReviewAt.UnixNano()
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Update change request only if nobody has changed its status before
+	query := transaction.Model(&ChangeRequest{}).Where("id like ? AND status like ?", changeRequest.ID, status).
+		Updates(map[string]interface{}{
+			"status":    changeRequest.Status,
+			"reviewer":  changeRequest.Reviewer,
+			"comment":   changeRequest.Comment,
+			"review_at": reviewAt,
+		})
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	if query.RowsAffected != 1 {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.CHANGE_REQUEST_STATUS_CHANGED,
+			Message: fmt.Sprintf("Change request with id %v isn't %v", changeRequest.ID, status),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return &changeRequest, nil
+}
+
+func (pr PostgresRepo) ExpireChangeRequests(now time.Time) error {
+	defer pr.observeQuery("ExpireChangeRequests")()
+	query := pr.Dbmap.Model(&ChangeRequest{}).Where("status like ? AND expires_at <= ?", api.CHANGE_REQUEST_STATUS_PENDING, now.UnixNano()).
+		Update("status", api.CHANGE_REQUEST_STATUS_EXPIRED)
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform a change request for API into a change request model for db
+func apiChangeRequestToDBChangeRequest(changeRequest api.ChangeRequest) (*ChangeRequest, error) {
+	payload, err := json.Marshal(changeRequest.Payload)
+	if err != nil {
+		return nil, err
+	}
+	var reviewAt int64
+	if !changeRequest.ReviewAt.IsZero() {
+		reviewAt = changeRequest.ReviewAt.UnixNano()
+	}
+
+	return &ChangeRequest{
+		ID:             changeRequest.ID,
+		Org:            changeRequest.Org,
+		Operation:      changeRequest.Operation,
+		Payload:        string(payload),
+		Status:         changeRequest.Status,
+		Requester:      changeRequest.Requester,
+		RequesterAdmin: changeRequest.RequesterAdmin,
+		Reviewer:       changeRequest.Reviewer,
+		Comment:        changeRequest.Comment,
+		Urn:            changeRequest.Urn,
+		CreateAt:       changeRequest.CreateAt.UnixNano(),
+		ExpiresAt:      changeRequest.ExpiresAt.UnixNano(),
+		ReviewAt:       reviewAt,
+	}, nil
+}
+
+// Transform a change request retrieved from db into a change request for API
+func dbChangeRequestToAPIChangeRequest(changeRequest *ChangeRequest) (*api.ChangeRequest, error) {
+	payload := api.ChangeRequestPayload{}
+	if err := json.Unmarshal([]byte(changeRequest.Payload), &payload); err != nil {
+		return nil, err
+	}
+	var reviewAt time.Time
+	if changeRequest.ReviewAt != 0 {
+		reviewAt = time.Unix(0, changeRequest.ReviewAt).UTC()
+	}
+
+	return &api.ChangeRequest{
+		ID:             changeRequest.ID,
+		Org:            changeRequest.Org,
+		Operation:      changeRequest.Operation,
+		Payload:        payload,
+		Status:         changeRequest.Status,
+		Requester:      changeRequest.Requester,
+		RequesterAdmin: changeRequest.RequesterAdmin,
+		Reviewer:       changeRequest.Reviewer,
+		Comment:        changeRequest.Comment,
+		Urn:            changeRequest.Urn,
+		CreateAt:       time.Unix(0, changeRequest.CreateAt).UTC(),
+		ExpiresAt:      time.Unix(0, changeRequest.ExpiresAt).UTC(),
+		ReviewAt:       reviewAt,
+	}, nil
+}
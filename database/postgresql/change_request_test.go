@@ -0,0 +1,530 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRepo_AddChangeRequest(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousChangeRequest *ChangeRequest
+		// Postgres Repo Args
+		changeRequestToCreate *api.ChangeRequest
+		// Expected result
+		expectedResponse *api.ChangeRequest
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			changeRequestToCreate: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: api.ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedResponse: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: api.ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+		},
+		"ErrorCaseAlreadyExists": {
+			previousChangeRequest: &ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload:   `{"policyName":"policy1","groupName":"group1"}`,
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       "urn",
+				CreateAt:  now.UnixNano(),
+				ExpiresAt: now.Add(time.Hour).UnixNano(),
+			},
+			changeRequestToCreate: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: api.ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "pq: duplicate key value violates unique constraint \"change_requests_pkey\"",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean change requests database
+		cleanChangeRequestsTable(t, n)
+
+		// Insert previous data
+		if test.previousChangeRequest != nil {
+			insertChangeRequest(t, n, *test.previousChangeRequest)
+		}
+		// Call to repository to store the change request
+		storedChangeRequest, err := repoDB.AddChangeRequest(*test.changeRequestToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check response
+			assert.Equal(t, test.expectedResponse, storedChangeRequest, "Error in test case %v", n)
+			// Check database
+			changeRequestNumber := getChangeRequestsCountFiltered(t, n, test.changeRequestToCreate.ID, test.changeRequestToCreate.Org,
+				test.changeRequestToCreate.Status)
+			assert.Equal(t, 1, changeRequestNumber, "Error in test case %v, change request not found in database", n)
+		}
+	}
+}
+
+func TestPostgresRepo_GetChangeRequestByID(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousChangeRequest *ChangeRequest
+		// Postgres Repo Args
+		org string
+		id  string
+		// Expected result
+		expectedResponse *api.ChangeRequest
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousChangeRequest: &ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+				Payload:   `{"policyName":"policy1","newName":"policy1","newPath":"/path/","statements":[{"effect":"allow","actions":["iam:*"],"resources":["urn:everything:*"]}]}`,
+				Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+				Requester: "user1",
+				Reviewer:  "user2",
+				Comment:   "Looks good",
+				Urn:       "urn",
+				CreateAt:  now.UnixNano(),
+				ExpiresAt: now.Add(time.Hour).UnixNano(),
+				ReviewAt:  now.UnixNano(),
+			},
+			org: "org1",
+			id:  "ChangeRequestID",
+			expectedResponse: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+				Payload: api.ChangeRequestPayload{
+					PolicyName: "policy1",
+					NewName:    "policy1",
+					NewPath:    "/path/",
+					Statements: &[]api.Statement{
+						{
+							Effect:    "allow",
+							Actions:   []string{"iam:*"},
+							Resources: []string{"urn:everything:*"},
+						},
+					},
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+				Requester: "user1",
+				Reviewer:  "user2",
+				Comment:   "Looks good",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+		},
+		"ErrorCaseNotFound": {
+			previousChangeRequest: &ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload:   `{"policyName":"policy1","groupName":"group1"}`,
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       "urn",
+				CreateAt:  now.UnixNano(),
+				ExpiresAt: now.Add(time.Hour).UnixNano(),
+			},
+			org: "org2",
+			id:  "ChangeRequestID",
+			expectedError: &database.Error{
+				Code:    database.CHANGE_REQUEST_NOT_FOUND,
+				Message: "Change request with organization org2 and id ChangeRequestID not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean change requests database
+		cleanChangeRequestsTable(t, n)
+
+		// Insert previous data
+		if test.previousChangeRequest != nil {
+			insertChangeRequest(t, n, *test.previousChangeRequest)
+		}
+		// Call to repository to get the change request
+		changeRequest, err := repoDB.GetChangeRequestByID(test.org, test.id)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, changeRequest, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_GetChangeRequestsFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	previousChangeRequests := []ChangeRequest{
+		{
+			ID:        "ChangeRequest1",
+			Org:       "org1",
+			Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+			Payload:   `{"policyName":"policy1","groupName":"group1"}`,
+			Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+			Requester: "user1",
+			Urn:       "urn1",
+			CreateAt:  now.UnixNano(),
+			ExpiresAt: now.Add(time.Hour).UnixNano(),
+		},
+		{
+			ID:        "ChangeRequest2",
+			Org:       "org2",
+			Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+			Payload:   `{"policyName":"policy2","groupName":"group2"}`,
+			Status:    api.CHANGE_REQUEST_STATUS_REJECTED,
+			Requester: "user2",
+			Reviewer:  "user1",
+			Urn:       "urn2",
+			CreateAt:  now.UnixNano(),
+			ExpiresAt: now.Add(time.Hour).UnixNano(),
+			ReviewAt:  now.UnixNano(),
+		},
+	}
+	changeRequest1 := api.ChangeRequest{
+		ID:        "ChangeRequest1",
+		Org:       "org1",
+		Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+		Payload: api.ChangeRequestPayload{
+			GroupName:  "group1",
+			PolicyName: "policy1",
+		},
+		Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+		Requester: "user1",
+		Urn:       "urn1",
+		CreateAt:  now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	changeRequest2 := api.ChangeRequest{
+		ID:        "ChangeRequest2",
+		Org:       "org2",
+		Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+		Payload: api.ChangeRequestPayload{
+			GroupName:  "group2",
+			PolicyName: "policy2",
+		},
+		Status:    api.CHANGE_REQUEST_STATUS_REJECTED,
+		Requester: "user2",
+		Reviewer:  "user1",
+		Urn:       "urn2",
+		CreateAt:  now,
+		ExpiresAt: now.Add(time.Hour),
+		ReviewAt:  now,
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.ChangeRequest
+		expectedTotal    int
+	}{
+		"OkCaseFilterByOrg": {
+			filter: &api.Filter{
+				Org:   "org1",
+				Limit: 20,
+			},
+			expectedResponse: []api.ChangeRequest{changeRequest1},
+			expectedTotal:    1,
+		},
+		"OkCaseFilterByStatus": {
+			filter: &api.Filter{
+				Status: api.CHANGE_REQUEST_STATUS_REJECTED,
+				Limit:  20,
+			},
+			expectedResponse: []api.ChangeRequest{changeRequest2},
+			expectedTotal:    1,
+		},
+		"OkCaseOrderBy": {
+			filter: &api.Filter{
+				Limit:   20,
+				OrderBy: "org desc",
+			},
+			expectedResponse: []api.ChangeRequest{changeRequest2, changeRequest1},
+			expectedTotal:    2,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean change requests database
+		cleanChangeRequestsTable(t, n)
+
+		// Insert previous data
+		for _, changeRequest := range previousChangeRequests {
+			insertChangeRequest(t, n, changeRequest)
+		}
+		// Call to repository to get change requests
+		changeRequests, total, err := repoDB.GetChangeRequestsFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, changeRequests, "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_UpdateChangeRequest(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousChangeRequest *ChangeRequest
+		// Postgres Repo Args
+		changeRequestToUpdate *api.ChangeRequest
+		status                string
+		// Expected result
+		expectedResponse *api.ChangeRequest
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousChangeRequest: &ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload:   `{"policyName":"policy1","groupName":"group1"}`,
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "user1",
+				Urn:       "urn",
+				CreateAt:  now.UnixNano(),
+				ExpiresAt: now.Add(time.Hour).UnixNano(),
+			},
+			changeRequestToUpdate: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: api.ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+				Requester: "user1",
+				Reviewer:  "user2",
+				Comment:   "Looks good",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+			status: api.CHANGE_REQUEST_STATUS_PENDING,
+			expectedResponse: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: api.ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+				Requester: "user1",
+				Reviewer:  "user2",
+				Comment:   "Looks good",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+		},
+		"ErrorCaseStatusChanged": {
+			previousChangeRequest: &ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload:   `{"policyName":"policy1","groupName":"group1"}`,
+				Status:    api.CHANGE_REQUEST_STATUS_REJECTED,
+				Requester: "user1",
+				Reviewer:  "user3",
+				Urn:       "urn",
+				CreateAt:  now.UnixNano(),
+				ExpiresAt: now.Add(time.Hour).UnixNano(),
+				ReviewAt:  now.UnixNano(),
+			},
+			changeRequestToUpdate: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: api.ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+				Requester: "user1",
+				Reviewer:  "user2",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+			status: api.CHANGE_REQUEST_STATUS_PENDING,
+			expectedResponse: &api.ChangeRequest{
+				ID:        "ChangeRequestID",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Payload: api.ChangeRequestPayload{
+					GroupName:  "group1",
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_REJECTED,
+				Requester: "user1",
+				Reviewer:  "user3",
+				Urn:       "urn",
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+			expectedError: &database.Error{
+				Code:    database.CHANGE_REQUEST_STATUS_CHANGED,
+				Message: "Change request with id ChangeRequestID isn't pending",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean change requests database
+		cleanChangeRequestsTable(t, n)
+
+		// Insert previous data
+		if test.previousChangeRequest != nil {
+			insertChangeRequest(t, n, *test.previousChangeRequest)
+		}
+		// Call to repository to update the change request
+		updatedChangeRequest, err := repoDB.UpdateChangeRequest(*test.changeRequestToUpdate, test.status)
+		if test.expectedError != nil {
+			dbError, ok := err.(*database.Error)
+			if !ok || dbError == nil {
+				t.Errorf("Test %v failed. Unexpected data retrieved from error: %v", n, err)
+				continue
+			}
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check response
+			assert.Equal(t, test.expectedResponse, updatedChangeRequest, "Error in test case %v", n)
+		}
+		// Check database
+		changeRequest, err := repoDB.GetChangeRequestByID(test.changeRequestToUpdate.Org, test.changeRequestToUpdate.ID)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, changeRequest, "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_ExpireChangeRequests(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousChangeRequests []ChangeRequest
+		// Expected result
+		expectedPending  int
+		expectedExpired  int
+		expectedApproved int
+	}{
+		"OkCase": {
+			previousChangeRequests: []ChangeRequest{
+				{
+					ID:        "Expired",
+					Org:       "org1",
+					Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+					Payload:   `{"policyName":"policy1","groupName":"group1"}`,
+					Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+					Requester: "user1",
+					Urn:       "urn1",
+					CreateAt:  now.Add(-2 * time.Hour).UnixNano(),
+					ExpiresAt: now.Add(-time.Hour).UnixNano(),
+				},
+				{
+					ID:        "Pending",
+					Org:       "org1",
+					Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+					Payload:   `{"policyName":"policy2","groupName":"group1"}`,
+					Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+					Requester: "user1",
+					Urn:       "urn2",
+					CreateAt:  now.UnixNano(),
+					ExpiresAt: now.Add(time.Hour).UnixNano(),
+				},
+				{
+					ID:        "Approved",
+					Org:       "org1",
+					Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+					Payload:   `{"policyName":"policy3","groupName":"group1"}`,
+					Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+					Requester: "user1",
+					Reviewer:  "user2",
+					Urn:       "urn3",
+					CreateAt:  now.Add(-2 * time.Hour).UnixNano(),
+					ExpiresAt: now.Add(-time.Hour).UnixNano(),
+					ReviewAt:  now.Add(-90 * time.Minute).UnixNano(),
+				},
+			},
+			expectedPending:  1,
+			expectedExpired:  1,
+			expectedApproved: 1,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean change requests database
+		cleanChangeRequestsTable(t, n)
+
+		// Insert previous data
+		for _, changeRequest := range test.previousChangeRequests {
+			insertChangeRequest(t, n, changeRequest)
+		}
+		// Call to repository to expire change requests
+		err := repoDB.ExpireChangeRequests(now)
+		assert.Nil(t, err, "Error in test case %v", n)
+		// Check database
+		assert.Equal(t, test.expectedPending, getChangeRequestsCountFiltered(t, n, "", "", api.CHANGE_REQUEST_STATUS_PENDING),
+			"Error in test case %v", n)
+		assert.Equal(t, test.expectedExpired, getChangeRequestsCountFiltered(t, n, "Expired", "", api.CHANGE_REQUEST_STATUS_EXPIRED),
+			"Error in test case %v", n)
+		assert.Equal(t, test.expectedApproved, getChangeRequestsCountFiltered(t, n, "", "", api.CHANGE_REQUEST_STATUS_APPROVED),
+			"Error in test case %v", n)
+	}
+}
@@ -11,6 +11,7 @@ import (
 // GROUP REPOSITORY IMPLEMENTATION
 
 func (pr PostgresRepo) AddGroup(group api.Group) (*api.Group, error) {
+	defer pr.observeQuery("AddGroup")()
 	// Create group model
 	groupDB := &Group{
 		ID:       group.ID,
@@ -22,11 +23,24 @@ func (pr PostgresRepo) AddGroup(group api.Group) (*api.Group, error) {
 		Org:      group.Org,
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Store group
-	err := pr.Dbmap.Create(groupDB).Error
+	if err := transaction.Create(groupDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error handling
-	if err != nil {
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -37,6 +51,7 @@ func (pr PostgresRepo) AddGroup(group api.Group) (*api.Group, error) {
 }
 
 func (pr PostgresRepo) GetGroupByName(org string, name string) (*api.Group, error) {
+	defer pr.observeQuery("GetGroupByName")()
 	group := &Group{}
 	query := pr.Dbmap.Where("org like ? AND name like ?", org, name).First(group)
 
@@ -60,6 +75,7 @@ func (pr PostgresRepo) GetGroupByName(org string, name string) (*api.Group, erro
 }
 
 func (pr PostgresRepo) GetGroupById(id string) (*api.Group, error) {
+	defer pr.observeQuery("GetGroupById")()
 	group := &Group{}
 	query := pr.Dbmap.Where("id like ?", id).First(group)
 
@@ -83,6 +99,7 @@ func (pr PostgresRepo) GetGroupById(id string) (*api.Group, error) {
 }
 
 func (pr PostgresRepo) GetGroupsFiltered(filter *api.Filter) ([]api.Group, int, error) {
+	defer pr.observeQuery("GetGroupsFiltered")()
 	var total int
 	groups := []Group{}
 	query := pr.Dbmap
@@ -119,6 +136,7 @@ func (pr PostgresRepo) GetGroupsFiltered(filter *api.Filter) ([]api.Group, int,
 }
 
 func (pr PostgresRepo) UpdateGroup(group api.Group) (*api.Group, error) {
+	defer pr.observeQuery("UpdateGroup")()
 	groupDB := Group{
 		ID:       group.ID,
 		Name:     group.Name,
@@ -129,11 +147,14 @@ func (pr PostgresRepo) UpdateGroup(group api.Group) (*api.Group, error) {
 		Org:      group.Org,
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Update group
-	query := pr.Dbmap.Model(&Group{ID: group.ID}).Updates(groupDB)
+	query := transaction.Model(&Group{ID: group.ID}).Updates(groupDB)
 
 	// Check if group exist
 	if query.RecordNotFound() {
+		transaction.Rollback()
 		return nil, &database.Error{
 			Code:    database.GROUP_NOT_FOUND,
 			Message: fmt.Sprintf("Group with name %v not found", group.Name),
@@ -142,6 +163,26 @@ func (pr PostgresRepo) UpdateGroup(group api.Group) (*api.Group, error) {
 
 	// Error Handling
 	if err := query.Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -152,6 +193,7 @@ func (pr PostgresRepo) UpdateGroup(group api.Group) (*api.Group, error) {
 }
 
 func (pr PostgresRepo) RemoveGroup(id string) error {
+	defer pr.observeQuery("RemoveGroup")()
 	transaction := pr.Dbmap.Begin()
 
 	// Delete group
@@ -184,11 +226,24 @@ func (pr PostgresRepo) RemoveGroup(id string) error {
 		}
 	}
 
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
 	transaction.Commit()
 	return nil
 }
 
 func (pr PostgresRepo) AddMember(userID string, groupID string) error {
+	defer pr.observeQuery("AddMember")()
 	// Create relation
 	relation := &GroupUserRelation{
 		UserID:   userID,
@@ -196,11 +251,30 @@ func (pr PostgresRepo) AddMember(userID string, groupID string) error {
 		CreateAt: time.Now().UTC().UnixNano(),
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Store relation
-	err := pr.Dbmap.Create(relation).Error
+	if err := transaction.Create(relation).Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error handling
-	if err != nil {
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -211,19 +285,42 @@ func (pr PostgresRepo) AddMember(userID string, groupID string) error {
 }
 
 func (pr PostgresRepo) RemoveMember(userID string, groupID string) error {
-	err := pr.Dbmap.Where("user_id like ? AND group_id like ?", userID, groupID).Delete(&GroupUserRelation{}).Error
+	defer pr.observeQuery("RemoveMember")()
+	transaction := pr.Dbmap.Begin()
 
-	// Error handling
-	if err != nil {
+	// Remove relation
+	if err := transaction.Where("user_id like ? AND group_id like ?", userID, groupID).Delete(&GroupUserRelation{}).Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
 		}
 	}
+
 	return nil
 }
 
 func (pr PostgresRepo) IsMemberOfGroup(userID string, groupID string) (bool, error) {
+	defer pr.observeQuery("IsMemberOfGroup")()
 	relation := GroupUserRelation{}
 	query := pr.Dbmap.Where("user_id like ? AND group_id like ?", userID, groupID).First(&relation)
 
@@ -244,6 +341,7 @@ func (pr PostgresRepo) IsMemberOfGroup(userID string, groupID string) (bool, err
 }
 
 func (pr PostgresRepo) GetGroupMembers(groupID string, filter *api.Filter) ([]api.UserGroupRelation, int, error) {
+	defer pr.observeQuery("GetGroupMembers")()
 	var total int
 	members := []GroupUserRelation{}
 	query := pr.Dbmap.Where("group_id like ?", groupID)
@@ -286,6 +384,7 @@ func (pr PostgresRepo) GetGroupMembers(groupID string, filter *api.Filter) ([]ap
 }
 
 func (pr PostgresRepo) AttachPolicy(groupID string, policyID string) error {
+	defer pr.observeQuery("AttachPolicy")()
 	// Create relation
 	relation := &GroupPolicyRelation{
 		GroupID:  groupID,
@@ -293,11 +392,30 @@ func (pr PostgresRepo) AttachPolicy(groupID string, policyID string) error {
 		CreateAt: time.Now().UTC().UnixNano(),
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Store relation
-	err := pr.Dbmap.Create(relation).Error
+	if err := transaction.Create(relation).Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error handling
-	if err != nil {
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -308,11 +426,31 @@ func (pr PostgresRepo) AttachPolicy(groupID string, policyID string) error {
 }
 
 func (pr PostgresRepo) DetachPolicy(groupID string, policyID string) error {
+	defer pr.observeQuery("DetachPolicy")()
+	transaction := pr.Dbmap.Begin()
+
 	// Remove relation
-	err := pr.Dbmap.Where("group_id like ? AND policy_id like ?", groupID, policyID).Delete(&GroupPolicyRelation{}).Error
+	if err := transaction.Where("group_id like ? AND policy_id like ?", groupID, policyID).Delete(&GroupPolicyRelation{}).Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error handling
-	if err != nil {
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -323,6 +461,7 @@ func (pr PostgresRepo) DetachPolicy(groupID string, policyID string) error {
 }
 
 func (pr PostgresRepo) IsAttachedToGroup(groupID string, policyID string) (bool, error) {
+	defer pr.observeQuery("IsAttachedToGroup")()
 	relation := GroupPolicyRelation{}
 	query := pr.Dbmap.Where("group_id like ? AND policy_id like ?", groupID, policyID).First(&relation)
 
@@ -343,6 +482,7 @@ func (pr PostgresRepo) IsAttachedToGroup(groupID string, policyID string) (bool,
 }
 
 func (pr PostgresRepo) GetAttachedPolicies(groupID string, filter *api.Filter) ([]api.PolicyGroupRelation, int, error) {
+	defer pr.observeQuery("GetAttachedPolicies")()
 	var total int
 	relations := []GroupPolicyRelation{}
 	query := pr.Dbmap.Where("group_id like ?", groupID)
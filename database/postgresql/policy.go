@@ -13,6 +13,7 @@ import (
 // POLICY REPOSITORY IMPLEMENTATION
 
 func (pr PostgresRepo) AddPolicy(policy api.Policy) (*api.Policy, error) {
+	defer pr.observeQuery("AddPolicy")()
 	// Create policy model
 	policyDB := &Policy{
 		ID:       policy.ID,
@@ -54,6 +55,12 @@ func (pr PostgresRepo) AddPolicy(policy api.Policy) (*api.Policy, error) {
 		}
 	}
 
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
 	transaction.Commit()
 
 	// Create API policy
@@ -64,6 +71,7 @@ func (pr PostgresRepo) AddPolicy(policy api.Policy) (*api.Policy, error) {
 }
 
 func (pr PostgresRepo) GetPolicyByName(org string, name string) (*api.Policy, error) {
+	defer pr.observeQuery("GetPolicyByName")()
 	policy := &Policy{}
 	query := pr.Dbmap.Where("org like ? AND name like ?", org, name).First(policy)
 
@@ -102,6 +110,7 @@ func (pr PostgresRepo) GetPolicyByName(org string, name string) (*api.Policy, er
 }
 
 func (pr PostgresRepo) GetPolicyById(id string) (*api.Policy, error) {
+	defer pr.observeQuery("GetPolicyById")()
 	policy := &Policy{}
 	query := pr.Dbmap.Where("id like ?", id).First(&policy)
 
@@ -140,6 +149,7 @@ func (pr PostgresRepo) GetPolicyById(id string) (*api.Policy, error) {
 }
 
 func (pr PostgresRepo) GetPoliciesFiltered(filter *api.Filter) ([]api.Policy, int, error) {
+	defer pr.observeQuery("GetPoliciesFiltered")()
 	var total int
 	policies := []Policy{}
 	query := pr.Dbmap
@@ -193,6 +203,7 @@ func (pr PostgresRepo) GetPoliciesFiltered(filter *api.Filter) ([]api.Policy, in
 }
 
 func (pr PostgresRepo) UpdatePolicy(policy api.Policy) (*api.Policy, error) {
+	defer pr.observeQuery("UpdatePolicy")()
 
 	policyDB := Policy{
 		ID:       policy.ID,
@@ -242,12 +253,25 @@ func (pr PostgresRepo) UpdatePolicy(policy api.Policy) (*api.Policy, error) {
 		}
 	}
 
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
 	transaction.Commit()
 
 	return &policy, nil
 }
 
 func (pr PostgresRepo) RemovePolicy(id string) error {
+	defer pr.observeQuery("RemovePolicy")()
 
 	transaction := pr.Dbmap.Begin()
 
@@ -260,6 +284,15 @@ func (pr PostgresRepo) RemovePolicy(id string) error {
 			Message: err.Error(),
 		}
 	}
+	// Delete policy template relation
+	transaction.Where("policy_id like ?", id).Delete(&PolicyTemplateInstance{})
+	if err := transaction.Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 	// Delete policy statements
 	transaction.Where("policy_id like ?", id).Delete(&Statement{})
 	if err := transaction.Error; err != nil {
@@ -279,11 +312,24 @@ func (pr PostgresRepo) RemovePolicy(id string) error {
 		}
 	}
 
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
 	transaction.Commit()
 	return nil
 }
 
 func (pr PostgresRepo) GetAttachedGroups(policyID string, filter *api.Filter) ([]api.PolicyGroupRelation, int, error) {
+	defer pr.observeQuery("GetAttachedGroups")()
 	var total int
 	relations := []GroupPolicyRelation{}
 	query := pr.Dbmap
@@ -0,0 +1,490 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+)
+
+// POLICY TEMPLATE REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) AddPolicyTemplate(policyTemplate api.PolicyTemplate) (*api.PolicyTemplate, error) {
+	defer pr.observeQuery("AddPolicyTemplate")()
+	// Create policy template model
+	policyTemplateDB := &PolicyTemplate{
+		ID:       policyTemplate.ID,
+		Name:     policyTemplate.Name,
+		Path:     policyTemplate.Path,
+		CreateAt: policyTemplate.CreateAt.UnixNano(),
+		UpdateAt: policyTemplate.UpdateAt.UnixNano(),
+		Urn:      policyTemplate.Urn,
+		Org:      policyTemplate.Org,
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Create policy template
+	if err := transaction.Create(policyTemplateDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Create parameters and statements
+	if err := createPolicyTemplateDefinitions(transaction, policyTemplate); err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	transaction.Commit()
+
+	// Create API policy template
+	policyTemplateApi := dbPolicyTemplateToAPIPolicyTemplate(policyTemplateDB)
+	policyTemplateApi.Parameters = policyTemplate.Parameters
+	policyTemplateApi.Statements = policyTemplate.Statements
+
+	return policyTemplateApi, nil
+}
+
+func (pr PostgresRepo) GetPolicyTemplateByName(org string, name string) (*api.PolicyTemplate, error) {
+	defer pr.observeQuery("GetPolicyTemplateByName")()
+	policyTemplate := &PolicyTemplate{}
+	query := pr.Dbmap.Where("org like ? AND name like ?", org, name).First(policyTemplate)
+
+	// Check if policy template exists
+	if query.RecordNotFound() {
+		return nil, &database.Error{
+			Code:    database.POLICY_TEMPLATE_NOT_FOUND,
+			Message: fmt.Sprintf("Policy template with organization %v and name %v not found", org, name),
+		}
+	}
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return pr.getPolicyTemplateDefinitions(policyTemplate)
+}
+
+func (pr PostgresRepo) GetPolicyTemplatesFiltered(filter *api.Filter) ([]api.PolicyTemplate, int, error) {
+	defer pr.observeQuery("GetPolicyTemplatesFiltered")()
+	var total int
+	policyTemplates := []PolicyTemplate{}
+	query := pr.Dbmap
+
+	if len(filter.Org) > 0 {
+		query = query.Where("org like ?", filter.Org)
+	}
+	if len(filter.PathPrefix) > 0 {
+		query = query.Where("path like ?", filter.PathPrefix+"%")
+	}
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	}
+
+	// Error handling
+	if err := query.Find(&policyTemplates).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&policyTemplates).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform policy templates for API
+	var apiPolicyTemplates []api.PolicyTemplate
+	if policyTemplates != nil {
+		apiPolicyTemplates = make([]api.PolicyTemplate, len(policyTemplates), cap(policyTemplates))
+		for i, pt := range policyTemplates {
+			policyTemplate, err := pr.getPolicyTemplateDefinitions(&pt)
+			if err != nil {
+				return nil, total, err
+			}
+			apiPolicyTemplates[i] = *policyTemplate
+		}
+	}
+
+	return apiPolicyTemplates, total, nil
+}
+
+func (pr PostgresRepo) UpdatePolicyTemplate(policyTemplate api.PolicyTemplate, renderedPolicies []api.Policy) (*api.PolicyTemplate, error) {
+	defer pr.observeQuery("UpdatePolicyTemplate")()
+
+	policyTemplateDB := PolicyTemplate{
+		ID:       policyTemplate.ID,
+		Name:     policyTemplate.Name,
+		Path:     policyTemplate.Path,
+		CreateAt: policyTemplate.CreateAt.UTC().UnixNano(),
+		UpdateAt: policyTemplate.UpdateAt.UTC().UnixNano(),
+		Urn:      policyTemplate.Urn,
+		Org:      policyTemplate.Org,
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Update policy template
+	if err := transaction.Model(&PolicyTemplate{ID: policyTemplate.ID}).Update(policyTemplateDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Clear old parameters and statements
+	if err := transaction.Where("policy_template_id like ?", policyTemplate.ID).Delete(PolicyTemplateParameter{}).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	if err := transaction.Where("policy_template_id like ?", policyTemplate.ID).Delete(PolicyTemplateStatement{}).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Create new parameters and statements
+	if err := createPolicyTemplateDefinitions(transaction, policyTemplate); err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Override statements of rendered policies
+	for _, policy := range renderedPolicies {
+		if err := transaction.Model(&Policy{ID: policy.ID}).Update(Policy{UpdateAt: policy.UpdateAt.UTC().UnixNano()}).Error; err != nil {
+			transaction.Rollback()
+			return nil, &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		if err := transaction.Where("policy_id like ?", policy.ID).Delete(Statement{}).Error; err != nil {
+			transaction.Rollback()
+			return nil, &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		for _, s := range *policy.Statements {
+			statementDB := &Statement{
+				ID:        uuid.NewV4().String(),
+				PolicyID:  policy.ID,
+				Effect:    s.Effect,
+				Actions:   stringArrayToString(s.Actions),
+				Resources: stringArrayToString(s.Resources),
+			}
+			if err := transaction.Create(statementDB).Error; err != nil {
+				transaction.Rollback()
+				return nil, &database.Error{
+					Code:    database.INTERNAL_ERROR,
+					Message: err.Error(),
+				}
+			}
+		}
+	}
+
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	transaction.Commit()
+
+	return &policyTemplate, nil
+}
+
+func (pr PostgresRepo) RemovePolicyTemplate(id string) error {
+	defer pr.observeQuery("RemovePolicyTemplate")()
+
+	transaction := pr.Dbmap.Begin()
+
+	// Delete policy template relations (instances)
+	transaction.Where("policy_template_id like ?", id).Delete(&PolicyTemplateInstance{})
+	if err := transaction.Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	// Delete policy template parameters
+	transaction.Where("policy_template_id like ?", id).Delete(&PolicyTemplateParameter{})
+	if err := transaction.Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	// Delete policy template statements
+	transaction.Where("policy_template_id like ?", id).Delete(&PolicyTemplateStatement{})
+	if err := transaction.Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	// Delete policy template
+	transaction.Where("id like ?", id).Delete(&PolicyTemplate{})
+	if err := transaction.Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	transaction.Commit()
+	return nil
+}
+
+func (pr PostgresRepo) AddPolicyTemplateInstance(instance api.PolicyTemplateInstance) (*api.Policy, error) {
+	defer pr.observeQuery("AddPolicyTemplateInstance")()
+	policy := instance.Policy
+	parameters, err := json.Marshal(instance.Parameters)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Create policy model
+	policyDB := &Policy{
+		ID:       policy.ID,
+		Name:     policy.Name,
+		Path:     policy.Path,
+		CreateAt: policy.CreateAt.UnixNano(),
+		UpdateAt: policy.UpdateAt.UnixNano(),
+		Urn:      policy.Urn,
+		Org:      policy.Org,
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Create policy
+	if err := transaction.Create(policyDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Create statements
+	for _, statementApi := range *policy.Statements {
+		statementDB := &Statement{
+			ID:        uuid.NewV4().String(),
+			PolicyID:  policy.ID,
+			Effect:    statementApi.Effect,
+			Actions:   stringArrayToString(statementApi.Actions),
+			Resources: stringArrayToString(statementApi.Resources),
+		}
+		if err := transaction.Create(statementDB).Error; err != nil {
+			transaction.Rollback()
+			return nil, &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+	}
+
+	// Link policy with its template
+	instanceDB := &PolicyTemplateInstance{
+		PolicyID:         policy.ID,
+		PolicyTemplateID: instance.TemplateID,
+		Parameters:       string(parameters),
+		CreateAt:         policy.CreateAt.UnixNano(),
+	}
+	if err := transaction.Create(instanceDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	transaction.Commit()
+
+	// Create API policy
+	policyApi := dbPolicyToAPIPolicy(policyDB)
+	policyApi.Statements = policy.Statements
+
+	return policyApi, nil
+}
+
+func (pr PostgresRepo) GetPolicyTemplateInstances(templateID string) ([]api.PolicyTemplateInstance, error) {
+	defer pr.observeQuery("GetPolicyTemplateInstances")()
+	relations := []PolicyTemplateInstance{}
+	query := pr.Dbmap.Where("policy_template_id like ?", templateID).Order("create_at").Find(&relations)
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	instances := make([]api.PolicyTemplateInstance, len(relations), cap(relations))
+	for i, r := range relations {
+		policy, err := pr.GetPolicyById(r.PolicyID)
+		// Error handling
+		if err != nil {
+			return nil, &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+
+		parameters := map[string]string{}
+		if err := json.Unmarshal([]byte(r.Parameters), &parameters); err != nil {
+			return nil, &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+
+		instances[i] = api.PolicyTemplateInstance{
+			TemplateID: r.PolicyTemplateID,
+			Policy:     policy,
+			Parameters: parameters,
+		}
+	}
+
+	return instances, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Store parameters and statements of a policy template using the transaction received
+func createPolicyTemplateDefinitions(transaction *gorm.DB, policyTemplate api.PolicyTemplate) error {
+	for _, p := range policyTemplate.Parameters {
+		parameterDB := &PolicyTemplateParameter{
+			ID:               uuid.NewV4().String(),
+			PolicyTemplateID: policyTemplate.ID,
+			Name:             p.Name,
+			Description:      p.Description,
+			Pattern:          p.Pattern,
+		}
+		if err := transaction.Create(parameterDB).Error; err != nil {
+			return err
+		}
+	}
+	if policyTemplate.Statements != nil {
+		for _, s := range *policyTemplate.Statements {
+			statementDB := &PolicyTemplateStatement{
+				ID:               uuid.NewV4().String(),
+				PolicyTemplateID: policyTemplate.ID,
+				Effect:           s.Effect,
+				Actions:          stringArrayToString(s.Actions),
+				Resources:        stringArrayToString(s.Resources),
+			}
+			if err := transaction.Create(statementDB).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Retrieve parameters and statements of a policy template retrieved from db and transform it for API
+func (pr PostgresRepo) getPolicyTemplateDefinitions(policyTemplate *PolicyTemplate) (*api.PolicyTemplate, error) {
+	defer pr.observeQuery("getPolicyTemplateDefinitions")()
+	parameters := []PolicyTemplateParameter{}
+	if err := pr.Dbmap.Where("policy_template_id like ?", policyTemplate.ID).Order("name").Find(&parameters).Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	templateStatements := []PolicyTemplateStatement{}
+	if err := pr.Dbmap.Where("policy_template_id like ?", policyTemplate.ID).Find(&templateStatements).Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	statements := make([]Statement, len(templateStatements), cap(templateStatements))
+	for i, s := range templateStatements {
+		statements[i] = Statement{
+			Effect:    s.Effect,
+			Actions:   s.Actions,
+			Resources: s.Resources,
+		}
+	}
+
+	policyTemplateApi := dbPolicyTemplateToAPIPolicyTemplate(policyTemplate)
+	policyTemplateApi.Parameters = make([]api.PolicyTemplateParameter, len(parameters), cap(parameters))
+	for i, p := range parameters {
+		policyTemplateApi.Parameters[i] = api.PolicyTemplateParameter{
+			Name:        p.Name,
+			Description: p.Description,
+			Pattern:     p.Pattern,
+		}
+	}
+	policyTemplateApi.Statements = dbStatementsToAPIStatements(statements)
+
+	return policyTemplateApi, nil
+}
+
+// Transform a policy template retrieved from db into a policy template for API
+func dbPolicyTemplateToAPIPolicyTemplate(policyTemplatedb *PolicyTemplate) *api.PolicyTemplate {
+	return &api.PolicyTemplate{
+		ID:       policyTemplatedb.ID,
+		Name:     policyTemplatedb.Name,
+		Path:     policyTemplatedb.Path,
+		CreateAt: time.Unix(0, policyTemplatedb.CreateAt).UTC(),
+		UpdateAt: time.Unix(0, policyTemplatedb.UpdateAt).UTC(),
+		Urn:      policyTemplatedb.Urn,
+		Org:      policyTemplatedb.Org,
+	}
+}
@@ -0,0 +1,559 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRepo_AddPolicyTemplate(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPolicyTemplate *PolicyTemplate
+		// Postgres Repo Args
+		policyTemplateToCreate *api.PolicyTemplate
+		// Expected result
+		expectedResponse *api.PolicyTemplate
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			policyTemplateToCreate: &api.PolicyTemplate{
+				ID:       "TemplateID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name:        "team",
+						Description: "Team name",
+						Pattern:     "[a-z]+",
+					},
+				},
+				Statements: &[]api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{"iam:*"},
+						Resources: []string{"urn:iws:iam::user/{{team}}/*"},
+					},
+				},
+			},
+			expectedResponse: &api.PolicyTemplate{
+				ID:       "TemplateID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name:        "team",
+						Description: "Team name",
+						Pattern:     "[a-z]+",
+					},
+				},
+				Statements: &[]api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{"iam:*"},
+						Resources: []string{"urn:iws:iam::user/{{team}}/*"},
+					},
+				},
+			},
+		},
+		"ErrorCaseDuplicateUrn": {
+			previousPolicyTemplate: &PolicyTemplate{
+				ID:       "AnotherID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			policyTemplateToCreate: &api.PolicyTemplate{
+				ID:         "TemplateID",
+				Name:       "Name",
+				Org:        "Org",
+				Path:       "/path/",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+				Statements: &[]api.Statement{},
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "pq: duplicate key value violates unique constraint \"policy_templates_urn_key\"",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean policy template database
+		cleanPolicyTemplateTables(t, n)
+
+		// Insert previous data
+		if test.previousPolicyTemplate != nil {
+			insertPolicyTemplate(t, n, *test.previousPolicyTemplate, nil, nil)
+		}
+
+		// Call to repository to store a policy template
+		storedPolicyTemplate, err := repoDB.AddPolicyTemplate(*test.policyTemplateToCreate)
+		if test.expectedError != nil {
+			dbError, ok := err.(*database.Error)
+			if !ok || dbError == nil {
+				t.Errorf("Test %v failed. Unexpected data retrieved from error: %v", n, err)
+				continue
+			}
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check response
+			assert.Equal(t, test.expectedResponse, storedPolicyTemplate, "Error in test case %v", n)
+			// Check database
+			policyTemplateNumber := getPolicyTemplatesCountFiltered(t, n, test.policyTemplateToCreate.ID,
+				test.policyTemplateToCreate.Org, test.policyTemplateToCreate.Name)
+			assert.Equal(t, 1, policyTemplateNumber, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_GetPolicyTemplateByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPolicyTemplate *PolicyTemplate
+		previousParameters     []PolicyTemplateParameter
+		previousStatements     []PolicyTemplateStatement
+		// Postgres Repo Args
+		org  string
+		name string
+		// Expected result
+		expectedResponse *api.PolicyTemplate
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousPolicyTemplate: &PolicyTemplate{
+				ID:       "TemplateID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			previousParameters: []PolicyTemplateParameter{
+				{
+					ID:      "ParameterID",
+					Name:    "team",
+					Pattern: "[a-z]+",
+				},
+			},
+			previousStatements: []PolicyTemplateStatement{
+				{
+					ID:        "StatementID",
+					Effect:    "allow",
+					Actions:   "iam:*",
+					Resources: "urn:iws:iam::user/{{team}}/*",
+				},
+			},
+			org:  "Org",
+			name: "Name",
+			expectedResponse: &api.PolicyTemplate{
+				ID:       "TemplateID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name:    "team",
+						Pattern: "[a-z]+",
+					},
+				},
+				Statements: &[]api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{"iam:*"},
+						Resources: []string{"urn:iws:iam::user/{{team}}/*"},
+					},
+				},
+			},
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			org:  "Org",
+			name: "Name",
+			expectedError: &database.Error{
+				Code:    database.POLICY_TEMPLATE_NOT_FOUND,
+				Message: "Policy template with organization Org and name Name not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean policy template database
+		cleanPolicyTemplateTables(t, n)
+
+		// Insert previous data
+		if test.previousPolicyTemplate != nil {
+			insertPolicyTemplate(t, n, *test.previousPolicyTemplate, test.previousParameters, test.previousStatements)
+		}
+
+		// Call to repository to get a policy template
+		receivedPolicyTemplate, err := repoDB.GetPolicyTemplateByName(test.org, test.name)
+		if test.expectedError != nil {
+			dbError, ok := err.(*database.Error)
+			if !ok || dbError == nil {
+				t.Errorf("Test %v failed. Unexpected data retrieved from error: %v", n, err)
+				continue
+			}
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check response
+			assert.Equal(t, test.expectedResponse, receivedPolicyTemplate, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_AddPolicyTemplateInstance(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		instance api.PolicyTemplateInstance
+		// Expected result
+		expectedInstances []api.PolicyTemplateInstance
+	}{
+		"OkCase": {
+			instance: api.PolicyTemplateInstance{
+				TemplateID: "TemplateID",
+				Policy: &api.Policy{
+					ID:       "PolicyID",
+					Name:     "Name",
+					Org:      "Org",
+					Path:     "/path/",
+					Urn:      "urn",
+					CreateAt: now,
+					UpdateAt: now,
+					Statements: &[]api.Statement{
+						{
+							Effect:    "allow",
+							Actions:   []string{"iam:*"},
+							Resources: []string{"urn:iws:iam::user/a/*"},
+						},
+					},
+				},
+				Parameters: map[string]string{
+					"team": "a",
+				},
+			},
+			expectedInstances: []api.PolicyTemplateInstance{
+				{
+					TemplateID: "TemplateID",
+					Policy: &api.Policy{
+						ID:       "PolicyID",
+						Name:     "Name",
+						Org:      "Org",
+						Path:     "/path/",
+						Urn:      "urn",
+						CreateAt: now,
+						UpdateAt: now,
+						Statements: &[]api.Statement{
+							{
+								Effect:    "allow",
+								Actions:   []string{"iam:*"},
+								Resources: []string{"urn:iws:iam::user/a/*"},
+							},
+						},
+					},
+					Parameters: map[string]string{
+						"team": "a",
+					},
+				},
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanPolicyTemplateTables(t, n)
+		cleanPolicyTable(t, n)
+		cleanStatementTable(t, n)
+
+		// Call to repository to store the policy created from the template
+		storedPolicy, err := repoDB.AddPolicyTemplateInstance(test.instance)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.instance.Policy, storedPolicy, "Error in test case %v", n)
+
+		// Check database
+		instanceNumber := getPolicyTemplateInstancesCountFiltered(t, n, test.instance.Policy.ID, test.instance.TemplateID)
+		assert.Equal(t, 1, instanceNumber, "Error in test case %v", n)
+
+		// Check instances retrieved
+		instances, err := repoDB.GetPolicyTemplateInstances(test.instance.TemplateID)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedInstances, instances, "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_UpdatePolicyTemplate(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPolicyTemplate PolicyTemplate
+		previousParameters     []PolicyTemplateParameter
+		previousStatements     []PolicyTemplateStatement
+		previousPolicy         Policy
+		previousPolicyStmts    []Statement
+		previousInstance       PolicyTemplateInstance
+		// Postgres Repo Args
+		policyTemplate   api.PolicyTemplate
+		renderedPolicies []api.Policy
+	}{
+		"OkCase": {
+			previousPolicyTemplate: PolicyTemplate{
+				ID:       "TemplateID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			previousParameters: []PolicyTemplateParameter{
+				{
+					ID:   "ParameterID",
+					Name: "team",
+				},
+			},
+			previousStatements: []PolicyTemplateStatement{
+				{
+					ID:        "StatementID",
+					Effect:    "allow",
+					Actions:   "iam:getUser",
+					Resources: "urn:iws:iam::user/{{team}}/*",
+				},
+			},
+			previousPolicy: Policy{
+				ID:       "PolicyID",
+				Name:     "Policy",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "policyUrn",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			previousPolicyStmts: []Statement{
+				{
+					ID:        "PolicyStatementID",
+					Effect:    "allow",
+					Actions:   "iam:getUser",
+					Resources: "urn:iws:iam::user/a/*",
+				},
+			},
+			previousInstance: PolicyTemplateInstance{
+				PolicyID:         "PolicyID",
+				PolicyTemplateID: "TemplateID",
+				Parameters:       "{\"team\":\"a\"}",
+				CreateAt:         now.UnixNano(),
+			},
+			policyTemplate: api.PolicyTemplate{
+				ID:       "TemplateID",
+				Name:     "NewName",
+				Org:      "Org",
+				Path:     "/newpath/",
+				Urn:      "newUrn",
+				CreateAt: now,
+				UpdateAt: now,
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name: "team",
+					},
+				},
+				Statements: &[]api.Statement{
+					{
+						Effect:    "deny",
+						Actions:   []string{"iam:*"},
+						Resources: []string{"urn:iws:iam::user/{{team}}/*"},
+					},
+				},
+			},
+			renderedPolicies: []api.Policy{
+				{
+					ID:       "PolicyID",
+					Name:     "Policy",
+					Org:      "Org",
+					Path:     "/path/",
+					Urn:      "policyUrn",
+					CreateAt: now,
+					UpdateAt: now,
+					Statements: &[]api.Statement{
+						{
+							Effect:    "deny",
+							Actions:   []string{"iam:*"},
+							Resources: []string{"urn:iws:iam::user/a/*"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanPolicyTemplateTables(t, n)
+		cleanPolicyTable(t, n)
+		cleanStatementTable(t, n)
+
+		// Insert previous data
+		insertPolicyTemplate(t, n, test.previousPolicyTemplate, test.previousParameters, test.previousStatements)
+		insertPolicy(t, n, test.previousPolicy, test.previousPolicyStmts)
+		insertPolicyTemplateInstance(t, n, test.previousInstance)
+
+		// Call to repository to update the policy template
+		updatedPolicyTemplate, err := repoDB.UpdatePolicyTemplate(test.policyTemplate, test.renderedPolicies)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, &test.policyTemplate, updatedPolicyTemplate, "Error in test case %v", n)
+
+		// Check database
+		policyTemplateNumber := getPolicyTemplatesCountFiltered(t, n, test.policyTemplate.ID,
+			test.policyTemplate.Org, test.policyTemplate.Name)
+		assert.Equal(t, 1, policyTemplateNumber, "Error in test case %v", n)
+
+		// Check rendered policies
+		for _, p := range test.renderedPolicies {
+			for _, s := range *p.Statements {
+				statementNumber := getStatementsCountFiltered(t, n, "", p.ID, s.Effect,
+					stringArrayToString(s.Actions), stringArrayToString(s.Resources))
+				assert.Equal(t, 1, statementNumber, "Error in test case %v", n)
+			}
+			oldStatementNumber := getStatementsCountFiltered(t, n, "PolicyStatementID", "", "", "", "")
+			assert.Equal(t, 0, oldStatementNumber, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_RemovePolicyTemplate(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPolicyTemplates []PolicyTemplate
+		previousInstances       []PolicyTemplateInstance
+		policyTemplateToDelete  string
+	}{
+		"OkCase": {
+			previousPolicyTemplates: []PolicyTemplate{
+				{
+					ID:       "111",
+					Name:     "test1",
+					Org:      "Org",
+					Path:     "/path/",
+					Urn:      "urn1",
+					CreateAt: now.UnixNano(),
+					UpdateAt: now.UnixNano(),
+				},
+				{
+					ID:       "222",
+					Name:     "test2",
+					Org:      "Org",
+					Path:     "/path/",
+					Urn:      "urn2",
+					CreateAt: now.UnixNano(),
+					UpdateAt: now.UnixNano(),
+				},
+			},
+			previousInstances: []PolicyTemplateInstance{
+				{
+					PolicyID:         "p1",
+					PolicyTemplateID: "111",
+					Parameters:       "{}",
+					CreateAt:         now.UnixNano(),
+				},
+				{
+					PolicyID:         "p2",
+					PolicyTemplateID: "222",
+					Parameters:       "{}",
+					CreateAt:         now.UnixNano(),
+				},
+			},
+			policyTemplateToDelete: "111",
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean policy template database
+		cleanPolicyTemplateTables(t, n)
+
+		// Insert previous data
+		for _, pt := range test.previousPolicyTemplates {
+			insertPolicyTemplate(t, n, pt, nil, nil)
+		}
+		for _, i := range test.previousInstances {
+			insertPolicyTemplateInstance(t, n, i)
+		}
+
+		// Call to repository to remove policy template
+		err := repoDB.RemovePolicyTemplate(test.policyTemplateToDelete)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check database
+		policyTemplateNumber := getPolicyTemplatesCountFiltered(t, n, test.policyTemplateToDelete, "", "")
+		assert.Equal(t, 0, policyTemplateNumber, "Error in test case %v", n)
+
+		// Check total policy templates
+		totalPolicyTemplateNumber := getPolicyTemplatesCountFiltered(t, n, "", "", "")
+		assert.Equal(t, 1, totalPolicyTemplateNumber, "Error in test case %v", n)
+
+		// Check instances
+		instances := getPolicyTemplateInstancesCountFiltered(t, n, "", test.policyTemplateToDelete)
+		assert.Equal(t, 0, instances, "Error in test case %v", n)
+
+		// Check total instances
+		totalInstances := getPolicyTemplateInstancesCountFiltered(t, n, "", "")
+		assert.Equal(t, 1, totalInstances, "Error in test case %v", n)
+	}
+}
+
+func Test_dbPolicyTemplateToAPIPolicyTemplate(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		dbPolicyTemplate  *PolicyTemplate
+		apiPolicyTemplate *api.PolicyTemplate
+	}{
+		"OkCase": {
+			dbPolicyTemplate: &PolicyTemplate{
+				ID:       "test1",
+				Name:     "test",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "test"),
+			},
+			apiPolicyTemplate: &api.PolicyTemplate{
+				ID:       "test1",
+				Name:     "test",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "test"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		receivedAPIPolicyTemplate := dbPolicyTemplateToAPIPolicyTemplate(test.dbPolicyTemplate)
+		// Check response
+		assert.Equal(t, test.apiPolicyTemplate, receivedAPIPolicyTemplate, "Error in test case %v", n)
+	}
+}
@@ -1,17 +1,50 @@
 package postgresql
 
 import (
+	"database/sql"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
+	"github.com/Tecsisa/foulkon/tracing"
 	"github.com/jinzhu/gorm"
 	_ "github.com/lib/pq" //GORM needs to import the lib/pq driver
 )
 
 type PostgresRepo struct {
 	Dbmap *gorm.DB
+
+	// Parent span of traced calls
+	span *tracing.Span
+
+	// Audit events and webhook deliveries stored in the transaction of the change
+	events     []api.AuditEvent
+	deliveries []api.WebhookDelivery
+}
+
+// WithSpan returns a copy of the repository that traces its calls as children of the span
+func (pr PostgresRepo) WithSpan(span *tracing.Span) interface{} {
+	pr.span = span
+	return pr
+}
+
+// WithEvents returns a copy of the repository that stores the events and deliveries in the transaction of its change
+func (pr PostgresRepo) WithEvents(events []api.AuditEvent, deliveries []api.WebhookDelivery) interface{} {
+	pr.events = events
+	pr.deliveries = deliveries
+	return pr
+}
+
+// observeQuery starts the span and the latency measure of a repository method, returning the func that ends them
+func (pr PostgresRepo) observeQuery(method string) func() {
+	start := time.Now()
+	span := pr.span.StartChild("PostgresRepo."+method, tracing.KIND_CLIENT)
+	return func() {
+		span.Finish()
+		metrics.ObserveDBQuery(method, start)
+	}
 }
 
 func InitDb(datasourcename string, idleConns string, maxOpenConns string, connTTL string) (*gorm.DB, error) {
@@ -22,6 +55,51 @@ func InitDb(datasourcename string, idleConns string, maxOpenConns string, connTT
 	}
 
 	// construct a gorm DbMap
+	if err := SetPoolConfig(db.DB(), idleConns, maxOpenConns, connTTL); err != nil {
+		return nil, err
+	}
+
+	// Check connection
+	err = db.DB().Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create tables if not exist
+	err = db.AutoMigrate(&User{}, &Group{}, &Policy{}, &Statement{}, &GroupUserRelation{}, &GroupPolicyRelation{},
+		&ProxyResource{}, &OidcProvider{}, &OidcClient{}, &PolicyTemplate{}, &PolicyTemplateParameter{},
+		&PolicyTemplateStatement{}, &PolicyTemplateInstance{}, &BreakGlassSession{}, &ChangeRequest{},
+		&AuditEvent{}, &Webhook{}, &WebhookDelivery{}, &ProxyResourcesRevision{}, &AuthzRevision{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Create proxy resources revision if not exists, ON CONFLICT needs PostgreSQL 9.5 or later
+	err = db.Exec("INSERT INTO proxy_resources_revision (id, revision) VALUES (?, 0) ON CONFLICT DO NOTHING",
+		PROXY_RESOURCES_REVISION_ID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Create authorization revision if not exists
+	err = db.Exec("INSERT INTO authz_revision (id, revision) VALUES (?, 0) ON CONFLICT DO NOTHING",
+		AUTHZ_REVISION_ID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// PoolConfig has the connection pool sizes and connection lifetime in seconds
+type PoolConfig struct {
+	IdleConns    int
+	MaxOpenConns int
+	ConnTTL      int
+}
+
+// ParsePoolConfig validates the connection pool params
+func ParsePoolConfig(idleConns string, maxOpenConns string, connTTL string) (*PoolConfig, error) {
 	idle, err := strconv.Atoi(idleConns)
 	if err != nil {
 		return nil, fmt.Errorf("Invalid postgresql idleConns param: %v", idleConns)
@@ -34,24 +112,28 @@ func InitDb(datasourcename string, idleConns string, maxOpenConns string, connTT
 	if err != nil {
 		return nil, fmt.Errorf("Invalid postgresql connTTL param: %v", connTTL)
 	}
-	db.DB().SetMaxIdleConns(idle)
-	db.DB().SetMaxOpenConns(maxOpen)
-	db.DB().SetConnMaxLifetime(time.Duration(ttl) * time.Second)
+	return &PoolConfig{
+		IdleConns:    idle,
+		MaxOpenConns: maxOpen,
+		ConnTTL:      ttl,
+	}, nil
+}
 
-	// Check connection
-	err = db.DB().Ping()
-	if err != nil {
-		return nil, err
-	}
+// Apply sets the connection pool config in db
+func (pc PoolConfig) Apply(db *sql.DB) {
+	db.SetMaxIdleConns(pc.IdleConns)
+	db.SetMaxOpenConns(pc.MaxOpenConns)
+	db.SetConnMaxLifetime(time.Duration(pc.ConnTTL) * time.Second)
+}
 
-	// Create tables if not exist
-	err = db.AutoMigrate(&User{}, &Group{}, &Policy{}, &Statement{}, &GroupUserRelation{}, &GroupPolicyRelation{},
-		&ProxyResource{}, &OidcProvider{}, &OidcClient{}).Error
+// SetPoolConfig sets the connection pool sizes and connection lifetime in seconds. Nothing is changed if any param is invalid
+func SetPoolConfig(db *sql.DB, idleConns string, maxOpenConns string, connTTL string) error {
+	poolConfig, err := ParsePoolConfig(idleConns, maxOpenConns, connTTL)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	return db, nil
+	poolConfig.Apply(db)
+	return nil
 }
 
 // User table
@@ -139,6 +221,63 @@ func (GroupPolicyRelation) TableName() string {
 	return "group_policy_relations"
 }
 
+// Policy template table
+type PolicyTemplate struct {
+	ID       string `gorm:"primary_key"`
+	Name     string `gorm:"not null"`
+	Path     string `gorm:"not null"`
+	Org      string `gorm:"not null"`
+	CreateAt int64  `gorm:"not null"`
+	UpdateAt int64  `gorm:"not null"`
+	Urn      string `gorm:"not null;unique"`
+}
+
+// PolicyTemplate's table name
+func (PolicyTemplate) TableName() string {
+	return "policy_templates"
+}
+
+// Policy template parameter table
+type PolicyTemplateParameter struct {
+	ID               string `gorm:"primary_key"`
+	PolicyTemplateID string `gorm:"not null;unique_index:idx_policy_template_parameter"`
+	Name             string `gorm:"not null;unique_index:idx_policy_template_parameter"`
+	Description      string
+	Pattern          string
+}
+
+// PolicyTemplateParameter's table name
+func (PolicyTemplateParameter) TableName() string {
+	return "policy_template_parameters"
+}
+
+// Policy template statement table
+type PolicyTemplateStatement struct {
+	ID               string `gorm:"primary_key"`
+	PolicyTemplateID string `gorm:"not null"`
+	Effect           string `gorm:"not null"`
+	Actions          string `gorm:"not null"`
+	Resources        string `gorm:"not null"`
+}
+
+// PolicyTemplateStatement's table name
+func (PolicyTemplateStatement) TableName() string {
+	return "policy_template_statements"
+}
+
+// Policy template instance relationship. Parameters are stored as a JSON object.
+type PolicyTemplateInstance struct {
+	PolicyID         string `gorm:"primary_key"`
+	PolicyTemplateID string `gorm:"not null"`
+	Parameters       string `gorm:"not null"`
+	CreateAt         int64  `gorm:"not null"`
+}
+
+// PolicyTemplateInstance's table name
+func (PolicyTemplateInstance) TableName() string {
+	return "policy_template_instances"
+}
+
 func (pr PostgresRepo) OrderByValidColumns(action string) []string {
 	switch action {
 	case api.USER_ACTION_LIST_USERS:
@@ -155,11 +294,21 @@ func (pr PostgresRepo) OrderByValidColumns(action string) []string {
 		return []string{"name", "path", "org", "create_at", "update_at", "urn"}
 	case api.POLICY_ACTION_LIST_ATTACHED_GROUPS:
 		return []string{"create_at"}
+	case api.POLICY_TEMPLATE_ACTION_LIST_POLICY_TEMPLATES:
+		return []string{"name", "path", "org", "create_at", "update_at", "urn"}
 	case api.PROXY_ACTION_LIST_RESOURCES:
 		return []string{"name", "path", "org", "host", "path_resource", "method",
 			"urn_resource", "urn", "action", "create_at", "update_at"}
 	case api.AUTH_OIDC_ACTION_LIST_PROVIDERS:
 		return []string{"name", "path", "create_at", "update_at", "urn"}
+	case api.BREAK_GLASS_ACTION_LIST_SESSIONS:
+		return []string{"user_id", "create_at", "expires_at"}
+	case api.CHANGE_REQUEST_ACTION_LIST_CHANGE_REQUESTS:
+		return []string{"org", "operation", "status", "requester", "reviewer", "create_at", "expires_at", "review_at"}
+	case api.AUDIT_ACTION_LIST_EVENTS:
+		return []string{"type", "actor", "action", "entity_urn", "create_at"}
+	case api.WEBHOOK_ACTION_LIST_WEBHOOKS:
+		return []string{"name", "url", "create_at", "update_at", "urn"}
 	default:
 		return nil
 	}
@@ -186,6 +335,34 @@ func (ProxyResource) TableName() string {
 	return "proxy_resources"
 }
 
+// Identifier of the only row of proxy resources revision table
+const PROXY_RESOURCES_REVISION_ID = 1
+
+// ProxyResourcesRevision table, increased in the same transaction of every proxy resource change
+type ProxyResourcesRevision struct {
+	ID       int   `gorm:"primary_key"`
+	Revision int64 `gorm:"not null"`
+}
+
+// ProxyResourcesRevision's table name
+func (ProxyResourcesRevision) TableName() string {
+	return "proxy_resources_revision"
+}
+
+// Identifier of the only row of authorization revision table
+const AUTHZ_REVISION_ID = 1
+
+// AuthzRevision table, increased in the same transaction of every change of the authorization snapshot content
+type AuthzRevision struct {
+	ID       int   `gorm:"primary_key"`
+	Revision int64 `gorm:"not null"`
+}
+
+// AuthzRevision's table name
+func (AuthzRevision) TableName() string {
+	return "authz_revision"
+}
+
 // Auth OIDC Provider table
 type OidcProvider struct {
 	ID        string `gorm:"primary_key"`
@@ -213,3 +390,99 @@ type OidcClient struct {
 func (OidcClient) TableName() string {
 	return "oidc_clients"
 }
+
+// Break-glass session table
+type BreakGlassSession struct {
+	ID            string `gorm:"primary_key"`
+	UserID        string `gorm:"not null;index"`
+	Justification string `gorm:"not null"`
+	Urn           string `gorm:"not null;unique"`
+	CreateAt      int64  `gorm:"not null"`
+	ExpiresAt     int64  `gorm:"not null"`
+	// 0 until the session is used
+	FirstUseAt int64 `gorm:"not null;default:0"`
+}
+
+// BreakGlassSession's table name
+func (BreakGlassSession) TableName() string {
+	return "break_glass_sessions"
+}
+
+// Change request table
+type ChangeRequest struct {
+	ID             string `gorm:"primary_key"`
+	Org            string `gorm:"not null;index"`
+	Operation      string `gorm:"not null"`
+	Payload        string `gorm:"not null"`
+	Status         string `gorm:"not null;index"`
+	Requester      string `gorm:"not null"`
+	RequesterAdmin bool   `gorm:"not null"`
+	Reviewer       string
+	Comment        string
+	Urn            string `gorm:"not null;unique"`
+	CreateAt       int64  `gorm:"not null"`
+	ExpiresAt      int64  `gorm:"not null"`
+	ReviewAt       int64
+}
+
+// ChangeRequest's table name
+func (ChangeRequest) TableName() string {
+	return "change_requests"
+}
+
+// Audit event table
+type AuditEvent struct {
+	ID                  string `gorm:"primary_key"`
+	Type                string `gorm:"not null"`
+	Actor               string `gorm:"not null;index"`
+	BreakGlassSessionID string
+	RequestID           string
+	Action              string `gorm:"not null"`
+	EntityUrn           string `gorm:"not null;index"`
+	Before              string
+	After               string
+	Urn                 string `gorm:"not null;unique"`
+	CreateAt            int64  `gorm:"not null;index"`
+}
+
+// AuditEvent's table name
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// Webhook table
+type Webhook struct {
+	ID       string `gorm:"primary_key"`
+	Name     string `gorm:"not null;unique"`
+	URL      string `gorm:"not null"`
+	Events   string `gorm:"not null"`
+	Secret   string `gorm:"not null"`
+	Urn      string `gorm:"not null;unique"`
+	CreateAt int64  `gorm:"not null"`
+	UpdateAt int64  `gorm:"not null"`
+}
+
+// Webhook's table name
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Webhook delivery table, the outbox of webhook events
+type WebhookDelivery struct {
+	ID            string `gorm:"primary_key"`
+	WebhookID     string `gorm:"not null;index"`
+	EventID       string `gorm:"not null"`
+	Event         string `gorm:"not null"`
+	Payload       string `gorm:"not null"`
+	Status        string `gorm:"not null;index"`
+	Attempts      int    `gorm:"not null"`
+	LastError     string
+	NextAttemptAt int64 `gorm:"not null;index"`
+	CreateAt      int64 `gorm:"not null"`
+	UpdateAt      int64 `gorm:"not null"`
+}
+
+// WebhookDelivery's table name
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -105,11 +105,23 @@ func TestPostgresRepo_OrderByValidColumns(t *testing.T) {
 			action:          api.POLICY_ACTION_LIST_ATTACHED_GROUPS,
 			expectedColumns: []string{"create_at"},
 		},
+		"OkCaseAction-" + api.POLICY_TEMPLATE_ACTION_LIST_POLICY_TEMPLATES: {
+			action:          api.POLICY_TEMPLATE_ACTION_LIST_POLICY_TEMPLATES,
+			expectedColumns: []string{"name", "path", "org", "create_at", "update_at", "urn"},
+		},
 		"OkCaseAction-" + api.PROXY_ACTION_LIST_RESOURCES: {
 			action: api.PROXY_ACTION_LIST_RESOURCES,
 			expectedColumns: []string{"name", "path", "org", "host", "path_resource", "method",
 				"urn_resource", "urn", "action", "create_at", "update_at"},
 		},
+		"OkCaseAction-" + api.BREAK_GLASS_ACTION_LIST_SESSIONS: {
+			action:          api.BREAK_GLASS_ACTION_LIST_SESSIONS,
+			expectedColumns: []string{"user_id", "create_at", "expires_at"},
+		},
+		"OkCaseAction-" + api.CHANGE_REQUEST_ACTION_LIST_CHANGE_REQUESTS: {
+			action:          api.CHANGE_REQUEST_ACTION_LIST_CHANGE_REQUESTS,
+			expectedColumns: []string{"org", "operation", "status", "requester", "reviewer", "create_at", "expires_at", "review_at"},
+		},
 		"OkCaseOtherActions": {
 			action:          "other",
 			expectedColumns: nil,
@@ -374,6 +386,18 @@ func insertProxyResource(t *testing.T, testcase string, pr ProxyResource) {
 	assert.Nil(t, err, "Error in testcase %v", testcase)
 }
 
+func setProxyResourcesRevision(t *testing.T, testcase string, revision int64) {
+	err := repoDB.Dbmap.Exec("UPDATE public.proxy_resources_revision SET revision = ? WHERE id = ?",
+		revision, PROXY_RESOURCES_REVISION_ID).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func setAuthzRevision(t *testing.T, testcase string, revision int64) {
+	err := repoDB.Dbmap.Exec("UPDATE public.authz_revision SET revision = ? WHERE id = ?",
+		revision, AUTHZ_REVISION_ID).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
 func getProxyResourcesCountFiltered(t *testing.T, testcase string, id string,
 	name string, org string, path string, urn string, createAt int64, updateAt int64) int {
 	query := repoDB.Dbmap.Table(ProxyResource{}.TableName())
@@ -489,3 +513,241 @@ func getOidcClientsCountFiltered(t *testing.T, testcase string,
 
 	return number
 }
+
+// POLICY TEMPLATE
+
+func cleanPolicyTemplateTables(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&PolicyTemplate{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+	err = repoDB.Dbmap.Delete(&PolicyTemplateParameter{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+	err = repoDB.Dbmap.Delete(&PolicyTemplateStatement{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+	err = repoDB.Dbmap.Delete(&PolicyTemplateInstance{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertPolicyTemplate(t *testing.T, testcase string, policyTemplate PolicyTemplate,
+	parameters []PolicyTemplateParameter, statements []PolicyTemplateStatement) {
+	err := repoDB.Dbmap.Exec("INSERT INTO public.policy_templates (id, name, org, path, create_at, update_at, urn) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		policyTemplate.ID, policyTemplate.Name, policyTemplate.Org, policyTemplate.Path, policyTemplate.CreateAt,
+		policyTemplate.UpdateAt, policyTemplate.Urn).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	for _, p := range parameters {
+		err = repoDB.Dbmap.Exec("INSERT INTO public.policy_template_parameters (id, policy_template_id, name, description, pattern) VALUES (?, ?, ?, ?, ?)",
+			p.ID, policyTemplate.ID, p.Name, p.Description, p.Pattern).Error
+		// Error handling
+		assert.Nil(t, err, "Error in test case %v", testcase)
+	}
+
+	for _, s := range statements {
+		err = repoDB.Dbmap.Exec("INSERT INTO public.policy_template_statements (id, policy_template_id, effect, actions, resources) VALUES (?, ?, ?, ?, ?)",
+			s.ID, policyTemplate.ID, s.Effect, s.Actions, s.Resources).Error
+		// Error handling
+		assert.Nil(t, err, "Error in test case %v", testcase)
+	}
+}
+
+func insertPolicyTemplateInstance(t *testing.T, testcase string, instance PolicyTemplateInstance) {
+	err := repoDB.Dbmap.Exec("INSERT INTO public.policy_template_instances (policy_id, policy_template_id, parameters, create_at) VALUES (?, ?, ?, ?)",
+		instance.PolicyID, instance.PolicyTemplateID, instance.Parameters, instance.CreateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getPolicyTemplatesCountFiltered(t *testing.T, testcase string, id string, org string, name string) int {
+	query := repoDB.Dbmap.Table(PolicyTemplate{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if org != "" {
+		query = query.Where("org = ?", org)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func getPolicyTemplateInstancesCountFiltered(t *testing.T, testcase string, policyID string, policyTemplateID string) int {
+	query := repoDB.Dbmap.Table(PolicyTemplateInstance{}.TableName())
+	if policyID != "" {
+		query = query.Where("policy_id = ?", policyID)
+	}
+	if policyTemplateID != "" {
+		query = query.Where("policy_template_id = ?", policyTemplateID)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// BREAK-GLASS
+
+func cleanBreakGlassSessionsTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&BreakGlassSession{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertBreakGlassSession(t *testing.T, testcase string, session BreakGlassSession) {
+	err := repoDB.Dbmap.Exec("INSERT INTO public.break_glass_sessions (id, user_id, justification, urn, create_at, expires_at, first_use_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		session.ID, session.UserID, session.Justification, session.Urn, session.CreateAt, session.ExpiresAt, session.FirstUseAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getBreakGlassSessionsCountFiltered(t *testing.T, testcase string, id string, userID string, justification string) int {
+	query := repoDB.Dbmap.Table(BreakGlassSession{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if justification != "" {
+		query = query.Where("justification = ?", justification)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func cleanChangeRequestsTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&ChangeRequest{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertChangeRequest(t *testing.T, testcase string, changeRequest ChangeRequest) {
+	err := repoDB.Dbmap.Exec("INSERT INTO public.change_requests (id, org, operation, payload, status, requester, requester_admin, reviewer, comment, urn, create_at, expires_at, review_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		changeRequest.ID, changeRequest.Org, changeRequest.Operation, changeRequest.Payload, changeRequest.Status, changeRequest.Requester,
+		changeRequest.RequesterAdmin, changeRequest.Reviewer, changeRequest.Comment, changeRequest.Urn, changeRequest.CreateAt,
+		changeRequest.ExpiresAt, changeRequest.ReviewAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getChangeRequestsCountFiltered(t *testing.T, testcase string, id string, org string, status string) int {
+	query := repoDB.Dbmap.Table(ChangeRequest{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if org != "" {
+		query = query.Where("org = ?", org)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// AUDIT
+
+func cleanAuditEventsTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&AuditEvent{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertAuditEvent(t *testing.T, testcase string, event AuditEvent) {
+	err := repoDB.Dbmap.Exec("INSERT INTO public.audit_events (id, type, actor, break_glass_session_id, request_id, action, entity_urn, before, after, urn, create_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		event.ID, event.Type, event.Actor, event.BreakGlassSessionID, event.RequestID, event.Action, event.EntityUrn,
+		event.Before, event.After, event.Urn, event.CreateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getAuditEventsCountFiltered(t *testing.T, testcase string, id string, actor string, entityUrn string) int {
+	query := repoDB.Dbmap.Table(AuditEvent{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	if entityUrn != "" {
+		query = query.Where("entity_urn = ?", entityUrn)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func cleanWebhooksTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&Webhook{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanWebhookDeliveriesTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&WebhookDelivery{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertWebhook(t *testing.T, testcase string, webhook Webhook) {
+	err := repoDB.Dbmap.Exec("INSERT INTO public.webhooks (id, name, url, events, secret, urn, create_at, update_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		webhook.ID, webhook.Name, webhook.URL, webhook.Events, webhook.Secret, webhook.Urn, webhook.CreateAt, webhook.UpdateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertWebhookDelivery(t *testing.T, testcase string, delivery WebhookDelivery) {
+	err := repoDB.Dbmap.Exec("INSERT INTO public.webhook_deliveries (id, webhook_id, event_id, event, payload, status, attempts, last_error, next_attempt_at, create_at, update_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		delivery.ID, delivery.WebhookID, delivery.EventID, delivery.Event, delivery.Payload, delivery.Status, delivery.Attempts,
+		delivery.LastError, delivery.NextAttemptAt, delivery.CreateAt, delivery.UpdateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getWebhooksCountFiltered(t *testing.T, testcase string, id string, name string) int {
+	query := repoDB.Dbmap.Table(Webhook{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func getWebhookDeliveriesCountFiltered(t *testing.T, testcase string, id string, webhookID string, status string) int {
+	query := repoDB.Dbmap.Table(WebhookDelivery{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if webhookID != "" {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
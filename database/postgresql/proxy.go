@@ -7,11 +7,13 @@ import (
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/database"
+	"github.com/jinzhu/gorm"
 )
 
 // PROXY REPOSITORY IMPLEMENTATION
 
 func (pr PostgresRepo) GetProxyResourceByName(org string, name string) (*api.ProxyResource, error) {
+	defer pr.observeQuery("GetProxyResourceByName")()
 	proxyResource := &ProxyResource{}
 	query := pr.Dbmap.Where("org like ? AND name like ?", org, name).First(proxyResource)
 
@@ -34,6 +36,7 @@ func (pr PostgresRepo) GetProxyResourceByName(org string, name string) (*api.Pro
 }
 
 func (pr PostgresRepo) GetProxyResources(filter *api.Filter) ([]api.ProxyResource, int, error) {
+	defer pr.observeQuery("GetProxyResources")()
 	var total int
 	resources := []ProxyResource{}
 	query := pr.Dbmap
@@ -69,6 +72,7 @@ func (pr PostgresRepo) GetProxyResources(filter *api.Filter) ([]api.ProxyResourc
 }
 
 func (pr PostgresRepo) AddProxyResource(proxyResource api.ProxyResource) (*api.ProxyResource, error) {
+	defer pr.observeQuery("AddProxyResource")()
 	// Create proxyResource model
 	proxyResourceDB := &ProxyResource{
 		ID:           proxyResource.ID,
@@ -85,11 +89,30 @@ func (pr PostgresRepo) AddProxyResource(proxyResource api.ProxyResource) (*api.P
 		UpdateAt:     proxyResource.UpdateAt.UnixNano(),
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Store proxyResource
-	err := pr.Dbmap.Create(proxyResourceDB).Error
+	if err := transaction.Create(proxyResourceDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error handling
-	if err != nil {
+	// Notify change to proxy resources watchers
+	if err := increaseProxyResourcesRevision(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -100,6 +123,7 @@ func (pr PostgresRepo) AddProxyResource(proxyResource api.ProxyResource) (*api.P
 }
 
 func (pr PostgresRepo) UpdateProxyResource(proxyResource api.ProxyResource) (*api.ProxyResource, error) {
+	defer pr.observeQuery("UpdateProxyResource")()
 	proxyResourceDB := &ProxyResource{
 		ID:           proxyResource.ID,
 		Name:         proxyResource.Name,
@@ -115,11 +139,30 @@ func (pr PostgresRepo) UpdateProxyResource(proxyResource api.ProxyResource) (*ap
 		UpdateAt:     proxyResource.UpdateAt.UnixNano(),
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Store proxyResource
-	query := pr.Dbmap.Model(&ProxyResource{ID: proxyResource.ID}).Updates(proxyResourceDB)
+	if err := transaction.Model(&ProxyResource{ID: proxyResource.ID}).Updates(proxyResourceDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error Handling
-	if err := query.Error; err != nil {
+	// Notify change to proxy resources watchers
+	if err := increaseProxyResourcesRevision(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -130,11 +173,31 @@ func (pr PostgresRepo) UpdateProxyResource(proxyResource api.ProxyResource) (*ap
 }
 
 func (pr PostgresRepo) RemoveProxyResource(id string) error {
+	defer pr.observeQuery("RemoveProxyResource")()
+	transaction := pr.Dbmap.Begin()
+
 	// Remove proxy resource
-	query := pr.Dbmap.Where("id like ?", id).Delete(&ProxyResource{})
+	if err := transaction.Where("id like ?", id).Delete(&ProxyResource{}).Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error handling
-	if err := query.Error; err != nil {
+	// Notify change to proxy resources watchers
+	if err := increaseProxyResourcesRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -144,8 +207,43 @@ func (pr PostgresRepo) RemoveProxyResource(id string) error {
 	return nil
 }
 
+func (pr PostgresRepo) GetProxyResourcesRevision() (int64, error) {
+	defer pr.observeQuery("GetProxyResourcesRevision")()
+	revision := &ProxyResourcesRevision{}
+	query := pr.Dbmap.Where("id = ?", PROXY_RESOURCES_REVISION_ID).First(revision)
+
+	// Revision starts when proxy resources change for first time
+	if query.RecordNotFound() {
+		return 0, nil
+	}
+
+	// Error handling
+	if err := query.Error; err != nil {
+		return 0, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return revision.Revision, nil
+}
+
 // PRIVATE HELPER METHODS
 
+// Increase proxy resources revision in the transaction of a proxy resource change. Row locking
+// serializes changes, so revisions are seen in the same order they were committed
+func increaseProxyResourcesRevision(transaction *gorm.DB) error {
+	err := transaction.Exec("UPDATE proxy_resources_revision SET revision = revision + 1 WHERE id = ?",
+		PROXY_RESOURCES_REVISION_ID).Error
+	if err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
 // Transform a proxyResource retrieved from db into a proxyResource for API
 func dbResourceToApiResource(pr *ProxyResource) *api.ProxyResource {
 	return &api.ProxyResource{
@@ -550,3 +550,95 @@ func TestPostgresRepo_RemoveProxyResource(t *testing.T) {
 		assert.Equal(t, 1, totalPrNumber, "Error in test case %v", n)
 	}
 }
+
+func TestPostgresRepo_GetProxyResourcesRevision(t *testing.T) {
+	now := time.Now().UTC()
+	proxyResource := api.ProxyResource{
+		ID:   "PrID1",
+		Name: "Name1",
+		Org:  "Org1",
+		Path: "/path/",
+		Urn:  "urn",
+		Resource: api.ResourceEntity{
+			Host:   "http://example.com",
+			Path:   "/path",
+			Method: "GET",
+			Urn:    "urnResource",
+			Action: "example:get",
+		},
+		CreateAt: now,
+		UpdateAt: now,
+	}
+	testcases := map[string]struct {
+		// Previous data
+		previousRevision int64
+		// Change made before reading revision
+		change func() error
+		// Expected result
+		expectedRevision int64
+	}{
+		"OkCaseNoChanges": {
+			previousRevision: 5,
+			change: func() error {
+				return nil
+			},
+			expectedRevision: 5,
+		},
+		"OkCaseAddProxyResource": {
+			previousRevision: 5,
+			change: func() error {
+				_, err := repoDB.AddProxyResource(proxyResource)
+				return err
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseUpdateProxyResource": {
+			previousRevision: 5,
+			change: func() error {
+				insertProxyResource(t, "OkCaseUpdateProxyResource", ProxyResource{
+					ID:           proxyResource.ID,
+					Name:         proxyResource.Name,
+					Org:          proxyResource.Org,
+					Path:         proxyResource.Path,
+					Urn:          proxyResource.Urn,
+					Host:         proxyResource.Resource.Host,
+					PathResource: proxyResource.Resource.Path,
+					Method:       proxyResource.Resource.Method,
+					Action:       proxyResource.Resource.Action,
+					UrnResource:  proxyResource.Resource.Urn,
+					CreateAt:     now.UnixNano(),
+					UpdateAt:     now.UnixNano(),
+				})
+				updated := proxyResource
+				updated.Path = "/newpath/"
+				_, err := repoDB.UpdateProxyResource(updated)
+				return err
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseRemoveProxyResource": {
+			previousRevision: 5,
+			change: func() error {
+				return repoDB.RemoveProxyResource("PrID1")
+			},
+			expectedRevision: 6,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean proxy resource database
+		cleanProxyResourcesTable(t, n)
+
+		// Insert previous data
+		setProxyResourcesRevision(t, n, test.previousRevision)
+
+		// Make change
+		err := test.change()
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Call to repository to get revision
+		revision, err := repoDB.GetProxyResourcesRevision()
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedRevision, revision, "Error in test case %v", n)
+	}
+}
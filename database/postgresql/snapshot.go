@@ -0,0 +1,165 @@
+package postgresql
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/jinzhu/gorm"
+)
+
+// SNAPSHOT REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) GetAuthzRevision() (int64, error) {
+	defer pr.observeQuery("GetAuthzRevision")()
+	revision := &AuthzRevision{}
+	query := pr.Dbmap.Where("id = ?", AUTHZ_REVISION_ID).First(revision)
+
+	// Revision starts when authorization content changes for first time
+	if query.RecordNotFound() {
+		return 0, nil
+	}
+
+	// Error handling
+	if err := query.Error; err != nil {
+		return 0, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return revision.Revision, nil
+}
+
+func (pr PostgresRepo) GetAuthzSnapshot() (*api.AuthzSnapshot, error) {
+	defer pr.observeQuery("GetAuthzSnapshot")()
+
+	// Retrieve users with their memberships
+	userRows := []struct {
+		ExternalID string
+		GroupID    sql.NullString
+	}{}
+	err := pr.Dbmap.Table("users").
+		Select("users.external_id, group_user_relations.group_id").
+		Joins("LEFT JOIN group_user_relations ON group_user_relations.user_id = users.id").
+		Order("users.external_id").
+		Scan(&userRows).Error
+
+	// Error handling
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Retrieve groups with members with their attached policies
+	groupRows := []struct {
+		ID       string
+		Urn      string
+		PolicyID sql.NullString
+	}{}
+	err = pr.Dbmap.Table("groups").
+		Select("groups.id, groups.urn, group_policy_relations.policy_id").
+		Joins("LEFT JOIN group_policy_relations ON group_policy_relations.group_id = groups.id").
+		Where("groups.id IN (SELECT group_id FROM group_user_relations)").
+		Order("groups.id").
+		Scan(&groupRows).Error
+
+	// Error handling
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Retrieve policies attached to groups with members with their statements
+	policyRows := []struct {
+		Policy
+		Effect    sql.NullString
+		Actions   sql.NullString
+		Resources sql.NullString
+	}{}
+	err = pr.Dbmap.Table("policies").
+		Select("policies.*, statements.effect, statements.actions, statements.resources").
+		Joins("LEFT JOIN statements ON statements.policy_id = policies.id").
+		Where("policies.id IN (SELECT group_policy_relations.policy_id FROM group_policy_relations " +
+			"JOIN group_user_relations ON group_user_relations.group_id = group_policy_relations.group_id)").
+		Order("policies.id, statements.id").
+		Scan(&policyRows).Error
+
+	// Error handling
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform rows to API domain, rows of the same user, group or policy are consecutive
+	snapshot := &api.AuthzSnapshot{
+		Users:    []api.SnapshotUser{},
+		Groups:   []api.SnapshotGroup{},
+		Policies: []api.Policy{},
+	}
+	for i, row := range userRows {
+		if i == 0 || userRows[i-1].ExternalID != row.ExternalID {
+			snapshot.Users = append(snapshot.Users, api.SnapshotUser{
+				ExternalID: row.ExternalID,
+				Groups:     []string{},
+			})
+		}
+		if row.GroupID.Valid {
+			user := &snapshot.Users[len(snapshot.Users)-1]
+			user.Groups = append(user.Groups, row.GroupID.String)
+		}
+	}
+	for i, row := range groupRows {
+		if i == 0 || groupRows[i-1].ID != row.ID {
+			snapshot.Groups = append(snapshot.Groups, api.SnapshotGroup{
+				ID:       row.ID,
+				Urn:      row.Urn,
+				Policies: []string{},
+			})
+		}
+		if row.PolicyID.Valid {
+			group := &snapshot.Groups[len(snapshot.Groups)-1]
+			group.Policies = append(group.Policies, row.PolicyID.String)
+		}
+	}
+	for i, row := range policyRows {
+		if i == 0 || policyRows[i-1].ID != row.ID {
+			policy := dbPolicyToAPIPolicy(&row.Policy)
+			policy.Statements = &[]api.Statement{}
+			snapshot.Policies = append(snapshot.Policies, *policy)
+		}
+		if row.Effect.Valid {
+			policy := &snapshot.Policies[len(snapshot.Policies)-1]
+			*policy.Statements = append(*policy.Statements, api.Statement{
+				Effect:    row.Effect.String,
+				Actions:   strings.Split(row.Actions.String, ";"),
+				Resources: strings.Split(row.Resources.String, ";"),
+			})
+		}
+	}
+
+	return snapshot, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Increase authorization revision in the transaction of a change of users, memberships, group urns,
+// attached policies or policy statements, so snapshot is built again
+func increaseAuthzRevision(transaction *gorm.DB) error {
+	err := transaction.Exec("UPDATE authz_revision SET revision = revision + 1 WHERE id = ?",
+		AUTHZ_REVISION_ID).Error
+	if err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
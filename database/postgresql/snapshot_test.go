@@ -0,0 +1,280 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRepo_GetAuthzRevision(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Change made before reading revision
+		change func() error
+		// Expected result
+		expectedRevision int64
+	}{
+		"OkCaseNoChanges": {
+			change: func() error {
+				return nil
+			},
+			expectedRevision: 5,
+		},
+		"OkCaseAddUser": {
+			change: func() error {
+				_, err := repoDB.AddUser(api.User{
+					ID:         "UserID2",
+					ExternalID: "ExternalID2",
+					Path:       "/path/",
+					Urn:        "urn2",
+					CreateAt:   now,
+					UpdateAt:   now,
+				})
+				return err
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseUpdateUser": {
+			change: func() error {
+				_, err := repoDB.UpdateUser(api.User{
+					ID:         "UserID",
+					ExternalID: "NewExternalID",
+					Path:       "/path/",
+					Urn:        "urn",
+					CreateAt:   now,
+					UpdateAt:   now,
+				})
+				return err
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseRemoveUser": {
+			change: func() error {
+				return repoDB.RemoveUser("UserID")
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseUpdateGroup": {
+			change: func() error {
+				_, err := repoDB.UpdateGroup(api.Group{
+					ID:       "GroupID",
+					Name:     "NewName",
+					Path:     "/path/",
+					Org:      "Org1",
+					Urn:      "urnGroup",
+					CreateAt: now,
+					UpdateAt: now,
+				})
+				return err
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseRemoveGroup": {
+			change: func() error {
+				return repoDB.RemoveGroup("GroupID")
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseAddMember": {
+			change: func() error {
+				return repoDB.AddMember("UserID", "GroupID")
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseRemoveMember": {
+			change: func() error {
+				return repoDB.RemoveMember("UserID", "GroupID")
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseAttachPolicy": {
+			change: func() error {
+				return repoDB.AttachPolicy("GroupID", "PolicyID")
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseDetachPolicy": {
+			change: func() error {
+				return repoDB.DetachPolicy("GroupID", "PolicyID")
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseUpdatePolicy": {
+			change: func() error {
+				_, err := repoDB.UpdatePolicy(api.Policy{
+					ID:       "PolicyID",
+					Name:     "NewName",
+					Path:     "/path/",
+					Org:      "Org1",
+					Urn:      "urnPolicy",
+					CreateAt: now,
+					UpdateAt: now,
+					Statements: &[]api.Statement{
+						{
+							Effect:    "allow",
+							Actions:   []string{"action"},
+							Resources: []string{"resource"},
+						},
+					},
+				})
+				return err
+			},
+			expectedRevision: 6,
+		},
+		"OkCaseRemovePolicy": {
+			change: func() error {
+				return repoDB.RemovePolicy("PolicyID")
+			},
+			expectedRevision: 6,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanUserTable(t, n)
+		cleanGroupTable(t, n)
+		cleanGroupUserRelationTable(t, n)
+		cleanGroupPolicyRelationTable(t, n)
+		cleanPolicyTable(t, n)
+		cleanStatementTable(t, n)
+
+		// Insert previous data
+		insertUser(t, n, User{ID: "UserID", ExternalID: "ExternalID", Path: "/path/", Urn: "urn"})
+		insertGroup(t, n, Group{ID: "GroupID", Name: "Name", Path: "/path/", Org: "Org1", Urn: "urnGroup"})
+		insertPolicy(t, n, Policy{ID: "PolicyID", Name: "Name", Path: "/path/", Org: "Org1", Urn: "urnPolicy"}, nil)
+		setAuthzRevision(t, n, 5)
+
+		// Make change
+		err := test.change()
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Call to repository to get revision
+		revision, err := repoDB.GetAuthzRevision()
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedRevision, revision, "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_GetAuthzSnapshot(t *testing.T) {
+	testcases := map[string]struct {
+		// Previous data
+		previousUsers          []User
+		previousGroups         []Group
+		previousPolicies       []Policy
+		previousStatements     []Statement
+		previousMembers        map[string][]string
+		previousAttachPolicies map[string][]string
+		// Expected result
+		expectedResponse *api.AuthzSnapshot
+	}{
+		"OkCase": {
+			previousUsers: []User{
+				{ID: "UserID1", ExternalID: "ExternalID1", Path: "/path/", Urn: "urnUser1"},
+				{ID: "UserID2", ExternalID: "ExternalID2", Path: "/path/", Urn: "urnUser2"},
+			},
+			previousGroups: []Group{
+				{ID: "GroupID1", Name: "Name1", Path: "/path/", Org: "Org1", Urn: "urnGroup1"},
+				{ID: "GroupID2", Name: "Name2", Path: "/path/", Org: "Org1", Urn: "urnGroup2"},
+				{ID: "GroupID3", Name: "Name3", Path: "/path/", Org: "Org1", Urn: "urnGroup3"},
+			},
+			previousPolicies: []Policy{
+				{ID: "PolicyID1", Name: "Name1", Path: "/path/", Org: "Org1", Urn: "urnPolicy1", CreateAt: 1, UpdateAt: 2},
+				{ID: "PolicyID2", Name: "Name2", Path: "/path/", Org: "Org1", Urn: "urnPolicy2"},
+			},
+			previousStatements: []Statement{
+				{ID: "StatementID1", PolicyID: "PolicyID1", Effect: "allow", Actions: "iam:*;example:get", Resources: "urn:*"},
+				{ID: "StatementID2", PolicyID: "PolicyID1", Effect: "deny", Actions: "iam:*", Resources: "urn:ews:*"},
+				{ID: "StatementID3", PolicyID: "PolicyID2", Effect: "allow", Actions: "iam:*", Resources: "urn:*"},
+			},
+			previousMembers: map[string][]string{
+				"GroupID1": {"UserID1", "UserID2"},
+				"GroupID2": {"UserID1"},
+			},
+			previousAttachPolicies: map[string][]string{
+				"GroupID1": {"PolicyID1"},
+				"GroupID3": {"PolicyID2"},
+			},
+			expectedResponse: &api.AuthzSnapshot{
+				Users: []api.SnapshotUser{
+					{ExternalID: "ExternalID1", Groups: []string{"GroupID1", "GroupID2"}},
+					{ExternalID: "ExternalID2", Groups: []string{"GroupID1"}},
+				},
+				Groups: []api.SnapshotGroup{
+					{ID: "GroupID1", Urn: "urnGroup1", Policies: []string{"PolicyID1"}},
+					{ID: "GroupID2", Urn: "urnGroup2", Policies: []string{}},
+				},
+				Policies: []api.Policy{
+					{
+						ID:       "PolicyID1",
+						Name:     "Name1",
+						Path:     "/path/",
+						Org:      "Org1",
+						Urn:      "urnPolicy1",
+						CreateAt: time.Unix(0, 1).UTC(),
+						UpdateAt: time.Unix(0, 2).UTC(),
+						Statements: &[]api.Statement{
+							{
+								Effect:    "allow",
+								Actions:   []string{"iam:*", "example:get"},
+								Resources: []string{"urn:*"},
+							},
+							{
+								Effect:    "deny",
+								Actions:   []string{"iam:*"},
+								Resources: []string{"urn:ews:*"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"OkCaseNoUsers": {
+			expectedResponse: &api.AuthzSnapshot{
+				Users:    []api.SnapshotUser{},
+				Groups:   []api.SnapshotGroup{},
+				Policies: []api.Policy{},
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanUserTable(t, n)
+		cleanGroupTable(t, n)
+		cleanGroupUserRelationTable(t, n)
+		cleanGroupPolicyRelationTable(t, n)
+		cleanPolicyTable(t, n)
+		cleanStatementTable(t, n)
+
+		// Insert previous data
+		for _, user := range test.previousUsers {
+			insertUser(t, n, user)
+		}
+		for _, group := range test.previousGroups {
+			insertGroup(t, n, group)
+		}
+		for _, policy := range test.previousPolicies {
+			insertPolicy(t, n, policy, nil)
+		}
+		for _, statement := range test.previousStatements {
+			insertStatements(t, n, statement)
+		}
+		for groupID, userIDs := range test.previousMembers {
+			for _, userID := range userIDs {
+				insertGroupUserRelation(t, n, userID, groupID, 0)
+			}
+		}
+		for groupID, policyIDs := range test.previousAttachPolicies {
+			for _, policyID := range policyIDs {
+				insertGroupPolicyRelation(t, n, groupID, policyID, 0)
+			}
+		}
+
+		// Call to repository to get snapshot
+		snapshot, err := repoDB.GetAuthzSnapshot()
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, snapshot, "Error in test case %v", n)
+	}
+}
@@ -11,6 +11,7 @@ import (
 // USER REPOSITORY IMPLEMENTATION
 
 func (pr PostgresRepo) AddUser(user api.User) (*api.User, error) {
+	defer pr.observeQuery("AddUser")()
 	// Create user model
 	userDB := &User{
 		ID:         user.ID,
@@ -21,11 +22,30 @@ func (pr PostgresRepo) AddUser(user api.User) (*api.User, error) {
 		Urn:        user.Urn,
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Store user
-	err := pr.Dbmap.Create(userDB).Error
+	if err := transaction.Create(userDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error handling
-	if err != nil {
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -36,6 +56,7 @@ func (pr PostgresRepo) AddUser(user api.User) (*api.User, error) {
 }
 
 func (pr PostgresRepo) GetUserByExternalID(id string) (*api.User, error) {
+	defer pr.observeQuery("GetUserByExternalID")()
 	user := &User{}
 	query := pr.Dbmap.Where("external_id like ?", id).First(user)
 
@@ -59,6 +80,7 @@ func (pr PostgresRepo) GetUserByExternalID(id string) (*api.User, error) {
 }
 
 func (pr PostgresRepo) GetUserByID(id string) (*api.User, error) {
+	defer pr.observeQuery("GetUserByID")()
 	user := &User{}
 	query := pr.Dbmap.Where("id like ?", id).First(user)
 
@@ -82,6 +104,7 @@ func (pr PostgresRepo) GetUserByID(id string) (*api.User, error) {
 }
 
 func (pr PostgresRepo) GetUsersFiltered(filter *api.Filter) ([]api.User, int, error) {
+	defer pr.observeQuery("GetUsersFiltered")()
 	var total int
 	users := []User{}
 	query := pr.Dbmap
@@ -114,6 +137,7 @@ func (pr PostgresRepo) GetUsersFiltered(filter *api.Filter) ([]api.User, int, er
 }
 
 func (pr PostgresRepo) UpdateUser(user api.User) (*api.User, error) {
+	defer pr.observeQuery("UpdateUser")()
 	userDB := User{
 		ID:         user.ID,
 		ExternalID: user.ExternalID,
@@ -123,11 +147,30 @@ func (pr PostgresRepo) UpdateUser(user api.User) (*api.User, error) {
 		Urn:        user.Urn,
 	}
 
+	transaction := pr.Dbmap.Begin()
+
 	// Update user
-	query := pr.Dbmap.Model(&User{ID: user.ID}).Updates(userDB)
+	if err := transaction.Model(&User{ID: user.ID}).Updates(userDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
 
-	// Error Handling
-	if err := query.Error; err != nil {
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -138,6 +181,7 @@ func (pr PostgresRepo) UpdateUser(user api.User) (*api.User, error) {
 }
 
 func (pr PostgresRepo) RemoveUser(id string) error {
+	defer pr.observeQuery("RemoveUser")()
 	transaction := pr.Dbmap.Begin()
 	// Delete user
 	transaction.Where("id like ?", id).Delete(&User{})
@@ -163,11 +207,24 @@ func (pr PostgresRepo) RemoveUser(id string) error {
 		}
 	}
 
+	// Notify change to authorization snapshot
+	if err := increaseAuthzRevision(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
 	transaction.Commit()
 	return nil
 }
 
 func (pr PostgresRepo) GetGroupsByUserID(id string, filter *api.Filter) ([]api.UserGroupRelation, int, error) {
+	defer pr.observeQuery("GetGroupsByUserID")()
 	var total int
 	relations := []GroupUserRelation{}
 	query := pr.Dbmap
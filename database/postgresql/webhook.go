@@ -0,0 +1,349 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// WEBHOOK REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) AddWebhook(webhook api.Webhook) (*api.Webhook, error) {
+	defer pr.observeQuery("AddWebhook")()
+	// Create webhook model
+	webhookDB, err := apiWebhookToDBWebhook(webhook)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Store webhook
+	if err := transaction.Create(webhookDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbWebhookToAPIWebhook(webhookDB)
+}
+
+func (pr PostgresRepo) GetWebhookByName(name string) (*api.Webhook, error) {
+	defer pr.observeQuery("GetWebhookByName")()
+	webhook := &Webhook{}
+	query := pr.Dbmap.Where("name like ?", name).First(webhook)
+
+	// Check if webhook exists
+	if query.RecordNotFound() {
+		return nil, &database.Error{
+			Code:    database.WEBHOOK_NOT_FOUND,
+			Message: fmt.Sprintf("Webhook with name %v not found", name),
+		}
+	}
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbWebhookToAPIWebhook(webhook)
+}
+
+func (pr PostgresRepo) GetWebhooksFiltered(filter *api.Filter) ([]api.Webhook, int, error) {
+	defer pr.observeQuery("GetWebhooksFiltered")()
+	var total int
+	webhooks := []Webhook{}
+	query := pr.Dbmap
+
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	}
+
+	// Error handling
+	if err := query.Find(&webhooks).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&webhooks).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	apiWebhooks, err := dbWebhooksToAPIWebhooks(webhooks)
+	if err != nil {
+		return nil, total, err
+	}
+
+	return apiWebhooks, total, nil
+}
+
+func (pr PostgresRepo) GetWebhooks() ([]api.Webhook, error) {
+	defer pr.observeQuery("GetWebhooks")()
+	webhooks := []Webhook{}
+
+	// Error handling
+	if err := pr.Dbmap.Find(&webhooks).Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbWebhooksToAPIWebhooks(webhooks)
+}
+
+func (pr PostgresRepo) UpdateWebhook(webhook api.Webhook) (*api.Webhook, error) {
+	defer pr.observeQuery("UpdateWebhook")()
+	webhookDB, err := apiWebhookToDBWebhook(webhook)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	transaction := pr.Dbmap.Begin()
+
+	// Update webhook
+	if err := transaction.Model(&Webhook{ID: webhook.ID}).Updates(webhookDB).Error; err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbWebhookToAPIWebhook(webhookDB)
+}
+
+func (pr PostgresRepo) RemoveWebhook(id string) error {
+	defer pr.observeQuery("RemoveWebhook")()
+	transaction := pr.Dbmap.Begin()
+
+	// Delete webhook
+	transaction.Where("id like ?", id).Delete(&Webhook{})
+	if err := transaction.Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Delete pending deliveries, sent and failed ones are kept
+	transaction.Where("webhook_id like ? AND status like ?", id, api.WEBHOOK_DELIVERY_STATUS_PENDING).Delete(&WebhookDelivery{})
+	if err := transaction.Error; err != nil {
+		transaction.Rollback()
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store audit events and webhook deliveries of the change
+	if err := pr.addChangeEvents(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	transaction.Commit()
+	return nil
+}
+
+func (pr PostgresRepo) ClaimWebhookDeliveries(now time.Time, claimUntil time.Time, limit int) ([]api.WebhookDelivery, error) {
+	defer pr.observeQuery("ClaimWebhookDeliveries")()
+	transaction := pr.Dbmap.Begin()
+
+	// Lock due deliveries, the ones locked by other workers are skipped
+	deliveries := []WebhookDelivery{}
+	err := transaction.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		Where("status like ? AND next_attempt_at <= ?", api.WEBHOOK_DELIVERY_STATUS_PENDING, now.UnixNano()).
+		Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		transaction.Rollback()
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Delay next attempt of locked deliveries until the claim expires
+	if len(deliveries) > 0 {
+		ids := make([]string, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		err = transaction.Model(&WebhookDelivery{}).Where("id in (?)", ids).
+			Update("next_attempt_at", claimUntil.UnixNano()).Error
+		if err != nil {
+			transaction.Rollback()
+			return nil, &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform webhook deliveries to API
+	apiDeliveries := make([]api.WebhookDelivery, len(deliveries), cap(deliveries))
+	for i, d := range deliveries {
+		apiDeliveries[i] = *dbWebhookDeliveryToAPIWebhookDelivery(&d)
+	}
+
+	return apiDeliveries, nil
+}
+
+func (pr PostgresRepo) UpdateWebhookDelivery(delivery api.WebhookDelivery) (*api.WebhookDelivery, error) {
+	defer pr.observeQuery("UpdateWebhookDelivery")()
+	// Update attempt fields, a map is used to store the empty last error of sent deliveries
+	query := pr.Dbmap.Model(&WebhookDelivery{ID: delivery.ID}).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"last_error":      delivery.LastError,
+		"next_attempt_at": delivery.NextAttemptAt.UnixNano(),
+		"update_at":       delivery.UpdateAt.UnixNano(),
+	})
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return &delivery, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform a webhook for API into a webhook model for db
+func apiWebhookToDBWebhook(webhook api.Webhook) (*Webhook, error) {
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Webhook{
+		ID:       webhook.ID,
+		Name:     webhook.Name,
+		URL:      webhook.URL,
+		Events:   string(events),
+		Secret:   webhook.Secret,
+		Urn:      webhook.Urn,
+		CreateAt: webhook.CreateAt.UnixNano(),
+		UpdateAt: webhook.UpdateAt.UnixNano(),
+	}, nil
+}
+
+// Transform a webhook retrieved from db into a webhook for API
+func dbWebhookToAPIWebhook(webhook *Webhook) (*api.Webhook, error) {
+	events := []string{}
+	if err := json.Unmarshal([]byte(webhook.Events), &events); err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return &api.Webhook{
+		ID:       webhook.ID,
+		Name:     webhook.Name,
+		URL:      webhook.URL,
+		Events:   events,
+		Secret:   webhook.Secret,
+		Urn:      webhook.Urn,
+		CreateAt: time.Unix(0, webhook.CreateAt).UTC(),
+		UpdateAt: time.Unix(0, webhook.UpdateAt).UTC(),
+	}, nil
+}
+
+// Transform a list of webhooks from db into API webhooks
+func dbWebhooksToAPIWebhooks(webhooks []Webhook) ([]api.Webhook, error) {
+	apiWebhooks := make([]api.Webhook, len(webhooks), cap(webhooks))
+	for i, w := range webhooks {
+		apiWebhook, err := dbWebhookToAPIWebhook(&w)
+		if err != nil {
+			return nil, err
+		}
+		apiWebhooks[i] = *apiWebhook
+	}
+
+	return apiWebhooks, nil
+}
+
+// Transform a webhook delivery for API into a webhook delivery model for db
+func apiWebhookDeliveryToDBWebhookDelivery(delivery api.WebhookDelivery) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:            delivery.ID,
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		LastError:     delivery.LastError,
+		NextAttemptAt: delivery.NextAttemptAt.UnixNano(),
+		CreateAt:      delivery.CreateAt.UnixNano(),
+		UpdateAt:      delivery.UpdateAt.UnixNano(),
+	}
+}
+
+// Transform a webhook delivery retrieved from db into a webhook delivery for API
+func dbWebhookDeliveryToAPIWebhookDelivery(delivery *WebhookDelivery) *api.WebhookDelivery {
+	return &api.WebhookDelivery{
+		ID:            delivery.ID,
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		LastError:     delivery.LastError,
+		NextAttemptAt: time.Unix(0, delivery.NextAttemptAt).UTC(),
+		CreateAt:      time.Unix(0, delivery.CreateAt).UTC(),
+		UpdateAt:      time.Unix(0, delivery.UpdateAt).UTC(),
+	}
+}
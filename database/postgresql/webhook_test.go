@@ -0,0 +1,487 @@
+package postgresql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRepo_AddWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousWebhook *Webhook
+		// Postgres Repo Args
+		webhookToCreate *api.Webhook
+		// Expected result
+		expectedResponse *api.Webhook
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			webhookToCreate: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				URL:      "https://example.com/hook",
+				Events:   []string{api.GROUP_ACTION_ADD_MEMBER, "iam:*"},
+				Secret:   "secret",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			expectedResponse: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				URL:      "https://example.com/hook",
+				Events:   []string{api.GROUP_ACTION_ADD_MEMBER, "iam:*"},
+				Secret:   "secret",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseAlreadyExists": {
+			previousWebhook: &Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				URL:      "https://example.com/hook",
+				Events:   `["iam:*"]`,
+				Secret:   "secret",
+				Urn:      "urn",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			webhookToCreate: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				URL:      "https://example.com/hook",
+				Events:   []string{"iam:*"},
+				Secret:   "secret",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "pq: duplicate key value violates unique constraint \"webhooks_pkey\"",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhook database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		if test.previousWebhook != nil {
+			insertWebhook(t, n, *test.previousWebhook)
+		}
+		// Call to repository to store the webhook
+		storedWebhook, err := repoDB.AddWebhook(*test.webhookToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check response
+			assert.Equal(t, test.expectedResponse, storedWebhook, "Error in test case %v", n)
+			// Check database
+			webhookNumber := getWebhooksCountFiltered(t, n, test.webhookToCreate.ID, test.webhookToCreate.Name)
+			assert.Equal(t, 1, webhookNumber, "Error in test case %v, webhook not found in database", n)
+		}
+	}
+}
+
+func TestPostgresRepo_GetWebhookByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousWebhook *Webhook
+		// Postgres Repo Args
+		name string
+		// Expected result
+		expectedResponse *api.Webhook
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousWebhook: &Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				URL:      "https://example.com/hook",
+				Events:   `["iam:*"]`,
+				Secret:   "secret",
+				Urn:      "urn",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			name: "Name",
+			expectedResponse: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				URL:      "https://example.com/hook",
+				Events:   []string{"iam:*"},
+				Secret:   "secret",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseNotFound": {
+			name: "Name",
+			expectedError: &database.Error{
+				Code:    database.WEBHOOK_NOT_FOUND,
+				Message: "Webhook with name Name not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhook database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		if test.previousWebhook != nil {
+			insertWebhook(t, n, *test.previousWebhook)
+		}
+		// Call to repository to get the webhook
+		receivedWebhook, err := repoDB.GetWebhookByName(test.name)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, receivedWebhook, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_GetWebhooksFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	previousWebhooks := []Webhook{
+		{
+			ID:       "WebhookID1",
+			Name:     "Name1",
+			URL:      "https://example.com/hook1",
+			Events:   `["iam:*"]`,
+			Secret:   "secret",
+			Urn:      "urn1",
+			CreateAt: now.UnixNano(),
+			UpdateAt: now.UnixNano(),
+		},
+		{
+			ID:       "WebhookID2",
+			Name:     "Name2",
+			URL:      "https://example.com/hook2",
+			Events:   `["iam:AddMember"]`,
+			Secret:   "secret",
+			Urn:      "urn2",
+			CreateAt: now.UnixNano(),
+			UpdateAt: now.UnixNano(),
+		},
+	}
+	webhook1 := api.Webhook{
+		ID:       "WebhookID1",
+		Name:     "Name1",
+		URL:      "https://example.com/hook1",
+		Events:   []string{"iam:*"},
+		Secret:   "secret",
+		Urn:      "urn1",
+		CreateAt: now,
+		UpdateAt: now,
+	}
+	webhook2 := api.Webhook{
+		ID:       "WebhookID2",
+		Name:     "Name2",
+		URL:      "https://example.com/hook2",
+		Events:   []string{api.GROUP_ACTION_ADD_MEMBER},
+		Secret:   "secret",
+		Urn:      "urn2",
+		CreateAt: now,
+		UpdateAt: now,
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.Webhook
+		expectedTotal    int
+	}{
+		"OkCaseOrderBy": {
+			filter: &api.Filter{
+				OrderBy: "name desc",
+				Limit:   20,
+			},
+			expectedResponse: []api.Webhook{webhook2, webhook1},
+			expectedTotal:    2,
+		},
+		"OkCaseLimit": {
+			filter: &api.Filter{
+				OrderBy: "name",
+				Limit:   1,
+			},
+			expectedResponse: []api.Webhook{webhook1},
+			expectedTotal:    2,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhook database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		for _, webhook := range previousWebhooks {
+			insertWebhook(t, n, webhook)
+		}
+		// Call to repository to get the webhooks
+		webhooks, total, err := repoDB.GetWebhooksFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, webhooks, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+
+		// Call to repository to get all webhooks
+		allWebhooks, err := repoDB.GetWebhooks()
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, 2, len(allWebhooks), "Error in test case %v", n)
+	}
+}
+
+func TestPostgresRepo_UpdateWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	n := "OkCase"
+
+	// Clean webhook database
+	cleanWebhooksTable(t, n)
+
+	// Insert previous data
+	insertWebhook(t, n, Webhook{
+		ID:       "WebhookID",
+		Name:     "Name",
+		URL:      "https://example.com/hook",
+		Events:   `["iam:*"]`,
+		Secret:   "secret",
+		Urn:      "urn",
+		CreateAt: now.UnixNano(),
+		UpdateAt: now.UnixNano(),
+	})
+
+	webhookToUpdate := api.Webhook{
+		ID:       "WebhookID",
+		Name:     "NewName",
+		URL:      "https://example.com/newhook",
+		Events:   []string{api.POLICY_ACTION_UPDATE_POLICY},
+		Secret:   "newSecret",
+		Urn:      "newUrn",
+		CreateAt: now,
+		UpdateAt: now.Add(time.Hour),
+	}
+
+	// Call to repository to update the webhook
+	updatedWebhook, err := repoDB.UpdateWebhook(webhookToUpdate)
+	assert.Nil(t, err, "Error in test case %v", n)
+	assert.Equal(t, &webhookToUpdate, updatedWebhook, "Error in test case %v", n)
+
+	// Check database
+	receivedWebhook, err := repoDB.GetWebhookByName("NewName")
+	assert.Nil(t, err, "Error in test case %v", n)
+	assert.Equal(t, &webhookToUpdate, receivedWebhook, "Error in test case %v", n)
+}
+
+func TestPostgresRepo_RemoveWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	n := "OkCase"
+
+	// Clean webhook database
+	cleanWebhooksTable(t, n)
+	cleanWebhookDeliveriesTable(t, n)
+
+	// Insert previous data
+	insertWebhook(t, n, Webhook{
+		ID:       "WebhookID",
+		Name:     "Name",
+		URL:      "https://example.com/hook",
+		Events:   `["iam:*"]`,
+		Secret:   "secret",
+		Urn:      "urn",
+		CreateAt: now.UnixNano(),
+		UpdateAt: now.UnixNano(),
+	})
+	insertWebhookDelivery(t, n, WebhookDelivery{
+		ID:            "Pending",
+		WebhookID:     "WebhookID",
+		EventID:       "EventID",
+		Event:         api.GROUP_ACTION_ADD_MEMBER,
+		Payload:       "{}",
+		Status:        api.WEBHOOK_DELIVERY_STATUS_PENDING,
+		NextAttemptAt: now.UnixNano(),
+		CreateAt:      now.UnixNano(),
+		UpdateAt:      now.UnixNano(),
+	})
+	insertWebhookDelivery(t, n, WebhookDelivery{
+		ID:            "Delivered",
+		WebhookID:     "WebhookID",
+		EventID:       "EventID",
+		Event:         api.GROUP_ACTION_ADD_MEMBER,
+		Payload:       "{}",
+		Status:        api.WEBHOOK_DELIVERY_STATUS_DELIVERED,
+		Attempts:      1,
+		NextAttemptAt: now.UnixNano(),
+		CreateAt:      now.UnixNano(),
+		UpdateAt:      now.UnixNano(),
+	})
+
+	// Call to repository to remove the webhook
+	err := repoDB.RemoveWebhook("WebhookID")
+	assert.Nil(t, err, "Error in test case %v", n)
+
+	// Check database
+	assert.Equal(t, 0, getWebhooksCountFiltered(t, n, "WebhookID", ""), "Error in test case %v", n)
+	assert.Equal(t, 0, getWebhookDeliveriesCountFiltered(t, n, "Pending", "", ""), "Error in test case %v", n)
+	assert.Equal(t, 1, getWebhookDeliveriesCountFiltered(t, n, "Delivered", "", ""), "Error in test case %v", n)
+}
+
+func TestPostgresRepo_ClaimWebhookDeliveries(t *testing.T) {
+	now := time.Now().UTC()
+	previousDeliveries := []WebhookDelivery{
+		{
+			ID:            "Due",
+			WebhookID:     "WebhookID",
+			EventID:       "EventID",
+			Event:         api.GROUP_ACTION_ADD_MEMBER,
+			Payload:       "{}",
+			Status:        api.WEBHOOK_DELIVERY_STATUS_PENDING,
+			NextAttemptAt: now.Add(-time.Minute).UnixNano(),
+			CreateAt:      now.UnixNano(),
+			UpdateAt:      now.UnixNano(),
+		},
+		{
+			ID:            "OldestDue",
+			WebhookID:     "WebhookID",
+			EventID:       "EventID",
+			Event:         api.GROUP_ACTION_ADD_MEMBER,
+			Payload:       "{}",
+			Status:        api.WEBHOOK_DELIVERY_STATUS_PENDING,
+			NextAttemptAt: now.Add(-time.Hour).UnixNano(),
+			CreateAt:      now.UnixNano(),
+			UpdateAt:      now.UnixNano(),
+		},
+		{
+			ID:            "NotDue",
+			WebhookID:     "WebhookID",
+			EventID:       "EventID",
+			Event:         api.GROUP_ACTION_ADD_MEMBER,
+			Payload:       "{}",
+			Status:        api.WEBHOOK_DELIVERY_STATUS_PENDING,
+			NextAttemptAt: now.Add(time.Hour).UnixNano(),
+			CreateAt:      now.UnixNano(),
+			UpdateAt:      now.UnixNano(),
+		},
+		{
+			ID:            "Delivered",
+			WebhookID:     "WebhookID",
+			EventID:       "EventID",
+			Event:         api.GROUP_ACTION_ADD_MEMBER,
+			Payload:       "{}",
+			Status:        api.WEBHOOK_DELIVERY_STATUS_DELIVERED,
+			NextAttemptAt: now.Add(-time.Hour).UnixNano(),
+			CreateAt:      now.UnixNano(),
+			UpdateAt:      now.UnixNano(),
+		},
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		limit int
+		// Expected result
+		expectedIDs []string
+	}{
+		"OkCase": {
+			limit:       10,
+			expectedIDs: []string{"OldestDue", "Due"},
+		},
+		"OkCaseLimit": {
+			limit:       1,
+			expectedIDs: []string{"OldestDue"},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhook deliveries database
+		cleanWebhookDeliveriesTable(t, n)
+
+		// Insert previous data
+		for _, delivery := range previousDeliveries {
+			insertWebhookDelivery(t, n, delivery)
+		}
+		// Call to repository to claim the pending deliveries
+		deliveries, err := repoDB.ClaimWebhookDeliveries(now, now.Add(time.Hour), test.limit)
+		assert.Nil(t, err, "Error in test case %v", n)
+		ids := []string{}
+		for _, d := range deliveries {
+			ids = append(ids, d.ID)
+		}
+		assert.Equal(t, test.expectedIDs, ids, "Error in test case %v", n)
+
+		// Claimed deliveries aren't claimed again until the claim expires
+		deliveries, err = repoDB.ClaimWebhookDeliveries(now, now.Add(time.Hour), test.limit)
+		assert.Nil(t, err, "Error in test case %v", n)
+		for _, d := range deliveries {
+			assert.NotContains(t, test.expectedIDs, d.ID, "Error in test case %v", n)
+		}
+		for _, id := range test.expectedIDs {
+			deliveryDB := WebhookDelivery{}
+			err = repoDB.Dbmap.Where("id = ?", id).First(&deliveryDB).Error
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, now.Add(time.Hour).UnixNano(), deliveryDB.NextAttemptAt, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestPostgresRepo_UpdateWebhookDelivery(t *testing.T) {
+	now := time.Now().UTC()
+	n := "OkCase"
+
+	// Clean webhook deliveries database
+	cleanWebhookDeliveriesTable(t, n)
+
+	// Insert previous data
+	insertWebhookDelivery(t, n, WebhookDelivery{
+		ID:            "DeliveryID",
+		WebhookID:     "WebhookID",
+		EventID:       "EventID",
+		Event:         api.GROUP_ACTION_ADD_MEMBER,
+		Payload:       "{}",
+		Status:        api.WEBHOOK_DELIVERY_STATUS_PENDING,
+		Attempts:      1,
+		LastError:     "Unexpected status code 500",
+		NextAttemptAt: now.UnixNano(),
+		CreateAt:      now.UnixNano(),
+		UpdateAt:      now.UnixNano(),
+	})
+
+	delivery := api.WebhookDelivery{
+		ID:            "DeliveryID",
+		WebhookID:     "WebhookID",
+		EventID:       "EventID",
+		Event:         api.GROUP_ACTION_ADD_MEMBER,
+		Payload:       "{}",
+		Status:        api.WEBHOOK_DELIVERY_STATUS_DELIVERED,
+		Attempts:      2,
+		NextAttemptAt: now,
+		CreateAt:      now,
+		UpdateAt:      now.Add(time.Minute),
+	}
+
+	// Call to repository to update the delivery
+	updatedDelivery, err := repoDB.UpdateWebhookDelivery(delivery)
+	assert.Nil(t, err, "Error in test case %v", n)
+	assert.Equal(t, &delivery, updatedDelivery, "Error in test case %v", n)
+
+	// Check database, last error must be cleaned
+	deliveryDB := WebhookDelivery{}
+	err = repoDB.Dbmap.Where("id = ?", "DeliveryID").First(&deliveryDB).Error
+	assert.Nil(t, err, "Error in test case %v", n)
+	assert.Equal(t, api.WEBHOOK_DELIVERY_STATUS_DELIVERED, deliveryDB.Status, "Error in test case %v", n)
+	assert.Equal(t, 2, deliveryDB.Attempts, "Error in test case %v", n)
+	assert.Equal(t, "", deliveryDB.LastError, "Error in test case %v", n)
+}
@@ -8,10 +8,13 @@ import (
 
 	"fmt"
 
+	"strconv"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
+	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/pelletier/go-toml"
 
 	"github.com/Tecsisa/foulkon/database/postgresql"
@@ -25,6 +28,9 @@ type Proxy struct {
 	Host string
 	Port string
 
+	// Time to drain in-flight requests when the proxy is stopped
+	ShutdownTimeout time.Duration
+
 	// Worker location
 	WorkerHost string
 
@@ -37,6 +43,21 @@ type Proxy struct {
 
 	// Refresh time
 	RefreshTime time.Duration
+
+	// Watch proxy resources in worker, refreshing them only when watch fails
+	Watch          bool
+	WatchTimeout   time.Duration
+	WorkerUsername string
+	WorkerPassword string
+
+	// Prometheus metrics listen address, metrics aren't served if empty
+	MetricsAddress string
+
+	// Readiness checks by name
+	ReadinessChecks map[string]ReadinessCheck
+
+	// Middlewares applied before proxy resources
+	MiddlewareHandler *middleware.MiddlewareHandler
 }
 
 func NewProxy(config *toml.TomlTree) (*Proxy, error) {
@@ -67,6 +88,12 @@ func NewProxy(config *toml.TomlTree) (*Proxy, error) {
 	}
 	api.Log.Infof("Logger type: %v, LogLevel: %v", loggerType, api.Log.Level.String())
 
+	// Tracing
+	if err := initTracing(config, "foulkon-proxy"); err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+
 	// Start DB with API
 	var prApi api.ProxyAPI
 
@@ -93,6 +120,7 @@ func NewProxy(config *toml.TomlTree) (*Proxy, error) {
 			return nil, err
 		}
 		db = gormDB.DB()
+		metrics.SetDB(db)
 		api.Log.Info("Connected to postgres database")
 
 		// Create repository
@@ -124,6 +152,11 @@ func NewProxy(config *toml.TomlTree) (*Proxy, error) {
 		api.Log.Error(err)
 		return nil, err
 	}
+	shutdownTimeout, err := getShutdownTimeout(config)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
 
 	refresh, err := time.ParseDuration(getDefaultValue(config, "resources.refresh", "10s"))
 	if err != nil {
@@ -131,14 +164,67 @@ func NewProxy(config *toml.TomlTree) (*Proxy, error) {
 		return nil, err
 	}
 
+	watchParam := getDefaultValue(config, "resources.watch", "false")
+	watch, err := strconv.ParseBool(watchParam)
+	if err != nil {
+		err := fmt.Errorf("Invalid resources watch param: %v", watchParam)
+		api.Log.Error(err)
+		return nil, err
+	}
+	var watchTimeout time.Duration
+	var workerUsername, workerPassword string
+	if watch {
+		watchTimeoutParam := getDefaultValue(config, "resources.watch-timeout", "30s")
+		watchTimeout, err = time.ParseDuration(watchTimeoutParam)
+		if err != nil || watchTimeout <= 0 || watchTimeout > api.MAX_PROXY_RESOURCES_WATCH_TIMEOUT {
+			err := fmt.Errorf("Invalid resources watch-timeout param: %v", watchTimeoutParam)
+			api.Log.Error(err)
+			return nil, err
+		}
+		workerUsername, err = getMandatoryValue(config, "resources.watch-username")
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		workerPassword, err = getMandatoryValue(config, "resources.watch-password")
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		api.Log.Infof("Watching proxy resources in worker with timeout %v", watchTimeout)
+	}
+
+	metricsAddress := getDefaultValue(config, "metrics.address", "")
+	if metricsAddress != "" {
+		api.Log.Infof("Metrics server enabled in address %v", metricsAddress)
+	}
+
+	// Middlewares, proxy doesn't authenticate requests
+	middlewareHandler, err := newMiddlewareHandler(config, DEFAULT_PROXY_PIPELINE, nil)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	api.Log.Infof("Middlewares pipeline: %v", middlewareHandler.Pipeline)
+
 	return &Proxy{
-		Host:        host,
-		Port:        port,
-		WorkerHost:  workerHost,
-		CertFile:    getDefaultValue(config, "server.certfile", ""),
-		KeyFile:     getDefaultValue(config, "server.keyfile", ""),
-		ProxyApi:    prApi,
-		RefreshTime: refresh,
+		Host:            host,
+		Port:            port,
+		ShutdownTimeout: shutdownTimeout,
+		WorkerHost:      workerHost,
+		CertFile:        getDefaultValue(config, "server.certfile", ""),
+		KeyFile:         getDefaultValue(config, "server.keyfile", ""),
+		ProxyApi:        prApi,
+		RefreshTime:     refresh,
+		Watch:           watch,
+		WatchTimeout:    watchTimeout,
+		WorkerUsername:  workerUsername,
+		WorkerPassword:  workerPassword,
+		MetricsAddress:  metricsAddress,
+		ReadinessChecks: map[string]ReadinessCheck{
+			"database": pingDB,
+		},
+		MiddlewareHandler: middlewareHandler,
 	}, nil
 }
 
@@ -154,5 +240,9 @@ func CloseProxy() int {
 			status = 1
 		}
 	}
+	if err := closeTracing(); err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't close tracing file: %v", err)
+		status = 1
+	}
 	return status
 }
@@ -2,7 +2,11 @@ package foulkon
 
 import (
 	"io"
+	"io/ioutil"
+	"reflect"
 	"regexp"
+	"sort"
+	"sync"
 
 	"errors"
 	"os"
@@ -12,28 +16,56 @@ import (
 
 	"database/sql"
 
+	"net/http"
+
 	"strconv"
 
+	"time"
+
 	"github.com/Sirupsen/logrus"
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/Tecsisa/foulkon/middleware/auth"
 	"github.com/Tecsisa/foulkon/middleware/auth/header"
+	"github.com/Tecsisa/foulkon/middleware/auth/jwt"
 	"github.com/Tecsisa/foulkon/middleware/auth/oidc"
-	"github.com/Tecsisa/foulkon/middleware/logger"
-	"github.com/Tecsisa/foulkon/middleware/xrequestid"
+	// Middlewares available for pipelines
+	_ "github.com/Tecsisa/foulkon/middleware/cors"
+	_ "github.com/Tecsisa/foulkon/middleware/logger"
+	_ "github.com/Tecsisa/foulkon/middleware/ratelimit"
+	_ "github.com/Tecsisa/foulkon/middleware/recovery"
+	_ "github.com/Tecsisa/foulkon/middleware/tracing"
+	_ "github.com/Tecsisa/foulkon/middleware/xrequestid"
+	"github.com/Tecsisa/foulkon/tracing"
 	"github.com/pelletier/go-toml"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	FOULKON_VERSION = "v0.4.0-SNAPSHOT"
+
+	// Middlewares of the worker when pipeline isn't configured
+	DEFAULT_WORKER_PIPELINE = "xrequestid,tracing,authenticator,request-logger"
+	// Middlewares of the proxy when pipeline isn't configured
+	DEFAULT_PROXY_PIPELINE = ""
+
+	// Time between OIDC providers reloads, when authenticator isn't oidc it is just checked again
+	DEFAULT_OIDC_REFRESH = time.Minute
 )
 
 // aux var for ${OS_ENV_VAR} regex
 var rEnvVar, _ = regexp.Compile(`^\$\{(\w+)\}$`)
 var db *sql.DB
 var workerLogfile *os.File
+var workerLogOutput *logOutput
+var tracingFile *os.File
+var auditDecisionQueue *api.AuditDecisionQueue
+
+// Periodic tasks of the worker, they are stopped before closing DB connection
+var stopBackgroundTasks chan struct{}
+var backgroundTasks sync.WaitGroup
 
 // Worker is the Authorization server.
 type Worker struct {
@@ -41,23 +73,75 @@ type Worker struct {
 	Host string
 	Port string
 
+	// Time to drain in-flight requests when the worker is stopped
+	ShutdownTimeout time.Duration
+
+	// gRPC authorization server port, disabled if empty
+	GrpcPort string
+
+	// Time between proxy resources reloads in Envoy external authorization checks
+	ExtAuthzRefresh time.Duration
+
+	// Kubernetes SubjectAccessReview mapping to external resources
+	K8sMapping K8sMapping
+
+	// Time between webhook delivery rounds
+	WebhookInterval time.Duration
+
+	// Prometheus metrics listen address, metrics are served in the API server if empty
+	MetricsAddress string
+
+	// Readiness checks by name
+	ReadinessChecks map[string]ReadinessCheck
+
 	// TLS configuration
 	CertFile string
 	KeyFile  string
 
 	// APIs
-	UserApi     api.UserAPI
-	GroupApi    api.GroupAPI
-	PolicyApi   api.PolicyAPI
-	AuthzApi    api.AuthzAPI
-	ProxyApi    api.ProxyResourcesAPI
-	AuthOidcAPI api.AuthOidcAPI
+	UserApi           api.UserAPI
+	GroupApi          api.GroupAPI
+	PolicyApi         api.PolicyAPI
+	PolicyTemplateApi api.PolicyTemplateAPI
+	AuthzApi          api.AuthzAPI
+	ProxyApi          api.ProxyResourcesAPI
+	AuthOidcAPI       api.AuthOidcAPI
+	BreakGlassApi     api.BreakGlassAPI
+	ChangeRequestApi  api.ChangeRequestAPI
+	AuditApi          api.AuditAPI
+	WebhookApi        api.WebhookAPI
+	InternalProxyApi  api.InternalProxyAPI
 
 	//  Middleware handler
 	MiddlewareHandler *middleware.MiddlewareHandler
 
 	// Current Foulkon configuration
 	Config WorkerConfig
+
+	// Configuration file values, and the repository needed to reload the authenticator
+	config       *toml.TomlTree
+	authOidcRepo api.AuthOidcRepo
+	configLock   sync.RWMutex
+	reloadLock   sync.Mutex
+
+	// Closed when the worker starts shutting down
+	shutdown     chan struct{}
+	shutdownLock sync.Mutex
+}
+
+// ReadinessCheck returns an error when a dependency isn't ready to serve requests
+type ReadinessCheck func() error
+
+// K8sMapping defines the external urn and action checked for a Kubernetes SubjectAccessReview
+type K8sMapping struct {
+	// Urn for resource requests, with {namespace}, {group}, {resource}, {subresource} and {name} parameters
+	Urn string
+	// Urn for non resource requests, with {path} parameter
+	NonResourceUrn string
+	// Action, with {verb} parameter
+	Action string
+	// Answer denied to not allowed requests, instead of no opinion
+	Deny bool
 }
 
 // WorkerConfig
@@ -76,6 +160,19 @@ type WorkerConfig struct {
 	// Authenticator Config
 	AuthType      string
 	OidcProviders []api.OidcProvider
+	OidcRefresh   time.Duration
+
+	// Admin Config
+	Admins []string
+
+	// Break-glass Config
+	BreakGlassUsers      []string
+	BreakGlassSessionTTL int
+
+	// Approval Config
+	ApprovalActions []string
+	ApprovalOrgs    []string
+	ApprovalTTL     int
 
 	Version string
 }
@@ -85,38 +182,35 @@ func NewWorker(config *toml.TomlTree) (*Worker, error) {
 	var wc WorkerConfig
 
 	// Create logger
-	var logOut io.Writer
-	var err error
-	logOut = os.Stdout
-	loggerType := getDefaultValue(config, "logger.type", "Stdout")
-	if loggerType == "file" {
-		logFileDir := getDefaultValue(config, "logger.file.dir", "/tmp/foulkon.log")
-		workerLogfile, err = os.OpenFile(logFileDir, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
-		if err != nil {
-			return nil, err
-		}
-		wc.FileDirectory = logFileDir
-		logOut = workerLogfile
-	}
-	wc.LoggerType = loggerType
-
-	// Logger level. Defaults to INFO
-	loglevel, err := logrus.ParseLevel(getDefaultValue(config, "logger.level", "info"))
+	logOut, logFile, loglevel, err := getLoggerConfig(config, &wc)
 	if err != nil {
-		loglevel = logrus.InfoLevel
+		return nil, err
 	}
-	wc.LoggerLevel = loglevel.String()
+	workerLogfile = logFile
 
+	// Entries are filtered by the output level, logger level isn't changed since logrus reads it without lock
+	workerLogOutput = &logOutput{
+		out:       logOut,
+		level:     loglevel,
+		formatter: &logrus.JSONFormatter{},
+	}
 	api.Log = &logrus.Logger{
-		Out:       logOut,
-		Formatter: &logrus.JSONFormatter{},
+		Out:       workerLogOutput,
+		Formatter: workerLogOutput,
 		Hooks:     make(logrus.LevelHooks),
-		Level:     loglevel,
+		Level:     logrus.DebugLevel,
+	}
+	api.Log.Infof("Logger type: %v, LogLevel: %v", wc.LoggerType, loglevel.String())
+
+	// Tracing
+	if err := initTracing(config, "foulkon-worker"); err != nil {
+		api.Log.Error(err)
+		return nil, err
 	}
-	api.Log.Infof("Logger type: %v, LogLevel: %v", loggerType, api.Log.Level.String())
 
 	// Start DB with API
 	var authApi api.WorkerAPI
+	var proxyApi api.ProxyAPI
 
 	dbType, err := getMandatoryValue(config, "database.type")
 	if err != nil {
@@ -142,6 +236,7 @@ func NewWorker(config *toml.TomlTree) (*Worker, error) {
 			return nil, err
 		}
 		db = gormDB.DB()
+		metrics.SetDB(db)
 		api.Log.Info("Connected to postgres database")
 
 		// Create repository
@@ -149,11 +244,21 @@ func NewWorker(config *toml.TomlTree) (*Worker, error) {
 			Dbmap: gormDB,
 		}
 		authApi = api.WorkerAPI{
-			GroupRepo:    repoDB,
-			UserRepo:     repoDB,
-			PolicyRepo:   repoDB,
-			ProxyRepo:    repoDB,
-			AuthOidcRepo: repoDB,
+			GroupRepo:          repoDB,
+			UserRepo:           repoDB,
+			PolicyRepo:         repoDB,
+			ProxyRepo:          repoDB,
+			AuthOidcRepo:       repoDB,
+			PolicyTemplateRepo: repoDB,
+			BreakGlassRepo:     repoDB,
+			ChangeRequestRepo:  repoDB,
+			AuditRepo:          repoDB,
+			WebhookRepo:        repoDB,
+			SnapshotRepo:       repoDB,
+			AuthzSnapshotCache: &api.AuthzSnapshotCache{},
+		}
+		proxyApi = api.ProxyAPI{
+			ProxyRepo: repoDB,
 		}
 		wc.IdleConns, _ = strconv.Atoi(dbIdleconns)
 		wc.MaxOpenConns, _ = strconv.Atoi(dbMaxopenconns)
@@ -166,111 +271,476 @@ func NewWorker(config *toml.TomlTree) (*Worker, error) {
 	}
 
 	// Instantiate Auth Connector
-	var authConnector auth.AuthConnector
-	authType, err := getMandatoryValue(config, "authenticator.type")
+	authConnector, err := newAuthConnector(config, authApi.AuthOidcRepo, &wc)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+
+	admins, err := getAdminAccounts(config, &wc)
 	if err != nil {
+		api.Log.Error(err)
 		return nil, err
 	}
-	wc.AuthType = authType
 
-	switch authType {
-	case "header":
-		headerName, err := getMandatoryValue(config, "authenticator.header.name")
-		if err != nil {
-			api.Log.Warn("Header authenticator configured, but no header provided - only admin access allowed")
-		} else {
-			authConnector = header.InitHeaderConnector(headerName)
-			api.Log.Infof("Header authenticator configured with header: %v", headerName)
+	breakGlassUsers, err := getBreakGlassUsers(config, admins, &wc)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	breakGlassTTL := getDefaultValue(config, "breakglass.ttl", "3600")
+	wc.BreakGlassSessionTTL, err = strconv.Atoi(breakGlassTTL)
+	if err != nil || wc.BreakGlassSessionTTL < 1 {
+		err := fmt.Errorf("Invalid break-glass ttl param: %v", breakGlassTTL)
+		api.Log.Error(err)
+		return nil, err
+	}
+	authApi.BreakGlassSessionTTL = time.Duration(wc.BreakGlassSessionTTL) * time.Second
+
+	// Approval rules, policy updates and attachments granting these actions or in these organizations need a second approver
+	if approvalActions := getDefaultValue(config, "approval.actions", ""); approvalActions != "" {
+		for _, action := range strings.Split(approvalActions, ",") {
+			wc.ApprovalActions = append(wc.ApprovalActions, strings.TrimSpace(action))
 		}
-	case "oidc":
-		oidcProviders, total, err := authApi.AuthOidcRepo.GetOidcProvidersFiltered(&api.Filter{})
-		if err != nil {
+		if err := api.AreValidActions(wc.ApprovalActions); err != nil {
+			api.Log.Error(err)
 			return nil, err
 		}
-
-		if total > 0 {
-			wc.OidcProviders = oidcProviders
-			api.Log.Infof("OIDC connectors retrieved %v", total)
-
-			authOidcConnector, err := oidc.InitOIDCConnector(oidcProviders)
-			if err != nil {
+	}
+	if approvalOrgs := getDefaultValue(config, "approval.orgs", ""); approvalOrgs != "" {
+		for _, org := range strings.Split(approvalOrgs, ",") {
+			org = strings.TrimSpace(org)
+			if !api.IsValidOrg(org) {
+				err := fmt.Errorf("Invalid approval org param: %v", org)
 				api.Log.Error(err)
 				return nil, err
 			}
-			authConnector = authOidcConnector
-			api.Log.Infof("OIDC connector configured with %v OIDC Providers: %v", total, oidcProviders)
-		} else {
-			api.Log.Warn("No OIDC connectors retrieved, only admin access allowed")
+			wc.ApprovalOrgs = append(wc.ApprovalOrgs, org)
 		}
-	default:
-		err := fmt.Errorf("Unexpected auth_connector_type value in configuration file: '%s' (maybe it is empty)", authType)
+	}
+	approvalTTL := getDefaultValue(config, "approval.ttl", "86400")
+	wc.ApprovalTTL, err = strconv.Atoi(approvalTTL)
+	if err != nil || wc.ApprovalTTL < 1 {
+		err := fmt.Errorf("Invalid approval ttl param: %v", approvalTTL)
 		api.Log.Error(err)
 		return nil, err
 	}
+	authApi.ApprovalRules = api.ApprovalRules{
+		Actions:       wc.ApprovalActions,
+		ProtectedOrgs: wc.ApprovalOrgs,
+		TTL:           time.Duration(wc.ApprovalTTL) * time.Second,
+	}
+	if len(wc.ApprovalActions) > 0 || len(wc.ApprovalOrgs) > 0 {
+		api.Log.Infof("Approval rules configured with actions: %v, organizations: %v, ttl: %vs",
+			wc.ApprovalActions, wc.ApprovalOrgs, wc.ApprovalTTL)
+	}
 
-	adminUser, err := getMandatoryValue(config, "admin.username")
-	if err != nil {
+	// Audit log, events are kept forever without retention
+	auditRetention := getDefaultValue(config, "audit.retention", "0")
+	authApi.AuditRetention, err = time.ParseDuration(auditRetention)
+	if err != nil || authApi.AuditRetention < 0 {
+		err := fmt.Errorf("Invalid audit retention param: %v", auditRetention)
 		api.Log.Error(err)
 		return nil, err
 	}
-	adminPassword, err := getMandatoryValue(config, "admin.password")
+	auditDecisions := getDefaultValue(config, "audit.decisions", "false")
+	recordDecisions, err := strconv.ParseBool(auditDecisions)
 	if err != nil {
+		err := fmt.Errorf("Invalid audit decisions param: %v", auditDecisions)
+		api.Log.Error(err)
+		return nil, err
+	}
+	api.Log.Infof("Audit log configured with retention: %v, authorization decisions: %v", authApi.AuditRetention, recordDecisions)
+
+	// Webhook deliveries
+	webhookMaxAttempts := getDefaultValue(config, "webhook.maxattempts", "10")
+	authApi.WebhookRetry.MaxAttempts, err = strconv.Atoi(webhookMaxAttempts)
+	if err != nil || authApi.WebhookRetry.MaxAttempts < 1 {
+		err := fmt.Errorf("Invalid webhook max attempts param: %v", webhookMaxAttempts)
+		api.Log.Error(err)
+		return nil, err
+	}
+	webhookBackoff := getDefaultValue(config, "webhook.backoff", "10s")
+	authApi.WebhookRetry.Backoff, err = time.ParseDuration(webhookBackoff)
+	if err != nil || authApi.WebhookRetry.Backoff <= 0 {
+		err := fmt.Errorf("Invalid webhook backoff param: %v", webhookBackoff)
+		api.Log.Error(err)
+		return nil, err
+	}
+	webhookMaxBackoff := getDefaultValue(config, "webhook.maxbackoff", "1h")
+	authApi.WebhookRetry.MaxBackoff, err = time.ParseDuration(webhookMaxBackoff)
+	if err != nil || authApi.WebhookRetry.MaxBackoff < authApi.WebhookRetry.Backoff {
+		err := fmt.Errorf("Invalid webhook max backoff param: %v", webhookMaxBackoff)
+		api.Log.Error(err)
+		return nil, err
+	}
+	webhookTimeout := getDefaultValue(config, "webhook.timeout", "10s")
+	webhookTimeoutDuration, err := time.ParseDuration(webhookTimeout)
+	if err != nil || webhookTimeoutDuration <= 0 {
+		err := fmt.Errorf("Invalid webhook timeout param: %v", webhookTimeout)
 		api.Log.Error(err)
 		return nil, err
 	}
-	if len(strings.TrimSpace(adminUser)) < 1 || len(strings.TrimSpace(adminPassword)) < 1 {
-		err := fmt.Errorf("Admin user config unexpected adminUser:%v, adminpassword:%v", adminUser, adminPassword)
+	authApi.WebhookClient = &http.Client{Timeout: webhookTimeoutDuration}
+	webhookInterval := getDefaultValue(config, "webhook.interval", "5s")
+	webhookIntervalDuration, err := time.ParseDuration(webhookInterval)
+	if err != nil || webhookIntervalDuration <= 0 {
+		err := fmt.Errorf("Invalid webhook interval param: %v", webhookInterval)
 		api.Log.Error(err)
 		return nil, err
 	}
+	api.Log.Infof("Webhook deliveries configured with max attempts: %v, backoff: %v, max backoff: %v, timeout: %v, interval: %v",
+		authApi.WebhookRetry.MaxAttempts, authApi.WebhookRetry.Backoff, authApi.WebhookRetry.MaxBackoff,
+		webhookTimeoutDuration, webhookIntervalDuration)
+
+	// Authenticator middleware, it is created here since it needs the connector
+	authenticatorMiddleware := auth.NewAuthenticatorMiddleware(authConnector, admins, breakGlassUsers)
+	api.Log.Infof("Created authenticator with admin accounts %v", wc.Admins)
+	if len(wc.BreakGlassUsers) > 0 {
+		api.Log.Infof("Break-glass accounts configured: %v, session ttl: %vs", wc.BreakGlassUsers, wc.BreakGlassSessionTTL)
+	}
 
 	// Middlewares
-	middlewares := make(map[string]middleware.Middleware)
+	middlewareHandler, err := newMiddlewareHandler(config, DEFAULT_WORKER_PIPELINE, map[string]middleware.Middleware{
+		middleware.AUTHENTICATOR_MIDDLEWARE: authenticatorMiddleware,
+	})
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	if _, ok := middlewareHandler.Middlewares[middleware.AUTHENTICATOR_MIDDLEWARE]; !ok {
+		err := errors.New("Authenticator middleware must be in the middlewares pipeline")
+		api.Log.Error(err)
+		return nil, err
+	}
+	api.Log.Infof("Middlewares pipeline: %v", middlewareHandler.Pipeline)
 
-	// Authenticator middleware
-	authenticatorMiddleware := auth.NewAuthenticatorMiddleware(authConnector, adminUser, adminPassword)
-	middlewares[middleware.AUTHENTICATOR_MIDDLEWARE] = authenticatorMiddleware
-	api.Log.Infof("Created authenticator with admin username %v", adminUser)
+	host, err := getMandatoryValue(config, "server.host")
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	port, err := getMandatoryValue(config, "server.port")
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
 
-	// X-Request-Id middleware
-	xrequestidMiddleware := xrequestid.NewXRequestIdMiddleware()
-	middlewares[middleware.XREQUESTID_MIDDLEWARE] = xrequestidMiddleware
+	shutdownTimeout, err := getShutdownTimeout(config)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
 
-	// Request Logger middleware
-	requestLoggerMiddleware := logger.NewRequestLoggerMiddleware()
-	middlewares[middleware.REQUEST_LOGGER_MIDDLEWARE] = requestLoggerMiddleware
+	grpcPort := getDefaultValue(config, "grpc.port", "")
+	if grpcPort != "" {
+		api.Log.Infof("gRPC authorization server enabled in port %v", grpcPort)
+	}
 
-	host, err := getMandatoryValue(config, "server.host")
+	extAuthzRefresh, err := time.ParseDuration(getDefaultValue(config, "extauthz.refresh", "10s"))
 	if err != nil {
 		api.Log.Error(err)
 		return nil, err
 	}
-	port, err := getMandatoryValue(config, "server.port")
+
+	// Kubernetes SubjectAccessReview mapping
+	k8sDeny := getDefaultValue(config, "k8s.deny", "false")
+	k8sMapping := K8sMapping{
+		Urn:            getDefaultValue(config, "k8s.urn", "urn:k8s:cluster:{namespace}:{group}/{resource}/{subresource}/{name}"),
+		NonResourceUrn: getDefaultValue(config, "k8s.nonresourceurn", "urn:k8s:cluster:nonresource:{path}"),
+		Action:         getDefaultValue(config, "k8s.action", "k8s:{verb}"),
+	}
+	k8sMapping.Deny, err = strconv.ParseBool(k8sDeny)
 	if err != nil {
+		err := fmt.Errorf("Invalid k8s deny param: %v", k8sDeny)
 		api.Log.Error(err)
 		return nil, err
 	}
 
+	metricsAddress := getDefaultValue(config, "metrics.address", "")
+	if metricsAddress != "" {
+		api.Log.Infof("Metrics server enabled in address %v", metricsAddress)
+	}
+
 	wc.Version = FOULKON_VERSION
 
-	return &Worker{
+	// Decision audit queue is started once configuration is valid, it is closed with DB connection
+	if recordDecisions {
+		auditDecisionQueue = api.NewAuditDecisionQueue(authApi.AuditRepo)
+		authApi.AuditDecisionQueue = auditDecisionQueue
+	}
+
+	worker := &Worker{
 		Host:              host,
 		Port:              port,
+		ShutdownTimeout:   shutdownTimeout,
+		GrpcPort:          grpcPort,
+		ExtAuthzRefresh:   extAuthzRefresh,
+		K8sMapping:        k8sMapping,
+		WebhookInterval:   webhookIntervalDuration,
+		MetricsAddress:    metricsAddress,
 		CertFile:          getDefaultValue(config, "server.certfile", ""),
 		KeyFile:           getDefaultValue(config, "server.keyfile", ""),
-		MiddlewareHandler: &middleware.MiddlewareHandler{Middlewares: middlewares},
+		MiddlewareHandler: middlewareHandler,
 		UserApi:           authApi,
 		GroupApi:          authApi,
 		PolicyApi:         authApi,
+		PolicyTemplateApi: authApi,
 		AuthzApi:          authApi,
 		ProxyApi:          authApi,
 		AuthOidcAPI:       authApi,
+		BreakGlassApi:     authApi,
+		ChangeRequestApi:  authApi,
+		AuditApi:          authApi,
+		WebhookApi:        authApi,
+		InternalProxyApi:  proxyApi,
 		Config:            wc,
-	}, nil
+		config:            config,
+		authOidcRepo:      authApi.AuthOidcRepo,
+	}
+
+	// Readiness checks, OIDC connector isn't ready without providers
+	worker.ReadinessChecks = map[string]ReadinessCheck{
+		"database": pingDB,
+	}
+	if wc.AuthType == "oidc" {
+		worker.ReadinessChecks["oidc"] = worker.checkOidcProviders
+	}
+
+	return worker, nil
+}
+
+// GetConfig returns the current Foulkon configuration, that changes when it is reloaded
+func (w *Worker) GetConfig() WorkerConfig {
+	w.configLock.RLock()
+	defer w.configLock.RUnlock()
+	return w.Config
+}
+
+// Reload applies the configuration values that can change while the worker is running: logger, authenticator,
+// admin and break-glass accounts and database pool sizes. Nothing is applied if any other value changed,
+// or if any value is invalid
+func (w *Worker) Reload(config *toml.TomlTree) error {
+	w.reloadLock.Lock()
+	defer w.reloadLock.Unlock()
+
+	// Values that need a restart
+	if keys := getChangedConfigKeys(w.config, config); len(keys) > 0 {
+		for _, key := range keys {
+			api.Log.Errorf("Configuration value %v can't be reloaded, restart the worker to change it", key)
+		}
+		return fmt.Errorf("Configuration values can't be reloaded: %v", strings.Join(keys, ", "))
+	}
+
+	// Read and validate new values
+	wc := w.GetConfig()
+	authConnector, err := newAuthConnector(config, w.authOidcRepo, &wc)
+	if err != nil {
+		return err
+	}
+	admins, err := getAdminAccounts(config, &wc)
+	if err != nil {
+		return err
+	}
+	breakGlassUsers, err := getBreakGlassUsers(config, admins, &wc)
+	if err != nil {
+		return err
+	}
+	authenticator, ok := w.MiddlewareHandler.Middlewares[middleware.AUTHENTICATOR_MIDDLEWARE].(*auth.AuthenticatorMiddleware)
+	if !ok {
+		return errors.New("Authenticator middleware not found")
+	}
+	poolConfig, err := postgresql.ParsePoolConfig(
+		getDefaultValue(config, "database.postgres.idleconns", "5"),
+		getDefaultValue(config, "database.postgres.maxopenconns", "20"),
+		getDefaultValue(config, "database.postgres.connttl", "300"))
+	if err != nil {
+		return err
+	}
+	// Logger is the last one since it opens the log file, nothing fails after it
+	logOut, logFile, loglevel, err := getLoggerConfig(config, &wc)
+	if err != nil {
+		return err
+	}
+
+	// Apply them. Logger output is changed in place, so references to the logger keep working
+	authenticator.Update(authConnector, admins, breakGlassUsers)
+	if db != nil {
+		poolConfig.Apply(db)
+		wc.IdleConns = poolConfig.IdleConns
+		wc.MaxOpenConns = poolConfig.MaxOpenConns
+		wc.ConnTtl = poolConfig.ConnTTL
+	}
+	if workerLogOutput != nil {
+		workerLogOutput.update(logOut, loglevel)
+	}
+	// Previous log file isn't used after the output is updated
+	if workerLogfile != nil {
+		if err := workerLogfile.Close(); err != nil {
+			api.Log.Errorf("Couldn't close previous logfile: %v", err)
+		}
+	}
+	workerLogfile = logFile
+
+	w.configLock.Lock()
+	w.Config = wc
+	w.config = config
+	w.configLock.Unlock()
+
+	api.Log.Infof("Configuration reloaded with logger type: %v, LogLevel: %v, authenticator type: %v, admin accounts: %v, "+
+		"break-glass accounts: %v", wc.LoggerType, wc.LoggerLevel, wc.AuthType, wc.Admins, wc.BreakGlassUsers)
+	return nil
+}
+
+// ReloadOidcProviders reads OIDC providers from database again, replacing the connector of the authenticator
+// if they changed. Requests being authenticated keep using the previous connector
+func (w *Worker) ReloadOidcProviders() error {
+	w.reloadLock.Lock()
+	defer w.reloadLock.Unlock()
+
+	wc := w.GetConfig()
+	// Providers can't be read without repository
+	if wc.AuthType != "oidc" || w.authOidcRepo == nil {
+		return nil
+	}
+	oidcProviders, _, err := w.authOidcRepo.GetOidcProvidersFiltered(&api.Filter{})
+	if err != nil {
+		return err
+	}
+	if len(oidcProviders) < 1 {
+		oidcProviders = nil
+	}
+	if reflect.DeepEqual(oidcProviders, wc.OidcProviders) {
+		return nil
+	}
+
+	authenticator, ok := w.MiddlewareHandler.Middlewares[middleware.AUTHENTICATOR_MIDDLEWARE].(*auth.AuthenticatorMiddleware)
+	if !ok {
+		return errors.New("Authenticator middleware not found")
+	}
+	authConnector, err := newOidcConnector(oidcProviders)
+	if err != nil {
+		return err
+	}
+	authenticator.SetConnector(authConnector)
+
+	wc.OidcProviders = oidcProviders
+	w.configLock.Lock()
+	w.Config = wc
+	w.configLock.Unlock()
+	return nil
+}
+
+// StartBackgroundTasks starts the periodic tasks of the worker until CloseWorker is called. OIDC providers are
+// reloaded to apply changes made through other workers, audit events out of retention are removed every hour
+// and pending webhook deliveries are sent
+func (w *Worker) StartBackgroundTasks() {
+	stopBackgroundTasks = make(chan struct{})
+	w.startBackgroundTask(w.getOidcRefresh, w.ReloadOidcProviders, "Unexpected error reloading OIDC providers: %v")
+	w.startBackgroundTask(func() time.Duration { return time.Hour }, w.AuditApi.PurgeAuditEvents,
+		"Unexpected error removing audit events out of retention: %v")
+	w.startBackgroundTask(func() time.Duration { return w.WebhookInterval }, w.WebhookApi.DeliverWebhooks,
+		"Unexpected error sending webhook deliveries: %v")
+}
+
+// ShuttingDown returns a channel that is closed when the worker starts shutting down, so long running requests
+// like proxy resources watches end before in-flight requests are drained
+func (w *Worker) ShuttingDown() <-chan struct{} {
+	w.shutdownLock.Lock()
+	defer w.shutdownLock.Unlock()
+	if w.shutdown == nil {
+		w.shutdown = make(chan struct{})
+	}
+	return w.shutdown
+}
+
+// StartShutdown closes the ShuttingDown channel, it can be called more than once
+func (w *Worker) StartShutdown() {
+	w.shutdownLock.Lock()
+	defer w.shutdownLock.Unlock()
+	if w.shutdown == nil {
+		w.shutdown = make(chan struct{})
+	}
+	select {
+	case <-w.shutdown:
+	default:
+		close(w.shutdown)
+	}
+}
+
+// startBackgroundTask calls task every interval until background tasks are stopped
+func (w *Worker) startBackgroundTask(interval func() time.Duration, task func() error, errorFormat string) {
+	stop := stopBackgroundTasks
+	backgroundTasks.Add(1)
+	go func() {
+		defer backgroundTasks.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(interval()):
+			}
+			if err := task(); err != nil {
+				api.Log.Errorf(errorFormat, err)
+			}
+		}
+	}()
+}
+
+// getOidcRefresh returns the time between OIDC providers reloads, it can change when configuration is reloaded
+func (w *Worker) getOidcRefresh() time.Duration {
+	refresh := w.GetConfig().OidcRefresh
+	if refresh <= 0 {
+		refresh = DEFAULT_OIDC_REFRESH
+	}
+	return refresh
+}
+
+// checkOidcProviders is the readiness check of the OIDC connector, that needs providers
+func (w *Worker) checkOidcProviders() error {
+	wc := w.GetConfig()
+	if wc.AuthType == "oidc" && len(wc.OidcProviders) < 1 {
+		return errors.New("No OIDC providers loaded")
+	}
+	return nil
+}
+
+// GetRequestInfo retrieves the request information from the middleware context of an authenticated request
+func (w *Worker) GetRequestInfo(r *http.Request) api.RequestInfo {
+	// Retrieve request information from middleware context
+	mc := w.MiddlewareHandler.GetMiddlewareContext(r)
+	requestInfo := api.RequestInfo{
+		Identifier: mc.UserId,
+		Admin:      mc.Admin,
+		RequestID:  mc.XRequestId,
+		BreakGlass: mc.BreakGlass,
+		Span:       mc.Span,
+	}
+	// Break-glass accounts have admin privileges while they have an active session
+	if mc.BreakGlass {
+		session, err := w.BreakGlassApi.UseBreakGlassSession(requestInfo)
+		if err == nil {
+			requestInfo.Admin = true
+			requestInfo.BreakGlassSessionID = session.ID
+		} else if apiError := err.(*api.Error); apiError.Code != api.BREAK_GLASS_SESSION_NOT_FOUND {
+			api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, apiError)
+		}
+	}
+	return requestInfo
 }
 
 func CloseWorker() int {
 	status := 0
+	// Background tasks are stopped before closing DB connection, waiting for the ones in progress
+	if stopBackgroundTasks != nil {
+		close(stopBackgroundTasks)
+		stopBackgroundTasks = nil
+		backgroundTasks.Wait()
+	}
+	// Pending decision audit events are stored before closing DB connection
+	if auditDecisionQueue != nil {
+		auditDecisionQueue.Close()
+	}
 	if err := db.Close(); err != nil {
 		api.Log.Errorf("Couldn't close DB connection: %v", err)
 		status = 1
@@ -281,9 +751,442 @@ func CloseWorker() int {
 			status = 1
 		}
 	}
+	if err := closeTracing(); err != nil {
+		fmt.Fprintf(os.Stderr, "Couldn't close tracing file: %v", err)
+		status = 1
+	}
 	return status
 }
 
+// logOutput is the output and formatter of the worker logger. Its writer and level are replaced when
+// configuration is reloaded, under its lock, since logrus reads logger fields without locking
+type logOutput struct {
+	lock      sync.RWMutex
+	out       io.Writer
+	level     logrus.Level
+	formatter logrus.Formatter
+}
+
+// Format formats the entries of the output level, other entries are discarded
+func (lo *logOutput) Format(entry *logrus.Entry) ([]byte, error) {
+	lo.lock.RLock()
+	level := lo.level
+	lo.lock.RUnlock()
+	if entry.Level > level {
+		return nil, nil
+	}
+	return lo.formatter.Format(entry)
+}
+
+func (lo *logOutput) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	lo.lock.Lock()
+	defer lo.lock.Unlock()
+	return lo.out.Write(p)
+}
+
+// update replaces the writer and level, the previous writer isn't used once it returns
+func (lo *logOutput) update(out io.Writer, level logrus.Level) {
+	lo.lock.Lock()
+	defer lo.lock.Unlock()
+	lo.out = out
+	lo.level = level
+}
+
+// This aux method returns the logger output and level configured, with the log file if it is used
+func getLoggerConfig(config *toml.TomlTree, wc *WorkerConfig) (io.Writer, *os.File, logrus.Level, error) {
+	var logOut io.Writer
+	var logFile *os.File
+	var err error
+	logOut = os.Stdout
+	loggerType := getDefaultValue(config, "logger.type", "Stdout")
+	wc.FileDirectory = ""
+	if loggerType == "file" {
+		logFileDir := getDefaultValue(config, "logger.file.dir", "/tmp/foulkon.log")
+		logFile, err = os.OpenFile(logFileDir, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		wc.FileDirectory = logFileDir
+		logOut = logFile
+	}
+	wc.LoggerType = loggerType
+
+	// Logger level. Defaults to INFO
+	loglevel, err := logrus.ParseLevel(getDefaultValue(config, "logger.level", "info"))
+	if err != nil {
+		loglevel = logrus.InfoLevel
+	}
+	wc.LoggerLevel = loglevel.String()
+	return logOut, logFile, loglevel, nil
+}
+
+// This aux method creates the authentication connector configured, nil if only admin access is allowed
+func newAuthConnector(config *toml.TomlTree, oidcRepo api.AuthOidcRepo, wc *WorkerConfig) (auth.AuthConnector, error) {
+	var authConnector auth.AuthConnector
+	authType, err := getMandatoryValue(config, "authenticator.type")
+	if err != nil {
+		return nil, err
+	}
+	wc.AuthType = authType
+	wc.OidcProviders = nil
+	wc.OidcRefresh = 0
+
+	switch authType {
+	case "header":
+		headerName, err := getMandatoryValue(config, "authenticator.header.name")
+		if err != nil {
+			api.Log.Warn("Header authenticator configured, but no header provided - only admin access allowed")
+		} else {
+			authConnector = header.InitHeaderConnector(headerName)
+			api.Log.Infof("Header authenticator configured with header: %v", headerName)
+		}
+	case "oidc":
+		oidcRefresh := getDefaultValue(config, "authenticator.oidc.refresh", DEFAULT_OIDC_REFRESH.String())
+		if oidcRefresh == "" {
+			oidcRefresh = DEFAULT_OIDC_REFRESH.String()
+		}
+		wc.OidcRefresh, err = time.ParseDuration(oidcRefresh)
+		if err != nil || wc.OidcRefresh <= 0 {
+			return nil, fmt.Errorf("Invalid authenticator oidc refresh param: %v", oidcRefresh)
+		}
+
+		oidcProviders, total, err := oidcRepo.GetOidcProvidersFiltered(&api.Filter{})
+		if err != nil {
+			return nil, err
+		}
+		api.Log.Infof("OIDC connectors retrieved %v", total)
+
+		authConnector, err = newOidcConnector(oidcProviders)
+		if err != nil {
+			return nil, err
+		}
+		if total > 0 {
+			wc.OidcProviders = oidcProviders
+		}
+	case "jwt":
+		jwtConfig, err := getJWTConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		authConnector, err = jwt.InitJWTConnector(jwtConfig)
+		if err != nil {
+			return nil, err
+		}
+		api.Log.Infof("JWT authenticator configured with %v keys, issuer: %v, audiences: %v, user claim: %v",
+			len(jwtConfig.Keys), jwtConfig.Issuer, jwtConfig.Audiences, jwtConfig.UserClaim)
+	default:
+		return nil, fmt.Errorf("Unexpected auth_connector_type value in configuration file: '%s' (maybe it is empty)", authType)
+	}
+	return authConnector, nil
+}
+
+// This aux method creates the OIDC connector of the providers, nil if there isn't any provider
+func newOidcConnector(oidcProviders []api.OidcProvider) (auth.AuthConnector, error) {
+	if len(oidcProviders) < 1 {
+		api.Log.Warn("No OIDC connectors retrieved, only admin access allowed")
+		return nil, nil
+	}
+	authOidcConnector, err := oidc.InitOIDCConnector(oidcProviders)
+	if err != nil {
+		return nil, err
+	}
+	api.Log.Infof("OIDC connector configured with %v OIDC Providers: %v", len(oidcProviders), oidcProviders)
+	return authOidcConnector, nil
+}
+
+// This aux method returns the JWT connector config, with keys read from jwks and pem files and the HMAC secret
+func getJWTConfig(config *toml.TomlTree) (jwt.JWTConfig, error) {
+	jwtConfig := jwt.JWTConfig{
+		Keys:      []jwt.Key{},
+		Issuer:    getDefaultValue(config, "authenticator.jwt.issuer", ""),
+		Audiences: []string{},
+		UserClaim: getDefaultValue(config, "authenticator.jwt.userclaim", jwt.DEFAULT_USER_CLAIM),
+	}
+	if jwtConfig.UserClaim == "" {
+		jwtConfig.UserClaim = jwt.DEFAULT_USER_CLAIM
+	}
+	for _, audience := range strings.Split(getDefaultValue(config, "authenticator.jwt.audiences", ""), ",") {
+		if audience = strings.TrimSpace(audience); audience != "" {
+			jwtConfig.Audiences = append(jwtConfig.Audiences, audience)
+		}
+	}
+	leeway := getDefaultValue(config, "authenticator.jwt.leeway", "0s")
+	if leeway == "" {
+		leeway = "0s"
+	}
+	var err error
+	if jwtConfig.Leeway, err = time.ParseDuration(leeway); err != nil || jwtConfig.Leeway < 0 {
+		return jwtConfig, fmt.Errorf("Invalid authenticator jwt leeway param: %v", leeway)
+	}
+
+	if jwksFile := getDefaultValue(config, "authenticator.jwt.jwks", ""); jwksFile != "" {
+		data, err := ioutil.ReadFile(jwksFile)
+		if err != nil {
+			return jwtConfig, err
+		}
+		keys, err := jwt.ParseJWKS(data)
+		if err != nil {
+			return jwtConfig, err
+		}
+		jwtConfig.Keys = append(jwtConfig.Keys, keys...)
+	}
+	if pemFile := getDefaultValue(config, "authenticator.jwt.pem", ""); pemFile != "" {
+		data, err := ioutil.ReadFile(pemFile)
+		if err != nil {
+			return jwtConfig, err
+		}
+		keys, err := jwt.ParsePEM(data)
+		if err != nil {
+			return jwtConfig, err
+		}
+		jwtConfig.Keys = append(jwtConfig.Keys, keys...)
+	}
+	if secret := getDefaultValue(config, "authenticator.jwt.secret", ""); secret != "" {
+		jwtConfig.Keys = append(jwtConfig.Keys, jwt.Key{Key: []byte(secret)})
+	}
+	if len(jwtConfig.Keys) < 1 {
+		return jwtConfig, errors.New("JWT authenticator configured without keys, jwks, pem or secret params needed")
+	}
+	return jwtConfig, nil
+}
+
+// This aux method returns the bcrypt password hashes of the admin accounts configured, without the disabled ones.
+// Admins are read from username and password params, with a plaintext password that is hashed here, and from users
+// param, with format "user1:hash1,user2:hash2"
+func getAdminAccounts(config *toml.TomlTree, wc *WorkerConfig) (map[string][]byte, error) {
+	admins := make(map[string][]byte)
+	adminUser := getDefaultValue(config, "admin.username", "")
+	adminPassword := getDefaultValue(config, "admin.password", "")
+	if adminUser != "" || adminPassword != "" {
+		if len(strings.TrimSpace(adminUser)) < 1 || len(strings.TrimSpace(adminPassword)) < 1 {
+			return nil, fmt.Errorf("Admin user config unexpected adminUser:%v", adminUser)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		admins[adminUser] = hash
+	}
+	if adminsConfig := getDefaultValue(config, "admin.users", ""); adminsConfig != "" {
+		for _, account := range strings.Split(adminsConfig, ",") {
+			credentials := strings.SplitN(strings.TrimSpace(account), ":", 2)
+			if len(credentials) != 2 || len(strings.TrimSpace(credentials[0])) < 1 {
+				return nil, fmt.Errorf("Admin user config unexpected: %v", credentials[0])
+			}
+			if _, ok := admins[credentials[0]]; ok {
+				return nil, fmt.Errorf("Admin user config repeated: %v", credentials[0])
+			}
+			if _, err := bcrypt.Cost([]byte(credentials[1])); err != nil {
+				return nil, fmt.Errorf("Invalid bcrypt hash for admin user %v: %v", credentials[0], err)
+			}
+			admins[credentials[0]] = []byte(credentials[1])
+		}
+	}
+	if len(admins) < 1 {
+		return nil, errors.New("No admin accounts configured, admin.username and admin.password or admin.users params needed")
+	}
+
+	// Disabled admins are removed, keeping their credentials in config
+	if disabledConfig := getDefaultValue(config, "admin.disabled", ""); disabledConfig != "" {
+		for _, username := range strings.Split(disabledConfig, ",") {
+			delete(admins, strings.TrimSpace(username))
+		}
+	}
+	wc.Admins = []string{}
+	for username := range admins {
+		wc.Admins = append(wc.Admins, username)
+	}
+	sort.Strings(wc.Admins)
+	return admins, nil
+}
+
+// This aux method returns the bcrypt password hashes of break-glass accounts configured, with format "user1:hash1,user2:hash2"
+func getBreakGlassUsers(config *toml.TomlTree, admins map[string][]byte, wc *WorkerConfig) (map[string][]byte, error) {
+	breakGlassUsers := make(map[string][]byte)
+	wc.BreakGlassUsers = nil
+	if breakGlassConfig := getDefaultValue(config, "breakglass.users", ""); breakGlassConfig != "" {
+		for _, account := range strings.Split(breakGlassConfig, ",") {
+			credentials := strings.SplitN(strings.TrimSpace(account), ":", 2)
+			if len(credentials) != 2 || !api.IsValidUserExternalID(credentials[0]) || len(credentials[1]) < 1 ||
+				admins[credentials[0]] != nil {
+				return nil, fmt.Errorf("Break-glass user config unexpected: %v", credentials[0])
+			}
+			if _, err := bcrypt.Cost([]byte(credentials[1])); err != nil {
+				return nil, fmt.Errorf("Invalid bcrypt hash for break-glass user %v: %v", credentials[0], err)
+			}
+			breakGlassUsers[credentials[0]] = []byte(credentials[1])
+			wc.BreakGlassUsers = append(wc.BreakGlassUsers, credentials[0])
+		}
+	}
+	return breakGlassUsers, nil
+}
+
+// Configuration keys that can be changed by a reload, by prefix
+var reloadableConfigKeys = []string{
+	"logger.",
+	"authenticator.",
+	"admin.",
+	"breakglass.users",
+	"database.postgres.idleconns",
+	"database.postgres.maxopenconns",
+	"database.postgres.connttl",
+}
+
+// tomlSettings reads the settings of a middleware from its table in the configuration
+type tomlSettings struct {
+	config *toml.TomlTree
+	table  string
+}
+
+func (s tomlSettings) Get(key string, def string) string {
+	return getDefaultValue(s.config, s.table+"."+key, def)
+}
+
+func (s tomlSettings) Tables(key string) []string {
+	if tree, ok := s.config.Get(s.table + "." + key).(*toml.TomlTree); ok {
+		return tree.Keys()
+	}
+	return nil
+}
+
+// This aux method creates the middleware handler with the pipeline configured, or the default one. Each middleware
+// reads its settings from [middlewares.{name}] table, provided middlewares are already created
+func newMiddlewareHandler(config *toml.TomlTree, defaultPipeline string,
+	provided map[string]middleware.Middleware) (*middleware.MiddlewareHandler, error) {
+	pipeline := []string{}
+	for _, name := range strings.Split(getDefaultValue(config, "middlewares.pipeline", defaultPipeline), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			pipeline = append(pipeline, name)
+		}
+	}
+	return middleware.NewMiddlewareHandler(pipeline, func(name string) middleware.Settings {
+		return tomlSettings{config: config, table: "middlewares." + name}
+	}, provided)
+}
+
+// This aux method returns the keys with a different value in both configurations that can't be reloaded
+func getChangedConfigKeys(current *toml.TomlTree, config *toml.TomlTree) []string {
+	currentValues := make(map[string]interface{})
+	newValues := make(map[string]interface{})
+	if current != nil {
+		flattenConfig(current, "", currentValues)
+	}
+	flattenConfig(config, "", newValues)
+
+	// Keys of both configurations, a missing key is a change too
+	allKeys := make(map[string]bool)
+	for key := range currentValues {
+		allKeys[key] = true
+	}
+	for key := range newValues {
+		allKeys[key] = true
+	}
+
+	keys := []string{}
+	for key := range allKeys {
+		if isReloadableConfigKey(key) || reflect.DeepEqual(currentValues[key], newValues[key]) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// This aux method puts the values of the configuration tree in values, by dotted key
+func flattenConfig(tree *toml.TomlTree, prefix string, values map[string]interface{}) {
+	for _, key := range tree.Keys() {
+		if subtree, ok := tree.Get(key).(*toml.TomlTree); ok {
+			flattenConfig(subtree, prefix+key+".", values)
+		} else {
+			values[prefix+key] = tree.Get(key)
+		}
+	}
+}
+
+func isReloadableConfigKey(key string) bool {
+	for _, prefix := range reloadableConfigKeys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// This aux method returns the configured time to drain in-flight requests on shutdown
+func getShutdownTimeout(config *toml.TomlTree) (time.Duration, error) {
+	shutdownTimeoutParam := getDefaultValue(config, "server.shutdown-timeout", "30s")
+	shutdownTimeout, err := time.ParseDuration(shutdownTimeoutParam)
+	if err != nil || shutdownTimeout <= 0 {
+		return 0, fmt.Errorf("Invalid server shutdown-timeout param: %v", shutdownTimeoutParam)
+	}
+	return shutdownTimeout, nil
+}
+
+// This aux method checks the database connection
+func pingDB() error {
+	if db == nil {
+		return errors.New("Database not connected")
+	}
+	return db.Ping()
+}
+
+// This aux method sets the span exporter configured, tracing is disabled by default
+func initTracing(config *toml.TomlTree, serviceName string) error {
+	exporterType := getDefaultValue(config, "tracing.exporter", tracing.EXPORTER_NONE)
+	switch exporterType {
+	case tracing.EXPORTER_NONE, "":
+		tracing.SetExporter(nil)
+		return nil
+	case tracing.EXPORTER_STDOUT:
+		tracing.SetExporter(tracing.NewWriterExporter(os.Stdout))
+	case tracing.EXPORTER_FILE:
+		var err error
+		tracingFileDir := getDefaultValue(config, "tracing.file.dir", "/tmp/foulkon-traces.log")
+		tracingFile, err = os.OpenFile(tracingFileDir, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
+		if err != nil {
+			return err
+		}
+		tracing.SetExporter(tracing.NewWriterExporter(tracingFile))
+	case tracing.EXPORTER_OTLP:
+		endpoint, err := getMandatoryValue(config, "tracing.otlp.endpoint")
+		if err != nil {
+			return err
+		}
+		otlpInterval := getDefaultValue(config, "tracing.otlp.interval", "5s")
+		interval, err := time.ParseDuration(otlpInterval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("Invalid tracing otlp interval param: %v", otlpInterval)
+		}
+		otlpTimeout := getDefaultValue(config, "tracing.otlp.timeout", "10s")
+		timeout, err := time.ParseDuration(otlpTimeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("Invalid tracing otlp timeout param: %v", otlpTimeout)
+		}
+		serviceName = getDefaultValue(config, "tracing.otlp.servicename", serviceName)
+		exporter := tracing.NewOtlpExporter(strings.TrimSuffix(endpoint, "/"), serviceName, interval, timeout)
+		exporter.ErrorFunc = func(err error) {
+			api.Log.Errorf("Unexpected error exporting spans: %v", err)
+		}
+		tracing.SetExporter(exporter)
+	default:
+		return fmt.Errorf("Unexpected tracing exporter value in configuration file: '%s'", exporterType)
+	}
+	api.Log.Infof("Tracing enabled with exporter: %v", exporterType)
+	return nil
+}
+
+// This aux method closes the tracing file if it is used
+func closeTracing() error {
+	if tracingFile != nil {
+		return tracingFile.Close()
+	}
+	return nil
+}
+
 // This aux method returns mandatory config value or any error occurred
 func getMandatoryValue(config *toml.TomlTree, key string) (string, error) {
 	if !config.Has(key) {
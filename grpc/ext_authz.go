@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/grpc/envoy"
+	foulkonhttp "github.com/Tecsisa/foulkon/http"
+	"github.com/Tecsisa/foulkon/middleware"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Envoy check method, authenticated with the checked request headers instead of gRPC metadata
+	EXT_AUTHZ_CHECK_METHOD = "/envoy.service.auth.v3.Authorization/Check"
+)
+
+// ExtAuthzServer implements the Envoy external authorization service. Checked requests are authenticated
+// by the worker middlewares and matched with the proxy resources, like the HTTP check endpoint
+type ExtAuthzServer struct {
+	handler http.Handler
+}
+
+// NewExtAuthzServer returns an ExtAuthzServer that checks the requests with the worker
+func NewExtAuthzServer(worker *foulkon.Worker) *ExtAuthzServer {
+	return &ExtAuthzServer{
+		handler: worker.MiddlewareHandler.Handle(foulkonhttp.NewExtAuthzHandler(worker)),
+	}
+}
+
+// SERVICE IMPLEMENTATION
+
+// Check answers if the request received by Envoy is allowed. Allowed requests are forwarded with the
+// authenticated user in X-FOULKON-USER-ID header, denied requests are answered with the worker response
+func (es *ExtAuthzServer) Check(ctx context.Context, request *envoy.CheckRequest) (*envoy.CheckResponse, error) {
+	httpRequest := request.GetAttributes().GetRequest().GetHttp()
+	if httpRequest == nil {
+		return nil, status.Error(codes.InvalidArgument, "Missing HTTP request attributes")
+	}
+	r, err := newCheckRequest(httpRequest, request.GetAttributes().GetSource())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	rw := newResponseWriter()
+	es.handler.ServeHTTP(rw, r)
+
+	if rw.status == http.StatusOK {
+		// User identifier replaces the values sent by the client, instead of being appended to them
+		userHeader := newHeaderValueOption(middleware.USER_ID_HEADER, rw.header.Get(middleware.USER_ID_HEADER))
+		userHeader.Append = &envoy.BoolValue{Value: false}
+		return &envoy.CheckResponse{
+			Status: &envoy.Status{Code: int32(codes.OK)},
+			OkResponse: &envoy.OkHttpResponse{
+				Headers: []*envoy.HeaderValueOption{userHeader},
+			},
+		}, nil
+	}
+
+	var code codes.Code
+	switch rw.status {
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	default:
+		code = codes.Internal
+	}
+	headers := []*envoy.HeaderValueOption{}
+	for key, values := range rw.header {
+		for _, value := range values {
+			headers = append(headers, newHeaderValueOption(key, value))
+		}
+	}
+	return &envoy.CheckResponse{
+		Status: &envoy.Status{
+			Code:    int32(code),
+			Message: http.StatusText(rw.status),
+		},
+		DeniedResponse: &envoy.DeniedHttpResponse{
+			Status:  &envoy.HttpStatus{Code: int32(rw.status)},
+			Headers: headers,
+			Body:    rw.body.String(),
+		},
+	}, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// newCheckRequest builds the HTTP request checked by the worker from Envoy request attributes
+func newCheckRequest(httpRequest *envoy.AttributeContext_HttpRequest, source *envoy.AttributeContext_Peer) (*http.Request, error) {
+	// Envoy path includes the query string
+	checkURL, err := url.ParseRequestURI(httpRequest.Path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequest(httpRequest.Method, checkURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Host = httpRequest.Host
+	// Checked requests come from Envoy, so the client address is the downstream address it received them from
+	if socketAddress := source.GetAddress().GetSocketAddress(); socketAddress.GetAddress() != "" {
+		r.RemoteAddr = net.JoinHostPort(socketAddress.GetAddress(), strconv.Itoa(int(socketAddress.GetPortValue())))
+	}
+	for key, value := range httpRequest.Headers {
+		// Pseudo headers aren't HTTP headers, and user identifier is set by the authenticator, never by the client
+		if strings.HasPrefix(key, ":") || strings.EqualFold(key, middleware.USER_ID_HEADER) {
+			continue
+		}
+		r.Header.Set(key, value)
+	}
+
+	return r, nil
+}
+
+func newHeaderValueOption(key string, value string) *envoy.HeaderValueOption {
+	return &envoy.HeaderValueOption{
+		Header: &envoy.HeaderValue{
+			Key:   key,
+			Value: value,
+		},
+	}
+}
@@ -0,0 +1,221 @@
+package grpc
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/grpc/envoy"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+func TestExtAuthzServer_Check(t *testing.T) {
+	proxyResources := []api.ProxyResource{
+		{
+			ID:   "ID1",
+			Name: "example",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:   "http://upstream",
+				Path:   "/example/:id",
+				Method: http.MethodGet,
+				Urn:    "urn:ews:example:instance1:resource/{id}",
+				Action: "example:get",
+			},
+		},
+		{
+			ID:   "ID2",
+			Name: "public",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:   "http://upstream",
+				Path:   "/public/*rest",
+				Method: http.MethodGet,
+				Urn:    "urn:ews:example:instance1:public",
+				Action: "example:read",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		// Request
+		request *envoy.CheckRequest
+		// Expected result
+		expectedCode       codes.Code
+		expectedStatus     int32
+		expectedHttpStatus int32
+		expectedUserID     string
+		expectedResources  []string
+		// Manager Results
+		getAuthorizedExternalResourcesResult []string
+		// Manager Errors
+		getAuthorizedExternalResourcesErr error
+	}{
+		"OkCaseAllowed": {
+			request:                              newEnvoyCheckRequest(http.MethodGet, "/example/res1?query=value", TEST_TOKEN),
+			expectedCode:                         codes.OK,
+			expectedStatus:                       int32(codes.OK),
+			expectedUserID:                       "userID",
+			expectedResources:                    []string{"urn:ews:example:instance1:resource/res1"},
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/res1"},
+		},
+		"OkCaseDenied": {
+			request:            newEnvoyCheckRequest(http.MethodGet, "/example/res1", TEST_TOKEN),
+			expectedCode:       codes.OK,
+			expectedStatus:     int32(codes.PermissionDenied),
+			expectedHttpStatus: http.StatusForbidden,
+			expectedResources:  []string{"urn:ews:example:instance1:resource/res1"},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"OkCaseResourceNotFound": {
+			request:            newEnvoyCheckRequest(http.MethodPost, "/example/res1", TEST_TOKEN),
+			expectedCode:       codes.OK,
+			expectedStatus:     int32(codes.PermissionDenied),
+			expectedHttpStatus: http.StatusForbidden,
+		},
+		"OkCaseUnauthenticated": {
+			request:            newEnvoyCheckRequest(http.MethodGet, "/example/res1", "Bearer invalid"),
+			expectedCode:       codes.OK,
+			expectedStatus:     int32(codes.Unauthenticated),
+			expectedHttpStatus: http.StatusUnauthorized,
+		},
+		"OkCaseInternalServerError": {
+			request:            newEnvoyCheckRequest(http.MethodGet, "/example/res1", TEST_TOKEN),
+			expectedCode:       codes.OK,
+			expectedStatus:     int32(codes.Internal),
+			expectedHttpStatus: http.StatusInternalServerError,
+			expectedResources:  []string{"urn:ews:example:instance1:resource/res1"},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"OkCaseDotSegments": {
+			request:            newEnvoyCheckRequest(http.MethodGet, "/public/../example/res1", TEST_TOKEN),
+			expectedCode:       codes.OK,
+			expectedStatus:     int32(codes.PermissionDenied),
+			expectedHttpStatus: http.StatusForbidden,
+		},
+		"OkCaseEncodedDotSegments": {
+			request:            newEnvoyCheckRequest(http.MethodGet, "/public/%2e%2E%2Fexample/res1", TEST_TOKEN),
+			expectedCode:       codes.OK,
+			expectedStatus:     int32(codes.PermissionDenied),
+			expectedHttpStatus: http.StatusForbidden,
+		},
+		"OkCaseRepeatedSlashes": {
+			request:            newEnvoyCheckRequest(http.MethodGet, "/public//example", TEST_TOKEN),
+			expectedCode:       codes.OK,
+			expectedStatus:     int32(codes.PermissionDenied),
+			expectedHttpStatus: http.StatusForbidden,
+		},
+		"ErrorCaseMissingAttributes": {
+			request:      &envoy.CheckRequest{},
+			expectedCode: codes.InvalidArgument,
+		},
+		"ErrorCaseInvalidPath": {
+			request:      newEnvoyCheckRequest(http.MethodGet, "example", TEST_TOKEN),
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for n, test := range testcases {
+		*testApi = TestAPI{
+			proxyResources:      proxyResources,
+			authorizedResources: test.getAuthorizedExternalResourcesResult,
+			err:                 test.getAuthorizedExternalResourcesErr,
+		}
+
+		// Envoy doesn't send credentials in gRPC metadata
+		response, err := envoyClient.Check(context.Background(), test.request)
+		assert.Equal(t, test.expectedCode, errorCode(err), "Error in test case %v", n)
+		if test.expectedCode != codes.OK {
+			continue
+		}
+		assert.Equal(t, test.expectedResources, testApi.resources, "Error in test case %v", n)
+		assert.Equal(t, test.expectedStatus, response.Status.Code, "Error in test case %v", n)
+		if test.expectedStatus == int32(codes.OK) {
+			assert.Nil(t, response.DeniedResponse, "Error in test case %v", n)
+			assert.Equal(t, []*envoy.HeaderValueOption{
+				{
+					Header: &envoy.HeaderValue{
+						Key:   middleware.USER_ID_HEADER,
+						Value: test.expectedUserID,
+					},
+					Append: &envoy.BoolValue{Value: false},
+				},
+			}, response.OkResponse.Headers, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, response.OkResponse, "Error in test case %v", n)
+		assert.Equal(t, test.expectedHttpStatus, response.DeniedResponse.Status.Code, "Error in test case %v", n)
+	}
+}
+
+func TestNewCheckRequest(t *testing.T) {
+	testcases := map[string]struct {
+		// Request
+		source *envoy.AttributeContext_Peer
+		// Expected result
+		expectedRemoteAddr string
+	}{
+		"OkCaseSourceAddress": {
+			source: &envoy.AttributeContext_Peer{
+				Address: &envoy.Address{
+					SocketAddress: &envoy.SocketAddress{
+						Address:   "10.0.0.1",
+						PortValue: 43210,
+					},
+				},
+			},
+			expectedRemoteAddr: "10.0.0.1:43210",
+		},
+		"OkCaseSourceIPv6Address": {
+			source: &envoy.AttributeContext_Peer{
+				Address: &envoy.Address{
+					SocketAddress: &envoy.SocketAddress{
+						Address:   "2001:db8::1",
+						PortValue: 43210,
+					},
+				},
+			},
+			expectedRemoteAddr: "[2001:db8::1]:43210",
+		},
+		"OkCaseMissingSource": {
+			expectedRemoteAddr: "",
+		},
+	}
+
+	for n, test := range testcases {
+		request := newEnvoyCheckRequest(http.MethodGet, "/example/res1", TEST_TOKEN)
+		r, err := newCheckRequest(request.Attributes.Request.Http, test.source)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedRemoteAddr, r.RemoteAddr, "Error in test case %v", n)
+		assert.Equal(t, "", r.Header.Get(middleware.USER_ID_HEADER), "Error in test case %v", n)
+	}
+}
+
+// Aux functions
+
+func newEnvoyCheckRequest(method string, path string, authorization string) *envoy.CheckRequest {
+	return &envoy.CheckRequest{
+		Attributes: &envoy.AttributeContext{
+			Request: &envoy.AttributeContext_Request{
+				Http: &envoy.AttributeContext_HttpRequest{
+					Method: method,
+					Path:   path,
+					Host:   "example.com",
+					Headers: map[string]string{
+						":authority":        "example.com",
+						"authorization":     authorization,
+						"x-foulkon-user-id": "spoofed",
+					},
+				},
+			},
+		},
+	}
+}
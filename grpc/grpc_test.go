@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	logrusTest "github.com/Sirupsen/logrus/hooks/test"
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/grpc/authz"
+	"github.com/Tecsisa/foulkon/grpc/envoy"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/Tecsisa/foulkon/middleware/auth"
+	"github.com/Tecsisa/foulkon/middleware/xrequestid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	// Test user token accepted by test connector
+	TEST_TOKEN = "Bearer token"
+)
+
+var testApi *TestAPI
+var client authz.AuthzClient
+var envoyClient envoy.AuthorizationClient
+
+// Test API that implements authorization and internal proxy api interfaces
+type TestAPI struct {
+	requestInfo api.RequestInfo
+
+	action    string
+	resources []string
+	urnPrefix string
+
+	authorizedResources    []string
+	authorizedRestrictions *api.AuthorizedRestrictions
+	proxyResources         []api.ProxyResource
+	err                    error
+}
+
+func (t *TestAPI) GetAuthorizedUsers(requestInfo api.RequestInfo, resourceUrn string, action string, users []api.User) ([]api.User, error) {
+	return nil, nil
+}
+
+func (t *TestAPI) GetAuthorizedGroups(requestInfo api.RequestInfo, resourceUrn string, action string, groups []api.Group) ([]api.Group, error) {
+	return nil, nil
+}
+
+func (t *TestAPI) GetAuthorizedPolicies(requestInfo api.RequestInfo, resourceUrn string, action string, policies []api.Policy) ([]api.Policy, error) {
+	return nil, nil
+}
+
+func (t *TestAPI) GetAuthorizedProxyResources(requestInfo api.RequestInfo, resourceUrn string, action string, proxyResources []api.ProxyResource) ([]api.ProxyResource, error) {
+	return nil, nil
+}
+
+func (t *TestAPI) GetAuthorizedExternalResources(requestInfo api.RequestInfo, action string, resources []string) ([]string, error) {
+	t.requestInfo = requestInfo
+	t.action = action
+	t.resources = resources
+	return t.authorizedResources, t.err
+}
+
+func (t *TestAPI) GetAuthorizedRestrictions(requestInfo api.RequestInfo, action string, urnPrefix string, column string) (*api.AuthorizedRestrictions, error) {
+	t.requestInfo = requestInfo
+	t.action = action
+	t.urnPrefix = urnPrefix
+	return t.authorizedRestrictions, t.err
+}
+
+func (t *TestAPI) GetAuthzSnapshot(requestInfo api.RequestInfo) (*api.AuthzSnapshot, error) {
+	return nil, nil
+}
+
+func (t *TestAPI) GetProxyResources() ([]api.ProxyResource, error) {
+	return t.proxyResources, nil
+}
+
+// Test connector that only authenticates requests with the test token
+type TestConnector struct {
+	userID string
+}
+
+func (tc *TestConnector) Authenticate(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != TEST_TOKEN {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (tc TestConnector) RetrieveUserID(r http.Request) string {
+	return tc.userID
+}
+
+// Main Test that executes at first time and create all necessary data to work
+func TestMain(m *testing.M) {
+	// Create logger
+	api.Log, _ = logrusTest.NewNullLogger()
+
+	testApi = &TestAPI{}
+
+	// Middlewares
+	middlewares := make(map[string]middleware.Middleware)
+	adminPassword, err := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+	middlewares[middleware.AUTHENTICATOR_MIDDLEWARE] = auth.NewAuthenticatorMiddleware(&TestConnector{userID: "userID"},
+		map[string][]byte{"admin": adminPassword}, map[string][]byte{})
+	middlewares[middleware.XREQUESTID_MIDDLEWARE] = xrequestid.NewXRequestIdMiddleware()
+
+	worker := &foulkon.Worker{
+		MiddlewareHandler: &middleware.MiddlewareHandler{Middlewares: middlewares},
+		AuthzApi:          testApi,
+		InternalProxyApi:  testApi,
+	}
+
+	// Serve gRPC authorization service in memory
+	ws := NewWorker(worker)
+	if err := ws.Configuration(); err != nil {
+		panic(err)
+	}
+	lis := bufconn.Listen(1024 * 1024)
+	go ws.Serve(lis)
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	if err != nil {
+		panic(err)
+	}
+	client = authz.NewAuthzClient(conn)
+	envoyClient = envoy.NewAuthorizationClient(conn)
+
+	// Run tests
+	result := m.Run()
+
+	ws.Stop()
+	conn.Close()
+
+	// Exit tests.
+	os.Exit(result)
+}
+
+// Aux functions
+
+func authContext(authorization string) context.Context {
+	if authorization == "" {
+		return context.Background()
+	}
+	return metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+func adminAuthorization() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:admin"))
+}
+
+func errorCode(err error) codes.Code {
+	s, _ := status.FromError(err)
+	return s.Code()
+}
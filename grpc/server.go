@@ -0,0 +1,227 @@
+package grpc
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/grpc/authz"
+	"github.com/Tecsisa/foulkon/grpc/envoy"
+	"github.com/Tecsisa/foulkon/middleware"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// WorkerServer struct with gRPC authorization server configuration
+type WorkerServer struct {
+	addr     string
+	certFile string
+	keyFile  string
+
+	worker *foulkon.Worker
+
+	*grpc.Server
+}
+
+// AuthzServer implements the gRPC authorization service using the worker APIs
+type AuthzServer struct {
+	worker *foulkon.Worker
+}
+
+type requestInfoKey struct{}
+
+// NewWorker returns a new gRPC WorkerServer
+func NewWorker(worker *foulkon.Worker) *WorkerServer {
+	return &WorkerServer{
+		addr:     worker.Host + ":" + worker.GrpcPort,
+		certFile: worker.CertFile,
+		keyFile:  worker.KeyFile,
+		worker:   worker,
+	}
+}
+
+// Configuration creates the gRPC server and registers the authorization services
+func (ws *WorkerServer) Configuration() error {
+	authzServer := &AuthzServer{worker: ws.worker}
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(authzServer.authenticate)}
+	if ws.certFile != "" || ws.keyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(ws.certFile, ws.keyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	ws.Server = grpc.NewServer(opts...)
+	authz.RegisterAuthzServer(ws.Server, authzServer)
+	envoy.RegisterAuthorizationServer(ws.Server, NewExtAuthzServer(ws.worker))
+
+	return nil
+}
+
+// Run starts a gRPC WorkerServer
+func (ws *WorkerServer) Run() error {
+	lis, err := net.Listen("tcp", ws.addr)
+	if err != nil {
+		return err
+	}
+
+	return ws.Serve(lis)
+}
+
+// Shutdown stops accepting connections and waits for pending RPCs until ctx is done, closing them then
+func (ws *WorkerServer) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		ws.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		ws.Stop()
+		return ctx.Err()
+	}
+}
+
+// SERVICE IMPLEMENTATION
+
+// Authorize checks if authenticated user is allowed to do the action over the resource
+func (as *AuthzServer) Authorize(ctx context.Context, request *authz.AuthorizeRequest) (*authz.AuthorizeResponse, error) {
+	requestInfo := getRequestInfo(ctx)
+	resources, err := as.worker.AuthzApi.GetAuthorizedExternalResources(requestInfo, request.Action, []string{request.Resource})
+	if err != nil {
+		// A denied resource is a valid answer
+		if apiError := err.(*api.Error); apiError.Code == api.UNAUTHORIZED_RESOURCES_ERROR {
+			return &authz.AuthorizeResponse{Allowed: false}, nil
+		}
+		return nil, processError(requestInfo, err)
+	}
+
+	return &authz.AuthorizeResponse{Allowed: len(resources) > 0}, nil
+}
+
+// BatchAuthorize returns the resources that authenticated user is allowed to do the action over
+func (as *AuthzServer) BatchAuthorize(ctx context.Context, request *authz.BatchAuthorizeRequest) (*authz.BatchAuthorizeResponse, error) {
+	requestInfo := getRequestInfo(ctx)
+	resources, err := as.worker.AuthzApi.GetAuthorizedExternalResources(requestInfo, request.Action, request.Resources)
+	if err != nil {
+		// No allowed resources is a valid answer
+		if apiError := err.(*api.Error); apiError.Code == api.UNAUTHORIZED_RESOURCES_ERROR {
+			return &authz.BatchAuthorizeResponse{ResourcesAllowed: []string{}}, nil
+		}
+		return nil, processError(requestInfo, err)
+	}
+
+	return &authz.BatchAuthorizeResponse{ResourcesAllowed: resources}, nil
+}
+
+// Explain returns the restrictions that apply to authenticated user for the action under the urn prefix
+func (as *AuthzServer) Explain(ctx context.Context, request *authz.ExplainRequest) (*authz.ExplainResponse, error) {
+	requestInfo := getRequestInfo(ctx)
+	authorized, err := as.worker.AuthzApi.GetAuthorizedRestrictions(requestInfo, request.Action, request.UrnPrefix, "")
+	if err != nil {
+		return nil, processError(requestInfo, err)
+	}
+
+	return &authz.ExplainResponse{
+		Restrictions: &authz.Restrictions{
+			AllowedUrnPrefixes: authorized.Restrictions.AllowedUrnPrefixes,
+			AllowedFullUrns:    authorized.Restrictions.AllowedFullUrns,
+			DeniedUrnPrefixes:  authorized.Restrictions.DeniedUrnPrefixes,
+			DeniedFullUrns:     authorized.Restrictions.DeniedFullUrns,
+		},
+	}, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// authenticate runs the worker middlewares over the request metadata, so gRPC requests are
+// authenticated in the same way as HTTP requests
+func (as *AuthzServer) authenticate(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	// Envoy checks authenticate the checked request
+	if info.FullMethod == EXT_AUTHZ_CHECK_METHOD {
+		return handler(ctx, request)
+	}
+
+	r, err := http.NewRequest(http.MethodPost, info.FullMethod, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			// User identifier is set by the authenticator, never by the client
+			if strings.HasPrefix(key, ":") || strings.EqualFold(key, middleware.USER_ID_HEADER) {
+				continue
+			}
+			for _, value := range values {
+				r.Header.Add(key, value)
+			}
+		}
+	}
+
+	var requestInfo *api.RequestInfo
+	as.worker.MiddlewareHandler.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated := as.worker.GetRequestInfo(r)
+		requestInfo = &authenticated
+	})).ServeHTTP(newResponseWriter(), r)
+
+	if requestInfo == nil {
+		return nil, status.Error(codes.Unauthenticated, "Authentication failed")
+	}
+
+	return handler(context.WithValue(ctx, requestInfoKey{}, *requestInfo), request)
+}
+
+func getRequestInfo(ctx context.Context) api.RequestInfo {
+	requestInfo, _ := ctx.Value(requestInfoKey{}).(api.RequestInfo)
+	return requestInfo
+}
+
+// processError logs the API error and transforms it into a gRPC status error
+func processError(requestInfo api.RequestInfo, err error) error {
+	apiError := err.(*api.Error)
+	api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, apiError)
+	switch apiError.Code {
+	case api.INVALID_PARAMETER_ERROR, api.REGEX_NO_MATCH:
+		return status.Error(codes.InvalidArgument, apiError.Message)
+	case api.UNAUTHORIZED_RESOURCES_ERROR:
+		return status.Error(codes.PermissionDenied, apiError.Message)
+	default:
+		return status.Error(codes.Internal, apiError.Message)
+	}
+}
+
+// responseWriter records the response written by the middlewares and handlers
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{
+		header: http.Header{},
+		status: http.StatusOK,
+	}
+}
+
+func (rw *responseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	return rw.body.Write(b)
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	rw.status = status
+}
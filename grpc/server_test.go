@@ -0,0 +1,365 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/grpc/authz"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+func TestAuthzServer_Authorize(t *testing.T) {
+	testcases := map[string]struct {
+		// Request metadata
+		authorization string
+		// Request
+		request *authz.AuthorizeRequest
+		// Expected result
+		expectedResponse    *authz.AuthorizeResponse
+		expectedCode        codes.Code
+		expectedRequestInfo api.RequestInfo
+		// Manager Results
+		getAuthorizedExternalResourcesResult []string
+		// Manager Errors
+		getAuthorizedExternalResourcesErr error
+	}{
+		"OkCaseAllowed": {
+			authorization: TEST_TOKEN,
+			request: &authz.AuthorizeRequest{
+				Action:   "example:get",
+				Resource: "urn:ews:example:instance1:resource/get",
+			},
+			expectedResponse: &authz.AuthorizeResponse{
+				Allowed: true,
+			},
+			expectedCode: codes.OK,
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "userID",
+			},
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/get"},
+		},
+		"OkCaseAdmin": {
+			authorization: adminAuthorization(),
+			request: &authz.AuthorizeRequest{
+				Action:   "example:get",
+				Resource: "urn:ews:example:instance1:resource/get",
+			},
+			expectedResponse: &authz.AuthorizeResponse{
+				Allowed: true,
+			},
+			expectedCode: codes.OK,
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/get"},
+		},
+		"OkCaseDenied": {
+			authorization: TEST_TOKEN,
+			request: &authz.AuthorizeRequest{
+				Action:   "example:get",
+				Resource: "urn:ews:example:instance1:resource/get",
+			},
+			expectedResponse: &authz.AuthorizeResponse{
+				Allowed: false,
+			},
+			expectedCode: codes.OK,
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "userID",
+			},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthenticated": {
+			authorization: "Bearer invalid",
+			request: &authz.AuthorizeRequest{
+				Action:   "example:get",
+				Resource: "urn:ews:example:instance1:resource/get",
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		"ErrorCaseNoMetadata": {
+			request: &authz.AuthorizeRequest{
+				Action:   "example:get",
+				Resource: "urn:ews:example:instance1:resource/get",
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		"ErrorCaseInvalidParameter": {
+			authorization: TEST_TOKEN,
+			request: &authz.AuthorizeRequest{
+				Action:   "example:get",
+				Resource: "invalid",
+			},
+			expectedCode: codes.InvalidArgument,
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "userID",
+			},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			authorization: TEST_TOKEN,
+			request: &authz.AuthorizeRequest{
+				Action:   "example:get",
+				Resource: "urn:ews:example:instance1:resource/get",
+			},
+			expectedCode: codes.Internal,
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "userID",
+			},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		*testApi = TestAPI{
+			authorizedResources: test.getAuthorizedExternalResourcesResult,
+			err:                 test.getAuthorizedExternalResourcesErr,
+		}
+
+		response, err := client.Authorize(authContext(test.authorization), test.request)
+		assert.Equal(t, test.expectedCode, errorCode(err), "Error in test case %v", n)
+		if test.expectedCode != codes.OK {
+			continue
+		}
+		assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+
+		// Check authenticated request info
+		assert.Equal(t, test.expectedRequestInfo.Identifier, testApi.requestInfo.Identifier, "Error in test case %v", n)
+		assert.Equal(t, test.expectedRequestInfo.Admin, testApi.requestInfo.Admin, "Error in test case %v", n)
+		assert.NotEmpty(t, testApi.requestInfo.RequestID, "Error in test case %v", n)
+		assert.Equal(t, test.request.Action, testApi.action, "Error in test case %v", n)
+		assert.Equal(t, []string{test.request.Resource}, testApi.resources, "Error in test case %v", n)
+	}
+}
+
+func TestAuthzServer_BatchAuthorize(t *testing.T) {
+	testcases := map[string]struct {
+		// Request metadata
+		authorization string
+		// Request
+		request *authz.BatchAuthorizeRequest
+		// Expected result
+		expectedResponse *authz.BatchAuthorizeResponse
+		expectedCode     codes.Code
+		// Manager Results
+		getAuthorizedExternalResourcesResult []string
+		// Manager Errors
+		getAuthorizedExternalResourcesErr error
+	}{
+		"OkCase": {
+			authorization: TEST_TOKEN,
+			request: &authz.BatchAuthorizeRequest{
+				Action:    "example:get",
+				Resources: []string{"urn:ews:example:instance1:resource/get", "urn:ews:example:instance1:resource/list"},
+			},
+			expectedResponse: &authz.BatchAuthorizeResponse{
+				ResourcesAllowed: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedCode:                         codes.OK,
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/get"},
+		},
+		"OkCaseNoResourcesAllowed": {
+			authorization: TEST_TOKEN,
+			request: &authz.BatchAuthorizeRequest{
+				Action:    "example:get",
+				Resources: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedResponse: &authz.BatchAuthorizeResponse{
+				ResourcesAllowed: []string{},
+			},
+			expectedCode: codes.OK,
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthenticated": {
+			authorization: "Bearer invalid",
+			request: &authz.BatchAuthorizeRequest{
+				Action:    "example:get",
+				Resources: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		"ErrorCaseInvalidParameter": {
+			authorization: TEST_TOKEN,
+			request: &authz.BatchAuthorizeRequest{
+				Action:    "example:get",
+				Resources: []string{"invalid"},
+			},
+			expectedCode: codes.InvalidArgument,
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			authorization: TEST_TOKEN,
+			request: &authz.BatchAuthorizeRequest{
+				Action:    "example:get",
+				Resources: []string{"urn:ews:example:instance1:resource/get"},
+			},
+			expectedCode: codes.Internal,
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		*testApi = TestAPI{
+			authorizedResources: test.getAuthorizedExternalResourcesResult,
+			err:                 test.getAuthorizedExternalResourcesErr,
+		}
+
+		response, err := client.BatchAuthorize(authContext(test.authorization), test.request)
+		assert.Equal(t, test.expectedCode, errorCode(err), "Error in test case %v", n)
+		if test.expectedCode != codes.OK {
+			continue
+		}
+		assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		assert.Equal(t, test.request.Resources, testApi.resources, "Error in test case %v", n)
+	}
+}
+
+func TestAuthzServer_Explain(t *testing.T) {
+	testcases := map[string]struct {
+		// Request metadata
+		authorization string
+		// Request
+		request *authz.ExplainRequest
+		// Expected result
+		expectedResponse *authz.ExplainResponse
+		expectedCode     codes.Code
+		// Manager Results
+		getAuthorizedRestrictionsResult *api.AuthorizedRestrictions
+		// Manager Errors
+		getAuthorizedRestrictionsErr error
+	}{
+		"OkCase": {
+			authorization: TEST_TOKEN,
+			request: &authz.ExplainRequest{
+				Action:    "example:get",
+				UrnPrefix: "urn:ews:example:instance1:resource/",
+			},
+			expectedResponse: &authz.ExplainResponse{
+				Restrictions: &authz.Restrictions{
+					AllowedUrnPrefixes: []string{"urn:ews:example:instance1:resource/"},
+					DeniedFullUrns:     []string{"urn:ews:example:instance1:resource/secret"},
+				},
+			},
+			expectedCode: codes.OK,
+			getAuthorizedRestrictionsResult: &api.AuthorizedRestrictions{
+				Action:    "example:get",
+				UrnPrefix: "urn:ews:example:instance1:resource/",
+				Restrictions: &api.Restrictions{
+					AllowedUrnPrefixes: []string{"urn:ews:example:instance1:resource/"},
+					DeniedFullUrns:     []string{"urn:ews:example:instance1:resource/secret"},
+				},
+			},
+		},
+		"ErrorCaseUnauthenticated": {
+			authorization: "Bearer invalid",
+			request: &authz.ExplainRequest{
+				Action:    "example:get",
+				UrnPrefix: "urn:ews:example:instance1:resource/",
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		"ErrorCaseUnauthorizedError": {
+			authorization: TEST_TOKEN,
+			request: &authz.ExplainRequest{
+				Action:    "example:get",
+				UrnPrefix: "urn:ews:example:instance1:resource/",
+			},
+			expectedCode: codes.PermissionDenied,
+			getAuthorizedRestrictionsErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			authorization: TEST_TOKEN,
+			request: &authz.ExplainRequest{
+				Action:    "example:*",
+				UrnPrefix: "urn:ews:example:instance1:resource/",
+			},
+			expectedCode: codes.InvalidArgument,
+			getAuthorizedRestrictionsErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			authorization: TEST_TOKEN,
+			request: &authz.ExplainRequest{
+				Action:    "example:get",
+				UrnPrefix: "urn:ews:example:instance1:resource/",
+			},
+			expectedCode: codes.Internal,
+			getAuthorizedRestrictionsErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		*testApi = TestAPI{
+			authorizedRestrictions: test.getAuthorizedRestrictionsResult,
+			err:                    test.getAuthorizedRestrictionsErr,
+		}
+
+		response, err := client.Explain(authContext(test.authorization), test.request)
+		assert.Equal(t, test.expectedCode, errorCode(err), "Error in test case %v", n)
+		if test.expectedCode != codes.OK {
+			continue
+		}
+		assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		assert.Equal(t, test.request.UrnPrefix, testApi.urnPrefix, "Error in test case %v", n)
+	}
+}
+
+func TestWorkerServer_Shutdown(t *testing.T) {
+	ws := NewWorker(&foulkon.Worker{
+		Host:              "localhost",
+		GrpcPort:          "0",
+		MiddlewareHandler: &middleware.MiddlewareHandler{},
+	})
+	err := ws.Configuration()
+	assert.Nil(t, err, "Error in test")
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- ws.Run()
+	}()
+	// Wait server to listen
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = ws.Shutdown(ctx)
+	assert.Nil(t, err, "Error in test")
+
+	select {
+	case err := <-runErr:
+		assert.Nil(t, err, "Error in test")
+	case <-time.After(time.Second):
+		t.Error("Server didn't stop after shutdown")
+	}
+}
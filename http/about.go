@@ -27,10 +27,23 @@ type AuthConnectorConfig struct {
 	OidcProviders []api.OidcProvider `json:"oidcProviders,omitempty"`
 }
 
+type BreakGlassConfig struct {
+	Users      []string `json:"users,omitempty"`
+	SessionTTL int      `json:"ttl,omitempty"`
+}
+
+type ApprovalConfig struct {
+	Actions []string `json:"actions,omitempty"`
+	Orgs    []string `json:"orgs,omitempty"`
+	TTL     int      `json:"ttl,omitempty"`
+}
+
 type Config struct {
 	Logger        LoggerConfig        `json:"logger,omitempty"`
 	Database      DatabaseConfig      `json:"database,omitempty"`
 	AuthConnector AuthConnectorConfig `json:"authenticator,omitempty"`
+	BreakGlass    BreakGlassConfig    `json:"breakglass,omitempty"`
+	Approval      ApprovalConfig      `json:"approval,omitempty"`
 	Version       string              `json:"version,omitempty"`
 }
 
@@ -54,7 +67,7 @@ func (wh *WorkerHandler) HandleGetCurrentConfig(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	wc := wh.worker.Config
+	wc := wh.worker.GetConfig()
 	// Get Logger config
 	logger := LoggerConfig{
 		Type:          wc.LoggerType,
@@ -76,11 +89,26 @@ func (wh *WorkerHandler) HandleGetCurrentConfig(w http.ResponseWriter, r *http.R
 		OidcProviders: wc.OidcProviders,
 	}
 
+	// Get break-glass config
+	breakGlass := BreakGlassConfig{
+		Users:      wc.BreakGlassUsers,
+		SessionTTL: wc.BreakGlassSessionTTL,
+	}
+
+	// Get approval config
+	approval := ApprovalConfig{
+		Actions: wc.ApprovalActions,
+		Orgs:    wc.ApprovalOrgs,
+		TTL:     wc.ApprovalTTL,
+	}
+
 	// Config Response
 	response := Config{
 		Logger:        logger,
 		Database:      db,
 		AuthConnector: auth,
+		BreakGlass:    breakGlass,
+		Approval:      approval,
 		Version:       wc.Version,
 	}
 
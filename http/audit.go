@@ -0,0 +1,38 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+// RESPONSES
+
+type ListAuditEventsResponse struct {
+	Events []api.AuditEvent `json:"events,omitempty"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+	Total  int              `json:"total"`
+}
+
+// HANDLERS
+
+func (wh *WorkerHandler) HandleListAuditEvents(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call audit API to list events
+	result, total, err := wh.worker.AuditApi.ListAuditEvents(requestInfo, filterData)
+	// Create response
+	response := &ListAuditEventsResponse{
+		Events: result,
+		Offset: filterData.Offset,
+		Limit:  filterData.Limit,
+		Total:  total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
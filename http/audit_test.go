@@ -0,0 +1,184 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleListAuditEvents(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	testcases := map[string]struct {
+		// API method args
+		queryParams map[string]string
+		// Expected result
+		expectedFilter     *api.Filter
+		expectedStatusCode int
+		expectedResponse   ListAuditEventsResponse
+		expectedError      api.Error
+		// Manager Results
+		listAuditEventsResult []api.AuditEvent
+		totalAuditEvents      int
+		// Manager Errors
+		listAuditEventsErr error
+	}{
+		"OkCase": {
+			queryParams: map[string]string{
+				"Actor":  "admin",
+				"Urn":    api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+				"Action": api.USER_ACTION_UPDATE_USER,
+				"From":   now.Add(-time.Hour).Format(time.RFC3339),
+				"To":     now.Format(time.RFC3339),
+				"Limit":  "10",
+			},
+			expectedFilter: &api.Filter{
+				Actor:     "admin",
+				EntityUrn: api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+				Action:    api.USER_ACTION_UPDATE_USER,
+				From:      now.Add(-time.Hour),
+				To:        now,
+				Limit:     10,
+			},
+			expectedStatusCode: http.StatusOK,
+			listAuditEventsResult: []api.AuditEvent{
+				{
+					ID:        "event1",
+					Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+					Actor:     "admin",
+					Action:    api.USER_ACTION_UPDATE_USER,
+					EntityUrn: api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+					Urn:       api.CreateUrn("", api.RESOURCE_AUDIT_EVENT, "/", "event1"),
+					CreateAt:  now,
+				},
+			},
+			totalAuditEvents: 1,
+			expectedResponse: ListAuditEventsResponse{
+				Events: []api.AuditEvent{
+					{
+						ID:        "event1",
+						Type:      api.AUDIT_EVENT_TYPE_CHANGE,
+						Actor:     "admin",
+						Action:    api.USER_ACTION_UPDATE_USER,
+						EntityUrn: api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+						Urn:       api.CreateUrn("", api.RESOURCE_AUDIT_EVENT, "/", "event1"),
+						CreateAt:  now,
+					},
+				},
+				Offset: 0,
+				Limit:  10,
+				Total:  1,
+			},
+		},
+		"ErrorCaseInvalidFrom": {
+			queryParams: map[string]string{
+				"From": "yesterday",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: From yesterday",
+			},
+		},
+		"ErrorCaseInvalidTo": {
+			queryParams: map[string]string{
+				"To": "2017-01-01",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: To 2017-01-01",
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			queryParams: map[string]string{
+				"OrderBy": "invalid",
+			},
+			expectedFilter: &api.Filter{
+				OrderBy: "invalid",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+			listAuditEventsErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			expectedFilter:     &api.Filter{},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			listAuditEventsErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			expectedFilter:     &api.Filter{},
+			expectedStatusCode: http.StatusInternalServerError,
+			listAuditEventsErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsIn[ListAuditEventsMethod] = make([]interface{}, 2)
+		testApi.ArgsOut[ListAuditEventsMethod][0] = test.listAuditEventsResult
+		testApi.ArgsOut[ListAuditEventsMethod][1] = test.totalAuditEvents
+		testApi.ArgsOut[ListAuditEventsMethod][2] = test.listAuditEventsErr
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+AUDIT_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		for key, value := range test.queryParams {
+			q.Add(key, value)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		filterData, ok := testApi.ArgsIn[ListAuditEventsMethod][1].(*api.Filter)
+		if test.expectedFilter != nil {
+			assert.True(t, ok, "Error in test case %v", n)
+			assert.Equal(t, test.expectedFilter, filterData, "Error in test case %v", n)
+		} else {
+			assert.False(t, ok, "Error in test case %v", n)
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			listAuditEventsResponse := ListAuditEventsResponse{}
+			err = json.NewDecoder(res.Body).Decode(&listAuditEventsResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, listAuditEventsResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
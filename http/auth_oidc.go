@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 
+	"github.com/Tecsisa/foulkon/api"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -44,6 +45,7 @@ func (wh *WorkerHandler) HandleAddOidcProvider(w http.ResponseWriter, r *http.Re
 
 	// Call Auth Provider API to create the new OIDC provider
 	response, err := wh.worker.AuthOidcAPI.AddOidcProvider(requestInfo, request.Name, request.Path, request.IssuerURL, request.OidcClients)
+	wh.reloadOidcProviders(requestInfo, err)
 	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusCreated)
 }
 
@@ -92,6 +94,7 @@ func (wh *WorkerHandler) HandleUpdateOidcProvider(w http.ResponseWriter, r *http
 	// Call Auth Provider API to update the OIDC Provider
 	response, err := wh.worker.AuthOidcAPI.UpdateOidcProvider(requestInfo, filterData.AuthProviderName,
 		request.Name, request.Path, request.IssuerURL, request.OidcClients)
+	wh.reloadOidcProviders(requestInfo, err)
 	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
 }
 
@@ -105,5 +108,19 @@ func (wh *WorkerHandler) HandleRemoveOidcProvider(w http.ResponseWriter, r *http
 
 	// Call Auth Provider API to delete the OIDC Provider
 	err := wh.worker.AuthOidcAPI.RemoveOidcProvider(requestInfo, filterData.AuthProviderName)
+	wh.reloadOidcProviders(requestInfo, err)
 	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
 }
+
+// reloadOidcProviders applies OIDC providers changes to the authenticator of this worker without waiting for the refresh
+func (wh *WorkerHandler) reloadOidcProviders(requestInfo api.RequestInfo, err error) {
+	if err != nil {
+		return
+	}
+	if err := wh.worker.ReloadOidcProviders(); err != nil {
+		api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, &api.Error{
+			Code:    api.UNKNOWN_API_ERROR,
+			Message: err.Error(),
+		})
+	}
+}
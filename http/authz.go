@@ -13,6 +13,12 @@ type AuthorizeResourcesRequest struct {
 	Resources []string `json:"resources,omitempty"`
 }
 
+type AuthorizeRestrictionsRequest struct {
+	Action    string `json:"action,omitempty"`
+	UrnPrefix string `json:"urnPrefix,omitempty"`
+	Column    string `json:"column,omitempty"`
+}
+
 // RESPONSES
 
 type AuthorizeResourcesResponse struct {
@@ -38,3 +44,39 @@ func (wh *WorkerHandler) HandleGetAuthorizedExternalResources(w http.ResponseWri
 	}
 	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
 }
+
+func (wh *WorkerHandler) HandleGetAuthorizedRestrictions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Process request
+	request := &AuthorizeRestrictionsRequest{}
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, nil, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve restrictions
+	response, err := wh.worker.AuthzApi.GetAuthorizedRestrictions(requestInfo, request.Action, request.UrnPrefix, request.Column)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleGetAuthzSnapshot(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve snapshot
+	snapshot, err := wh.worker.AuthzApi.GetAuthzSnapshot(requestInfo)
+	if err == nil {
+		etag := `"` + snapshot.Version + `"`
+		w.Header().Set("ETag", etag)
+		// Client already has this version
+		if r.Header.Get("If-None-Match") == etag {
+			wh.processHttpResponse(r, w, requestInfo, nil, nil, http.StatusNotModified)
+			return
+		}
+	}
+	wh.processHttpResponse(r, w, requestInfo, snapshot, err, http.StatusOK)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/stretchr/testify/assert"
@@ -127,3 +128,277 @@ func TestWorkerHandler_HandleGetAuthorizedExternalResources(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkerHandler_HandleGetAuthorizedRestrictions(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		request *AuthorizeRestrictionsRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.AuthorizedRestrictions
+		expectedError      api.Error
+		// Manager Results
+		getAuthorizedRestrictionsResult *api.AuthorizedRestrictions
+		// Manager Errors
+		getAuthorizedRestrictionsErr error
+	}{
+		"OkCase": {
+			request: &AuthorizeRestrictionsRequest{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+				Column:    "urn",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.AuthorizedRestrictions{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+				Restrictions: &api.Restrictions{
+					AllowedUrnPrefixes: []string{"urn:ews:product:instance:resource/path1*"},
+					DeniedFullUrns:     []string{"urn:ews:product:instance:resource/path1/resource1"},
+				},
+				Predicate: &api.Predicate{
+					Where: "(urn LIKE ?) AND NOT (urn = ?)",
+					Args:  []string{"urn:ews:product:instance:resource/path1%", "urn:ews:product:instance:resource/path1/resource1"},
+				},
+			},
+			getAuthorizedRestrictionsResult: &api.AuthorizedRestrictions{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+				Restrictions: &api.Restrictions{
+					AllowedUrnPrefixes: []string{"urn:ews:product:instance:resource/path1*"},
+					AllowedFullUrns:    []string{},
+					DeniedUrnPrefixes:  []string{},
+					DeniedFullUrns:     []string{"urn:ews:product:instance:resource/path1/resource1"},
+				},
+				Predicate: &api.Predicate{
+					Where: "(urn LIKE ?) AND NOT (urn = ?)",
+					Args:  []string{"urn:ews:product:instance:resource/path1%", "urn:ews:product:instance:resource/path1/resource1"},
+				},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			request: &AuthorizeRestrictionsRequest{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/resource1",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+			getAuthorizedRestrictionsErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			request: &AuthorizeRestrictionsRequest{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+			getAuthorizedRestrictionsErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			request: &AuthorizeRestrictionsRequest{
+				Action:    "product:DoAction",
+				UrnPrefix: "urn:ews:product:instance:resource/*",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			getAuthorizedRestrictionsErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetAuthorizedRestrictionsMethod][0] = test.getAuthorizedRestrictionsResult
+		testApi.ArgsOut[GetAuthorizedRestrictionsMethod][1] = test.getAuthorizedRestrictionsErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+RESOURCE_RESTRICTIONS_URL, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.request.Action, testApi.ArgsIn[GetAuthorizedRestrictionsMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.UrnPrefix, testApi.ArgsIn[GetAuthorizedRestrictionsMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Column, testApi.ArgsIn[GetAuthorizedRestrictionsMethod][3], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			authorizedRestrictions := api.AuthorizedRestrictions{}
+			err = json.NewDecoder(res.Body).Decode(&authorizedRestrictions)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, authorizedRestrictions, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleGetAuthzSnapshot(t *testing.T) {
+	now := time.Now().UTC()
+	snapshot := &api.AuthzSnapshot{
+		Version:  "1234",
+		CreateAt: now,
+		Users: []api.SnapshotUser{
+			{
+				ExternalID: "user1",
+				Groups:     []string{"group1"},
+			},
+		},
+		Groups: []api.SnapshotGroup{
+			{
+				ID:       "group1",
+				Urn:      api.CreateUrn("org1", api.RESOURCE_GROUP, "/path/", "group1"),
+				Policies: []string{"policy1"},
+			},
+		},
+		Policies: []api.Policy{
+			{
+				ID:   "policy1",
+				Name: "policy1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  api.CreateUrn("org1", api.RESOURCE_POLICY, "/path/", "policy1"),
+				Statements: &[]api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{"product:DoAction"},
+						Resources: []string{"urn:ews:product:instance:resource/*"},
+					},
+				},
+			},
+		},
+	}
+	testcases := map[string]struct {
+		// Request headers
+		ifNoneMatch string
+		// Expected result
+		expectedStatusCode int
+		expectedETag       string
+		expectedResponse   *api.AuthzSnapshot
+		expectedError      api.Error
+		// Manager Results
+		getAuthzSnapshotResult *api.AuthzSnapshot
+		// Manager Errors
+		getAuthzSnapshotErr error
+	}{
+		"OkCase": {
+			expectedStatusCode:     http.StatusOK,
+			expectedETag:           `"1234"`,
+			expectedResponse:       snapshot,
+			getAuthzSnapshotResult: snapshot,
+		},
+		"OkCaseOldVersion": {
+			ifNoneMatch:            `"1233"`,
+			expectedStatusCode:     http.StatusOK,
+			expectedETag:           `"1234"`,
+			expectedResponse:       snapshot,
+			getAuthzSnapshotResult: snapshot,
+		},
+		"OkCaseNotModified": {
+			ifNoneMatch:            `"1234"`,
+			expectedStatusCode:     http.StatusNotModified,
+			expectedETag:           `"1234"`,
+			getAuthzSnapshotResult: snapshot,
+		},
+		"ErrorCaseUnauthorizedError": {
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+			getAuthzSnapshotErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			expectedStatusCode: http.StatusInternalServerError,
+			getAuthzSnapshotErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetAuthzSnapshotMethod][0] = test.getAuthzSnapshotResult
+		testApi.ArgsOut[GetAuthzSnapshotMethod][1] = test.getAuthzSnapshotErr
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+AUTHZ_SNAPSHOT_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+		if test.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+		assert.Equal(t, test.expectedETag, res.Header.Get("ETag"), "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := &api.AuthzSnapshot{}
+			err = json.NewDecoder(res.Body).Decode(response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusNotModified, http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
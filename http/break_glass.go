@@ -0,0 +1,58 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+// REQUESTS
+
+type ActivateBreakGlassSessionRequest struct {
+	Justification string `json:"justification,omitempty"`
+}
+
+// RESPONSES
+
+type ListBreakGlassSessionsResponse struct {
+	Sessions []api.BreakGlassSession `json:"sessions,omitempty"`
+	Limit    int                     `json:"limit"`
+	Offset   int                     `json:"offset"`
+	Total    int                     `json:"total"`
+}
+
+// HANDLERS
+
+func (wh *WorkerHandler) HandleActivateBreakGlassSession(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &ActivateBreakGlassSessionRequest{}
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call break-glass API to activate the session
+	response, err := wh.worker.BreakGlassApi.ActivateBreakGlassSession(requestInfo, request.Justification)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusCreated)
+}
+
+func (wh *WorkerHandler) HandleListBreakGlassSessions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call break-glass API to list sessions
+	result, total, err := wh.worker.BreakGlassApi.ListBreakGlassSessions(requestInfo, filterData)
+	// Create response
+	response := &ListBreakGlassSessionsResponse{
+		Sessions: result,
+		Offset:   filterData.Offset,
+		Limit:    filterData.Limit,
+		Total:    total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
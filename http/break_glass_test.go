@@ -0,0 +1,396 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleActivateBreakGlassSession(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		request *ActivateBreakGlassSessionRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.BreakGlassSession
+		expectedError      api.Error
+		// Manager Results
+		activateBreakGlassSessionResult *api.BreakGlassSession
+		// Manager Errors
+		activateBreakGlassSessionErr error
+	}{
+		"OkCase": {
+			request: &ActivateBreakGlassSessionRequest{
+				Justification: "OIDC provider down",
+			},
+			activateBreakGlassSessionResult: &api.BreakGlassSession{
+				ID:            "session1",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           api.CreateUrn("", api.RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:      now,
+				ExpiresAt:     now.Add(time.Hour),
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse: api.BreakGlassSession{
+				ID:            "session1",
+				UserID:        "emergency",
+				Justification: "OIDC provider down",
+				Urn:           api.CreateUrn("", api.RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+				CreateAt:      now,
+				ExpiresAt:     now.Add(time.Hour),
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseSessionAlreadyActive": {
+			request: &ActivateBreakGlassSessionRequest{
+				Justification: "OIDC provider down",
+			},
+			activateBreakGlassSessionErr: &api.Error{
+				Code: api.BREAK_GLASS_SESSION_ALREADY_ACTIVE,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code: api.BREAK_GLASS_SESSION_ALREADY_ACTIVE,
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			request: &ActivateBreakGlassSessionRequest{
+				Justification: "",
+			},
+			activateBreakGlassSessionErr: &api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			request: &ActivateBreakGlassSessionRequest{
+				Justification: "OIDC provider down",
+			},
+			activateBreakGlassSessionErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			request: &ActivateBreakGlassSessionRequest{
+				Justification: "OIDC provider down",
+			},
+			activateBreakGlassSessionErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedError: api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[UseBreakGlassSessionMethod][0] = nil
+		testApi.ArgsOut[UseBreakGlassSessionMethod][1] = &api.Error{
+			Code: api.BREAK_GLASS_SESSION_NOT_FOUND,
+		}
+		testApi.ArgsOut[ActivateBreakGlassSessionMethod][0] = test.activateBreakGlassSessionResult
+		testApi.ArgsOut[ActivateBreakGlassSessionMethod][1] = test.activateBreakGlassSessionErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL + BREAK_GLASS_SESSIONS_URL)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+		req.SetBasicAuth("emergency", "emergency")
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			requestInfo, ok := testApi.ArgsIn[ActivateBreakGlassSessionMethod][0].(api.RequestInfo)
+			assert.True(t, ok, "Error in test case %v", n)
+			assert.True(t, requestInfo.BreakGlass, "Error in test case %v", n)
+			assert.False(t, requestInfo.Admin, "Error in test case %v", n)
+			assert.Equal(t, test.request.Justification, testApi.ArgsIn[ActivateBreakGlassSessionMethod][1], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusCreated:
+			response := api.BreakGlassSession{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListBreakGlassSessions(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		filter *api.Filter
+		userID string
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListBreakGlassSessionsResponse
+		expectedError      api.Error
+		// Manager Results
+		listBreakGlassSessionsResult []api.BreakGlassSession
+		totalBreakGlassSessions      int
+		// Manager Errors
+		listBreakGlassSessionsErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				PathPrefix: "",
+				ExternalID: "emergency",
+				Offset:     0,
+				Limit:      0,
+			},
+			userID:             "emergency",
+			expectedStatusCode: http.StatusOK,
+			listBreakGlassSessionsResult: []api.BreakGlassSession{
+				{
+					ID:            "session1",
+					UserID:        "emergency",
+					Justification: "OIDC provider down",
+					Urn:           api.CreateUrn("", api.RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+					CreateAt:      now,
+					ExpiresAt:     now.Add(time.Hour),
+				},
+			},
+			totalBreakGlassSessions: 1,
+			expectedResponse: ListBreakGlassSessionsResponse{
+				Sessions: []api.BreakGlassSession{
+					{
+						ID:            "session1",
+						UserID:        "emergency",
+						Justification: "OIDC provider down",
+						Urn:           api.CreateUrn("", api.RESOURCE_BREAK_GLASS_SESSION, "/", "session1"),
+						CreateAt:      now,
+						ExpiresAt:     now.Add(time.Hour),
+					},
+				},
+				Offset: 0,
+				Limit:  0,
+				Total:  1,
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			filter: &api.Filter{
+				OrderBy: "invalid",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+			listBreakGlassSessionsErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			filter:             testFilter,
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			listBreakGlassSessionsErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			filter:             testFilter,
+			expectedStatusCode: http.StatusInternalServerError,
+			listBreakGlassSessionsErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListBreakGlassSessionsMethod][0] = test.listBreakGlassSessionsResult
+		testApi.ArgsOut[ListBreakGlassSessionsMethod][1] = test.totalBreakGlassSessions
+		testApi.ArgsOut[ListBreakGlassSessionsMethod][2] = test.listBreakGlassSessionsErr
+
+		url := fmt.Sprintf(server.URL + BREAK_GLASS_SESSIONS_URL)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		addQueryParams(test.filter, req)
+		q := req.URL.Query()
+		if test.userID != "" {
+			q.Add("UserId", test.userID)
+		}
+		if test.filter.OrderBy != "" {
+			q.Add("OrderBy", test.filter.OrderBy)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		filterData, ok := testApi.ArgsIn[ListBreakGlassSessionsMethod][1].(*api.Filter)
+		if ok {
+			// Check result
+			assert.Equal(t, test.filter, filterData, "Error in test case %v", n)
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			listBreakGlassSessionsResponse := ListBreakGlassSessionsResponse{}
+			err = json.NewDecoder(res.Body).Decode(&listBreakGlassSessionsResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, listBreakGlassSessionsResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_getRequestInfoBreakGlass(t *testing.T) {
+	testcases := map[string]struct {
+		// Request credentials
+		user     string
+		password string
+		// Manager Results
+		useBreakGlassSessionResult *api.BreakGlassSession
+		// Manager Errors
+		useBreakGlassSessionErr error
+		// Expected result
+		expectedRequestInfo api.RequestInfo
+	}{
+		"OkCaseActiveSession": {
+			user:     "emergency",
+			password: "emergency",
+			useBreakGlassSessionResult: &api.BreakGlassSession{
+				ID:     "session1",
+				UserID: "emergency",
+			},
+			expectedRequestInfo: api.RequestInfo{
+				Identifier:          "emergency",
+				Admin:               true,
+				BreakGlass:          true,
+				BreakGlassSessionID: "session1",
+			},
+		},
+		"OkCaseSessionExpired": {
+			user:     "emergency",
+			password: "emergency",
+			useBreakGlassSessionErr: &api.Error{
+				Code: api.BREAK_GLASS_SESSION_NOT_FOUND,
+			},
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+		},
+		"OkCaseUnknownApiError": {
+			user:     "emergency",
+			password: "emergency",
+			useBreakGlassSessionErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "emergency",
+				BreakGlass: true,
+			},
+		},
+		"OkCaseAdmin": {
+			user:     "admin",
+			password: "admin",
+			useBreakGlassSessionResult: &api.BreakGlassSession{
+				ID:     "session1",
+				UserID: "admin",
+			},
+			expectedRequestInfo: api.RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[UseBreakGlassSessionMethod][0] = test.useBreakGlassSessionResult
+		testApi.ArgsOut[UseBreakGlassSessionMethod][1] = test.useBreakGlassSessionErr
+		testApi.ArgsOut[ListBreakGlassSessionsMethod][0] = nil
+		testApi.ArgsOut[ListBreakGlassSessionsMethod][1] = 0
+		testApi.ArgsOut[ListBreakGlassSessionsMethod][2] = nil
+
+		url := fmt.Sprintf(server.URL + BREAK_GLASS_SESSIONS_URL)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+		req.SetBasicAuth(test.user, test.password)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, http.StatusOK, res.StatusCode, "Error in test case %v", n)
+
+		// Check request info
+		requestInfo, ok := testApi.ArgsIn[ListBreakGlassSessionsMethod][0].(api.RequestInfo)
+		assert.True(t, ok, "Error in test case %v", n)
+		requestInfo.RequestID = ""
+		assert.Equal(t, test.expectedRequestInfo, requestInfo, "Error in test case %v", n)
+	}
+}
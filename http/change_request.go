@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+// REQUESTS
+
+type ReviewChangeRequestRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// RESPONSES
+
+type ListChangeRequestsResponse struct {
+	ChangeRequests []api.ChangeRequest `json:"changeRequests,omitempty"`
+	Limit          int                 `json:"limit"`
+	Offset         int                 `json:"offset"`
+	Total          int                 `json:"total"`
+}
+
+// HANDLERS
+
+func (wh *WorkerHandler) HandleGetChangeRequest(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call change request API to retrieve change request
+	response, err := wh.worker.ChangeRequestApi.GetChangeRequest(requestInfo, filterData.Org, filterData.ChangeRequestID)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleListChangeRequests(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call change request API to list change requests
+	result, total, err := wh.worker.ChangeRequestApi.ListChangeRequests(requestInfo, filterData)
+	// Create response
+	response := &ListChangeRequestsResponse{
+		ChangeRequests: result,
+		Offset:         filterData.Offset,
+		Limit:          filterData.Limit,
+		Total:          total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleApproveChangeRequest(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &ReviewChangeRequestRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call change request API to approve change request
+	response, err := wh.worker.ChangeRequestApi.ApproveChangeRequest(requestInfo, filterData.Org, filterData.ChangeRequestID, request.Comment)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleRejectChangeRequest(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &ReviewChangeRequestRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call change request API to reject change request
+	response, err := wh.worker.ChangeRequestApi.RejectChangeRequest(requestInfo, filterData.Org, filterData.ChangeRequestID, request.Comment)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
@@ -0,0 +1,612 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleGetChangeRequest(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org string
+		id  string
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.ChangeRequest
+		expectedError      api.Error
+		// Manager Results
+		getChangeRequestResult *api.ChangeRequest
+		// Manager Errors
+		getChangeRequestErr error
+	}{
+		"OkCase": {
+			org: "org1",
+			id:  "request1",
+			getChangeRequestResult: &api.ChangeRequest{
+				ID:        "request1",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+				Payload: api.ChangeRequestPayload{
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "requester",
+				Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.ChangeRequest{
+				ID:        "request1",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+				Payload: api.ChangeRequestPayload{
+					PolicyName: "policy1",
+				},
+				Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+				Requester: "requester",
+				Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+			},
+		},
+		"ErrorCaseChangeRequestNotFound": {
+			org: "org1",
+			id:  "request1",
+			getChangeRequestErr: &api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+		},
+		"ErrorCaseInvalidParameterError": {
+			org: "org1",
+			id:  "request1",
+			getChangeRequestErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedResourcesError": {
+			org: "org1",
+			id:  "request1",
+			getChangeRequestErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org: "org1",
+			id:  "request1",
+			getChangeRequestErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetChangeRequestMethod][0] = test.getChangeRequestResult
+		testApi.ArgsOut[GetChangeRequestMethod][1] = test.getChangeRequestErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/change-requests/%v", test.org, test.id)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		assert.Equal(t, test.org, testApi.ArgsIn[GetChangeRequestMethod][1], "Error in test case %v", n)
+		assert.Equal(t, test.id, testApi.ArgsIn[GetChangeRequestMethod][2], "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.ChangeRequest{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListChangeRequests(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		filter *api.Filter
+		status string
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListChangeRequestsResponse
+		expectedError      api.Error
+		// Manager Results
+		listChangeRequestsResult []api.ChangeRequest
+		totalChangeRequests      int
+		// Manager Errors
+		listChangeRequestsErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				Org:    "org1",
+				Status: api.CHANGE_REQUEST_STATUS_PENDING,
+			},
+			status:             api.CHANGE_REQUEST_STATUS_PENDING,
+			expectedStatusCode: http.StatusOK,
+			listChangeRequestsResult: []api.ChangeRequest{
+				{
+					ID:        "request1",
+					Org:       "org1",
+					Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+					Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+					Requester: "requester",
+					Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+					CreateAt:  now,
+					ExpiresAt: now.Add(time.Hour),
+				},
+			},
+			totalChangeRequests: 1,
+			expectedResponse: ListChangeRequestsResponse{
+				ChangeRequests: []api.ChangeRequest{
+					{
+						ID:        "request1",
+						Org:       "org1",
+						Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+						Status:    api.CHANGE_REQUEST_STATUS_PENDING,
+						Requester: "requester",
+						Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+						CreateAt:  now,
+						ExpiresAt: now.Add(time.Hour),
+					},
+				},
+				Offset: 0,
+				Limit:  0,
+				Total:  1,
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			filter: &api.Filter{
+				Org:    "org1",
+				Status: "invalid",
+			},
+			status:             "invalid",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+			listChangeRequestsErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			filter: &api.Filter{
+				Org: "org1",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			listChangeRequestsErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			filter: &api.Filter{
+				Org: "org1",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			listChangeRequestsErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListChangeRequestsMethod][0] = test.listChangeRequestsResult
+		testApi.ArgsOut[ListChangeRequestsMethod][1] = test.totalChangeRequests
+		testApi.ArgsOut[ListChangeRequestsMethod][2] = test.listChangeRequestsErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/change-requests", test.filter.Org)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		if test.status != "" {
+			q.Add("Status", test.status)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		filterData, ok := testApi.ArgsIn[ListChangeRequestsMethod][1].(*api.Filter)
+		if assert.True(t, ok, "Error in test case %v", n) {
+			assert.Equal(t, test.filter.Org, filterData.Org, "Error in test case %v", n)
+			assert.Equal(t, test.filter.Status, filterData.Status, "Error in test case %v", n)
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			listChangeRequestsResponse := ListChangeRequestsResponse{}
+			err = json.NewDecoder(res.Body).Decode(&listChangeRequestsResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, listChangeRequestsResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleApproveChangeRequest(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org     string
+		id      string
+		request *ReviewChangeRequestRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.ChangeRequest
+		expectedError      api.Error
+		// Manager Results
+		approveChangeRequestResult *api.ChangeRequest
+		// Manager Errors
+		approveChangeRequestErr error
+	}{
+		"OkCase": {
+			org: "org1",
+			id:  "request1",
+			request: &ReviewChangeRequestRequest{
+				Comment: "looks good",
+			},
+			approveChangeRequestResult: &api.ChangeRequest{
+				ID:        "request1",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+				Requester: "requester",
+				Reviewer:  "reviewer",
+				Comment:   "looks good",
+				Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.ChangeRequest{
+				ID:        "request1",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_ATTACH_POLICY_TO_GROUP,
+				Status:    api.CHANGE_REQUEST_STATUS_APPROVED,
+				Requester: "requester",
+				Reviewer:  "reviewer",
+				Comment:   "looks good",
+				Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			org:                "org1",
+			id:                 "request1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseChangeRequestNotPending": {
+			org:     "org1",
+			id:      "request1",
+			request: &ReviewChangeRequestRequest{},
+			approveChangeRequestErr: &api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_PENDING,
+				Message: "Not pending",
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_PENDING,
+				Message: "Not pending",
+			},
+		},
+		"ErrorCaseChangeRequestNotFound": {
+			org:     "org1",
+			id:      "request1",
+			request: &ReviewChangeRequestRequest{},
+			approveChangeRequestErr: &api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_FOUND,
+				Message: "Not found",
+			},
+		},
+		"ErrorCaseUnauthorizedResourcesError": {
+			org:     "org1",
+			id:      "request1",
+			request: &ReviewChangeRequestRequest{},
+			approveChangeRequestErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org:     "org1",
+			id:      "request1",
+			request: &ReviewChangeRequestRequest{},
+			approveChangeRequestErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ApproveChangeRequestMethod][0] = test.approveChangeRequestResult
+		testApi.ArgsOut[ApproveChangeRequestMethod][1] = test.approveChangeRequestErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/change-requests/%v/approve", test.org, test.id)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[ApproveChangeRequestMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.id, testApi.ArgsIn[ApproveChangeRequestMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Comment, testApi.ArgsIn[ApproveChangeRequestMethod][3], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.ChangeRequest{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleRejectChangeRequest(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org     string
+		id      string
+		request *ReviewChangeRequestRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.ChangeRequest
+		expectedError      api.Error
+		// Manager Results
+		rejectChangeRequestResult *api.ChangeRequest
+		// Manager Errors
+		rejectChangeRequestErr error
+	}{
+		"OkCase": {
+			org: "org1",
+			id:  "request1",
+			request: &ReviewChangeRequestRequest{
+				Comment: "too broad",
+			},
+			rejectChangeRequestResult: &api.ChangeRequest{
+				ID:        "request1",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+				Status:    api.CHANGE_REQUEST_STATUS_REJECTED,
+				Requester: "requester",
+				Reviewer:  "reviewer",
+				Comment:   "too broad",
+				Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.ChangeRequest{
+				ID:        "request1",
+				Org:       "org1",
+				Operation: api.CHANGE_REQUEST_OPERATION_UPDATE_POLICY,
+				Status:    api.CHANGE_REQUEST_STATUS_REJECTED,
+				Requester: "requester",
+				Reviewer:  "reviewer",
+				Comment:   "too broad",
+				Urn:       api.CreateUrn("org1", api.RESOURCE_CHANGE_REQUEST, "/", "request1"),
+				CreateAt:  now,
+				ExpiresAt: now.Add(time.Hour),
+				ReviewAt:  now,
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			org:                "org1",
+			id:                 "request1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseChangeRequestNotPending": {
+			org:     "org1",
+			id:      "request1",
+			request: &ReviewChangeRequestRequest{},
+			rejectChangeRequestErr: &api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_PENDING,
+				Message: "Not pending",
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.CHANGE_REQUEST_NOT_PENDING,
+				Message: "Not pending",
+			},
+		},
+		"ErrorCaseUnauthorizedResourcesError": {
+			org:     "org1",
+			id:      "request1",
+			request: &ReviewChangeRequestRequest{},
+			rejectChangeRequestErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org:     "org1",
+			id:      "request1",
+			request: &ReviewChangeRequestRequest{},
+			rejectChangeRequestErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[RejectChangeRequestMethod][0] = test.rejectChangeRequestResult
+		testApi.ArgsOut[RejectChangeRequestMethod][1] = test.rejectChangeRequestErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/change-requests/%v/reject", test.org, test.id)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[RejectChangeRequestMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.id, testApi.ArgsIn[RejectChangeRequestMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Comment, testApi.ArgsIn[RejectChangeRequestMethod][3], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.ChangeRequest{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/julienschmidt/httprouter"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+const (
+	// Envoy external authorization headers with the checked resource
+	EXT_AUTHZ_URN_HEADER    = "X-Foulkon-Urn"
+	EXT_AUTHZ_ACTION_HEADER = "X-Foulkon-Action"
+
+	// URI path param with the path of the checked request
+	EXT_AUTHZ_PATH = "path"
+)
+
+// ExtAuthzHandler answers Envoy external authorization and forward auth checks. Requests are matched with the
+// proxy resources in the same way as the proxy does, and the authenticated user must be allowed to do the action
+// of the matched resource over its urn
+type ExtAuthzHandler struct {
+	worker *foulkon.Worker
+
+	resourceLock     sync.RWMutex
+	currentResources []api.ProxyResource
+	router           *httprouter.Router
+	refreshAt        time.Time
+}
+
+// NewExtAuthzHandler returns an ExtAuthzHandler that reloads the proxy resources every worker ExtAuthzRefresh
+func NewExtAuthzHandler(worker *foulkon.Worker) *ExtAuthzHandler {
+	return &ExtAuthzHandler{worker: worker}
+}
+
+// ServeHTTP checks the request method and path. Request must be already authenticated by the worker middlewares.
+// Allowed requests are answered with 200 status code and the authenticated user in X-FOULKON-USER-ID header,
+// denied requests with 403 status code. Paths that aren't clean are denied.
+func (eh *ExtAuthzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Upstreams resolve dot segments and repeated slashes, so these paths could match a broader resource
+	// than the one that is served
+	if httprouter.CleanPath(r.URL.Path) != r.URL.Path {
+		requestInfo := eh.worker.GetRequestInfo(r)
+		api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, &api.Error{
+			Code:    api.INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Checked path %v isn't clean", r.URL.Path),
+		})
+		WriteHttpResponse(r, w, requestInfo.RequestID, requestInfo.Identifier, http.StatusForbidden, getErrorMessage(FORBIDDEN_ERROR, ""))
+		return
+	}
+
+	router, err := eh.getRouter()
+	if err != nil {
+		requestInfo := eh.worker.GetRequestInfo(r)
+		api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, err.(*api.Error))
+		WriteHttpResponse(r, w, requestInfo.RequestID, requestInfo.Identifier, http.StatusInternalServerError,
+			getErrorMessage(INTERNAL_SERVER_ERROR, "Internal server error. Contact the administrator"))
+		return
+	}
+	router.ServeHTTP(w, r)
+}
+
+// HANDLERS
+
+// HandleExtAuthz checks the request of Envoy HTTP external authorization service, where the path of the original
+// request comes after the endpoint path
+func (wh *WorkerHandler) HandleExtAuthz(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Check original request path
+	checkRequest := new(http.Request)
+	*checkRequest = *r
+	checkURL := *r.URL
+	checkURL.Path = ps.ByName(EXT_AUTHZ_PATH)
+	checkURL.RawPath = ""
+	checkRequest.URL = &checkURL
+
+	wh.extAuthzHandler.ServeHTTP(w, checkRequest)
+}
+
+// PRIVATE HELPER METHODS
+
+// checkRequest answers if the authenticated user is allowed to do the action of the proxy resource over its urn
+func (eh *ExtAuthzHandler) checkRequest(proxyResource api.ProxyResource) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		requestInfo := eh.worker.GetRequestInfo(r)
+		// Retrieve parameters to replace in URN
+		parameters := getUrnParameters(proxyResource.Resource.Urn)
+		urn := proxyResource.Resource.Urn
+		for _, p := range parameters {
+			urn = strings.Replace(urn, p[0], ps.ByName(p[1]), -1)
+		}
+		w.Header().Set(EXT_AUTHZ_URN_HEADER, urn)
+		w.Header().Set(EXT_AUTHZ_ACTION_HEADER, proxyResource.Resource.Action)
+
+		if err := eh.isAllowed(requestInfo, urn, proxyResource.Resource.Action); err != nil {
+			apiError := err.(*api.Error)
+			api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, apiError)
+			var statusCode int
+			var responseErr *api.Error
+			switch apiError.Code {
+			case api.UNAUTHORIZED_RESOURCES_ERROR:
+				statusCode = http.StatusForbidden
+				responseErr = getErrorMessage(FORBIDDEN_ERROR, "")
+			case api.INVALID_PARAMETER_ERROR, api.REGEX_NO_MATCH:
+				statusCode = http.StatusBadRequest
+				responseErr = getErrorMessage(api.INVALID_PARAMETER_ERROR, "Bad request")
+			default:
+				statusCode = http.StatusInternalServerError
+				responseErr = getErrorMessage(INTERNAL_SERVER_ERROR, "Internal server error. Contact the administrator")
+			}
+			WriteHttpResponse(r, w, requestInfo.RequestID, requestInfo.Identifier, statusCode, responseErr)
+			return
+		}
+
+		w.Header().Set(middleware.USER_ID_HEADER, requestInfo.Identifier)
+		WriteHttpResponse(r, w, requestInfo.RequestID, requestInfo.Identifier, http.StatusOK, nil)
+	}
+}
+
+func (eh *ExtAuthzHandler) isAllowed(requestInfo api.RequestInfo, urn string, action string) error {
+	if !isFullUrn(urn) {
+		return getErrorMessage(api.INVALID_PARAMETER_ERROR, fmt.Sprintf("Urn %v is a prefix, it would be a full urn resource", urn))
+	}
+	resources, err := eh.worker.AuthzApi.GetAuthorizedExternalResources(requestInfo, action, []string{urn})
+	if err != nil {
+		return err
+	}
+
+	// Check urns allowed to find target urn
+	for _, allowedRes := range resources {
+		if allowedRes == urn {
+			return nil
+		}
+	}
+	return getErrorMessage(api.UNAUTHORIZED_RESOURCES_ERROR, fmt.Sprintf("No access for urn %v", urn))
+}
+
+// getRouter returns the router with current proxy resources, reloading them when refresh time is over.
+// If reload fails, previous resources are used
+func (eh *ExtAuthzHandler) getRouter() (*httprouter.Router, error) {
+	eh.resourceLock.RLock()
+	router, refreshAt := eh.router, eh.refreshAt
+	eh.resourceLock.RUnlock()
+	if router != nil && time.Now().Before(refreshAt) {
+		return router, nil
+	}
+
+	eh.resourceLock.Lock()
+	defer eh.resourceLock.Unlock()
+	// Another request could have reloaded resources while waiting for the lock
+	if eh.router != nil && time.Now().Before(eh.refreshAt) {
+		return eh.router, nil
+	}
+	eh.refreshAt = time.Now().Add(eh.worker.ExtAuthzRefresh)
+
+	proxyResources, err := eh.worker.InternalProxyApi.GetProxyResources()
+	if err != nil {
+		if eh.router != nil {
+			api.Log.Errorf("Unexpected error reading proxy resources from database %v", err)
+			return eh.router, nil
+		}
+		return nil, err
+	}
+
+	if eh.router == nil || pretty.Compare(eh.currentResources, proxyResources) != "" {
+		router := httprouter.New()
+		// Requests without proxy resource are denied, not redirected
+		router.RedirectTrailingSlash = false
+		router.RedirectFixedPath = false
+		router.HandleMethodNotAllowed = false
+		router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestInfo := eh.worker.GetRequestInfo(r)
+			WriteHttpResponse(r, w, requestInfo.RequestID, requestInfo.Identifier, http.StatusForbidden, getErrorMessage(FORBIDDEN_ERROR, ""))
+		})
+		for _, pr := range proxyResources {
+			// Clean path
+			pr.Resource.Path = httprouter.CleanPath(pr.Resource.Path)
+
+			// Attach resource
+			eh.safeRouterAdderHandler(router, pr)
+		}
+		eh.currentResources = proxyResources
+		eh.router = router
+	}
+
+	return eh.router, nil
+}
+
+// Method to control when router has a resource already defined that collides with another
+func (eh *ExtAuthzHandler) safeRouterAdderHandler(router *httprouter.Router, pr api.ProxyResource) {
+	defer func() {
+		if r := recover(); r != nil {
+			api.Log.Errorf("There was a problem adding proxy resource with name %v and org %v: %v", pr.Name, pr.Org, r)
+		}
+	}()
+	router.Handle(pr.Resource.Method, pr.Resource.Path, eh.checkRequest(pr))
+}
@@ -0,0 +1,275 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleExtAuthz(t *testing.T) {
+	proxyResources := []api.ProxyResource{
+		{
+			ID:   "ID1",
+			Name: "example",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:   "http://upstream",
+				Path:   "/example/:id",
+				Method: http.MethodGet,
+				Urn:    "urn:ews:example:instance1:resource/{id}",
+				Action: "example:get",
+			},
+		},
+		{
+			ID:   "ID2",
+			Name: "prefix",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:   "http://upstream",
+				Path:   "/prefix",
+				Method: http.MethodPost,
+				Urn:    "urn:ews:example:instance1:resource/*",
+				Action: "example:add",
+			},
+		},
+		{
+			ID:   "ID3",
+			Name: "public",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:   "http://upstream",
+				Path:   "/public/*rest",
+				Method: http.MethodGet,
+				Urn:    "urn:ews:example:instance1:public",
+				Action: "example:read",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		// Checked request
+		method string
+		path   string
+		// Expected result
+		expectedStatusCode int
+		expectedUserID     string
+		expectedUrn        string
+		expectedAction     string
+		expectedError      api.Error
+		// Manager Results
+		getAuthorizedExternalResourcesResult []string
+		// Manager Errors
+		getAuthorizedExternalResourcesErr error
+	}{
+		"OkCase": {
+			method:                               http.MethodGet,
+			path:                                 "/example/res1?query=value",
+			expectedStatusCode:                   http.StatusOK,
+			expectedUserID:                       "userID",
+			expectedUrn:                          "urn:ews:example:instance1:resource/res1",
+			expectedAction:                       "example:get",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/res1"},
+		},
+		"ErrorCaseUrnNotAllowed": {
+			method:                               http.MethodGet,
+			path:                                 "/example/res1",
+			expectedStatusCode:                   http.StatusForbidden,
+			expectedUrn:                          "urn:ews:example:instance1:resource/res1",
+			expectedAction:                       "example:get",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/res2"},
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			method:             http.MethodGet,
+			path:               "/example/res1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedUrn:        "urn:ews:example:instance1:resource/res1",
+			expectedAction:     "example:get",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCaseResourceNotFound": {
+			method:             http.MethodGet,
+			path:               "/unknown",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCaseMethodNotFound": {
+			method:             http.MethodDelete,
+			path:               "/example/res1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCaseDotSegments": {
+			method:             http.MethodGet,
+			path:               "/public/../example/res1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCaseEncodedDotSegments": {
+			method:             http.MethodGet,
+			path:               "/public/%2e%2E%2Fexample/res1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCaseRepeatedSlashes": {
+			method:             http.MethodGet,
+			path:               "/public//example",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCasePrefixUrn": {
+			method:             http.MethodPost,
+			path:               "/prefix",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedUrn:        "urn:ews:example:instance1:resource/*",
+			expectedAction:     "example:add",
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Bad request",
+			},
+		},
+		"ErrorCaseInvalidParameterError": {
+			method:             http.MethodGet,
+			path:               "/example/res1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedUrn:        "urn:ews:example:instance1:resource/res1",
+			expectedAction:     "example:get",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Bad request",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			method:             http.MethodGet,
+			path:               "/example/res1",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedUrn:        "urn:ews:example:instance1:resource/res1",
+			expectedAction:     "example:get",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			expectedError: api.Error{
+				Code:    INTERNAL_SERVER_ERROR,
+				Message: "Internal server error. Contact the administrator",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsOut[GetProxyResourcesMethod][0] = proxyResources
+		testApi.ArgsOut[GetProxyResourcesMethod][1] = nil
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][0] = test.getAuthorizedExternalResourcesResult
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][1] = test.getAuthorizedExternalResourcesErr
+
+		req, err := http.NewRequest(test.method, server.URL+EXT_AUTHZ_URL+test.path, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code and headers
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+		assert.Equal(t, test.expectedUserID, res.Header.Get(middleware.USER_ID_HEADER), "Error in test case %v", n)
+		assert.Equal(t, test.expectedUrn, res.Header.Get(EXT_AUTHZ_URN_HEADER), "Error in test case %v", n)
+		assert.Equal(t, test.expectedAction, res.Header.Get(EXT_AUTHZ_ACTION_HEADER), "Error in test case %v", n)
+
+		if res.StatusCode == http.StatusOK {
+			// Check authorized resource
+			assert.Equal(t, []string{test.expectedUrn}, testApi.ArgsIn[GetAuthorizedExternalResourcesMethod][2], "Error in test case %v", n)
+			continue
+		}
+		apiError := api.Error{}
+		err = json.NewDecoder(res.Body).Decode(&apiError)
+		assert.Nil(t, err, "Error in test case %v", n)
+		// Check result
+		assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+	}
+}
+
+func TestExtAuthzHandler_getRouter(t *testing.T) {
+	proxyResources := []api.ProxyResource{
+		{
+			ID:   "ID1",
+			Name: "example",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Path:   "/example",
+				Method: http.MethodGet,
+				Urn:    "urn:ews:example:instance1:resource/example",
+				Action: "example:get",
+			},
+		},
+	}
+	testAPI := makeTestApi()
+	extAuthzHandler := NewExtAuthzHandler(&foulkon.Worker{InternalProxyApi: testAPI})
+
+	// Without previous resources error is returned
+	testAPI.ArgsOut[GetProxyResourcesMethod][1] = &api.Error{
+		Code:    api.UNKNOWN_API_ERROR,
+		Message: "Error",
+	}
+	router, err := extAuthzHandler.getRouter()
+	assert.Nil(t, router)
+	assert.Equal(t, &api.Error{
+		Code:    api.UNKNOWN_API_ERROR,
+		Message: "Error",
+	}, err)
+
+	// Resources are loaded
+	testAPI.ArgsOut[GetProxyResourcesMethod][0] = proxyResources
+	testAPI.ArgsOut[GetProxyResourcesMethod][1] = nil
+	router, err = extAuthzHandler.getRouter()
+	assert.Nil(t, err)
+	assert.NotNil(t, router)
+	assert.Equal(t, proxyResources, extAuthzHandler.currentResources)
+
+	// Same resources don't rebuild the router
+	sameRouter, err := extAuthzHandler.getRouter()
+	assert.Nil(t, err)
+	assert.True(t, router == sameRouter)
+
+	// With previous resources, reload errors keep current router
+	testAPI.ArgsOut[GetProxyResourcesMethod][1] = &api.Error{
+		Code:    api.UNKNOWN_API_ERROR,
+		Message: "Error",
+	}
+	sameRouter, err = extAuthzHandler.getRouter()
+	assert.Nil(t, err)
+	assert.True(t, router == sameRouter)
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// Nginx auth_request headers with the original request
+	ORIGINAL_METHOD_HEADER = "X-Original-Method"
+	ORIGINAL_URI_HEADER    = "X-Original-URI"
+
+	// Traefik ForwardAuth headers with the original request
+	FORWARDED_METHOD_HEADER = "X-Forwarded-Method"
+	FORWARDED_URI_HEADER    = "X-Forwarded-Uri"
+)
+
+// HANDLERS
+
+// HandleForwardAuth checks the original request of nginx auth_request or Traefik ForwardAuth, that comes in
+// X-Original-Method and X-Original-URI or X-Forwarded-Method and X-Forwarded-Uri headers
+func (wh *WorkerHandler) HandleForwardAuth(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	method, uri := getOriginalRequest(r)
+	checkURL, err := url.ParseRequestURI(uri)
+	if method == "" || err != nil {
+		requestInfo := wh.worker.GetRequestInfo(r)
+		apiError := getErrorMessage(api.INVALID_PARAMETER_ERROR, "Bad request")
+		api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, &api.Error{
+			Code:    api.INVALID_PARAMETER_ERROR,
+			Message: "Missing or invalid original request method or uri",
+		})
+		WriteHttpResponse(r, w, requestInfo.RequestID, requestInfo.Identifier, http.StatusBadRequest, apiError)
+		return
+	}
+
+	// Check original request
+	checkRequest := new(http.Request)
+	*checkRequest = *r
+	checkRequest.Method = method
+	checkRequest.URL = checkURL
+	checkRequest.RequestURI = uri
+
+	wh.extAuthzHandler.ServeHTTP(w, checkRequest)
+}
+
+// PRIVATE HELPER METHODS
+
+// getOriginalRequest returns the method and uri of the original request, nginx headers take precedence
+func getOriginalRequest(r *http.Request) (string, string) {
+	method := r.Header.Get(ORIGINAL_METHOD_HEADER)
+	if method == "" {
+		method = r.Header.Get(FORWARDED_METHOD_HEADER)
+	}
+	uri := r.Header.Get(ORIGINAL_URI_HEADER)
+	if uri == "" {
+		uri = r.Header.Get(FORWARDED_URI_HEADER)
+	}
+	return method, uri
+}
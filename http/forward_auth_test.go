@@ -0,0 +1,212 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleForwardAuth(t *testing.T) {
+	proxyResources := []api.ProxyResource{
+		{
+			ID:   "ID1",
+			Name: "example",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:   "http://upstream",
+				Path:   "/example/:id",
+				Method: http.MethodPut,
+				Urn:    "urn:ews:example:instance1:resource/{id}",
+				Action: "example:update",
+			},
+		},
+		{
+			ID:   "ID2",
+			Name: "public",
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:   "http://upstream",
+				Path:   "/public/*rest",
+				Method: http.MethodGet,
+				Urn:    "urn:ews:example:instance1:public",
+				Action: "example:read",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		// Request headers
+		headers map[string]string
+		// Expected result
+		expectedStatusCode int
+		expectedUserID     string
+		expectedUrn        string
+		expectedError      api.Error
+		// Manager Results
+		getAuthorizedExternalResourcesResult []string
+		// Manager Errors
+		getAuthorizedExternalResourcesErr error
+	}{
+		"OkCaseNginx": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER: http.MethodPut,
+				ORIGINAL_URI_HEADER:    "/example/res1?query=value",
+			},
+			expectedStatusCode:                   http.StatusOK,
+			expectedUserID:                       "userID",
+			expectedUrn:                          "urn:ews:example:instance1:resource/res1",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/res1"},
+		},
+		"OkCaseTraefik": {
+			headers: map[string]string{
+				FORWARDED_METHOD_HEADER: http.MethodPut,
+				FORWARDED_URI_HEADER:    "/example/res2",
+			},
+			expectedStatusCode:                   http.StatusOK,
+			expectedUserID:                       "userID",
+			expectedUrn:                          "urn:ews:example:instance1:resource/res2",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/res2"},
+		},
+		"OkCaseNginxHeadersFirst": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER:  http.MethodPut,
+				ORIGINAL_URI_HEADER:     "/example/res1",
+				FORWARDED_METHOD_HEADER: http.MethodGet,
+				FORWARDED_URI_HEADER:    "/example/res2",
+			},
+			expectedStatusCode:                   http.StatusOK,
+			expectedUserID:                       "userID",
+			expectedUrn:                          "urn:ews:example:instance1:resource/res1",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/res1"},
+		},
+		"ErrorCaseDenied": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER: http.MethodPut,
+				ORIGINAL_URI_HEADER:    "/example/res1",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedUrn:        "urn:ews:example:instance1:resource/res1",
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseResourceNotFound": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER: http.MethodGet,
+				ORIGINAL_URI_HEADER:    "/example/res1",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+		},
+		"ErrorCaseDotSegments": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER: http.MethodGet,
+				ORIGINAL_URI_HEADER:    "/public/../example/res1",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:public"},
+		},
+		"ErrorCaseEncodedDotSegments": {
+			headers: map[string]string{
+				FORWARDED_METHOD_HEADER: http.MethodGet,
+				FORWARDED_URI_HEADER:    "/public/%2e%2E%2Fexample/res1",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:public"},
+		},
+		"ErrorCaseRepeatedSlashes": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER: http.MethodGet,
+				ORIGINAL_URI_HEADER:    "/public//example",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    FORBIDDEN_ERROR,
+				Message: "Forbidden resource. If you need access, contact the administrator",
+			},
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:public"},
+		},
+		"OkCasePublic": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER: http.MethodGet,
+				ORIGINAL_URI_HEADER:    "/public/docs/index.html",
+			},
+			expectedStatusCode:                   http.StatusOK,
+			expectedUserID:                       "userID",
+			expectedUrn:                          "urn:ews:example:instance1:public",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:public"},
+		},
+		"ErrorCaseMissingMethod": {
+			headers: map[string]string{
+				ORIGINAL_URI_HEADER: "/example/res1",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Bad request",
+			},
+		},
+		"ErrorCaseInvalidUri": {
+			headers: map[string]string{
+				ORIGINAL_METHOD_HEADER: http.MethodPut,
+				ORIGINAL_URI_HEADER:    "example/res1",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Bad request",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsOut[GetProxyResourcesMethod][0] = proxyResources
+		testApi.ArgsOut[GetProxyResourcesMethod][1] = nil
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][0] = test.getAuthorizedExternalResourcesResult
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][1] = test.getAuthorizedExternalResourcesErr
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+FORWARD_AUTH_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+		for key, value := range test.headers {
+			req.Header.Set(key, value)
+		}
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code and headers
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+		assert.Equal(t, test.expectedUserID, res.Header.Get(middleware.USER_ID_HEADER), "Error in test case %v", n)
+		assert.Equal(t, test.expectedUrn, res.Header.Get(EXT_AUTHZ_URN_HEADER), "Error in test case %v", n)
+
+		if res.StatusCode == http.StatusOK {
+			continue
+		}
+		apiError := api.Error{}
+		err = json.NewDecoder(res.Body).Decode(&apiError)
+		assert.Nil(t, err, "Error in test case %v", n)
+		// Check result
+		assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+	}
+}
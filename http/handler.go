@@ -1,25 +1,32 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/metrics"
+	"github.com/Tecsisa/foulkon/tracing"
 	"github.com/julienschmidt/httprouter"
 )
 
 const (
 	// Constants for values in url
-	USER_ID             = "userid"
-	GROUP_NAME          = "groupname"
-	POLICY_NAME         = "policyname"
-	PROXY_RESOURCE_NAME = "proxyresourcename"
-	AUTH_PROVIDER_NAME  = "authprovidername"
-	ORG_NAME            = "orgname"
+	USER_ID              = "userid"
+	GROUP_NAME           = "groupname"
+	POLICY_NAME          = "policyname"
+	POLICY_TEMPLATE_NAME = "policytemplatename"
+	PROXY_RESOURCE_NAME  = "proxyresourcename"
+	AUTH_PROVIDER_NAME   = "authprovidername"
+	CHANGE_REQUEST_ID    = "changerequestid"
+	WEBHOOK_NAME         = "webhookname"
+	ORG_NAME             = "orgname"
 
 	// URI Path param prefix
 	URI_PATH_PREFIX = "/:"
@@ -49,12 +56,30 @@ const (
 	POLICY_ID_URL        = POLICY_ROOT_URL + URI_PATH_PREFIX + POLICY_NAME
 	POLICY_ID_GROUPS_URL = POLICY_ROOT_URL + URI_PATH_PREFIX + POLICY_NAME + "/groups"
 
+	// Policy template API urls
+	POLICY_TEMPLATE_ROOT_URL        = API_VERSION_1 + ORG_ROOT + "/policy-templates"
+	POLICY_TEMPLATE_ID_URL          = POLICY_TEMPLATE_ROOT_URL + URI_PATH_PREFIX + POLICY_TEMPLATE_NAME
+	POLICY_TEMPLATE_ID_POLICIES_URL = POLICY_TEMPLATE_ID_URL + "/policies"
+
 	// Proxy resource API urls
 	PROXY_RESOURCE_ROOT_URL = API_VERSION_1 + ORG_ROOT + "/proxy-resources"
 	PROXY_RESOURCE_ID_URL   = PROXY_RESOURCE_ROOT_URL + URI_PATH_PREFIX + PROXY_RESOURCE_NAME
 
+	// Change request API urls
+	CHANGE_REQUEST_ROOT_URL       = API_VERSION_1 + ORG_ROOT + "/change-requests"
+	CHANGE_REQUEST_ID_URL         = CHANGE_REQUEST_ROOT_URL + URI_PATH_PREFIX + CHANGE_REQUEST_ID
+	CHANGE_REQUEST_ID_APPROVE_URL = CHANGE_REQUEST_ID_URL + "/approve"
+	CHANGE_REQUEST_ID_REJECT_URL  = CHANGE_REQUEST_ID_URL + "/reject"
+
 	// Authorization URLs
-	RESOURCE_URL = API_VERSION_1 + "/resource"
+	RESOURCE_URL              = API_VERSION_1 + "/resource"
+	RESOURCE_RESTRICTIONS_URL = RESOURCE_URL + "/restrictions"
+
+	// Envoy external authorization URL, followed by the path of the checked request
+	EXT_AUTHZ_URL = API_VERSION_1 + "/ext-authz"
+
+	// Forward auth URL, the checked request comes in headers
+	FORWARD_AUTH_URL = API_VERSION_1 + "/forward-auth"
 
 	// Admin URLs
 	ADMIN_ROOT = "/admin"
@@ -63,8 +88,34 @@ const (
 	OIDC_AUTH_ROOT_URL = API_VERSION_1 + ADMIN_ROOT + "/auth/oidc/providers"
 	OIDC_AUTH_ID_URL   = OIDC_AUTH_ROOT_URL + URI_PATH_PREFIX + AUTH_PROVIDER_NAME
 
+	// Admin break-glass API URLs
+	BREAK_GLASS_SESSIONS_URL = API_VERSION_1 + ADMIN_ROOT + "/break-glass/sessions"
+
+	// Admin audit API URL
+	AUDIT_URL = API_VERSION_1 + ADMIN_ROOT + "/audit"
+
+	// Admin webhook API URLs
+	WEBHOOK_ROOT_URL = API_VERSION_1 + ADMIN_ROOT + "/webhooks"
+	WEBHOOK_ID_URL   = WEBHOOK_ROOT_URL + URI_PATH_PREFIX + WEBHOOK_NAME
+
+	// Admin proxy resources watch URL
+	PROXY_RESOURCES_WATCH_URL = API_VERSION_1 + ADMIN_ROOT + "/proxy-resources/watch"
+
+	// Admin authorization snapshot URL
+	AUTHZ_SNAPSHOT_URL = API_VERSION_1 + ADMIN_ROOT + "/authz/snapshot"
+
+	// Admin Kubernetes authorization webhook URL
+	K8S_SUBJECT_ACCESS_REVIEW_URL = API_VERSION_1 + ADMIN_ROOT + "/k8s/subjectaccessreview"
+
 	// Foulkon configuration URL
 	ABOUT = "/about"
+
+	// Prometheus metrics URL
+	METRICS_URL = "/metrics"
+
+	// Health endpoints, without authentication
+	HEALTHZ_URL = "/healthz"
+	READYZ_URL  = "/readyz"
 )
 
 // PROXY
@@ -77,13 +128,30 @@ type ProxyHandler struct {
 // WORKER
 
 type WorkerHandler struct {
-	worker *foulkon.Worker
+	worker          *foulkon.Worker
+	extAuthzHandler *ExtAuthzHandler
 }
 
 func (wh *WorkerHandler) processHttpRequest(r *http.Request, w http.ResponseWriter, ps httprouter.Params, request interface{}) (
 	requestInfo api.RequestInfo, filterData *api.Filter, apiError *api.Error) {
 	// Get Request Info
 	requestInfo = wh.getRequestInfo(r)
+	// Retrieve dry run flag for methods that modify data
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+		if dryRun := r.URL.Query().Get("dryRun"); len(dryRun) != 0 {
+			var err error
+			requestInfo.DryRun, err = strconv.ParseBool(dryRun)
+			if err != nil {
+				apiError = &api.Error{
+					Code:    api.INVALID_PARAMETER_ERROR,
+					Message: fmt.Sprintf("Invalid parameter: dryRun %v", dryRun),
+				}
+				api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, apiError)
+				return requestInfo, nil, apiError
+			}
+		}
+	}
 	// Decode request if passed
 	if request != nil {
 		err := json.NewDecoder(r.Body).Decode(&request)
@@ -114,8 +182,12 @@ func (wh *WorkerHandler) processHttpResponse(r *http.Request, w http.ResponseWri
 			api.USER_IS_ALREADY_A_MEMBER_OF_GROUP,
 			api.PROXY_RESOURCE_ALREADY_EXIST,
 			api.POLICY_IS_ALREADY_ATTACHED_TO_GROUP, api.POLICY_ALREADY_EXIST,
+			api.POLICY_TEMPLATE_ALREADY_EXIST,
 			api.PROXY_RESOURCES_ROUTES_CONFLICT,
-			api.AUTH_OIDC_PROVIDER_ALREADY_EXIST:
+			api.AUTH_OIDC_PROVIDER_ALREADY_EXIST,
+			api.BREAK_GLASS_SESSION_ALREADY_ACTIVE,
+			api.CHANGE_REQUEST_NOT_PENDING,
+			api.WEBHOOK_ALREADY_EXIST:
 			// A conflict occurs
 			statusCode = http.StatusConflict
 		case api.UNAUTHORIZED_RESOURCES_ERROR:
@@ -124,9 +196,16 @@ func (wh *WorkerHandler) processHttpResponse(r *http.Request, w http.ResponseWri
 		case api.USER_BY_EXTERNAL_ID_NOT_FOUND, api.GROUP_BY_ORG_AND_NAME_NOT_FOUND,
 			api.USER_IS_NOT_A_MEMBER_OF_GROUP, api.POLICY_IS_NOT_ATTACHED_TO_GROUP,
 			api.POLICY_BY_ORG_AND_NAME_NOT_FOUND, api.PROXY_RESOURCE_BY_ORG_AND_NAME_NOT_FOUND,
-			api.AUTH_OIDC_PROVIDER_BY_NAME_NOT_FOUND:
+			api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			api.AUTH_OIDC_PROVIDER_BY_NAME_NOT_FOUND,
+			api.BREAK_GLASS_SESSION_NOT_FOUND,
+			api.CHANGE_REQUEST_NOT_FOUND,
+			api.WEBHOOK_BY_NAME_NOT_FOUND:
 			// Resource or relation not found
 			statusCode = http.StatusNotFound
+		case api.CHANGE_REQUEST_PENDING:
+			// Operation stored as a change request waiting for approval
+			statusCode = http.StatusAccepted
 		case api.INVALID_PARAMETER_ERROR, api.REGEX_NO_MATCH:
 			// Unexpected input in validation parameters
 			statusCode = http.StatusBadRequest
@@ -142,21 +221,18 @@ func (wh *WorkerHandler) processHttpResponse(r *http.Request, w http.ResponseWri
 }
 
 func (wh *WorkerHandler) getRequestInfo(r *http.Request) api.RequestInfo {
-	// Retrieve request information from middleware context
-	mc := wh.worker.MiddlewareHandler.GetMiddlewareContext(r)
-	return api.RequestInfo{
-		Identifier: mc.UserId,
-		Admin:      mc.Admin,
-		RequestID:  mc.XRequestId,
-	}
+	return wh.worker.GetRequestInfo(r)
 }
 
 // WorkerHandlerRouter returns http.Handler for the APIs.
 func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 	// Create the muxer to handle the actual endpoints
-	router := httprouter.New()
+	router := instrumentedRouter{httprouter.New()}
 
-	workerHandler := WorkerHandler{worker: worker}
+	workerHandler := WorkerHandler{
+		worker:          worker,
+		extAuthzHandler: NewExtAuthzHandler(worker),
+	}
 
 	// User api
 	router.GET(USER_ROOT_URL, workerHandler.HandleListUsers)
@@ -203,6 +279,28 @@ func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 	// Special endpoint without organization URI for policies
 	router.GET(API_VERSION_1+"/policies", workerHandler.HandleListAllPolicies)
 
+	// Policy template api
+	router.GET(POLICY_TEMPLATE_ROOT_URL, workerHandler.HandleListPolicyTemplates)
+	router.POST(POLICY_TEMPLATE_ROOT_URL, workerHandler.HandleAddPolicyTemplate)
+
+	router.DELETE(POLICY_TEMPLATE_ID_URL, workerHandler.HandleRemovePolicyTemplate)
+
+	router.GET(POLICY_TEMPLATE_ID_URL, workerHandler.HandleGetPolicyTemplateByName)
+	router.PUT(POLICY_TEMPLATE_ID_URL, workerHandler.HandleUpdatePolicyTemplate)
+
+	router.POST(POLICY_TEMPLATE_ID_POLICIES_URL, workerHandler.HandleInstantiatePolicyTemplate)
+
+	// Change request api
+	router.GET(CHANGE_REQUEST_ROOT_URL, workerHandler.HandleListChangeRequests)
+
+	router.GET(CHANGE_REQUEST_ID_URL, workerHandler.HandleGetChangeRequest)
+
+	router.POST(CHANGE_REQUEST_ID_APPROVE_URL, workerHandler.HandleApproveChangeRequest)
+	router.POST(CHANGE_REQUEST_ID_REJECT_URL, workerHandler.HandleRejectChangeRequest)
+
+	// Special endpoint without organization URI for change requests
+	router.GET(API_VERSION_1+"/change-requests", workerHandler.HandleListChangeRequests)
+
 	// Proxy Resources api
 	router.GET(PROXY_RESOURCE_ROOT_URL, workerHandler.HandleListProxyResource)
 	router.POST(PROXY_RESOURCE_ROOT_URL, workerHandler.HandleAddProxyResource)
@@ -214,6 +312,7 @@ func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 
 	// Resources authorized endpoint
 	router.POST(RESOURCE_URL, workerHandler.HandleGetAuthorizedExternalResources)
+	router.POST(RESOURCE_RESTRICTIONS_URL, workerHandler.HandleGetAuthorizedRestrictions)
 
 	// OIDC authentication api
 	router.GET(OIDC_AUTH_ROOT_URL, workerHandler.HandleListOidcProviders)
@@ -224,14 +323,62 @@ func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 	router.GET(OIDC_AUTH_ID_URL, workerHandler.HandleGetOidcProviderByName)
 	router.PUT(OIDC_AUTH_ID_URL, workerHandler.HandleUpdateOidcProvider)
 
+	// Break-glass api
+	router.GET(BREAK_GLASS_SESSIONS_URL, workerHandler.HandleListBreakGlassSessions)
+	router.POST(BREAK_GLASS_SESSIONS_URL, workerHandler.HandleActivateBreakGlassSession)
+
+	// Audit api
+	router.GET(AUDIT_URL, workerHandler.HandleListAuditEvents)
+
+	// Webhook api
+	router.GET(WEBHOOK_ROOT_URL, workerHandler.HandleListWebhooks)
+	router.POST(WEBHOOK_ROOT_URL, workerHandler.HandleAddWebhook)
+
+	router.DELETE(WEBHOOK_ID_URL, workerHandler.HandleRemoveWebhook)
+
+	router.GET(WEBHOOK_ID_URL, workerHandler.HandleGetWebhookByName)
+	router.PUT(WEBHOOK_ID_URL, workerHandler.HandleUpdateWebhook)
+
+	// Proxy resources watch api
+	router.GET(PROXY_RESOURCES_WATCH_URL, workerHandler.HandleWatchProxyResources)
+
+	// Authorization snapshot api
+	router.GET(AUTHZ_SNAPSHOT_URL, workerHandler.HandleGetAuthzSnapshot)
+
+	// Envoy external authorization api
+	extAuthzPath := EXT_AUTHZ_URL + "/*" + EXT_AUTHZ_PATH
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions} {
+		router.Handle(method, extAuthzPath, workerHandler.HandleExtAuthz)
+	}
+
+	// Forward auth api
+	router.GET(FORWARD_AUTH_URL, workerHandler.HandleForwardAuth)
+	router.HEAD(FORWARD_AUTH_URL, workerHandler.HandleForwardAuth)
+
+	// Kubernetes authorization webhook
+	router.POST(K8S_SUBJECT_ACCESS_REVIEW_URL, workerHandler.HandleReviewSubjectAccess)
+
 	// Current Foulkon configuration
 	router.GET(ABOUT, workerHandler.HandleGetCurrentConfig)
 
-	return workerHandler.worker.MiddlewareHandler.Handle(router)
+	// Prometheus metrics, unless they are served in their own address
+	if worker.MetricsAddress == "" {
+		router.GET(METRICS_URL, workerHandler.HandleGetMetrics)
+	}
+
+	return healthHandler(worker.ReadinessChecks, workerHandler.worker.MiddlewareHandler.Handle(router))
 }
 
 // WriteHttpResponse fill a http response with data, controlling marshalling errors
 func WriteHttpResponse(r *http.Request, w http.ResponseWriter, requestId string, userId string, statusCode int, value interface{}) {
+	// Record request metrics with the final status code
+	if info, ok := r.Context().Value(routeInfoKey{}).(routeInfo); ok {
+		defer func() {
+			metrics.ObserveHttpRequest(info.route, r.Method, statusCode, info.start)
+		}()
+	}
+
 	if value != nil {
 		b, err := json.Marshal(value)
 		if err != nil {
@@ -239,8 +386,9 @@ func WriteHttpResponse(r *http.Request, w http.ResponseWriter, requestId string,
 				Code:    api.UNKNOWN_API_ERROR,
 				Message: err.Error(),
 			}
-			api.TransactionResponseErrorLog(requestId, userId, r, http.StatusInternalServerError, apiErr)
-			w.WriteHeader(http.StatusInternalServerError)
+			statusCode = http.StatusInternalServerError
+			api.TransactionResponseErrorLog(requestId, userId, r, statusCode, apiErr)
+			w.WriteHeader(statusCode)
 			return
 		}
 		w.Header().Add("Content-Type", "application/json")
@@ -256,6 +404,49 @@ func WriteHttpResponse(r *http.Request, w http.ResponseWriter, requestId string,
 
 // Private Helper Methods
 
+// routeInfoKey is the request context key of the routeInfo
+type routeInfoKey struct{}
+
+// routeInfo holds the route pattern that matched the request and when it started to be handled
+type routeInfo struct {
+	route string
+	start time.Time
+}
+
+// instrumentedRouter registers handles keeping their route pattern in the request context,
+// so request metrics and spans aren't named with path parameters
+type instrumentedRouter struct {
+	*httprouter.Router
+}
+
+func (ir instrumentedRouter) GET(path string, handle httprouter.Handle) {
+	ir.Handle(http.MethodGet, path, handle)
+}
+
+func (ir instrumentedRouter) HEAD(path string, handle httprouter.Handle) {
+	ir.Handle(http.MethodHead, path, handle)
+}
+
+func (ir instrumentedRouter) POST(path string, handle httprouter.Handle) {
+	ir.Handle(http.MethodPost, path, handle)
+}
+
+func (ir instrumentedRouter) PUT(path string, handle httprouter.Handle) {
+	ir.Handle(http.MethodPut, path, handle)
+}
+
+func (ir instrumentedRouter) DELETE(path string, handle httprouter.Handle) {
+	ir.Handle(http.MethodDelete, path, handle)
+}
+
+func (ir instrumentedRouter) Handle(method string, path string, handle httprouter.Handle) {
+	ir.Router.Handle(method, path, func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		tracing.SpanFromContext(r.Context()).SetName(method + " " + path)
+		ctx := context.WithValue(r.Context(), routeInfoKey{}, routeInfo{route: path, start: time.Now()})
+		handle(w, r.WithContext(ctx), ps)
+	})
+}
+
 func getFilterData(r *http.Request, ps httprouter.Params) (*api.Filter, error) {
 	var err error
 	// Retrieve Offset
@@ -289,16 +480,76 @@ func getFilterData(r *http.Request, ps httprouter.Params) (*api.Filter, error) {
 		org = r.URL.Query().Get("Org")
 	}
 
+	// Retrieve user
+	var externalID string
+	if externalID = ps.ByName(USER_ID); len(externalID) == 0 {
+		externalID = r.URL.Query().Get("UserId")
+	}
+
+	// Retrieve time range
+	var from, to time.Time
+	if fromParam := r.URL.Query().Get("From"); len(fromParam) != 0 {
+		from, err = time.Parse(time.RFC3339, fromParam)
+		if err != nil {
+			return nil, &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: From %v", fromParam),
+			}
+		}
+	}
+	if toParam := r.URL.Query().Get("To"); len(toParam) != 0 {
+		to, err = time.Parse(time.RFC3339, toParam)
+		if err != nil {
+			return nil, &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: To %v", toParam),
+			}
+		}
+	}
+
+	// Retrieve proxy resources watch parameters
+	var revision int64
+	if revisionParam := r.URL.Query().Get("Revision"); len(revisionParam) != 0 {
+		revision, err = strconv.ParseInt(revisionParam, 10, 64)
+		if err != nil || revision < 0 {
+			return nil, &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: Revision %v", revisionParam),
+			}
+		}
+	}
+	var timeout time.Duration
+	if timeoutParam := r.URL.Query().Get("Timeout"); len(timeoutParam) != 0 {
+		timeout, err = time.ParseDuration(timeoutParam)
+		if err != nil || timeout <= 0 {
+			return nil, &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: Timeout %v", timeoutParam),
+			}
+		}
+	}
+
 	return &api.Filter{
-		PathPrefix:        r.URL.Query().Get("PathPrefix"),
-		Org:               org,
-		ExternalID:        ps.ByName(USER_ID),
-		PolicyName:        ps.ByName(POLICY_NAME),
-		GroupName:         ps.ByName(GROUP_NAME),
-		ProxyResourceName: ps.ByName(PROXY_RESOURCE_NAME),
-		AuthProviderName:  ps.ByName(AUTH_PROVIDER_NAME),
-		Offset:            offset,
-		Limit:             limit,
-		OrderBy:           r.URL.Query().Get("OrderBy"),
+		PathPrefix:         r.URL.Query().Get("PathPrefix"),
+		Org:                org,
+		ExternalID:         externalID,
+		PolicyName:         ps.ByName(POLICY_NAME),
+		GroupName:          ps.ByName(GROUP_NAME),
+		ProxyResourceName:  ps.ByName(PROXY_RESOURCE_NAME),
+		AuthProviderName:   ps.ByName(AUTH_PROVIDER_NAME),
+		PolicyTemplateName: ps.ByName(POLICY_TEMPLATE_NAME),
+		ChangeRequestID:    ps.ByName(CHANGE_REQUEST_ID),
+		WebhookName:        ps.ByName(WEBHOOK_NAME),
+		Status:             r.URL.Query().Get("Status"),
+		Actor:              r.URL.Query().Get("Actor"),
+		EntityUrn:          r.URL.Query().Get("Urn"),
+		Action:             r.URL.Query().Get("Action"),
+		From:               from,
+		To:                 to,
+		Revision:           revision,
+		Timeout:            timeout,
+		Offset:             offset,
+		Limit:              limit,
+		OrderBy:            r.URL.Query().Get("OrderBy"),
 	}, nil
 }
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/metrics"
+)
+
+const (
+	// Health statuses
+	HEALTH_STATUS_OK   = "ok"
+	HEALTH_STATUS_FAIL = "fail"
+
+	// Timeout of the worker health check made by the proxy
+	WORKER_HEALTH_TIMEOUT = 5 * time.Second
+)
+
+// RESPONSE
+
+type CheckStatus struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string                 `json:"status,omitempty"`
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// HANDLER
+
+// HandleHealthz answers that the server is alive, without checking its dependencies
+func HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	WriteHttpResponse(r, w, "", "", http.StatusOK, HealthResponse{Status: HEALTH_STATUS_OK})
+}
+
+// HandleReadyz runs the readiness checks, answering with the status of each one. Status code is
+// 503 if any check fails, so orchestrators stop sending traffic to the server
+func HandleReadyz(checks map[string]foulkon.ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := HealthResponse{
+			Status: HEALTH_STATUS_OK,
+			Checks: make(map[string]CheckStatus),
+		}
+		statusCode := http.StatusOK
+		// Run checks in a stable order
+		names := []string{}
+		for name := range checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := checks[name](); err != nil {
+				response.Checks[name] = CheckStatus{Status: HEALTH_STATUS_FAIL, Error: err.Error()}
+				response.Status = HEALTH_STATUS_FAIL
+				statusCode = http.StatusServiceUnavailable
+			} else {
+				response.Checks[name] = CheckStatus{Status: HEALTH_STATUS_OK}
+			}
+		}
+		WriteHttpResponse(r, w, "", "", statusCode, response)
+	}
+}
+
+// healthHandler serves health endpoints before next, so they don't need authentication and
+// can't be overridden by proxy resources
+func healthHandler(checks map[string]foulkon.ReadinessCheck, next http.Handler) http.Handler {
+	readyz := HandleReadyz(checks)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case HEALTHZ_URL:
+			HandleHealthz(w, r)
+		case READYZ_URL:
+			readyz(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// readinessChecks returns the proxy checks, adding to the configured ones whether resources were
+// loaded and the worker is reachable
+func (ps *ProxyServer) readinessChecks() map[string]foulkon.ReadinessCheck {
+	checks := make(map[string]foulkon.ReadinessCheck)
+	for name, check := range ps.proxy.ReadinessChecks {
+		checks[name] = check
+	}
+	checks["resources"] = func() error {
+		ps.resourceLock.Lock()
+		defer ps.resourceLock.Unlock()
+		if !ps.resourcesLoaded {
+			return fmt.Errorf("Proxy resources not loaded yet")
+		}
+		return nil
+	}
+	client := &http.Client{Timeout: WORKER_HEALTH_TIMEOUT}
+	checks["worker"] = func() error {
+		res, err := client.Get(ps.proxy.WorkerHost + HEALTHZ_URL)
+		if err != nil {
+			metrics.ObserveProxyWorkerUnreachable()
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("Unexpected status code %v", res.StatusCode)
+		}
+		return nil
+	}
+	return checks
+}
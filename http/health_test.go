@@ -0,0 +1,174 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleHealthz(t *testing.T) {
+	testcases := map[string]struct {
+		url string
+
+		expectedStatusCode int
+		expectedResponse   HealthResponse
+	}{
+		"OKCaseWorkerHealthz": {
+			url:                server.URL + HEALTHZ_URL,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: HealthResponse{
+				Status: HEALTH_STATUS_OK,
+			},
+		},
+		"OKCaseWorkerReadyzWithoutChecks": {
+			url:                server.URL + READYZ_URL,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: HealthResponse{
+				Status: HEALTH_STATUS_OK,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		// Health endpoints don't need authentication
+		req, err := http.NewRequest(http.MethodGet, test.url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		// Check result
+		response := HealthResponse{}
+		err = json.NewDecoder(res.Body).Decode(&response)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+	}
+}
+
+func TestHandleReadyz(t *testing.T) {
+	testcases := map[string]struct {
+		checks map[string]foulkon.ReadinessCheck
+
+		expectedStatusCode int
+		expectedResponse   HealthResponse
+	}{
+		"OKCase": {
+			checks: map[string]foulkon.ReadinessCheck{
+				"database": func() error { return nil },
+				"oidc":     func() error { return nil },
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: HealthResponse{
+				Status: HEALTH_STATUS_OK,
+				Checks: map[string]CheckStatus{
+					"database": {Status: HEALTH_STATUS_OK},
+					"oidc":     {Status: HEALTH_STATUS_OK},
+				},
+			},
+		},
+		"ErrorCaseCheckFailed": {
+			checks: map[string]foulkon.ReadinessCheck{
+				"database": func() error { return errors.New("connection refused") },
+				"oidc":     func() error { return nil },
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponse: HealthResponse{
+				Status: HEALTH_STATUS_FAIL,
+				Checks: map[string]CheckStatus{
+					"database": {Status: HEALTH_STATUS_FAIL, Error: "connection refused"},
+					"oidc":     {Status: HEALTH_STATUS_OK},
+				},
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		req := httptest.NewRequest(http.MethodGet, READYZ_URL, nil)
+		w := httptest.NewRecorder()
+		healthHandler(test.checks, http.NotFoundHandler()).ServeHTTP(w, req)
+		res := w.Result()
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		// Check result
+		response := HealthResponse{}
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+	}
+}
+
+func TestProxyServer_readinessChecks(t *testing.T) {
+	unhealthyWorker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthyWorker.Close()
+
+	testcases := map[string]struct {
+		workerHost      string
+		resourcesLoaded bool
+
+		expectedErrors map[string]string
+	}{
+		"OKCase": {
+			workerHost:      server.URL,
+			resourcesLoaded: true,
+			expectedErrors: map[string]string{
+				"database":  "",
+				"resources": "",
+				"worker":    "",
+			},
+		},
+		"ErrorCaseResourcesNotLoaded": {
+			workerHost: server.URL,
+			expectedErrors: map[string]string{
+				"database":  "",
+				"resources": "Proxy resources not loaded yet",
+				"worker":    "",
+			},
+		},
+		"ErrorCaseWorkerUnhealthy": {
+			workerHost:      unhealthyWorker.URL,
+			resourcesLoaded: true,
+			expectedErrors: map[string]string{
+				"database":  "",
+				"resources": "",
+				"worker":    "Unexpected status code 503",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		ps := &ProxyServer{
+			proxy: &foulkon.Proxy{
+				WorkerHost: test.workerHost,
+				ReadinessChecks: map[string]foulkon.ReadinessCheck{
+					"database": func() error { return nil },
+				},
+			},
+			resourcesLoaded: test.resourcesLoaded,
+		}
+
+		checks := ps.readinessChecks()
+		assert.Equal(t, len(test.expectedErrors), len(checks), "Error in test case %v", n)
+		for name, expectedError := range test.expectedErrors {
+			err := checks[name]()
+			if expectedError == "" {
+				assert.Nil(t, err, "Error in test case %v, check %v", n, name)
+			} else {
+				assert.EqualError(t, err, expectedError, "Error in test case %v, check %v", n, name)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"github.com/Tecsisa/foulkon/middleware/logger"
 	"github.com/Tecsisa/foulkon/middleware/xrequestid"
 	"github.com/julienschmidt/httprouter"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -55,6 +57,8 @@ const (
 	GetAuthorizedGroupsMethod            = "GetAuthorizedGroups"
 	GetAuthorizedPoliciesMethod          = "GetAuthorizedPolicies"
 	GetAuthorizedExternalResourcesMethod = "GetAuthorizedExternalResources"
+	GetAuthorizedRestrictionsMethod      = "GetAuthorizedRestrictions"
+	GetAuthzSnapshotMethod               = "GetAuthzSnapshot"
 	GetAuthorizedProxyResources          = "GetAuthorizedProxyResources"
 
 	// PROXY API
@@ -64,6 +68,7 @@ const (
 	UpdateProxyResourceMethod    = "UpdateProxyResource"
 	RemoveProxyResourceMethod    = "RemoveProxyResource"
 	ListProxyResourcesMethod     = "ListProxyResources"
+	WatchProxyResourcesMethod    = "WatchProxyResources"
 
 	// AUTH OIDC PROVIDER API
 	AddOidcProviderMethod       = "AddOidcProvider"
@@ -71,6 +76,38 @@ const (
 	ListOidcProvidersMethod     = "ListOidcProviders"
 	UpdateOidcProviderMethod    = "UpdateOidcProvider"
 	RemoveOidcProviderMethod    = "RemoveOidcProvider"
+
+	// POLICY TEMPLATE API
+	AddPolicyTemplateMethod         = "AddPolicyTemplate"
+	GetPolicyTemplateByNameMethod   = "GetPolicyTemplateByName"
+	ListPolicyTemplatesMethod       = "ListPolicyTemplates"
+	UpdatePolicyTemplateMethod      = "UpdatePolicyTemplate"
+	RemovePolicyTemplateMethod      = "RemovePolicyTemplate"
+	InstantiatePolicyTemplateMethod = "InstantiatePolicyTemplate"
+
+	// BREAK-GLASS API
+	ActivateBreakGlassSessionMethod  = "ActivateBreakGlassSession"
+	GetActiveBreakGlassSessionMethod = "GetActiveBreakGlassSession"
+	UseBreakGlassSessionMethod       = "UseBreakGlassSession"
+	ListBreakGlassSessionsMethod     = "ListBreakGlassSessions"
+
+	// CHANGE REQUEST API
+	GetChangeRequestMethod     = "GetChangeRequest"
+	ListChangeRequestsMethod   = "ListChangeRequests"
+	ApproveChangeRequestMethod = "ApproveChangeRequest"
+	RejectChangeRequestMethod  = "RejectChangeRequest"
+
+	// AUDIT API
+	ListAuditEventsMethod  = "ListAuditEvents"
+	PurgeAuditEventsMethod = "PurgeAuditEvents"
+
+	// WEBHOOK API
+	AddWebhookMethod       = "AddWebhook"
+	GetWebhookByNameMethod = "GetWebhookByName"
+	ListWebhooksMethod     = "ListWebhooks"
+	UpdateWebhookMethod    = "UpdateWebhook"
+	RemoveWebhookMethod    = "RemoveWebhook"
+	DeliverWebhooksMethod  = "DeliverWebhooks"
 )
 
 // Test server used to test handlers
@@ -139,14 +176,22 @@ func TestMain(m *testing.M) {
 		userID: "userID",
 	}
 
-	adminUser := "admin"
-	adminPassword := "admin"
+	adminPassword, err := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+	admins := map[string][]byte{"admin": adminPassword}
+	breakGlassPassword, err := bcrypt.GenerateFromPassword([]byte("emergency"), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
 
 	// Middlewares
 	middlewares := make(map[string]middleware.Middleware)
 
 	// Authenticator middleware
-	authenticatorMiddleware := auth.NewAuthenticatorMiddleware(authConnector, adminUser, adminPassword)
+	breakGlassUsers := map[string][]byte{"emergency": breakGlassPassword}
+	authenticatorMiddleware := auth.NewAuthenticatorMiddleware(authConnector, admins, breakGlassUsers)
 	middlewares[middleware.AUTHENTICATOR_MIDDLEWARE] = authenticatorMiddleware
 
 	// X-Request-Id middleware
@@ -194,7 +239,18 @@ func TestMain(m *testing.M) {
 		AuthzApi:          testApi,
 		ProxyApi:          testApi,
 		AuthOidcAPI:       testApi,
-		Config:            config,
+		PolicyTemplateApi: testApi,
+		BreakGlassApi:     testApi,
+		ChangeRequestApi:  testApi,
+		AuditApi:          testApi,
+		WebhookApi:        testApi,
+		InternalProxyApi:  testApi,
+		K8sMapping: foulkon.K8sMapping{
+			Urn:            "urn:k8s:cluster:{namespace}:{group}/{resource}/{subresource}/{name}",
+			NonResourceUrn: "urn:k8s:cluster:nonresource:{path}",
+			Action:         "k8s:{verb}",
+		},
+		Config: config,
 	}
 
 	server = httptest.NewServer(WorkerHandlerRouter(worker))
@@ -251,6 +307,8 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsIn[GetAuthorizedGroupsMethod] = make([]interface{}, 4)
 	testApi.ArgsIn[GetAuthorizedPoliciesMethod] = make([]interface{}, 4)
 	testApi.ArgsIn[GetAuthorizedExternalResourcesMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[GetAuthorizedRestrictionsMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[GetAuthzSnapshotMethod] = make([]interface{}, 1)
 	testApi.ArgsIn[GetAuthorizedProxyResources] = make([]interface{}, 4)
 
 	testApi.ArgsIn[AddProxyResourceMethod] = make([]interface{}, 5)
@@ -259,6 +317,7 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsIn[UpdateProxyResourceMethod] = make([]interface{}, 6)
 	testApi.ArgsIn[RemoveProxyResourceMethod] = make([]interface{}, 3)
 	testApi.ArgsIn[ListProxyResourcesMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[WatchProxyResourcesMethod] = make([]interface{}, 3)
 
 	testApi.ArgsIn[AddOidcProviderMethod] = make([]interface{}, 5)
 	testApi.ArgsIn[GetOidcProviderByNameMethod] = make([]interface{}, 2)
@@ -266,6 +325,31 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsIn[UpdateOidcProviderMethod] = make([]interface{}, 6)
 	testApi.ArgsIn[RemoveOidcProviderMethod] = make([]interface{}, 2)
 
+	testApi.ArgsIn[AddPolicyTemplateMethod] = make([]interface{}, 6)
+	testApi.ArgsIn[GetPolicyTemplateByNameMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[ListPolicyTemplatesMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[UpdatePolicyTemplateMethod] = make([]interface{}, 7)
+	testApi.ArgsIn[RemovePolicyTemplateMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[InstantiatePolicyTemplateMethod] = make([]interface{}, 6)
+
+	testApi.ArgsIn[ActivateBreakGlassSessionMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[GetActiveBreakGlassSessionMethod] = make([]interface{}, 1)
+	testApi.ArgsIn[UseBreakGlassSessionMethod] = make([]interface{}, 1)
+	testApi.ArgsIn[ListBreakGlassSessionsMethod] = make([]interface{}, 2)
+
+	testApi.ArgsIn[GetChangeRequestMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[ListChangeRequestsMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[ApproveChangeRequestMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[RejectChangeRequestMethod] = make([]interface{}, 4)
+
+	testApi.ArgsIn[ListAuditEventsMethod] = make([]interface{}, 2)
+
+	testApi.ArgsIn[AddWebhookMethod] = make([]interface{}, 5)
+	testApi.ArgsIn[GetWebhookByNameMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[ListWebhooksMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[UpdateWebhookMethod] = make([]interface{}, 6)
+	testApi.ArgsIn[RemoveWebhookMethod] = make([]interface{}, 2)
+
 	testApi.ArgsOut[AddUserMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetUserByExternalIdMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[ListUsersMethod] = make([]interface{}, 3)
@@ -296,6 +380,8 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsOut[GetAuthorizedGroupsMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetAuthorizedPoliciesMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetAuthorizedExternalResourcesMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetAuthorizedRestrictionsMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetAuthzSnapshotMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetAuthorizedProxyResources] = make([]interface{}, 2)
 
 	testApi.ArgsOut[AddProxyResourceMethod] = make([]interface{}, 2)
@@ -304,6 +390,7 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsOut[UpdateProxyResourceMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[RemoveProxyResourceMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[ListProxyResourcesMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[WatchProxyResourcesMethod] = make([]interface{}, 3)
 
 	testApi.ArgsOut[AddOidcProviderMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetOidcProviderByNameMethod] = make([]interface{}, 2)
@@ -311,6 +398,33 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsOut[UpdateOidcProviderMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[RemoveOidcProviderMethod] = make([]interface{}, 1)
 
+	testApi.ArgsOut[AddPolicyTemplateMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetPolicyTemplateByNameMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[ListPolicyTemplatesMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[UpdatePolicyTemplateMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[RemovePolicyTemplateMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[InstantiatePolicyTemplateMethod] = make([]interface{}, 2)
+
+	testApi.ArgsOut[ActivateBreakGlassSessionMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetActiveBreakGlassSessionMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[UseBreakGlassSessionMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[ListBreakGlassSessionsMethod] = make([]interface{}, 3)
+
+	testApi.ArgsOut[GetChangeRequestMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[ListChangeRequestsMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[ApproveChangeRequestMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[RejectChangeRequestMethod] = make([]interface{}, 2)
+
+	testApi.ArgsOut[ListAuditEventsMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[PurgeAuditEventsMethod] = make([]interface{}, 1)
+
+	testApi.ArgsOut[AddWebhookMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetWebhookByNameMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[ListWebhooksMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[UpdateWebhookMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[RemoveWebhookMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[DeliverWebhooksMethod] = make([]interface{}, 1)
+
 	return testApi
 }
 
@@ -705,6 +819,35 @@ func (t TestAPI) GetAuthorizedExternalResources(authenticatedUser api.RequestInf
 	return resourcesToReturn, err
 }
 
+func (t TestAPI) GetAuthorizedRestrictions(authenticatedUser api.RequestInfo, action string, urnPrefix string, column string) (*api.AuthorizedRestrictions, error) {
+	t.ArgsIn[GetAuthorizedRestrictionsMethod][0] = authenticatedUser
+	t.ArgsIn[GetAuthorizedRestrictionsMethod][1] = action
+	t.ArgsIn[GetAuthorizedRestrictionsMethod][2] = urnPrefix
+	t.ArgsIn[GetAuthorizedRestrictionsMethod][3] = column
+	var restrictions *api.AuthorizedRestrictions
+	if t.ArgsOut[GetAuthorizedRestrictionsMethod][0] != nil {
+		restrictions = t.ArgsOut[GetAuthorizedRestrictionsMethod][0].(*api.AuthorizedRestrictions)
+	}
+	var err error
+	if t.ArgsOut[GetAuthorizedRestrictionsMethod][1] != nil {
+		err = t.ArgsOut[GetAuthorizedRestrictionsMethod][1].(error)
+	}
+	return restrictions, err
+}
+
+func (t TestAPI) GetAuthzSnapshot(authenticatedUser api.RequestInfo) (*api.AuthzSnapshot, error) {
+	t.ArgsIn[GetAuthzSnapshotMethod][0] = authenticatedUser
+	var snapshot *api.AuthzSnapshot
+	if t.ArgsOut[GetAuthzSnapshotMethod][0] != nil {
+		snapshot = t.ArgsOut[GetAuthzSnapshotMethod][0].(*api.AuthzSnapshot)
+	}
+	var err error
+	if t.ArgsOut[GetAuthzSnapshotMethod][1] != nil {
+		err = t.ArgsOut[GetAuthzSnapshotMethod][1].(error)
+	}
+	return snapshot, err
+}
+
 func (t TestAPI) GetAuthorizedProxyResources(authenticatedUser api.RequestInfo, resourceUrn string, action string, proxyResources []api.ProxyResource) ([]api.ProxyResource, error) {
 	return nil, nil
 }
@@ -804,6 +947,28 @@ func (t TestAPI) RemoveProxyResource(authenticatedUser api.RequestInfo, org stri
 	return err
 }
 
+func (t TestAPI) WatchProxyResources(ctx context.Context, authenticatedUser api.RequestInfo, revision int64, timeout time.Duration) (*api.ProxyResourcesWatch, bool, error) {
+	t.ArgsIn[WatchProxyResourcesMethod][0] = authenticatedUser
+	t.ArgsIn[WatchProxyResourcesMethod][1] = revision
+	t.ArgsIn[WatchProxyResourcesMethod][2] = timeout
+	if specialFunc, ok := t.SpecialFuncs[WatchProxyResourcesMethod].(func(ctx context.Context) (*api.ProxyResourcesWatch, bool, error)); ok && specialFunc != nil {
+		return specialFunc(ctx)
+	}
+	var watch *api.ProxyResourcesWatch
+	if t.ArgsOut[WatchProxyResourcesMethod][0] != nil {
+		watch = t.ArgsOut[WatchProxyResourcesMethod][0].(*api.ProxyResourcesWatch)
+	}
+	var modified bool
+	if t.ArgsOut[WatchProxyResourcesMethod][1] != nil {
+		modified = t.ArgsOut[WatchProxyResourcesMethod][1].(bool)
+	}
+	var err error
+	if t.ArgsOut[WatchProxyResourcesMethod][2] != nil {
+		err = t.ArgsOut[WatchProxyResourcesMethod][2].(error)
+	}
+	return watch, modified, err
+}
+
 func (t TestAPI) AddOidcProvider(requestInfo api.RequestInfo, name string, path string, issuerURL string, oidcClients []string) (*api.OidcProvider, error) {
 	t.ArgsIn[AddOidcProviderMethod][0] = requestInfo
 	t.ArgsIn[AddOidcProviderMethod][1] = name
@@ -885,6 +1050,371 @@ func (t TestAPI) RemoveOidcProvider(requestInfo api.RequestInfo, name string) er
 	return err
 }
 
+// POLICY TEMPLATE API
+
+func (t TestAPI) AddPolicyTemplate(requestInfo api.RequestInfo, name string, path string, org string,
+	parameters []api.PolicyTemplateParameter, statements []api.Statement) (*api.PolicyTemplate, error) {
+	t.ArgsIn[AddPolicyTemplateMethod][0] = requestInfo
+	t.ArgsIn[AddPolicyTemplateMethod][1] = name
+	t.ArgsIn[AddPolicyTemplateMethod][2] = path
+	t.ArgsIn[AddPolicyTemplateMethod][3] = org
+	t.ArgsIn[AddPolicyTemplateMethod][4] = parameters
+	t.ArgsIn[AddPolicyTemplateMethod][5] = statements
+
+	var policyTemplate *api.PolicyTemplate
+	if t.ArgsOut[AddPolicyTemplateMethod][0] != nil {
+		policyTemplate = t.ArgsOut[AddPolicyTemplateMethod][0].(*api.PolicyTemplate)
+	}
+	var err error
+	if t.ArgsOut[AddPolicyTemplateMethod][1] != nil {
+		err = t.ArgsOut[AddPolicyTemplateMethod][1].(error)
+	}
+	return policyTemplate, err
+}
+
+func (t TestAPI) GetPolicyTemplateByName(requestInfo api.RequestInfo, org string, name string) (*api.PolicyTemplate, error) {
+	t.ArgsIn[GetPolicyTemplateByNameMethod][0] = requestInfo
+	t.ArgsIn[GetPolicyTemplateByNameMethod][1] = org
+	t.ArgsIn[GetPolicyTemplateByNameMethod][2] = name
+
+	var policyTemplate *api.PolicyTemplate
+	if t.ArgsOut[GetPolicyTemplateByNameMethod][0] != nil {
+		policyTemplate = t.ArgsOut[GetPolicyTemplateByNameMethod][0].(*api.PolicyTemplate)
+	}
+	var err error
+	if t.ArgsOut[GetPolicyTemplateByNameMethod][1] != nil {
+		err = t.ArgsOut[GetPolicyTemplateByNameMethod][1].(error)
+	}
+	return policyTemplate, err
+}
+
+func (t TestAPI) ListPolicyTemplates(requestInfo api.RequestInfo, filter *api.Filter) ([]api.PolicyTemplateIdentity, int, error) {
+	t.ArgsIn[ListPolicyTemplatesMethod][0] = requestInfo
+	t.ArgsIn[ListPolicyTemplatesMethod][1] = filter
+
+	var policyTemplates []api.PolicyTemplateIdentity
+	if t.ArgsOut[ListPolicyTemplatesMethod][0] != nil {
+		policyTemplates = t.ArgsOut[ListPolicyTemplatesMethod][0].([]api.PolicyTemplateIdentity)
+	}
+	var total int
+	if t.ArgsOut[ListPolicyTemplatesMethod][1] != nil {
+		total = t.ArgsOut[ListPolicyTemplatesMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[ListPolicyTemplatesMethod][2] != nil {
+		err = t.ArgsOut[ListPolicyTemplatesMethod][2].(error)
+	}
+	return policyTemplates, total, err
+}
+
+func (t TestAPI) UpdatePolicyTemplate(requestInfo api.RequestInfo, org string, name string, newName string, newPath string,
+	newParameters []api.PolicyTemplateParameter, newStatements []api.Statement) (*api.PolicyTemplate, error) {
+	t.ArgsIn[UpdatePolicyTemplateMethod][0] = requestInfo
+	t.ArgsIn[UpdatePolicyTemplateMethod][1] = org
+	t.ArgsIn[UpdatePolicyTemplateMethod][2] = name
+	t.ArgsIn[UpdatePolicyTemplateMethod][3] = newName
+	t.ArgsIn[UpdatePolicyTemplateMethod][4] = newPath
+	t.ArgsIn[UpdatePolicyTemplateMethod][5] = newParameters
+	t.ArgsIn[UpdatePolicyTemplateMethod][6] = newStatements
+
+	var policyTemplate *api.PolicyTemplate
+	if t.ArgsOut[UpdatePolicyTemplateMethod][0] != nil {
+		policyTemplate = t.ArgsOut[UpdatePolicyTemplateMethod][0].(*api.PolicyTemplate)
+	}
+	var err error
+	if t.ArgsOut[UpdatePolicyTemplateMethod][1] != nil {
+		err = t.ArgsOut[UpdatePolicyTemplateMethod][1].(error)
+	}
+	return policyTemplate, err
+}
+
+func (t TestAPI) RemovePolicyTemplate(requestInfo api.RequestInfo, org string, name string) error {
+	t.ArgsIn[RemovePolicyTemplateMethod][0] = requestInfo
+	t.ArgsIn[RemovePolicyTemplateMethod][1] = org
+	t.ArgsIn[RemovePolicyTemplateMethod][2] = name
+
+	var err error
+	if t.ArgsOut[RemovePolicyTemplateMethod][0] != nil {
+		err = t.ArgsOut[RemovePolicyTemplateMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) InstantiatePolicyTemplate(requestInfo api.RequestInfo, org string, templateName string, policyName string,
+	policyPath string, parameters map[string]string) (*api.Policy, error) {
+	t.ArgsIn[InstantiatePolicyTemplateMethod][0] = requestInfo
+	t.ArgsIn[InstantiatePolicyTemplateMethod][1] = org
+	t.ArgsIn[InstantiatePolicyTemplateMethod][2] = templateName
+	t.ArgsIn[InstantiatePolicyTemplateMethod][3] = policyName
+	t.ArgsIn[InstantiatePolicyTemplateMethod][4] = policyPath
+	t.ArgsIn[InstantiatePolicyTemplateMethod][5] = parameters
+
+	var policy *api.Policy
+	if t.ArgsOut[InstantiatePolicyTemplateMethod][0] != nil {
+		policy = t.ArgsOut[InstantiatePolicyTemplateMethod][0].(*api.Policy)
+	}
+	var err error
+	if t.ArgsOut[InstantiatePolicyTemplateMethod][1] != nil {
+		err = t.ArgsOut[InstantiatePolicyTemplateMethod][1].(error)
+	}
+	return policy, err
+}
+
+// BREAK-GLASS API
+
+func (t TestAPI) ActivateBreakGlassSession(requestInfo api.RequestInfo, justification string) (*api.BreakGlassSession, error) {
+	t.ArgsIn[ActivateBreakGlassSessionMethod][0] = requestInfo
+	t.ArgsIn[ActivateBreakGlassSessionMethod][1] = justification
+
+	var session *api.BreakGlassSession
+	if t.ArgsOut[ActivateBreakGlassSessionMethod][0] != nil {
+		session = t.ArgsOut[ActivateBreakGlassSessionMethod][0].(*api.BreakGlassSession)
+	}
+	var err error
+	if t.ArgsOut[ActivateBreakGlassSessionMethod][1] != nil {
+		err = t.ArgsOut[ActivateBreakGlassSessionMethod][1].(error)
+	}
+	return session, err
+}
+
+func (t TestAPI) GetActiveBreakGlassSession(userID string) (*api.BreakGlassSession, error) {
+	t.ArgsIn[GetActiveBreakGlassSessionMethod][0] = userID
+
+	var session *api.BreakGlassSession
+	if t.ArgsOut[GetActiveBreakGlassSessionMethod][0] != nil {
+		session = t.ArgsOut[GetActiveBreakGlassSessionMethod][0].(*api.BreakGlassSession)
+	}
+	var err error
+	if t.ArgsOut[GetActiveBreakGlassSessionMethod][1] != nil {
+		err = t.ArgsOut[GetActiveBreakGlassSessionMethod][1].(error)
+	}
+	return session, err
+}
+
+func (t TestAPI) UseBreakGlassSession(requestInfo api.RequestInfo) (*api.BreakGlassSession, error) {
+	t.ArgsIn[UseBreakGlassSessionMethod][0] = requestInfo
+
+	var session *api.BreakGlassSession
+	if t.ArgsOut[UseBreakGlassSessionMethod][0] != nil {
+		session = t.ArgsOut[UseBreakGlassSessionMethod][0].(*api.BreakGlassSession)
+	}
+	var err error
+	if t.ArgsOut[UseBreakGlassSessionMethod][1] != nil {
+		err = t.ArgsOut[UseBreakGlassSessionMethod][1].(error)
+	}
+	return session, err
+}
+
+func (t TestAPI) ListBreakGlassSessions(requestInfo api.RequestInfo, filter *api.Filter) ([]api.BreakGlassSession, int, error) {
+	t.ArgsIn[ListBreakGlassSessionsMethod][0] = requestInfo
+	t.ArgsIn[ListBreakGlassSessionsMethod][1] = filter
+
+	var sessions []api.BreakGlassSession
+	var total int
+	if t.ArgsOut[ListBreakGlassSessionsMethod][1] != nil {
+		total = t.ArgsOut[ListBreakGlassSessionsMethod][1].(int)
+	}
+	if t.ArgsOut[ListBreakGlassSessionsMethod][0] != nil {
+		sessions = t.ArgsOut[ListBreakGlassSessionsMethod][0].([]api.BreakGlassSession)
+	}
+	var err error
+	if t.ArgsOut[ListBreakGlassSessionsMethod][2] != nil {
+		err = t.ArgsOut[ListBreakGlassSessionsMethod][2].(error)
+	}
+	return sessions, total, err
+}
+
+// CHANGE REQUEST API
+
+func (t TestAPI) GetChangeRequest(requestInfo api.RequestInfo, org string, id string) (*api.ChangeRequest, error) {
+	t.ArgsIn[GetChangeRequestMethod][0] = requestInfo
+	t.ArgsIn[GetChangeRequestMethod][1] = org
+	t.ArgsIn[GetChangeRequestMethod][2] = id
+
+	var changeRequest *api.ChangeRequest
+	if t.ArgsOut[GetChangeRequestMethod][0] != nil {
+		changeRequest = t.ArgsOut[GetChangeRequestMethod][0].(*api.ChangeRequest)
+	}
+	var err error
+	if t.ArgsOut[GetChangeRequestMethod][1] != nil {
+		err = t.ArgsOut[GetChangeRequestMethod][1].(error)
+	}
+	return changeRequest, err
+}
+
+func (t TestAPI) ListChangeRequests(requestInfo api.RequestInfo, filter *api.Filter) ([]api.ChangeRequest, int, error) {
+	t.ArgsIn[ListChangeRequestsMethod][0] = requestInfo
+	t.ArgsIn[ListChangeRequestsMethod][1] = filter
+
+	var changeRequests []api.ChangeRequest
+	var total int
+	if t.ArgsOut[ListChangeRequestsMethod][1] != nil {
+		total = t.ArgsOut[ListChangeRequestsMethod][1].(int)
+	}
+	if t.ArgsOut[ListChangeRequestsMethod][0] != nil {
+		changeRequests = t.ArgsOut[ListChangeRequestsMethod][0].([]api.ChangeRequest)
+	}
+	var err error
+	if t.ArgsOut[ListChangeRequestsMethod][2] != nil {
+		err = t.ArgsOut[ListChangeRequestsMethod][2].(error)
+	}
+	return changeRequests, total, err
+}
+
+func (t TestAPI) ApproveChangeRequest(requestInfo api.RequestInfo, org string, id string, comment string) (*api.ChangeRequest, error) {
+	t.ArgsIn[ApproveChangeRequestMethod][0] = requestInfo
+	t.ArgsIn[ApproveChangeRequestMethod][1] = org
+	t.ArgsIn[ApproveChangeRequestMethod][2] = id
+	t.ArgsIn[ApproveChangeRequestMethod][3] = comment
+
+	var changeRequest *api.ChangeRequest
+	if t.ArgsOut[ApproveChangeRequestMethod][0] != nil {
+		changeRequest = t.ArgsOut[ApproveChangeRequestMethod][0].(*api.ChangeRequest)
+	}
+	var err error
+	if t.ArgsOut[ApproveChangeRequestMethod][1] != nil {
+		err = t.ArgsOut[ApproveChangeRequestMethod][1].(error)
+	}
+	return changeRequest, err
+}
+
+func (t TestAPI) RejectChangeRequest(requestInfo api.RequestInfo, org string, id string, comment string) (*api.ChangeRequest, error) {
+	t.ArgsIn[RejectChangeRequestMethod][0] = requestInfo
+	t.ArgsIn[RejectChangeRequestMethod][1] = org
+	t.ArgsIn[RejectChangeRequestMethod][2] = id
+	t.ArgsIn[RejectChangeRequestMethod][3] = comment
+
+	var changeRequest *api.ChangeRequest
+	if t.ArgsOut[RejectChangeRequestMethod][0] != nil {
+		changeRequest = t.ArgsOut[RejectChangeRequestMethod][0].(*api.ChangeRequest)
+	}
+	var err error
+	if t.ArgsOut[RejectChangeRequestMethod][1] != nil {
+		err = t.ArgsOut[RejectChangeRequestMethod][1].(error)
+	}
+	return changeRequest, err
+}
+
+// AUDIT API
+
+func (t TestAPI) ListAuditEvents(requestInfo api.RequestInfo, filter *api.Filter) ([]api.AuditEvent, int, error) {
+	t.ArgsIn[ListAuditEventsMethod][0] = requestInfo
+	t.ArgsIn[ListAuditEventsMethod][1] = filter
+
+	var events []api.AuditEvent
+	var total int
+	if t.ArgsOut[ListAuditEventsMethod][1] != nil {
+		total = t.ArgsOut[ListAuditEventsMethod][1].(int)
+	}
+	if t.ArgsOut[ListAuditEventsMethod][0] != nil {
+		events = t.ArgsOut[ListAuditEventsMethod][0].([]api.AuditEvent)
+	}
+	var err error
+	if t.ArgsOut[ListAuditEventsMethod][2] != nil {
+		err = t.ArgsOut[ListAuditEventsMethod][2].(error)
+	}
+	return events, total, err
+}
+
+func (t TestAPI) PurgeAuditEvents() error {
+	var err error
+	if t.ArgsOut[PurgeAuditEventsMethod][0] != nil {
+		err = t.ArgsOut[PurgeAuditEventsMethod][0].(error)
+	}
+	return err
+}
+
+// WEBHOOK API
+
+func (t TestAPI) AddWebhook(requestInfo api.RequestInfo, name string, webhookURL string, events []string, secret string) (*api.Webhook, error) {
+	t.ArgsIn[AddWebhookMethod][0] = requestInfo
+	t.ArgsIn[AddWebhookMethod][1] = name
+	t.ArgsIn[AddWebhookMethod][2] = webhookURL
+	t.ArgsIn[AddWebhookMethod][3] = events
+	t.ArgsIn[AddWebhookMethod][4] = secret
+	var webhook *api.Webhook
+	if t.ArgsOut[AddWebhookMethod][0] != nil {
+		webhook = t.ArgsOut[AddWebhookMethod][0].(*api.Webhook)
+	}
+	var err error
+	if t.ArgsOut[AddWebhookMethod][1] != nil {
+		err = t.ArgsOut[AddWebhookMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestAPI) GetWebhookByName(requestInfo api.RequestInfo, name string) (*api.Webhook, error) {
+	t.ArgsIn[GetWebhookByNameMethod][0] = requestInfo
+	t.ArgsIn[GetWebhookByNameMethod][1] = name
+	var webhook *api.Webhook
+	if t.ArgsOut[GetWebhookByNameMethod][0] != nil {
+		webhook = t.ArgsOut[GetWebhookByNameMethod][0].(*api.Webhook)
+	}
+	var err error
+	if t.ArgsOut[GetWebhookByNameMethod][1] != nil {
+		err = t.ArgsOut[GetWebhookByNameMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestAPI) ListWebhooks(requestInfo api.RequestInfo, filter *api.Filter) ([]string, int, error) {
+	t.ArgsIn[ListWebhooksMethod][0] = requestInfo
+	t.ArgsIn[ListWebhooksMethod][1] = filter
+
+	var webhooks []string
+	var total int
+	if t.ArgsOut[ListWebhooksMethod][1] != nil {
+		total = t.ArgsOut[ListWebhooksMethod][1].(int)
+	}
+	if t.ArgsOut[ListWebhooksMethod][0] != nil {
+		webhooks = t.ArgsOut[ListWebhooksMethod][0].([]string)
+	}
+	var err error
+	if t.ArgsOut[ListWebhooksMethod][2] != nil {
+		err = t.ArgsOut[ListWebhooksMethod][2].(error)
+	}
+	return webhooks, total, err
+}
+
+func (t TestAPI) UpdateWebhook(requestInfo api.RequestInfo, webhookName string, newName string, newURL string, newEvents []string,
+	newSecret string) (*api.Webhook, error) {
+
+	t.ArgsIn[UpdateWebhookMethod][0] = requestInfo
+	t.ArgsIn[UpdateWebhookMethod][1] = webhookName
+	t.ArgsIn[UpdateWebhookMethod][2] = newName
+	t.ArgsIn[UpdateWebhookMethod][3] = newURL
+	t.ArgsIn[UpdateWebhookMethod][4] = newEvents
+	t.ArgsIn[UpdateWebhookMethod][5] = newSecret
+
+	var webhook *api.Webhook
+	if t.ArgsOut[UpdateWebhookMethod][0] != nil {
+		webhook = t.ArgsOut[UpdateWebhookMethod][0].(*api.Webhook)
+	}
+	var err error
+	if t.ArgsOut[UpdateWebhookMethod][1] != nil {
+		err = t.ArgsOut[UpdateWebhookMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestAPI) RemoveWebhook(requestInfo api.RequestInfo, name string) error {
+	t.ArgsIn[RemoveWebhookMethod][0] = requestInfo
+	t.ArgsIn[RemoveWebhookMethod][1] = name
+	var err error
+	if t.ArgsOut[RemoveWebhookMethod][0] != nil {
+		err = t.ArgsOut[RemoveWebhookMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) DeliverWebhooks() error {
+	var err error
+	if t.ArgsOut[DeliverWebhooksMethod][0] != nil {
+		err = t.ArgsOut[DeliverWebhooksMethod][0].(error)
+	}
+	return err
+}
+
 // Private helper methods
 
 func addQueryParams(filter *api.Filter, r *http.Request) {
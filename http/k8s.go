@@ -0,0 +1,166 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// SubjectAccessReview API
+	K8S_AUTHORIZATION_API_VERSION = "authorization.k8s.io/v1"
+	K8S_SUBJECT_ACCESS_REVIEW     = "SubjectAccessReview"
+
+	// Value for empty SubjectAccessReview attributes in urns, like the namespace of cluster scoped resources
+	K8S_EMPTY_ATTRIBUTE = "_"
+)
+
+// REQUESTS
+
+type SubjectAccessReview struct {
+	APIVersion string                    `json:"apiVersion,omitempty"`
+	Kind       string                    `json:"kind,omitempty"`
+	Spec       SubjectAccessReviewSpec   `json:"spec"`
+	Status     SubjectAccessReviewStatus `json:"status"`
+}
+
+type SubjectAccessReviewSpec struct {
+	ResourceAttributes    *K8sResourceAttributes    `json:"resourceAttributes,omitempty"`
+	NonResourceAttributes *K8sNonResourceAttributes `json:"nonResourceAttributes,omitempty"`
+	User                  string                    `json:"user,omitempty"`
+	Groups                []string                  `json:"groups,omitempty"`
+	UID                   string                    `json:"uid,omitempty"`
+}
+
+type K8sResourceAttributes struct {
+	Namespace   string `json:"namespace,omitempty"`
+	Verb        string `json:"verb,omitempty"`
+	Group       string `json:"group,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Resource    string `json:"resource,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+type K8sNonResourceAttributes struct {
+	Path string `json:"path,omitempty"`
+	Verb string `json:"verb,omitempty"`
+}
+
+// RESPONSES
+
+type SubjectAccessReviewStatus struct {
+	Allowed         bool   `json:"allowed"`
+	Denied          bool   `json:"denied,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+	EvaluationError string `json:"evaluationError,omitempty"`
+}
+
+// HANDLERS
+
+// HandleReviewSubjectAccess implements the Kubernetes authorization webhook. The user of the review is evaluated
+// with the urn and action mapped from the review attributes, so only admin users can review subject access
+func (wh *WorkerHandler) HandleReviewSubjectAccess(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &SubjectAccessReview{}
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Only admin can check permissions of other users
+	if !requestInfo.Admin {
+		wh.processHttpResponse(r, w, requestInfo, nil, &api.Error{
+			Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to review subject access", requestInfo.Identifier),
+		}, http.StatusOK)
+		return
+	}
+
+	response := SubjectAccessReview{
+		APIVersion: request.APIVersion,
+		Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+	}
+	if response.APIVersion == "" {
+		response.APIVersion = K8S_AUTHORIZATION_API_VERSION
+	}
+
+	urn, action, err := wh.getK8sResource(request.Spec)
+	if err == nil {
+		subject := api.RequestInfo{
+			Identifier: request.Spec.User,
+			RequestID:  requestInfo.RequestID,
+			Span:       requestInfo.Span,
+		}
+		_, err = wh.worker.AuthzApi.GetAuthorizedExternalResources(subject, action, []string{urn})
+	}
+	if err != nil {
+		apiError := err.(*api.Error)
+		switch apiError.Code {
+		case api.UNAUTHORIZED_RESOURCES_ERROR:
+			response.Status.Denied = wh.worker.K8sMapping.Deny
+			response.Status.Reason = fmt.Sprintf("User %v is not allowed to do action %v over resource %v", request.Spec.User, action, urn)
+		case api.INVALID_PARAMETER_ERROR, api.REGEX_NO_MATCH:
+			// Attributes don't map to a valid external resource
+			api.LogOperationError(requestInfo.RequestID, requestInfo.Identifier, apiError)
+			response.Status.Reason = "Invalid subject access review attributes"
+			response.Status.EvaluationError = apiError.Message
+		default:
+			wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusOK)
+			return
+		}
+	} else {
+		response.Status.Allowed = true
+		response.Status.Reason = fmt.Sprintf("User %v is allowed to do action %v over resource %v", request.Spec.User, action, urn)
+	}
+
+	wh.processHttpResponse(r, w, requestInfo, response, nil, http.StatusOK)
+}
+
+// PRIVATE HELPER METHODS
+
+// getK8sResource returns the urn and action mapped from review attributes with the worker K8sMapping
+func (wh *WorkerHandler) getK8sResource(spec SubjectAccessReviewSpec) (string, string, error) {
+	mapping := wh.worker.K8sMapping
+	switch {
+	case spec.ResourceAttributes != nil:
+		attributes := spec.ResourceAttributes
+		urn := replaceK8sAttributes(mapping.Urn, map[string]string{
+			"namespace":   attributes.Namespace,
+			"group":       attributes.Group,
+			"resource":    attributes.Resource,
+			"subresource": attributes.Subresource,
+			"name":        attributes.Name,
+		})
+		action := replaceK8sAttributes(mapping.Action, map[string]string{"verb": attributes.Verb})
+		return urn, action, nil
+	case spec.NonResourceAttributes != nil:
+		attributes := spec.NonResourceAttributes
+		urn := replaceK8sAttributes(mapping.NonResourceUrn, map[string]string{
+			"path": strings.Trim(attributes.Path, "/"),
+		})
+		action := replaceK8sAttributes(mapping.Action, map[string]string{"verb": attributes.Verb})
+		return urn, action, nil
+	default:
+		return "", "", &api.Error{
+			Code:    api.INVALID_PARAMETER_ERROR,
+			Message: "Missing resource or non resource attributes",
+		}
+	}
+}
+
+// replaceK8sAttributes replaces the {attribute} parameters of the template, using K8S_EMPTY_ATTRIBUTE for empty values
+func replaceK8sAttributes(template string, attributes map[string]string) string {
+	result := template
+	for name, value := range attributes {
+		if value == "" {
+			value = K8S_EMPTY_ATTRIBUTE
+		}
+		result = strings.Replace(result, "{"+name+"}", value, -1)
+	}
+	return result
+}
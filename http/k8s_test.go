@@ -0,0 +1,282 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleReviewSubjectAccess(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		request *SubjectAccessReview
+		user    string
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   SubjectAccessReview
+		expectedError      api.Error
+		expectedSubject    string
+		expectedAction     string
+		expectedResources  []string
+		// Manager Results
+		getAuthorizedExternalResourcesResult []string
+		// Manager Errors
+		getAuthorizedExternalResourcesErr error
+	}{
+		"OkCaseAllowed": {
+			request: &SubjectAccessReview{
+				APIVersion: "authorization.k8s.io/v1beta1",
+				Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+				Spec: SubjectAccessReviewSpec{
+					ResourceAttributes: &K8sResourceAttributes{
+						Namespace: "prod",
+						Verb:      "get",
+						Version:   "v1",
+						Resource:  "pods",
+						Name:      "pod1",
+					},
+					User: "user1",
+				},
+			},
+			user:               "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: SubjectAccessReview{
+				APIVersion: "authorization.k8s.io/v1beta1",
+				Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+				Status: SubjectAccessReviewStatus{
+					Allowed: true,
+					Reason:  "User user1 is allowed to do action k8s:get over resource urn:k8s:cluster:prod:_/pods/_/pod1",
+				},
+			},
+			expectedSubject:                      "user1",
+			expectedAction:                       "k8s:get",
+			expectedResources:                    []string{"urn:k8s:cluster:prod:_/pods/_/pod1"},
+			getAuthorizedExternalResourcesResult: []string{"urn:k8s:cluster:prod:_/pods/_/pod1"},
+		},
+		"OkCaseAllowedSubresource": {
+			request: &SubjectAccessReview{
+				Spec: SubjectAccessReviewSpec{
+					ResourceAttributes: &K8sResourceAttributes{
+						Namespace:   "prod",
+						Verb:        "create",
+						Group:       "apps",
+						Resource:    "deployments",
+						Subresource: "scale",
+						Name:        "deploy1",
+					},
+					User: "user1",
+				},
+			},
+			user:               "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: SubjectAccessReview{
+				APIVersion: K8S_AUTHORIZATION_API_VERSION,
+				Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+				Status: SubjectAccessReviewStatus{
+					Allowed: true,
+					Reason:  "User user1 is allowed to do action k8s:create over resource urn:k8s:cluster:prod:apps/deployments/scale/deploy1",
+				},
+			},
+			expectedSubject:                      "user1",
+			expectedAction:                       "k8s:create",
+			expectedResources:                    []string{"urn:k8s:cluster:prod:apps/deployments/scale/deploy1"},
+			getAuthorizedExternalResourcesResult: []string{"urn:k8s:cluster:prod:apps/deployments/scale/deploy1"},
+		},
+		"OkCaseAllowedNonResource": {
+			request: &SubjectAccessReview{
+				Spec: SubjectAccessReviewSpec{
+					NonResourceAttributes: &K8sNonResourceAttributes{
+						Path: "/healthz",
+						Verb: "get",
+					},
+					User: "user1",
+				},
+			},
+			user:               "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: SubjectAccessReview{
+				APIVersion: K8S_AUTHORIZATION_API_VERSION,
+				Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+				Status: SubjectAccessReviewStatus{
+					Allowed: true,
+					Reason:  "User user1 is allowed to do action k8s:get over resource urn:k8s:cluster:nonresource:healthz",
+				},
+			},
+			expectedSubject:                      "user1",
+			expectedAction:                       "k8s:get",
+			expectedResources:                    []string{"urn:k8s:cluster:nonresource:healthz"},
+			getAuthorizedExternalResourcesResult: []string{"urn:k8s:cluster:nonresource:healthz"},
+		},
+		"OkCaseNotAllowed": {
+			request: &SubjectAccessReview{
+				Spec: SubjectAccessReviewSpec{
+					ResourceAttributes: &K8sResourceAttributes{
+						Verb:     "list",
+						Resource: "nodes",
+					},
+					User: "user1",
+				},
+			},
+			user:               "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: SubjectAccessReview{
+				APIVersion: K8S_AUTHORIZATION_API_VERSION,
+				Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+				Status: SubjectAccessReviewStatus{
+					Reason: "User user1 is not allowed to do action k8s:list over resource urn:k8s:cluster:_:_/nodes/_/_",
+				},
+			},
+			expectedSubject:   "user1",
+			expectedAction:    "k8s:list",
+			expectedResources: []string{"urn:k8s:cluster:_:_/nodes/_/_"},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"OkCaseInvalidAttributes": {
+			request: &SubjectAccessReview{
+				Spec: SubjectAccessReviewSpec{
+					ResourceAttributes: &K8sResourceAttributes{
+						Verb:     "*",
+						Resource: "pods",
+					},
+					User: "user1",
+				},
+			},
+			user:               "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: SubjectAccessReview{
+				APIVersion: K8S_AUTHORIZATION_API_VERSION,
+				Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+				Status: SubjectAccessReviewStatus{
+					Reason:          "Invalid subject access review attributes",
+					EvaluationError: "Invalid parameter action, value: k8s:*",
+				},
+			},
+			expectedSubject:   "user1",
+			expectedAction:    "k8s:*",
+			expectedResources: []string{"urn:k8s:cluster:_:_/pods/_/_"},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action, value: k8s:*",
+			},
+		},
+		"OkCaseMissingAttributes": {
+			request: &SubjectAccessReview{
+				Spec: SubjectAccessReviewSpec{
+					User: "user1",
+				},
+			},
+			user:               "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: SubjectAccessReview{
+				APIVersion: K8S_AUTHORIZATION_API_VERSION,
+				Kind:       K8S_SUBJECT_ACCESS_REVIEW,
+				Status: SubjectAccessReviewStatus{
+					Reason:          "Invalid subject access review attributes",
+					EvaluationError: "Missing resource or non resource attributes",
+				},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			user:               "admin",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseNotAdmin": {
+			request: &SubjectAccessReview{
+				Spec: SubjectAccessReviewSpec{
+					NonResourceAttributes: &K8sNonResourceAttributes{
+						Path: "/healthz",
+						Verb: "get",
+					},
+					User: "user1",
+				},
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId userID is not allowed to review subject access",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			request: &SubjectAccessReview{
+				Spec: SubjectAccessReviewSpec{
+					NonResourceAttributes: &K8sNonResourceAttributes{
+						Path: "/healthz",
+						Verb: "get",
+					},
+					User: "user1",
+				},
+			},
+			user:               "admin",
+			expectedStatusCode: http.StatusInternalServerError,
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsIn[GetAuthorizedExternalResourcesMethod] = make([]interface{}, 3)
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][0] = test.getAuthorizedExternalResourcesResult
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][1] = test.getAuthorizedExternalResourcesErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+K8S_SUBJECT_ACCESS_REVIEW_URL, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+		if test.user != "" {
+			req.SetBasicAuth(test.user, "admin")
+		}
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := SubjectAccessReview{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			// Check evaluated subject
+			if test.expectedSubject != "" {
+				requestInfo := testApi.ArgsIn[GetAuthorizedExternalResourcesMethod][0].(api.RequestInfo)
+				assert.Equal(t, test.expectedSubject, requestInfo.Identifier, "Error in test case %v", n)
+				assert.False(t, requestInfo.Admin, "Error in test case %v", n)
+				assert.Equal(t, test.expectedAction, testApi.ArgsIn[GetAuthorizedExternalResourcesMethod][1], "Error in test case %v", n)
+				assert.Equal(t, test.expectedResources, testApi.ArgsIn[GetAuthorizedExternalResourcesMethod][2], "Error in test case %v", n)
+			}
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
+	"github.com/julienschmidt/httprouter"
+)
+
+// REQUEST HANDLERS
+
+func (wh *WorkerHandler) HandleGetMetrics(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	requestInfo := wh.getRequestInfo(r)
+
+	// Only admin is authorized
+	if !requestInfo.Admin {
+		err := &api.Error{
+			Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+			Message: "Unauthorized, user is not admin",
+		}
+		wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusForbidden)
+		return
+	}
+
+	metrics.Handler().ServeHTTP(w, r)
+}
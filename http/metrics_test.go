@@ -0,0 +1,82 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleGetMetrics(t *testing.T) {
+	testcases := map[string]struct {
+		adminUser     string
+		adminPassword string
+
+		expectedStatusCode int
+		expectedMetrics    []string
+		expectedError      api.Error
+	}{
+		"OKCase": {
+			adminUser:          "admin",
+			adminPassword:      "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedMetrics: []string{
+				`foulkon_http_requests_total{method="GET",route="/about",status="200"}`,
+				`foulkon_http_requests_total{method="GET",route="/about",status="403"}`,
+				`foulkon_http_request_duration_seconds_count{method="GET",route="/about",status="200"}`,
+			},
+		},
+		"ErrorCaseInvalidAdmin": {
+			adminUser:          "admin",
+			adminPassword:      "fail",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized, user is not admin",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	// Requests recorded in metrics with their route
+	for _, password := range []string{"admin", "fail"} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+ABOUT, nil)
+		assert.Nil(t, err, "Error in test")
+		req.SetBasicAuth("admin", password)
+		_, err = client.Do(req)
+		assert.Nil(t, err, "Error in test")
+	}
+
+	for n, test := range testcases {
+		req, err := http.NewRequest(http.MethodGet, server.URL+METRICS_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		req.SetBasicAuth(test.adminUser, test.adminPassword)
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			buffer := new(bytes.Buffer)
+			_, err = buffer.ReadFrom(res.Body)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			for _, metric := range test.expectedMetrics {
+				assert.Contains(t, buffer.String(), metric, "Error in test case %v", n)
+			}
+		default:
+			apiError := api.Error{}
+			// Check error
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
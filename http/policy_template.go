@@ -0,0 +1,132 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+// REQUESTS
+
+type CreatePolicyTemplateRequest struct {
+	Name       string                        `json:"name,omitempty"`
+	Path       string                        `json:"path,omitempty"`
+	Parameters []api.PolicyTemplateParameter `json:"parameters,omitempty"`
+	Statements []api.Statement               `json:"statements,omitempty"`
+}
+
+type UpdatePolicyTemplateRequest struct {
+	Name       string                        `json:"name,omitempty"`
+	Path       string                        `json:"path,omitempty"`
+	Parameters []api.PolicyTemplateParameter `json:"parameters,omitempty"`
+	Statements []api.Statement               `json:"statements,omitempty"`
+}
+
+type InstantiatePolicyTemplateRequest struct {
+	Name       string            `json:"name,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// RESPONSES
+
+type ListPolicyTemplatesResponse struct {
+	PolicyTemplates []string `json:"policyTemplates,omitempty"`
+	Limit           int      `json:"limit"`
+	Offset          int      `json:"offset"`
+	Total           int      `json:"total"`
+}
+
+// HANDLERS
+
+func (wh *WorkerHandler) HandleAddPolicyTemplate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &CreatePolicyTemplateRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call policy template API to create policy template
+	response, err := wh.worker.PolicyTemplateApi.AddPolicyTemplate(requestInfo, request.Name, request.Path, filterData.Org,
+		request.Parameters, request.Statements)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusCreated)
+}
+
+func (wh *WorkerHandler) HandleGetPolicyTemplateByName(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call policy template API to retrieve policy template
+	response, err := wh.worker.PolicyTemplateApi.GetPolicyTemplateByName(requestInfo, filterData.Org, filterData.PolicyTemplateName)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleListPolicyTemplates(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call policy template API to list policy templates
+	result, total, err := wh.worker.PolicyTemplateApi.ListPolicyTemplates(requestInfo, filterData)
+	// Create response
+	policyTemplates := []string{}
+	for _, policyTemplate := range result {
+		policyTemplates = append(policyTemplates, policyTemplate.Name)
+	}
+	response := &ListPolicyTemplatesResponse{
+		PolicyTemplates: policyTemplates,
+		Offset:          filterData.Offset,
+		Limit:           filterData.Limit,
+		Total:           total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleUpdatePolicyTemplate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &UpdatePolicyTemplateRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call policy template API to update policy template
+	response, err := wh.worker.PolicyTemplateApi.UpdatePolicyTemplate(requestInfo, filterData.Org, filterData.PolicyTemplateName,
+		request.Name, request.Path, request.Parameters, request.Statements)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleRemovePolicyTemplate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call policy template API to remove policy template
+	err := wh.worker.PolicyTemplateApi.RemovePolicyTemplate(requestInfo, filterData.Org, filterData.PolicyTemplateName)
+	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
+}
+
+func (wh *WorkerHandler) HandleInstantiatePolicyTemplate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &InstantiatePolicyTemplateRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call policy template API to create a policy from the policy template
+	response, err := wh.worker.PolicyTemplateApi.InstantiatePolicyTemplate(requestInfo, filterData.Org, filterData.PolicyTemplateName,
+		request.Name, request.Path, request.Parameters)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusCreated)
+}
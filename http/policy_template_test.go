@@ -0,0 +1,897 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleAddPolicyTemplate(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org     string
+		request *CreatePolicyTemplateRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.PolicyTemplate
+		expectedError      api.Error
+		// Manager Results
+		addPolicyTemplateResult *api.PolicyTemplate
+		// Manager Errors
+		addPolicyTemplateErr error
+	}{
+		"OkCase": {
+			org: "org1",
+			request: &CreatePolicyTemplateRequest{
+				Name: "team",
+				Path: "/path/",
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name:    "team",
+						Pattern: "[a-z]+",
+					},
+				},
+				Statements: []api.Statement{
+					{
+						Effect: "allow",
+						Actions: []string{
+							api.USER_ACTION_GET_USER,
+						},
+						Resources: []string{
+							"urn:iws:iam::user/{{team}}/*",
+						},
+					},
+				},
+			},
+			addPolicyTemplateResult: &api.PolicyTemplate{
+				ID:       "test1",
+				Name:     "team",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "team"),
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name:    "team",
+						Pattern: "[a-z]+",
+					},
+				},
+				Statements: &[]api.Statement{
+					{
+						Effect: "allow",
+						Actions: []string{
+							api.USER_ACTION_GET_USER,
+						},
+						Resources: []string{
+							"urn:iws:iam::user/{{team}}/*",
+						},
+					},
+				},
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse: api.PolicyTemplate{
+				ID:       "test1",
+				Name:     "team",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "team"),
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name:    "team",
+						Pattern: "[a-z]+",
+					},
+				},
+				Statements: &[]api.Statement{
+					{
+						Effect: "allow",
+						Actions: []string{
+							api.USER_ACTION_GET_USER,
+						},
+						Resources: []string{
+							"urn:iws:iam::user/{{team}}/*",
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			org:                "org1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCasePolicyTemplateAlreadyExists": {
+			org: "org1",
+			request: &CreatePolicyTemplateRequest{
+				Name: "team",
+				Path: "/path/",
+			},
+			addPolicyTemplateErr: &api.Error{
+				Code: api.POLICY_TEMPLATE_ALREADY_EXIST,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code: api.POLICY_TEMPLATE_ALREADY_EXIST,
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			org: "org1",
+			request: &CreatePolicyTemplateRequest{
+				Name: "team",
+				Path: "/path/**",
+			},
+			addPolicyTemplateErr: &api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			org: "org1",
+			request: &CreatePolicyTemplateRequest{
+				Name: "team",
+				Path: "/path/",
+			},
+			addPolicyTemplateErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			org: "org1",
+			request: &CreatePolicyTemplateRequest{
+				Name: "team",
+				Path: "/path/",
+			},
+			addPolicyTemplateErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedError: api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[AddPolicyTemplateMethod][0] = test.addPolicyTemplateResult
+		testApi.ArgsOut[AddPolicyTemplateMethod][1] = test.addPolicyTemplateErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policy-templates", test.org)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[AddPolicyTemplateMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.Path, testApi.ArgsIn[AddPolicyTemplateMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.org, testApi.ArgsIn[AddPolicyTemplateMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Parameters, testApi.ArgsIn[AddPolicyTemplateMethod][4], "Error in test case %v", n)
+			assert.Equal(t, test.request.Statements, testApi.ArgsIn[AddPolicyTemplateMethod][5], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusCreated:
+			response := api.PolicyTemplate{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleGetPolicyTemplateByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org          string
+		templateName string
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.PolicyTemplate
+		expectedError      api.Error
+		// Manager Results
+		getPolicyTemplateByNameResult *api.PolicyTemplate
+		// Manager Errors
+		getPolicyTemplateByNameErr error
+	}{
+		"OkCase": {
+			org:                "org1",
+			templateName:       "team",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.PolicyTemplate{
+				ID:       "test1",
+				Name:     "team",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "team"),
+			},
+			getPolicyTemplateByNameResult: &api.PolicyTemplate{
+				ID:       "test1",
+				Name:     "team",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "team"),
+			},
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			org:                "org1",
+			templateName:       "team",
+			expectedStatusCode: http.StatusNotFound,
+			getPolicyTemplateByNameErr: &api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+			expectedError: api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			org:                "org1",
+			templateName:       "team",
+			expectedStatusCode: http.StatusForbidden,
+			getPolicyTemplateByNameErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedError: api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			org:                "org1",
+			templateName:       "team",
+			expectedStatusCode: http.StatusInternalServerError,
+			getPolicyTemplateByNameErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetPolicyTemplateByNameMethod][0] = test.getPolicyTemplateByNameResult
+		testApi.ArgsOut[GetPolicyTemplateByNameMethod][1] = test.getPolicyTemplateByNameErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policy-templates/%v", test.org, test.templateName)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		assert.Equal(t, test.org, testApi.ArgsIn[GetPolicyTemplateByNameMethod][1], "Error in test case %v", n)
+		assert.Equal(t, test.templateName, testApi.ArgsIn[GetPolicyTemplateByNameMethod][2], "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.PolicyTemplate{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListPolicyTemplates(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		filter *api.Filter
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListPolicyTemplatesResponse
+		expectedError      api.Error
+		// Manager Results
+		listPolicyTemplatesResult []api.PolicyTemplateIdentity
+		totalListResult           int
+		// Manager Errors
+		listPolicyTemplatesErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				Org:        "org1",
+				PathPrefix: "/path/",
+				Offset:     0,
+				Limit:      0,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: ListPolicyTemplatesResponse{
+				PolicyTemplates: []string{"team"},
+				Offset:          0,
+				Limit:           0,
+				Total:           1,
+			},
+			listPolicyTemplatesResult: []api.PolicyTemplateIdentity{
+				{
+					Org:  "org1",
+					Name: "team",
+				},
+			},
+			totalListResult: 1,
+		},
+		"ErrorCaseInvalidParameter": {
+			filter: &api.Filter{
+				Org:        "org1",
+				PathPrefix: "/path/**",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			listPolicyTemplatesErr: &api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+			expectedError: api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			filter: &api.Filter{
+				Org: "org1",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			listPolicyTemplatesErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListPolicyTemplatesMethod][0] = test.listPolicyTemplatesResult
+		testApi.ArgsOut[ListPolicyTemplatesMethod][1] = test.totalListResult
+		testApi.ArgsOut[ListPolicyTemplatesMethod][2] = test.listPolicyTemplatesErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policy-templates", test.filter.Org)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+		addQueryParams(test.filter, req)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		assert.Equal(t, test.filter, testApi.ArgsIn[ListPolicyTemplatesMethod][1], "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := ListPolicyTemplatesResponse{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleUpdatePolicyTemplate(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org          string
+		templateName string
+		request      *UpdatePolicyTemplateRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.PolicyTemplate
+		expectedError      api.Error
+		// Manager Results
+		updatePolicyTemplateResult *api.PolicyTemplate
+		// Manager Errors
+		updatePolicyTemplateErr error
+	}{
+		"OkCase": {
+			org:          "org1",
+			templateName: "team",
+			request: &UpdatePolicyTemplateRequest{
+				Name: "newTeam",
+				Path: "/path/",
+				Parameters: []api.PolicyTemplateParameter{
+					{
+						Name: "team",
+					},
+				},
+				Statements: []api.Statement{
+					{
+						Effect: "allow",
+						Actions: []string{
+							api.USER_ACTION_GET_USER,
+						},
+						Resources: []string{
+							"urn:iws:iam::user/{{team}}/*",
+						},
+					},
+				},
+			},
+			updatePolicyTemplateResult: &api.PolicyTemplate{
+				ID:       "test1",
+				Name:     "newTeam",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "newTeam"),
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.PolicyTemplate{
+				ID:       "test1",
+				Name:     "newTeam",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY_TEMPLATE, "/path/", "newTeam"),
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			org:                "org1",
+			templateName:       "team",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			org:          "org1",
+			templateName: "team",
+			request: &UpdatePolicyTemplateRequest{
+				Name: "newTeam",
+				Path: "/path/",
+			},
+			updatePolicyTemplateErr: &api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCasePolicyTemplateAlreadyExists": {
+			org:          "org1",
+			templateName: "team",
+			request: &UpdatePolicyTemplateRequest{
+				Name: "newTeam",
+				Path: "/path/",
+			},
+			updatePolicyTemplateErr: &api.Error{
+				Code: api.POLICY_TEMPLATE_ALREADY_EXIST,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code: api.POLICY_TEMPLATE_ALREADY_EXIST,
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			org:          "org1",
+			templateName: "team",
+			request: &UpdatePolicyTemplateRequest{
+				Name: "newTeam",
+				Path: "/path/",
+			},
+			updatePolicyTemplateErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			org:          "org1",
+			templateName: "team",
+			request: &UpdatePolicyTemplateRequest{
+				Name: "newTeam",
+				Path: "/path/",
+			},
+			updatePolicyTemplateErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[UpdatePolicyTemplateMethod][0] = test.updatePolicyTemplateResult
+		testApi.ArgsOut[UpdatePolicyTemplateMethod][1] = test.updatePolicyTemplateErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policy-templates/%v", test.org, test.templateName)
+		req, err := http.NewRequest(http.MethodPut, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[UpdatePolicyTemplateMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.templateName, testApi.ArgsIn[UpdatePolicyTemplateMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[UpdatePolicyTemplateMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Path, testApi.ArgsIn[UpdatePolicyTemplateMethod][4], "Error in test case %v", n)
+			assert.Equal(t, test.request.Parameters, testApi.ArgsIn[UpdatePolicyTemplateMethod][5], "Error in test case %v", n)
+			assert.Equal(t, test.request.Statements, testApi.ArgsIn[UpdatePolicyTemplateMethod][6], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.PolicyTemplate{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleRemovePolicyTemplate(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		org          string
+		templateName string
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		removePolicyTemplateErr error
+	}{
+		"OkCase": {
+			org:                "org1",
+			templateName:       "team",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			org:          "org1",
+			templateName: "team",
+			removePolicyTemplateErr: &api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			org:          "org1",
+			templateName: "team",
+			removePolicyTemplateErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			org:          "org1",
+			templateName: "team",
+			removePolicyTemplateErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[RemovePolicyTemplateMethod][0] = test.removePolicyTemplateErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policy-templates/%v", test.org, test.templateName)
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		assert.Equal(t, test.org, testApi.ArgsIn[RemovePolicyTemplateMethod][1], "Error in test case %v", n)
+		assert.Equal(t, test.templateName, testApi.ArgsIn[RemovePolicyTemplateMethod][2], "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleInstantiatePolicyTemplate(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org          string
+		templateName string
+		request      *InstantiatePolicyTemplateRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.Policy
+		expectedError      api.Error
+		// Manager Results
+		instantiatePolicyTemplateResult *api.Policy
+		// Manager Errors
+		instantiatePolicyTemplateErr error
+	}{
+		"OkCase": {
+			org:          "org1",
+			templateName: "team",
+			request: &InstantiatePolicyTemplateRequest{
+				Name: "teamA",
+				Path: "/path/",
+				Parameters: map[string]string{
+					"team": "a",
+				},
+			},
+			instantiatePolicyTemplateResult: &api.Policy{
+				ID:       "test1",
+				Name:     "teamA",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY, "/path/", "teamA"),
+				Statements: &[]api.Statement{
+					{
+						Effect: "allow",
+						Actions: []string{
+							api.USER_ACTION_GET_USER,
+						},
+						Resources: []string{
+							"urn:iws:iam::user/a/*",
+						},
+					},
+				},
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse: api.Policy{
+				ID:       "test1",
+				Name:     "teamA",
+				Org:      "org1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("org1", api.RESOURCE_POLICY, "/path/", "teamA"),
+				Statements: &[]api.Statement{
+					{
+						Effect: "allow",
+						Actions: []string{
+							api.USER_ACTION_GET_USER,
+						},
+						Resources: []string{
+							"urn:iws:iam::user/a/*",
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			org:                "org1",
+			templateName:       "team",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			org:          "org1",
+			templateName: "team",
+			request: &InstantiatePolicyTemplateRequest{
+				Name: "teamA",
+				Path: "/path/",
+			},
+			instantiatePolicyTemplateErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter team is required",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: template parameter team is required",
+			},
+		},
+		"ErrorCasePolicyTemplateNotFound": {
+			org:          "org1",
+			templateName: "team",
+			request: &InstantiatePolicyTemplateRequest{
+				Name: "teamA",
+				Path: "/path/",
+			},
+			instantiatePolicyTemplateErr: &api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code: api.POLICY_TEMPLATE_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCasePolicyAlreadyExists": {
+			org:          "org1",
+			templateName: "team",
+			request: &InstantiatePolicyTemplateRequest{
+				Name: "teamA",
+				Path: "/path/",
+			},
+			instantiatePolicyTemplateErr: &api.Error{
+				Code: api.POLICY_ALREADY_EXIST,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code: api.POLICY_ALREADY_EXIST,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			org:          "org1",
+			templateName: "team",
+			request: &InstantiatePolicyTemplateRequest{
+				Name: "teamA",
+				Path: "/path/",
+			},
+			instantiatePolicyTemplateErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[InstantiatePolicyTemplateMethod][0] = test.instantiatePolicyTemplateResult
+		testApi.ArgsOut[InstantiatePolicyTemplateMethod][1] = test.instantiatePolicyTemplateErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policy-templates/%v/policies", test.org, test.templateName)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[InstantiatePolicyTemplateMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.templateName, testApi.ArgsIn[InstantiatePolicyTemplateMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[InstantiatePolicyTemplateMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Path, testApi.ArgsIn[InstantiatePolicyTemplateMethod][4], "Error in test case %v", n)
+			assert.Equal(t, test.request.Parameters, testApi.ArgsIn[InstantiatePolicyTemplateMethod][5], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusCreated:
+			response := api.Policy{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
@@ -900,6 +900,33 @@ func TestWorkerHandler_HandleUpdatePolicy(t *testing.T) {
 				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
 			},
 		},
+		"ErrorCaseChangeRequestPending": {
+			org: "org1",
+			request: &UpdatePolicyRequest{
+				Name: "policy1",
+				Path: "path1",
+				Statements: []api.Statement{
+					{
+						Effect: "allow",
+						Actions: []string{
+							api.USER_ACTION_GET_USER,
+						},
+						Resources: []string{
+							api.GetUrnPrefix("", api.RESOURCE_USER, "/path/"),
+						},
+					},
+				},
+			},
+			updatePolicyErr: &api.Error{
+				Code:    api.CHANGE_REQUEST_PENDING,
+				Message: "Operation UpdatePolicy needs approval",
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedError: api.Error{
+				Code:    api.CHANGE_REQUEST_PENDING,
+				Message: "Operation UpdatePolicy needs approval",
+			},
+		},
 		"ErrorCaseUnknownApiError": {
 			org: "org1",
 			request: &UpdatePolicyRequest{
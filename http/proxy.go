@@ -2,15 +2,20 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/Tecsisa/foulkon/tracing"
 	"github.com/julienschmidt/httprouter"
 	"github.com/satori/go.uuid"
 )
@@ -54,8 +59,25 @@ type ListProxyResourcesResponse struct {
 var rUrnParam, _ = regexp.Compile(`\{(\w+)\}`)
 
 func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httprouter.Handle {
-	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	return func(rw http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		requestID := uuid.NewV4().String()
+		// Continue the trace of the request, or start a new one
+		span := tracing.StartSpan("proxy "+proxyResource.Org+"/"+proxyResource.Name, tracing.KIND_SERVER, tracing.Extract(r.Header))
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+		span.SetAttribute("request.id", requestID)
+
+		// Record proxied request with the status code finally written
+		w := &statusResponseWriter{ResponseWriter: rw, statusCode: http.StatusOK}
+		defer func() {
+			metrics.ObserveProxyRequest(proxyResource.Org, proxyResource.Name, w.statusCode)
+			span.SetAttribute("http.status_code", strconv.Itoa(w.statusCode))
+			if w.statusCode >= http.StatusInternalServerError {
+				span.SetError(fmt.Errorf("Status code %v", w.statusCode))
+			}
+			span.Finish()
+		}()
+
 		w.Header().Set(middleware.REQUEST_ID_HEADER, requestID)
 		// Retrieve parameters to replace in URN
 		parameters := getUrnParameters(proxyResource.Resource.Urn)
@@ -63,7 +85,15 @@ func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httproute
 		for _, p := range parameters {
 			urn = strings.Replace(urn, p[0], ps.ByName(p[1]), -1)
 		}
-		if workerRequestID, err := ph.checkAuthorization(r, urn, proxyResource.Resource.Action); err == nil {
+		authzStart := time.Now()
+		authzSpan := span.StartChild("authorize", tracing.KIND_CLIENT)
+		// Worker request copies the request headers
+		authzSpan.Inject(r.Header)
+		workerRequestID, err := ph.checkAuthorization(r, urn, proxyResource.Resource.Action)
+		authzSpan.SetAttribute("authorized", strconv.FormatBool(err == nil))
+		authzSpan.Finish()
+		metrics.ObserveProxyAuthorization(proxyResource.Org, proxyResource.Name, authzStart)
+		if err == nil {
 			destURL, err := url.Parse(proxyResource.Resource.Host)
 			if err != nil {
 				apiErr := getErrorMessage(INVALID_DEST_HOST_URL, fmt.Sprintf("Error creating destination host URL: %v", err.Error()))
@@ -76,7 +106,13 @@ func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httproute
 			// Clean request URI because net/http send method force this
 			r.RequestURI = ""
 			// Retrieve requested resource
+			upstreamStart := time.Now()
+			upstreamSpan := span.StartChild("upstream "+destURL.Host, tracing.KIND_CLIENT)
+			upstreamSpan.Inject(r.Header)
 			res, err := ph.client.Do(r)
+			upstreamSpan.SetError(err)
+			upstreamSpan.Finish()
+			metrics.ObserveProxyUpstream(proxyResource.Org, proxyResource.Name, upstreamStart)
 			if err != nil {
 				apiErr := getErrorMessage(HOST_UNREACHABLE, fmt.Sprintf("Error calling to destination host resource: %v", err.Error()))
 				api.TransactionProxyErrorLogWithStatus(requestID, workerRequestID, r, http.StatusInternalServerError, apiErr)
@@ -108,6 +144,9 @@ func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httproute
 			api.TransactionProxyLog(requestID, workerRequestID, r, "Request accepted")
 		} else {
 			apiError := err.(*api.Error)
+			if apiError.Code == HOST_UNREACHABLE {
+				metrics.ObserveProxyWorkerUnreachable()
+			}
 			var statusCode int
 			var responseErr *api.Error
 			switch apiError.Code {
@@ -128,6 +167,17 @@ func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httproute
 	}
 }
 
+// statusResponseWriter keeps the status code written in the response
+type statusResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
 // HANDLERS
 
 func (wh *WorkerHandler) HandleAddProxyResource(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -193,6 +243,39 @@ func (wh *WorkerHandler) HandleUpdateProxyResource(w http.ResponseWriter, r *htt
 	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
 }
 
+func (wh *WorkerHandler) HandleWatchProxyResources(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Current proxy resources are returned without waiting if revision isn't set
+	revision := filterData.Revision
+	if len(r.URL.Query().Get("Revision")) == 0 {
+		revision = api.PROXY_RESOURCES_CURRENT_REVISION
+	}
+
+	// Wait for proxy resources changes until the client goes away or the worker starts shutting down
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-wh.worker.ShuttingDown():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	response, modified, err := wh.worker.ProxyApi.WatchProxyResources(ctx, requestInfo, revision, filterData.Timeout)
+	if err == nil && !modified {
+		// Client already has this revision
+		wh.processHttpResponse(r, w, requestInfo, nil, nil, http.StatusNotModified)
+		return
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
 func (wh *WorkerHandler) HandleRemoveProxyResource(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	// Process request
 	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
@@ -1145,3 +1145,149 @@ func TestWorkerHandler_HandleRemoveProxyResource(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkerHandler_HandleWatchProxyResources(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		revision     string
+		timeout      string
+		ignoreArgsIn bool
+		// Expected result
+		expectedRevision   int64
+		expectedTimeout    time.Duration
+		expectedStatusCode int
+		expectedResponse   api.ProxyResourcesWatch
+		expectedError      api.Error
+		// Manager Results
+		watchProxyResourcesResult   *api.ProxyResourcesWatch
+		watchProxyResourcesModified bool
+		// Manager Errors
+		watchProxyResourcesErr error
+	}{
+		"OKCaseModified": {
+			revision:         "3",
+			timeout:          "10s",
+			expectedRevision: 3,
+			expectedTimeout:  10 * time.Second,
+			watchProxyResourcesResult: &api.ProxyResourcesWatch{
+				Revision: 4,
+				Resources: []api.ProxyResource{
+					{
+						ID:   "ID",
+						Name: "pr",
+						Org:  "org",
+					},
+				},
+			},
+			watchProxyResourcesModified: true,
+			expectedStatusCode:          http.StatusOK,
+			expectedResponse: api.ProxyResourcesWatch{
+				Revision: 4,
+				Resources: []api.ProxyResource{
+					{
+						ID:   "ID",
+						Name: "pr",
+						Org:  "org",
+					},
+				},
+			},
+		},
+		"OKCaseNotModified": {
+			revision:           "3",
+			expectedRevision:   3,
+			expectedStatusCode: http.StatusNotModified,
+		},
+		"OKCaseWithoutRevision": {
+			expectedRevision:   api.PROXY_RESOURCES_CURRENT_REVISION,
+			expectedStatusCode: http.StatusNotModified,
+		},
+		"ErrorCaseInvalidRevision": {
+			revision:           "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Revision -1",
+			},
+		},
+		"ErrorCaseInvalidTimeout": {
+			revision:           "3",
+			timeout:            "never",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Timeout never",
+			},
+		},
+		"ErrorCaseUnauthorizedResourcesError": {
+			revision:           "3",
+			expectedRevision:   3,
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			watchProxyResourcesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			revision:           "3",
+			expectedRevision:   3,
+			expectedStatusCode: http.StatusInternalServerError,
+			watchProxyResourcesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsOut[WatchProxyResourcesMethod][0] = test.watchProxyResourcesResult
+		testApi.ArgsOut[WatchProxyResourcesMethod][1] = test.watchProxyResourcesModified
+		testApi.ArgsOut[WatchProxyResourcesMethod][2] = test.watchProxyResourcesErr
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+PROXY_RESOURCES_WATCH_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Revision", test.revision)
+		if test.timeout != "" {
+			q.Add("Timeout", test.timeout)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.expectedRevision, testApi.ArgsIn[WatchProxyResourcesMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.expectedTimeout, testApi.ArgsIn[WatchProxyResourcesMethod][2], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.ProxyResourcesWatch{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusNotModified, http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"time"
 
@@ -12,23 +17,36 @@ import (
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/julienschmidt/httprouter"
 	"github.com/kylelemons/godebug/pretty"
 )
 
 type ReloadHandlerFunc func(watch *ProxyServer) bool
 
+// WatchHandlerFunc waits for proxy resources changes from revision. It returns the resources and true when they changed
+type WatchHandlerFunc func(revision int64) (*api.ProxyResourcesWatch, bool, error)
+
+// Time added to watch timeout to wait for worker response
+const WATCH_RESPONSE_MARGIN = 10 * time.Second
+
 // ProxyServer struct with reload Handler extension
 type ProxyServer struct {
 	certFile string
 	keyFile  string
 
+	proxy        *foulkon.Proxy
 	resourceLock sync.Mutex
 	reloadFunc   ReloadHandlerFunc
+	watchFunc    WatchHandlerFunc
 	refreshTime  time.Duration
 
 	reloadServe      chan struct{}
 	currentResources []api.ProxyResource
+	resourcesLoaded  bool
+	// Handler of proxy resources, server handler reads it for every request since it is replaced while serving
+	router     http.Handler
+	routerLock sync.RWMutex
 	http.Server
 }
 
@@ -37,6 +55,7 @@ type WorkerServer struct {
 	certFile string
 	keyFile  string
 
+	worker *foulkon.Worker
 	http.Server
 }
 
@@ -44,6 +63,9 @@ type WorkerServer struct {
 type Server interface {
 	Run() error
 	Configuration() error
+	// Shutdown stops accepting connections and waits for in-flight requests until ctx is done.
+	// Run returns http.ErrServerClosed after it is called
+	Shutdown(ctx context.Context) error
 }
 
 // Run starts an HTTP WorkerServer
@@ -91,36 +113,50 @@ func (ps *ProxyServer) Configuration() error {
 // Configuration an HTTP WorkerServer
 func (ws *WorkerServer) Configuration() error { return nil }
 
+// Shutdown ends proxy resources watches before draining in-flight requests, because watches could wait
+// longer than the shutdown timeout
+func (ws *WorkerServer) Shutdown(ctx context.Context) error {
+	if ws.worker != nil {
+		ws.worker.StartShutdown()
+	}
+	return ws.Server.Shutdown(ctx)
+}
+
 // Run starts an HTTP ProxyServer
 func (ps *ProxyServer) Run() error {
-	// Call reloadFunc every refreshTime
-	timer := time.NewTicker(ps.refreshTime)
-	// now wait for the other times when we needed to
-	go func() {
-		for range timer.C {
-			// change the handler
-			if ps.reloadFunc(ps) {
-				ps.reloadServe <- struct{}{} // reset the listening binding
+	if ps.watchFunc != nil {
+		// Update resources when worker notifies changes
+		go ps.watchResources()
+	} else {
+		// Call reloadFunc every refreshTime
+		timer := time.NewTicker(ps.refreshTime)
+		// now wait for the other times when we needed to
+		go func() {
+			for range timer.C {
+				// change the handler
+				if ps.reloadFunc(ps) {
+					ps.reloadServe <- struct{}{} // reset the listening binding
+				}
 			}
-		}
-	}()
+		}()
+	}
 
-	var err error
 	ln, err := net.Listen("tcp", ps.Addr)
 	if err != nil {
 		return err
 	}
 
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- ps.Serve(ln)
+	}()
 	for {
-		l := ln.(*net.TCPListener)
-		defer l.Close()
-		go func(l net.Listener) {
-			err = ps.Serve(l)
-		}(l)
-		if err != nil {
+		select {
+		case err := <-serveErr:
 			return err
+		case <-ps.reloadServe:
+			// Router was already replaced, it is used by new requests
 		}
-		<-ps.reloadServe
 	}
 }
 
@@ -136,8 +172,15 @@ func NewProxy(proxy *foulkon.Proxy) Server {
 	ps.keyFile = proxy.KeyFile
 
 	ps.Addr = proxy.Host + ":" + proxy.Port
+	ps.proxy = proxy
+	ps.router = ps.routerHandler(httprouter.New())
+	ps.Handler = http.HandlerFunc(ps.serveRouter)
+	metrics.RegisterProxyMetrics()
 	ps.refreshTime = proxy.RefreshTime
 	ps.reloadFunc = ps.RefreshResources(proxy)
+	if proxy.Watch {
+		ps.watchFunc = ps.WatchResources(proxy)
+	}
 
 	ps.reloadFunc(ps)
 
@@ -150,6 +193,7 @@ func NewWorker(worker *foulkon.Worker, h http.Handler) Server {
 	ws.certFile = worker.CertFile
 	ws.keyFile = worker.KeyFile
 	ws.Addr = worker.Host + ":" + worker.Port
+	ws.worker = worker
 
 	ws.Handler = h
 
@@ -159,50 +203,153 @@ func NewWorker(worker *foulkon.Worker, h http.Handler) Server {
 // RefreshResources implements reloadFunc
 func (ps *ProxyServer) RefreshResources(proxy *foulkon.Proxy) func(s *ProxyServer) bool {
 	return func(srv *ProxyServer) bool {
-		proxyHandler := ProxyHandler{proxy: proxy, client: http.DefaultClient}
-
 		// Get proxy resources
 		newProxyResources, err := proxy.ProxyApi.GetProxyResources()
 		if err != nil {
 			api.Log.Errorf("Unexpected error reading proxy resources from database %v", err)
 			return false
 		}
+		metrics.ObserveProxyRefresh()
+		srv.setResourcesLoaded()
+
+		return srv.updateResources(proxy, newProxyResources)
+	}
+}
+
+// WatchResources implements watchFunc, using the proxy resources watch of the worker
+func (ps *ProxyServer) WatchResources(proxy *foulkon.Proxy) WatchHandlerFunc {
+	client := &http.Client{Timeout: proxy.WatchTimeout + WATCH_RESPONSE_MARGIN}
+	return func(revision int64) (*api.ProxyResourcesWatch, bool, error) {
+		query := url.Values{}
+		if revision != api.PROXY_RESOURCES_CURRENT_REVISION {
+			query.Set("Revision", strconv.FormatInt(revision, 10))
+		}
+		query.Set("Timeout", proxy.WatchTimeout.String())
+		req, err := http.NewRequest(http.MethodGet, proxy.WorkerHost+PROXY_RESOURCES_WATCH_URL+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, false, getErrorMessage(api.UNKNOWN_API_ERROR, err.Error())
+		}
+		req.SetBasicAuth(proxy.WorkerUsername, proxy.WorkerPassword)
+
+		res, err := client.Do(req)
+		if err != nil {
+			metrics.ObserveProxyWorkerUnreachable()
+			return nil, false, getErrorMessage(HOST_UNREACHABLE, err.Error())
+		}
+		defer res.Body.Close()
+
+		switch res.StatusCode {
+		case http.StatusNotModified:
+			return nil, false, nil
+		case http.StatusOK:
+			watch := &api.ProxyResourcesWatch{}
+			if err := json.NewDecoder(res.Body).Decode(watch); err != nil {
+				return nil, false, getErrorMessage(api.UNKNOWN_API_ERROR, fmt.Sprintf("Error parsing foulkon response %v", err.Error()))
+			}
+			return watch, true, nil
+		default:
+			return nil, false, getErrorMessage(INTERNAL_SERVER_ERROR, fmt.Sprintf("Unexpected status code %v", res.StatusCode))
+		}
+	}
+}
+
+// watchResources updates resources every time the worker notifies a change. While watch fails,
+// resources are refreshed from database every refreshTime
+func (ps *ProxyServer) watchResources() {
+	revision := int64(api.PROXY_RESOURCES_CURRENT_REVISION)
+	for {
+		watch, modified, err := ps.watchFunc(revision)
+		if err != nil {
+			api.Log.Errorf("Unexpected error watching proxy resources, refreshing them from database: %v", err)
+			// Ask for all resources when watch is back
+			revision = api.PROXY_RESOURCES_CURRENT_REVISION
+			if ps.reloadFunc(ps) {
+				ps.reloadServe <- struct{}{} // reset the listening binding
+			}
+			time.Sleep(ps.refreshTime)
+			continue
+		}
+
+		metrics.ObserveProxyRefresh()
+		ps.setResourcesLoaded()
+		if modified {
+			revision = watch.Revision
+			if ps.updateResources(ps.proxy, watch.Resources) {
+				ps.reloadServe <- struct{}{} // reset the listening binding
+			}
+		}
+	}
+}
 
-		if diff := pretty.Compare(srv.currentResources, newProxyResources); diff != "" {
-			router := httprouter.New()
+// updateResources replaces the router when resources changed, returning true in that case
+func (ps *ProxyServer) updateResources(proxy *foulkon.Proxy, newProxyResources []api.ProxyResource) bool {
+	if diff := pretty.Compare(ps.currentResources, newProxyResources); diff != "" {
+		proxyHandler := ProxyHandler{proxy: proxy, client: http.DefaultClient}
+		router := httprouter.New()
 
-			defer srv.resourceLock.Unlock()
-			srv.resourceLock.Lock()
+		defer ps.resourceLock.Unlock()
+		ps.resourceLock.Lock()
 
-			// writer lock
-			ps.currentResources = newProxyResources
+		// writer lock
+		ps.currentResources = newProxyResources
 
-			api.Log.Info("Updating resources ...")
-			for _, pr := range newProxyResources {
-				// Clean path
-				pr.Resource.Path = httprouter.CleanPath(pr.Resource.Path)
+		api.Log.Info("Updating resources ...")
+		routes := 0
+		for _, pr := range newProxyResources {
+			// Clean path
+			pr.Resource.Path = httprouter.CleanPath(pr.Resource.Path)
 
-				// Attach resource
-				safeRouterAdderHandler(router, pr, &proxyHandler)
+			// Attach resource
+			if safeRouterAdderHandler(router, pr, &proxyHandler) {
+				routes++
 			}
-			// TODO: test when resources are empty
-			// If we had resources and those were deleted then handler must be
-			// created with empty router.
-			ps.Server.Handler = router
-			return true
 		}
-		return false
+		metrics.SetProxyRoutes(routes)
+		// TODO: test when resources are empty
+		// If we had resources and those were deleted then handler must be
+		// created with empty router.
+		ps.routerLock.Lock()
+		ps.router = ps.routerHandler(router)
+		ps.routerLock.Unlock()
+		return true
+	}
+	return false
+}
+
+// serveRouter serves requests with the current router of proxy resources
+func (ps *ProxyServer) serveRouter(w http.ResponseWriter, r *http.Request) {
+	ps.routerLock.RLock()
+	router := ps.router
+	ps.routerLock.RUnlock()
+	router.ServeHTTP(w, r)
+}
+
+// routerHandler applies the proxy middlewares before the router of proxy resources
+func (ps *ProxyServer) routerHandler(router http.Handler) http.Handler {
+	if ps.proxy.MiddlewareHandler != nil {
+		router = ps.proxy.MiddlewareHandler.Handle(router)
 	}
+	return healthHandler(ps.readinessChecks(), router)
+}
+
+// setResourcesLoaded records that proxy resources were read at least once
+func (ps *ProxyServer) setResourcesLoaded() {
+	ps.resourceLock.Lock()
+	defer ps.resourceLock.Unlock()
+	ps.resourcesLoaded = true
 }
 
-// Method to control when router has a resource already defined that collides with another
-func safeRouterAdderHandler(router *httprouter.Router, pr api.ProxyResource, ph *ProxyHandler) {
+// Method to control when router has a resource already defined that collides with another,
+// returning false in that case
+func safeRouterAdderHandler(router *httprouter.Router, pr api.ProxyResource, ph *ProxyHandler) (added bool) {
 	defer func() {
 		if r := recover(); r != nil {
 			api.Log.Errorf("There was a problem adding proxy resource with name %v and org %v: %v", pr.Name, pr.Org, r)
+			added = false
 		}
 	}()
 	router.Handle(pr.Resource.Method, pr.Resource.Path, ph.HandleRequest(pr))
+	return true
 }
 
 func strSliceContains(ss []string, s string) bool {
@@ -1,6 +1,10 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"crypto/tls"
@@ -10,10 +14,13 @@ import (
 
 	"time"
 
+	"strconv"
 	"strings"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/Tecsisa/foulkon/middleware/xrequestid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,6 +45,54 @@ func TestNewWorker(t *testing.T) {
 	assert.Equal(t, handler, ws.Handler, "Error in test")
 }
 
+func TestWorkerServer_Shutdown(t *testing.T) {
+	testApi := makeTestApi()
+	testApi.SpecialFuncs[WatchProxyResourcesMethod] = func(ctx context.Context) (*api.ProxyResourcesWatch, bool, error) {
+		// Wait like a watch without changes
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+		return nil, false, nil
+	}
+	worker := &foulkon.Worker{
+		Host:              "localhost",
+		Port:              "0",
+		MiddlewareHandler: &middleware.MiddlewareHandler{},
+		ProxyApi:          testApi,
+	}
+	handler := WorkerHandlerRouter(worker)
+	srv := NewWorker(worker, handler)
+	// Watch is requested to other server with the same worker, to check it ends when shutdown starts
+	watchServer := httptest.NewServer(handler)
+	defer watchServer.Close()
+
+	watchStatus := make(chan int, 1)
+	go func() {
+		res, err := http.Get(watchServer.URL + PROXY_RESOURCES_WATCH_URL + "?Revision=3")
+		if err != nil {
+			watchStatus <- 0
+			return
+		}
+		res.Body.Close()
+		watchStatus <- res.StatusCode
+	}()
+	// Wait watch to start
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	assert.Nil(t, err, "Error in test")
+
+	select {
+	case status := <-watchStatus:
+		assert.Equal(t, http.StatusNotModified, status, "Error in test")
+	case <-time.After(time.Second):
+		t.Error("Watch didn't end after shutdown")
+	}
+}
+
 func TestNewProxy(t *testing.T) {
 	testApi := makeTestApi()
 	testcases := map[string]struct {
@@ -474,3 +529,334 @@ func TestProxyServer_Run(t *testing.T) {
 		}
 	}
 }
+
+func TestProxyServer_WatchResources(t *testing.T) {
+	resources := []api.ProxyResource{
+		{
+			ID: "ID2",
+			Resource: api.ResourceEntity{
+				Host:   "host2",
+				Path:   "/path2",
+				Method: "Method2",
+				Urn:    "urn2",
+				Action: "action2",
+			},
+		},
+	}
+	testcases := map[string]struct {
+		revision int64
+		// Worker response
+		statusCode int
+		response   interface{}
+		// Worker is down
+		closed bool
+
+		expectedWatch    *api.ProxyResourcesWatch
+		expectedModified bool
+		expectedError    string
+	}{
+		"OKCaseModified": {
+			revision:   3,
+			statusCode: http.StatusOK,
+			response: api.ProxyResourcesWatch{
+				Revision:  4,
+				Resources: resources,
+			},
+			expectedWatch: &api.ProxyResourcesWatch{
+				Revision:  4,
+				Resources: resources,
+			},
+			expectedModified: true,
+		},
+		"OKCaseNotModified": {
+			revision:   3,
+			statusCode: http.StatusNotModified,
+		},
+		"OKCaseCurrentRevision": {
+			revision:   api.PROXY_RESOURCES_CURRENT_REVISION,
+			statusCode: http.StatusOK,
+			response: api.ProxyResourcesWatch{
+				Resources: resources,
+			},
+			expectedWatch: &api.ProxyResourcesWatch{
+				Resources: resources,
+			},
+			expectedModified: true,
+		},
+		"ErrorCaseUnauthorized": {
+			revision:      3,
+			statusCode:    http.StatusUnauthorized,
+			expectedError: "Code: InternalServerError, Message: Unexpected status code 401",
+		},
+		"ErrorCaseInvalidResponse": {
+			revision:      3,
+			statusCode:    http.StatusOK,
+			response:      "invalid",
+			expectedError: "Code: UnknownApiError, Message: Error parsing foulkon response",
+		},
+		"ErrorCaseWorkerDown": {
+			revision:      3,
+			closed:        true,
+			expectedError: "Code: HostUnreachableError",
+		},
+	}
+
+	for n, test := range testcases {
+		worker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Check received parameters
+			assert.Equal(t, PROXY_RESOURCES_WATCH_URL, r.URL.Path, "Error in test case %v", n)
+			expectedRevision := strconv.FormatInt(test.revision, 10)
+			if test.revision == api.PROXY_RESOURCES_CURRENT_REVISION {
+				expectedRevision = ""
+			}
+			assert.Equal(t, expectedRevision, r.URL.Query().Get("Revision"), "Error in test case %v", n)
+			assert.Equal(t, "30s", r.URL.Query().Get("Timeout"), "Error in test case %v", n)
+			username, password, ok := r.BasicAuth()
+			assert.True(t, ok, "Error in test case %v", n)
+			assert.Equal(t, "admin", username, "Error in test case %v", n)
+			assert.Equal(t, "admin", password, "Error in test case %v", n)
+
+			w.WriteHeader(test.statusCode)
+			if test.response != nil {
+				json.NewEncoder(w).Encode(test.response)
+			}
+		}))
+		if test.closed {
+			worker.Close()
+		}
+
+		ps := &ProxyServer{}
+		watchFunc := ps.WatchResources(&foulkon.Proxy{
+			WorkerHost:     worker.URL,
+			WatchTimeout:   30 * time.Second,
+			WorkerUsername: "admin",
+			WorkerPassword: "admin",
+		})
+		watch, modified, err := watchFunc(test.revision)
+		if test.expectedError != "" {
+			assert.NotNil(t, err, "Error in test case %v", n)
+			if err != nil {
+				assert.True(t, strings.Contains(err.Error(), test.expectedError), "Error in test case %v: %v", n, err)
+			}
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedWatch, watch, "Error in test case %v", n)
+			assert.Equal(t, test.expectedModified, modified, "Error in test case %v", n)
+		}
+
+		worker.Close()
+	}
+}
+
+func TestProxyServer_watchResources(t *testing.T) {
+	testApi := makeTestApi()
+	databaseResources := []api.ProxyResource{
+		{
+			ID: "ID1",
+			Resource: api.ResourceEntity{
+				Host:   "host1",
+				Path:   "/path1",
+				Method: "Method1",
+				Urn:    "urn1",
+				Action: "action1",
+			},
+		},
+	}
+	watchResources := []api.ProxyResource{
+		{
+			ID: "ID2",
+			Resource: api.ResourceEntity{
+				Host:   "host2",
+				Path:   "/path2",
+				Method: "Method2",
+				Urn:    "urn2",
+				Action: "action2",
+			},
+		},
+	}
+	testApi.ArgsOut[GetProxyResourcesMethod][0] = databaseResources
+
+	proxy := &foulkon.Proxy{
+		RefreshTime: time.Millisecond,
+		ProxyApi:    testApi,
+	}
+	ps := &ProxyServer{
+		proxy:       proxy,
+		refreshTime: proxy.RefreshTime,
+		reloadServe: make(chan struct{}, 10),
+	}
+	ps.reloadFunc = ps.RefreshResources(proxy)
+
+	// Watch fails first, then it notifies a change and then it doesn't notify more changes
+	var mutex sync.Mutex
+	revisions := []int64{}
+	calls := 0
+	fallback := make(chan []api.ProxyResource, 1)
+	ps.watchFunc = func(revision int64) (*api.ProxyResourcesWatch, bool, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		revisions = append(revisions, revision)
+		calls++
+		switch calls {
+		case 1:
+			return nil, false, getErrorMessage(HOST_UNREACHABLE, "Worker down")
+		case 2:
+			// Resources were refreshed from database while watch was down
+			ps.resourceLock.Lock()
+			fallback <- ps.currentResources
+			ps.resourceLock.Unlock()
+			return &api.ProxyResourcesWatch{
+				Revision:  4,
+				Resources: watchResources,
+			}, true, nil
+		default:
+			time.Sleep(time.Millisecond)
+			return nil, false, nil
+		}
+	}
+
+	go ps.watchResources()
+
+	assert.Equal(t, databaseResources, <-fallback, "Error in test")
+
+	// Wait watch update
+	time.Sleep(20 * time.Millisecond)
+
+	ps.resourceLock.Lock()
+	assert.Equal(t, watchResources, ps.currentResources, "Error in test")
+	ps.resourceLock.Unlock()
+
+	mutex.Lock()
+	assert.Equal(t, []int64{api.PROXY_RESOURCES_CURRENT_REVISION, api.PROXY_RESOURCES_CURRENT_REVISION, 4}, revisions[:3], "Error in test")
+	mutex.Unlock()
+}
+
+func TestProxyServer_routerHandler(t *testing.T) {
+	router := httprouter.New()
+	router.GET("/example", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	})
+	testcases := map[string]struct {
+		middlewareHandler *middleware.MiddlewareHandler
+		url               string
+
+		expectedStatusCode int
+		expectedRequestID  bool
+	}{
+		"OKCaseWithoutMiddlewares": {
+			url:                "/example",
+			expectedStatusCode: http.StatusOK,
+		},
+		"OKCaseMiddlewares": {
+			middlewareHandler: &middleware.MiddlewareHandler{
+				Middlewares: map[string]middleware.Middleware{
+					middleware.XREQUESTID_MIDDLEWARE: xrequestid.NewXRequestIdMiddleware(),
+				},
+				Pipeline: []string{middleware.XREQUESTID_MIDDLEWARE},
+			},
+			url:                "/example",
+			expectedStatusCode: http.StatusOK,
+			expectedRequestID:  true,
+		},
+		"OKCaseHealthWithoutMiddlewares": {
+			middlewareHandler: &middleware.MiddlewareHandler{
+				Middlewares: map[string]middleware.Middleware{
+					middleware.XREQUESTID_MIDDLEWARE: xrequestid.NewXRequestIdMiddleware(),
+				},
+				Pipeline: []string{middleware.XREQUESTID_MIDDLEWARE},
+			},
+			url:                HEALTHZ_URL,
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for n, test := range testcases {
+		ps := &ProxyServer{
+			proxy: &foulkon.Proxy{
+				MiddlewareHandler: test.middlewareHandler,
+			},
+		}
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		w := httptest.NewRecorder()
+		ps.routerHandler(router).ServeHTTP(w, req)
+		res := w.Result()
+
+		// Check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		// Check header of middleware
+		if test.expectedRequestID {
+			assert.NotEmpty(t, res.Header.Get(middleware.REQUEST_ID_HEADER), "Error in test case %v", n)
+		} else {
+			assert.Empty(t, res.Header.Get(middleware.REQUEST_ID_HEADER), "Error in test case %v", n)
+		}
+	}
+}
+
+func TestProxyServer_serveRouter(t *testing.T) {
+	ps := &ProxyServer{
+		proxy: &foulkon.Proxy{},
+	}
+	ps.router = ps.routerHandler(httprouter.New())
+	handler := http.HandlerFunc(ps.serveRouter)
+
+	// Router is replaced while requests are served
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			ps.updateResources(ps.proxy, []api.ProxyResource{
+				{
+					ID: "ID",
+					Resource: api.ResourceEntity{
+						Method: http.MethodGet,
+						Path:   "/path" + strconv.Itoa(i),
+					},
+				},
+			})
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, "Error in test")
+	}
+	<-done
+
+	ps.resourceLock.Lock()
+	assert.Equal(t, "/path9", ps.currentResources[0].Resource.Path, "Error in test")
+	ps.resourceLock.Unlock()
+}
+
+func TestProxyServer_Shutdown(t *testing.T) {
+	testApi := makeTestApi()
+	testApi.ArgsOut[GetProxyResourcesMethod][0] = []api.ProxyResource{}
+	testApi.ArgsOut[GetProxyResourcesMethod][1] = nil
+
+	srv := NewProxy(&foulkon.Proxy{
+		Host:        "localhost",
+		Port:        "0",
+		RefreshTime: 1 * time.Millisecond,
+		ProxyApi:    testApi,
+	})
+	srv.Configuration()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.Run()
+	}()
+	// Wait server to listen and reload resources
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	assert.Nil(t, err, "Error in test")
+
+	select {
+	case err := <-runErr:
+		assert.Equal(t, http.ErrServerClosed, err, "Error in test")
+	case <-time.After(time.Second):
+		t.Error("Server didn't stop after shutdown")
+	}
+}
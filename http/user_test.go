@@ -18,7 +18,9 @@ func TestWorkerHandler_HandleAddUser(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// API method args
-		request *CreateUserRequest
+		request      *CreateUserRequest
+		dryRun       string
+		ignoreArgsIn bool
 		// Expected result
 		expectedStatusCode int
 		expectedResponse   *api.User
@@ -51,6 +53,43 @@ func TestWorkerHandler_HandleAddUser(t *testing.T) {
 				UpdateAt:   now,
 			},
 		},
+		"OkCaseDryRun": {
+			request: &CreateUserRequest{
+				ExternalID: "UserID",
+				Path:       "Path",
+			},
+			dryRun:             "true",
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse: &api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+			addUserResult: &api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+		},
+		"ErrorCaseInvalidDryRun": {
+			request: &CreateUserRequest{
+				ExternalID: "UserID",
+				Path:       "Path",
+			},
+			dryRun:             "maybe",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: dryRun maybe",
+			},
+		},
 		"ErrorCaseMalformedRequest": {
 			expectedStatusCode: http.StatusBadRequest,
 			expectedError: api.Error{
@@ -137,11 +176,18 @@ func TestWorkerHandler_HandleAddUser(t *testing.T) {
 		req, err := http.NewRequest(http.MethodPost, url, body)
 		assert.Nil(t, err, "Error in test case %v", n)
 
+		if test.dryRun != "" {
+			q := req.URL.Query()
+			q.Add("dryRun", test.dryRun)
+			req.URL.RawQuery = q.Encode()
+		}
+
 		res, err := client.Do(req)
 		assert.Nil(t, err, "Error in test case %v", n)
 
-		if test.request != nil {
+		if test.request != nil && !test.ignoreArgsIn {
 			// Check received parameters
+			assert.Equal(t, test.dryRun == "true", testApi.ArgsIn[AddUserMethod][0].(api.RequestInfo).DryRun, "Error in test case %v", n)
 			assert.Equal(t, test.request.ExternalID, testApi.ArgsIn[AddUserMethod][1], "Error in test case %v", n)
 			assert.Equal(t, test.request.Path, testApi.ArgsIn[AddUserMethod][2], "Error in test case %v", n)
 		}
@@ -0,0 +1,615 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleAddWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		request *CreateWebhookRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.Webhook
+		expectedError      api.Error
+		// Manager Results
+		addWebhookResult *api.Webhook
+		// Manager Errors
+		addWebhookErr error
+	}{
+		"OkCase": {
+			request: &CreateWebhookRequest{
+				Name:   "test",
+				URL:    "https://example.com/hook",
+				Events: []string{api.GROUP_ACTION_ADD_MEMBER},
+				Secret: "secret",
+			},
+			addWebhookResult: &api.Webhook{
+				ID:       "test1",
+				Name:     "test",
+				URL:      "https://example.com/hook",
+				Events:   []string{api.GROUP_ACTION_ADD_MEMBER},
+				Secret:   "secret",
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/", "test"),
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse: api.Webhook{
+				ID:       "test1",
+				Name:     "test",
+				URL:      "https://example.com/hook",
+				Events:   []string{api.GROUP_ACTION_ADD_MEMBER},
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/", "test"),
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseWebhookAlreadyExists": {
+			request: &CreateWebhookRequest{
+				Name:   "test",
+				URL:    "https://example.com/hook",
+				Events: []string{api.GROUP_ACTION_ADD_MEMBER},
+				Secret: "secret",
+			},
+			addWebhookErr: &api.Error{
+				Code: api.WEBHOOK_ALREADY_EXIST,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code: api.WEBHOOK_ALREADY_EXIST,
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			request: &CreateWebhookRequest{
+				Name:   "test",
+				URL:    "invalid",
+				Events: []string{api.GROUP_ACTION_ADD_MEMBER},
+				Secret: "secret",
+			},
+			addWebhookErr: &api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code: api.INVALID_PARAMETER_ERROR,
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			request: &CreateWebhookRequest{
+				Name:   "test",
+				URL:    "https://example.com/hook",
+				Events: []string{api.GROUP_ACTION_ADD_MEMBER},
+				Secret: "secret",
+			},
+			addWebhookErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			request: &CreateWebhookRequest{
+				Name:   "test",
+				URL:    "https://example.com/hook",
+				Events: []string{api.GROUP_ACTION_ADD_MEMBER},
+				Secret: "secret",
+			},
+			addWebhookErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[AddWebhookMethod][0] = test.addWebhookResult
+		testApi.ArgsOut[AddWebhookMethod][1] = test.addWebhookErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+WEBHOOK_ROOT_URL, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[AddWebhookMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.URL, testApi.ArgsIn[AddWebhookMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Events, testApi.ArgsIn[AddWebhookMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Secret, testApi.ArgsIn[AddWebhookMethod][4], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusCreated:
+			data, err := ioutil.ReadAll(res.Body)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Secret is never returned
+			assert.NotContains(t, string(data), test.request.Secret, "Error in test case %v", n)
+			response := api.Webhook{}
+			err = json.Unmarshal(data, &response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleGetWebhookByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		name string
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.Webhook
+		expectedError      api.Error
+		// Manager Results
+		getWebhookByNameResult *api.Webhook
+		// Manager Errors
+		getWebhookByNameErr error
+	}{
+		"OkCase": {
+			name: "test",
+			getWebhookByNameResult: &api.Webhook{
+				ID:       "test1",
+				Name:     "test",
+				URL:      "https://example.com/hook",
+				Events:   []string{api.GROUP_ACTION_ADD_MEMBER},
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/", "test"),
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.Webhook{
+				ID:       "test1",
+				Name:     "test",
+				URL:      "https://example.com/hook",
+				Events:   []string{api.GROUP_ACTION_ADD_MEMBER},
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/", "test"),
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			name: "test",
+			getWebhookByNameErr: &api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			name: "test",
+			getWebhookByNameErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			name: "test",
+			getWebhookByNameErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetWebhookByNameMethod][0] = test.getWebhookByNameResult
+		testApi.ArgsOut[GetWebhookByNameMethod][1] = test.getWebhookByNameErr
+
+		url := fmt.Sprintf(server.URL+WEBHOOK_ROOT_URL+"/%v", test.name)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		assert.Equal(t, test.name, testApi.ArgsIn[GetWebhookByNameMethod][1], "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.Webhook{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListWebhooks(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		filter *api.Filter
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListWebhooksResponse
+		expectedError      api.Error
+		// Manager Results
+		listWebhooksResult []string
+		totalWebhooks      int
+		// Manager Errors
+		listWebhooksErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				Offset: 0,
+				Limit:  20,
+			},
+			expectedStatusCode: http.StatusOK,
+			listWebhooksResult: []string{"webhook1", "webhook2"},
+			totalWebhooks:      2,
+			expectedResponse: ListWebhooksResponse{
+				Webhooks: []string{"webhook1", "webhook2"},
+				Offset:   0,
+				Limit:    20,
+				Total:    2,
+			},
+		},
+		"ErrorCaseInvalidRequest": {
+			filter: &api.Filter{
+				Offset: -1,
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			filter:             &api.Filter{},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			listWebhooksErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			filter:             &api.Filter{},
+			expectedStatusCode: http.StatusInternalServerError,
+			listWebhooksErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListWebhooksMethod][0] = test.listWebhooksResult
+		testApi.ArgsOut[ListWebhooksMethod][1] = test.totalWebhooks
+		testApi.ArgsOut[ListWebhooksMethod][2] = test.listWebhooksErr
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+WEBHOOK_ROOT_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+		addQueryParams(test.filter, req)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := ListWebhooksResponse{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleUpdateWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		name    string
+		request *UpdateWebhookRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.Webhook
+		expectedError      api.Error
+		// Manager Results
+		updateWebhookResult *api.Webhook
+		// Manager Errors
+		updateWebhookErr error
+	}{
+		"OkCase": {
+			name: "test",
+			request: &UpdateWebhookRequest{
+				Name:   "newTest",
+				URL:    "https://example.com/newhook",
+				Events: []string{"iam:*"},
+			},
+			updateWebhookResult: &api.Webhook{
+				ID:       "test1",
+				Name:     "newTest",
+				URL:      "https://example.com/newhook",
+				Events:   []string{"iam:*"},
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/", "newTest"),
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.Webhook{
+				ID:       "test1",
+				Name:     "newTest",
+				URL:      "https://example.com/newhook",
+				Events:   []string{"iam:*"},
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/", "newTest"),
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			name:               "test",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			name: "test",
+			request: &UpdateWebhookRequest{
+				Name:   "newTest",
+				URL:    "https://example.com/newhook",
+				Events: []string{"iam:*"},
+			},
+			updateWebhookErr: &api.Error{
+				Code: api.WEBHOOK_BY_NAME_NOT_FOUND,
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code: api.WEBHOOK_BY_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseWebhookAlreadyExists": {
+			name: "test",
+			request: &UpdateWebhookRequest{
+				Name:   "other",
+				URL:    "https://example.com/newhook",
+				Events: []string{"iam:*"},
+			},
+			updateWebhookErr: &api.Error{
+				Code: api.WEBHOOK_ALREADY_EXIST,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code: api.WEBHOOK_ALREADY_EXIST,
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			name: "test",
+			request: &UpdateWebhookRequest{
+				Name:   "newTest",
+				URL:    "https://example.com/newhook",
+				Events: []string{"iam:*"},
+			},
+			updateWebhookErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[UpdateWebhookMethod][0] = test.updateWebhookResult
+		testApi.ArgsOut[UpdateWebhookMethod][1] = test.updateWebhookErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+WEBHOOK_ROOT_URL+"/%v", test.name)
+		req, err := http.NewRequest(http.MethodPut, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.name, testApi.ArgsIn[UpdateWebhookMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[UpdateWebhookMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.URL, testApi.ArgsIn[UpdateWebhookMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Events, testApi.ArgsIn[UpdateWebhookMethod][4], "Error in test case %v", n)
+			assert.Equal(t, test.request.Secret, testApi.ArgsIn[UpdateWebhookMethod][5], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.Webhook{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleRemoveWebhook(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		name string
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		removeWebhookErr error
+	}{
+		"OkCase": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCaseWebhookNotFound": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+			removeWebhookErr: &api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+		},
+		"ErrorCaseUnauthorizedResourcesError": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			removeWebhookErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusInternalServerError,
+			removeWebhookErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[RemoveWebhookMethod][0] = test.removeWebhookErr
+
+		url := fmt.Sprintf(server.URL+WEBHOOK_ROOT_URL+"/%v", test.name)
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		assert.Equal(t, test.name, testApi.ArgsIn[RemoveWebhookMethod][1], "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
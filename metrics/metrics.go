@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	NAMESPACE = "foulkon"
+
+	// Authorization decision outcomes
+	DECISION_ALLOW = "allow"
+	DECISION_DENY  = "deny"
+
+	// Authorization decisions are labeled with the service prefix of the action, up to MAX_ACTION_SERVICES
+	// different services. Actions of the rest of services are labeled as ACTION_OTHER
+	MAX_ACTION_SERVICES = 100
+	ACTION_OTHER        = "other"
+
+	// Authentication connector types
+	CONNECTOR_HEADER = "header"
+	CONNECTOR_OIDC   = "oidc"
+	CONNECTOR_JWT    = "jwt"
+	CONNECTOR_NONE   = "none"
+)
+
+// Registry holds all Foulkon metrics exposed in the metrics endpoint
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latencies in seconds by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	authzDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "authz",
+		Name:      "decisions_total",
+		Help:      "Total number of authorization decisions over external resources by action service and outcome.",
+	}, []string{"action", "outcome"})
+
+	// Services of the actions labeled in authorization decisions, actions are set by clients so they are limited
+	actionServices     = map[string]bool{}
+	actionServicesLock sync.Mutex
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Database query latencies in seconds by repository method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	authenticationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "authentication",
+		Name:      "failures_total",
+		Help:      "Total number of failed authentications by connector type.",
+	}, []string{"connector"})
+
+	rateLimitedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "ratelimit",
+		Name:      "rejected_requests_total",
+		Help:      "Total number of requests rejected by the rate limiter by route group.",
+	}, []string{"group"})
+
+	dbStats = &dbStatsCollector{
+		openConnections: prometheus.NewDesc(
+			prometheus.BuildFQName(NAMESPACE, "db", "open_connections"),
+			"Number of open connections to the database.",
+			nil, nil,
+		),
+	}
+)
+
+func init() {
+	Registry.MustRegister(httpRequests, httpRequestDuration, authzDecisions, dbQueryDuration,
+		authenticationFailures, rateLimitedRequests, dbStats)
+}
+
+// Handler returns the http.Handler that exposes the metrics in Prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHttpRequest records a request served in the route pattern, with its method and status code
+func ObserveHttpRequest(route string, method string, statusCode int, start time.Time) {
+	status := strconv.Itoa(statusCode)
+	httpRequests.WithLabelValues(route, method, status).Inc()
+	httpRequestDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+}
+
+// ObserveAuthzDecision records an authorization decision for the action, labeled with its service prefix
+func ObserveAuthzDecision(action string, allowed bool) {
+	outcome := DECISION_DENY
+	if allowed {
+		outcome = DECISION_ALLOW
+	}
+	authzDecisions.WithLabelValues(getActionLabel(action), outcome).Inc()
+}
+
+// ObserveDBQuery records the latency of a repository method started at start
+func ObserveDBQuery(method string, start time.Time) {
+	dbQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// ObserveAuthenticationFailure records a failed authentication in the connector type
+func ObserveAuthenticationFailure(connector string) {
+	authenticationFailures.WithLabelValues(connector).Inc()
+}
+
+// ObserveRateLimited records a request rejected by the rate limiter in the route group
+func ObserveRateLimited(group string) {
+	rateLimitedRequests.WithLabelValues(group).Inc()
+}
+
+// getActionLabel returns the service prefix of the action if it is already labeled or there is room for another
+// service, otherwise ACTION_OTHER
+func getActionLabel(action string) string {
+	i := strings.Index(action, ":")
+	if i < 1 {
+		return ACTION_OTHER
+	}
+	service := action[:i]
+
+	actionServicesLock.Lock()
+	defer actionServicesLock.Unlock()
+	if !actionServices[service] {
+		if len(actionServices) >= MAX_ACTION_SERVICES {
+			return ACTION_OTHER
+		}
+		actionServices[service] = true
+	}
+	return service
+}
+
+// SetDB sets the database whose connection pool stats are exposed
+func SetDB(db *sql.DB) {
+	dbStats.Lock()
+	defer dbStats.Unlock()
+	dbStats.db = db
+}
+
+// dbStatsCollector collects the connection pool stats of the current database in each scrape
+type dbStatsCollector struct {
+	sync.Mutex
+	db *sql.DB
+
+	openConnections *prometheus.Desc
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openConnections
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	defer c.Unlock()
+	if c.db == nil {
+		return
+	}
+	stats := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.openConnections, prometheus.GaugeValue, float64(stats.OpenConnections))
+}
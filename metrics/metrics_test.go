@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDriver struct{}
+
+func (d testDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("Not implemented")
+}
+
+func init() {
+	sql.Register("metrics-test", testDriver{})
+}
+
+func TestHandler(t *testing.T) {
+	testcases := map[string]struct {
+		observe       func()
+		expectedLines []string
+	}{
+		"OkCaseHttpRequest": {
+			observe: func() {
+				ObserveHttpRequest("/api/v1/users/:id", http.MethodGet, http.StatusOK, time.Now())
+				ObserveHttpRequest("/api/v1/users/:id", http.MethodGet, http.StatusOK, time.Now())
+				ObserveHttpRequest("/api/v1/users/:id", http.MethodGet, http.StatusNotFound, time.Now())
+			},
+			expectedLines: []string{
+				`foulkon_http_requests_total{method="GET",route="/api/v1/users/:id",status="200"} 2`,
+				`foulkon_http_requests_total{method="GET",route="/api/v1/users/:id",status="404"} 1`,
+				`foulkon_http_request_duration_seconds_count{method="GET",route="/api/v1/users/:id",status="200"} 2`,
+			},
+		},
+		"OkCaseAuthzDecision": {
+			observe: func() {
+				ObserveAuthzDecision("example:get", true)
+				ObserveAuthzDecision("example:list", false)
+				ObserveAuthzDecision("example:get", false)
+			},
+			expectedLines: []string{
+				`foulkon_authz_decisions_total{action="example",outcome="allow"} 1`,
+				`foulkon_authz_decisions_total{action="example",outcome="deny"} 2`,
+			},
+		},
+		"OkCaseDBQuery": {
+			observe: func() {
+				ObserveDBQuery("GetUserByExternalID", time.Now())
+			},
+			expectedLines: []string{
+				`foulkon_db_query_duration_seconds_count{method="GetUserByExternalID"} 1`,
+			},
+		},
+		"OkCaseAuthenticationFailure": {
+			observe: func() {
+				ObserveAuthenticationFailure(CONNECTOR_HEADER)
+			},
+			expectedLines: []string{
+				`foulkon_authentication_failures_total{connector="header"} 1`,
+			},
+		},
+		"OkCaseRateLimited": {
+			observe: func() {
+				ObserveRateLimited("default")
+			},
+			expectedLines: []string{
+				`foulkon_ratelimit_rejected_requests_total{group="default"} 1`,
+			},
+		},
+		"OkCaseDBStats": {
+			observe: func() {
+				db, err := sql.Open("metrics-test", "")
+				assert.Nil(t, err, "Error in test")
+				SetDB(db)
+			},
+			expectedLines: []string{
+				`foulkon_db_open_connections 0`,
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		test.observe()
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, req)
+		res := w.Result()
+		assert.Equal(t, http.StatusOK, res.StatusCode, "Error in test case %v", n)
+
+		buffer := new(bytes.Buffer)
+		_, err := buffer.ReadFrom(res.Body)
+		assert.Nil(t, err, "Error in test case %v", n)
+		for _, line := range test.expectedLines {
+			assert.Contains(t, buffer.String(), line+"\n", "Error in test case %v", n)
+		}
+	}
+}
+
+func TestGetActionLabel(t *testing.T) {
+	// Start without labeled services
+	actionServicesLock.Lock()
+	previousServices := actionServices
+	actionServices = map[string]bool{}
+	actionServicesLock.Unlock()
+	defer func() {
+		actionServicesLock.Lock()
+		actionServices = previousServices
+		actionServicesLock.Unlock()
+	}()
+
+	for i := 0; i < MAX_ACTION_SERVICES; i++ {
+		assert.Equal(t, fmt.Sprintf("service%v", i), getActionLabel(fmt.Sprintf("service%v:get", i)), "Error in test")
+	}
+
+	testcases := map[string]struct {
+		action        string
+		expectedLabel string
+	}{
+		"OkCaseLabeledService": {
+			action:        "service0:list",
+			expectedLabel: "service0",
+		},
+		"OkCaseTooManyServices": {
+			action:        "unknown:get",
+			expectedLabel: ACTION_OTHER,
+		},
+		"OkCaseWithoutService": {
+			action:        "get",
+			expectedLabel: ACTION_OTHER,
+		},
+	}
+
+	for n, test := range testcases {
+		assert.Equal(t, test.expectedLabel, getActionLabel(test.action), "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	proxyRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "proxy",
+		Name:      "requests_total",
+		Help:      "Total number of proxied requests by proxy resource organization, name and status code class.",
+	}, []string{"org", "resource", "code"})
+
+	proxyUpstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "proxy",
+		Name:      "upstream_duration_seconds",
+		Help:      "Upstream request latencies in seconds by proxy resource organization and name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"org", "resource"})
+
+	proxyAuthorizationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "proxy",
+		Name:      "authorization_duration_seconds",
+		Help:      "Worker authorization latencies in seconds by proxy resource organization and name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"org", "resource"})
+
+	proxyWorkerUnreachable = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Subsystem: "proxy",
+		Name:      "worker_unreachable_total",
+		Help:      "Total number of calls to the worker that failed because it was unreachable.",
+	})
+
+	proxyStats = &proxyStatsCollector{
+		routes: prometheus.NewDesc(
+			prometheus.BuildFQName(NAMESPACE, "proxy", "routes"),
+			"Number of proxy resources routes currently served.",
+			nil, nil,
+		),
+		sinceLastRefresh: prometheus.NewDesc(
+			prometheus.BuildFQName(NAMESPACE, "proxy", "seconds_since_last_refresh"),
+			"Seconds since proxy resources were successfully refreshed, or since the proxy started.",
+			nil, nil,
+		),
+	}
+
+	registerProxyOnce sync.Once
+)
+
+// RegisterProxyMetrics adds the proxy metrics to the Registry. It is safe to call it several times
+func RegisterProxyMetrics() {
+	registerProxyOnce.Do(func() {
+		ObserveProxyRefresh()
+		Registry.MustRegister(proxyRequests, proxyUpstreamDuration, proxyAuthorizationDuration,
+			proxyWorkerUnreachable, proxyStats)
+	})
+}
+
+// ObserveProxyRequest records a request proxied by the resource with its status code class, like 2xx
+func ObserveProxyRequest(org string, name string, statusCode int) {
+	proxyRequests.WithLabelValues(org, name, fmt.Sprintf("%dxx", statusCode/100)).Inc()
+}
+
+// ObserveProxyUpstream records the latency of an upstream request of the resource started at start
+func ObserveProxyUpstream(org string, name string, start time.Time) {
+	proxyUpstreamDuration.WithLabelValues(org, name).Observe(time.Since(start).Seconds())
+}
+
+// ObserveProxyAuthorization records the latency of a worker authorization of the resource started at start
+func ObserveProxyAuthorization(org string, name string, start time.Time) {
+	proxyAuthorizationDuration.WithLabelValues(org, name).Observe(time.Since(start).Seconds())
+}
+
+// ObserveProxyWorkerUnreachable records a call to the worker that couldn't reach it
+func ObserveProxyWorkerUnreachable() {
+	proxyWorkerUnreachable.Inc()
+}
+
+// SetProxyRoutes sets the number of routes currently served
+func SetProxyRoutes(routes int) {
+	proxyStats.Lock()
+	defer proxyStats.Unlock()
+	proxyStats.routeCount = routes
+}
+
+// ObserveProxyRefresh records a successful refresh of proxy resources
+func ObserveProxyRefresh() {
+	proxyStats.Lock()
+	defer proxyStats.Unlock()
+	proxyStats.lastRefresh = time.Now()
+}
+
+// proxyStatsCollector collects the current state of the proxy routes in each scrape
+type proxyStatsCollector struct {
+	sync.Mutex
+	routeCount  int
+	lastRefresh time.Time
+
+	routes           *prometheus.Desc
+	sinceLastRefresh *prometheus.Desc
+}
+
+func (c *proxyStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.routes
+	ch <- c.sinceLastRefresh
+}
+
+func (c *proxyStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	defer c.Unlock()
+	ch <- prometheus.MustNewConstMetric(c.routes, prometheus.GaugeValue, float64(c.routeCount))
+	ch <- prometheus.MustNewConstMetric(c.sinceLastRefresh, prometheus.GaugeValue, time.Since(c.lastRefresh).Seconds())
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterProxyMetrics(t *testing.T) {
+	// Registering twice is allowed
+	RegisterProxyMetrics()
+	RegisterProxyMetrics()
+
+	testcases := map[string]struct {
+		observe       func()
+		expectedLines []string
+	}{
+		"OkCaseProxyRequest": {
+			observe: func() {
+				ObserveProxyRequest("example", "users", http.StatusOK)
+				ObserveProxyRequest("example", "users", http.StatusNoContent)
+				ObserveProxyRequest("example", "users", http.StatusForbidden)
+			},
+			expectedLines: []string{
+				`foulkon_proxy_requests_total{code="2xx",org="example",resource="users"} 2`,
+				`foulkon_proxy_requests_total{code="4xx",org="example",resource="users"} 1`,
+			},
+		},
+		"OkCaseProxyLatencies": {
+			observe: func() {
+				ObserveProxyUpstream("example", "groups", time.Now())
+				ObserveProxyAuthorization("example", "groups", time.Now())
+			},
+			expectedLines: []string{
+				`foulkon_proxy_upstream_duration_seconds_count{org="example",resource="groups"} 1`,
+				`foulkon_proxy_authorization_duration_seconds_count{org="example",resource="groups"} 1`,
+			},
+		},
+		"OkCaseWorkerUnreachable": {
+			observe: func() {
+				ObserveProxyWorkerUnreachable()
+			},
+			expectedLines: []string{
+				`foulkon_proxy_worker_unreachable_total 1`,
+			},
+		},
+		"OkCaseRoutes": {
+			observe: func() {
+				SetProxyRoutes(3)
+				ObserveProxyRefresh()
+			},
+			expectedLines: []string{
+				`foulkon_proxy_routes 3`,
+				`foulkon_proxy_seconds_since_last_refresh `,
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		test.observe()
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, req)
+		res := w.Result()
+		assert.Equal(t, http.StatusOK, res.StatusCode, "Error in test case %v", n)
+
+		buffer := new(bytes.Buffer)
+		_, err := buffer.ReadFrom(res.Body)
+		assert.Nil(t, err, "Error in test case %v", n)
+		for _, line := range test.expectedLines {
+			assert.Contains(t, buffer.String(), line, "Error in test case %v", n)
+		}
+	}
+}
@@ -1,28 +1,112 @@
 package auth
 
 import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/Tecsisa/foulkon/middleware"
+	"golang.org/x/crypto/bcrypt"
 )
 
-// Authenticator middleware system, with connector and basic admin authentication
+const (
+	// Max credentials kept as verified, the cache is emptied when it is full
+	MAX_VERIFIED_CREDENTIALS = 1024
+
+	// Failed password checks allowed for each account from each client IP in each interval, next ones are rejected
+	// without checking. Clients are limited by IP, so they can't lock accounts out for other clients
+	MAX_FAILED_PASSWORD_CHECKS      = 10
+	FAILED_PASSWORD_CHECKS_INTERVAL = time.Minute
+	// Max accounts and client IPs with failures kept, finished intervals are removed when it is full
+	MAX_FAILED_PASSWORD_CLIENTS = 1024
+
+	RETRY_AFTER_HEADER = "Retry-After"
+)
+
+// Authenticator middleware system, with connector, basic authentication for admin accounts and
+// basic authentication for pre-registered break-glass accounts
 type AuthenticatorMiddleware struct {
-	connector     AuthConnector
-	adminUser     string
-	adminPassword string
+	lock            sync.RWMutex
+	connector       AuthConnector
+	admins          map[string][]byte
+	breakGlassUsers map[string][]byte
+
+	// bcrypt comparisons are slow on purpose, so successful ones are kept by hash of the credentials,
+	// and failed ones are limited by client IP and account
+	passwordsLock sync.Mutex
+	verified      map[[sha256.Size]byte]bool
+	failures      map[string]*passwordFailures
+	now           func() time.Time
 }
 
-// NewAuthenticator returns a configured AuthenticatorMiddleware with associated connector
-func NewAuthenticatorMiddleware(connector AuthConnector, adminUser string, adminPassword string) *AuthenticatorMiddleware {
+// Failed password checks of an account from a client IP since start of the interval
+type passwordFailures struct {
+	count int
+	since time.Time
+}
+
+// Result of basic authentication credentials of a request, checked once by the authenticator action
+type basicCredentials struct {
+	username   string
+	admin      bool
+	breakGlass bool
+	// Seconds until passwords of the account are checked again for the client, when there are too many failures
+	retryAfter int
+}
+
+type credentialsContextKey struct{}
+
+// NewAuthenticator returns a configured AuthenticatorMiddleware with associated connector.
+// admins and breakGlassUsers map admin and break-glass user names to bcrypt hashes of their passwords.
+func NewAuthenticatorMiddleware(connector AuthConnector, admins map[string][]byte,
+	breakGlassUsers map[string][]byte) *AuthenticatorMiddleware {
 	return &AuthenticatorMiddleware{
-		connector:     connector,
-		adminUser:     adminUser,
-		adminPassword: adminPassword,
+		connector:       connector,
+		admins:          admins,
+		breakGlassUsers: breakGlassUsers,
+		verified:        make(map[[sha256.Size]byte]bool),
+		failures:        make(map[string]*passwordFailures),
+		now:             time.Now,
 	}
 }
 
+// Update replaces the connector and the accounts of the authenticator, while requests are being served.
+// Admins not in admins can't authenticate anymore
+func (a *AuthenticatorMiddleware) Update(connector AuthConnector, admins map[string][]byte,
+	breakGlassUsers map[string][]byte) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.connector = connector
+	a.admins = admins
+	a.breakGlassUsers = breakGlassUsers
+
+	a.passwordsLock.Lock()
+	defer a.passwordsLock.Unlock()
+	a.verified = make(map[[sha256.Size]byte]bool)
+}
+
+// SetConnector replaces the connector of the authenticator. Requests being authenticated keep using the previous one
+func (a *AuthenticatorMiddleware) SetConnector(connector AuthConnector) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.connector = connector
+}
+
+// getConfig returns the current connector and accounts of the authenticator
+func (a *AuthenticatorMiddleware) getConfig() (AuthConnector, map[string][]byte, map[string][]byte) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.connector, a.admins, a.breakGlassUsers
+}
+
 // Interface for authentication that connectors implement
 type AuthConnector interface {
 	Authenticate(next http.Handler) http.Handler
@@ -33,14 +117,27 @@ func (a *AuthenticatorMiddleware) Action(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var handler http.Handler
 		requestID := r.Header.Get(middleware.REQUEST_ID_HEADER)
-		if isAdmin(r, a.adminUser, a.adminPassword) {
-			// Admin check
-			r.Header.Add(middleware.USER_ID_HEADER, a.adminUser)
+		// User identifier is set by the authenticator, never by the client
+		r.Header.Del(middleware.USER_ID_HEADER)
+		connector, admins, breakGlassUsers := a.getConfig()
+		credentials := a.checkCredentials(r, admins, breakGlassUsers)
+		if credentials.retryAfter > 0 {
+			msg := fmt.Sprintf("Too many failed password checks, retry after %v seconds", credentials.retryAfter)
+			api.LogOperationWarn(requestID, credentials.username, msg)
+			w.Header().Set(RETRY_AFTER_HEADER, strconv.Itoa(credentials.retryAfter))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		// Credentials are kept in request context, so they aren't checked again retrieving the user
+		r = r.WithContext(context.WithValue(r.Context(), credentialsContextKey{}, credentials))
+		if credentials.breakGlass || credentials.admin {
+			// Break-glass account or admin
+			r.Header.Add(middleware.USER_ID_HEADER, credentials.username)
 			handler = next
 		} else {
-			if a.connector != nil {
+			if connector != nil {
 				// Connector
-				handler = a.connector.Authenticate(next)
+				handler = connector.Authenticate(next)
 			} else {
 				// Error response when there isn't any authentication connector
 				apiError := &api.Error{
@@ -48,6 +145,7 @@ func (a *AuthenticatorMiddleware) Action(next http.Handler) http.Handler {
 					Message: "No Authenticator Provider configured",
 				}
 				api.LogOperationError(requestID, "", apiError)
+				metrics.ObserveAuthenticationFailure(metrics.CONNECTOR_NONE)
 				http.Error(w, "Authentication failed", http.StatusUnauthorized)
 				return
 			}
@@ -58,24 +156,113 @@ func (a *AuthenticatorMiddleware) Action(next http.Handler) http.Handler {
 }
 
 func (a *AuthenticatorMiddleware) GetInfo(r *http.Request, mc *middleware.MiddlewareContext) {
-	mc.UserId, mc.Admin = a.getAuthenticatedUser(r)
+	mc.UserId, mc.Admin, mc.BreakGlass = a.getAuthenticatedUser(r)
 }
 
-// getAuthenticatedUser retrieves user from request
-func (a *AuthenticatorMiddleware) getAuthenticatedUser(r *http.Request) (string, bool) {
-	if isAdmin(r, a.adminUser, a.adminPassword) {
-		return a.adminUser, true
+// getAuthenticatedUser retrieves user from request, and whether it is the admin or a break-glass account
+func (a *AuthenticatorMiddleware) getAuthenticatedUser(r *http.Request) (string, bool, bool) {
+	connector, admins, breakGlassUsers := a.getConfig()
+	credentials, ok := r.Context().Value(credentialsContextKey{}).(basicCredentials)
+	if !ok {
+		credentials = a.checkCredentials(r, admins, breakGlassUsers)
+	}
+	if credentials.breakGlass {
+		return credentials.username, false, true
+	}
+	if credentials.admin {
+		return credentials.username, true, false
 	}
-	return a.connector.RetrieveUserID(*r), false
+	// Only admin accounts can authenticate without connector
+	if connector == nil {
+		return "", false, false
+	}
+	return connector.RetrieveUserID(*r), false, false
 }
 
-func isAdmin(r *http.Request, adminUser string, adminPassword string) bool {
+// checkCredentials checks the basic authentication of the request against break-glass and admin accounts
+func (a *AuthenticatorMiddleware) checkCredentials(r *http.Request, admins map[string][]byte,
+	breakGlassUsers map[string][]byte) basicCredentials {
 	username, password, ok := r.BasicAuth()
-	isAdmin := username == adminUser && password == adminPassword
-	if ok && !isAdmin {
+	if !ok {
+		return basicCredentials{}
+	}
+	credentials := basicCredentials{username: username}
+	if hash, registered := breakGlassUsers[username]; registered {
+		credentials.breakGlass, credentials.retryAfter = a.checkPassword(getClientIP(r), username, password, hash)
+		if credentials.breakGlass || credentials.retryAfter > 0 {
+			return credentials
+		}
+	}
+	if hash, registered := admins[username]; registered {
+		credentials.admin, credentials.retryAfter = a.checkPassword(getClientIP(r), username, password, hash)
+	}
+	if !credentials.admin && credentials.retryAfter < 1 {
 		msg := "Trying to connect as admin, admin user/password invalid, delegating to connector..."
 		api.LogOperationWarn(r.Header.Get(middleware.REQUEST_ID_HEADER), username, msg)
 	}
 	// Password is never stored in DB
-	return ok && isAdmin
+	return credentials
+}
+
+// checkPassword compares the password with the bcrypt hash of the account, in constant time. When the account has too
+// many failures from the client IP the password isn't compared, returning the seconds until next check is allowed
+func (a *AuthenticatorMiddleware) checkPassword(clientIP string, username string, password string, hash []byte) (bool, int) {
+	key := sha256.Sum256([]byte(username + "\x00" + password + "\x00" + string(hash)))
+	failuresKey := clientIP + "\x00" + username
+	a.passwordsLock.Lock()
+	verified := a.verified[key]
+	now := a.now()
+	failures, ok := a.failures[failuresKey]
+	if ok && now.Sub(failures.since) >= FAILED_PASSWORD_CHECKS_INTERVAL {
+		delete(a.failures, failuresKey)
+		ok = false
+	}
+	a.passwordsLock.Unlock()
+	if verified {
+		return true, 0
+	}
+	if ok && failures.count >= MAX_FAILED_PASSWORD_CHECKS {
+		return false, int(math.Ceil(failures.since.Add(FAILED_PASSWORD_CHECKS_INTERVAL).Sub(now).Seconds()))
+	}
+
+	matches := bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+	a.passwordsLock.Lock()
+	defer a.passwordsLock.Unlock()
+	if !matches {
+		if failures, ok := a.failures[failuresKey]; ok {
+			failures.count++
+		} else {
+			if len(a.failures) >= MAX_FAILED_PASSWORD_CLIENTS {
+				a.removeFinishedFailures(now)
+			}
+			a.failures[failuresKey] = &passwordFailures{count: 1, since: now}
+		}
+		return false, 0
+	}
+	if len(a.verified) >= MAX_VERIFIED_CREDENTIALS {
+		a.verified = make(map[[sha256.Size]byte]bool)
+	}
+	a.verified[key] = true
+	return true, 0
+}
+
+// removeFinishedFailures removes failures whose interval finished, or all of them if every interval is in progress
+func (a *AuthenticatorMiddleware) removeFinishedFailures(now time.Time) {
+	for key, failures := range a.failures {
+		if now.Sub(failures.since) >= FAILED_PASSWORD_CHECKS_INTERVAL {
+			delete(a.failures, key)
+		}
+	}
+	if len(a.failures) >= MAX_FAILED_PASSWORD_CLIENTS {
+		a.failures = make(map[string]*passwordFailures)
+	}
+}
+
+// getClientIP returns the IP of the request client, or its remote address if it hasn't got port
+func getClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
@@ -2,14 +2,17 @@ package auth
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Sirupsen/logrus/hooks/test"
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Aux connector
@@ -36,6 +39,19 @@ func (tc TestConnector) RetrieveUserID(r http.Request) string {
 	return tc.userID
 }
 
+// Aux func that hashes account passwords, with min cost to keep tests fast
+func hashPasswords(passwords map[string]string) map[string][]byte {
+	admins := make(map[string][]byte)
+	for username, password := range passwords {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			panic(err)
+		}
+		admins[username] = hash
+	}
+	return admins
+}
+
 func TestAuthenticatorMiddleware_Action(t *testing.T) {
 	testMessage := "TestMessage"
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -51,9 +67,11 @@ func TestAuthenticatorMiddleware_Action(t *testing.T) {
 		password           string
 		unauthenticated    bool
 		admin              bool
+		breakGlass         bool
 		expectedLog        string
 		expectedStatusCode int
 		testConnectorNull  bool
+		spoofedUserID      string
 	}{
 		"OkCase": {
 			userID:             "UserId",
@@ -67,6 +85,21 @@ func TestAuthenticatorMiddleware_Action(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			admin:              true,
 		},
+		"OkCaseAdminWithSpoofedUserHeader": {
+			userID:             "admin",
+			password:           "admin",
+			unauthenticated:    false,
+			expectedStatusCode: http.StatusOK,
+			admin:              true,
+			spoofedUserID:      "spoofed",
+		},
+		"OkCaseSecondAdmin": {
+			userID:             "ops",
+			password:           "opspassword",
+			unauthenticated:    false,
+			expectedStatusCode: http.StatusOK,
+			admin:              true,
+		},
 		"OkCaseInvalidAdmin": {
 			userID:             "admin",
 			password:           "fail",
@@ -75,6 +108,21 @@ func TestAuthenticatorMiddleware_Action(t *testing.T) {
 			admin:              true,
 			expectedLog:        "Trying to connect as admin, admin user/password invalid, delegating to connector...",
 		},
+		"OkCaseBreakGlass": {
+			userID:             "emergency",
+			password:           "secret",
+			unauthenticated:    false,
+			expectedStatusCode: http.StatusOK,
+			breakGlass:         true,
+		},
+		"OkCaseInvalidBreakGlass": {
+			userID:             "emergency",
+			password:           "fail",
+			unauthenticated:    false,
+			expectedStatusCode: http.StatusOK,
+			breakGlass:         true,
+			expectedLog:        "Trying to connect as admin, admin user/password invalid, delegating to connector...",
+		},
 		"OkCaseUnautenticated": {
 			userID:             "UserId",
 			unauthenticated:    true,
@@ -88,17 +136,22 @@ func TestAuthenticatorMiddleware_Action(t *testing.T) {
 		},
 	}
 
+	admins := hashPasswords(map[string]string{"admin": "admin", "ops": "opspassword"})
 	for n, testcase := range testcases {
 		var mw *AuthenticatorMiddleware
 		if testcase.testConnectorNull {
-			mw = NewAuthenticatorMiddleware(nil, "admin", "admin")
+			mw = NewAuthenticatorMiddleware(nil, admins, nil)
 		} else {
-			mw = NewAuthenticatorMiddleware(&TestConnector{userID: testcase.userID, unauthenticated: testcase.unauthenticated}, "admin", "admin")
+			mw = NewAuthenticatorMiddleware(&TestConnector{userID: testcase.userID, unauthenticated: testcase.unauthenticated}, admins,
+				hashPasswords(map[string]string{"emergency": "secret"}))
 		}
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
-		if testcase.admin {
+		if testcase.admin || testcase.breakGlass {
 			req.SetBasicAuth(testcase.userID, testcase.password)
 		}
+		if testcase.spoofedUserID != "" {
+			req.Header.Set(middleware.USER_ID_HEADER, testcase.spoofedUserID)
+		}
 		w := httptest.NewRecorder()
 		mw.Action(testHandler).ServeHTTP(w, req)
 		res := w.Result()
@@ -135,7 +188,9 @@ func TestAuthenticatorMiddleware_GetInfo(t *testing.T) {
 		userID             string
 		password           string
 		unauthenticated    bool
+		withoutConnector   bool
 		admin              bool
+		breakGlass         bool
 		expectedStatusCode int
 	}{
 		"OkCase": {
@@ -150,16 +205,40 @@ func TestAuthenticatorMiddleware_GetInfo(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			admin:              true,
 		},
+		"OkCaseBreakGlass": {
+			userID:             "emergency",
+			password:           "secret",
+			unauthenticated:    false,
+			expectedStatusCode: http.StatusOK,
+			breakGlass:         true,
+		},
+		"OkCaseAdminWithoutConnector": {
+			userID:             "admin",
+			password:           "admin",
+			withoutConnector:   true,
+			expectedStatusCode: http.StatusOK,
+			admin:              true,
+		},
+		"OkCaseUserWithoutConnector": {
+			withoutConnector:   true,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
 	}
 
+	admins := hashPasswords(map[string]string{"admin": "admin"})
 	for n, testcase := range testcases {
-		mw := NewAuthenticatorMiddleware(&TestConnector{userID: testcase.userID, unauthenticated: testcase.unauthenticated}, "admin", "admin")
+		var connector AuthConnector
+		if !testcase.withoutConnector {
+			connector = &TestConnector{userID: testcase.userID, unauthenticated: testcase.unauthenticated}
+		}
+		mw := NewAuthenticatorMiddleware(connector, admins, hashPasswords(map[string]string{"emergency": "secret"}))
 		req := httptest.NewRequest(http.MethodGet, "/", nil)
-		if testcase.admin {
+		if testcase.admin || testcase.breakGlass {
 			req.SetBasicAuth(testcase.userID, testcase.password)
 		}
 		w := httptest.NewRecorder()
 		mw.Action(testHandler).ServeHTTP(w, req)
+		assert.Equal(t, testcase.expectedStatusCode, w.Code, "Error in test case %v", n)
 		mc := new(middleware.MiddlewareContext)
 		mw.GetInfo(req, mc)
 
@@ -167,5 +246,247 @@ func TestAuthenticatorMiddleware_GetInfo(t *testing.T) {
 		assert.Equal(t, testcase.userID, mc.UserId, "Error in test case %v", n)
 		// Check admin privilege
 		assert.Equal(t, testcase.admin, mc.Admin, "Error in test case %v", n)
+		// Check break-glass account
+		assert.Equal(t, testcase.breakGlass, mc.BreakGlass, "Error in test case %v", n)
+	}
+}
+
+func TestAuthenticatorMiddleware_Update(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	testcases := map[string]struct {
+		// Request credentials
+		userID   string
+		password string
+
+		expectedStatusCode int
+		expectedUserID     string
+		expectedAdmin      bool
+		expectedBreakGlass bool
+	}{
+		"OkCaseNewAdmin": {
+			userID:             "root",
+			password:           "newpassword",
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "root",
+			expectedAdmin:      true,
+		},
+		"OkCaseKeptAdmin": {
+			userID:             "admin",
+			password:           "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "admin",
+			expectedAdmin:      true,
+		},
+		"OkCaseDisabledAdminDelegatedToNewConnector": {
+			userID:             "ops",
+			password:           "opspassword",
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "NewUserId",
+		},
+		"OkCaseNewBreakGlass": {
+			userID:             "oncall",
+			password:           "secret",
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "oncall",
+			expectedBreakGlass: true,
+		},
+		"OkCaseNewConnector": {
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "NewUserId",
+		},
+	}
+
+	admins := hashPasswords(map[string]string{"admin": "admin", "ops": "opspassword"})
+	// ops admin is disabled, and root admin is added
+	newAdmins := hashPasswords(map[string]string{"admin": "admin", "root": "newpassword"})
+	for n, testcase := range testcases {
+		mw := NewAuthenticatorMiddleware(&TestConnector{userID: "UserId"}, admins,
+			hashPasswords(map[string]string{"emergency": "secret"}))
+		// Authenticate before update, so verified credentials are kept
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if testcase.userID != "" {
+			req.SetBasicAuth(testcase.userID, testcase.password)
+		}
+		mw.Action(testHandler).ServeHTTP(httptest.NewRecorder(), req)
+		mw.Update(&TestConnector{userID: "NewUserId"}, newAdmins, hashPasswords(map[string]string{"oncall": "secret"}))
+
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		if testcase.userID != "" {
+			req.SetBasicAuth(testcase.userID, testcase.password)
+		}
+		w := httptest.NewRecorder()
+		mw.Action(testHandler).ServeHTTP(w, req)
+		// Check status code
+		assert.Equal(t, testcase.expectedStatusCode, w.Result().StatusCode, "Error in test case %v", n)
+
+		mc := new(middleware.MiddlewareContext)
+		mw.GetInfo(req, mc)
+		// Check user
+		assert.Equal(t, testcase.expectedUserID, mc.UserId, "Error in test case %v", n)
+		assert.Equal(t, testcase.expectedAdmin, mc.Admin, "Error in test case %v", n)
+		assert.Equal(t, testcase.expectedBreakGlass, mc.BreakGlass, "Error in test case %v", n)
+	}
+}
+
+func TestAuthenticatorMiddleware_SetConnector(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	testcases := map[string]struct {
+		connector AuthConnector
+
+		expectedStatusCode int
+		expectedUserID     string
+	}{
+		"OkCaseNewConnector": {
+			connector:          &TestConnector{userID: "NewUserId"},
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "NewUserId",
+		},
+		"OkCaseConnectorRemoved": {
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for n, testcase := range testcases {
+		mw := NewAuthenticatorMiddleware(&TestConnector{userID: "UserId"}, hashPasswords(map[string]string{"admin": "admin"}), nil)
+		handler := mw.Action(testHandler)
+		mw.SetConnector(testcase.connector)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		// Check status code
+		assert.Equal(t, testcase.expectedStatusCode, w.Result().StatusCode, "Error in test case %v", n)
+		// Check Header
+		assert.Equal(t, testcase.expectedUserID, req.Header.Get(middleware.USER_ID_HEADER), "Error in test case %v", n)
+	}
+}
+
+func TestAuthenticatorMiddleware_FailedPasswordChecks(t *testing.T) {
+	testLogger, hook := test.NewNullLogger()
+	api.Log = testLogger
+	start := time.Now()
+	testcases := map[string]struct {
+		// Failed checks before the request
+		failures int
+		// Seconds since first failure
+		at       float64
+		password string
+		// Client of the request, the same one of the failures if it is empty
+		remoteAddr string
+
+		expectedStatusCode int
+		expectedRetryAfter string
+		expectedUserID     string
+		expectedFailures   int
+	}{
+		"OkCaseUnderLimit": {
+			failures:           MAX_FAILED_PASSWORD_CHECKS - 1,
+			password:           "fail",
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "UserId",
+			expectedFailures:   MAX_FAILED_PASSWORD_CHECKS,
+		},
+		"OkCaseIntervalFinished": {
+			failures:           MAX_FAILED_PASSWORD_CHECKS,
+			at:                 FAILED_PASSWORD_CHECKS_INTERVAL.Seconds(),
+			password:           "admin",
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "admin",
+		},
+		"OkCaseLimitExceededByOtherClient": {
+			failures:           MAX_FAILED_PASSWORD_CHECKS,
+			at:                 20.5,
+			password:           "admin",
+			remoteAddr:         "198.51.100.1:1234",
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "admin",
+		},
+		"ErrorCaseLimitExceeded": {
+			failures:           MAX_FAILED_PASSWORD_CHECKS,
+			at:                 20.5,
+			password:           "admin",
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedRetryAfter: "40",
+		},
+	}
+
+	for n, testcase := range testcases {
+		mw := NewAuthenticatorMiddleware(&TestConnector{userID: "UserId"}, hashPasswords(map[string]string{"admin": "admin"}), nil)
+		mw.now = func() time.Time { return start }
+		var handledReq *http.Request
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handledReq = r
+		})
+		for i := 0; i < testcase.failures; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.SetBasicAuth("admin", "fail")
+			mw.Action(testHandler).ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		mw.now = func() time.Time {
+			return start.Add(time.Duration(testcase.at * float64(time.Second)))
+		}
+		handledReq = nil
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if testcase.remoteAddr != "" {
+			req.RemoteAddr = testcase.remoteAddr
+		}
+		req.SetBasicAuth("admin", testcase.password)
+		w := httptest.NewRecorder()
+		mw.Action(testHandler).ServeHTTP(w, req)
+		// Check status code and retry header
+		assert.Equal(t, testcase.expectedStatusCode, w.Result().StatusCode, "Error in test case %v", n)
+		assert.Equal(t, testcase.expectedRetryAfter, w.Result().Header.Get(RETRY_AFTER_HEADER), "Error in test case %v", n)
+		if testcase.expectedStatusCode == http.StatusTooManyRequests {
+			assert.Contains(t, hook.LastEntry().Message, "Too many failed password checks", "Error in test case %v", n)
+			continue
+		}
+
+		// Credentials of the request are checked once, retrieving the user doesn't add failures
+		mc := new(middleware.MiddlewareContext)
+		mw.GetInfo(handledReq, mc)
+		assert.Equal(t, testcase.expectedUserID, mc.UserId, "Error in test case %v", n)
+		failures := 0
+		if f, ok := mw.failures[getClientIP(req)+"\x00admin"]; ok {
+			failures = f.count
+		}
+		assert.Equal(t, testcase.expectedFailures, failures, "Error in test case %v", n)
+	}
+}
+
+func TestAuthenticatorMiddleware_RemoveFinishedFailures(t *testing.T) {
+	start := time.Now()
+	testcases := map[string]struct {
+		// Seconds since start
+		at float64
+
+		expectedFailures int
+	}{
+		"OkCaseFinishedIntervals": {
+			at:               FAILED_PASSWORD_CHECKS_INTERVAL.Seconds(),
+			expectedFailures: MAX_FAILED_PASSWORD_CLIENTS / 2,
+		},
+		"OkCaseIntervalsInProgress": {
+			at:               1,
+			expectedFailures: 0,
+		},
+	}
+
+	for n, testcase := range testcases {
+		// Half of the clients failed at start, and the other half in the middle of the interval
+		mw := NewAuthenticatorMiddleware(nil, nil, nil)
+		for i := 0; i < MAX_FAILED_PASSWORD_CLIENTS; i++ {
+			since := start
+			if i%2 == 1 {
+				since = start.Add(FAILED_PASSWORD_CHECKS_INTERVAL / 2)
+			}
+			mw.failures[fmt.Sprintf("client%v\x00admin", i)] = &passwordFailures{count: 1, since: since}
+		}
+		mw.removeFinishedFailures(start.Add(time.Duration(testcase.at * float64(time.Second))))
+		assert.Equal(t, testcase.expectedFailures, len(mw.failures), "Error in test case %v", n)
 	}
 }
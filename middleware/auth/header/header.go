@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/Tecsisa/foulkon/middleware/auth"
 )
@@ -32,6 +33,7 @@ func (h HeaderAuthConnector) Authenticate(next http.Handler) http.Handler {
 			}
 			requestID := r.Header.Get(middleware.REQUEST_ID_HEADER)
 			api.LogOperationError(requestID, "", apiError)
+			metrics.ObserveAuthenticationFailure(metrics.CONNECTOR_HEADER)
 			http.Error(rw, fmt.Sprintf("Error %v", apiError.Message), http.StatusUnauthorized)
 		} else {
 			r.Header.Add(middleware.USER_ID_HEADER, hdr)
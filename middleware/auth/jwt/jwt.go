@@ -0,0 +1,307 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	// Hash functions used by signature algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/Tecsisa/foulkon/middleware/auth"
+)
+
+const (
+	// Claim mapped to user id when it isn't configured
+	DEFAULT_USER_CLAIM = "sub"
+
+	BEARER_PREFIX = "Bearer "
+)
+
+// JWTConfig has the keys that verify token signatures and the expected claims
+type JWTConfig struct {
+	Keys []Key
+	// Expected iss claim, any issuer is allowed if it is empty
+	Issuer string
+	// Expected aud claim values, tokens need one of them. Any audience is allowed if it is empty
+	Audiences []string
+	// Claim with the user id
+	UserClaim string
+	// Clock skew allowed checking exp and nbf claims
+	Leeway time.Duration
+}
+
+// JWTAuthConnector represents a connector that validates JWT bearer tokens offline, with configured keys.
+// It implements interface of auth connector
+type JWTAuthConnector struct {
+	config JWTConfig
+	now    func() time.Time
+}
+
+// InitJWTConnector initializes JWT connector configuration
+func InitJWTConnector(config JWTConfig) (auth.AuthConnector, error) {
+	return newJWTConnector(config)
+}
+
+func newJWTConnector(config JWTConfig) (*JWTAuthConnector, error) {
+	if len(config.Keys) < 1 {
+		return nil, errors.New("No keys configured for JWT authenticator")
+	}
+	if config.UserClaim == "" {
+		config.UserClaim = DEFAULT_USER_CLAIM
+	}
+	return &JWTAuthConnector{
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+// Authenticate validates the bearer token of the request and adds the user id of its claims
+func (c JWTAuthConnector) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID, err := c.validateRequest(r)
+		if err != nil {
+			apiError := &api.Error{
+				Code:    api.AUTHENTICATION_API_ERROR,
+				Message: fmt.Sprintf("jwt authenticator: %v", err),
+			}
+			requestID := r.Header.Get(middleware.REQUEST_ID_HEADER)
+			api.LogOperationError(requestID, "", apiError)
+			metrics.ObserveAuthenticationFailure(metrics.CONNECTOR_JWT)
+			http.Error(rw, fmt.Sprintf("Error %v", apiError.Message), http.StatusUnauthorized)
+			return
+		}
+		r.Header.Set(middleware.USER_ID_HEADER, userID)
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// RetrieveUserID retrieves user set by Authenticate, so the token isn't validated again
+func (c JWTAuthConnector) RetrieveUserID(r http.Request) string {
+	userID := r.Header.Get(middleware.USER_ID_HEADER)
+	return userID
+}
+
+func (c JWTAuthConnector) validateRequest(r *http.Request) (string, error) {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, BEARER_PREFIX) {
+		return "", errors.New("no bearer token found")
+	}
+	return c.validateToken(strings.TrimSpace(strings.TrimPrefix(authorization, BEARER_PREFIX)))
+}
+
+// validateToken checks token signature and claims, and returns the user id
+func (c JWTAuthConnector) validateToken(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed token")
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", errors.New("malformed token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("malformed token signature")
+	}
+	if err := c.verifySignature(header.Alg, header.Kid, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return "", err
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", errors.New("malformed token claims")
+	}
+	if err := c.validateClaims(claims); err != nil {
+		return "", err
+	}
+	userID, ok := claimString(claims[c.config.UserClaim])
+	if !ok || userID == "" {
+		return "", fmt.Errorf("claim %v not found", c.config.UserClaim)
+	}
+	return userID, nil
+}
+
+// verifySignature tries the keys allowed for the algorithm and key id of the token
+func (c JWTAuthConnector) verifySignature(alg string, kid string, signingInput []byte, signature []byte) error {
+	hash, ok := algorithmHashes[alg]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %v", alg)
+	}
+	digest := hash.New()
+	digest.Write(signingInput)
+	hashed := digest.Sum(nil)
+
+	for _, key := range c.config.Keys {
+		if (kid != "" && key.ID != "" && key.ID != kid) || (key.Algorithm != "" && key.Algorithm != alg) {
+			continue
+		}
+		if verify(alg, hash, key.Key, signingInput, hashed, signature) {
+			return nil
+		}
+	}
+	return errors.New("invalid token signature")
+}
+
+// Supported signature algorithms
+var algorithmHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// verify checks the signature with the key, only if the key type matches the algorithm family
+func verify(alg string, hash crypto.Hash, key interface{}, signingInput []byte, hashed []byte, signature []byte) bool {
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signingInput)
+		return hmac.Equal(signature, mac.Sum(nil))
+	case "RS":
+		publicKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(publicKey, hash, hashed, signature) == nil
+	case "PS":
+		publicKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPSS(publicKey, hash, hashed, signature,
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case "ES":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || publicKey.Curve.Params().BitSize != ecdsaBitSize(alg) {
+			return false
+		}
+		// Signature is r and s concatenated, with the size of the curve
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(publicKey, hashed, r, s)
+	}
+	return false
+}
+
+func ecdsaBitSize(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	default:
+		return 521
+	}
+}
+
+// validateClaims checks exp, nbf, iss and aud claims. Tokens without exp claim are rejected
+func (c JWTAuthConnector) validateClaims(claims map[string]interface{}) error {
+	now := c.now()
+	exp, ok := claimTime(claims["exp"])
+	if !ok {
+		return errors.New("claim exp not found")
+	}
+	if !now.Before(exp.Add(c.config.Leeway)) {
+		return errors.New("token is expired")
+	}
+	if value, found := claims["nbf"]; found {
+		nbf, ok := claimTime(value)
+		if !ok {
+			return errors.New("invalid claim nbf")
+		}
+		if now.Add(c.config.Leeway).Before(nbf) {
+			return errors.New("token is not valid yet")
+		}
+	}
+
+	if c.config.Issuer != "" {
+		if iss, _ := claimString(claims["iss"]); iss != c.config.Issuer {
+			return fmt.Errorf("unexpected issuer %v", iss)
+		}
+	}
+
+	if len(c.config.Audiences) > 0 {
+		audiences := []string{}
+		switch aud := claims["aud"].(type) {
+		case string:
+			audiences = append(audiences, aud)
+		case []interface{}:
+			for _, value := range aud {
+				if audience, ok := value.(string); ok {
+					audiences = append(audiences, audience)
+				}
+			}
+		}
+		for _, audience := range audiences {
+			for _, expected := range c.config.Audiences {
+				if audience == expected {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("unexpected audience %v", strings.Join(audiences, ","))
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// claimTime returns a NumericDate claim, seconds since epoch
+func claimTime(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	sec := int64(seconds)
+	return time.Unix(sec, int64((seconds-float64(sec))*float64(time.Second))), true
+}
+
+// claimString returns string and numeric claims as string
+func claimString(value interface{}) (string, bool) {
+	switch claim := value.(type) {
+	case string:
+		return claim, true
+	case json.Number:
+		return claim.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,259 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus/hooks/test"
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// Aux func that signs a token with header and claims, using RS256 with *rsa.PrivateKey,
+// ES256 with *ecdsa.PrivateKey and HS256 with []byte
+func signToken(header map[string]interface{}, claims map[string]interface{}, key interface{}) string {
+	headerJSON, _ := json.Marshal(header)
+	claimsJSON, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	hashed := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hashed[:])
+	case *ecdsa.PrivateKey:
+		r, s, _ := ecdsa.Sign(rand.Reader, k, hashed[:])
+		signature = make([]byte, 64)
+		rBytes, sBytes := r.Bytes(), s.Bytes()
+		copy(signature[32-len(rBytes):32], rBytes)
+		copy(signature[64-len(sBytes):], sBytes)
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTAuthConnector_Authenticate(t *testing.T) {
+	// Create logger
+	testLogger, hook := test.NewNullLogger()
+	api.Log = testLogger
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err, "Error in test")
+	otherRsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err, "Error in test")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err, "Error in test")
+	secret := []byte("secret")
+
+	now := time.Unix(1500000000, 0)
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   "https://issuer.example.com",
+			"aud":   []string{"other", "foulkon"},
+			"sub":   "subject",
+			"email": "user@example.com",
+			"exp":   now.Add(time.Hour).Unix(),
+			"nbf":   now.Add(-time.Minute).Unix(),
+		}
+	}
+	withClaim := func(key string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	rs256 := map[string]interface{}{"alg": "RS256", "kid": "rsa1"}
+
+	config := JWTConfig{
+		Keys: []Key{
+			{ID: "rsa1", Algorithm: "RS256", Key: &rsaKey.PublicKey},
+			{ID: "rsa2", Key: &otherRsaKey.PublicKey},
+			{Key: &ecKey.PublicKey},
+			{Key: secret},
+		},
+		Issuer:    "https://issuer.example.com",
+		Audiences: []string{"foulkon"},
+		Leeway:    time.Minute,
+	}
+	testcases := map[string]struct {
+		config        JWTConfig
+		authorization string
+
+		expectedStatusCode int
+		expectedUserID     string
+		expectedLog        string
+	}{
+		"OkCaseRSA": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, validClaims(), rsaKey),
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "subject",
+		},
+		"OkCaseRSAWithoutKid": {
+			config:             config,
+			authorization:      "Bearer " + signToken(map[string]interface{}{"alg": "RS256"}, validClaims(), otherRsaKey),
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "subject",
+		},
+		"OkCaseECDSA": {
+			config:             config,
+			authorization:      "Bearer " + signToken(map[string]interface{}{"alg": "ES256", "kid": "any"}, validClaims(), ecKey),
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "subject",
+		},
+		"OkCaseHMAC": {
+			config:             config,
+			authorization:      "Bearer " + signToken(map[string]interface{}{"alg": "HS256"}, validClaims(), secret),
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "subject",
+		},
+		"OkCaseUserClaim": {
+			config: JWTConfig{
+				Keys:      config.Keys,
+				UserClaim: "email",
+			},
+			authorization:      "Bearer " + signToken(rs256, withClaim("aud", "any"), rsaKey),
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "user@example.com",
+		},
+		"OkCaseExpiredInLeeway": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, withClaim("exp", now.Add(-30*time.Second).Unix()), rsaKey),
+			expectedStatusCode: http.StatusOK,
+			expectedUserID:     "subject",
+		},
+		"ErrorCaseNoToken": {
+			config:             config,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: no bearer token found",
+		},
+		"ErrorCaseMalformedToken": {
+			config:             config,
+			authorization:      "Bearer token",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: malformed token",
+		},
+		"ErrorCaseAlgorithmNone": {
+			config:             config,
+			authorization:      "Bearer " + signToken(map[string]interface{}{"alg": "none"}, validClaims(), nil),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: unsupported algorithm none",
+		},
+		"ErrorCaseKidMismatch": {
+			config:             config,
+			authorization:      "Bearer " + signToken(map[string]interface{}{"alg": "RS256", "kid": "rsa2"}, validClaims(), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: invalid token signature",
+		},
+		"ErrorCaseUnknownKey": {
+			config: JWTConfig{
+				Keys: []Key{{Key: &otherRsaKey.PublicKey}},
+			},
+			authorization:      "Bearer " + signToken(rs256, validClaims(), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: invalid token signature",
+		},
+		"ErrorCaseExpired": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, withClaim("exp", now.Add(-2*time.Minute).Unix()), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: token is expired",
+		},
+		"ErrorCaseWithoutExp": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, withClaim("exp", nil), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: claim exp not found",
+		},
+		"ErrorCaseNotValidYet": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, withClaim("nbf", now.Add(2*time.Minute).Unix()), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: token is not valid yet",
+		},
+		"ErrorCaseUnexpectedIssuer": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, withClaim("iss", "https://other.example.com"), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: unexpected issuer https://other.example.com",
+		},
+		"ErrorCaseUnexpectedAudience": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, withClaim("aud", "other"), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: unexpected audience other",
+		},
+		"ErrorCaseWithoutUserClaim": {
+			config:             config,
+			authorization:      "Bearer " + signToken(rs256, withClaim("sub", nil), rsaKey),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLog:        "jwt authenticator: claim sub not found",
+		},
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for n, testcase := range testcases {
+		hook.Reset()
+		connector, err := newJWTConnector(testcase.config)
+		assert.Nil(t, err, "Error in test case %v", n)
+		connector.now = func() time.Time { return now }
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if testcase.authorization != "" {
+			req.Header.Set("Authorization", testcase.authorization)
+		}
+		w := httptest.NewRecorder()
+		connector.Authenticate(testHandler).ServeHTTP(w, req)
+
+		// Check status code
+		assert.Equal(t, testcase.expectedStatusCode, w.Result().StatusCode, "Error in test case %v", n)
+		// Check user
+		assert.Equal(t, testcase.expectedUserID, req.Header.Get(middleware.USER_ID_HEADER), "Error in test case %v", n)
+		assert.Equal(t, testcase.expectedUserID, connector.RetrieveUserID(*req), "Error in test case %v", n)
+		// Check logger
+		if testcase.expectedLog != "" {
+			assert.Equal(t, testcase.expectedLog, hook.LastEntry().Message, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestJWTAuthConnector_RetrieveUserID(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err, "Error in test")
+	connector, err := newJWTConnector(JWTConfig{
+		Keys: []Key{{Key: &rsaKey.PublicKey}},
+	})
+	assert.Nil(t, err, "Error in test")
+
+	// User is read from the authenticated request, without validating the token again
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set(middleware.USER_ID_HEADER, "user")
+	assert.Equal(t, "user", connector.RetrieveUserID(*req), "Error in test")
+}
+
+func TestInitJWTConnector(t *testing.T) {
+	_, err := InitJWTConnector(JWTConfig{})
+	assert.EqualError(t, err, "No keys configured for JWT authenticator", "Error in test")
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Key is a key that verifies token signatures. Tokens with a key id can only be verified with keys
+// with the same id or without id, and keys with algorithm can only verify tokens signed with it
+type Key struct {
+	ID        string
+	Algorithm string
+	// *rsa.PublicKey, *ecdsa.PublicKey or []byte HMAC secret
+	Key interface{}
+}
+
+// JWKS file format, RFC 7517
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	// RSA params
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC params
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// Symmetric params
+	K string `json:"k"`
+}
+
+// ParseJWKS returns the signature keys of a JWKS document. Encryption keys are skipped
+func ParseJWKS(data []byte) ([]Key, error) {
+	jwks := new(jsonWebKeySet)
+	if err := json.Unmarshal(data, jwks); err != nil {
+		return nil, fmt.Errorf("Invalid JWKS: %v", err)
+	}
+	keys := []Key{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid JWKS key %v: %v", jwk.Kid, err)
+		}
+		keys = append(keys, Key{ID: jwk.Kid, Algorithm: jwk.Alg, Key: key})
+	}
+	return keys, nil
+}
+
+func parseJWK(jwk jsonWebKey) (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if e.BitLen() > 31 || e.Int64() < 2 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %v", jwk.Crv)
+		}
+		x, err := decodeInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(k) < 1 {
+			return nil, errors.New("invalid symmetric key")
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %v", jwk.Kty)
+	}
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) < 1 {
+		return nil, errors.New("invalid key param")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// ParsePEM returns the RSA and EC public keys of PEM encoded public keys and certificates, without key id
+func ParsePEM(data []byte) ([]Key, error) {
+	keys := []Key{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var publicKey interface{}
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid PEM public key: %v", err)
+			}
+			publicKey = key
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid PEM certificate: %v", err)
+			}
+			publicKey = cert.PublicKey
+		default:
+			return nil, fmt.Errorf("Unsupported PEM block type %v", block.Type)
+		}
+		switch publicKey.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			keys = append(keys, Key{Key: publicKey})
+		default:
+			return nil, errors.New("Unsupported PEM key type, only RSA and EC keys are allowed")
+		}
+	}
+	if len(keys) < 1 {
+		return nil, errors.New("No PEM keys found")
+	}
+	return keys, nil
+}
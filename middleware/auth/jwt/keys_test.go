@@ -0,0 +1,125 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Aux func that encodes a big int as a JWK param
+func encodeInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err, "Error in test")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err, "Error in test")
+
+	rsaJWK := fmt.Sprintf(`{"kty":"RSA","kid":"rsa1","alg":"RS256","use":"sig","n":"%v","e":"%v"}`,
+		encodeInt(rsaKey.N), encodeInt(big.NewInt(int64(rsaKey.E))))
+	ecJWK := fmt.Sprintf(`{"kty":"EC","kid":"ec1","crv":"P-256","x":"%v","y":"%v"}`,
+		encodeInt(ecKey.X), encodeInt(ecKey.Y))
+	testcases := map[string]struct {
+		jwks string
+
+		expectedKeys  []Key
+		expectedError string
+	}{
+		"OkCase": {
+			jwks: fmt.Sprintf(`{"keys":[%v,%v,{"kty":"oct","kid":"hmac1","k":"c2VjcmV0"}]}`, rsaJWK, ecJWK),
+			expectedKeys: []Key{
+				{ID: "rsa1", Algorithm: "RS256", Key: &rsaKey.PublicKey},
+				{ID: "ec1", Key: &ecKey.PublicKey},
+				{ID: "hmac1", Key: []byte("secret")},
+			},
+		},
+		"OkCaseEncryptionKeySkipped": {
+			jwks:         `{"keys":[{"kty":"RSA","kid":"enc1","use":"enc","n":"AQAB","e":"AQAB"}]}`,
+			expectedKeys: []Key{},
+		},
+		"ErrorCaseInvalidJSON": {
+			jwks:          `{"keys":`,
+			expectedError: "Invalid JWKS: unexpected end of JSON input",
+		},
+		"ErrorCaseUnsupportedKeyType": {
+			jwks:          `{"keys":[{"kty":"OKP","kid":"ed1"}]}`,
+			expectedError: "Invalid JWKS key ed1: unsupported key type OKP",
+		},
+		"ErrorCaseInvalidCurvePoint": {
+			jwks:          `{"keys":[{"kty":"EC","kid":"ec1","crv":"P-256","x":"AQ","y":"AQ"}]}`,
+			expectedError: "Invalid JWKS key ec1: EC point is not on curve",
+		},
+		"ErrorCaseInvalidExponent": {
+			jwks:          `{"keys":[{"kty":"RSA","kid":"rsa1","n":"AQAB","e":"AQ"}]}`,
+			expectedError: "Invalid JWKS key rsa1: invalid RSA exponent",
+		},
+	}
+
+	for n, test := range testcases {
+		keys, err := ParseJWKS([]byte(test.jwks))
+		if test.expectedError != "" {
+			assert.EqualError(t, err, test.expectedError, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedKeys, keys, "Error in test case %v", n)
+	}
+}
+
+func TestParsePEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err, "Error in test")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err, "Error in test")
+
+	rsaDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.Nil(t, err, "Error in test")
+	ecDER, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	assert.Nil(t, err, "Error in test")
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaDER})
+	ecPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecDER})
+
+	testcases := map[string]struct {
+		pem string
+
+		expectedKeys  []Key
+		expectedError string
+	}{
+		"OkCase": {
+			pem: string(rsaPEM) + string(ecPEM),
+			expectedKeys: []Key{
+				{Key: &rsaKey.PublicKey},
+				{Key: &ecKey.PublicKey},
+			},
+		},
+		"ErrorCaseNoKeys": {
+			pem:           "not a pem",
+			expectedError: "No PEM keys found",
+		},
+		"ErrorCasePrivateKey": {
+			pem:           string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})),
+			expectedError: "Unsupported PEM block type RSA PRIVATE KEY",
+		},
+	}
+
+	for n, test := range testcases {
+		keys, err := ParsePEM([]byte(test.pem))
+		if test.expectedError != "" {
+			assert.EqualError(t, err, test.expectedError, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedKeys, keys, "Error in test case %v", n)
+	}
+}
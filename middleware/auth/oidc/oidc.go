@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
 	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/Tecsisa/foulkon/middleware/auth"
 	"github.com/emanoelxavier/openid2go/openid"
@@ -36,6 +37,7 @@ func InitOIDCConnector(oidcProviders []api.OidcProvider) (auth.AuthConnector, er
 	}
 	errorHandler := func(e error, rw http.ResponseWriter, r *http.Request) bool {
 		requestID := r.Header.Get(middleware.REQUEST_ID_HEADER)
+		metrics.ObserveAuthenticationFailure(metrics.CONNECTOR_OIDC)
 		if validationErr, ok := e.(*openid.ValidationError); ok {
 			apiError := &api.Error{
 				Code:    api.AUTHENTICATION_API_ERROR,
@@ -0,0 +1,162 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/middleware"
+)
+
+const (
+	// CORS headers
+	ORIGIN_HEADER            = "Origin"
+	VARY_HEADER              = "Vary"
+	REQUEST_METHOD_HEADER    = "Access-Control-Request-Method"
+	ALLOW_ORIGIN_HEADER      = "Access-Control-Allow-Origin"
+	ALLOW_CREDENTIALS_HEADER = "Access-Control-Allow-Credentials"
+	ALLOW_METHODS_HEADER     = "Access-Control-Allow-Methods"
+	ALLOW_HEADERS_HEADER     = "Access-Control-Allow-Headers"
+	EXPOSE_HEADERS_HEADER    = "Access-Control-Expose-Headers"
+	MAX_AGE_HEADER           = "Access-Control-Max-Age"
+
+	// Origin setting that allows any origin
+	ANY_ORIGIN = "*"
+
+	// Default settings
+	DEFAULT_CORS_METHODS           = "GET,POST,PUT,DELETE"
+	DEFAULT_CORS_HEADERS           = "Authorization,Content-Type"
+	DEFAULT_CORS_EXPOSED_HEADERS   = middleware.REQUEST_ID_HEADER
+	DEFAULT_CORS_PREFLIGHT_MAX_AGE = "600"
+)
+
+func init() {
+	middleware.Register(middleware.CORS_MIDDLEWARE, func(settings middleware.Settings, handler *middleware.MiddlewareHandler) (middleware.Middleware, error) {
+		config, err := NewCorsConfig(settings)
+		if err != nil {
+			return nil, err
+		}
+		return NewCorsMiddleware(config), nil
+	})
+}
+
+// CorsConfig has the origins allowed to make cross-origin requests, and the values of the CORS response headers
+type CorsConfig struct {
+	Origins          []string
+	Methods          []string
+	Headers          []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// Seconds browsers cache preflight responses
+	MaxAge int
+}
+
+// NewCorsConfig reads CORS config from origins, methods, headers, exposedheaders, credentials and maxage settings.
+// Origins are mandatory, * allows any origin
+func NewCorsConfig(settings middleware.Settings) (CorsConfig, error) {
+	origins := splitList(settings.Get("origins", ""))
+	if len(origins) < 1 {
+		return CorsConfig{}, errors.New("CORS origins not configured")
+	}
+	credentials := settings.Get("credentials", "false")
+	allowCredentials, err := strconv.ParseBool(credentials)
+	if err != nil {
+		return CorsConfig{}, fmt.Errorf("Invalid CORS credentials param: %v", credentials)
+	}
+	maxAgeParam := settings.Get("maxage", DEFAULT_CORS_PREFLIGHT_MAX_AGE)
+	maxAge, err := strconv.Atoi(maxAgeParam)
+	if err != nil || maxAge < 0 {
+		return CorsConfig{}, fmt.Errorf("Invalid CORS maxage param: %v", maxAgeParam)
+	}
+	return CorsConfig{
+		Origins:          origins,
+		Methods:          splitList(settings.Get("methods", DEFAULT_CORS_METHODS)),
+		Headers:          splitList(settings.Get("headers", DEFAULT_CORS_HEADERS)),
+		ExposedHeaders:   splitList(settings.Get("exposedheaders", DEFAULT_CORS_EXPOSED_HEADERS)),
+		AllowCredentials: allowCredentials,
+		MaxAge:           maxAge,
+	}, nil
+}
+
+// CORS middleware system, it adds CORS headers to requests from allowed origins and answers preflight
+// requests, so browser applications in other domains can use the API
+type CorsMiddleware struct {
+	config    CorsConfig
+	anyOrigin bool
+}
+
+// NewCorsMiddleware returns a configured CorsMiddleware
+func NewCorsMiddleware(config CorsConfig) *CorsMiddleware {
+	cm := &CorsMiddleware{
+		config: config,
+	}
+	for _, origin := range config.Origins {
+		if origin == ANY_ORIGIN {
+			cm.anyOrigin = true
+		}
+	}
+	return cm
+}
+
+func (cm *CorsMiddleware) Action(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(ORIGIN_HEADER)
+		if origin == "" || !cm.isAllowedOrigin(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add(VARY_HEADER, ORIGIN_HEADER)
+		// Any origin can't be used with credentials
+		if cm.anyOrigin && !cm.config.AllowCredentials {
+			header.Set(ALLOW_ORIGIN_HEADER, ANY_ORIGIN)
+		} else {
+			header.Set(ALLOW_ORIGIN_HEADER, origin)
+		}
+		if cm.config.AllowCredentials {
+			header.Set(ALLOW_CREDENTIALS_HEADER, "true")
+		}
+
+		// Preflight requests are answered here, they don't have credentials
+		if r.Method == http.MethodOptions && r.Header.Get(REQUEST_METHOD_HEADER) != "" {
+			header.Set(ALLOW_METHODS_HEADER, strings.Join(cm.config.Methods, ","))
+			header.Set(ALLOW_HEADERS_HEADER, strings.Join(cm.config.Headers, ","))
+			header.Set(MAX_AGE_HEADER, strconv.Itoa(cm.config.MaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if len(cm.config.ExposedHeaders) > 0 {
+			header.Set(EXPOSE_HEADERS_HEADER, strings.Join(cm.config.ExposedHeaders, ","))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CORS doesn't add info to middleware context
+func (cm *CorsMiddleware) GetInfo(r *http.Request, mc *middleware.MiddlewareContext) {}
+
+func (cm *CorsMiddleware) isAllowedOrigin(origin string) bool {
+	if cm.anyOrigin {
+		return true
+	}
+	for _, allowed := range cm.config.Origins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList returns the non empty values of a comma separated list
+func splitList(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,217 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// Aux settings
+type testSettings map[string]string
+
+func (ts testSettings) Get(key string, def string) string {
+	if value, ok := ts[key]; ok {
+		return value
+	}
+	return def
+}
+
+func (ts testSettings) Tables(key string) []string {
+	return nil
+}
+
+func TestNewCorsConfig(t *testing.T) {
+	testcases := map[string]struct {
+		settings testSettings
+
+		expectedConfig CorsConfig
+		expectedError  string
+	}{
+		"OkCaseDefaults": {
+			settings: testSettings{
+				"origins": "https://app.example.com, https://admin.example.com",
+			},
+			expectedConfig: CorsConfig{
+				Origins:        []string{"https://app.example.com", "https://admin.example.com"},
+				Methods:        []string{"GET", "POST", "PUT", "DELETE"},
+				Headers:        []string{"Authorization", "Content-Type"},
+				ExposedHeaders: []string{middleware.REQUEST_ID_HEADER},
+				MaxAge:         600,
+			},
+		},
+		"OkCase": {
+			settings: testSettings{
+				"origins":        "*",
+				"methods":        "GET",
+				"headers":        "Authorization",
+				"exposedheaders": "",
+				"credentials":    "true",
+				"maxage":         "0",
+			},
+			expectedConfig: CorsConfig{
+				Origins:          []string{"*"},
+				Methods:          []string{"GET"},
+				Headers:          []string{"Authorization"},
+				ExposedHeaders:   []string{},
+				AllowCredentials: true,
+			},
+		},
+		"ErrorCaseNoOrigins": {
+			settings:      testSettings{},
+			expectedError: "CORS origins not configured",
+		},
+		"ErrorCaseInvalidCredentials": {
+			settings: testSettings{
+				"origins":     "*",
+				"credentials": "yes",
+			},
+			expectedError: "Invalid CORS credentials param: yes",
+		},
+		"ErrorCaseInvalidMaxAge": {
+			settings: testSettings{
+				"origins": "*",
+				"maxage":  "-1",
+			},
+			expectedError: "Invalid CORS maxage param: -1",
+		},
+	}
+
+	for n, test := range testcases {
+		config, err := NewCorsConfig(test.settings)
+		if test.expectedError != "" {
+			assert.EqualError(t, err, test.expectedError, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedConfig, config, "Error in test case %v", n)
+	}
+}
+
+func TestCorsMiddleware_Action(t *testing.T) {
+	testcases := map[string]struct {
+		config CorsConfig
+		method string
+		header map[string]string
+
+		expectedStatusCode int
+		expectedHeader     map[string]string
+	}{
+		"OkCaseAllowedOrigin": {
+			config: CorsConfig{
+				Origins:        []string{"https://app.example.com"},
+				ExposedHeaders: []string{middleware.REQUEST_ID_HEADER},
+			},
+			method: http.MethodGet,
+			header: map[string]string{
+				ORIGIN_HEADER: "https://app.example.com",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeader: map[string]string{
+				ALLOW_ORIGIN_HEADER:   "https://app.example.com",
+				EXPOSE_HEADERS_HEADER: middleware.REQUEST_ID_HEADER,
+				VARY_HEADER:           ORIGIN_HEADER,
+				ALLOW_METHODS_HEADER:  "",
+			},
+		},
+		"OkCaseAnyOrigin": {
+			config: CorsConfig{
+				Origins: []string{"*"},
+			},
+			method: http.MethodGet,
+			header: map[string]string{
+				ORIGIN_HEADER: "https://other.example.com",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeader: map[string]string{
+				ALLOW_ORIGIN_HEADER:      "*",
+				ALLOW_CREDENTIALS_HEADER: "",
+			},
+		},
+		"OkCaseAnyOriginWithCredentials": {
+			config: CorsConfig{
+				Origins:          []string{"*"},
+				AllowCredentials: true,
+			},
+			method: http.MethodGet,
+			header: map[string]string{
+				ORIGIN_HEADER: "https://other.example.com",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeader: map[string]string{
+				ALLOW_ORIGIN_HEADER:      "https://other.example.com",
+				ALLOW_CREDENTIALS_HEADER: "true",
+			},
+		},
+		"OkCasePreflight": {
+			config: CorsConfig{
+				Origins: []string{"https://app.example.com"},
+				Methods: []string{"GET", "POST"},
+				Headers: []string{"Authorization"},
+				MaxAge:  600,
+			},
+			method: http.MethodOptions,
+			header: map[string]string{
+				ORIGIN_HEADER:         "https://app.example.com",
+				REQUEST_METHOD_HEADER: "POST",
+			},
+			expectedStatusCode: http.StatusNoContent,
+			expectedHeader: map[string]string{
+				ALLOW_ORIGIN_HEADER:  "https://app.example.com",
+				ALLOW_METHODS_HEADER: "GET,POST",
+				ALLOW_HEADERS_HEADER: "Authorization",
+				MAX_AGE_HEADER:       "600",
+			},
+		},
+		"OkCaseNotAllowedOrigin": {
+			config: CorsConfig{
+				Origins: []string{"https://app.example.com"},
+			},
+			method: http.MethodOptions,
+			header: map[string]string{
+				ORIGIN_HEADER:         "https://evil.example.com",
+				REQUEST_METHOD_HEADER: "POST",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeader: map[string]string{
+				ALLOW_ORIGIN_HEADER:  "",
+				ALLOW_METHODS_HEADER: "",
+			},
+		},
+		"OkCaseWithoutOrigin": {
+			config: CorsConfig{
+				Origins: []string{"*"},
+			},
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusOK,
+			expectedHeader: map[string]string{
+				ALLOW_ORIGIN_HEADER: "",
+			},
+		},
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for n, test := range testcases {
+		mw := NewCorsMiddleware(test.config)
+		req := httptest.NewRequest(test.method, "/api/v1/users", nil)
+		for key, value := range test.header {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		mw.Action(testHandler).ServeHTTP(w, req)
+		res := w.Result()
+
+		// Check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		// Check headers
+		for key, value := range test.expectedHeader {
+			assert.Equal(t, value, res.Header.Get(key), "Error in test case %v, header %v", n, key)
+		}
+	}
+}
@@ -7,6 +7,12 @@ import (
 	"github.com/Tecsisa/foulkon/middleware"
 )
 
+func init() {
+	middleware.Register(middleware.REQUEST_LOGGER_MIDDLEWARE, func(settings middleware.Settings, handler *middleware.MiddlewareHandler) (middleware.Middleware, error) {
+		return NewRequestLoggerMiddleware(), nil
+	})
+}
+
 // Request logger middleware system
 type RequestLoggerMiddleware struct{}
 
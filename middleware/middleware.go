@@ -1,6 +1,13 @@
 package middleware
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Tecsisa/foulkon/tracing"
+)
 
 const (
 	// HTTP Header
@@ -11,21 +18,46 @@ const (
 	AUTHENTICATOR_MIDDLEWARE  = "AUTHENTICATOR"
 	XREQUESTID_MIDDLEWARE     = "XREQUESTID"
 	REQUEST_LOGGER_MIDDLEWARE = "REQUEST-LOGGER"
+	TRACING_MIDDLEWARE        = "TRACING"
+	RATE_LIMIT_MIDDLEWARE     = "RATE-LIMIT"
+	IP_RATE_LIMIT_MIDDLEWARE  = "IP-RATE-LIMIT"
+	RECOVERY_MIDDLEWARE       = "RECOVERY"
+	CORS_MIDDLEWARE           = "CORS"
 )
 
+// DEFAULT_PIPELINE is the order of middlewares of a handler without pipeline
+var DEFAULT_PIPELINE = []string{
+	RECOVERY_MIDDLEWARE,
+	CORS_MIDDLEWARE,
+	XREQUESTID_MIDDLEWARE,
+	TRACING_MIDDLEWARE,
+	// Rate limiter by client IP limits unauthenticated requests too
+	IP_RATE_LIMIT_MIDDLEWARE,
+	AUTHENTICATOR_MIDDLEWARE,
+	// Rate limiter needs the authenticated user
+	RATE_LIMIT_MIDDLEWARE,
+	REQUEST_LOGGER_MIDDLEWARE,
+}
+
 // MiddlewareHandler handles the HTTP request and applies its list of middlewares before calling the API
 type MiddlewareHandler struct {
 	Middlewares map[string]Middleware
+	// Names of middlewares in execution order, the first one receives the request first
+	Pipeline []string
 }
 
 // MiddlewareContext struct contains all parameters used in the context of middlewares
 type MiddlewareContext struct {
 	// Authenticator middleware
-	UserId string
-	Admin  bool
+	UserId     string
+	Admin      bool
+	BreakGlass bool
 
 	// X-Request-Id middleware
 	XRequestId string
+
+	// Tracing middleware
+	Span *tracing.Span
 }
 
 // Middleware interface with operations that all middlewares must implement
@@ -37,23 +69,23 @@ type Middleware interface {
 	GetInfo(r *http.Request, mc *MiddlewareContext)
 }
 
-// Handle method execute middlewares in correct order before API handler
+// Handle method execute middlewares in pipeline order before API handler
 func (mwh *MiddlewareHandler) Handle(apiHandler http.Handler) http.Handler {
 	var handler http.Handler
 	// Wrap target handler to use middleware
 	handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		apiHandler.ServeHTTP(w, r)
 	})
+	pipeline := mwh.Pipeline
+	if pipeline == nil {
+		pipeline = DEFAULT_PIPELINE
+	}
 	// Middleware execution order is upside-down because when adding an action
 	// it executes itself first, then the rest of the old handler.
-	if val, ok := mwh.Middlewares[REQUEST_LOGGER_MIDDLEWARE]; ok {
-		handler = val.Action(handler)
-	}
-	if val, ok := mwh.Middlewares[AUTHENTICATOR_MIDDLEWARE]; ok {
-		handler = val.Action(handler)
-	}
-	if val, ok := mwh.Middlewares[XREQUESTID_MIDDLEWARE]; ok {
-		handler = val.Action(handler)
+	for i := len(pipeline) - 1; i >= 0; i-- {
+		if val, ok := mwh.Middlewares[pipeline[i]]; ok {
+			handler = val.Action(handler)
+		}
 	}
 
 	return handler
@@ -68,3 +100,65 @@ func (mwh *MiddlewareHandler) GetMiddlewareContext(r *http.Request) *MiddlewareC
 
 	return context
 }
+
+// Settings gives access to the configuration values of a middleware in the pipeline
+type Settings interface {
+	// Get returns the value of key, or def if it isn't configured
+	Get(key string, def string) string
+
+	// Tables returns the names of the tables inside key
+	Tables(key string) []string
+}
+
+// Factory creates a middleware with its settings. handler is the middleware handler the middleware
+// will belong to, to retrieve the middleware context of requests
+type Factory func(settings Settings, handler *MiddlewareHandler) (Middleware, error)
+
+var factories = make(map[string]Factory)
+var factoriesLock sync.RWMutex
+
+// Register makes a middleware available by name for pipelines. It panics if the name is already registered
+func Register(name string, factory Factory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	name = strings.ToUpper(name)
+	if _, ok := factories[name]; ok {
+		panic("Middleware already registered: " + name)
+	}
+	factories[name] = factory
+}
+
+func getFactory(name string) (Factory, bool) {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+	factory, ok := factories[name]
+	return factory, ok
+}
+
+// NewMiddlewareHandler returns a handler with the middlewares of the pipeline, in that order. Middlewares are
+// created with their registered factory and settings, except the ones in provided, that are already created
+func NewMiddlewareHandler(pipeline []string, settings func(name string) Settings, provided map[string]Middleware) (*MiddlewareHandler, error) {
+	mwh := &MiddlewareHandler{
+		Middlewares: make(map[string]Middleware),
+		Pipeline:    []string{},
+	}
+	for _, name := range pipeline {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if _, ok := mwh.Middlewares[name]; ok {
+			return nil, fmt.Errorf("Middleware %v is repeated in pipeline", name)
+		}
+		if m, ok := provided[name]; ok {
+			mwh.Middlewares[name] = m
+		} else if factory, ok := getFactory(name); ok {
+			m, err := factory(settings(strings.ToLower(name)), mwh)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %v middleware config: %v", name, err)
+			}
+			mwh.Middlewares[name] = m
+		} else {
+			return nil, fmt.Errorf("Unknown middleware %v in pipeline", name)
+		}
+		mwh.Pipeline = append(mwh.Pipeline, name)
+	}
+	return mwh, nil
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -37,7 +38,8 @@ func TestMiddlewareHandler_Handle(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 	testcases := map[string]struct {
-		middlewares map[string]Middleware
+		middlewares    map[string]Middleware
+		expectedHeader string
 	}{
 		"OkTestCase": {
 			middlewares: map[string]Middleware{
@@ -50,7 +52,35 @@ func TestMiddlewareHandler_Handle(t *testing.T) {
 				XREQUESTID_MIDDLEWARE: &TestMiddleware{
 					HeaderValue: XREQUESTID_MIDDLEWARE,
 				},
+				TRACING_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: TRACING_MIDDLEWARE,
+				},
+			},
+			expectedHeader: XREQUESTID_MIDDLEWARE + TRACING_MIDDLEWARE + AUTHENTICATOR_MIDDLEWARE + REQUEST_LOGGER_MIDDLEWARE,
+		},
+		"OkTestCaseRateLimit": {
+			middlewares: map[string]Middleware{
+				REQUEST_LOGGER_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: REQUEST_LOGGER_MIDDLEWARE,
+				},
+				AUTHENTICATOR_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: AUTHENTICATOR_MIDDLEWARE,
+				},
+				XREQUESTID_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: XREQUESTID_MIDDLEWARE,
+				},
+				TRACING_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: TRACING_MIDDLEWARE,
+				},
+				RATE_LIMIT_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: RATE_LIMIT_MIDDLEWARE,
+				},
+				IP_RATE_LIMIT_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: IP_RATE_LIMIT_MIDDLEWARE,
+				},
 			},
+			expectedHeader: XREQUESTID_MIDDLEWARE + TRACING_MIDDLEWARE + IP_RATE_LIMIT_MIDDLEWARE + AUTHENTICATOR_MIDDLEWARE +
+				RATE_LIMIT_MIDDLEWARE + REQUEST_LOGGER_MIDDLEWARE,
 		},
 	}
 
@@ -72,8 +102,7 @@ func TestMiddlewareHandler_Handle(t *testing.T) {
 		assert.Equal(t, string(buffer.Bytes()), testMessage)
 
 		// Check Header
-		expectedHeader := XREQUESTID_MIDDLEWARE + AUTHENTICATOR_MIDDLEWARE + REQUEST_LOGGER_MIDDLEWARE
-		assert.Equal(t, expectedHeader, req.Header.Get(TEST_HEADER_NAME))
+		assert.Equal(t, testcase.expectedHeader, req.Header.Get(TEST_HEADER_NAME), "Error in test case %v", x)
 	}
 
 }
@@ -119,6 +148,99 @@ func TestMiddlewareHandler_GetMiddlewareContext(t *testing.T) {
 
 }
 
+// Aux settings
+type testSettings map[string]string
+
+func (ts testSettings) Get(key string, def string) string {
+	if value, ok := ts[key]; ok {
+		return value
+	}
+	return def
+}
+
+func (ts testSettings) Tables(key string) []string {
+	return nil
+}
+
+func TestNewMiddlewareHandler(t *testing.T) {
+	Register("test-value", func(settings Settings, handler *MiddlewareHandler) (Middleware, error) {
+		return &TestMiddleware{HeaderValue: settings.Get("value", "default")}, nil
+	})
+	Register("TEST-ERROR", func(settings Settings, handler *MiddlewareHandler) (Middleware, error) {
+		return nil, errors.New("invalid value")
+	})
+	// Names are registered only once
+	assert.Panics(t, func() {
+		Register("TEST-VALUE", nil)
+	}, "Error in test")
+
+	settings := map[string]testSettings{
+		"test-value": {"value": "A"},
+	}
+	testcases := map[string]struct {
+		pipeline []string
+		provided map[string]Middleware
+
+		expectedPipeline []string
+		expectedHeader   string
+		expectedError    string
+	}{
+		"OkCase": {
+			pipeline: []string{" test-value", "authenticator "},
+			provided: map[string]Middleware{
+				AUTHENTICATOR_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: AUTHENTICATOR_MIDDLEWARE,
+				},
+			},
+			expectedPipeline: []string{"TEST-VALUE", AUTHENTICATOR_MIDDLEWARE},
+			expectedHeader:   "A" + AUTHENTICATOR_MIDDLEWARE,
+		},
+		"OkCaseEmptyPipeline": {
+			pipeline: []string{},
+			provided: map[string]Middleware{
+				AUTHENTICATOR_MIDDLEWARE: &TestMiddleware{
+					HeaderValue: AUTHENTICATOR_MIDDLEWARE,
+				},
+			},
+			expectedPipeline: []string{},
+			expectedHeader:   "",
+		},
+		"ErrorCaseUnknownMiddleware": {
+			pipeline:      []string{"test-value", "unknown"},
+			expectedError: "Unknown middleware UNKNOWN in pipeline",
+		},
+		"ErrorCaseRepeatedMiddleware": {
+			pipeline:      []string{"test-value", "TEST-VALUE"},
+			expectedError: "Middleware TEST-VALUE is repeated in pipeline",
+		},
+		"ErrorCaseFactoryError": {
+			pipeline:      []string{"test-error"},
+			expectedError: "Invalid TEST-ERROR middleware config: invalid value",
+		},
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for n, test := range testcases {
+		mwh, err := NewMiddlewareHandler(test.pipeline, func(name string) Settings {
+			return settings[name]
+		}, test.provided)
+		if test.expectedError != "" {
+			assert.EqualError(t, err, test.expectedError, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedPipeline, mwh.Pipeline, "Error in test case %v", n)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		mwh.Handle(testHandler).ServeHTTP(w, req)
+		assert.Equal(t, test.expectedHeader, req.Header.Get(TEST_HEADER_NAME), "Error in test case %v", n)
+	}
+}
+
 // Private helper methods
 func getMiddlewareHandler(middlewares map[string]Middleware) *MiddlewareHandler {
 	return &MiddlewareHandler{Middlewares: middlewares}
@@ -0,0 +1,290 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/metrics"
+	"github.com/Tecsisa/foulkon/middleware"
+)
+
+const (
+	RETRY_AFTER_HEADER = "Retry-After"
+
+	// Route group of requests that don't match any configured group
+	DEFAULT_GROUP = "default"
+
+	// Time between removals of unused buckets
+	BUCKET_CLEANUP_INTERVAL = time.Minute
+)
+
+// Limit of a token bucket, that allows Rate requests per second with bursts of Burst requests.
+// A Rate of 0 means unlimited
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RouteGroup applies its limit to requests whose path starts with Prefix
+type RouteGroup struct {
+	Name   string
+	Prefix string
+	Limit  Limit
+}
+
+// RateLimitConfig has the limit of requests that don't match any group, the route groups and
+// the limits of principals that override the limit of any group
+type RateLimitConfig struct {
+	Default    Limit
+	Groups     []RouteGroup
+	Principals map[string]Limit
+}
+
+func init() {
+	middleware.Register(middleware.RATE_LIMIT_MIDDLEWARE, func(settings middleware.Settings, handler *middleware.MiddlewareHandler) (middleware.Middleware, error) {
+		// Users are only trusted when the authenticator runs before, otherwise clients could choose their principal
+		byUser := false
+		for _, name := range handler.Pipeline {
+			if name == middleware.AUTHENTICATOR_MIDDLEWARE {
+				byUser = true
+			}
+		}
+		return newRateLimitMiddleware(settings, byUser)
+	})
+	middleware.Register(middleware.IP_RATE_LIMIT_MIDDLEWARE, func(settings middleware.Settings, handler *middleware.MiddlewareHandler) (middleware.Middleware, error) {
+		return newRateLimitMiddleware(settings, false)
+	})
+}
+
+func newRateLimitMiddleware(settings middleware.Settings, byUser bool) (middleware.Middleware, error) {
+	config, err := NewRateLimitConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+	api.Log.Infof("Rate limiter configured with default limit: %v, route groups: %v, principal limits: %v, limit by user: %v",
+		config.Default, config.Groups, config.Principals, byUser)
+	return NewRateLimitMiddleware(config, byUser), nil
+}
+
+// NewRateLimitConfig reads the default limit from rate and burst settings, route groups from groups tables
+// and principal limits from principals setting, with principal:rate:burst format
+func NewRateLimitConfig(settings middleware.Settings) (RateLimitConfig, error) {
+	defaultLimit, err := parseLimit(settings.Get("rate", "0"), settings.Get("burst", ""))
+	if err != nil {
+		return RateLimitConfig{}, err
+	}
+	config := RateLimitConfig{
+		Default:    defaultLimit,
+		Principals: make(map[string]Limit),
+	}
+
+	// Route groups, each one in its own table
+	for _, name := range settings.Tables("groups") {
+		key := "groups." + name
+		prefix := settings.Get(key+".prefix", "")
+		if !strings.HasPrefix(prefix, "/") {
+			return RateLimitConfig{}, fmt.Errorf("Invalid rate limit prefix param in group %v: %v", name, prefix)
+		}
+		limit, err := parseLimit(settings.Get(key+".rate", "0"), settings.Get(key+".burst", ""))
+		if err != nil {
+			return RateLimitConfig{}, err
+		}
+		config.Groups = append(config.Groups, RouteGroup{
+			Name:   name,
+			Prefix: prefix,
+			Limit:  limit,
+		})
+	}
+
+	// Principal is an user id or an IP, that can have colons
+	if principals := settings.Get("principals", ""); principals != "" {
+		for _, principalLimit := range strings.Split(principals, ",") {
+			principalLimit = strings.TrimSpace(principalLimit)
+			burstIndex := strings.LastIndex(principalLimit, ":")
+			rateIndex := -1
+			if burstIndex > 0 {
+				rateIndex = strings.LastIndex(principalLimit[:burstIndex], ":")
+			}
+			if rateIndex < 1 {
+				return RateLimitConfig{}, fmt.Errorf("Invalid rate limit principal param: %v", principalLimit)
+			}
+			limit, err := parseLimit(principalLimit[rateIndex+1:burstIndex], principalLimit[burstIndex+1:])
+			if err != nil {
+				return RateLimitConfig{}, err
+			}
+			config.Principals[principalLimit[:rateIndex]] = limit
+		}
+	}
+	return config, nil
+}
+
+// parseLimit parses a limit of requests per second, with a burst of the rate rounded up by default
+func parseLimit(rate string, burst string) (Limit, error) {
+	rateValue, err := strconv.ParseFloat(rate, 64)
+	if err != nil || rateValue < 0 {
+		return Limit{}, fmt.Errorf("Invalid rate limit rate param: %v", rate)
+	}
+	burstValue := int(math.Ceil(rateValue))
+	if burst != "" {
+		burstValue, err = strconv.Atoi(burst)
+		if err != nil || burstValue < 0 {
+			return Limit{}, fmt.Errorf("Invalid rate limit burst param: %v", burst)
+		}
+	}
+	return Limit{Rate: rateValue, Burst: burstValue}, nil
+}
+
+// RateLimit middleware system, it applies a token bucket to each principal in each route group.
+// Principal is the authenticated user when limiting by user, or the client IP
+type RateLimitMiddleware struct {
+	config RateLimitConfig
+	byUser bool
+
+	lock        sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+// NewRateLimitMiddleware returns a configured RateLimitMiddleware. When byUser is true requests are limited by the user
+// set by the authenticator, so this middleware must be applied after it. Otherwise they are limited by client IP, so
+// it can be applied before the authenticator to limit unauthenticated requests too
+func NewRateLimitMiddleware(config RateLimitConfig, byUser bool) *RateLimitMiddleware {
+	// Longest prefixes first, so the most specific group is matched
+	groups := make([]RouteGroup, len(config.Groups))
+	copy(groups, config.Groups)
+	sort.Stable(byPrefixLength(groups))
+	config.Groups = groups
+
+	return &RateLimitMiddleware{
+		config:      config,
+		byUser:      byUser,
+		buckets:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+		now:         time.Now,
+	}
+}
+
+func (rl *RateLimitMiddleware) Action(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group, limit := rl.getGroup(r.URL.Path)
+		principal := rl.getPrincipal(r)
+		if principalLimit, ok := rl.config.Principals[principal]; ok {
+			limit = principalLimit
+		}
+		if limit.Rate <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed, retryAfter := rl.take(group+"/"+principal, limit); !allowed {
+			msg := fmt.Sprintf("Rate limit exceeded in route group %v, retry after %v seconds", group, retryAfter)
+			api.LogOperationWarn(r.Header.Get(middleware.REQUEST_ID_HEADER), principal, msg)
+			metrics.ObserveRateLimited(group)
+			w.Header().Set(RETRY_AFTER_HEADER, strconv.Itoa(retryAfter))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Rate limiter doesn't add info to middleware context
+func (rl *RateLimitMiddleware) GetInfo(r *http.Request, mc *middleware.MiddlewareContext) {}
+
+// getGroup returns the name and limit of the route group of the path
+func (rl *RateLimitMiddleware) getGroup(path string) (string, Limit) {
+	for _, group := range rl.config.Groups {
+		if strings.HasPrefix(path, group.Prefix) {
+			return group.Name, group.Limit
+		}
+	}
+	return DEFAULT_GROUP, rl.config.Default
+}
+
+// getPrincipal returns the authenticated user of the request when limiting by user, or the client IP.
+// User is read from the header set by the authenticator, so credentials aren't validated again
+func (rl *RateLimitMiddleware) getPrincipal(r *http.Request) string {
+	if rl.byUser {
+		if userID := r.Header.Get(middleware.USER_ID_HEADER); userID != "" {
+			return userID
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// take consumes a token of the bucket, returning whether there was any token and
+// otherwise the seconds until next token
+func (rl *RateLimitMiddleware) take(key string, limit Limit) (bool, int) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	now := rl.now()
+	rl.cleanup(now)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst(limit)), last: now}
+		rl.buckets[key] = b
+	}
+	b.refill(now, limit)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, int(math.Ceil((1 - b.tokens) / limit.Rate))
+}
+
+// cleanup removes the buckets that are full again, since they are the same as new ones
+func (rl *RateLimitMiddleware) cleanup(now time.Time) {
+	if now.Sub(rl.lastCleanup) < BUCKET_CLEANUP_INTERVAL {
+		return
+	}
+	rl.lastCleanup = now
+	for key, b := range rl.buckets {
+		b.refill(now, b.limit)
+		if b.tokens >= float64(burst(b.limit)) {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// refill adds the tokens generated since last refill, up to the burst
+func (b *bucket) refill(now time.Time, limit Limit) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(burst(limit)), b.tokens+elapsed*limit.Rate)
+	}
+	b.last = now
+	b.limit = limit
+}
+
+// burst returns the bucket size of the limit, at least one request
+func burst(limit Limit) int {
+	if limit.Burst < 1 {
+		return 1
+	}
+	return limit.Burst
+}
+
+type byPrefixLength []RouteGroup
+
+func (g byPrefixLength) Len() int           { return len(g) }
+func (g byPrefixLength) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
+func (g byPrefixLength) Less(i, j int) bool { return len(g[i].Prefix) > len(g[j].Prefix) }
@@ -0,0 +1,313 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus/hooks/test"
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// Aux settings, with table keys separated by dots
+type testSettings map[string]string
+
+func (ts testSettings) Get(key string, def string) string {
+	if value, ok := ts[key]; ok {
+		return value
+	}
+	return def
+}
+
+func (ts testSettings) Tables(key string) []string {
+	names := make(map[string]bool)
+	for k := range ts {
+		if strings.HasPrefix(k, key+".") {
+			names[strings.SplitN(strings.TrimPrefix(k, key+"."), ".", 2)[0]] = true
+		}
+	}
+	tables := []string{}
+	for name := range names {
+		tables = append(tables, name)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+type testRequest struct {
+	// Seconds since first request
+	at     float64
+	path   string
+	userID string
+	ip     string
+
+	expectedStatusCode int
+	expectedRetryAfter string
+}
+
+func TestRateLimitMiddleware_Action(t *testing.T) {
+	// Create logger
+	testLogger, hook := test.NewNullLogger()
+	api.Log = testLogger
+	testcases := map[string]struct {
+		config   RateLimitConfig
+		byIP     bool
+		requests []testRequest
+	}{
+		"OkCaseBurstAndRefill": {
+			config: RateLimitConfig{
+				Default: Limit{Rate: 1, Burst: 2},
+			},
+			requests: []testRequest{
+				{at: 0, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusTooManyRequests, expectedRetryAfter: "1"},
+				{at: 0, path: "/api/v1/users", userID: "user2", expectedStatusCode: http.StatusOK},
+				{at: 1, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusOK},
+			},
+		},
+		"OkCaseRetryAfter": {
+			config: RateLimitConfig{
+				Default: Limit{Rate: 0.2, Burst: 1},
+			},
+			requests: []testRequest{
+				{at: 0, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusOK},
+				{at: 1, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusTooManyRequests, expectedRetryAfter: "4"},
+				{at: 5, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusOK},
+			},
+		},
+		"OkCaseAnonymousByIP": {
+			config: RateLimitConfig{
+				Default: Limit{Rate: 1, Burst: 1},
+			},
+			requests: []testRequest{
+				{at: 0, path: "/", ip: "10.0.0.1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", ip: "10.0.0.1", expectedStatusCode: http.StatusTooManyRequests, expectedRetryAfter: "1"},
+				{at: 0, path: "/", ip: "10.0.0.2", expectedStatusCode: http.StatusOK},
+			},
+		},
+		"OkCaseByIP": {
+			config: RateLimitConfig{
+				Default: Limit{Rate: 1, Burst: 1},
+			},
+			byIP: true,
+			requests: []testRequest{
+				{at: 0, path: "/", userID: "user1", ip: "10.0.0.1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", userID: "user2", ip: "10.0.0.1", expectedStatusCode: http.StatusTooManyRequests, expectedRetryAfter: "1"},
+				{at: 0, path: "/", userID: "user1", ip: "10.0.0.2", expectedStatusCode: http.StatusOK},
+			},
+		},
+		"OkCaseRouteGroups": {
+			config: RateLimitConfig{
+				Default: Limit{},
+				Groups: []RouteGroup{
+					{Name: "api", Prefix: "/api/v1", Limit: Limit{Rate: 10, Burst: 10}},
+					{Name: "authorize", Prefix: "/api/v1/resource", Limit: Limit{Rate: 1, Burst: 1}},
+				},
+			},
+			requests: []testRequest{
+				{at: 0, path: "/api/v1/resource", userID: "user1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/api/v1/resource", userID: "user1", expectedStatusCode: http.StatusTooManyRequests, expectedRetryAfter: "1"},
+				{at: 0, path: "/api/v1/users", userID: "user1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/about", userID: "user1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/about", userID: "user1", expectedStatusCode: http.StatusOK},
+			},
+		},
+		"OkCasePrincipalOverride": {
+			config: RateLimitConfig{
+				Default: Limit{Rate: 1, Burst: 1},
+				Principals: map[string]Limit{
+					"batch":    {Rate: 1, Burst: 2},
+					"admin":    {},
+					"10.0.0.1": {Rate: 1, Burst: 3},
+				},
+			},
+			requests: []testRequest{
+				{at: 0, path: "/", userID: "batch", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", userID: "batch", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", userID: "batch", expectedStatusCode: http.StatusTooManyRequests, expectedRetryAfter: "1"},
+				{at: 0, path: "/", userID: "admin", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", userID: "admin", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", ip: "10.0.0.1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", ip: "10.0.0.1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", ip: "10.0.0.1", expectedStatusCode: http.StatusOK},
+				{at: 0, path: "/", ip: "10.0.0.1", expectedStatusCode: http.StatusTooManyRequests, expectedRetryAfter: "1"},
+			},
+		},
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	start := time.Now()
+
+	for n, test := range testcases {
+		mw := NewRateLimitMiddleware(test.config, !test.byIP)
+		for i, request := range test.requests {
+			mw.now = func() time.Time {
+				return start.Add(time.Duration(request.at * float64(time.Second)))
+			}
+			req := httptest.NewRequest(http.MethodGet, request.path, nil)
+			req.Header.Set(middleware.USER_ID_HEADER, request.userID)
+			if request.ip != "" {
+				req.RemoteAddr = request.ip + ":1234"
+			}
+			w := httptest.NewRecorder()
+			mw.Action(testHandler).ServeHTTP(w, req)
+			res := w.Result()
+
+			// Check status code and retry header
+			assert.Equal(t, request.expectedStatusCode, res.StatusCode, "Error in test case %v, request %v", n, i)
+			assert.Equal(t, request.expectedRetryAfter, res.Header.Get(RETRY_AFTER_HEADER), "Error in test case %v, request %v", n, i)
+			if request.expectedStatusCode == http.StatusTooManyRequests {
+				assert.Contains(t, hook.LastEntry().Message, "Rate limit exceeded", "Error in test case %v, request %v", n, i)
+			}
+		}
+	}
+}
+
+func TestRateLimitMiddleware_cleanup(t *testing.T) {
+	start := time.Now()
+	mw := NewRateLimitMiddleware(RateLimitConfig{Default: Limit{Rate: 1, Burst: 2}}, true)
+	mw.now = func() time.Time { return start }
+	mw.lastCleanup = start
+
+	allowed, _ := mw.take("default/user1", Limit{Rate: 1, Burst: 2})
+	assert.True(t, allowed, "Error in test")
+	assert.Equal(t, 1, len(mw.buckets), "Error in test")
+
+	// Full buckets are removed after cleanup interval
+	mw.now = func() time.Time { return start.Add(BUCKET_CLEANUP_INTERVAL) }
+	allowed, _ = mw.take("default/user2", Limit{Rate: 0.001, Burst: 1})
+	assert.True(t, allowed, "Error in test")
+	assert.Equal(t, 1, len(mw.buckets), "Error in test")
+	_, ok := mw.buckets["default/user2"]
+	assert.True(t, ok, "Error in test")
+}
+
+func TestNewRateLimitConfig(t *testing.T) {
+	testcases := map[string]struct {
+		settings testSettings
+
+		expectedConfig RateLimitConfig
+		expectedError  string
+	}{
+		"OkCaseDefaults": {
+			settings: testSettings{},
+			expectedConfig: RateLimitConfig{
+				Principals: map[string]Limit{},
+			},
+		},
+		"OkCase": {
+			settings: testSettings{
+				"rate":                    "2.5",
+				"principals":              "batch:100:200, ::1:0:0",
+				"groups.authorize.prefix": "/api/v1/resource",
+				"groups.authorize.rate":   "50",
+				"groups.authorize.burst":  "100",
+			},
+			expectedConfig: RateLimitConfig{
+				Default: Limit{Rate: 2.5, Burst: 3},
+				Groups: []RouteGroup{
+					{Name: "authorize", Prefix: "/api/v1/resource", Limit: Limit{Rate: 50, Burst: 100}},
+				},
+				Principals: map[string]Limit{
+					"batch": {Rate: 100, Burst: 200},
+					"::1":   {Rate: 0, Burst: 0},
+				},
+			},
+		},
+		"ErrorCaseInvalidRate": {
+			settings: testSettings{
+				"rate": "-1",
+			},
+			expectedError: "Invalid rate limit rate param: -1",
+		},
+		"ErrorCaseInvalidBurst": {
+			settings: testSettings{
+				"rate":  "1",
+				"burst": "many",
+			},
+			expectedError: "Invalid rate limit burst param: many",
+		},
+		"ErrorCaseInvalidPrefix": {
+			settings: testSettings{
+				"groups.authorize.prefix": "api",
+			},
+			expectedError: "Invalid rate limit prefix param in group authorize: api",
+		},
+		"ErrorCaseInvalidPrincipal": {
+			settings: testSettings{
+				"principals": "batch:1",
+			},
+			expectedError: "Invalid rate limit principal param: batch:1",
+		},
+	}
+
+	for n, test := range testcases {
+		config, err := NewRateLimitConfig(test.settings)
+		if test.expectedError != "" {
+			assert.EqualError(t, err, test.expectedError, "Error in test case %v", n)
+			continue
+		}
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedConfig, config, "Error in test case %v", n)
+	}
+}
+
+// Aux authenticator middleware of pipelines
+type testAuthenticator struct{}
+
+func (ta testAuthenticator) Action(next http.Handler) http.Handler {
+	return next
+}
+
+func (ta testAuthenticator) GetInfo(r *http.Request, mc *middleware.MiddlewareContext) {}
+
+func TestRateLimitMiddleware_Register(t *testing.T) {
+	// Create logger
+	testLogger, _ := test.NewNullLogger()
+	api.Log = testLogger
+	testcases := map[string]struct {
+		pipeline   []string
+		middleware string
+
+		expectedByUser bool
+	}{
+		"OkCaseAfterAuthenticator": {
+			pipeline:       []string{"authenticator", "rate-limit"},
+			middleware:     middleware.RATE_LIMIT_MIDDLEWARE,
+			expectedByUser: true,
+		},
+		"OkCaseBeforeAuthenticator": {
+			pipeline:       []string{"rate-limit", "authenticator"},
+			middleware:     middleware.RATE_LIMIT_MIDDLEWARE,
+			expectedByUser: false,
+		},
+		"OkCaseWithoutAuthenticator": {
+			pipeline:       []string{"rate-limit"},
+			middleware:     middleware.RATE_LIMIT_MIDDLEWARE,
+			expectedByUser: false,
+		},
+		"OkCaseByIP": {
+			pipeline:       []string{"authenticator", "ip-rate-limit"},
+			middleware:     middleware.IP_RATE_LIMIT_MIDDLEWARE,
+			expectedByUser: false,
+		},
+	}
+
+	for n, test := range testcases {
+		mwh, err := middleware.NewMiddlewareHandler(test.pipeline, func(name string) middleware.Settings {
+			return testSettings{}
+		}, map[string]middleware.Middleware{middleware.AUTHENTICATOR_MIDDLEWARE: testAuthenticator{}})
+		assert.Nil(t, err, "Error in test case %v", n)
+		mw, ok := mwh.Middlewares[test.middleware].(*RateLimitMiddleware)
+		assert.True(t, ok, "Error in test case %v", n)
+		assert.Equal(t, test.expectedByUser, mw.byUser, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,50 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
+)
+
+func init() {
+	middleware.Register(middleware.RECOVERY_MIDDLEWARE, func(settings middleware.Settings, handler *middleware.MiddlewareHandler) (middleware.Middleware, error) {
+		return NewRecoveryMiddleware(), nil
+	})
+}
+
+// Recovery middleware system, it answers with an internal server error when a later handler panics,
+// instead of closing the connection without response
+type RecoveryMiddleware struct{}
+
+// NewRecoveryMiddleware returns a configured RecoveryMiddleware
+func NewRecoveryMiddleware() *RecoveryMiddleware {
+	return &RecoveryMiddleware{}
+}
+
+func (rm *RecoveryMiddleware) Action(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Handlers abort responses on purpose with this panic
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			apiError := &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: fmt.Sprintf("Panic serving request: %v\n%s", rec, debug.Stack()),
+			}
+			api.LogOperationError(r.Header.Get(middleware.REQUEST_ID_HEADER), r.Header.Get(middleware.USER_ID_HEADER), apiError)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Recovery doesn't add info to middleware context
+func (rm *RecoveryMiddleware) GetInfo(r *http.Request, mc *middleware.MiddlewareContext) {}
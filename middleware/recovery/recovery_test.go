@@ -0,0 +1,70 @@
+package recovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Sirupsen/logrus/hooks/test"
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryMiddleware_Action(t *testing.T) {
+	// Create logger
+	testLogger, hook := test.NewNullLogger()
+	api.Log = testLogger
+	testcases := map[string]struct {
+		handler http.HandlerFunc
+
+		expectedStatusCode int
+		expectedLog        string
+	}{
+		"OkCase": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		"ErrorCasePanic": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("unexpected nil")
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedLog:        "Panic serving request: unexpected nil",
+		},
+	}
+
+	for n, test := range testcases {
+		hook.Reset()
+		mw := NewRecoveryMiddleware()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middleware.REQUEST_ID_HEADER, "123")
+		w := httptest.NewRecorder()
+		mw.Action(test.handler).ServeHTTP(w, req)
+		res := w.Result()
+
+		// Check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		// Check log
+		if test.expectedLog != "" {
+			assert.Contains(t, hook.LastEntry().Message, test.expectedLog, "Error in test case %v", n)
+			assert.Equal(t, "123", hook.LastEntry().Data["requestID"], "Error in test case %v", n)
+		} else {
+			assert.Nil(t, hook.LastEntry(), "Error in test case %v", n)
+		}
+	}
+}
+
+func TestRecoveryMiddleware_ActionAbortHandler(t *testing.T) {
+	mw := NewRecoveryMiddleware()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	assert.Panics(t, func() {
+		mw.Action(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(w, req)
+	}, "Error in test")
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/Tecsisa/foulkon/tracing"
+)
+
+func init() {
+	middleware.Register(middleware.TRACING_MIDDLEWARE, func(settings middleware.Settings, handler *middleware.MiddlewareHandler) (middleware.Middleware, error) {
+		return NewTracingMiddleware(), nil
+	})
+}
+
+// Tracing middleware system, it starts the server span of each request continuing the trace propagated in
+// traceparent header
+type TracingMiddleware struct{}
+
+// NewTracingMiddleware returns a configured TracingMiddleware
+func NewTracingMiddleware() *TracingMiddleware {
+	return &TracingMiddleware{}
+}
+
+// statusResponseWriter keeps the status code written in the response
+type statusResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (t *TracingMiddleware) Action(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := tracing.StartSpan("HTTP "+r.Method, tracing.KIND_SERVER, tracing.Extract(r.Header))
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer span.Finish()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+		span.SetAttribute("request.id", r.Header.Get(middleware.REQUEST_ID_HEADER))
+
+		sw := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(tracing.ContextWithSpan(r.Context(), span)))
+
+		span.SetAttribute("http.status_code", strconv.Itoa(sw.statusCode))
+		if sw.statusCode >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("Status code %v", sw.statusCode))
+		}
+	})
+}
+
+func (t *TracingMiddleware) GetInfo(r *http.Request, mc *middleware.MiddlewareContext) {
+	mc.Span = tracing.SpanFromContext(r.Context())
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/Tecsisa/foulkon/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+// testExporter keeps exported spans
+type testExporter struct {
+	lock  sync.Mutex
+	spans []*tracing.Span
+}
+
+func (te *testExporter) Export(span *tracing.Span) {
+	te.lock.Lock()
+	defer te.lock.Unlock()
+	te.spans = append(te.spans, span)
+}
+
+func TestTracingMiddleware_Action(t *testing.T) {
+	testcases := map[string]struct {
+		disabled    bool
+		traceParent string
+		statusCode  int
+
+		expectedTraceID string
+		expectedParent  string
+		expectedError   string
+	}{
+		"OkCaseNewTrace": {
+			statusCode: http.StatusOK,
+		},
+		"OkCaseContinueTrace": {
+			traceParent:     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			statusCode:      http.StatusOK,
+			expectedTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedParent:  "00f067aa0ba902b7",
+		},
+		"OkCaseServerError": {
+			statusCode:    http.StatusInternalServerError,
+			expectedError: "Status code 500",
+		},
+		"OkCaseDisabled": {
+			disabled:   true,
+			statusCode: http.StatusOK,
+		},
+	}
+
+	mw := NewTracingMiddleware()
+	for n, test := range testcases {
+		exporter := &testExporter{}
+		tracing.SetExporter(exporter)
+		if test.disabled {
+			tracing.SetExporter(nil)
+		}
+
+		var mc *middleware.MiddlewareContext
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mc = new(middleware.MiddlewareContext)
+			mw.GetInfo(r, mc)
+			w.WriteHeader(test.statusCode)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/path?key=value", nil)
+		req.Header.Set(middleware.REQUEST_ID_HEADER, "requestID")
+		if test.traceParent != "" {
+			req.Header.Set(tracing.TRACEPARENT_HEADER, test.traceParent)
+		}
+		w := httptest.NewRecorder()
+		mw.Action(testHandler).ServeHTTP(w, req)
+
+		// Check status code
+		assert.Equal(t, test.statusCode, w.Result().StatusCode, "Error in test case %v", n)
+
+		if test.disabled {
+			assert.Nil(t, mc.Span, "Error in test case %v", n)
+			assert.Empty(t, exporter.spans, "Error in test case %v", n)
+			continue
+		}
+
+		// Check span
+		assert.Equal(t, 1, len(exporter.spans), "Error in test case %v", n)
+		span := exporter.spans[0]
+		assert.Equal(t, span, mc.Span, "Error in test case %v", n)
+		assert.Equal(t, "HTTP GET", span.Name, "Error in test case %v", n)
+		assert.Equal(t, tracing.KIND_SERVER, span.Kind, "Error in test case %v", n)
+		if test.expectedTraceID != "" {
+			assert.Equal(t, test.expectedTraceID, span.Context.TraceID, "Error in test case %v", n)
+		}
+		assert.Equal(t, test.expectedParent, span.ParentID, "Error in test case %v", n)
+		assert.Equal(t, test.expectedError, span.Error, "Error in test case %v", n)
+		assert.Equal(t, "/path?key=value", span.Attributes["http.target"], "Error in test case %v", n)
+		assert.Equal(t, "requestID", span.Attributes["request.id"], "Error in test case %v", n)
+	}
+	tracing.SetExporter(nil)
+}
@@ -7,6 +7,12 @@ import (
 	"github.com/satori/go.uuid"
 )
 
+func init() {
+	middleware.Register(middleware.XREQUESTID_MIDDLEWARE, func(settings middleware.Settings, handler *middleware.MiddlewareHandler) (middleware.Middleware, error) {
+		return NewXRequestIdMiddleware(), nil
+	})
+}
+
 // XRequestId middleware system
 type XRequestIdMiddleware struct{}
 
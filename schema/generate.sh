@@ -2,6 +2,11 @@
 prmd doc group.json > ../doc/api/group.md
 prmd doc user.json > ../doc/api/user.md
 prmd doc policy.json > ../doc/api/policy.md
+prmd doc policy_template.json > ../doc/api/policy_template.md
 prmd doc proxy_resource.json > ../doc/api/proxy_resource.md
 prmd doc resource.json > ../doc/api/resource.md
-prmd doc oidc_provider.json > ../doc/api/oidc_provider.md
\ No newline at end of file
+prmd doc oidc_provider.json > ../doc/api/oidc_provider.md
+prmd doc break_glass.json > ../doc/api/break_glass.md
+prmd doc change_request.json > ../doc/api/change_request.md
+prmd doc audit.json > ../doc/api/audit.md
+prmd doc webhook.json > ../doc/api/webhook.md
\ No newline at end of file
@@ -0,0 +1,250 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Exporter types
+	EXPORTER_NONE   = "none"
+	EXPORTER_STDOUT = "stdout"
+	EXPORTER_FILE   = "file"
+	EXPORTER_OTLP   = "otlp"
+
+	// OTLP exporter batching
+	OTLP_TRACES_PATH = "/v1/traces"
+	OTLP_QUEUE_SIZE  = 2048
+	OTLP_BATCH_SIZE  = 256
+)
+
+// Exporter sends ended spans to a tracing backend
+type Exporter interface {
+	Export(span *Span)
+}
+
+// WRITER EXPORTER
+
+// WriterExporter writes each span as a JSON line, for local testing
+type WriterExporter struct {
+	lock sync.Mutex
+	out  io.Writer
+}
+
+// NewWriterExporter returns an exporter that writes spans in out, like os.Stdout or a file
+func NewWriterExporter(out io.Writer) *WriterExporter {
+	return &WriterExporter{
+		out: out,
+	}
+}
+
+type writerSpan struct {
+	TraceID    string            `json:"traceId"`
+	SpanID     string            `json:"spanId"`
+	ParentID   string            `json:"parentSpanId,omitempty"`
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+func (we *WriterExporter) Export(span *Span) {
+	b, err := json.Marshal(writerSpan{
+		TraceID:    span.Context.TraceID,
+		SpanID:     span.Context.SpanID,
+		ParentID:   span.ParentID,
+		Name:       span.Name,
+		Kind:       span.Kind,
+		Start:      span.Start,
+		End:        span.End,
+		Attributes: span.Attributes,
+		Error:      span.Error,
+	})
+	if err != nil {
+		return
+	}
+	we.lock.Lock()
+	defer we.lock.Unlock()
+	we.out.Write(append(b, '\n'))
+}
+
+// OTLP EXPORTER
+
+// OtlpExporter sends spans in batches to an OpenTelemetry collector, using OTLP/HTTP with JSON encoding.
+// Spans are dropped when the queue is full, so tracing never blocks requests. It encodes the OTLP messages
+// itself, the OpenTelemetry Go SDK isn't used since it needs a newer Go version than Foulkon supports
+type OtlpExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+	queue       chan *Span
+	// ErrorFunc is called when a batch can't be sent
+	ErrorFunc func(err error)
+}
+
+// NewOtlpExporter returns an exporter that sends spans to the collector endpoint every interval
+func NewOtlpExporter(endpoint string, serviceName string, interval time.Duration, timeout time.Duration) *OtlpExporter {
+	oe := &OtlpExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: timeout},
+		queue:       make(chan *Span, OTLP_QUEUE_SIZE),
+	}
+	go oe.run(interval)
+	return oe
+}
+
+func (oe *OtlpExporter) Export(span *Span) {
+	select {
+	case oe.queue <- span:
+	default:
+	}
+}
+
+// run sends queued spans when the batch is full or every interval
+func (oe *OtlpExporter) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	batch := []*Span{}
+	for {
+		select {
+		case span := <-oe.queue:
+			batch = append(batch, span)
+			if len(batch) < OTLP_BATCH_SIZE {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) < 1 {
+				continue
+			}
+		}
+		if err := oe.send(batch); err != nil && oe.ErrorFunc != nil {
+			oe.ErrorFunc(err)
+		}
+		batch = []*Span{}
+	}
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope map[string]string `json:"scope"`
+	Spans []otlpSpan        `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   map[string][]otlpAttribute `json:"resource"`
+	ScopeSpans []otlpScopeSpans           `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// send posts a batch of spans to the collector
+func (oe *OtlpExporter) send(batch []*Span) error {
+	spans := []otlpSpan{}
+	for _, span := range batch {
+		spans = append(spans, toOtlpSpan(span))
+	}
+	body, err := json.Marshal(otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: map[string][]otlpAttribute{
+					"attributes": {
+						{Key: "service.name", Value: otlpValue{StringValue: oe.serviceName}},
+					},
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: map[string]string{"name": "foulkon"},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	res, err := oe.client.Post(oe.endpoint+OTLP_TRACES_PATH, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status code %v exporting %v spans", res.StatusCode, len(batch))
+	}
+	return nil
+}
+
+func toOtlpSpan(span *Span) otlpSpan {
+	// OTLP span kinds
+	kind := 1
+	switch span.Kind {
+	case KIND_SERVER:
+		kind = 2
+	case KIND_CLIENT:
+		kind = 3
+	}
+	// OTLP status codes, ok or error
+	status := otlpStatus{Code: 1}
+	if span.Error != "" {
+		status = otlpStatus{Code: 2, Message: span.Error}
+	}
+	// Sort attributes to keep a stable encoding
+	keys := []string{}
+	for key := range span.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attributes := []otlpAttribute{}
+	for _, key := range keys {
+		attributes = append(attributes, otlpAttribute{Key: key, Value: otlpValue{StringValue: span.Attributes[key]}})
+	}
+
+	return otlpSpan{
+		TraceID:           span.Context.TraceID,
+		SpanID:            span.Context.SpanID,
+		ParentSpanID:      span.ParentID,
+		Name:              span.Name,
+		Kind:              kind,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+		Attributes:        attributes,
+		Status:            status,
+	}
+}
@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterExporter_Export(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	exporter := NewWriterExporter(buffer)
+	start := time.Date(2017, 1, 12, 9, 41, 53, 0, time.UTC)
+	exporter.Export(&Span{
+		Name: "test",
+		Kind: KIND_SERVER,
+		Context: SpanContext{
+			TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanID:  "00f067aa0ba902b7",
+			Sampled: true,
+		},
+		ParentID:   "b7ad6b7169203331",
+		Start:      start,
+		End:        start.Add(time.Second),
+		Attributes: map[string]string{"key": "value"},
+	})
+
+	expected := `{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","parentSpanId":"b7ad6b7169203331",` +
+		`"name":"test","kind":"server","start":"2017-01-12T09:41:53Z","end":"2017-01-12T09:41:54Z","attributes":{"key":"value"}}` + "\n"
+	assert.Equal(t, expected, buffer.String(), "Error in test")
+}
+
+func TestOtlpExporter_Export(t *testing.T) {
+	testcases := map[string]struct {
+		statusCode int
+
+		expectedError bool
+	}{
+		"OkCase": {
+			statusCode: http.StatusOK,
+		},
+		"ErrorCaseCollectorError": {
+			statusCode:    http.StatusServiceUnavailable,
+			expectedError: true,
+		},
+	}
+
+	for n, test := range testcases {
+		requests := make(chan otlpRequest, 1)
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, OTLP_TRACES_PATH, r.URL.Path, "Error in test case %v", n)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"), "Error in test case %v", n)
+			request := otlpRequest{}
+			err := json.NewDecoder(r.Body).Decode(&request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			w.WriteHeader(test.statusCode)
+			requests <- request
+		}))
+
+		errors := make(chan error, 1)
+		exporter := NewOtlpExporter(collector.URL, "foulkon-test", 10*time.Millisecond, time.Second)
+		exporter.ErrorFunc = func(err error) {
+			errors <- err
+		}
+		start := time.Unix(1, 0)
+		exporter.Export(&Span{
+			Name: "test",
+			Kind: KIND_CLIENT,
+			Context: SpanContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+				Sampled: true,
+			},
+			Start:      start,
+			End:        start.Add(time.Second),
+			Attributes: map[string]string{"key": "value"},
+			Error:      "test error",
+		})
+
+		select {
+		case request := <-requests:
+			assert.Equal(t, 1, len(request.ResourceSpans), "Error in test case %v", n)
+			resourceSpans := request.ResourceSpans[0]
+			assert.Equal(t, []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: "foulkon-test"}}},
+				resourceSpans.Resource["attributes"], "Error in test case %v", n)
+			assert.Equal(t, []otlpSpan{
+				{
+					TraceID:           "4bf92f3577b34da6a3ce929d0e0e4736",
+					SpanID:            "00f067aa0ba902b7",
+					Name:              "test",
+					Kind:              3,
+					StartTimeUnixNano: "1000000000",
+					EndTimeUnixNano:   "2000000000",
+					Attributes:        []otlpAttribute{{Key: "key", Value: otlpValue{StringValue: "value"}}},
+					Status:            otlpStatus{Code: 2, Message: "test error"},
+				},
+			}, resourceSpans.ScopeSpans[0].Spans, "Error in test case %v", n)
+		case <-time.After(5 * time.Second):
+			t.Errorf("Test case %v: spans weren't exported", n)
+		}
+
+		if test.expectedError {
+			select {
+			case err := <-errors:
+				assert.Contains(t, err.Error(), "Unexpected status code 503", "Error in test case %v", n)
+			case <-time.After(5 * time.Second):
+				t.Errorf("Test case %v: error wasn't reported", n)
+			}
+		}
+		collector.Close()
+	}
+}
@@ -0,0 +1,226 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// W3C trace context header
+	TRACEPARENT_HEADER = "traceparent"
+
+	// Span kinds
+	KIND_INTERNAL = "internal"
+	KIND_SERVER   = "server"
+	KIND_CLIENT   = "client"
+)
+
+// aux var for traceparent header version 00: version-traceid-spanid-flags
+var rTraceParent, _ = regexp.Compile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// exporter receives ended spans, tracing is disabled when it is nil
+var exporter Exporter
+var exporterLock sync.RWMutex
+
+// SetExporter sets the exporter of ended spans. A nil exporter disables tracing
+func SetExporter(e Exporter) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	exporter = e
+}
+
+func getExporter() Exporter {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+	return exporter
+}
+
+// SpanContext identifies a span inside a trace, as propagated in traceparent header
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// IsValid returns true if span context belongs to a trace
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+// TraceParent returns the traceparent header value of the span context
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%v-%v-%v", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceParent returns the span context of a traceparent header value, or an invalid one if it isn't well formed
+func ParseTraceParent(traceParent string) SpanContext {
+	match := rTraceParent.FindStringSubmatch(traceParent)
+	if match == nil || match[1] == "00000000000000000000000000000000" || match[2] == "0000000000000000" {
+		return SpanContext{}
+	}
+	flags, _ := hex.DecodeString(match[3])
+	return SpanContext{
+		TraceID: match[1],
+		SpanID:  match[2],
+		Sampled: flags[0]&1 == 1,
+	}
+}
+
+// Extract returns the span context propagated in the request headers
+func Extract(header http.Header) SpanContext {
+	return ParseTraceParent(header.Get(TRACEPARENT_HEADER))
+}
+
+// Span is a timed operation of a trace. All methods are safe to use with a nil span, that is what
+// StartSpan returns when tracing is disabled
+type Span struct {
+	lock sync.Mutex
+
+	Name       string
+	Kind       string
+	Context    SpanContext
+	ParentID   string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	Error      string
+}
+
+// StartSpan starts a span, child of parent if it is valid or root of a new trace otherwise
+func StartSpan(name string, kind string, parent SpanContext) *Span {
+	if getExporter() == nil {
+		return nil
+	}
+	span := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now().UTC(),
+		Attributes: make(map[string]string),
+	}
+	if parent.IsValid() {
+		span.Context = SpanContext{
+			TraceID: parent.TraceID,
+			SpanID:  newID(8),
+			Sampled: parent.Sampled,
+		}
+		span.ParentID = parent.SpanID
+	} else {
+		span.Context = SpanContext{
+			TraceID: newID(16),
+			SpanID:  newID(8),
+			Sampled: true,
+		}
+	}
+	return span
+}
+
+// StartChild starts a span child of this one
+func (s *Span) StartChild(name string, kind string) *Span {
+	if s == nil {
+		return nil
+	}
+	return StartSpan(name, kind, s.Context)
+}
+
+// GetContext returns the span context, or an invalid one for a nil span
+func (s *Span) GetContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.Context
+}
+
+// SetName replaces the span name, once a more specific name is known
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Name = name
+}
+
+// SetAttribute adds an attribute to the span
+func (s *Span) SetAttribute(key string, value string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Attributes[key] = value
+}
+
+// SetError marks the span as failed if err isn't nil
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Error = err.Error()
+}
+
+// Inject sets the traceparent header of the span in the request headers
+func (s *Span) Inject(header http.Header) {
+	if s == nil {
+		return
+	}
+	header.Set(TRACEPARENT_HEADER, s.Context.TraceParent())
+}
+
+// Finish ends the span and exports it if it is sampled
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.End = time.Now().UTC()
+	// Exporters read a copy, since span can be changed after finishing it
+	ended := &Span{
+		Name:       s.Name,
+		Kind:       s.Kind,
+		Context:    s.Context,
+		ParentID:   s.ParentID,
+		Start:      s.Start,
+		End:        s.End,
+		Attributes: make(map[string]string, len(s.Attributes)),
+		Error:      s.Error,
+	}
+	for key, value := range s.Attributes {
+		ended.Attributes[key] = value
+	}
+	s.lock.Unlock()
+	if e := getExporter(); e != nil && ended.Context.Sampled {
+		e.Export(ended)
+	}
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx with the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span of ctx, or nil if there isn't any
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// newID returns a random hex encoded identifier of n bytes
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
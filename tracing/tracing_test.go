@@ -0,0 +1,177 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testExporter keeps exported spans
+type testExporter struct {
+	lock  sync.Mutex
+	spans []*Span
+}
+
+func (te *testExporter) Export(span *Span) {
+	te.lock.Lock()
+	defer te.lock.Unlock()
+	te.spans = append(te.spans, span)
+}
+
+func TestParseTraceParent(t *testing.T) {
+	testcases := map[string]struct {
+		traceParent string
+
+		expectedContext SpanContext
+	}{
+		"OkCaseSampled": {
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedContext: SpanContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+				Sampled: true,
+			},
+		},
+		"OkCaseNotSampled": {
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			expectedContext: SpanContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+			},
+		},
+		"ErrorCaseEmpty": {
+			traceParent: "",
+		},
+		"ErrorCaseInvalidVersion": {
+			traceParent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		"ErrorCaseInvalidTraceID": {
+			traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		"ErrorCaseInvalidSpanID": {
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		},
+		"ErrorCaseUppercase": {
+			traceParent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01",
+		},
+	}
+
+	for n, test := range testcases {
+		sc := ParseTraceParent(test.traceParent)
+		assert.Equal(t, test.expectedContext, sc, "Error in test case %v", n)
+		if sc.IsValid() {
+			assert.Equal(t, test.traceParent, sc.TraceParent(), "Error in test case %v", n)
+		}
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	parent := SpanContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Sampled: true,
+	}
+	testcases := map[string]struct {
+		disabled bool
+		parent   SpanContext
+
+		expectedExported bool
+	}{
+		"OkCaseRoot": {
+			expectedExported: true,
+		},
+		"OkCaseChild": {
+			parent:           parent,
+			expectedExported: true,
+		},
+		"OkCaseChildNotSampled": {
+			parent: SpanContext{
+				TraceID: parent.TraceID,
+				SpanID:  parent.SpanID,
+			},
+		},
+		"OkCaseDisabled": {
+			disabled: true,
+			parent:   parent,
+		},
+	}
+
+	for n, test := range testcases {
+		exporter := &testExporter{}
+		SetExporter(exporter)
+		if test.disabled {
+			SetExporter(nil)
+		}
+
+		span := StartSpan("test", KIND_SERVER, test.parent)
+		child := span.StartChild("child", KIND_CLIENT)
+		header := http.Header{}
+		child.Inject(header)
+		child.SetAttribute("key", "value")
+		child.SetError(errors.New("child error"))
+		child.Finish()
+		span.SetName("renamed")
+		span.Finish()
+
+		if test.disabled {
+			assert.Nil(t, span, "Error in test case %v", n)
+			assert.Nil(t, child, "Error in test case %v", n)
+			assert.Equal(t, "", header.Get(TRACEPARENT_HEADER), "Error in test case %v", n)
+			assert.False(t, span.GetContext().IsValid(), "Error in test case %v", n)
+			continue
+		}
+
+		// Check trace continuity
+		if test.parent.IsValid() {
+			assert.Equal(t, test.parent.TraceID, span.Context.TraceID, "Error in test case %v", n)
+			assert.Equal(t, test.parent.SpanID, span.ParentID, "Error in test case %v", n)
+		} else {
+			assert.True(t, span.Context.IsValid(), "Error in test case %v", n)
+			assert.Equal(t, "", span.ParentID, "Error in test case %v", n)
+		}
+		assert.Equal(t, span.Context.TraceID, child.Context.TraceID, "Error in test case %v", n)
+		assert.Equal(t, span.Context.SpanID, child.ParentID, "Error in test case %v", n)
+		assert.Equal(t, child.Context, Extract(header), "Error in test case %v", n)
+		assert.Equal(t, "renamed", span.Name, "Error in test case %v", n)
+		assert.Equal(t, map[string]string{"key": "value"}, child.Attributes, "Error in test case %v", n)
+		assert.Equal(t, "child error", child.Error, "Error in test case %v", n)
+
+		// Check exported spans, children end first
+		if test.expectedExported {
+			assert.Equal(t, []*Span{child, span}, exporter.spans, "Error in test case %v", n)
+		} else {
+			assert.Empty(t, exporter.spans, "Error in test case %v", n)
+		}
+	}
+	SetExporter(nil)
+}
+
+func TestSpan_FinishExportsCopy(t *testing.T) {
+	exporter := &testExporter{}
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	span := StartSpan("test", KIND_INTERNAL, SpanContext{})
+	span.SetAttribute("key", "value")
+	span.Finish()
+
+	// Changes after finishing don't modify the exported span
+	span.SetAttribute("other", "value")
+	if assert.Len(t, exporter.spans, 1, "Error in test") {
+		assert.Equal(t, map[string]string{"key": "value"}, exporter.spans[0].Attributes, "Error in test")
+		assert.Equal(t, span.End, exporter.spans[0].End, "Error in test")
+	}
+}
+
+func TestContextWithSpan(t *testing.T) {
+	SetExporter(&testExporter{})
+	defer SetExporter(nil)
+
+	span := StartSpan("test", KIND_INTERNAL, SpanContext{})
+	assert.Nil(t, SpanFromContext(context.Background()), "Error in test")
+	assert.Equal(t, span, SpanFromContext(ContextWithSpan(context.Background(), span)), "Error in test")
+}